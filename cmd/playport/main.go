@@ -1,16 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 
-	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/app"
 	"github.com/JanikSachs/PlayPort/internal/config"
-	"github.com/JanikSachs/PlayPort/internal/providers"
-	"github.com/JanikSachs/PlayPort/internal/providers/spotify"
-	"github.com/JanikSachs/PlayPort/internal/providers/youtubemusic"
 	"github.com/JanikSachs/PlayPort/internal/server"
 	"github.com/JanikSachs/PlayPort/internal/services"
-	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
 func main() {
@@ -20,69 +17,43 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Validate Spotify configuration
-	spotifyEnabled, err := cfg.ValidateSpotify()
+	// Create storage
+	stores, err := app.OpenStores(cfg)
 	if err != nil {
-		log.Fatalf("Spotify configuration error: %v", err)
+		log.Fatalf("Storage configuration error: %v", err)
 	}
+	defer stores.Close()
 
-	if spotifyEnabled {
-		log.Println("Spotify integration enabled")
+	if cfg.StorageDriver == "memory" {
+		log.Println("Using in-memory storage (data is lost on restart)")
 	} else {
-		log.Println("Spotify integration disabled (environment variables not set)")
+		log.Printf("Using %s storage", cfg.StorageDriver)
 	}
 
-	// Validate YouTube Music configuration
-	youtubeMusicEnabled, err := cfg.ValidateYouTubeMusic()
+	// Create transfer service with the mock provider and every configured provider
+	transferService, registry, err := app.NewTransferService(cfg, stores)
 	if err != nil {
-		log.Fatalf("YouTube Music configuration error: %v", err)
+		log.Fatalf("Provider configuration error: %v", err)
 	}
 
-	if youtubeMusicEnabled {
-		log.Println("YouTube Music integration enabled")
-	} else {
-		log.Println("YouTube Music integration disabled (environment variables not set)")
+	for _, reg := range registry {
+		if reg.Enabled {
+			log.Printf("%s integration enabled", reg.Name)
+		} else {
+			log.Printf("%s integration disabled (%s not set)", reg.Name, reg.Requires)
+		}
 	}
 
-	// Create storage
-	connectionStore := storage.NewInMemoryConnectionStore()
-	userStore := storage.NewInMemoryUserStore()
-	stateStore := auth.NewInMemoryStateStore()
-	sessionStore := auth.NewInMemorySessionStore(0)
-
-	// Create transfer service
-	transferService := services.NewTransferService()
+	// Create connection service
+	connectionService := services.NewConnectionService(stores.Connections, stores.Audit, transferService)
 
-	// Register mock provider
-	mockProvider := providers.NewMockProvider()
-	transferService.RegisterProvider(mockProvider)
-
-	// Create Spotify provider if enabled
-	var spotifyProvider *spotify.SpotifyProvider
-	if spotifyEnabled {
-		spotifyProvider = spotify.NewSpotifyProvider(
-			cfg.SpotifyClientID,
-			cfg.SpotifyClientSecret,
-			cfg.SpotifyRedirectURL,
-			connectionStore,
-		)
-		transferService.RegisterProvider(spotifyProvider)
-	}
-
-	// Create YouTube Music provider if enabled
-	var youtubeMusicProvider *youtubemusic.YouTubeMusicProvider
-	if youtubeMusicEnabled {
-		youtubeMusicProvider = youtubemusic.NewYouTubeMusicProvider(
-			cfg.YouTubeMusicClientID,
-			cfg.YouTubeMusicClientSecret,
-			cfg.YouTubeMusicRedirectURL,
-			connectionStore,
-		)
-		transferService.RegisterProvider(youtubeMusicProvider)
-	}
+	// Start running scheduled transfers and syncs
+	scheduleService := app.NewScheduleService(cfg, stores, transferService)
+	connectionService.SetScheduleService(scheduleService)
+	scheduleService.Start(context.Background())
 
 	// Create and start server
-	srv, err := server.New(cfg.ServerAddr, transferService, spotifyProvider, youtubeMusicProvider, connectionStore, userStore, stateStore, sessionStore, spotifyEnabled, youtubeMusicEnabled)
+	srv, err := server.New(cfg.ServerAddr, transferService, connectionService, scheduleService, registry, stores)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
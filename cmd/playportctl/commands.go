@@ -0,0 +1,630 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/playlistfile"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// errUsage reports invalid subcommand arguments after the usage was printed
+var errUsage = errors.New("invalid usage")
+
+// newFlagSet creates the flag set of a subcommand
+func (c *cli) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("playportctl "+name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: playportctl %s %s\n\n", name, commands[name].usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parse parses subcommand flags and checks that the required ones are set
+func parse(flags *flag.FlagSet, args []string, required ...string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	for _, name := range required {
+		if flags.Lookup(name).Value.String() == "" {
+			fmt.Fprintf(flags.Output(), "flag -%s is required\n", name)
+			flags.Usage()
+			return errUsage
+		}
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected argument %q\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// resolveProvider turns "slug" or "slug:account" into a provider and account ID
+func (c *cli) resolveProvider(spec string) (providers.Provider, string, error) {
+	slug, account, _ := strings.Cut(spec, ":")
+	provider, err := c.transferService.GetProviderBySlug(slug)
+	if err != nil {
+		return nil, "", err
+	}
+	return provider, account, nil
+}
+
+// printJSON writes v as indented JSON
+func (c *cli) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// connectionView is the JSON form of a linked account; tokens are left out
+type connectionView struct {
+	Provider    string `json:"provider"`
+	Account     string `json:"account"`
+	DisplayName string `json:"display_name"`
+	Connected   bool   `json:"connected"`
+}
+
+// connections lists the user's linked provider accounts
+func (c *cli) connections(args []string) error {
+	flags := c.newFlagSet("connections")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	connections, err := c.connectionStore.List(c.userID)
+	if err != nil {
+		return err
+	}
+
+	views := make([]connectionView, 0, len(connections))
+	for _, conn := range connections {
+		views = append(views, connectionView{
+			Provider:    conn.Provider,
+			Account:     conn.ExternalUserID,
+			DisplayName: conn.ExternalUserName,
+			Connected:   conn.Connected,
+		})
+	}
+	if *asJSON {
+		return c.printJSON(views)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tACCOUNT\tNAME\tCONNECTED")
+	for _, v := range views {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\n", v.Provider, v.Account, v.DisplayName, v.Connected)
+	}
+	return tw.Flush()
+}
+
+// playlistView is the JSON form of a playlist without its tracks
+type playlistView struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	TrackCount int    `json:"track_count"`
+}
+
+// listPlaylists lists the playlists of a provider account
+func (c *cli) listPlaylists(args []string) error {
+	flags := c.newFlagSet("list-playlists")
+	providerSpec := flags.String("provider", "", "provider slug, optionally followed by :ACCOUNT")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := parse(flags, args, "provider"); err != nil {
+		return err
+	}
+
+	provider, account, err := c.resolveProvider(*providerSpec)
+	if err != nil {
+		return err
+	}
+	acct := providers.Account{UserID: c.userID, ExternalUserID: account}
+	if err := provider.Authenticate(acct); err != nil {
+		return err
+	}
+	playlists, err := provider.GetPlaylists(acct)
+	if err != nil {
+		return err
+	}
+
+	views := make([]playlistView, 0, len(playlists))
+	for _, p := range playlists {
+		views = append(views, playlistView{ID: p.ID, Name: p.Name, TrackCount: p.TrackCount})
+	}
+	if *asJSON {
+		return c.printJSON(views)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tTRACKS")
+	for _, v := range views {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", v.ID, v.Name, v.TrackCount)
+	}
+	return tw.Flush()
+}
+
+// export writes a playlist with its tracks as JSON or CSV
+func (c *cli) export(args []string) error {
+	flags := c.newFlagSet("export")
+	providerSpec := flags.String("provider", "", "provider slug, optionally followed by :ACCOUNT")
+	playlistID := flags.String("playlist", "", "ID of the playlist to export")
+	format := flags.String("format", playlistfile.FormatJSON, "output format: json, csv, m3u, xspf, jspf, rekordbox or nml")
+	output := flags.String("o", "", "output file (default stdout)")
+	if err := parse(flags, args, "provider", "playlist"); err != nil {
+		return err
+	}
+
+	provider, account, err := c.resolveProvider(*providerSpec)
+	if err != nil {
+		return err
+	}
+	acct := providers.Account{UserID: c.userID, ExternalUserID: account}
+	if err := provider.Authenticate(acct); err != nil {
+		return err
+	}
+	playlist, err := provider.ExportPlaylist(acct, *playlistID)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return playlistfile.Write(c.stdout, *format, playlist)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := playlistfile.Write(f, *format, playlist); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Exported %q (%d tracks) to %s\n", playlist.Name, len(playlist.Tracks), *output)
+	return nil
+}
+
+// importFile imports a playlist file into a provider account
+func (c *cli) importFile(args []string) error {
+	flags := c.newFlagSet("import")
+	targetSpec := flags.String("to", "", "target provider slug, optionally followed by :ACCOUNT")
+	file := flags.String("file", "", "playlist file to import")
+	format := flags.String("format", "", "file format: json, csv, m3u, m3u8, xspf, jspf, rekordbox or nml (default from the file extension)")
+	name := flags.String("name", "", "playlist name (default from the file)")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := parse(flags, args, "to", "file"); err != nil {
+		return err
+	}
+
+	target, account, err := c.resolveProvider(*targetSpec)
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+	base := strings.TrimSuffix(filepath.Base(*file), filepath.Ext(*file))
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	playlist, err := playlistfile.Read(f, *format, base)
+	if err != nil {
+		return err
+	}
+	if *name != "" {
+		playlist.Name = *name
+	}
+
+	report, err := c.transferService.ImportPlaylistForUser(c.ctx, services.TransferRequest{
+		UserID:         c.userID,
+		TargetProvider: target.Name(),
+		TargetAccount:  account,
+	}, playlist)
+	return c.printReport(report, err, *asJSON)
+}
+
+// transfer transfers a playlist between provider accounts
+func (c *cli) transfer(args []string) error {
+	flags := c.newFlagSet("transfer")
+	sourceSpec := flags.String("from", "", "source provider slug, optionally followed by :ACCOUNT")
+	targetSpec := flags.String("to", "", "target provider slug, optionally followed by :ACCOUNT")
+	playlistID := flags.String("playlist", "", "ID of the playlist to transfer; several comma-separated IDs transfer a batch")
+	mode := flags.String("mode", services.ModePlaylist, "what to transfer: playlist, albums, artists, library or batch")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := parse(flags, args, "from", "to"); err != nil {
+		return err
+	}
+	switch {
+	case !services.ValidMode(*mode):
+		fmt.Fprintf(flags.Output(), "unknown mode %q\n", *mode)
+		flags.Usage()
+		return errUsage
+	case (*mode == services.ModePlaylist || *mode == services.ModeBatch) && *playlistID == "":
+		fmt.Fprintln(flags.Output(), "flag -playlist is required")
+		flags.Usage()
+		return errUsage
+	}
+
+	var playlistIDs []string
+	if ids := strings.Split(*playlistID, ","); len(ids) > 1 || *mode == services.ModeBatch {
+		*mode = services.ModeBatch
+		playlistIDs = ids
+	}
+
+	source, sourceAccount, err := c.resolveProvider(*sourceSpec)
+	if err != nil {
+		return err
+	}
+	target, targetAccount, err := c.resolveProvider(*targetSpec)
+	if err != nil {
+		return err
+	}
+
+	report, err := c.transferService.RunTransfer(c.ctx, services.TransferRequest{
+		UserID:         c.userID,
+		SourceProvider: source.Name(),
+		SourceAccount:  sourceAccount,
+		TargetProvider: target.Name(),
+		TargetAccount:  targetAccount,
+		Mode:           *mode,
+		PlaylistID:     *playlistID,
+		PlaylistIDs:    playlistIDs,
+	})
+	return c.printReport(report, err, *asJSON)
+}
+
+// printReport prints a transfer report, then returns the transfer's error.
+// Failed transfers still print the tracks matched before the failure.
+func (c *cli) printReport(report services.TransferReport, transferErr error, asJSON bool) error {
+	if asJSON {
+		if err := c.printJSON(report); err != nil {
+			return err
+		}
+		return transferErr
+	}
+
+	// One row per track, album or artist: status, source and target ID
+	var rows [][3]string
+	for _, r := range report.Tracks {
+		row := [3]string{r.Status, r.Source.Artist + " — " + r.Source.Title, "-"}
+		if r.Target != nil {
+			row[2] = r.Target.ID
+		}
+		rows = append(rows, row)
+	}
+	for _, r := range report.Albums {
+		row := [3]string{r.Status, r.Source.Artist + " — " + r.Source.Title, "-"}
+		if r.Target != nil {
+			row[2] = r.Target.ID
+		}
+		rows = append(rows, row)
+	}
+	for _, r := range report.Artists {
+		row := [3]string{r.Status, r.Source.Name, "-"}
+		if r.Target != nil {
+			row[2] = r.Target.ID
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) > 0 {
+		tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "STATUS\tSOURCE\tTARGET")
+		for _, row := range rows {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", row[0], row[1], row[2])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	// Library migrations add one line per playlist
+	for _, p := range report.Playlists {
+		if p.Error != "" {
+			fmt.Fprintf(c.stdout, "%s %q: %s\n", p.Status, p.Name, p.Error)
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s %q: %d of %d tracks\n", p.Status, p.Name, p.Transferred, p.TrackCount)
+	}
+
+	fmt.Fprintf(c.stdout, "%s %q: %d matched, %d override, %d skipped, %d not found, %d deferred\n",
+		report.Status, report.PlaylistName,
+		report.Summary[services.MatchFound], report.Summary[services.MatchOverride],
+		report.Summary[services.MatchSkipped], report.Summary[services.MatchNotFound],
+		report.Summary[services.MatchDeferred])
+	return transferErr
+}
+
+// sync creates a sync link or runs an existing one
+func (c *cli) sync(args []string) error {
+	flags := c.newFlagSet("sync")
+	sourceSpec := flags.String("from", "", "source provider slug, optionally followed by :ACCOUNT")
+	targetSpec := flags.String("to", "", "target provider slug, optionally followed by :ACCOUNT")
+	playlistID := flags.String("playlist", "", "ID of the source playlist to copy and link")
+	twoWay := flags.Bool("two-way", false, "also apply the target playlist's edits to the source")
+	policy := flags.String("policy", "", "how two-way conflicts are resolved: manual, source or target (default manual)")
+	linkID := flags.String("link", "", "ID of the sync link to run")
+	resolve := flags.String("resolve", "", "ID of a conflict of the -link to resolve")
+	keep := flags.String("keep", "", "side whose edit wins the -resolve conflict: source or target")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	creating := *sourceSpec != "" || *targetSpec != "" || *playlistID != ""
+	complete := *sourceSpec != "" && *targetSpec != "" && *playlistID != ""
+	if (*linkID != "") == creating || (creating && !complete) {
+		fmt.Fprintln(flags.Output(), "either -link or all of -from, -to and -playlist are required")
+		flags.Usage()
+		return errUsage
+	}
+	if (*twoWay || *policy != "") && !creating {
+		fmt.Fprintln(flags.Output(), "-two-way and -policy only apply when creating a link")
+		flags.Usage()
+		return errUsage
+	}
+	if (*resolve != "") != (*keep != "") || (*resolve != "" && *linkID == "") {
+		fmt.Fprintln(flags.Output(), "-resolve and -keep must be given together, with -link")
+		flags.Usage()
+		return errUsage
+	}
+
+	var result services.SyncResult
+	var err error
+	if *resolve != "" {
+		result, err = c.transferService.ResolveSyncConflict(c.ctx, c.userID, *linkID, *resolve, *keep)
+	} else if *linkID != "" {
+		result, err = c.transferService.RunSync(c.ctx, c.userID, *linkID)
+	} else {
+		source, sourceAccount, resolveErr := c.resolveProvider(*sourceSpec)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		target, targetAccount, resolveErr := c.resolveProvider(*targetSpec)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		result, err = c.transferService.CreateSyncLink(c.ctx, services.TransferRequest{
+			UserID:         c.userID,
+			SourceProvider: source.Name(),
+			SourceAccount:  sourceAccount,
+			TargetProvider: target.Name(),
+			TargetAccount:  targetAccount,
+			PlaylistID:     *playlistID,
+		}, services.SyncOptions{TwoWay: *twoWay, ConflictPolicy: *policy})
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return c.printJSON(result)
+	}
+	reordered := ""
+	if result.Reordered {
+		reordered = ", reordered"
+	}
+	fmt.Fprintf(c.stdout, "synced %q to %s playlist %s (link %s): %d added, %d removed%s, %d unmatched\n",
+		result.Link.Name, result.Link.TargetProvider, result.Link.TargetPlaylistID, result.Link.ID,
+		result.Added, result.Removed, reordered, result.Unmatched)
+	if !result.Link.TwoWay {
+		return nil
+	}
+
+	reordered = ""
+	if result.SourceReordered {
+		reordered = ", reordered"
+	}
+	fmt.Fprintf(c.stdout, "source %s playlist %s: %d added, %d removed%s; %d conflicts resolved\n",
+		result.Link.SourceProvider, result.Link.SourcePlaylistID,
+		result.SourceAdded, result.SourceRemoved, reordered, result.Resolved)
+	for _, conflict := range result.Link.Conflicts {
+		fmt.Fprintf(c.stdout, "conflict %s: %s\n", conflict.ID, describeConflict(conflict))
+	}
+	return nil
+}
+
+// describeConflict explains a two-way sync conflict in a line
+func describeConflict(conflict models.SyncConflict) string {
+	if conflict.Kind == models.ConflictOrder {
+		return "both playlists were reordered differently"
+	}
+	other := models.SyncSideTarget
+	if conflict.Side == models.SyncSideTarget {
+		other = models.SyncSideSource
+	}
+	return fmt.Sprintf("%q by %s was added to the %s after the %s removed it", conflict.Title, conflict.Artist, conflict.Side, other)
+}
+
+// listSyncs lists the user's sync links
+func (c *cli) listSyncs(args []string) error {
+	flags := c.newFlagSet("list-syncs")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	links, err := c.transferService.ListSyncLinks(c.userID)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if links == nil {
+			links = []*models.SyncLink{}
+		}
+		return c.printJSON(links)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSOURCE\tTARGET\tDIRECTION\tCONFLICTS\tLAST SYNCED")
+	for _, l := range links {
+		direction := "one-way"
+		if l.TwoWay {
+			direction = "two-way (" + l.ConflictPolicy + ")"
+		}
+		if l.Disconnected != "" {
+			direction += ", account disconnected"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s %s\t%s %s\t%s\t%d\t%s\n", l.ID, l.Name,
+			l.SourceProvider, l.SourcePlaylistID, l.TargetProvider, l.TargetPlaylistID,
+			direction, len(l.Conflicts), l.LastSyncedAt.Local().Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+// schedule creates a schedule, or runs or deletes an existing one. Scheduled
+// runs happen in the server; -run runs a schedule once, here and now.
+func (c *cli) schedule(args []string) error {
+	flags := c.newFlagSet("schedule")
+	sourceSpec := flags.String("from", "", "source provider slug, optionally followed by :ACCOUNT")
+	targetSpec := flags.String("to", "", "target provider slug, optionally followed by :ACCOUNT")
+	playlistID := flags.String("playlist", "", "ID of the source playlist")
+	spec := flags.String("spec", "", `when to run: a cron expression such as "0 3 * * *", "@daily" or "@every 6h" (UTC)`)
+	kind := flags.String("kind", models.ScheduleTransfer, "transfer copies the playlist on every run; sync keeps one copy in step")
+	runID := flags.String("run", "", "ID of the schedule to run now")
+	deleteID := flags.String("delete", "", "ID of the schedule to delete")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	creating := *sourceSpec != "" || *targetSpec != "" || *playlistID != "" || *spec != ""
+	complete := *sourceSpec != "" && *targetSpec != "" && *playlistID != "" && *spec != ""
+	actions := 0
+	for _, set := range []bool{creating, *runID != "", *deleteID != ""} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 || (creating && !complete) {
+		fmt.Fprintln(flags.Output(), "either -run, -delete or all of -from, -to, -playlist and -spec are required")
+		flags.Usage()
+		return errUsage
+	}
+
+	switch {
+	case *deleteID != "":
+		if err := c.scheduleService.DeleteSchedule(c.userID, *deleteID); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "deleted schedule %s\n", *deleteID)
+		return nil
+
+	case *runID != "":
+		run, err := c.scheduleService.RunSchedule(c.ctx, c.userID, *runID)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return c.printJSON(run)
+		}
+		fmt.Fprintf(c.stdout, "%s: %s\n", run.Status, run.Message)
+		if run.Status == services.StatusFailed {
+			return errors.New("the run failed")
+		}
+		return nil
+	}
+
+	source, sourceAccount, err := c.resolveProvider(*sourceSpec)
+	if err != nil {
+		return err
+	}
+	target, targetAccount, err := c.resolveProvider(*targetSpec)
+	if err != nil {
+		return err
+	}
+	schedule, err := c.scheduleService.CreateSchedule(services.TransferRequest{
+		UserID:         c.userID,
+		SourceProvider: source.Name(),
+		SourceAccount:  sourceAccount,
+		TargetProvider: target.Name(),
+		TargetAccount:  targetAccount,
+		PlaylistID:     *playlistID,
+	}, *kind, *spec)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return c.printJSON(schedule)
+	}
+	fmt.Fprintf(c.stdout, "scheduled %s of %s playlist %s to %s (schedule %s): first run at %s\n",
+		schedule.Kind, schedule.SourceProvider, schedule.SourcePlaylistID, schedule.TargetProvider,
+		schedule.ID, schedule.NextRunAt.Local().Format(time.DateTime))
+	return nil
+}
+
+// listSchedules lists the user's schedules, or the recent runs of one of them
+func (c *cli) listSchedules(args []string) error {
+	flags := c.newFlagSet("list-schedules")
+	runsOf := flags.String("runs", "", "ID of a schedule whose recent runs to list instead")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	if *runsOf != "" {
+		runs, err := c.scheduleService.ListRuns(c.userID, *runsOf, 0)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			if runs == nil {
+				runs = []*models.ScheduleRun{}
+			}
+			return c.printJSON(runs)
+		}
+
+		tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "STARTED\tDURATION\tSTATUS\tMESSAGE")
+		for _, r := range runs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.StartedAt.Local().Format(time.DateTime),
+				r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond), r.Status, r.Message)
+		}
+		return tw.Flush()
+	}
+
+	schedules, err := c.scheduleService.ListSchedules(c.userID)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if schedules == nil {
+			schedules = []*models.Schedule{}
+		}
+		return c.printJSON(schedules)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKIND\tSOURCE\tTARGET\tSPEC\tNEXT RUN\tLAST STATUS")
+	for _, s := range schedules {
+		next := "paused"
+		if s.Enabled {
+			next = s.NextRunAt.Local().Format(time.DateTime)
+		}
+		last := s.LastStatus
+		if last == "" {
+			last = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s %s\t%s\t%s\t%s\t%s\n", s.ID, s.Kind,
+			s.SourceProvider, s.SourcePlaylistID, s.TargetProvider, s.Spec, next, last)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,201 @@
+// Command playportctl runs PlayPort's playlist operations from the command
+// line, against the same storage and provider configuration as the server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/JanikSachs/PlayPort/internal/app"
+	"github.com/JanikSachs/PlayPort/internal/config"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a playportctl subcommand
+type command struct {
+	usage   string // arguments, shown after the command name
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+// commands holds the subcommands by name. It is filled in init because the
+// subcommands print their own usage from it.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"connections": {
+			usage:   "[-json]",
+			summary: "List linked provider accounts",
+			run:     (*cli).connections,
+		},
+		"list-playlists": {
+			usage:   "-provider SLUG[:ACCOUNT] [-json]",
+			summary: "List the playlists of a provider account",
+			run:     (*cli).listPlaylists,
+		},
+		"export": {
+			usage:   "-provider SLUG[:ACCOUNT] -playlist ID [-format json|csv|m3u|xspf|jspf|rekordbox|nml] [-o FILE]",
+			summary: "Write a playlist with its tracks to a file or stdout",
+			run:     (*cli).export,
+		},
+		"import": {
+			usage:   "-to SLUG[:ACCOUNT] -file FILE [-format json|csv|m3u|xspf|jspf|rekordbox|nml] [-name NAME] [-json]",
+			summary: "Import a playlist file into a provider account",
+			run:     (*cli).importFile,
+		},
+		"transfer": {
+			usage:   "-from SLUG[:ACCOUNT] -to SLUG[:ACCOUNT] {-playlist ID[,ID...] | -mode albums|artists|library} [-json]",
+			summary: "Transfer playlists, saved albums, followed artists or every playlist and print the report",
+			run:     (*cli).transfer,
+		},
+		"sync": {
+			usage:   "{-from SLUG[:ACCOUNT] -to SLUG[:ACCOUNT] -playlist ID [-two-way [-policy POLICY]] | -link ID [-resolve CONFLICT -keep SIDE]} [-json]",
+			summary: "Copy a playlist and link the copy to its source, or sync a linked copy",
+			run:     (*cli).sync,
+		},
+		"list-syncs": {
+			usage:   "[-json]",
+			summary: "List playlist sync links",
+			run:     (*cli).listSyncs,
+		},
+		"schedule": {
+			usage:   "{-from SLUG[:ACCOUNT] -to SLUG[:ACCOUNT] -playlist ID -spec SPEC [-kind transfer|sync] | -run ID | -delete ID} [-json]",
+			summary: "Schedule recurring transfers or syncs of a playlist, or run or delete a schedule",
+			run:     (*cli).schedule,
+		},
+		"list-schedules": {
+			usage:   "[-runs ID] [-json]",
+			summary: "List schedules, or the recent runs of one schedule",
+			run:     (*cli).listSchedules,
+		},
+	}
+}
+
+// cli carries what every subcommand needs
+type cli struct {
+	ctx             context.Context
+	stdout          io.Writer
+	stderr          io.Writer
+	userID          string
+	transferService *services.TransferService
+	scheduleService *services.ScheduleService
+	connectionStore storage.ConnectionStore
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run parses the global flags, sets up storage and providers and runs the subcommand
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("playportctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	username := flags.String("user", os.Getenv("PLAYPORT_USER"), "PlayPort username whose accounts to use (default $PLAYPORT_USER)")
+	flags.Usage = func() { usage(stderr, flags) }
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		usage(stderr, flags)
+		return exitUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "playportctl: unknown command %q\n\n", flags.Arg(0))
+		usage(stderr, flags)
+		return exitUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "playportctl: %v\n", err)
+		return exitError
+	}
+	stores, err := app.OpenStores(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "playportctl: %v\n", err)
+		return exitError
+	}
+	defer stores.Close()
+
+	transferService, _, err := app.NewTransferService(cfg, stores)
+	if err != nil {
+		fmt.Fprintf(stderr, "playportctl: %v\n", err)
+		return exitError
+	}
+
+	c := &cli{
+		ctx:             ctx,
+		stdout:          stdout,
+		stderr:          stderr,
+		transferService: transferService,
+		scheduleService: app.NewScheduleService(cfg, stores, transferService),
+		connectionStore: stores.Connections,
+	}
+
+	if cfg.StorageDriver == "memory" {
+		// Nothing persists between runs, so only the mock provider is usable
+		fmt.Fprintln(stderr, "playportctl: warning: STORAGE_DRIVER is memory; linked accounts are not available")
+		c.userID = *username
+	} else {
+		if *username == "" {
+			fmt.Fprintln(stderr, "playportctl: -user or PLAYPORT_USER is required")
+			return exitUsage
+		}
+		user, err := stores.Users.GetByUsername(*username)
+		if err != nil {
+			fmt.Fprintf(stderr, "playportctl: unknown user %q\n", *username)
+			return exitError
+		}
+		c.userID = user.ID
+	}
+
+	if err := cmd.run(c, flags.Args()[1:]); err != nil {
+		if err == flag.ErrHelp || err == errUsage {
+			return exitUsage
+		}
+		fmt.Fprintf(stderr, "playportctl %s: %v\n", flags.Arg(0), err)
+		return exitError
+	}
+	return exitOK
+}
+
+// usage prints the global flags and the list of subcommands
+func usage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: playportctl [-user NAME] <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-15s %s\n", name, commands[name].summary)
+		fmt.Fprintf(w, "  %-15s   %s %s\n", "", name, commands[name].usage)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	flags.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Storage and provider credentials are read from the same environment variables as the server.")
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// runCLI runs playportctl with in-memory storage and returns its exit code and output
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	t.Setenv("STORAGE_DRIVER", "memory")
+	for _, key := range []string{"SPOTIFY_CLIENT_ID", "SPOTIFY_CLIENT_SECRET", "SPOTIFY_REDIRECT_URL",
+		"YOUTUBE_MUSIC_CLIENT_ID", "YOUTUBE_MUSIC_CLIENT_SECRET", "YOUTUBE_MUSIC_REDIRECT_URL"} {
+		t.Setenv(key, "")
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	if code, _, stderr := runCLI(t); code != exitUsage || !strings.Contains(stderr, "list-playlists") {
+		t.Errorf("Expected usage listing the commands, got exit %d: %s", code, stderr)
+	}
+	if code, _, _ := runCLI(t, "bogus"); code != exitUsage {
+		t.Errorf("Expected exit %d for an unknown command, got %d", exitUsage, code)
+	}
+	if code, _, stderr := runCLI(t, "export", "-provider", "mockmusic"); code != exitUsage || !strings.Contains(stderr, "-playlist is required") {
+		t.Errorf("Expected a missing flag error, got exit %d: %s", code, stderr)
+	}
+}
+
+func TestRun_ListPlaylists(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "list-playlists", "--provider", "mockmusic", "--json")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+
+	var playlists []playlistView
+	if err := json.Unmarshal([]byte(stdout), &playlists); err != nil {
+		t.Fatalf("Invalid JSON output %q: %v", stdout, err)
+	}
+	if len(playlists) == 0 || playlists[0].ID != "mock-1" {
+		t.Errorf("Expected the mock playlists, got %+v", playlists)
+	}
+
+	if code, _, stderr := runCLI(t, "list-playlists", "--provider", "nope"); code != exitError || !strings.Contains(stderr, "provider not found") {
+		t.Errorf("Expected an unknown provider error, got exit %d: %s", code, stderr)
+	}
+}
+
+func TestRun_ExportAndImport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "Workout.csv")
+
+	code, _, stderr := runCLI(t, "export", "-provider", "mockmusic", "-playlist", "mock-2", "-format", "csv", "-o", file)
+	if code != exitOK {
+		t.Fatalf("export: expected exit 0, got %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "Title,Artist,Album,Duration,ISRC,ID\n") || !strings.Contains(string(data), "Power Up") {
+		t.Errorf("Unexpected CSV export:\n%s", data)
+	}
+
+	code, stdout, stderr := runCLI(t, "import", "-to", "mockmusic", "-file", file, "-json")
+	if code != exitOK {
+		t.Fatalf("import: expected exit 0, got %d: %s", code, stderr)
+	}
+	var report services.TransferReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("Invalid JSON report %q: %v", stdout, err)
+	}
+	if report.PlaylistName != "Workout" || report.Summary[services.MatchFound] != 2 {
+		t.Errorf("Expected playlist 'Workout' with 2 matched tracks, got %q %v", report.PlaylistName, report.Summary)
+	}
+}
+
+func TestRun_Transfer(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "transfer", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "mock-1")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `completed "Summer Vibes 2024": 3 matched`) {
+		t.Errorf("Expected a transfer summary, got:\n%s", stdout)
+	}
+
+	if code, _, _ := runCLI(t, "transfer", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "missing"); code != exitError {
+		t.Errorf("Expected exit %d for a failed transfer, got %d", exitError, code)
+	}
+}
+
+func TestRun_TransferAlbums(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "transfer", "-from", "mockmusic", "-to", "mockmusic", "-mode", "albums")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "The Happy Band — Good Times") || !strings.Contains(stdout, `completed "Saved albums": 2 matched`) {
+		t.Errorf("Expected the album report, got:\n%s", stdout)
+	}
+
+	if code, _, _ := runCLI(t, "transfer", "-from", "mockmusic", "-to", "mockmusic"); code != exitUsage {
+		t.Errorf("Expected exit %d without a playlist, got %d", exitUsage, code)
+	}
+	if code, _, _ := runCLI(t, "transfer", "-from", "mockmusic", "-to", "mockmusic", "-mode", "podcasts"); code != exitUsage {
+		t.Errorf("Expected exit %d for an unknown mode, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_TransferBatch(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "transfer", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "mock-1,mock-3")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `completed "Summer Vibes 2024": 3 of 3 tracks`) || !strings.Contains(stdout, `completed "Selected playlists":`) {
+		t.Errorf("Expected a line per playlist and the summary, got:\n%s", stdout)
+	}
+}
+
+func TestRun_TransferLibrary(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "transfer", "-from", "mockmusic", "-to", "mockmusic", "-mode", "library")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `completed "Summer Vibes 2024": 3 of 3 tracks`) || !strings.Contains(stdout, `completed "All playlists":`) {
+		t.Errorf("Expected a line per playlist and the summary, got:\n%s", stdout)
+	}
+}
+
+func TestRun_Sync(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "-user", "alice", "sync", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "mock-2")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `synced "Workout Mix" to Mock Music playlist mock-created-4`) || !strings.Contains(stdout, "2 added, 0 removed, 0 unmatched") {
+		t.Errorf("Expected a sync summary, got:\n%s", stdout)
+	}
+
+	if code, _, _ := runCLI(t, "sync", "-from", "mockmusic", "-to", "mockmusic"); code != exitUsage {
+		t.Errorf("Expected exit %d without a playlist, got %d", exitUsage, code)
+	}
+	if code, _, _ := runCLI(t, "sync", "-link", "abc", "-playlist", "mock-2"); code != exitUsage {
+		t.Errorf("Expected exit %d for -link with -playlist, got %d", exitUsage, code)
+	}
+	if code, _, _ := runCLI(t, "sync", "-link", "missing"); code != exitError {
+		t.Errorf("Expected exit %d for an unknown link, got %d", exitError, code)
+	}
+}
+
+func TestRun_Sync_TwoWay(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "-user", "alice", "sync", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "mock-2", "-two-way", "-policy", "target")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "source Mock Music playlist mock-2: 0 added, 0 removed; 0 conflicts resolved") {
+		t.Errorf("Expected a two-way sync summary, got:\n%s", stdout)
+	}
+
+	if code, _, _ := runCLI(t, "sync", "-link", "abc", "-two-way"); code != exitUsage {
+		t.Errorf("Expected exit %d for -two-way with -link, got %d", exitUsage, code)
+	}
+	if code, _, _ := runCLI(t, "sync", "-link", "abc", "-resolve", "order"); code != exitUsage {
+		t.Errorf("Expected exit %d for -resolve without -keep, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_Schedule(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "-user", "alice", "schedule", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "mock-1", "-spec", "@daily")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "scheduled transfer of Mock Music playlist mock-1 to Mock Music") {
+		t.Errorf("Expected the new schedule, got:\n%s", stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "schedule", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "mock-1", "-spec", "@every 1m"); code != exitError || !strings.Contains(stderr, "more often than") {
+		t.Errorf("Expected exit %d for a spec running too often, got %d: %s", exitError, code, stderr)
+	}
+	if code, _, _ := runCLI(t, "schedule", "-from", "mockmusic", "-to", "mockmusic", "-playlist", "mock-1"); code != exitUsage {
+		t.Errorf("Expected exit %d without a spec, got %d", exitUsage, code)
+	}
+	if code, _, _ := runCLI(t, "schedule", "-run", "abc", "-delete", "abc"); code != exitUsage {
+		t.Errorf("Expected exit %d for -run with -delete, got %d", exitUsage, code)
+	}
+	if code, _, _ := runCLI(t, "schedule", "-run", "missing"); code != exitError {
+		t.Errorf("Expected exit %d for an unknown schedule, got %d", exitError, code)
+	}
+}
+
+func TestRun_ListSchedules(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "list-schedules", "-json")
+	if code != exitOK {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != "[]" {
+		t.Errorf("Expected an empty list, got:\n%s", stdout)
+	}
+	if code, _, _ := runCLI(t, "list-schedules", "-runs", "missing"); code != exitError {
+		t.Errorf("Expected exit %d for an unknown schedule, got %d", exitError, code)
+	}
+}
@@ -0,0 +1,256 @@
+// Package api implements the versioned JSON REST API served under /api/v1.
+//
+// Every endpoint is declared once in the route table, which both registers
+// the handler and generates the OpenAPI document, so the two cannot drift.
+package api
+
+import (
+	"net/http"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// Prefix is the path prefix of every API endpoint
+const Prefix = "/api/v1"
+
+// API serves the JSON REST API
+type API struct {
+	transferService   *services.TransferService
+	connectionService *services.ConnectionService
+	scheduleService   *services.ScheduleService
+	connectionStore   storage.ConnectionStore
+	overrideStore     storage.MatchOverrideStore
+}
+
+// New creates a new API
+func New(transferService *services.TransferService, connectionService *services.ConnectionService, scheduleService *services.ScheduleService, connectionStore storage.ConnectionStore, overrideStore storage.MatchOverrideStore) *API {
+	return &API{
+		transferService:   transferService,
+		connectionService: connectionService,
+		scheduleService:   scheduleService,
+		connectionStore:   connectionStore,
+		overrideStore:     overrideStore,
+	}
+}
+
+// route declares an endpoint for both the mux and the OpenAPI document
+type route struct {
+	method  string
+	path    string // ServeMux path pattern, e.g. "/api/v1/transfers/{id}"
+	tag     string
+	summary string
+	query   []queryParam
+	body    interface{} // request body type, or nil
+	data    interface{} // response data type, or nil for 204 No Content
+	list    bool        // paginated list of data
+	status  int         // success status code
+	scope   string      // API token scope the endpoint requires
+	handler http.HandlerFunc
+}
+
+// queryParam documents a query string parameter
+type queryParam struct {
+	name        string
+	description string
+	required    bool
+}
+
+var accountParam = queryParam{name: "account", description: "Provider account ID; defaults to the oldest linked account"}
+
+// routes returns the API's route table
+func (a *API) routes() []route {
+	return []route{
+		{
+			method: http.MethodGet, path: Prefix + "/providers", tag: "Providers",
+			summary: "List available providers",
+			data:    ProviderView{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listProviders,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/providers/{provider}/playlists", tag: "Playlists",
+			summary: "List the playlists of a provider account",
+			query:   []queryParam{accountParam},
+			data:    PlaylistSummary{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listPlaylists,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/providers/{provider}/playlists/{id}", tag: "Playlists",
+			summary: "Get a playlist with its tracks",
+			query:   []queryParam{accountParam},
+			data:    models.Playlist{}, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.getPlaylist,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/connections", tag: "Connections",
+			summary: "List linked provider accounts",
+			data:    ConnectionView{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listConnections,
+		},
+		{
+			method: http.MethodDelete, path: Prefix + "/connections/{provider}/{account}", tag: "Connections",
+			summary: "Disconnect a provider account, revoking its token where supported",
+			status:  http.StatusNoContent, scope: auth.ScopeAdmin,
+			handler: a.deleteConnection,
+		},
+		{
+			method: http.MethodPost, path: Prefix + "/transfers", tag: "Transfers",
+			summary: "Start a playlist transfer",
+			body:    CreateTransferRequest{},
+			data:    services.TransferProgress{}, status: http.StatusAccepted, scope: auth.ScopeTransfer,
+			handler: a.createTransfer,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/transfers", tag: "Transfers",
+			summary: "List recent transfers, newest first",
+			data:    services.TransferProgress{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listTransfers,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/transfers/{id}", tag: "Transfers",
+			summary: "Get the status of a transfer",
+			data:    services.TransferProgress{}, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.getTransfer,
+		},
+		{
+			method: http.MethodPost, path: Prefix + "/transfers/{id}/cancel", tag: "Transfers",
+			summary: "Cancel a transfer that has not finished",
+			data:    services.TransferProgress{}, status: http.StatusOK, scope: auth.ScopeTransfer,
+			handler: a.cancelTransfer,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/transfers/{id}/report", tag: "Transfers",
+			summary: "Get the per-track report of a transfer",
+			data:    services.TransferReport{}, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.getTransferReport,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/syncs", tag: "Sync links",
+			summary: "List playlist sync links",
+			data:    models.SyncLink{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listSyncs,
+		},
+		{
+			method: http.MethodPost, path: Prefix + "/syncs", tag: "Sync links",
+			summary: "Copy a playlist to the target and link the copy to its source",
+			body:    CreateSyncRequest{},
+			data:    services.SyncResult{}, status: http.StatusCreated, scope: auth.ScopeTransfer,
+			handler: a.createSync,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/syncs/{id}", tag: "Sync links",
+			summary: "Get a sync link with its last synced tracks",
+			data:    models.SyncLink{}, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.getSync,
+		},
+		{
+			method: http.MethodPost, path: Prefix + "/syncs/{id}/run", tag: "Sync links",
+			summary: "Apply the changes made to the source playlist, and to the target of two-way links, since the last sync",
+			data:    services.SyncResult{}, status: http.StatusOK, scope: auth.ScopeTransfer,
+			handler: a.runSync,
+		},
+		{
+			method: http.MethodPost, path: Prefix + "/syncs/{id}/conflicts/{conflict}/resolve", tag: "Sync links",
+			summary: "Sync a two-way link, resolving one of its conflicts in favour of one side",
+			body:    ResolveConflictRequest{},
+			data:    services.SyncResult{}, status: http.StatusOK, scope: auth.ScopeTransfer,
+			handler: a.resolveSyncConflict,
+		},
+		{
+			method: http.MethodDelete, path: Prefix + "/syncs/{id}", tag: "Sync links",
+			summary: "Delete a sync link, keeping the target playlist",
+			status:  http.StatusNoContent, scope: auth.ScopeTransfer,
+			handler: a.deleteSync,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/schedules", tag: "Schedules",
+			summary: "List scheduled transfers and syncs",
+			data:    models.Schedule{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listSchedules,
+		},
+		{
+			method: http.MethodPost, path: Prefix + "/schedules", tag: "Schedules",
+			summary: "Schedule recurring transfers or syncs of a playlist",
+			body:    CreateScheduleRequest{},
+			data:    models.Schedule{}, status: http.StatusCreated, scope: auth.ScopeTransfer,
+			handler: a.createSchedule,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/schedules/{id}", tag: "Schedules",
+			summary: "Get a schedule",
+			data:    models.Schedule{}, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.getSchedule,
+		},
+		{
+			method: http.MethodPatch, path: Prefix + "/schedules/{id}", tag: "Schedules",
+			summary: "Change the spec of a schedule or enable or disable it",
+			body:    UpdateScheduleRequest{},
+			data:    models.Schedule{}, status: http.StatusOK, scope: auth.ScopeTransfer,
+			handler: a.updateSchedule,
+		},
+		{
+			method: http.MethodPost, path: Prefix + "/schedules/{id}/run", tag: "Schedules",
+			summary: "Run a schedule now, without moving its next run",
+			data:    models.ScheduleRun{}, status: http.StatusOK, scope: auth.ScopeTransfer,
+			handler: a.runSchedule,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/schedules/{id}/runs", tag: "Schedules",
+			summary: "List the most recent runs of a schedule with their outcomes",
+			data:    models.ScheduleRun{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listScheduleRuns,
+		},
+		{
+			method: http.MethodDelete, path: Prefix + "/schedules/{id}", tag: "Schedules",
+			summary: "Delete a schedule and its history, keeping the playlists it created",
+			status:  http.StatusNoContent, scope: auth.ScopeTransfer,
+			handler: a.deleteSchedule,
+		},
+		{
+			method: http.MethodGet, path: Prefix + "/overrides", tag: "Match overrides",
+			summary: "List match overrides",
+			data:    models.MatchOverride{}, list: true, status: http.StatusOK, scope: auth.ScopeRead,
+			handler: a.listOverrides,
+		},
+		{
+			method: http.MethodPut, path: Prefix + "/overrides", tag: "Match overrides",
+			summary: "Create or replace the match override of a source track",
+			body:    OverrideRequest{},
+			data:    models.MatchOverride{}, status: http.StatusOK, scope: auth.ScopeTransfer,
+			handler: a.putOverride,
+		},
+		{
+			method: http.MethodDelete, path: Prefix + "/overrides/{id}", tag: "Match overrides",
+			summary: "Delete a match override",
+			status:  http.StatusNoContent, scope: auth.ScopeTransfer,
+			handler: a.deleteOverride,
+		},
+	}
+}
+
+// Register adds the API's routes and its OpenAPI document to mux
+func (a *API) Register(mux *http.ServeMux) {
+	for _, rt := range a.routes() {
+		mux.HandleFunc(rt.method+" "+rt.path, requireScope(rt.scope, rt.handler))
+	}
+	mux.HandleFunc("GET "+Prefix+"/openapi.json", a.serveOpenAPI)
+
+	// Unknown API paths answer with an error envelope rather than the HTML 404 page
+	mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "no such endpoint: "+r.Method+" "+r.URL.Path)
+	})
+}
+
+// requireScope rejects requests whose API token lacks the given scope
+func requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.HasScope(r.Context(), scope) {
+			writeError(w, http.StatusForbidden, CodeInsufficientScope, "this API token needs the "+scope+" scope")
+			return
+		}
+		next(w, r)
+	}
+}
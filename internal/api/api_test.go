@@ -0,0 +1,650 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// testServer is an API behind the API token and session middleware with one logged-in user
+type testServer struct {
+	t               *testing.T
+	handler         http.Handler
+	token           string
+	connectionStore storage.ConnectionStore
+	apiTokenStore   auth.APITokenStore
+	transferService *services.TransferService
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWithConnections(t, storage.NewInMemoryConnectionStore())
+}
+
+// newTestServerWithConnections is newTestServer with the given connection store
+func newTestServerWithConnections(t *testing.T, connectionStore storage.ConnectionStore) *testServer {
+	t.Helper()
+
+	transferService := services.NewTransferService()
+	transferService.RegisterProvider(providers.NewMockProvider())
+	overrideStore := storage.NewInMemoryMatchOverrideStore()
+	transferService.SetMatchOverrideStore(overrideStore)
+	transferService.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+
+	connectionService := services.NewConnectionService(connectionStore, storage.NewInMemoryAuditStore(), transferService)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	scheduleService := services.NewScheduleService(storage.NewInMemoryScheduleStore(), transferService)
+	New(transferService, connectionService, scheduleService, connectionStore, overrideStore).Register(mux)
+
+	sessionStore := auth.NewInMemorySessionStore(0)
+	token, err := sessionStore.Create("user123")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	apiTokenStore := auth.NewInMemoryAPITokenStore()
+
+	return &testServer{
+		t:               t,
+		handler:         middleware.APITokenMiddleware(apiTokenStore)(middleware.SessionMiddleware(sessionStore)(mux)),
+		token:           token,
+		connectionStore: connectionStore,
+		apiTokenStore:   apiTokenStore,
+		transferService: transferService,
+	}
+}
+
+// do sends an authenticated request and returns the recorded response
+func (s *testServer) do(method, path, body string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: s.token})
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return w
+}
+
+// decode unmarshals a response body, failing the test on invalid JSON
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", w.Body.String(), err)
+	}
+}
+
+func TestAPI_ListProviders(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodGet, "/api/v1/providers", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data       []ProviderView `json:"data"`
+		Pagination Pagination     `json:"pagination"`
+	}
+	decode(t, w, &resp)
+	if len(resp.Data) != 1 || resp.Data[0].Slug != "mockmusic" || !resp.Data[0].TrackSearch {
+		t.Errorf("Unexpected providers: %+v", resp.Data)
+	}
+	if resp.Pagination.Total != 1 {
+		t.Errorf("Expected total 1, got %d", resp.Pagination.Total)
+	}
+}
+
+func TestAPI_ListPlaylists_Pagination(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodGet, "/api/v1/providers/mockmusic/playlists?per_page=2&page=2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data       []PlaylistSummary `json:"data"`
+		Pagination Pagination        `json:"pagination"`
+	}
+	decode(t, w, &resp)
+	if len(resp.Data) != 1 || resp.Data[0].ID != "mock-3" {
+		t.Errorf("Expected mock-3 on page 2, got %+v", resp.Data)
+	}
+	want := Pagination{Page: 2, PerPage: 2, Total: 3, TotalPages: 2}
+	if resp.Pagination != want {
+		t.Errorf("Pagination = %+v, want %+v", resp.Pagination, want)
+	}
+
+	w = s.do(http.MethodGet, "/api/v1/providers/mockmusic/playlists?per_page=500", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for oversized pages, got %d", w.Code)
+	}
+}
+
+func TestAPI_ListPlaylists_PastLastPage(t *testing.T) {
+	s := newTestServer(t)
+
+	// (page-1)*per_page overflows for this page
+	for _, page := range []string{"3", "100000000000000001", "9223372036854775807"} {
+		w := s.do(http.MethodGet, "/api/v1/providers/mockmusic/playlists?per_page=2&page="+page, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("page=%s: expected status 200, got %d: %s", page, w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			Data       []PlaylistSummary `json:"data"`
+			Pagination Pagination        `json:"pagination"`
+		}
+		decode(t, w, &resp)
+		if len(resp.Data) != 0 || resp.Pagination.Total != 3 || resp.Pagination.TotalPages != 2 {
+			t.Errorf("page=%s: expected an empty page of 3 items, got %+v", page, resp)
+		}
+	}
+}
+
+func TestAPI_GetPlaylist(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodGet, "/api/v1/providers/mockmusic/playlists/mock-1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data models.Playlist `json:"data"`
+	}
+	decode(t, w, &resp)
+	if resp.Data.Name != "Summer Vibes 2024" || len(resp.Data.Tracks) != 3 {
+		t.Errorf("Expected playlist with 3 tracks, got %+v", resp.Data)
+	}
+}
+
+func TestAPI_Errors(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown provider", http.MethodGet, "/api/v1/providers/nope/playlists", "", http.StatusNotFound, CodeNotFound},
+		{"unknown transfer", http.MethodGet, "/api/v1/transfers/nope", "", http.StatusNotFound, CodeNotFound},
+		{"unknown endpoint", http.MethodGet, "/api/v1/nope", "", http.StatusNotFound, CodeNotFound},
+		{"malformed body", http.MethodPost, "/api/v1/transfers", "{", http.StatusBadRequest, CodeBadRequest},
+		{"unknown field", http.MethodPost, "/api/v1/transfers", `{"playlist":"x"}`, http.StatusBadRequest, CodeBadRequest},
+		{"missing fields", http.MethodPost, "/api/v1/transfers", `{"playlist_id":"mock-1"}`, http.StatusBadRequest, CodeBadRequest},
+		{"missing playlist", http.MethodPost, "/api/v1/transfers", `{"source_provider":"mockmusic","target_provider":"mockmusic"}`, http.StatusBadRequest, CodeBadRequest},
+		{"unknown mode", http.MethodPost, "/api/v1/transfers", `{"source_provider":"mockmusic","target_provider":"mockmusic","mode":"podcasts"}`, http.StatusBadRequest, CodeBadRequest},
+		{"unknown connection", http.MethodDelete, "/api/v1/connections/mockmusic/nobody", "", http.StatusNotFound, CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(tt.method, tt.path, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var resp ErrorResponse
+			decode(t, w, &resp)
+			if resp.Error.Code != tt.wantCode || resp.Error.Message == "" {
+				t.Errorf("Unexpected error envelope: %+v", resp)
+			}
+		})
+	}
+}
+
+// blockingSource is a mock source whose exports wait until release is
+// closed, so its transfers are still running when the test cancels them
+type blockingSource struct {
+	*providers.MockProvider
+	release chan struct{}
+}
+
+func (p *blockingSource) Name() string { return "Blocking" }
+
+func (p *blockingSource) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	<-p.release
+	return p.MockProvider.ExportPlaylist(acct, id)
+}
+
+func TestAPI_TransferLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	source := &blockingSource{MockProvider: providers.NewMockProvider(), release: make(chan struct{})}
+	s.transferService.RegisterProvider(source)
+
+	w := s.do(http.MethodPost, "/api/v1/transfers", `{"source_provider":"blocking","target_provider":"mockmusic","playlist_id":"mock-1"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data services.TransferProgress `json:"data"`
+	}
+	decode(t, w, &created)
+	id := created.Data.ID
+	if id == "" || created.Data.Status != services.StatusPending {
+		t.Fatalf("Expected a pending transfer, got %+v", created.Data)
+	}
+
+	w = s.do(http.MethodGet, "/api/v1/transfers", "")
+	var list struct {
+		Data []services.TransferProgress `json:"data"`
+	}
+	decode(t, w, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != id {
+		t.Errorf("Expected the transfer in the list, got %+v", list.Data)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/transfers/"+id+"/cancel", "")
+	close(source.release)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var status string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		var got struct {
+			Data services.TransferProgress `json:"data"`
+		}
+		decode(t, s.do(http.MethodGet, "/api/v1/transfers/"+id, ""), &got)
+		status = got.Data.Status
+		if status == services.StatusCancelled {
+			break
+		}
+	}
+	if status != services.StatusCancelled {
+		t.Errorf("Expected cancelled transfer, got %s", status)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/transfers/"+id+"/cancel", "")
+	if w.Code != http.StatusConflict {
+		t.Errorf("Cancelling a finished transfer should conflict, got %d", w.Code)
+	}
+
+	w = s.do(http.MethodGet, "/api/v1/transfers/"+id+"/report", "")
+	var report struct {
+		Data services.TransferReport `json:"data"`
+	}
+	decode(t, w, &report)
+	if report.Data.TransferID != id || report.Data.Status != services.StatusCancelled {
+		t.Errorf("Unexpected report: %+v", report.Data)
+	}
+}
+
+func TestAPI_CreateTransfer_AlbumMode(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodPost, "/api/v1/transfers", `{"source_provider":"mockmusic","target_provider":"mockmusic","mode":"albums"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202 without playlist_id, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data services.TransferProgress `json:"data"`
+	}
+	decode(t, w, &created)
+	if created.Data.Mode != services.ModeAlbums {
+		t.Errorf("Expected the albums mode, got %+v", created.Data)
+	}
+}
+
+func TestAPI_CreateTransfer_BatchMode(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodPost, "/api/v1/transfers", `{"source_provider":"mockmusic","target_provider":"mockmusic","mode":"batch"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without playlist_ids, got %d", w.Code)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/transfers", `{"source_provider":"mockmusic","target_provider":"mockmusic","mode":"batch","playlist_ids":["mock-1","mock-2"]}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data services.TransferProgress `json:"data"`
+	}
+	decode(t, w, &created)
+	if created.Data.Mode != services.ModeBatch {
+		t.Errorf("Expected the batch mode, got %+v", created.Data)
+	}
+}
+
+func TestAPI_Overrides(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodPut, "/api/v1/overrides", `{"source_provider":"mockmusic","source_track_id":"track-1","target_provider":"mockmusic","target_track_id":"track-9"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved struct {
+		Data models.MatchOverride `json:"data"`
+	}
+	decode(t, w, &saved)
+	if saved.Data.ID == "" || saved.Data.TargetTrackID != "track-9" {
+		t.Fatalf("Unexpected override: %+v", saved.Data)
+	}
+
+	w = s.do(http.MethodPut, "/api/v1/overrides", `{"source_provider":"nope","source_track_id":"track-1","target_provider":"mockmusic"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown providers, got %d", w.Code)
+	}
+
+	var list struct {
+		Data []models.MatchOverride `json:"data"`
+	}
+	decode(t, s.do(http.MethodGet, "/api/v1/overrides", ""), &list)
+	if len(list.Data) != 1 {
+		t.Fatalf("Expected 1 override, got %d", len(list.Data))
+	}
+
+	w = s.do(http.MethodDelete, "/api/v1/overrides/"+saved.Data.ID, "")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	w = s.do(http.MethodDelete, "/api/v1/overrides/"+saved.Data.ID, "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for deleted override, got %d", w.Code)
+	}
+}
+
+func TestAPI_Syncs(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodPost, "/api/v1/syncs", `{"source_provider":"mockmusic","target_provider":"mockmusic"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without playlist_id, got %d", w.Code)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/syncs", `{"source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-2"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data services.SyncResult `json:"data"`
+	}
+	decode(t, w, &created)
+	if created.Data.Link == nil || created.Data.Added != 2 || created.Data.Link.SourceProvider != "Mock Music" {
+		t.Fatalf("Unexpected sync result: %+v", created.Data)
+	}
+	id := created.Data.Link.ID
+
+	var list struct {
+		Data []models.SyncLink `json:"data"`
+	}
+	decode(t, s.do(http.MethodGet, "/api/v1/syncs", ""), &list)
+	if len(list.Data) != 1 || list.Data[0].ID != id {
+		t.Fatalf("Expected the created link, got %+v", list.Data)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/syncs/"+id+"/run", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var synced struct {
+		Data services.SyncResult `json:"data"`
+	}
+	decode(t, w, &synced)
+	if synced.Data.Added != 0 || synced.Data.Removed != 0 || synced.Data.Reordered {
+		t.Errorf("Expected an unchanged playlist to change nothing, got %+v", synced.Data)
+	}
+
+	if w := s.do(http.MethodDelete, "/api/v1/syncs/"+id, ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if w := s.do(http.MethodPost, "/api/v1/syncs/"+id+"/run", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a deleted link, got %d", w.Code)
+	}
+}
+
+func TestAPI_Syncs_TwoWay(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodPost, "/api/v1/syncs", `{"source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-2","conflict_policy":"source"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a conflict policy without two_way, got %d", w.Code)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/syncs", `{"source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-2","two_way":true}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data services.SyncResult `json:"data"`
+	}
+	decode(t, w, &created)
+	link := created.Data.Link
+	if link == nil || !link.TwoWay || link.ConflictPolicy != models.ConflictManual {
+		t.Fatalf("Expected a two-way link with the manual policy, got %+v", created.Data)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/syncs/"+link.ID+"/run", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/syncs/"+link.ID+"/conflicts/order/resolve", `{"keep":"left"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown side, got %d", w.Code)
+	}
+	w = s.do(http.MethodPost, "/api/v1/syncs/"+link.ID+"/conflicts/order/resolve", `{"keep":"source"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a conflict the link does not have, got %d", w.Code)
+	}
+}
+
+func TestAPI_Connections(t *testing.T) {
+	s := newTestServer(t)
+
+	conn := &models.Connection{
+		Provider: "mockmusic", UserID: "user123", ExternalUserID: "alice", ExternalUserName: "Alice",
+		AccessToken: "secret-access", RefreshToken: "secret-refresh", Connected: true,
+	}
+	if err := s.connectionStore.Save(conn); err != nil {
+		t.Fatalf("Failed to save connection: %v", err)
+	}
+
+	w := s.do(http.MethodGet, "/api/v1/connections", "")
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Error("Connections must not expose tokens")
+	}
+	var list struct {
+		Data []ConnectionView `json:"data"`
+	}
+	decode(t, w, &list)
+	if len(list.Data) != 1 || list.Data[0].ExternalUserName != "Alice" {
+		t.Fatalf("Unexpected connections: %+v", list.Data)
+	}
+
+	w = s.do(http.MethodDelete, "/api/v1/connections/mockmusic/alice", "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := s.connectionStore.Get("mockmusic", "user123", "alice"); err == nil {
+		t.Error("Connection should be deleted")
+	}
+}
+
+// failingDeleteConnectionStore is a connection store whose Delete fails
+type failingDeleteConnectionStore struct {
+	storage.ConnectionStore
+}
+
+func (failingDeleteConnectionStore) Delete(provider, userID, externalUserID string) error {
+	return errors.New("database is locked")
+}
+
+func TestAPI_DeleteConnection_StoreError(t *testing.T) {
+	s := newTestServerWithConnections(t, failingDeleteConnectionStore{storage.NewInMemoryConnectionStore()})
+	conn := &models.Connection{
+		Provider: "mockmusic", UserID: "user123", ExternalUserID: "alice",
+		AccessToken: "secret-access", Connected: true,
+	}
+	if err := s.connectionStore.Save(conn); err != nil {
+		t.Fatalf("Failed to save connection: %v", err)
+	}
+
+	w := s.do(http.MethodDelete, "/api/v1/connections/mockmusic/alice", "")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", w.Code, w.Body.String())
+	}
+	var body ErrorResponse
+	decode(t, w, &body)
+	if body.Error.Code != CodeInternal {
+		t.Errorf("Expected code %s, got %s", CodeInternal, body.Error.Code)
+	}
+	if strings.Contains(w.Body.String(), "locked") {
+		t.Error("Internal errors must not be exposed")
+	}
+}
+
+func TestAPI_Schedules(t *testing.T) {
+	s := newTestServer(t)
+
+	w := s.do(http.MethodPost, "/api/v1/schedules", `{"kind":"backup","source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-1","spec":"@daily"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown kind, got %d", w.Code)
+	}
+	w = s.do(http.MethodPost, "/api/v1/schedules", `{"kind":"transfer","source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-1","spec":"* * * * *"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a spec running too often, got %d", w.Code)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/schedules", `{"kind":"transfer","source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-1","spec":"0 3 * * *"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data models.Schedule `json:"data"`
+	}
+	decode(t, w, &created)
+	if created.Data.ID == "" || !created.Data.Enabled || created.Data.NextRunAt.IsZero() || created.Data.SourceProvider != "Mock Music" {
+		t.Fatalf("Unexpected schedule: %+v", created.Data)
+	}
+	id := created.Data.ID
+
+	w = s.do(http.MethodPatch, "/api/v1/schedules/"+id, `{"enabled":false}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated struct {
+		Data models.Schedule `json:"data"`
+	}
+	decode(t, w, &updated)
+	if updated.Data.Enabled || updated.Data.Spec != "0 3 * * *" {
+		t.Errorf("Expected a disabled schedule with the same spec, got %+v", updated.Data)
+	}
+
+	w = s.do(http.MethodPost, "/api/v1/schedules/"+id+"/run", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var run struct {
+		Data models.ScheduleRun `json:"data"`
+	}
+	decode(t, w, &run)
+	if run.Data.Status != services.StatusCompleted || !strings.Contains(run.Data.Message, `"Summer Vibes 2024": 3 matched`) {
+		t.Errorf("Expected a completed run, got %+v", run.Data)
+	}
+
+	var runs struct {
+		Data []models.ScheduleRun `json:"data"`
+	}
+	decode(t, s.do(http.MethodGet, "/api/v1/schedules/"+id+"/runs", ""), &runs)
+	if len(runs.Data) != 1 || runs.Data[0].ID != run.Data.ID {
+		t.Errorf("Expected the run in the history, got %+v", runs.Data)
+	}
+
+	if w := s.do(http.MethodDelete, "/api/v1/schedules/"+id, ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/schedules/" + id},
+		{http.MethodPost, "/api/v1/schedules/" + id + "/run"},
+		{http.MethodGet, "/api/v1/schedules/" + id + "/runs"},
+	} {
+		if w := s.do(req.method, req.path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: expected status 404 for a deleted schedule, got %d", req.method, req.path, w.Code)
+		}
+	}
+}
+
+func TestAPI_Schedules_Limit(t *testing.T) {
+	s := newTestServer(t)
+
+	body := `{"kind":"sync","source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-2","spec":"@daily"}`
+	for i := 0; i < 10; i++ {
+		if w := s.do(http.MethodPost, "/api/v1/schedules", body); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if w := s.do(http.MethodPost, "/api/v1/schedules", body); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 beyond the per-user limit, got %d", w.Code)
+	}
+}
+
+func TestAPI_TokenScopes(t *testing.T) {
+	s := newTestServer(t)
+
+	readToken, _, err := s.apiTokenStore.Create("user123", "dashboard", []string{auth.ScopeRead}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to create API token: %v", err)
+	}
+	transferToken, _, err := s.apiTokenStore.Create("user123", "cron", []string{auth.ScopeTransfer}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to create API token: %v", err)
+	}
+
+	withToken := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		s.handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := withToken(readToken, http.MethodGet, "/api/v1/transfers", ""); w.Code != http.StatusOK {
+		t.Errorf("read token: expected 200 listing transfers, got %d: %s", w.Code, w.Body.String())
+	}
+
+	transfer := `{"source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-1"}`
+	w := withToken(readToken, http.MethodPost, "/api/v1/transfers", transfer)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("read token: expected 403 starting a transfer, got %d", w.Code)
+	}
+	var errResp ErrorResponse
+	decode(t, w, &errResp)
+	if errResp.Error.Code != CodeInsufficientScope {
+		t.Errorf("Expected error code %q, got %q", CodeInsufficientScope, errResp.Error.Code)
+	}
+
+	if w := withToken(transferToken, http.MethodPost, "/api/v1/transfers", transfer); w.Code != http.StatusAccepted {
+		t.Errorf("transfer token: expected 202 starting a transfer, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := withToken(transferToken, http.MethodDelete, "/api/v1/connections/mockmusic/acct", ""); w.Code != http.StatusForbidden {
+		t.Errorf("transfer token: expected 403 disconnecting, got %d", w.Code)
+	}
+
+	if w := withToken("pp_unknown", http.MethodGet, "/api/v1/transfers", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: expected 401, got %d", w.Code)
+	}
+}
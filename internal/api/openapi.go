@@ -0,0 +1,238 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/handlers"
+	"github.com/JanikSachs/PlayPort/internal/version"
+)
+
+// pathParamPattern matches {name} segments of a ServeMux path pattern
+var pathParamPattern = regexp.MustCompile(`\{([^}.]+)(?:\.\.\.)?\}`)
+
+// serveOpenAPI handles GET /api/v1/openapi.json
+func (a *API) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	handlers.RespondJSON(w, a.OpenAPI(), http.StatusOK)
+}
+
+// OpenAPI builds the OpenAPI 3.0 document describing the route table
+func (a *API) OpenAPI() map[string]interface{} {
+	g := &schemaGenerator{components: map[string]interface{}{}}
+	errorRef := g.schema(reflect.TypeOf(ErrorResponse{}))
+	paginationRef := g.schema(reflect.TypeOf(Pagination{}))
+
+	paths := map[string]interface{}{}
+	for _, rt := range a.routes() {
+		op := map[string]interface{}{
+			"summary":     rt.summary,
+			"description": "API tokens need the `" + rt.scope + "` scope.",
+			"operationId": operationID(rt),
+			"tags":        []string{rt.tag},
+		}
+
+		var params []interface{}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(rt.path, -1) {
+			params = append(params, map[string]interface{}{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		query := rt.query
+		if rt.list {
+			query = append(query,
+				queryParam{name: "page", description: "Page number, starting at 1"},
+				queryParam{name: "per_page", description: "Items per page, at most " + strconv.Itoa(maxPerPage)},
+			)
+		}
+		for _, q := range query {
+			schemaType := "string"
+			if q.name == "page" || q.name == "per_page" {
+				schemaType = "integer"
+			}
+			params = append(params, map[string]interface{}{
+				"name": q.name, "in": "query", "required": q.required, "description": q.description,
+				"schema": map[string]interface{}{"type": schemaType},
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		if rt.body != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(rt.body))},
+				},
+			}
+		}
+
+		responses := map[string]interface{}{
+			"default": jsonResponse("Error", errorRef),
+		}
+		success := strconv.Itoa(rt.status)
+		switch {
+		case rt.data == nil:
+			responses[success] = map[string]interface{}{"description": http.StatusText(rt.status)}
+		case rt.list:
+			responses[success] = jsonResponse(http.StatusText(rt.status), objectSchema(map[string]interface{}{
+				"data":       map[string]interface{}{"type": "array", "items": g.schema(reflect.TypeOf(rt.data))},
+				"pagination": paginationRef,
+			}))
+		default:
+			responses[success] = jsonResponse(http.StatusText(rt.status), objectSchema(map[string]interface{}{
+				"data": g.schema(reflect.TypeOf(rt.data)),
+			}))
+		}
+		op["responses"] = responses
+
+		item, _ := paths[rt.path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "PlayPort API",
+			"version": version.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": g.components,
+			"securitySchemes": map[string]interface{}{
+				"sessionCookie": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": "session_token"},
+				"bearerToken": map[string]interface{}{
+					"type": "http", "scheme": "bearer",
+					"description": "Personal API token created on the settings page. " +
+						"Scopes: read < transfer < admin; each includes the ones before it.",
+				},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"sessionCookie": []string{}},
+			map[string]interface{}{"bearerToken": []string{}},
+		},
+	}
+}
+
+// operationID derives a stable operation ID such as "get_transfers_id_report"
+func operationID(rt route) string {
+	path := strings.TrimPrefix(rt.path, Prefix+"/")
+	path = strings.NewReplacer("{", "", "}", "", "/", "_").Replace(path)
+	return strings.ToLower(rt.method) + "_" + path
+}
+
+// jsonResponse describes a JSON response with the given schema
+func jsonResponse(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// objectSchema describes an envelope object whose properties are all required
+func objectSchema(properties map[string]interface{}) map[string]interface{} {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// schemaGenerator converts Go types to JSON schemas, collecting named
+// structs as reusable components
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of t, or a $ref for named structs
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := t.Name()
+		if _, ok := g.components[name]; !ok {
+			g.components[name] = nil // reserve the name before recursing
+			g.components[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.structSchema(t)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct's JSON fields. Fields without omitempty
+// are always present and therefore required.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	sort.Strings(required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// loadOpenAPI fetches the served document as generic JSON
+func loadOpenAPI(t *testing.T, s *testServer) map[string]interface{} {
+	t.Helper()
+
+	w := s.do(http.MethodGet, "/api/v1/openapi.json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var doc map[string]interface{}
+	decode(t, w, &doc)
+	return doc
+}
+
+func TestOpenAPI_CoversRouteTable(t *testing.T) {
+	s := newTestServer(t)
+	doc := loadOpenAPI(t, s)
+	paths := doc["paths"].(map[string]interface{})
+
+	routes := (&API{}).routes()
+	operations := 0
+	for _, item := range paths {
+		operations += len(item.(map[string]interface{}))
+	}
+	if operations != len(routes) {
+		t.Errorf("Document has %d operations, route table has %d", operations, len(routes))
+	}
+
+	for _, rt := range routes {
+		item, ok := paths[rt.path].(map[string]interface{})
+		if !ok {
+			t.Errorf("Path %s is missing", rt.path)
+			continue
+		}
+		op, ok := item[strings.ToLower(rt.method)].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s %s is missing", rt.method, rt.path)
+			continue
+		}
+		responses := op["responses"].(map[string]interface{})
+		if _, ok := responses[strconv.Itoa(rt.status)]; !ok {
+			t.Errorf("%s %s does not document status %d", rt.method, rt.path, rt.status)
+		}
+		if _, ok := responses["default"]; !ok {
+			t.Errorf("%s %s does not document the error envelope", rt.method, rt.path)
+		}
+	}
+}
+
+func TestOpenAPI_ReferencesResolve(t *testing.T) {
+	s := newTestServer(t)
+	doc := loadOpenAPI(t, s)
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to marshal document: %v", err)
+	}
+	for _, part := range strings.Split(string(raw), `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		if schemas[name] == nil {
+			t.Errorf("Reference to undefined schema %s", name)
+		}
+	}
+}
+
+// TestOpenAPI_ResponsesMatchSchemas checks live handler responses against the
+// schemas the document declares for them, so the document cannot drift from
+// what the handlers actually send
+func TestOpenAPI_ResponsesMatchSchemas(t *testing.T) {
+	s := newTestServer(t)
+	doc := loadOpenAPI(t, s)
+
+	created := s.do(http.MethodPost, "/api/v1/transfers", `{"source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-1"}`)
+	var transfer struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	decode(t, created, &transfer)
+
+	created = s.do(http.MethodPost, "/api/v1/schedules", `{"kind":"sync","source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-2","spec":"@every 6h"}`)
+	var schedule struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	decode(t, created, &schedule)
+
+	requests := []struct {
+		method, path, pattern, body string
+	}{
+		{http.MethodGet, "/api/v1/providers", "/api/v1/providers", ""},
+		{http.MethodGet, "/api/v1/providers/mockmusic/playlists", "/api/v1/providers/{provider}/playlists", ""},
+		{http.MethodGet, "/api/v1/providers/mockmusic/playlists/mock-1", "/api/v1/providers/{provider}/playlists/{id}", ""},
+		{http.MethodGet, "/api/v1/providers/nope/playlists", "/api/v1/providers/{provider}/playlists", ""},
+		{http.MethodGet, "/api/v1/connections", "/api/v1/connections", ""},
+		{http.MethodGet, "/api/v1/transfers", "/api/v1/transfers", ""},
+		{http.MethodGet, "/api/v1/transfers/" + transfer.Data.ID, "/api/v1/transfers/{id}", ""},
+		{http.MethodGet, "/api/v1/transfers/" + transfer.Data.ID + "/report", "/api/v1/transfers/{id}/report", ""},
+		{http.MethodPut, "/api/v1/overrides", "/api/v1/overrides", `{"source_provider":"mockmusic","source_track_id":"t1","target_provider":"mockmusic","target_track_id":""}`},
+		{http.MethodGet, "/api/v1/overrides", "/api/v1/overrides", ""},
+		{http.MethodPost, "/api/v1/syncs", "/api/v1/syncs", `{"source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-3"}`},
+		{http.MethodPost, "/api/v1/syncs", "/api/v1/syncs", `{"source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-1","two_way":true,"conflict_policy":"target"}`},
+		{http.MethodGet, "/api/v1/syncs", "/api/v1/syncs", ""},
+		{http.MethodPost, "/api/v1/transfers", "/api/v1/transfers", `{}`},
+		{http.MethodPost, "/api/v1/schedules", "/api/v1/schedules", `{"kind":"transfer","source_provider":"mockmusic","target_provider":"mockmusic","playlist_id":"mock-1","spec":"@daily"}`},
+		{http.MethodGet, "/api/v1/schedules", "/api/v1/schedules", ""},
+		{http.MethodPost, "/api/v1/schedules/" + schedule.Data.ID + "/run", "/api/v1/schedules/{id}/run", ""},
+		{http.MethodGet, "/api/v1/schedules/" + schedule.Data.ID + "/runs", "/api/v1/schedules/{id}/runs", ""},
+	}
+
+	for _, req := range requests {
+		t.Run(req.method+" "+req.path, func(t *testing.T) {
+			w := s.do(req.method, req.path, req.body)
+
+			op := doc["paths"].(map[string]interface{})[req.pattern].(map[string]interface{})[strings.ToLower(req.method)].(map[string]interface{})
+			responses := op["responses"].(map[string]interface{})
+			response, ok := responses[strconv.Itoa(w.Code)].(map[string]interface{})
+			if !ok {
+				response = responses["default"].(map[string]interface{})
+			}
+			schema := response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+
+			var body interface{}
+			decode(t, w, &body)
+			if err := validate(doc, schema.(map[string]interface{}), body, "$"); err != nil {
+				t.Errorf("Response does not match schema: %v\n%s", err, w.Body.String())
+			}
+		})
+	}
+}
+
+// validate checks value against the subset of JSON Schema the generator emits
+func validate(doc map[string]interface{}, schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		target := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name]
+		return validate(doc, target.(map[string]interface{}), value, path)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", path, value)
+		}
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := obj[name.(string)]; !ok {
+					return fmt.Errorf("%s: missing required property %s", path, name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, v := range obj {
+			propSchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if additional == nil {
+					return fmt.Errorf("%s: undocumented property %s", path, name)
+				}
+				propSchema = additional
+			}
+			if err := validate(doc, propSchema, v, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", path, value)
+		}
+		for i, v := range arr {
+			if err := validate(doc, schema["items"].(map[string]interface{}), v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", path, value)
+		}
+	case "integer", "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", path, value)
+		}
+	}
+	return nil
+}
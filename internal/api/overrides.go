@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// OverrideRequest is the body of PUT /api/v1/overrides
+type OverrideRequest struct {
+	SourceProvider string `json:"source_provider"` // provider slug
+	SourceTrackID  string `json:"source_track_id"`
+	TargetProvider string `json:"target_provider"` // provider slug
+	TargetTrackID  string `json:"target_track_id"` // empty to skip the track
+}
+
+// listOverrides handles GET /api/v1/overrides
+func (a *API) listOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides, err := a.overrideStore.List(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "failed to list match overrides")
+		return
+	}
+
+	writeList(w, r, overrides)
+}
+
+// putOverride handles PUT /api/v1/overrides
+func (a *API) putOverride(w http.ResponseWriter, r *http.Request) {
+	var body OverrideRequest
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+	if body.SourceProvider == "" || body.SourceTrackID == "" || body.TargetProvider == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "source_provider, source_track_id and target_provider are required")
+		return
+	}
+	for _, slug := range []string{body.SourceProvider, body.TargetProvider} {
+		if _, err := a.transferService.GetProviderBySlug(slug); err != nil {
+			writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+			return
+		}
+	}
+
+	override := &models.MatchOverride{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		SourceProvider: body.SourceProvider,
+		SourceTrackID:  body.SourceTrackID,
+		TargetProvider: body.TargetProvider,
+		TargetTrackID:  body.TargetTrackID,
+	}
+	if err := a.overrideStore.Save(override); err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "failed to save match override")
+		return
+	}
+
+	writeData(w, http.StatusOK, override)
+}
+
+// deleteOverride handles DELETE /api/v1/overrides/{id}
+func (a *API) deleteOverride(w http.ResponseWriter, r *http.Request) {
+	if err := a.overrideStore.Delete(middleware.UserIDFromContext(r.Context()), r.PathValue("id")); err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// ProviderView describes a provider and what it supports
+type ProviderView struct {
+	Slug           string `json:"slug"`
+	Name           string `json:"name"`
+	LinkedAccounts bool   `json:"linked_accounts"` // accounts are linked through OAuth connections
+	TrackSearch    bool   `json:"track_search"`    // tracks are matched before import
+	TokenRevoke    bool   `json:"token_revoke"`    // disconnecting revokes the token
+}
+
+// PlaylistSummary is a playlist without its tracks
+type PlaylistSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TrackCount  int    `json:"track_count"`
+	Provider    string `json:"provider"`
+}
+
+// ConnectionView is a linked provider account, without its tokens
+type ConnectionView struct {
+	Provider         string    `json:"provider"`
+	ExternalUserID   string    `json:"external_user_id"`
+	ExternalUserName string    `json:"external_user_name"`
+	Scopes           []string  `json:"scopes"`
+	Connected        bool      `json:"connected"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// listProviders handles GET /api/v1/providers
+func (a *API) listProviders(w http.ResponseWriter, r *http.Request) {
+	var views []ProviderView
+	for _, name := range a.transferService.ListProviders() {
+		provider, err := a.transferService.GetProvider(name)
+		if err != nil {
+			continue
+		}
+		_, linked := provider.(providers.AccountLister)
+		_, search := provider.(providers.TrackSearcher)
+		_, revoke := provider.(providers.Revoker)
+		views = append(views, ProviderView{
+			Slug:           services.ProviderSlug(name),
+			Name:           name,
+			LinkedAccounts: linked,
+			TrackSearch:    search,
+			TokenRevoke:    revoke,
+		})
+	}
+
+	writeList(w, r, views)
+}
+
+// listPlaylists handles GET /api/v1/providers/{provider}/playlists
+func (a *API) listPlaylists(w http.ResponseWriter, r *http.Request) {
+	provider, acct, ok := a.providerAccount(w, r)
+	if !ok {
+		return
+	}
+
+	playlists, err := provider.GetPlaylists(acct)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, CodeProvider, "failed to fetch playlists: "+err.Error())
+		return
+	}
+
+	summaries := make([]PlaylistSummary, 0, len(playlists))
+	for _, p := range playlists {
+		summaries = append(summaries, PlaylistSummary{
+			ID:          p.ID,
+			Name:        p.Name,
+			Description: p.Description,
+			TrackCount:  p.TrackCount,
+			Provider:    p.Provider,
+		})
+	}
+
+	writeList(w, r, summaries)
+}
+
+// getPlaylist handles GET /api/v1/providers/{provider}/playlists/{id}
+func (a *API) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	provider, acct, ok := a.providerAccount(w, r)
+	if !ok {
+		return
+	}
+
+	playlist, err := provider.ExportPlaylist(acct, r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, CodeProvider, "failed to fetch playlist: "+err.Error())
+		return
+	}
+	if playlist.Tracks == nil {
+		playlist.Tracks = []models.Track{}
+	}
+
+	writeData(w, http.StatusOK, playlist)
+}
+
+// listConnections handles GET /api/v1/connections
+func (a *API) listConnections(w http.ResponseWriter, r *http.Request) {
+	connections, err := a.connectionStore.List(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "failed to list connections")
+		return
+	}
+
+	views := make([]ConnectionView, 0, len(connections))
+	for _, conn := range connections {
+		scopes := conn.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		views = append(views, ConnectionView{
+			Provider:         conn.Provider,
+			ExternalUserID:   conn.ExternalUserID,
+			ExternalUserName: conn.ExternalUserName,
+			Scopes:           scopes,
+			Connected:        conn.Connected,
+			ExpiresAt:        conn.ExpiresAt,
+			CreatedAt:        conn.CreatedAt,
+		})
+	}
+
+	writeList(w, r, views)
+}
+
+// deleteConnection handles DELETE /api/v1/connections/{provider}/{account}
+func (a *API) deleteConnection(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("provider")
+	provider, err := a.transferService.GetProviderBySlug(slug)
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	acct := providers.Account{UserID: middleware.UserIDFromContext(r.Context()), ExternalUserID: r.PathValue("account")}
+	if err := a.connectionService.Disconnect(r.Context(), provider, slug, acct); errors.Is(err, storage.ErrConnectionNotFound) {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "failed to disconnect account")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// providerAccount resolves the {provider} path value and account query
+// parameter and authenticates the account, writing an error if that fails
+func (a *API) providerAccount(w http.ResponseWriter, r *http.Request) (providers.Provider, providers.Account, bool) {
+	provider, err := a.transferService.GetProviderBySlug(r.PathValue("provider"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return nil, providers.Account{}, false
+	}
+
+	acct := providers.Account{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		ExternalUserID: r.URL.Query().Get("account"),
+	}
+	if err := provider.Authenticate(acct); err != nil {
+		writeError(w, http.StatusConflict, CodeNotConnected, err.Error())
+		return nil, providers.Account{}, false
+	}
+
+	return provider, acct, true
+}
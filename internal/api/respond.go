@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/JanikSachs/PlayPort/internal/handlers"
+)
+
+// Error codes used in error envelopes
+const (
+	CodeBadRequest        = "bad_request"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeNotConnected      = "not_connected"
+	CodeProvider          = "provider_error"
+	CodeInternal          = "internal_error"
+)
+
+// Pagination bounds
+const (
+	defaultPerPage = 50
+	maxPerPage     = 100
+)
+
+// maxBodyBytes limits the size of JSON request bodies
+const maxBodyBytes = 1 << 20
+
+// ErrorResponse is the envelope of every failed request
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes what went wrong
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Pagination describes the page of a list response
+type Pagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// dataResponse is the envelope of a successful single-object response
+type dataResponse struct {
+	Data interface{} `json:"data"`
+}
+
+// listResponse is the envelope of a successful list response
+type listResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// writeData sends a single object in the data envelope
+func writeData(w http.ResponseWriter, status int, data interface{}) {
+	handlers.RespondJSON(w, dataResponse{Data: data}, status)
+}
+
+// writeList sends one page of items in the list envelope
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	page, perPage, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+
+	// Pages past the last one are empty. Comparing page numbers instead of
+	// offsets keeps huge page values from overflowing.
+	total := len(items)
+	totalPages := (total + perPage - 1) / perPage
+	start, end := total, total
+	if page <= totalPages {
+		start = (page - 1) * perPage
+		end = min(start+perPage, total)
+	}
+
+	pageItems := items[start:end]
+	if pageItems == nil {
+		pageItems = []T{}
+	}
+
+	handlers.RespondJSON(w, listResponse{
+		Data: pageItems,
+		Pagination: Pagination{
+			Page:       page,
+			PerPage:    perPage,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}, http.StatusOK)
+}
+
+// writeError sends an error envelope
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	handlers.RespondJSON(w, ErrorResponse{Error: ErrorBody{Code: code, Message: message}}, status)
+}
+
+// parsePage reads the page and per_page query parameters
+func parsePage(r *http.Request) (page, perPage int, err error) {
+	page, perPage = 1, defaultPerPage
+
+	if v := r.URL.Query().Get("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("page must be a positive integer")
+		}
+	}
+	if v := r.URL.Query().Get("per_page"); v != "" {
+		perPage, err = strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return 0, 0, fmt.Errorf("per_page must be between 1 and %d", maxPerPage)
+		}
+	}
+
+	return page, perPage, nil
+}
+
+// decodeJSON reads a JSON request body into v, rejecting unknown fields
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("request body is empty")
+		}
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("request body must contain a single JSON object")
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// CreateScheduleRequest is the body of POST /api/v1/schedules
+type CreateScheduleRequest struct {
+	Kind           string `json:"kind"`            // "transfer" or "sync"
+	SourceProvider string `json:"source_provider"` // provider slug
+	SourceAccount  string `json:"source_account,omitempty"`
+	TargetProvider string `json:"target_provider"` // provider slug
+	TargetAccount  string `json:"target_account,omitempty"`
+	PlaylistID     string `json:"playlist_id"`
+	Spec           string `json:"spec"` // cron expression, e.g. "0 3 * * *", "@daily" or "@every 6h"
+}
+
+// UpdateScheduleRequest is the body of PATCH /api/v1/schedules/{id}
+type UpdateScheduleRequest struct {
+	Spec    string `json:"spec,omitempty"`    // omit to keep the current spec
+	Enabled *bool  `json:"enabled,omitempty"` // omit to keep the current setting
+}
+
+// listSchedules handles GET /api/v1/schedules
+func (a *API) listSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := a.scheduleService.ListSchedules(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "failed to list schedules")
+		return
+	}
+
+	writeList(w, r, schedules)
+}
+
+// createSchedule handles POST /api/v1/schedules
+func (a *API) createSchedule(w http.ResponseWriter, r *http.Request) {
+	var body CreateScheduleRequest
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+	if body.SourceProvider == "" || body.TargetProvider == "" || body.PlaylistID == "" || body.Spec == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "source_provider, target_provider, playlist_id and spec are required")
+		return
+	}
+	if body.Kind != models.ScheduleTransfer && body.Kind != models.ScheduleSync {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "kind must be transfer or sync")
+		return
+	}
+
+	source, err := a.transferService.GetProviderBySlug(body.SourceProvider)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "source "+err.Error())
+		return
+	}
+	target, err := a.transferService.GetProviderBySlug(body.TargetProvider)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "target "+err.Error())
+		return
+	}
+
+	schedule, err := a.scheduleService.CreateSchedule(services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		SourceProvider: source.Name(),
+		SourceAccount:  body.SourceAccount,
+		TargetProvider: target.Name(),
+		TargetAccount:  body.TargetAccount,
+		PlaylistID:     body.PlaylistID,
+	}, body.Kind, body.Spec)
+	if errors.Is(err, services.ErrScheduleLimit) {
+		writeError(w, http.StatusConflict, CodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+
+	writeData(w, http.StatusCreated, schedule)
+}
+
+// getSchedule handles GET /api/v1/schedules/{id}
+func (a *API) getSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := a.scheduleService.GetSchedule(middleware.UserIDFromContext(r.Context()), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	writeData(w, http.StatusOK, schedule)
+}
+
+// updateSchedule handles PATCH /api/v1/schedules/{id}
+func (a *API) updateSchedule(w http.ResponseWriter, r *http.Request) {
+	var body UpdateScheduleRequest
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	id := r.PathValue("id")
+	if _, err := a.scheduleService.GetSchedule(userID, id); err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+	schedule, err := a.scheduleService.UpdateSchedule(userID, id, services.ScheduleUpdate{Spec: body.Spec, Enabled: body.Enabled})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+
+	writeData(w, http.StatusOK, schedule)
+}
+
+// runSchedule handles POST /api/v1/schedules/{id}/run
+func (a *API) runSchedule(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	id := r.PathValue("id")
+
+	if _, err := a.scheduleService.GetSchedule(userID, id); err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+	run, err := a.scheduleService.RunSchedule(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, http.StatusConflict, CodeConflict, err.Error())
+		return
+	}
+
+	// A failed run is still recorded, and reported like any other
+	writeData(w, http.StatusOK, run)
+}
+
+// listScheduleRuns handles GET /api/v1/schedules/{id}/runs
+func (a *API) listScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := a.scheduleService.ListRuns(middleware.UserIDFromContext(r.Context()), r.PathValue("id"), 0)
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	writeList(w, r, runs)
+}
+
+// deleteSchedule handles DELETE /api/v1/schedules/{id}
+func (a *API) deleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := a.scheduleService.DeleteSchedule(middleware.UserIDFromContext(r.Context()), r.PathValue("id")); err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
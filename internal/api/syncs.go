@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// CreateSyncRequest is the body of POST /api/v1/syncs
+type CreateSyncRequest struct {
+	SourceProvider string `json:"source_provider"` // provider slug
+	SourceAccount  string `json:"source_account,omitempty"`
+	TargetProvider string `json:"target_provider"` // provider slug
+	TargetAccount  string `json:"target_account,omitempty"`
+	PlaylistID     string `json:"playlist_id"`
+	TwoWay         bool   `json:"two_way,omitempty"`         // also apply the target's edits to the source
+	ConflictPolicy string `json:"conflict_policy,omitempty"` // "manual", "source" or "target"; two-way links only
+}
+
+// ResolveConflictRequest is the body of POST /api/v1/syncs/{id}/conflicts/{conflict}/resolve
+type ResolveConflictRequest struct {
+	Keep string `json:"keep"` // side whose edit wins: "source" or "target"
+}
+
+// listSyncs handles GET /api/v1/syncs
+func (a *API) listSyncs(w http.ResponseWriter, r *http.Request) {
+	links, err := a.transferService.ListSyncLinks(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "failed to list sync links")
+		return
+	}
+
+	writeList(w, r, links)
+}
+
+// createSync handles POST /api/v1/syncs
+func (a *API) createSync(w http.ResponseWriter, r *http.Request) {
+	var body CreateSyncRequest
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+	if body.SourceProvider == "" || body.TargetProvider == "" || body.PlaylistID == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "source_provider, target_provider and playlist_id are required")
+		return
+	}
+	if body.ConflictPolicy != "" && (!body.TwoWay || !models.ValidConflictPolicy(body.ConflictPolicy)) {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "conflict_policy must be manual, source or target and needs two_way")
+		return
+	}
+
+	source, err := a.transferService.GetProviderBySlug(body.SourceProvider)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "source "+err.Error())
+		return
+	}
+	target, err := a.transferService.GetProviderBySlug(body.TargetProvider)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "target "+err.Error())
+		return
+	}
+
+	result, err := a.transferService.CreateSyncLink(r.Context(), services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		SourceProvider: source.Name(),
+		SourceAccount:  body.SourceAccount,
+		TargetProvider: target.Name(),
+		TargetAccount:  body.TargetAccount,
+		PlaylistID:     body.PlaylistID,
+	}, services.SyncOptions{TwoWay: body.TwoWay, ConflictPolicy: body.ConflictPolicy})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, CodeProvider, "failed to create sync link: "+err.Error())
+		return
+	}
+
+	writeData(w, http.StatusCreated, result)
+}
+
+// getSync handles GET /api/v1/syncs/{id}
+func (a *API) getSync(w http.ResponseWriter, r *http.Request) {
+	link, err := a.transferService.GetSyncLink(middleware.UserIDFromContext(r.Context()), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	writeData(w, http.StatusOK, link)
+}
+
+// runSync handles POST /api/v1/syncs/{id}/run
+func (a *API) runSync(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	id := r.PathValue("id")
+
+	if _, err := a.transferService.GetSyncLink(userID, id); err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+	result, err := a.transferService.RunSync(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, CodeProvider, "sync failed: "+err.Error())
+		return
+	}
+
+	writeData(w, http.StatusOK, result)
+}
+
+// resolveSyncConflict handles POST /api/v1/syncs/{id}/conflicts/{conflict}/resolve
+func (a *API) resolveSyncConflict(w http.ResponseWriter, r *http.Request) {
+	var body ResolveConflictRequest
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+	if body.Keep != models.SyncSideSource && body.Keep != models.SyncSideTarget {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "keep must be source or target")
+		return
+	}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	id := r.PathValue("id")
+	conflictID := r.PathValue("conflict")
+	link, err := a.transferService.GetSyncLink(userID, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+	if !slices.ContainsFunc(link.Conflicts, func(c models.SyncConflict) bool { return c.ID == conflictID }) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "sync conflict not found: "+conflictID)
+		return
+	}
+
+	result, err := a.transferService.ResolveSyncConflict(r.Context(), userID, id, conflictID, body.Keep)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, CodeProvider, "sync failed: "+err.Error())
+		return
+	}
+
+	writeData(w, http.StatusOK, result)
+}
+
+// deleteSync handles DELETE /api/v1/syncs/{id}
+func (a *API) deleteSync(w http.ResponseWriter, r *http.Request) {
+	if err := a.transferService.DeleteSyncLink(middleware.UserIDFromContext(r.Context()), r.PathValue("id")); err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
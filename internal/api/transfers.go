@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// CreateTransferRequest is the body of POST /api/v1/transfers
+type CreateTransferRequest struct {
+	SourceProvider string   `json:"source_provider"` // provider slug
+	SourceAccount  string   `json:"source_account,omitempty"`
+	TargetProvider string   `json:"target_provider"` // provider slug
+	TargetAccount  string   `json:"target_account,omitempty"`
+	Mode           string   `json:"mode,omitempty"`         // "playlist" (default), "albums", "artists", "library" or "batch"
+	PlaylistID     string   `json:"playlist_id"`            // required in playlist mode
+	PlaylistIDs    []string `json:"playlist_ids,omitempty"` // required in batch mode
+}
+
+// createTransfer handles POST /api/v1/transfers
+func (a *API) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var body CreateTransferRequest
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		return
+	}
+	if !services.ValidMode(body.Mode) {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "mode must be playlist, albums, artists, library or batch")
+		return
+	}
+	if body.SourceProvider == "" || body.TargetProvider == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "source_provider and target_provider are required")
+		return
+	}
+	if (body.Mode == "" || body.Mode == services.ModePlaylist) && body.PlaylistID == "" {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "playlist_id is required to transfer a playlist")
+		return
+	}
+	if body.Mode == services.ModeBatch && len(body.PlaylistIDs) == 0 {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "playlist_ids is required to transfer a batch")
+		return
+	}
+
+	source, err := a.transferService.GetProviderBySlug(body.SourceProvider)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "source "+err.Error())
+		return
+	}
+	target, err := a.transferService.GetProviderBySlug(body.TargetProvider)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "target "+err.Error())
+		return
+	}
+
+	progress, err := a.transferService.StartTransfer(services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		SourceProvider: source.Name(),
+		SourceAccount:  body.SourceAccount,
+		TargetProvider: target.Name(),
+		TargetAccount:  body.TargetAccount,
+		Mode:           body.Mode,
+		PlaylistID:     body.PlaylistID,
+		PlaylistIDs:    body.PlaylistIDs,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+
+	writeData(w, http.StatusAccepted, progress)
+}
+
+// listTransfers handles GET /api/v1/transfers
+func (a *API) listTransfers(w http.ResponseWriter, r *http.Request) {
+	writeList(w, r, a.transferService.ListTransfers(middleware.UserIDFromContext(r.Context())))
+}
+
+// getTransfer handles GET /api/v1/transfers/{id}
+func (a *API) getTransfer(w http.ResponseWriter, r *http.Request) {
+	progress, err := a.transferService.GetTransfer(middleware.UserIDFromContext(r.Context()), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	writeData(w, http.StatusOK, progress)
+}
+
+// cancelTransfer handles POST /api/v1/transfers/{id}/cancel
+func (a *API) cancelTransfer(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	id := r.PathValue("id")
+
+	progress, err := a.transferService.GetTransfer(userID, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+	if err := a.transferService.CancelTransfer(userID, id); err != nil {
+		writeError(w, http.StatusConflict, CodeConflict, err.Error())
+		return
+	}
+
+	// Cancellation completes asynchronously; return the state as of now
+	progress, _ = a.transferService.GetTransfer(userID, id)
+	writeData(w, http.StatusOK, progress)
+}
+
+// getTransferReport handles GET /api/v1/transfers/{id}/report
+func (a *API) getTransferReport(w http.ResponseWriter, r *http.Request) {
+	report, err := a.transferService.GetTransferReport(middleware.UserIDFromContext(r.Context()), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return
+	}
+
+	writeData(w, http.StatusOK, report)
+}
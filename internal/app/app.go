@@ -0,0 +1,251 @@
+// Package app assembles PlayPort's storage backends and providers from the
+// configuration, so the server and the command-line tool share one setup.
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/config"
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/providers/applemusic"
+	"github.com/JanikSachs/PlayPort/internal/providers/deezer"
+	"github.com/JanikSachs/PlayPort/internal/providers/jellyfin"
+	"github.com/JanikSachs/PlayPort/internal/providers/localmusic"
+	"github.com/JanikSachs/PlayPort/internal/providers/m3u"
+	"github.com/JanikSachs/PlayPort/internal/providers/soundcloud"
+	"github.com/JanikSachs/PlayPort/internal/providers/spotify"
+	"github.com/JanikSachs/PlayPort/internal/providers/subsonic"
+	"github.com/JanikSachs/PlayPort/internal/providers/tidal"
+	"github.com/JanikSachs/PlayPort/internal/providers/youtubemusic"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// Stores holds the storage backends selected by STORAGE_DRIVER
+type Stores struct {
+	Connections storage.ConnectionStore
+	Users       storage.UserStore
+	Audit       storage.AuditStore
+	Overrides   storage.MatchOverrideStore
+	SyncLinks   storage.SyncLinkStore
+	Schedules   storage.ScheduleStore
+	State       auth.StateStore
+	Sessions    auth.SessionStore
+	APITokens   auth.APITokenStore
+
+	db *database.DB // nil for in-memory storage
+}
+
+// OpenStores opens and migrates the configured database, or creates
+// in-memory stores when STORAGE_DRIVER is "memory"
+func OpenStores(cfg *config.Config) (*Stores, error) {
+	if err := cfg.ValidateStorage(); err != nil {
+		return nil, err
+	}
+
+	if cfg.StorageDriver == "memory" {
+		return &Stores{
+			Connections: storage.NewInMemoryConnectionStore(),
+			Users:       storage.NewInMemoryUserStore(),
+			Audit:       storage.NewInMemoryAuditStore(),
+			Overrides:   storage.NewInMemoryMatchOverrideStore(),
+			SyncLinks:   storage.NewInMemorySyncLinkStore(),
+			Schedules:   storage.NewInMemoryScheduleStore(),
+			State:       auth.NewInMemoryStateStore(),
+			Sessions:    auth.NewInMemorySessionStore(0),
+			APITokens:   auth.NewInMemoryAPITokenStore(),
+		}, nil
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Stores{
+		Connections: storage.NewSQLConnectionStore(db),
+		Users:       storage.NewSQLUserStore(db),
+		Audit:       storage.NewSQLAuditStore(db),
+		Overrides:   storage.NewSQLMatchOverrideStore(db),
+		SyncLinks:   storage.NewSQLSyncLinkStore(db),
+		Schedules:   storage.NewSQLScheduleStore(db),
+		State:       auth.NewSQLStateStore(db),
+		Sessions:    auth.NewSQLSessionStore(db, 0),
+		APITokens:   auth.NewSQLAPITokenStore(db),
+		db:          db,
+	}, nil
+}
+
+// Close closes the database, if any
+func (s *Stores) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// openDatabase opens the SQL database selected by the storage configuration
+func openDatabase(cfg *config.Config) (*database.DB, error) {
+	if cfg.StorageDriver == "postgres" {
+		return database.OpenPostgres(cfg.DatabaseURL, database.PoolConfig{
+			MaxOpenConns:    cfg.DBMaxOpenConns,
+			MaxIdleConns:    cfg.DBMaxIdleConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		})
+	}
+	return database.OpenSQLite(cfg.DatabaseURL)
+}
+
+// oauthSettings is what enables the providers that log in through OAuth or
+// MusicKit, for the registry's logs
+const oauthSettings = "environment variables"
+
+// NewTransferService creates a transfer service with the mock provider and
+// every provider whose credentials or directory are configured. It also
+// returns the registry of the providers users can link accounts to, in the
+// order they are listed, with the ones the configuration leaves disabled.
+func NewTransferService(cfg *config.Config, stores *Stores) (*services.TransferService, []providers.Registration, error) {
+	spotifyEnabled, err := cfg.ValidateSpotify()
+	if err != nil {
+		return nil, nil, err
+	}
+	youtubeMusicEnabled, err := cfg.ValidateYouTubeMusic()
+	if err != nil {
+		return nil, nil, err
+	}
+	deezerEnabled, err := cfg.ValidateDeezer()
+	if err != nil {
+		return nil, nil, err
+	}
+	tidalEnabled, err := cfg.ValidateTidal()
+	if err != nil {
+		return nil, nil, err
+	}
+	subsonicEnabled, err := cfg.ValidateSubsonic()
+	if err != nil {
+		return nil, nil, err
+	}
+	jellyfinEnabled, err := cfg.ValidateJellyfin()
+	if err != nil {
+		return nil, nil, err
+	}
+	appleMusicEnabled, err := cfg.ValidateAppleMusic()
+	if err != nil {
+		return nil, nil, err
+	}
+	soundCloudEnabled, err := cfg.ValidateSoundCloud()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transferService := services.NewTransferService()
+	transferService.SetMatchOverrideStore(stores.Overrides)
+	transferService.SetSyncLinkStore(stores.SyncLinks)
+	transferService.SetBatchLimits(cfg.TransferWorkers, cfg.TransferProviderConcurrency)
+	transferService.RegisterProvider(providers.NewMockProvider())
+
+	registry := []providers.Registration{
+		{Slug: "spotify", Name: "Spotify", Login: providers.LoginOAuth, Requires: oauthSettings, Enabled: spotifyEnabled},
+		{Slug: "youtubemusic", Name: "YouTube Music", Login: providers.LoginOAuth, Requires: oauthSettings, Enabled: youtubeMusicEnabled},
+		{Slug: "deezer", Name: "Deezer", Login: providers.LoginOAuth, Requires: oauthSettings, Enabled: deezerEnabled},
+		{Slug: "tidal", Name: "Tidal", Login: providers.LoginOAuth, Requires: oauthSettings, Enabled: tidalEnabled},
+		{Slug: "applemusic", Name: "Apple Music", Login: providers.LoginMusicKit, Requires: oauthSettings, Enabled: appleMusicEnabled},
+		{Slug: "soundcloud", Name: "SoundCloud", Login: providers.LoginOAuth, Requires: oauthSettings, Enabled: soundCloudEnabled},
+		{Slug: "subsonic", Name: "Subsonic", Login: providers.LoginCredentials, Requires: "SUBSONIC_URL", Enabled: subsonicEnabled},
+		{Slug: "jellyfin", Name: "Jellyfin", Login: providers.LoginCredentials, Requires: "JELLYFIN_URL", Enabled: jellyfinEnabled},
+	}
+	for i := range registry {
+		if !registry[i].Enabled {
+			continue
+		}
+		provider, err := newProvider(cfg, stores, registry[i].Slug)
+		if err != nil {
+			return nil, nil, err
+		}
+		registry[i].Provider = provider
+		transferService.RegisterProvider(provider)
+	}
+
+	if cfg.M3UDir != "" {
+		transferService.RegisterProvider(m3u.NewM3UProvider(cfg.M3UDir))
+	}
+
+	if cfg.MusicDir != "" {
+		transferService.RegisterProvider(localmusic.NewProvider(cfg.MusicDir))
+	}
+
+	return transferService, registry, nil
+}
+
+// newProvider creates the registry's provider with the given slug from the
+// configuration
+func newProvider(cfg *config.Config, stores *Stores, slug string) (providers.Provider, error) {
+	switch slug {
+	case "spotify":
+		return spotify.NewSpotifyProvider(
+			cfg.SpotifyClientID,
+			cfg.SpotifyClientSecret,
+			cfg.SpotifyRedirectURL,
+			stores.Connections,
+		), nil
+	case "youtubemusic":
+		return youtubemusic.NewYouTubeMusicProvider(
+			cfg.YouTubeMusicClientID,
+			cfg.YouTubeMusicClientSecret,
+			cfg.YouTubeMusicRedirectURL,
+			stores.Connections,
+		), nil
+	case "deezer":
+		return deezer.NewDeezerProvider(
+			cfg.DeezerAppID,
+			cfg.DeezerSecretKey,
+			cfg.DeezerRedirectURL,
+			stores.Connections,
+		), nil
+	case "tidal":
+		return tidal.NewTidalProvider(
+			cfg.TidalClientID,
+			cfg.TidalClientSecret,
+			cfg.TidalRedirectURL,
+			stores.Connections,
+		), nil
+	case "applemusic":
+		privateKey, err := os.ReadFile(cfg.AppleMusicPrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read APPLE_MUSIC_PRIVATE_KEY_PATH: %w", err)
+		}
+		return applemusic.NewAppleMusicProvider(
+			cfg.AppleMusicTeamID,
+			cfg.AppleMusicKeyID,
+			privateKey,
+			stores.Connections,
+		)
+	case "soundcloud":
+		return soundcloud.NewSoundCloudProvider(
+			cfg.SoundCloudClientID,
+			cfg.SoundCloudClientSecret,
+			cfg.SoundCloudRedirectURL,
+			stores.Connections,
+		), nil
+	case "subsonic":
+		return subsonic.NewSubsonicProvider(cfg.SubsonicURL, stores.Connections), nil
+	case "jellyfin":
+		return jellyfin.NewJellyfinProvider(cfg.JellyfinURL, stores.Connections), nil
+	}
+	return nil, fmt.Errorf("unknown provider: %s", slug)
+}
+
+// NewScheduleService creates a schedule service running transfers and syncs
+// with transferService, within the configured limits
+func NewScheduleService(cfg *config.Config, stores *Stores, transferService *services.TransferService) *services.ScheduleService {
+	scheduleService := services.NewScheduleService(stores.Schedules, transferService)
+	scheduleService.SetLimits(cfg.ScheduleMaxPerUser, cfg.ScheduleMinInterval, cfg.ScheduleJitter)
+	return scheduleService
+}
@@ -0,0 +1,93 @@
+// Package audiotags reads the metadata PlayPort needs from audio files:
+// ID3v2 tags in MP3 files, Vorbis comments in FLAC files and iTunes-style
+// metadata in MP4/M4A files.
+package audiotags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned for files of a type Read does not understand
+var ErrUnsupported = errors.New("unsupported audio file type")
+
+// Tags are the metadata of an audio file. Fields the file does not carry
+// are left empty.
+type Tags struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	ISRC        string
+	TrackNumber int
+	DiscNumber  int
+	Duration    time.Duration
+}
+
+// readers maps file extensions to the reader of their format
+var readers = map[string]func(r io.ReadSeeker, size int64) (Tags, error){
+	".mp3":  readMP3,
+	".flac": readFLAC,
+	".m4a":  readMP4,
+	".mp4":  readMP4,
+	".m4b":  readMP4,
+}
+
+// Supported reports whether Read understands files with path's extension
+func Supported(path string) bool {
+	_, ok := readers[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Read reads the tags of the audio file at path. The format is chosen by
+// the file extension.
+func Read(path string) (Tags, error) {
+	read, ok := readers[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return Tags{}, ErrUnsupported
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Tags{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Tags{}, err
+	}
+
+	tags, err := read(f, info.Size())
+	if err != nil {
+		return Tags{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return tags, nil
+}
+
+// setNumber parses a "3" or "3/12" style track or disc number
+func setNumber(dst *int, value string) {
+	value, _, _ = strings.Cut(strings.TrimSpace(value), "/")
+	if n, err := strconv.Atoi(value); err == nil && n > 0 {
+		*dst = n
+	}
+}
+
+// appendValue adds another value to a field that may hold several, such as
+// the artists of a track
+func appendValue(dst *string, value string) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+	case *dst == "":
+		*dst = value
+	default:
+		*dst += ", " + value
+	}
+}
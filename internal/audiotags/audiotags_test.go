@@ -0,0 +1,257 @@
+package audiotags
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// writeFile writes data to a temporary file with the given name
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	return path
+}
+
+// id3Tag builds an ID3v2 tag of the given version from frame ID/data pairs
+func id3Tag(version byte, frames ...[]byte) []byte {
+	var body []byte
+	for _, f := range frames {
+		body = append(body, f...)
+	}
+	size := len(body)
+	header := []byte{'I', 'D', '3', version, 0, 0,
+		byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(header, body...)
+}
+
+// id3Frame builds an ID3v2.3 (plain size) or v2.4 (syncsafe size) text frame
+func id3Frame(version byte, id string, encoding byte, text []byte) []byte {
+	data := append([]byte{encoding}, text...)
+	size := len(data)
+	frame := []byte(id)
+	if version == 4 {
+		frame = append(frame, byte(size>>21&0x7f), byte(size>>14&0x7f), byte(size>>7&0x7f), byte(size&0x7f))
+	} else {
+		frame = binary.BigEndian.AppendUint32(frame, uint32(size))
+	}
+	frame = append(frame, 0, 0)
+	return append(frame, data...)
+}
+
+// utf16WithBOM encodes s as little-endian UTF-16 with a byte order mark
+func utf16WithBOM(s string) []byte {
+	b := []byte{0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// mpegFrame is the header of an MPEG-1 Layer III frame at 128 kbit/s,
+// 44.1 kHz, stereo
+var mpegFrame = []byte{0xff, 0xfb, 0x90, 0x64}
+
+func TestRead_MP3WithXingHeader(t *testing.T) {
+	tag := id3Tag(3,
+		id3Frame(3, "TIT2", 1, utf16WithBOM("Déjà Vu")),
+		id3Frame(3, "TPE1", 0, []byte("Beyonc\xe9")),
+		id3Frame(3, "TALB", 0, []byte("B'Day")),
+		id3Frame(3, "TSRC", 0, []byte("usqx90600001")),
+		id3Frame(3, "TRCK", 0, []byte("3/12")),
+		id3Frame(3, "TXXX", 0, []byte("ignored")),
+	)
+	frame := append(append([]byte{}, mpegFrame...), make([]byte, 32)...) // side info
+	frame = append(frame, "Xing"...)
+	frame = binary.BigEndian.AppendUint32(frame, 1) // frame count present
+	frame = binary.BigEndian.AppendUint32(frame, 1000)
+	frame = append(frame, make([]byte, 400)...)
+
+	tags, err := Read(writeFile(t, "song.MP3", append(tag, frame...)))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	want := Tags{Title: "Déjà Vu", Artist: "Beyoncé", Album: "B'Day", ISRC: "USQX90600001", TrackNumber: 3,
+		Duration: 1000 * 1152 * time.Second / 44100}
+	if tags != want {
+		t.Errorf("Read() = %+v, want %+v", tags, want)
+	}
+}
+
+func TestRead_MP3ID3v24(t *testing.T) {
+	tag := id3Tag(4,
+		id3Frame(4, "TIT2", 3, []byte("Under Pressure")),
+		id3Frame(4, "TPE1", 3, []byte("Queen\x00David Bowie")),
+		id3Frame(4, "TPE2", 3, []byte("Queen")),
+		id3Frame(4, "TLEN", 3, []byte("248000")),
+		id3Frame(4, "TPOS", 3, []byte("2")),
+	)
+	tags, err := Read(writeFile(t, "song.mp3", tag))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	want := Tags{Title: "Under Pressure", Artist: "Queen, David Bowie", AlbumArtist: "Queen", DiscNumber: 2,
+		Duration: 248 * time.Second}
+	if tags != want {
+		t.Errorf("Read() = %+v, want %+v", tags, want)
+	}
+}
+
+func TestRead_MP3ConstantBitrate(t *testing.T) {
+	// Without a tag or VBR header the duration follows from the bitrate:
+	// 16000 bytes at 128 kbit/s are one second
+	data := append(append([]byte{}, mpegFrame...), make([]byte, 16000-len(mpegFrame))...)
+
+	tags, err := Read(writeFile(t, "untagged.mp3", data))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if tags.Duration != time.Second || tags.Title != "" {
+		t.Errorf("Read() = %+v, want only a 1s duration", tags)
+	}
+}
+
+// flacBlock builds a FLAC metadata block
+func flacBlock(blockType byte, last bool, data []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	n := len(data)
+	return append([]byte{blockType, byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+}
+
+// vorbisComment builds a Vorbis comment block from KEY=value fields
+func vorbisComment(fields ...string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, 6)
+	b = append(b, "PlayPt"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(fields)))
+	for _, f := range fields {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(f)))
+		b = append(b, f...)
+	}
+	return b
+}
+
+func TestRead_FLAC(t *testing.T) {
+	streamInfo := make([]byte, 34)
+	rate, samples := 44100, 441000*3 // 30 seconds
+	streamInfo[10] = byte(rate >> 12)
+	streamInfo[11] = byte(rate >> 4)
+	streamInfo[12] = byte(rate<<4) | 0x02 // two channels
+	binary.BigEndian.PutUint32(streamInfo[14:18], uint32(samples))
+
+	data := []byte("fLaC")
+	data = append(data, flacBlock(0, false, streamInfo)...)
+	data = append(data, flacBlock(6, false, make([]byte, 5000))...) // picture
+	data = append(data, flacBlock(4, true, vorbisComment(
+		"title=Clair de Lune",
+		"ARTIST=Claude Debussy",
+		"Artist=Isao Tomita",
+		"ALBUM=Snowflakes Are Dancing",
+		"ALBUMARTIST=Isao Tomita",
+		"ISRC=jpb601400123",
+		"TRACKNUMBER=01",
+		"DISCNUMBER=1/1",
+		"COMMENT=no equals sign is fine",
+		"broken",
+	))...)
+	data = append(data, make([]byte, 100)...) // audio frames
+
+	tags, err := Read(writeFile(t, "track.flac", data))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	want := Tags{Title: "Clair de Lune", Artist: "Claude Debussy, Isao Tomita", Album: "Snowflakes Are Dancing",
+		AlbumArtist: "Isao Tomita", ISRC: "JPB601400123", TrackNumber: 1, DiscNumber: 1, Duration: 30 * time.Second}
+	if tags != want {
+		t.Errorf("Read() = %+v, want %+v", tags, want)
+	}
+
+	if _, err := Read(writeFile(t, "fake.flac", []byte("RIFF...."))); err == nil {
+		t.Error("Read() should reject files without the FLAC signature")
+	}
+}
+
+// atom builds an MP4 atom
+func atom(kind string, contents ...[]byte) []byte {
+	var body []byte
+	for _, c := range contents {
+		body = append(body, c...)
+	}
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	b = append(b, kind...)
+	return append(b, body...)
+}
+
+// dataAtom builds the data atom of an ilst item
+func dataAtom(value []byte) []byte {
+	return atom("data", []byte{0, 0, 0, 1, 0, 0, 0, 0}, value)
+}
+
+func TestRead_MP4(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 600)       // timescale
+	binary.BigEndian.PutUint32(mvhd[16:20], 600*215+3) // duration
+
+	ilst := atom("ilst",
+		atom("\xa9nam", dataAtom([]byte("Hey Ya!"))),
+		atom("\xa9ART", dataAtom([]byte("OutKast"))),
+		atom("\xa9alb", dataAtom([]byte("Speakerboxxx/The Love Below"))),
+		atom("trkn", dataAtom([]byte{0, 0, 0, 9, 0, 20, 0, 0})),
+		atom("disk", dataAtom([]byte{0, 0, 0, 2, 0, 2})),
+		atom("----",
+			atom("mean", []byte{0, 0, 0, 0}, []byte("com.apple.iTunes")),
+			atom("name", []byte{0, 0, 0, 0}, []byte("ISRC")),
+			dataAtom([]byte("usaf10300016")),
+		),
+	)
+	moov := atom("moov",
+		atom("mvhd", mvhd),
+		atom("udta", atom("meta", []byte{0, 0, 0, 0}, atom("hdlr", make([]byte, 25)), ilst)),
+	)
+
+	// Streaming-optimised files put moov first; others after the media data
+	for name, data := range map[string][]byte{
+		"moov-last.m4a":  append(append(atom("ftyp", []byte("M4A \x00\x00\x00\x00")), atom("mdat", make([]byte, 2000))...), moov...),
+		"moov-first.mp4": append(append(atom("ftyp", []byte("M4A \x00\x00\x00\x00")), moov...), atom("mdat", make([]byte, 2000))...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tags, err := Read(writeFile(t, name, data))
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+			want := Tags{Title: "Hey Ya!", Artist: "OutKast", Album: "Speakerboxxx/The Love Below", ISRC: "USAF10300016",
+				TrackNumber: 9, DiscNumber: 2, Duration: 215*time.Second + 5*time.Millisecond}
+			if tags != want {
+				t.Errorf("Read() = %+v, want %+v", tags, want)
+			}
+		})
+	}
+
+	if _, err := Read(writeFile(t, "empty.m4a", atom("ftyp", []byte("M4A ")))); err == nil {
+		t.Error("Read() should reject MP4 files without a moov atom")
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for path, want := range map[string]bool{
+		"a.mp3": true, "b.FLAC": true, "c.m4a": true, "d.ogg": false, "cover.jpg": false, "noext": false,
+	} {
+		if got := Supported(path); got != want {
+			t.Errorf("Supported(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if _, err := Read("cover.jpg"); err != ErrUnsupported {
+		t.Errorf("Read() of an unsupported file = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,135 @@
+package audiotags
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FLAC metadata block types
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+// maxFLACBlock limits the metadata blocks read into memory; larger ones,
+// such as embedded pictures, are skipped
+const maxFLACBlock = 1 << 20
+
+// readFLAC reads the STREAMINFO and VORBIS_COMMENT blocks of a FLAC file.
+// A leading ID3v2 tag, which some taggers add, is skipped.
+func readFLAC(r io.ReadSeeker, size int64) (Tags, error) {
+	var ignored Tags
+	offset, err := readID3v2(r, &ignored)
+	if err != nil {
+		return Tags{}, err
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return Tags{}, err
+	}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil || string(magic[:]) != "fLaC" {
+		return Tags{}, fmt.Errorf("not a FLAC file")
+	}
+
+	var tags Tags
+	for last := false; !last; {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return Tags{}, fmt.Errorf("truncated FLAC metadata: %w", err)
+		}
+		last = header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if (blockType != flacStreamInfo && blockType != flacVorbisComment) || length > maxFLACBlock {
+			if _, err := r.Seek(length, io.SeekCurrent); err != nil {
+				return Tags{}, err
+			}
+			continue
+		}
+
+		block := make([]byte, length)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return Tags{}, fmt.Errorf("truncated FLAC metadata: %w", err)
+		}
+		if blockType == flacStreamInfo {
+			tags.Duration = flacDuration(block)
+		} else {
+			parseVorbisComment(block, &tags)
+		}
+	}
+
+	return tags, nil
+}
+
+// flacDuration computes the playing time from a STREAMINFO block
+func flacDuration(block []byte) time.Duration {
+	if len(block) < 18 {
+		return 0
+	}
+	sampleRate := int64(block[10])<<12 | int64(block[11])<<4 | int64(block[12])>>4
+	samples := int64(block[13]&0x0f)<<32 | int64(binary.BigEndian.Uint32(block[14:18]))
+	if sampleRate == 0 {
+		return 0
+	}
+	return time.Duration(samples * int64(time.Second) / sampleRate)
+}
+
+// parseVorbisComment reads the KEY=value fields of a Vorbis comment block.
+// Keys are case-insensitive and may repeat, for example one ARTIST per artist.
+func parseVorbisComment(block []byte, tags *Tags) {
+	next := func() ([]byte, bool) {
+		if len(block) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(block[:4])
+		if int64(n) > int64(len(block)-4) {
+			return nil, false
+		}
+		field := block[4 : 4+n]
+		block = block[4+n:]
+		return field, true
+	}
+
+	if _, ok := next(); !ok { // vendor string
+		return
+	}
+	if len(block) < 4 {
+		return
+	}
+	count := binary.LittleEndian.Uint32(block[:4])
+	block = block[4:]
+
+	for i := uint32(0); i < count; i++ {
+		field, ok := next()
+		if !ok {
+			break
+		}
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			appendValue(&tags.Title, value)
+		case "ARTIST":
+			appendValue(&tags.Artist, value)
+		case "ALBUM":
+			appendValue(&tags.Album, value)
+		case "ALBUMARTIST", "ALBUM ARTIST":
+			appendValue(&tags.AlbumArtist, value)
+		case "ISRC":
+			if tags.ISRC == "" {
+				tags.ISRC = strings.ToUpper(strings.TrimSpace(value))
+			}
+		case "TRACKNUMBER":
+			setNumber(&tags.TrackNumber, value)
+		case "DISCNUMBER":
+			setNumber(&tags.DiscNumber, value)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package audiotags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// id3Frames maps ID3v2.3/2.4 text frame IDs to the tags they fill
+var id3Frames = map[string]func(t *Tags, value string){
+	"TIT2": func(t *Tags, v string) { t.Title = v },
+	"TPE1": func(t *Tags, v string) { t.Artist = v },
+	"TALB": func(t *Tags, v string) { t.Album = v },
+	"TPE2": func(t *Tags, v string) { t.AlbumArtist = v },
+	"TSRC": func(t *Tags, v string) { t.ISRC = strings.ToUpper(strings.TrimSpace(v)) },
+	"TRCK": func(t *Tags, v string) { setNumber(&t.TrackNumber, v) },
+	"TPOS": func(t *Tags, v string) { setNumber(&t.DiscNumber, v) },
+	"TLEN": func(t *Tags, v string) {
+		if ms, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && ms > 0 {
+			t.Duration = time.Duration(ms) * time.Millisecond
+		}
+	},
+}
+
+// id3v22Frames maps ID3v2.2 frame IDs to their ID3v2.3 names
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2", "TP1": "TPE1", "TAL": "TALB", "TP2": "TPE2",
+	"TRC": "TSRC", "TRK": "TRCK", "TPA": "TPOS", "TLE": "TLEN",
+}
+
+// readMP3 reads the ID3v2 tag at the start of an MP3 file and works out
+// the duration from the MPEG frames if the tag does not give it
+func readMP3(r io.ReadSeeker, size int64) (Tags, error) {
+	var tags Tags
+	tagSize, err := readID3v2(r, &tags)
+	if err != nil {
+		return Tags{}, err
+	}
+
+	if tags.Duration == 0 {
+		if _, err := r.Seek(tagSize, io.SeekStart); err != nil {
+			return Tags{}, err
+		}
+		tags.Duration = mpegDuration(r, size-tagSize)
+	}
+	return tags, nil
+}
+
+// readID3v2 reads an ID3v2 tag at the current position into tags and
+// returns the tag's total size, or 0 if there is no tag
+func readID3v2(r io.Reader, tags *Tags) (int64, error) {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if string(header[:3]) != "ID3" {
+		return 0, nil
+	}
+
+	version, flags := header[3], header[5]
+	size := syncsafe(header[6:10])
+	if version < 2 || version > 4 {
+		return 10 + size, nil // unknown version; skip the tag
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, fmt.Errorf("truncated ID3v2 tag: %w", err)
+	}
+	if flags&0x80 != 0 && version < 4 {
+		body = unsynchronise(body)
+	}
+	if flags&0x40 != 0 && version > 2 {
+		body = skipExtendedHeader(body, version)
+	}
+
+	parseID3Frames(body, version, tags)
+	return 10 + size, nil
+}
+
+// parseID3Frames reads the text frames of an ID3v2 tag body
+func parseID3Frames(body []byte, version byte, tags *Tags) {
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	for len(body) >= headerLen && body[0] != 0 {
+		id := string(body[:idLen])
+		var size int64
+		var frameFlags uint16
+		switch version {
+		case 2:
+			size = int64(body[3])<<16 | int64(body[4])<<8 | int64(body[5])
+		case 3:
+			size = int64(binary.BigEndian.Uint32(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		default:
+			size = syncsafe(body[4:8])
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		}
+		if size < 0 || size > int64(len(body)-headerLen) {
+			return
+		}
+		data := body[headerLen : int64(headerLen)+size]
+		body = body[int64(headerLen)+size:]
+
+		if version == 2 {
+			id = id3v22Frames[id]
+		}
+		set, ok := id3Frames[id]
+		if !ok {
+			continue
+		}
+
+		data, ok = frameData(data, version, frameFlags)
+		if ok {
+			set(tags, decodeID3Text(data))
+		}
+	}
+}
+
+// frameData undoes per-frame encodings. It reports false for compressed
+// or encrypted frames, which are not worth supporting for text.
+func frameData(data []byte, version byte, flags uint16) ([]byte, bool) {
+	switch version {
+	case 3:
+		if flags&0x00c0 != 0 {
+			return nil, false
+		}
+		if flags&0x0020 != 0 && len(data) > 0 { // grouping identity
+			data = data[1:]
+		}
+	case 4:
+		if flags&0x000c != 0 {
+			return nil, false
+		}
+		if flags&0x0040 != 0 && len(data) > 0 { // grouping identity
+			data = data[1:]
+		}
+		if flags&0x0002 != 0 {
+			data = unsynchronise(data)
+		}
+		if flags&0x0001 != 0 && len(data) >= 4 { // data length indicator
+			data = data[4:]
+		}
+	}
+	return data, true
+}
+
+// decodeID3Text decodes a text frame. ID3v2.4 separates multiple values
+// with NUL; they are joined with commas.
+func decodeID3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	encoding, data := data[0], data[1:]
+
+	var text string
+	switch encoding {
+	case 0: // ISO-8859-1
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		text = decodeUTF16(data, encoding == 2)
+	default: // UTF-8
+		text = string(data)
+	}
+
+	var value string
+	for _, part := range strings.Split(text, "\x00") {
+		appendValue(&value, part)
+	}
+	return value
+}
+
+// decodeUTF16 decodes UTF-16 text, honouring byte order marks, which
+// ID3v2.4 may repeat before every value
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		switch {
+		case data[i] == 0xff && data[i+1] == 0xfe:
+			bigEndian = false
+			continue
+		case data[i] == 0xfe && data[i+1] == 0xff:
+			bigEndian = true
+			continue
+		}
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// skipExtendedHeader removes the extended header from a tag body
+func skipExtendedHeader(body []byte, version byte) []byte {
+	if len(body) < 4 {
+		return nil
+	}
+	size := int64(binary.BigEndian.Uint32(body[:4])) + 4 // v2.3 excludes the size field
+	if version == 4 {
+		size = syncsafe(body[:4]) // v2.4 includes it
+	}
+	if size > int64(len(body)) {
+		return nil
+	}
+	return body[size:]
+}
+
+// unsynchronise reverses the ID3 unsynchronisation scheme, which inserts
+// a zero byte after every 0xFF
+func unsynchronise(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// syncsafe decodes a 28-bit integer stored in four 7-bit bytes
+func syncsafe(b []byte) int64 {
+	return int64(b[0]&0x7f)<<21 | int64(b[1]&0x7f)<<14 | int64(b[2]&0x7f)<<7 | int64(b[3]&0x7f)
+}
@@ -0,0 +1,196 @@
+package audiotags
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxMP4Atom limits the atoms read into memory; only metadata atoms are read
+const maxMP4Atom = 4 << 20
+
+// mp4Atom is the header of an MP4 box
+type mp4Atom struct {
+	kind   string
+	offset int64 // of the atom's contents
+	size   int64 // of the atom's contents
+}
+
+// readMP4 reads the movie header and the iTunes metadata list
+// (moov/udta/meta/ilst) of an MP4 file. The moov atom may come before or
+// after the media data.
+func readMP4(r io.ReadSeeker, size int64) (Tags, error) {
+	moov, err := findMP4Atom(r, 0, size, "moov")
+	if err != nil {
+		return Tags{}, err
+	}
+	if moov == nil {
+		return Tags{}, fmt.Errorf("not an MP4 file: no moov atom")
+	}
+
+	var tags Tags
+	if mvhd, err := findMP4Atom(r, moov.offset, moov.size, "mvhd"); err == nil && mvhd != nil {
+		if data, err := readMP4Atom(r, mvhd); err == nil {
+			tags.Duration = mp4Duration(data)
+		}
+	}
+
+	udta, err := findMP4Atom(r, moov.offset, moov.size, "udta")
+	if err != nil || udta == nil {
+		return tags, err
+	}
+	meta, err := findMP4Atom(r, udta.offset, udta.size, "meta")
+	if err != nil || meta == nil {
+		return tags, err
+	}
+	// meta is a full atom: version and flags precede its children
+	ilst, err := findMP4Atom(r, meta.offset+4, meta.size-4, "ilst")
+	if err != nil || ilst == nil {
+		return tags, err
+	}
+	data, err := readMP4Atom(r, ilst)
+	if err != nil {
+		return Tags{}, err
+	}
+
+	parseMP4Items(data, &tags)
+	return tags, nil
+}
+
+// findMP4Atom returns the first atom of the given kind among the atoms in
+// [offset, offset+size), or nil if there is none
+func findMP4Atom(r io.ReadSeeker, offset, size int64, kind string) (*mp4Atom, error) {
+	end := offset + size
+	for offset+8 <= end {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, fmt.Errorf("truncated MP4 atom: %w", err)
+		}
+
+		atomSize := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch atomSize {
+		case 0: // extends to the end
+			atomSize = end - offset
+		case 1: // 64-bit size follows
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, fmt.Errorf("truncated MP4 atom: %w", err)
+			}
+			atomSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if atomSize < headerSize || offset+atomSize > end {
+			return nil, fmt.Errorf("invalid MP4 atom size")
+		}
+
+		if string(header[4:8]) == kind {
+			return &mp4Atom{kind: kind, offset: offset + headerSize, size: atomSize - headerSize}, nil
+		}
+		offset += atomSize
+	}
+	return nil, nil
+}
+
+// readMP4Atom reads the contents of an atom
+func readMP4Atom(r io.ReadSeeker, atom *mp4Atom) ([]byte, error) {
+	if atom.size > maxMP4Atom {
+		return nil, fmt.Errorf("MP4 %s atom too large", atom.kind)
+	}
+	if _, err := r.Seek(atom.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, atom.size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated MP4 atom: %w", err)
+	}
+	return data, nil
+}
+
+// mp4Duration computes the playing time from an mvhd atom
+func mp4Duration(mvhd []byte) time.Duration {
+	var timescale, duration uint64
+	switch {
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	case len(mvhd) >= 20:
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	}
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(duration * uint64(time.Second) / timescale)
+}
+
+// parseMP4Items reads the items of an ilst atom. Each item holds a data
+// atom; freeform "----" items also name themselves with mean and name atoms.
+func parseMP4Items(ilst []byte, tags *Tags) {
+	for _, item := range mp4Children(ilst) {
+		var value []byte
+		var freeform string
+		for _, child := range mp4Children(item.data) {
+			switch child.kind {
+			case "data":
+				if len(child.data) >= 8 { // type and locale
+					value = child.data[8:]
+				}
+			case "name":
+				if len(child.data) >= 4 { // version and flags
+					freeform = string(child.data[4:])
+				}
+			}
+		}
+		if value == nil {
+			continue
+		}
+
+		switch item.kind {
+		case "\xa9nam":
+			tags.Title = string(value)
+		case "\xa9ART":
+			tags.Artist = string(value)
+		case "\xa9alb":
+			tags.Album = string(value)
+		case "aART":
+			tags.AlbumArtist = string(value)
+		case "trkn":
+			if len(value) >= 4 {
+				tags.TrackNumber = int(binary.BigEndian.Uint16(value[2:4]))
+			}
+		case "disk":
+			if len(value) >= 4 {
+				tags.DiscNumber = int(binary.BigEndian.Uint16(value[2:4]))
+			}
+		case "----":
+			if strings.EqualFold(freeform, "ISRC") {
+				tags.ISRC = strings.ToUpper(strings.TrimSpace(string(value)))
+			}
+		}
+	}
+}
+
+// mp4Child is an atom held in memory
+type mp4Child struct {
+	kind string
+	data []byte
+}
+
+// mp4Children splits the contents of an atom into its child atoms
+func mp4Children(data []byte) []mp4Child {
+	var children []mp4Child
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[:4]))
+		if size < 8 || size > len(data) {
+			break
+		}
+		children = append(children, mp4Child{kind: string(data[4:8]), data: data[8:size]})
+		data = data[size:]
+	}
+	return children
+}
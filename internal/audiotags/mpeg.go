@@ -0,0 +1,104 @@
+package audiotags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// mpegScanBytes is how far into the audio data the first frame is looked for
+const mpegScanBytes = 64 << 10
+
+// mpegBitrates holds the bitrates in kbit/s by [MPEG-1?][layer-1][index]
+var mpegBitrates = [2][3][15]int{
+	{ // MPEG-2 and 2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+	{ // MPEG-1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+}
+
+// mpegSampleRates holds the sample rates in Hz by version bits and index
+var mpegSampleRates = map[byte][3]int{
+	0: {11025, 12000, 8000},  // MPEG-2.5
+	2: {22050, 24000, 16000}, // MPEG-2
+	3: {44100, 48000, 32000}, // MPEG-1
+}
+
+// mpegDuration estimates the playing time of MPEG audio data of the given
+// size starting at r. VBR files carry their frame count in a Xing or VBRI
+// header; otherwise the bitrate of the first frame is assumed throughout.
+// It returns 0 if no frame is found.
+func mpegDuration(r io.Reader, size int64) time.Duration {
+	buf := make([]byte, mpegScanBytes)
+	n, _ := io.ReadFull(r, buf)
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xff || buf[i+1]&0xe0 != 0xe0 {
+			continue
+		}
+
+		version := (buf[i+1] >> 3) & 3
+		layer := 4 - int((buf[i+1]>>1)&3) // 1, 2 or 3; 4 is reserved
+		bitrateIndex := int(buf[i+2] >> 4)
+		rateIndex := int((buf[i+2] >> 2) & 3)
+		rates, ok := mpegSampleRates[version]
+		if !ok || layer == 4 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+			continue
+		}
+
+		mpeg1 := 0
+		if version == 3 {
+			mpeg1 = 1
+		}
+		bitrate := mpegBitrates[mpeg1][layer-1][bitrateIndex] * 1000
+		sampleRate := rates[rateIndex]
+		samples := 1152
+		switch {
+		case layer == 1:
+			samples = 384
+		case layer == 3 && mpeg1 == 0:
+			samples = 576
+		}
+
+		if frames := vbrFrameCount(buf[i:], mpeg1 == 1, buf[i+3]>>6 == 3); frames > 0 {
+			return time.Duration(int64(frames) * int64(samples) * int64(time.Second) / int64(sampleRate))
+		}
+		audio := size - int64(i)
+		return time.Duration(audio * 8 * int64(time.Second) / int64(bitrate))
+	}
+
+	return 0
+}
+
+// vbrFrameCount reads the frame count of a Xing/Info or VBRI header in the
+// first frame, or returns 0 if there is none
+func vbrFrameCount(frame []byte, mpeg1, mono bool) uint32 {
+	sideInfo := 17
+	switch {
+	case mpeg1 && !mono:
+		sideInfo = 32
+	case !mpeg1 && mono:
+		sideInfo = 9
+	}
+
+	if x := frame[min(4+sideInfo, len(frame)):]; len(x) >= 12 &&
+		(bytes.HasPrefix(x, []byte("Xing")) || bytes.HasPrefix(x, []byte("Info"))) {
+		if binary.BigEndian.Uint32(x[4:8])&1 != 0 {
+			return binary.BigEndian.Uint32(x[8:12])
+		}
+		return 0
+	}
+
+	if v := frame[min(36, len(frame)):]; len(v) >= 18 && bytes.HasPrefix(v, []byte("VBRI")) {
+		return binary.BigEndian.Uint32(v[14:18])
+	}
+	return 0
+}
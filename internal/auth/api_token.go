@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// API token scopes. Each scope includes the ones before it.
+const (
+	ScopeRead     = "read"     // read playlists, connections and transfers
+	ScopeTransfer = "transfer" // start and cancel transfers, manage match overrides
+	ScopeAdmin    = "admin"    // disconnect provider accounts
+)
+
+// apiTokenPrefix marks PlayPort API tokens so they are easy to spot in scripts and secret scanners
+const apiTokenPrefix = "pp_"
+
+// lastUsedResolution limits how often a token's last-used time is written
+const lastUsedResolution = time.Minute
+
+var scopeRank = map[string]int{ScopeRead: 1, ScopeTransfer: 2, ScopeAdmin: 3}
+
+// ValidScope reports whether scope is a known API token scope
+func ValidScope(scope string) bool {
+	_, ok := scopeRank[scope]
+	return ok
+}
+
+// ScopeAllows reports whether any of the granted scopes covers the required one
+func ScopeAllows(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scopeRank[scope] >= scopeRank[required] {
+			return true
+		}
+	}
+	return false
+}
+
+// APITokenStore manages users' personal API tokens
+type APITokenStore interface {
+	// Create issues a new token and returns its secret, which cannot be retrieved later.
+	// A zero expiresAt creates a token that never expires.
+	Create(userID, name string, scopes []string, expiresAt time.Time) (secret string, token *models.APIToken, err error)
+
+	// Authenticate returns the token a secret belongs to and records its use
+	Authenticate(secret string) (*models.APIToken, error)
+
+	// List returns a user's tokens, newest first
+	List(userID string) ([]*models.APIToken, error)
+
+	// Revoke deletes one of a user's tokens
+	Revoke(userID, id string) error
+}
+
+// InMemoryAPITokenStore is a thread-safe in-memory API token store
+type InMemoryAPITokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]*models.APIToken // key: token hash
+}
+
+// NewInMemoryAPITokenStore creates a new in-memory API token store
+func NewInMemoryAPITokenStore() *InMemoryAPITokenStore {
+	return &InMemoryAPITokenStore{
+		tokens: make(map[string]*models.APIToken),
+	}
+}
+
+// Create issues a new token
+func (s *InMemoryAPITokenStore) Create(userID, name string, scopes []string, expiresAt time.Time) (string, *models.APIToken, error) {
+	secret, token, err := newAPIToken(userID, name, scopes, expiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *token
+	s.tokens[hashToken(secret)] = &stored
+	return secret, token, nil
+}
+
+// Authenticate returns the token a secret belongs to and records its use
+func (s *InMemoryAPITokenStore) Authenticate(secret string) (*models.APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[hashToken(secret)]
+	if !ok {
+		return nil, fmt.Errorf("API token not found")
+	}
+
+	now := time.Now().UTC()
+	if token.Expired(now) {
+		return nil, fmt.Errorf("API token expired")
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		token.LastUsedAt = &now
+	}
+
+	found := *token
+	return &found, nil
+}
+
+// List returns a user's tokens, newest first
+func (s *InMemoryAPITokenStore) List(userID string) ([]*models.APIToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tokens []*models.APIToken
+	for _, token := range s.tokens {
+		if token.UserID == userID {
+			found := *token
+			tokens = append(tokens, &found)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].ID > tokens[j].ID
+		}
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+
+	return tokens, nil
+}
+
+// Revoke deletes one of a user's tokens
+func (s *InMemoryAPITokenStore) Revoke(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, token := range s.tokens {
+		if token.UserID == userID && token.ID == id {
+			delete(s.tokens, hash)
+			return nil
+		}
+	}
+	return fmt.Errorf("API token not found")
+}
+
+// newAPIToken validates the token settings and generates its secret
+func newAPIToken(userID, name string, scopes []string, expiresAt time.Time) (string, *models.APIToken, error) {
+	if userID == "" {
+		return "", nil, fmt.Errorf("userID cannot be empty")
+	}
+	if name == "" {
+		return "", nil, fmt.Errorf("token name cannot be empty")
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !ValidScope(scope) {
+			return "", nil, fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+	secret := apiTokenPrefix + hex.EncodeToString(b)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate API token ID: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	token := &models.APIToken{
+		ID:        hex.EncodeToString(id),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[:len(apiTokenPrefix)+6],
+		Scopes:    append([]string(nil), scopes...),
+		CreatedAt: now,
+	}
+	if !expiresAt.IsZero() {
+		expires := expiresAt.UTC().Truncate(time.Microsecond)
+		token.ExpiresAt = &expires
+	}
+
+	return secret, token, nil
+}
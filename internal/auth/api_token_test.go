@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
+)
+
+// forEachAPITokenStore runs fn as a subtest against a fresh instance of every APITokenStore backend
+func forEachAPITokenStore(t *testing.T, fn func(t *testing.T, store APITokenStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewInMemoryAPITokenStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, NewSQLAPITokenStore(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, NewSQLAPITokenStore(dbtest.NewPostgres(t)))
+	})
+}
+
+func TestAPITokenStore_CreateAndAuthenticate(t *testing.T) {
+	forEachAPITokenStore(t, func(t *testing.T, store APITokenStore) {
+		expiresAt := time.Now().Add(24 * time.Hour)
+		secret, token, err := store.Create("user1", "backup script", []string{ScopeRead, ScopeTransfer}, expiresAt)
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+
+		if !strings.HasPrefix(secret, apiTokenPrefix) {
+			t.Errorf("Secret should start with %q, got %q", apiTokenPrefix, secret)
+		}
+		if !strings.HasPrefix(secret, token.Prefix) {
+			t.Errorf("Token prefix %q should be the start of the secret", token.Prefix)
+		}
+
+		found, err := store.Authenticate(secret)
+		if err != nil {
+			t.Fatalf("Authenticate() failed: %v", err)
+		}
+		if found.ID != token.ID || found.UserID != "user1" || found.Name != "backup script" {
+			t.Errorf("Authenticate() returned %+v, want token %s of user1", found, token.ID)
+		}
+		if len(found.Scopes) != 2 || found.Scopes[0] != ScopeRead || found.Scopes[1] != ScopeTransfer {
+			t.Errorf("Expected scopes [read transfer], got %v", found.Scopes)
+		}
+		if found.ExpiresAt == nil || !found.ExpiresAt.Equal(*token.ExpiresAt) {
+			t.Errorf("Expected expiry %v, got %v", token.ExpiresAt, found.ExpiresAt)
+		}
+		if found.LastUsedAt == nil {
+			t.Error("Authenticate() should record the last use")
+		}
+
+		tokens, err := store.List("user1")
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+			t.Errorf("List() should return the used token, got %+v", tokens)
+		}
+
+		if _, err := store.Authenticate(secret + "x"); err == nil {
+			t.Error("Authenticate() should fail for an unknown secret")
+		}
+	})
+}
+
+func TestAPITokenStore_Expired(t *testing.T) {
+	forEachAPITokenStore(t, func(t *testing.T, store APITokenStore) {
+		secret, _, err := store.Create("user1", "old", []string{ScopeRead}, time.Now().Add(-time.Minute))
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		if _, err := store.Authenticate(secret); err == nil {
+			t.Error("Authenticate() should fail for an expired token")
+		}
+
+		secret, token, err := store.Create("user1", "forever", []string{ScopeRead}, time.Time{})
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		if token.ExpiresAt != nil {
+			t.Errorf("A zero expiry should create a token that never expires, got %v", token.ExpiresAt)
+		}
+		if _, err := store.Authenticate(secret); err != nil {
+			t.Errorf("Authenticate() failed for a token without expiry: %v", err)
+		}
+	})
+}
+
+func TestAPITokenStore_Validation(t *testing.T) {
+	forEachAPITokenStore(t, func(t *testing.T, store APITokenStore) {
+		if _, _, err := store.Create("user1", "", []string{ScopeRead}, time.Time{}); err == nil {
+			t.Error("Create() should require a name")
+		}
+		if _, _, err := store.Create("user1", "ci", nil, time.Time{}); err == nil {
+			t.Error("Create() should require a scope")
+		}
+		if _, _, err := store.Create("user1", "ci", []string{"write"}, time.Time{}); err == nil {
+			t.Error("Create() should reject unknown scopes")
+		}
+	})
+}
+
+func TestAPITokenStore_ListAndRevoke(t *testing.T) {
+	forEachAPITokenStore(t, func(t *testing.T, store APITokenStore) {
+		secret1, token1, err := store.Create("user1", "first", []string{ScopeRead}, time.Time{})
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+		_, token2, err := store.Create("user1", "second", []string{ScopeAdmin}, time.Time{})
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		if _, _, err := store.Create("user2", "other", []string{ScopeRead}, time.Time{}); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+
+		tokens, err := store.List("user1")
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if len(tokens) != 2 || tokens[0].ID != token2.ID || tokens[1].ID != token1.ID {
+			t.Fatalf("List() should return user1's tokens newest first, got %+v", tokens)
+		}
+
+		if err := store.Revoke("user2", token1.ID); err == nil {
+			t.Error("Revoke() should not delete another user's token")
+		}
+		if err := store.Revoke("user1", token1.ID); err != nil {
+			t.Fatalf("Revoke() failed: %v", err)
+		}
+		if _, err := store.Authenticate(secret1); err == nil {
+			t.Error("Authenticate() should fail for a revoked token")
+		}
+		if err := store.Revoke("user1", token1.ID); err == nil {
+			t.Error("Revoke() should fail for an unknown token")
+		}
+	})
+}
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{ScopeRead}, ScopeRead, true},
+		{[]string{ScopeRead}, ScopeTransfer, false},
+		{[]string{ScopeTransfer}, ScopeRead, true},
+		{[]string{ScopeTransfer}, ScopeAdmin, false},
+		{[]string{ScopeAdmin}, ScopeTransfer, true},
+		{nil, ScopeRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := ScopeAllows(tt.granted, tt.required); got != tt.want {
+			t.Errorf("ScopeAllows(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
@@ -3,101 +3,161 @@ package auth
 import (
 	"testing"
 	"time"
-)
 
-func TestSessionStore_Create(t *testing.T) {
-	store := NewInMemorySessionStore(0)
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
+)
 
-	token1, err := store.Create("user1")
-	if err != nil {
-		t.Fatalf("Create() failed: %v", err)
-	}
+// sessionBackend is a SessionStore under test together with a way to force
+// one of its sessions past its expiry time
+type sessionBackend struct {
+	store  SessionStore
+	expire func(t *testing.T, token string)
+}
 
-	if token1 == "" {
-		t.Error("Create() should return a non-empty token")
-	}
+// forEachSessionStore runs fn as a subtest against a fresh instance of every SessionStore backend
+func forEachSessionStore(t *testing.T, fn func(t *testing.T, b sessionBackend)) {
+	t.Run("memory", func(t *testing.T) {
+		store := NewInMemorySessionStore(0)
+		fn(t, sessionBackend{
+			store: store,
+			expire: func(t *testing.T, token string) {
+				store.mu.Lock()
+				store.sessions[token].expiresAt = time.Now().Add(-1 * time.Minute)
+				store.mu.Unlock()
+			},
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, sqlSessionBackend(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, sqlSessionBackend(dbtest.NewPostgres(t)))
+	})
+}
 
-	// Tokens should be unique
-	token2, err := store.Create("user1")
-	if err != nil {
-		t.Fatalf("Create() failed: %v", err)
+// sqlSessionBackend wraps a SQLSessionStore on db as a sessionBackend
+func sqlSessionBackend(db *database.DB) sessionBackend {
+	return sessionBackend{
+		store: NewSQLSessionStore(db, 0),
+		expire: func(t *testing.T, token string) {
+			if _, err := db.Exec("UPDATE sessions SET expires_at = ? WHERE token_hash = ?", time.Now().UTC().Add(-1*time.Minute), hashToken(token)); err != nil {
+				t.Fatalf("Failed to expire session: %v", err)
+			}
+		},
 	}
+}
 
-	if token1 == token2 {
-		t.Error("Create() should generate unique tokens")
-	}
+func TestSessionStore_Create(t *testing.T) {
+	forEachSessionStore(t, func(t *testing.T, b sessionBackend) {
+		token1, err := b.store.Create("user1")
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+
+		if token1 == "" {
+			t.Error("Create() should return a non-empty token")
+		}
+
+		// Tokens should be unique
+		token2, err := b.store.Create("user1")
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+
+		if token1 == token2 {
+			t.Error("Create() should generate unique tokens")
+		}
+	})
 }
 
 func TestSessionStore_Get(t *testing.T) {
-	store := NewInMemorySessionStore(0)
-
-	token, err := store.Create("user123")
-	if err != nil {
-		t.Fatalf("Create() failed: %v", err)
-	}
-
-	userID, err := store.Get(token)
-	if err != nil {
-		t.Fatalf("Get() failed: %v", err)
-	}
-
-	if userID != "user123" {
-		t.Errorf("Expected userID 'user123', got '%s'", userID)
-	}
+	forEachSessionStore(t, func(t *testing.T, b sessionBackend) {
+		token, err := b.store.Create("user123")
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+
+		userID, err := b.store.Get(token)
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+
+		if userID != "user123" {
+			t.Errorf("Expected userID 'user123', got '%s'", userID)
+		}
+	})
 }
 
 func TestSessionStore_Get_NotFound(t *testing.T) {
-	store := NewInMemorySessionStore(0)
-
-	_, err := store.Get("invalid-token")
-	if err == nil {
-		t.Error("Get() should return error for non-existent token")
-	}
+	forEachSessionStore(t, func(t *testing.T, b sessionBackend) {
+		_, err := b.store.Get("invalid-token")
+		if err == nil {
+			t.Error("Get() should return error for non-existent token")
+		}
+	})
 }
 
 func TestSessionStore_Get_Expired(t *testing.T) {
-	store := NewInMemorySessionStore(1 * time.Millisecond)
-
-	token, err := store.Create("user123")
-	if err != nil {
-		t.Fatalf("Create() failed: %v", err)
-	}
+	forEachSessionStore(t, func(t *testing.T, b sessionBackend) {
+		token, err := b.store.Create("user123")
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+
+		// Manually expire the session
+		b.expire(t, token)
+
+		_, err = b.store.Get(token)
+		if err == nil {
+			t.Error("Get() should return error for expired session")
+		}
+	})
+}
 
-	// Manually expire the session
-	store.mu.Lock()
-	store.sessions[token].expiresAt = time.Now().Add(-1 * time.Minute)
-	store.mu.Unlock()
+func TestSessionStore_Delete(t *testing.T) {
+	forEachSessionStore(t, func(t *testing.T, b sessionBackend) {
+		token, err := b.store.Create("user123")
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+
+		err = b.store.Delete(token)
+		if err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+
+		_, err = b.store.Get(token)
+		if err == nil {
+			t.Error("Get() should return error after Delete()")
+		}
+	})
+}
 
-	_, err = store.Get(token)
-	if err == nil {
-		t.Error("Get() should return error for expired session")
-	}
+func TestSessionStore_Delete_NotFound(t *testing.T) {
+	forEachSessionStore(t, func(t *testing.T, b sessionBackend) {
+		err := b.store.Delete("nonexistent-token")
+		if err == nil {
+			t.Error("Delete() should return error for non-existent token")
+		}
+	})
 }
 
-func TestSessionStore_Delete(t *testing.T) {
-	store := NewInMemorySessionStore(0)
+func TestSQLSessionStore_StoresHashedTokens(t *testing.T) {
+	db := dbtest.NewSQLite(t)
+	store := NewSQLSessionStore(db, 0)
 
 	token, err := store.Create("user123")
 	if err != nil {
 		t.Fatalf("Create() failed: %v", err)
 	}
 
-	err = store.Delete(token)
-	if err != nil {
-		t.Fatalf("Delete() failed: %v", err)
-	}
-
-	_, err = store.Get(token)
-	if err == nil {
-		t.Error("Get() should return error after Delete()")
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sessions WHERE token_hash = ?", token).Scan(&count); err != nil {
+		t.Fatalf("Failed to query sessions: %v", err)
 	}
-}
-
-func TestSessionStore_Delete_NotFound(t *testing.T) {
-	store := NewInMemorySessionStore(0)
 
-	err := store.Delete("nonexistent-token")
-	if err == nil {
-		t.Error("Delete() should return error for non-existent token")
+	if count != 0 {
+		t.Error("Session tokens should not be stored in plaintext")
 	}
 }
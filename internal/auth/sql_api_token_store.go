@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+const apiTokenColumns = "id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at"
+
+// SQLAPITokenStore is an APITokenStore backed by a SQL database.
+// Like sessions, tokens are stored as SHA-256 hashes.
+type SQLAPITokenStore struct {
+	db *database.DB
+}
+
+// NewSQLAPITokenStore creates a new SQL-backed API token store.
+// The database must already be migrated.
+func NewSQLAPITokenStore(db *database.DB) *SQLAPITokenStore {
+	return &SQLAPITokenStore{db: db}
+}
+
+// Create issues a new token
+func (s *SQLAPITokenStore) Create(userID, name string, scopes []string, expiresAt time.Time) (string, *models.APIToken, error) {
+	secret, token, err := newAPIToken(userID, name, scopes, expiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	_, err = s.db.Exec("INSERT INTO api_tokens (token_hash, "+apiTokenColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		hashToken(secret), token.ID, token.UserID, token.Name, token.Prefix, strings.Join(token.Scopes, " "),
+		nullTime(token.ExpiresAt), nullTime(nil), token.CreatedAt,
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return secret, token, nil
+}
+
+// Authenticate returns the token a secret belongs to and records its use
+func (s *SQLAPITokenStore) Authenticate(secret string) (*models.APIToken, error) {
+	hash := hashToken(secret)
+	token, err := scanAPIToken(s.db.QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = ?", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("API token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if token.Expired(now) {
+		return nil, fmt.Errorf("API token expired")
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		if _, err := s.db.Exec("UPDATE api_tokens SET last_used_at = ? WHERE token_hash = ?", now, hash); err != nil {
+			return nil, fmt.Errorf("failed to record API token use: %w", err)
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
+
+// List returns a user's tokens, newest first
+func (s *SQLAPITokenStore) List(userID string) ([]*models.APIToken, error) {
+	rows, err := s.db.Query("SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke deletes one of a user's tokens
+func (s *SQLAPITokenStore) Revoke(userID, id string) error {
+	result, err := s.db.Exec("DELETE FROM api_tokens WHERE user_id = ? AND id = ?", userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("API token not found")
+	}
+
+	return nil
+}
+
+// apiTokenRow is the subset of *sql.Row and *sql.Rows used to scan tokens
+type apiTokenRow interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIToken scans an API token row
+func scanAPIToken(row apiTokenRow) (*models.APIToken, error) {
+	var token models.APIToken
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
+		&expiresAt, &lastUsedAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	token.CreatedAt = token.CreatedAt.UTC()
+	if expiresAt.Valid {
+		t := expiresAt.Time.UTC()
+		token.ExpiresAt = &t
+	}
+	if lastUsedAt.Valid {
+		t := lastUsedAt.Time.UTC()
+		token.LastUsedAt = &t
+	}
+	return &token, nil
+}
+
+// nullTime converts an optional time to a nullable SQL value
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+)
+
+// SQLSessionStore is a SessionStore backed by a SQL database.
+// Only a SHA-256 hash of each token is stored, so a leaked database
+// cannot be used to hijack sessions.
+type SQLSessionStore struct {
+	db       *database.DB
+	duration time.Duration
+}
+
+// NewSQLSessionStore creates a new SQL-backed session store with an optional duration.
+// If duration is zero or negative, the default of 24 hours is used.
+// The database must already be migrated.
+func NewSQLSessionStore(db *database.DB, duration time.Duration) *SQLSessionStore {
+	if duration <= 0 {
+		duration = defaultSessionDuration
+	}
+
+	store := &SQLSessionStore{
+		db:       db,
+		duration: duration,
+	}
+
+	go store.cleanup()
+
+	return store
+}
+
+// Create creates a new session for a user and returns the session token
+func (s *SQLSessionStore) Create(userID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	token := base64.URLEncoding.EncodeToString(b)
+	expiresAt := time.Now().UTC().Add(s.duration)
+
+	if _, err := s.db.Exec("INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)", hashToken(token), userID, expiresAt); err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return token, nil
+}
+
+// Get retrieves the userID for a session token
+func (s *SQLSessionStore) Get(token string) (string, error) {
+	var userID string
+	var expiresAt time.Time
+	err := s.db.QueryRow("SELECT user_id, expires_at FROM sessions WHERE token_hash = ?", hashToken(token)).Scan(&userID, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("session not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if time.Now().After(expiresAt) {
+		return "", fmt.Errorf("session expired")
+	}
+
+	return userID, nil
+}
+
+// Delete removes a session
+func (s *SQLSessionStore) Delete(token string) error {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE token_hash = ?", hashToken(token))
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+// cleanup periodically removes expired sessions
+func (s *SQLSessionStore) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.db.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now().UTC()); err != nil {
+			log.Printf("Failed to clean up expired sessions: %v", err)
+		}
+	}
+}
+
+// hashToken returns the hex-encoded SHA-256 hash of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+)
+
+// SQLStateStore is a StateStore backed by a SQL database
+type SQLStateStore struct {
+	db *database.DB
+}
+
+// NewSQLStateStore creates a new SQL-backed state store.
+// The database must already be migrated.
+func NewSQLStateStore(db *database.DB) *SQLStateStore {
+	store := &SQLStateStore{db: db}
+
+	// Start cleanup goroutine
+	go store.cleanup()
+
+	return store
+}
+
+// Generate creates a new state token
+func (s *SQLStateStore) Generate() (string, error) {
+	return s.generate("")
+}
+
+// GeneratePKCE creates a new state token with a PKCE code verifier
+func (s *SQLStateStore) GeneratePKCE() (string, string, error) {
+	verifier := oauth2.GenerateVerifier()
+	state, err := s.generate(verifier)
+	if err != nil {
+		return "", "", err
+	}
+	return state, verifier, nil
+}
+
+// generate creates a new state token stored with verifier
+func (s *SQLStateStore) generate(verifier string) (string, error) {
+	state, err := newState()
+	if err != nil {
+		return "", err
+	}
+
+	// Store state with expiration time (10 minutes)
+	if _, err := s.db.Exec("INSERT INTO oauth_states (state, verifier, expires_at) VALUES (?, ?, ?)", state, verifier, time.Now().UTC().Add(10*time.Minute)); err != nil {
+		return "", fmt.Errorf("failed to store state: %w", err)
+	}
+
+	return state, nil
+}
+
+// Validate checks if a state token is valid and removes it
+func (s *SQLStateStore) Validate(state string) bool {
+	_, ok := s.ValidatePKCE(state)
+	return ok
+}
+
+// ValidatePKCE checks if a state token is valid, removes it and returns its
+// code verifier
+func (s *SQLStateStore) ValidatePKCE(state string) (string, bool) {
+	// Deleting the row is what consumes the state (one-time use), so only
+	// one of several concurrent validations can succeed.
+	var verifier string
+	err := s.db.QueryRow("DELETE FROM oauth_states WHERE state = ? AND expires_at > ? RETURNING verifier", state, time.Now().UTC()).Scan(&verifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Expired states are still removed so they cannot linger
+		_, _ = s.db.Exec("DELETE FROM oauth_states WHERE state = ?", state)
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Failed to validate state: %v", err)
+		return "", false
+	}
+
+	return verifier, true
+}
+
+// cleanup periodically removes expired states
+func (s *SQLStateStore) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.db.Exec("DELETE FROM oauth_states WHERE expires_at < ?", time.Now().UTC()); err != nil {
+			log.Printf("Failed to clean up expired states: %v", err)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"golang.org/x/oauth2"
 )
 
 // StateStore manages OAuth state tokens for CSRF protection
@@ -15,18 +17,33 @@ type StateStore interface {
 
 	// Validate checks if a state token is valid and removes it
 	Validate(state string) bool
+
+	// GeneratePKCE creates a new state token together with a random PKCE
+	// code verifier, which is stored with it so that the callback can be
+	// handled by any instance sharing the store
+	GeneratePKCE() (state, verifier string, err error)
+
+	// ValidatePKCE checks if a state token is valid, removes it and returns
+	// the code verifier stored with it
+	ValidatePKCE(state string) (string, bool)
+}
+
+// stateEntry is a state token's expiry time and PKCE code verifier
+type stateEntry struct {
+	expiry   time.Time
+	verifier string // empty for states made by Generate
 }
 
 // InMemoryStateStore is a thread-safe in-memory state store
 type InMemoryStateStore struct {
 	mu     sync.RWMutex
-	states map[string]time.Time
+	states map[string]stateEntry
 }
 
 // NewInMemoryStateStore creates a new in-memory state store
 func NewInMemoryStateStore() *InMemoryStateStore {
 	store := &InMemoryStateStore{
-		states: make(map[string]time.Time),
+		states: make(map[string]stateEntry),
 	}
 	
 	// Start cleanup goroutine
@@ -37,37 +54,69 @@ func NewInMemoryStateStore() *InMemoryStateStore {
 
 // Generate creates a new state token
 func (s *InMemoryStateStore) Generate() (string, error) {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		return "", fmt.Errorf("failed to generate random state: %w", err)
+	return s.generate("")
+}
+
+// GeneratePKCE creates a new state token with a PKCE code verifier
+func (s *InMemoryStateStore) GeneratePKCE() (string, string, error) {
+	verifier := oauth2.GenerateVerifier()
+	state, err := s.generate(verifier)
+	if err != nil {
+		return "", "", err
 	}
-	
-	state := base64.URLEncoding.EncodeToString(b)
-	
+	return state, verifier, nil
+}
+
+// generate creates a new state token stored with verifier
+func (s *InMemoryStateStore) generate(verifier string) (string, error) {
+	state, err := newState()
+	if err != nil {
+		return "", err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	
+
 	// Store state with expiration time (10 minutes)
-	s.states[state] = time.Now().Add(10 * time.Minute)
-	
+	s.states[state] = stateEntry{expiry: time.Now().Add(10 * time.Minute), verifier: verifier}
+
 	return state, nil
 }
 
 // Validate checks if a state token is valid and removes it
 func (s *InMemoryStateStore) Validate(state string) bool {
+	_, ok := s.ValidatePKCE(state)
+	return ok
+}
+
+// ValidatePKCE checks if a state token is valid, removes it and returns its
+// code verifier
+func (s *InMemoryStateStore) ValidatePKCE(state string) (string, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	
-	expiry, exists := s.states[state]
+
+	entry, exists := s.states[state]
 	if !exists {
-		return false
+		return "", false
 	}
-	
+
 	// Remove the state (one-time use)
 	delete(s.states, state)
-	
+
 	// Check if expired
-	return time.Now().Before(expiry)
+	if !time.Now().Before(entry.expiry) {
+		return "", false
+	}
+	return entry.verifier, true
+}
+
+// newState returns a random state token
+func newState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random state: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
 }
 
 // cleanup periodically removes expired states
@@ -78,8 +127,8 @@ func (s *InMemoryStateStore) cleanup() {
 	for range ticker.C {
 		s.mu.Lock()
 		now := time.Now()
-		for state, expiry := range s.states {
-			if now.After(expiry) {
+		for state, entry := range s.states {
+			if now.After(entry.expiry) {
 				delete(s.states, state)
 			}
 		}
@@ -3,72 +3,152 @@ package auth
 import (
 	"testing"
 	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
 )
 
-func TestStateStore_Generate(t *testing.T) {
-	store := NewInMemoryStateStore()
+// stateBackend is a StateStore under test together with a way to force
+// one of its states past its expiry time
+type stateBackend struct {
+	store  StateStore
+	expire func(t *testing.T, state string)
+}
 
-	state1, err := store.Generate()
-	if err != nil {
-		t.Fatalf("Generate() failed: %v", err)
-	}
+// forEachStateStore runs fn as a subtest against a fresh instance of every StateStore backend
+func forEachStateStore(t *testing.T, fn func(t *testing.T, b stateBackend)) {
+	t.Run("memory", func(t *testing.T) {
+		store := NewInMemoryStateStore()
+		fn(t, stateBackend{
+			store: store,
+			expire: func(t *testing.T, state string) {
+				store.mu.Lock()
+				entry := store.states[state]
+				entry.expiry = time.Now().Add(-1 * time.Minute)
+				store.states[state] = entry
+				store.mu.Unlock()
+			},
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, sqlStateBackend(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, sqlStateBackend(dbtest.NewPostgres(t)))
+	})
+}
 
-	if state1 == "" {
-		t.Error("Generated state should not be empty")
+// sqlStateBackend wraps a SQLStateStore on db as a stateBackend
+func sqlStateBackend(db *database.DB) stateBackend {
+	return stateBackend{
+		store: NewSQLStateStore(db),
+		expire: func(t *testing.T, state string) {
+			if _, err := db.Exec("UPDATE oauth_states SET expires_at = ? WHERE state = ?", time.Now().UTC().Add(-1*time.Minute), state); err != nil {
+				t.Fatalf("Failed to expire state: %v", err)
+			}
+		},
 	}
+}
 
-	// Generate another state
-	state2, err := store.Generate()
-	if err != nil {
-		t.Fatalf("Generate() failed: %v", err)
-	}
+func TestStateStore_Generate(t *testing.T) {
+	forEachStateStore(t, func(t *testing.T, b stateBackend) {
+		state1, err := b.store.Generate()
+		if err != nil {
+			t.Fatalf("Generate() failed: %v", err)
+		}
 
-	if state1 == state2 {
-		t.Error("Generated states should be unique")
-	}
+		if state1 == "" {
+			t.Error("Generated state should not be empty")
+		}
+
+		// Generate another state
+		state2, err := b.store.Generate()
+		if err != nil {
+			t.Fatalf("Generate() failed: %v", err)
+		}
+
+		if state1 == state2 {
+			t.Error("Generated states should be unique")
+		}
+	})
 }
 
 func TestStateStore_Validate(t *testing.T) {
-	store := NewInMemoryStateStore()
+	forEachStateStore(t, func(t *testing.T, b stateBackend) {
+		// Generate a state
+		state, err := b.store.Generate()
+		if err != nil {
+			t.Fatalf("Generate() failed: %v", err)
+		}
 
-	// Generate a state
-	state, err := store.Generate()
-	if err != nil {
-		t.Fatalf("Generate() failed: %v", err)
-	}
+		// Valid state should pass validation
+		if !b.store.Validate(state) {
+			t.Error("Validate() should return true for valid state")
+		}
 
-	// Valid state should pass validation
-	if !store.Validate(state) {
-		t.Error("Validate() should return true for valid state")
-	}
-
-	// State should be removed after validation (one-time use)
-	if store.Validate(state) {
-		t.Error("Validate() should return false for already used state")
-	}
+		// State should be removed after validation (one-time use)
+		if b.store.Validate(state) {
+			t.Error("Validate() should return false for already used state")
+		}
 
-	// Invalid state should fail validation
-	if store.Validate("invalid-state") {
-		t.Error("Validate() should return false for invalid state")
-	}
+		// Invalid state should fail validation
+		if b.store.Validate("invalid-state") {
+			t.Error("Validate() should return false for invalid state")
+		}
+	})
 }
 
 func TestStateStore_Expiration(t *testing.T) {
-	store := NewInMemoryStateStore()
+	forEachStateStore(t, func(t *testing.T, b stateBackend) {
+		// Generate a state
+		state, err := b.store.Generate()
+		if err != nil {
+			t.Fatalf("Generate() failed: %v", err)
+		}
 
-	// Generate a state
-	state, err := store.Generate()
-	if err != nil {
-		t.Fatalf("Generate() failed: %v", err)
-	}
+		// Manually expire the state
+		b.expire(t, state)
 
-	// Manually expire the state
-	store.mu.Lock()
-	store.states[state] = time.Now().Add(-1 * time.Minute)
-	store.mu.Unlock()
+		// Expired state should fail validation
+		if b.store.Validate(state) {
+			t.Error("Validate() should return false for expired state")
+		}
+	})
+}
 
-	// Expired state should fail validation
-	if store.Validate(state) {
-		t.Error("Validate() should return false for expired state")
-	}
+func TestStateStore_PKCE(t *testing.T) {
+	forEachStateStore(t, func(t *testing.T, b stateBackend) {
+		state, verifier, err := b.store.GeneratePKCE()
+		if err != nil {
+			t.Fatalf("GeneratePKCE() failed: %v", err)
+		}
+		if state == "" || len(verifier) < 43 {
+			t.Errorf("Expected a state and a verifier of at least 43 characters, got %q and %q", state, verifier)
+		}
+
+		_, other, _ := b.store.GeneratePKCE()
+		if other == verifier {
+			t.Error("Expected a different verifier per state")
+		}
+
+		got, ok := b.store.ValidatePKCE(state)
+		if !ok || got != verifier {
+			t.Errorf("ValidatePKCE() = %q, %v, want %q, true", got, ok, verifier)
+		}
+		if _, ok := b.store.ValidatePKCE(state); ok {
+			t.Error("ValidatePKCE() should only accept a state once")
+		}
+
+		// States without PKCE validate with an empty verifier
+		plain, _ := b.store.Generate()
+		if got, ok := b.store.ValidatePKCE(plain); !ok || got != "" {
+			t.Errorf("ValidatePKCE() = %q, %v for a plain state, want \"\", true", got, ok)
+		}
+
+		expired, _, _ := b.store.GeneratePKCE()
+		b.expire(t, expired)
+		if _, ok := b.store.ValidatePKCE(expired); ok {
+			t.Error("ValidatePKCE() should reject expired states")
+		}
+	})
 }
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config holds the application configuration
@@ -10,6 +13,22 @@ type Config struct {
 	// Server configuration
 	ServerAddr string
 
+	// Storage configuration
+	StorageDriver     string // "memory", "sqlite" or "postgres"
+	DatabaseURL       string // SQLite database file path (default playport.db) or PostgreSQL DSN
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+
+	// Batch transfers
+	TransferWorkers             int // playlists of a batch transferred at once
+	TransferProviderConcurrency int // playlists using the same provider at once
+
+	// Scheduled transfers and syncs
+	ScheduleMaxPerUser  int           // schedules each user may have; 0 means no limit
+	ScheduleMinInterval time.Duration // shortest time allowed between two runs of a schedule
+	ScheduleJitter      time.Duration // largest random delay added to each run
+
 	// Spotify OAuth configuration
 	SpotifyClientID     string
 	SpotifyClientSecret string
@@ -19,23 +38,144 @@ type Config struct {
 	YouTubeMusicClientID     string
 	YouTubeMusicClientSecret string
 	YouTubeMusicRedirectURL  string
+
+	// Deezer OAuth configuration
+	DeezerAppID       string
+	DeezerSecretKey   string
+	DeezerRedirectURL string
+
+	// Tidal OAuth configuration; the secret is optional since Tidal's
+	// authorization code flow uses PKCE
+	TidalClientID     string
+	TidalClientSecret string
+	TidalRedirectURL  string
+
+	// SoundCloud OAuth configuration; SoundCloud requires PKCE in addition
+	// to the client secret
+	SoundCloudClientID     string
+	SoundCloudClientSecret string
+	SoundCloudRedirectURL  string
+
+	// Apple Music configuration: the MusicKit key signs developer tokens
+	AppleMusicTeamID         string
+	AppleMusicKeyID          string
+	AppleMusicPrivateKeyPath string // path to the key's .p8 file
+
+	// Self-hosted servers; users link their accounts with a username and
+	// password or API key
+	SubsonicURL string // Subsonic-compatible server such as Navidrome; empty disables the provider
+	JellyfinURL string // Jellyfin server; empty disables the provider
+
+	// File-based providers
+	M3UDir   string // directory for M3U playlists; empty disables the M3U provider
+	MusicDir string // local music collection; empty disables the local music provider
 }
 
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
+	maxOpenConns, err := getEnvInt("DB_MAX_OPEN_CONNS", 10)
+	if err != nil {
+		return nil, err
+	}
+	maxIdleConns, err := getEnvInt("DB_MAX_IDLE_CONNS", 5)
+	if err != nil {
+		return nil, err
+	}
+	connMaxLifetime, err := getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	transferWorkers, err := getEnvInt("TRANSFER_WORKERS", 4)
+	if err != nil {
+		return nil, err
+	}
+	providerConcurrency, err := getEnvInt("TRANSFER_PROVIDER_CONCURRENCY", 2)
+	if err != nil {
+		return nil, err
+	}
+	if transferWorkers < 1 || providerConcurrency < 1 {
+		return nil, fmt.Errorf("TRANSFER_WORKERS and TRANSFER_PROVIDER_CONCURRENCY must be at least 1")
+	}
+	scheduleMaxPerUser, err := getEnvInt("SCHEDULE_MAX_PER_USER", 10)
+	if err != nil {
+		return nil, err
+	}
+	scheduleMinInterval, err := getEnvDuration("SCHEDULE_MIN_INTERVAL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	scheduleJitter, err := getEnvDuration("SCHEDULE_JITTER", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if scheduleMaxPerUser < 0 || scheduleMinInterval < 0 || scheduleJitter < 0 {
+		return nil, fmt.Errorf("SCHEDULE_MAX_PER_USER, SCHEDULE_MIN_INTERVAL and SCHEDULE_JITTER cannot be negative")
+	}
+
+	// Only SQLite has a default database; PostgreSQL needs an explicit DATABASE_URL
+	storageDriver := getEnv("STORAGE_DRIVER", "memory")
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" && storageDriver == "sqlite" {
+		databaseURL = "playport.db"
+	}
+
 	cfg := &Config{
 		ServerAddr:                  getEnv("SERVER_ADDR", ":8080"),
+		StorageDriver:               storageDriver,
+		DatabaseURL:                 databaseURL,
+		DBMaxOpenConns:              maxOpenConns,
+		DBMaxIdleConns:              maxIdleConns,
+		DBConnMaxLifetime:           connMaxLifetime,
+		TransferWorkers:             transferWorkers,
+		TransferProviderConcurrency: providerConcurrency,
+		ScheduleMaxPerUser:          scheduleMaxPerUser,
+		ScheduleMinInterval:         scheduleMinInterval,
+		ScheduleJitter:              scheduleJitter,
 		SpotifyClientID:             os.Getenv("SPOTIFY_CLIENT_ID"),
 		SpotifyClientSecret:         os.Getenv("SPOTIFY_CLIENT_SECRET"),
 		SpotifyRedirectURL:          os.Getenv("SPOTIFY_REDIRECT_URL"),
 		YouTubeMusicClientID:        os.Getenv("YOUTUBE_MUSIC_CLIENT_ID"),
 		YouTubeMusicClientSecret:    os.Getenv("YOUTUBE_MUSIC_CLIENT_SECRET"),
 		YouTubeMusicRedirectURL:     os.Getenv("YOUTUBE_MUSIC_REDIRECT_URL"),
+		DeezerAppID:                 os.Getenv("DEEZER_APP_ID"),
+		DeezerSecretKey:             os.Getenv("DEEZER_SECRET_KEY"),
+		DeezerRedirectURL:           os.Getenv("DEEZER_REDIRECT_URL"),
+		TidalClientID:               os.Getenv("TIDAL_CLIENT_ID"),
+		TidalClientSecret:           os.Getenv("TIDAL_CLIENT_SECRET"),
+		TidalRedirectURL:            os.Getenv("TIDAL_REDIRECT_URL"),
+		SoundCloudClientID:          os.Getenv("SOUNDCLOUD_CLIENT_ID"),
+		SoundCloudClientSecret:      os.Getenv("SOUNDCLOUD_CLIENT_SECRET"),
+		SoundCloudRedirectURL:       os.Getenv("SOUNDCLOUD_REDIRECT_URL"),
+		AppleMusicTeamID:            os.Getenv("APPLE_MUSIC_TEAM_ID"),
+		AppleMusicKeyID:             os.Getenv("APPLE_MUSIC_KEY_ID"),
+		AppleMusicPrivateKeyPath:    os.Getenv("APPLE_MUSIC_PRIVATE_KEY_PATH"),
+		SubsonicURL:                 os.Getenv("SUBSONIC_URL"),
+		JellyfinURL:                 os.Getenv("JELLYFIN_URL"),
+		M3UDir:                      os.Getenv("M3U_DIR"),
+		MusicDir:                    os.Getenv("MUSIC_DIR"),
 	}
 
 	return cfg, nil
 }
 
+// ValidateStorage validates the storage configuration
+func (c *Config) ValidateStorage() error {
+	switch c.StorageDriver {
+	case "memory":
+		return nil
+	case "sqlite", "postgres":
+		if c.DatabaseURL == "" {
+			return fmt.Errorf("DATABASE_URL is required when STORAGE_DRIVER is %s", c.StorageDriver)
+		}
+		if c.DBMaxOpenConns < 1 {
+			return fmt.Errorf("DB_MAX_OPEN_CONNS must be at least 1")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported STORAGE_DRIVER %q (expected memory, sqlite or postgres)", c.StorageDriver)
+	}
+}
+
 // ValidateSpotify validates Spotify configuration
 // Returns true if Spotify is configured, false if not configured, error if partially configured
 func (c *Config) ValidateSpotify() (bool, error) {
@@ -88,6 +228,133 @@ func (c *Config) ValidateYouTubeMusic() (bool, error) {
 	return true, nil
 }
 
+// ValidateDeezer validates Deezer configuration
+// Returns true if Deezer is configured, false if not configured, error if partially configured
+func (c *Config) ValidateDeezer() (bool, error) {
+	hasAppID := c.DeezerAppID != ""
+	hasSecretKey := c.DeezerSecretKey != ""
+	hasRedirectURL := c.DeezerRedirectURL != ""
+
+	// If none are set, Deezer is simply not configured
+	if !hasAppID && !hasSecretKey && !hasRedirectURL {
+		return false, nil
+	}
+
+	// If some but not all are set, this is an error
+	if !hasAppID {
+		return false, fmt.Errorf("DEEZER_APP_ID is required when Deezer is configured")
+	}
+	if !hasSecretKey {
+		return false, fmt.Errorf("DEEZER_SECRET_KEY is required when Deezer is configured")
+	}
+	if !hasRedirectURL {
+		return false, fmt.Errorf("DEEZER_REDIRECT_URL is required when Deezer is configured")
+	}
+
+	return true, nil
+}
+
+// ValidateTidal validates Tidal configuration
+// Returns true if Tidal is configured, false if not configured, error if partially configured
+func (c *Config) ValidateTidal() (bool, error) {
+	hasClientID := c.TidalClientID != ""
+	hasClientSecret := c.TidalClientSecret != ""
+	hasRedirectURL := c.TidalRedirectURL != ""
+
+	// If none are set, Tidal is simply not configured
+	if !hasClientID && !hasClientSecret && !hasRedirectURL {
+		return false, nil
+	}
+
+	// The client secret is optional, the others are required
+	if !hasClientID {
+		return false, fmt.Errorf("TIDAL_CLIENT_ID is required when Tidal is configured")
+	}
+	if !hasRedirectURL {
+		return false, fmt.Errorf("TIDAL_REDIRECT_URL is required when Tidal is configured")
+	}
+
+	return true, nil
+}
+
+// ValidateSoundCloud validates SoundCloud configuration
+// Returns true if SoundCloud is configured, false if not configured, error if partially configured
+func (c *Config) ValidateSoundCloud() (bool, error) {
+	hasClientID := c.SoundCloudClientID != ""
+	hasClientSecret := c.SoundCloudClientSecret != ""
+	hasRedirectURL := c.SoundCloudRedirectURL != ""
+
+	// If none are set, SoundCloud is simply not configured
+	if !hasClientID && !hasClientSecret && !hasRedirectURL {
+		return false, nil
+	}
+
+	// If some but not all are set, this is an error
+	if !hasClientID {
+		return false, fmt.Errorf("SOUNDCLOUD_CLIENT_ID is required when SoundCloud is configured")
+	}
+	if !hasClientSecret {
+		return false, fmt.Errorf("SOUNDCLOUD_CLIENT_SECRET is required when SoundCloud is configured")
+	}
+	if !hasRedirectURL {
+		return false, fmt.Errorf("SOUNDCLOUD_REDIRECT_URL is required when SoundCloud is configured")
+	}
+
+	return true, nil
+}
+
+// ValidateAppleMusic validates Apple Music configuration
+// Returns true if Apple Music is configured, false if not configured, error if partially configured
+func (c *Config) ValidateAppleMusic() (bool, error) {
+	hasTeamID := c.AppleMusicTeamID != ""
+	hasKeyID := c.AppleMusicKeyID != ""
+	hasPrivateKey := c.AppleMusicPrivateKeyPath != ""
+
+	// If none are set, Apple Music is simply not configured
+	if !hasTeamID && !hasKeyID && !hasPrivateKey {
+		return false, nil
+	}
+
+	// If some but not all are set, this is an error
+	if !hasTeamID {
+		return false, fmt.Errorf("APPLE_MUSIC_TEAM_ID is required when Apple Music is configured")
+	}
+	if !hasKeyID {
+		return false, fmt.Errorf("APPLE_MUSIC_KEY_ID is required when Apple Music is configured")
+	}
+	if !hasPrivateKey {
+		return false, fmt.Errorf("APPLE_MUSIC_PRIVATE_KEY_PATH is required when Apple Music is configured")
+	}
+
+	return true, nil
+}
+
+// ValidateSubsonic validates Subsonic configuration
+// Returns true if a server URL is set, error if it is not an http or https URL
+func (c *Config) ValidateSubsonic() (bool, error) {
+	return validateServerURL("SUBSONIC_URL", c.SubsonicURL)
+}
+
+// ValidateJellyfin validates Jellyfin configuration
+// Returns true if a server URL is set, error if it is not an http or https URL
+func (c *Config) ValidateJellyfin() (bool, error) {
+	return validateServerURL("JELLYFIN_URL", c.JellyfinURL)
+}
+
+// validateServerURL checks the URL of a self-hosted server
+func validateServerURL(key, value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false, fmt.Errorf("%s must be an http or https URL such as https://music.example.com", key)
+	}
+
+	return true, nil
+}
+
 // getEnv gets an environment variable with a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -96,3 +363,29 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInt gets an integer environment variable with a default value
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "30m") with a default value
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 30m: %w", key, err)
+	}
+	return d, nil
+}
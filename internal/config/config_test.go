@@ -0,0 +1,44 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad_DatabaseURLDefault(t *testing.T) {
+	tests := []struct {
+		driver  string
+		url     string
+		wantURL string
+		wantErr string
+	}{
+		{driver: "sqlite", wantURL: "playport.db"},
+		{driver: "sqlite", url: "/var/lib/playport/playport.db", wantURL: "/var/lib/playport/playport.db"},
+		{driver: "postgres", wantErr: "DATABASE_URL is required"},
+		{driver: "postgres", url: "postgres://localhost/playport", wantURL: "postgres://localhost/playport"},
+		{driver: "memory", wantURL: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver+"/"+tt.url, func(t *testing.T) {
+			t.Setenv("STORAGE_DRIVER", tt.driver)
+			t.Setenv("DATABASE_URL", tt.url)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if cfg.DatabaseURL != tt.wantURL {
+				t.Errorf("Expected DatabaseURL %q, got %q", tt.wantURL, cfg.DatabaseURL)
+			}
+
+			err = cfg.ValidateStorage()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("ValidateStorage() failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected ValidateStorage() error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
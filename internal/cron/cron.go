@@ -0,0 +1,200 @@
+// Package cron parses the specs of recurring jobs: five-field cron
+// expressions, the usual "@daily" style shorthands and "@every" intervals.
+// Cron expressions are evaluated in UTC.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinInterval is the shortest interval "@every" accepts
+const MinInterval = time.Minute
+
+// maxYears bounds the search for the next time of an expression, so that
+// one that never fires, such as "0 0 30 2 *", does not loop forever
+const maxYears = 5
+
+// Schedule computes when a recurring job runs next
+type Schedule interface {
+	// Next returns the first time after the given one at which the job
+	// runs, or the zero time if it never runs again
+	Next(after time.Time) time.Time
+}
+
+// shorthands maps the "@" shorthands to their cron expressions
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a spec: "@every" followed by a duration such as "6h" or
+// "90m", a shorthand such as "@daily", or a cron expression with minute,
+// hour, day of month, month and day of week fields. Fields accept "*",
+// numbers, ranges ("1-5"), steps ("*/15", "0-30/10") and lists of those.
+// Sunday is day 0 or 7.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q: %w", rest, err)
+		}
+		if d < MinInterval {
+			return nil, fmt.Errorf("interval %s is shorter than %s", d, MinInterval)
+		}
+		return Every(d), nil
+	}
+	if expr, ok := shorthands[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, got %d", spec, len(fields))
+	}
+
+	var e expression
+	var err error
+	if e.minutes, _, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if e.hours, _, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if e.days, e.anyDay, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if e.months, _, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if e.weekdays, e.anyWeekday, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if e.weekdays&(1<<7) != 0 {
+		e.weekdays |= 1 << 0
+	}
+	return e, nil
+}
+
+// Every is a schedule that runs at a fixed interval after the previous run
+type Every time.Duration
+
+// Next returns after plus the interval
+func (d Every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(d))
+}
+
+// expression is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type expression struct {
+	minutes, hours, days, months, weekdays uint64
+	anyDay, anyWeekday                     bool // the day fields were "*"
+}
+
+// Next returns the first minute after the given time that matches e
+func (e expression) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case e.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !e.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case e.hours&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case e.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay reports whether t's day matches the day fields. As in cron, a
+// day matches either field if both are restricted.
+func (e expression) matchDay(t time.Time) bool {
+	day := e.days&(1<<uint(t.Day())) != 0
+	weekday := e.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case e.anyDay && e.anyWeekday:
+		return true
+	case e.anyDay:
+		return weekday
+	case e.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// parseField parses a comma-separated field into a bit set of the values
+// between min and max it matches, and reports whether it was "*"
+func parseField(field string, min, max int) (uint64, bool, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, false, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			first, last, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseValue(first, min, max); err != nil {
+				return 0, false, err
+			}
+			if hi, err = parseValue(last, min, max); err != nil {
+				return 0, false, err
+			}
+			if lo > hi {
+				return 0, false, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			var err error
+			if lo, err = parseValue(rangePart, min, max); err != nil {
+				return 0, false, err
+			}
+			if hasStep {
+				hi = max
+			} else {
+				hi = lo
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, field == "*", nil
+}
+
+// parseValue parses a number between min and max
+func parseValue(s string, min, max int) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, min, max)
+	}
+	return v, nil
+}
@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Next(t *testing.T) {
+	// A Wednesday
+	start := time.Date(2024, time.May, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "@every 6h", want: start.Add(6 * time.Hour)},
+		{spec: "* * * * *", want: time.Date(2024, time.May, 15, 10, 8, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2024, time.May, 15, 10, 15, 0, 0, time.UTC)},
+		{spec: "0 3 * * *", want: time.Date(2024, time.May, 16, 3, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", want: time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", want: time.Date(2024, time.May, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", want: time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", want: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "30 8 * * 1-5", want: time.Date(2024, time.May, 16, 8, 30, 0, 0, time.UTC)},
+		{spec: "0 12 * * 7", want: time.Date(2024, time.May, 19, 12, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1,15 * *", want: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1 * 3", want: time.Date(2024, time.May, 22, 0, 0, 0, 0, time.UTC)}, // either day field matches
+		{spec: "5-20/5 10 * * *", want: time.Date(2024, time.May, 15, 10, 10, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+			if got := schedule.Next(start); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"@every 30s",
+		"@every soon",
+		"@sometimes",
+	}
+
+	for _, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should fail", spec)
+		}
+	}
+}
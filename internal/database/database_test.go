@@ -0,0 +1,46 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		query    string
+		expected string
+	}{
+		{
+			name:     "sqlite keeps placeholders",
+			dialect:  SQLite,
+			query:    "SELECT * FROM users WHERE id = ? AND username = ?",
+			expected: "SELECT * FROM users WHERE id = ? AND username = ?",
+		},
+		{
+			name:     "postgres numbers placeholders",
+			dialect:  Postgres,
+			query:    "SELECT * FROM users WHERE id = ? AND username = ?",
+			expected: "SELECT * FROM users WHERE id = $1 AND username = $2",
+		},
+		{
+			name:     "postgres skips string literals",
+			dialect:  Postgres,
+			query:    "SELECT '?' FROM users WHERE id = ?",
+			expected: "SELECT '?' FROM users WHERE id = $1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &DB{dialect: tt.dialect}
+			if got := db.Rebind(tt.query); got != tt.expected {
+				t.Errorf("Rebind() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOpenPostgres_EmptyDSN(t *testing.T) {
+	if _, err := OpenPostgres("", DefaultPoolConfig()); err == nil {
+		t.Error("OpenPostgres() should fail for an empty DSN")
+	}
+}
@@ -0,0 +1,93 @@
+// Package dbtest provides freshly migrated databases for store tests.
+package dbtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+)
+
+// PostgresDSNEnv names the environment variable holding the DSN of a
+// PostgreSQL server to run tests against. Postgres tests are skipped when unset.
+const PostgresDSNEnv = "PLAYPORT_TEST_POSTGRES_DSN"
+
+// NewSQLite returns a migrated SQLite database in a temporary directory.
+// The database is closed automatically when the test finishes.
+func NewSQLite(t testing.TB) *database.DB {
+	t.Helper()
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "playport.db"))
+	if err != nil {
+		t.Fatalf("Failed to open sqlite database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate sqlite database: %v", err)
+	}
+
+	return db
+}
+
+// NewPostgres returns a migrated PostgreSQL database isolated in its own
+// schema, which is dropped when the test finishes. The test is skipped
+// unless PLAYPORT_TEST_POSTGRES_DSN is set.
+func NewPostgres(t testing.TB) *database.DB {
+	t.Helper()
+
+	dsn := os.Getenv(PostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping PostgreSQL test", PostgresDSNEnv)
+	}
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("Failed to generate schema name: %v", err)
+	}
+	schema := "playport_test_" + hex.EncodeToString(b)
+
+	admin, err := database.OpenPostgres(dsn, database.DefaultPoolConfig())
+	if err != nil {
+		t.Fatalf("Failed to open postgres database: %v", err)
+	}
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	db, err := database.OpenPostgres(withSearchPath(dsn, schema), database.DefaultPoolConfig())
+	if err != nil {
+		t.Fatalf("Failed to open postgres database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate postgres database: %v", err)
+	}
+
+	return db
+}
+
+// withSearchPath adds a search_path runtime parameter to a URL or keyword/value DSN
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// migration is a single versioned schema change
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrationLockID is the PostgreSQL advisory lock key held while migrating,
+// so that several instances starting at once apply migrations only once.
+const migrationLockID = 7_467_352_001
+
+// Migrate applies all pending schema migrations for the database's dialect.
+// Pending migrations are applied in a single transaction and recorded in the
+// schema_migrations table, so calling Migrate repeatedly is safe.
+func (db *DB) Migrate() error {
+	migrations, err := loadMigrations(db.dialect)
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(func(tx *Tx) error {
+		if db.dialect == Postgres {
+			if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+		}
+
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)`); err != nil {
+			return fmt.Errorf("failed to create schema_migrations table: %w", err)
+		}
+
+		applied := make(map[int]bool)
+		rows, err := tx.Query("SELECT version FROM schema_migrations")
+		if err != nil {
+			return fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		for rows.Next() {
+			var version int
+			if err := rows.Scan(&version); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to read applied migrations: %w", err)
+			}
+			applied[version] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+
+		for _, m := range migrations {
+			if applied[m.version] {
+				continue
+			}
+
+			if _, err := tx.Exec(m.sql); err != nil {
+				return fmt.Errorf("migration %s failed: %w", m.name, err)
+			}
+			if _, err := tx.Exec("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", m.version, time.Now().UTC()); err != nil {
+				return fmt.Errorf("failed to record migration %s: %w", m.name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// loadMigrations reads the embedded migrations for a dialect, sorted by version.
+// Files are named "<version>_<description>.sql", e.g. "0001_init.sql".
+func loadMigrations(dialect Dialect) ([]migration, error) {
+	dir := path.Join("migrations", string(dialect))
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s: %w", dialect, err)
+	}
+
+	var migrations []migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+
+		content, err := fs.ReadFile(migrationsFS, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, migration{version: version, name: name, sql: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
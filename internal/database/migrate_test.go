@@ -0,0 +1,65 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrate_Idempotent(t *testing.T) {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite() failed: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	// Running migrations again must be a no-op
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("second Migrate() failed: %v", err)
+	}
+
+	migrations, err := loadMigrations(SQLite)
+	if err != nil {
+		t.Fatalf("loadMigrations() failed: %v", err)
+	}
+
+	var applied int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&applied); err != nil {
+		t.Fatalf("Failed to count applied migrations: %v", err)
+	}
+
+	if applied != len(migrations) {
+		t.Errorf("Expected %d applied migrations, got %d", len(migrations), applied)
+	}
+}
+
+func TestOpenSQLite_EmptyPath(t *testing.T) {
+	if _, err := OpenSQLite(""); err == nil {
+		t.Error("OpenSQLite() should fail for an empty path")
+	}
+}
+
+func TestLoadMigrations_AllDialects(t *testing.T) {
+	sqlite, err := loadMigrations(SQLite)
+	if err != nil {
+		t.Fatalf("loadMigrations(SQLite) failed: %v", err)
+	}
+
+	postgres, err := loadMigrations(Postgres)
+	if err != nil {
+		t.Fatalf("loadMigrations(Postgres) failed: %v", err)
+	}
+
+	// Both dialects must define the same schema versions
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("Expected the same number of migrations, got %d sqlite and %d postgres", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].version != postgres[i].version {
+			t.Errorf("Migration %d: sqlite version %d, postgres version %d", i, sqlite[i].version, postgres[i].version)
+		}
+	}
+}
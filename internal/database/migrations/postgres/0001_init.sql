@@ -0,0 +1,37 @@
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    username TEXT UNIQUE,
+    password_hash TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE connections (
+    id TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    external_user_id TEXT NOT NULL DEFAULT '',
+    external_user_name TEXT NOT NULL DEFAULT '',
+    access_token TEXT NOT NULL DEFAULT '',
+    refresh_token TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    connected BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (provider, user_id)
+);
+
+CREATE INDEX idx_connections_user_id ON connections (user_id);
+
+CREATE TABLE sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
+
+CREATE TABLE oauth_states (
+    state TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,5 @@
+-- Allow several accounts per provider per user, keyed by the provider's account ID.
+ALTER TABLE connections DROP CONSTRAINT connections_provider_user_id_key;
+
+ALTER TABLE connections ADD CONSTRAINT connections_provider_user_id_external_user_id_key
+    UNIQUE (provider, user_id, external_user_id);
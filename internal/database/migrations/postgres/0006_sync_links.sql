@@ -0,0 +1,20 @@
+-- Links between a source playlist and the target playlist it is kept in
+-- step with. tracks holds the JSON-encoded source tracks as of the last
+-- sync, with the target track each was matched to.
+CREATE TABLE sync_links (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    source_provider TEXT NOT NULL,
+    source_account TEXT NOT NULL DEFAULT '',
+    source_playlist_id TEXT NOT NULL,
+    target_provider TEXT NOT NULL,
+    target_account TEXT NOT NULL DEFAULT '',
+    target_playlist_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    tracks TEXT NOT NULL,
+    last_synced_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_sync_links_user_id ON sync_links (user_id);
@@ -0,0 +1,25 @@
+-- Allow several accounts per provider per user, keyed by the provider's account ID.
+-- SQLite cannot alter constraints in place, so the table is rebuilt.
+CREATE TABLE connections_new (
+    id TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    external_user_id TEXT NOT NULL DEFAULT '',
+    external_user_name TEXT NOT NULL DEFAULT '',
+    access_token TEXT NOT NULL DEFAULT '',
+    refresh_token TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    connected BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (provider, user_id, external_user_id)
+);
+
+INSERT INTO connections_new SELECT * FROM connections;
+
+DROP TABLE connections;
+
+ALTER TABLE connections_new RENAME TO connections;
+
+CREATE INDEX idx_connections_user_id ON connections (user_id);
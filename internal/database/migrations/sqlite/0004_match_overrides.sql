@@ -0,0 +1,13 @@
+-- Per-user pins of the target track a source track is transferred as.
+-- An empty target_track_id skips the track.
+CREATE TABLE match_overrides (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    source_provider TEXT NOT NULL,
+    source_track_id TEXT NOT NULL,
+    target_provider TEXT NOT NULL,
+    target_track_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, source_provider, source_track_id, target_provider)
+);
@@ -0,0 +1,5 @@
+-- Two-way sync links apply the edits made to either playlist to the other.
+-- conflicts holds the JSON-encoded conflicts left unresolved by the last sync.
+ALTER TABLE sync_links ADD COLUMN two_way BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE sync_links ADD COLUMN conflict_policy TEXT NOT NULL DEFAULT '';
+ALTER TABLE sync_links ADD COLUMN conflicts TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,36 @@
+-- Schedules that run a transfer or a sync of a playlist again and again,
+-- and the outcomes of their most recent runs
+CREATE TABLE schedules (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    source_provider TEXT NOT NULL,
+    source_account TEXT NOT NULL DEFAULT '',
+    source_playlist_id TEXT NOT NULL,
+    target_provider TEXT NOT NULL,
+    target_account TEXT NOT NULL DEFAULT '',
+    sync_link_id TEXT NOT NULL DEFAULT '',
+    spec TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_status TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_schedules_user_id ON schedules (user_id);
+CREATE INDEX idx_schedules_next_run_at ON schedules (next_run_at);
+
+CREATE TABLE schedule_runs (
+    id TEXT PRIMARY KEY,
+    schedule_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_schedule_runs_schedule_id ON schedule_runs (schedule_id, started_at);
+CREATE INDEX idx_schedule_runs_user_id ON schedule_runs (user_id, started_at);
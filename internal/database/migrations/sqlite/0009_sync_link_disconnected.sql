@@ -0,0 +1,3 @@
+-- disconnected explains why a sync link cannot sync since one of its
+-- accounts was disconnected. It is cleared by the next successful sync.
+ALTER TABLE sync_links ADD COLUMN disconnected TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- verifier is the PKCE code verifier of the authorization request a state
+-- belongs to, kept with the state so that any instance can finish the
+-- callback. It is empty for providers without PKCE.
+ALTER TABLE oauth_states ADD COLUMN verifier TEXT NOT NULL DEFAULT '';
@@ -9,41 +9,66 @@ import (
 	"regexp"
 	"time"
 
+	"golang.org/x/oauth2"
+
 	"github.com/JanikSachs/PlayPort/internal/auth"
 	"github.com/JanikSachs/PlayPort/internal/middleware"
-	"github.com/JanikSachs/PlayPort/internal/providers/spotify"
-	"github.com/JanikSachs/PlayPort/internal/providers/youtubemusic"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/storage"
+	"github.com/JanikSachs/PlayPort/internal/version"
 )
 
 var usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
 
 // AuthHandlers contains OAuth authentication handlers
 type AuthHandlers struct {
-	spotifyProvider      *spotify.SpotifyProvider
-	youtubeMusicProvider *youtubemusic.YouTubeMusicProvider
-	stateStore           auth.StateStore
-	userStore            storage.UserStore
-	sessionStore         auth.SessionStore
-	templates            *template.Template
-	spotifyEnabled       bool
-	youtubeMusicEnabled  bool
+	registry     []providers.Registration
+	stateStore   auth.StateStore
+	userStore    storage.UserStore
+	sessionStore auth.SessionStore
+	templates    *template.Template
 }
 
-// NewAuthHandlers creates new auth handlers
-func NewAuthHandlers(spotifyProvider *spotify.SpotifyProvider, youtubeMusicProvider *youtubemusic.YouTubeMusicProvider, stateStore auth.StateStore, userStore storage.UserStore, sessionStore auth.SessionStore, templates *template.Template, spotifyEnabled bool, youtubeMusicEnabled bool) *AuthHandlers {
+// NewAuthHandlers creates new auth handlers for the providers of registry
+func NewAuthHandlers(registry []providers.Registration, stateStore auth.StateStore, userStore storage.UserStore, sessionStore auth.SessionStore, templates *template.Template) *AuthHandlers {
 	return &AuthHandlers{
-		spotifyProvider:      spotifyProvider,
-		youtubeMusicProvider: youtubeMusicProvider,
-		stateStore:           stateStore,
-		userStore:            userStore,
-		sessionStore:         sessionStore,
-		templates:            templates,
-		spotifyEnabled:       spotifyEnabled,
-		youtubeMusicEnabled:  youtubeMusicEnabled,
+		registry:     registry,
+		stateStore:   stateStore,
+		userStore:    userStore,
+		sessionStore: sessionStore,
+		templates:    templates,
 	}
 }
 
+// tokenSaver is implemented by providers that store the connection of an
+// OAuth token
+type tokenSaver interface {
+	SaveConnection(ctx context.Context, token *oauth2.Token, userID string) error
+}
+
+// oauthProvider is implemented by providers that link accounts through an
+// OAuth authorization code flow
+type oauthProvider interface {
+	tokenSaver
+	AuthURL(state string) string
+	Exchange(ctx context.Context, code string) (*oauth2.Token, error)
+}
+
+// pkceProvider is implemented by providers that link accounts through an
+// OAuth authorization code flow with a PKCE code verifier
+type pkceProvider interface {
+	tokenSaver
+	AuthURL(state, verifier string) string
+	Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error)
+}
+
+// musicKitProvider is implemented by providers that link accounts through
+// MusicKit JS
+type musicKitProvider interface {
+	DeveloperToken() (string, error)
+	SaveConnection(ctx context.Context, musicUserToken, userID string) error
+}
+
 // HandleLoginPage renders the login page
 func (h *AuthHandlers) HandleLoginPage(w http.ResponseWriter, r *http.Request) {
 	// Redirect to home if already authenticated
@@ -265,79 +290,125 @@ func (h *AuthHandlers) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/login", http.StatusFound)
 }
 
-// HandleSpotifyStart redirects to Spotify authorization
-func (h *AuthHandlers) HandleSpotifyStart(w http.ResponseWriter, r *http.Request) {
-	if !h.spotifyEnabled {
-		http.Error(w, "Spotify is not configured", http.StatusServiceUnavailable)
-		return
-	}
-
-	// Generate state for CSRF protection
-	state, err := h.stateStore.Generate()
-	if err != nil {
-		log.Printf("Failed to generate state: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+// HandleOAuthStart returns a handler redirecting to the authorization
+// page of the registered provider
+func (h *AuthHandlers) HandleOAuthStart(reg providers.Registration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !reg.Enabled {
+			http.Error(w, reg.Name+" is not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		// Generate state for CSRF protection, kept with the PKCE verifier
+		// for providers that use one
+		var authURL string
+		switch provider := reg.Provider.(type) {
+		case pkceProvider:
+			state, verifier, err := h.stateStore.GeneratePKCE()
+			if err != nil {
+				log.Printf("Failed to generate state: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			authURL = provider.AuthURL(state, verifier)
+		case oauthProvider:
+			state, err := h.stateStore.Generate()
+			if err != nil {
+				log.Printf("Failed to generate state: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			authURL = provider.AuthURL(state)
+		default:
+			http.Error(w, reg.Name+" does not support OAuth", http.StatusNotFound)
+			return
+		}
+
+		// Redirect to the provider's authorization page
+		http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 	}
-
-	// Redirect to Spotify authorization
-	authURL := h.spotifyProvider.AuthURL(state)
-	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
-// HandleSpotifyCallback handles the OAuth callback from Spotify
-func (h *AuthHandlers) HandleSpotifyCallback(w http.ResponseWriter, r *http.Request) {
-	if !h.spotifyEnabled {
-		http.Error(w, "Spotify is not configured", http.StatusServiceUnavailable)
-		return
-	}
-
-	// Validate state
-	state := r.URL.Query().Get("state")
-	if !h.stateStore.Validate(state) {
-		log.Printf("Invalid OAuth state")
-		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
-		return
-	}
-
-	// Check for error from Spotify
-	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
-		log.Printf("Spotify OAuth error: %s", errMsg)
-		http.Error(w, fmt.Sprintf("Spotify authorization failed: %s", errMsg), http.StatusBadRequest)
-		return
+// HandleOAuthCallback returns a handler for the OAuth callback from the
+// registered provider
+func (h *AuthHandlers) HandleOAuthCallback(reg providers.Registration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !reg.Enabled {
+			http.Error(w, reg.Name+" is not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		// Validate state
+		verifier, ok := h.stateStore.ValidatePKCE(r.URL.Query().Get("state"))
+		if !ok {
+			log.Printf("Invalid OAuth state")
+			http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+			return
+		}
+
+		// Check for error from the provider; Deezer reports a refusal as
+		// error_reason
+		errMsg := r.URL.Query().Get("error")
+		if errMsg == "" {
+			errMsg = r.URL.Query().Get("error_reason")
+		}
+		if errMsg != "" {
+			log.Printf("%s OAuth error: %s", reg.Name, errMsg)
+			http.Error(w, fmt.Sprintf("%s authorization failed: %s", reg.Name, errMsg), http.StatusBadRequest)
+			return
+		}
+
+		// Get authorization code
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "Missing authorization code", http.StatusBadRequest)
+			return
+		}
+
+		// Exchange code for token
+		ctx := context.Background()
+		var token *oauth2.Token
+		var err error
+		switch provider := reg.Provider.(type) {
+		case pkceProvider:
+			token, err = provider.Exchange(ctx, code, verifier)
+		case oauthProvider:
+			token, err = provider.Exchange(ctx, code)
+		default:
+			http.Error(w, reg.Name+" does not support OAuth", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to exchange code: %v", err)
+			http.Error(w, "Failed to exchange authorization code", http.StatusInternalServerError)
+			return
+		}
+
+		// Save connection
+		if err := reg.Provider.(tokenSaver).SaveConnection(ctx, token, middleware.UserIDFromContext(r.Context())); err != nil {
+			log.Printf("Failed to save connection: %v", err)
+			http.Error(w, "Failed to save connection", http.StatusInternalServerError)
+			return
+		}
+
+		// Redirect to providers page
+		http.Redirect(w, r, "/providers", http.StatusFound)
 	}
+}
 
-	// Get authorization code
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+// HandleAppleMusicStart renders the MusicKit JS connect page, which asks
+// the user to authorize PlayPort and posts the Music User Token back
+func (h *AuthHandlers) HandleAppleMusicStart(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.appleMusic()
+	if !ok {
+		http.Error(w, "Apple Music is not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	// Exchange code for token
-	ctx := context.Background()
-	token, err := h.spotifyProvider.Exchange(ctx, code)
+	developerToken, err := provider.DeveloperToken()
 	if err != nil {
-		log.Printf("Failed to exchange code: %v", err)
-		http.Error(w, "Failed to exchange authorization code", http.StatusInternalServerError)
-		return
-	}
-
-	// Save connection
-	if err := h.spotifyProvider.SaveConnection(ctx, token, middleware.UserIDFromContext(r.Context())); err != nil {
-		log.Printf("Failed to save connection: %v", err)
-		http.Error(w, "Failed to save connection", http.StatusInternalServerError)
-		return
-	}
-
-	// Redirect to providers page
-	http.Redirect(w, r, "/providers", http.StatusFound)
-}
-
-// HandleYouTubeMusicStart redirects to Google authorization for YouTube Music
-func (h *AuthHandlers) HandleYouTubeMusicStart(w http.ResponseWriter, r *http.Request) {
-	if !h.youtubeMusicEnabled {
-		http.Error(w, "YouTube Music is not configured", http.StatusServiceUnavailable)
+		log.Printf("Failed to sign Apple Music developer token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
@@ -349,57 +420,64 @@ func (h *AuthHandlers) HandleYouTubeMusicStart(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Redirect to Google authorization
-	authURL := h.youtubeMusicProvider.AuthURL(state)
-	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
-}
+	data := map[string]interface{}{
+		"Title":          "Connect Apple Music",
+		"DeveloperToken": developerToken,
+		"State":          state,
+		"Version":        version.Version,
+		"Username":       usernameFromContext(h.userStore, r),
+	}
 
-// HandleYouTubeMusicCallback handles the OAuth callback from Google for YouTube Music
-func (h *AuthHandlers) HandleYouTubeMusicCallback(w http.ResponseWriter, r *http.Request) {
-	if !h.youtubeMusicEnabled {
-		http.Error(w, "YouTube Music is not configured", http.StatusServiceUnavailable)
-		return
+	if err := h.templates.ExecuteTemplate(w, "applemusic-connect.html", data); err != nil {
+		log.Printf("Error rendering Apple Music connect template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
+}
 
-	// Validate state
-	state := r.URL.Query().Get("state")
-	if !h.stateStore.Validate(state) {
-		log.Printf("Invalid OAuth state")
-		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+// HandleAppleMusicCallback saves the Music User Token posted by the
+// connect page
+func (h *AuthHandlers) HandleAppleMusicCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.appleMusic()
+	if !ok {
+		http.Error(w, "Apple Music is not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	// Check for error from Google
-	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
-		log.Printf("YouTube Music OAuth error: %s", errMsg)
-		http.Error(w, fmt.Sprintf("YouTube Music authorization failed: %s", errMsg), http.StatusBadRequest)
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Get authorization code
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+	// Validate state
+	if !h.stateStore.Validate(r.FormValue("state")) {
+		log.Printf("Invalid Apple Music connect state")
+		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
 		return
 	}
 
-	// Exchange code for token
-	ctx := context.Background()
-	token, err := h.youtubeMusicProvider.Exchange(ctx, code)
-	if err != nil {
-		log.Printf("Failed to exchange code: %v", err)
-		http.Error(w, "Failed to exchange authorization code", http.StatusInternalServerError)
+	musicUserToken := r.FormValue("music_user_token")
+	if musicUserToken == "" {
+		http.Error(w, "Missing Music User Token", http.StatusBadRequest)
 		return
 	}
 
 	// Save connection
-	if err := h.youtubeMusicProvider.SaveConnection(ctx, token, middleware.UserIDFromContext(r.Context())); err != nil {
+	if err := provider.SaveConnection(r.Context(), musicUserToken, middleware.UserIDFromContext(r.Context())); err != nil {
 		log.Printf("Failed to save connection: %v", err)
 		http.Error(w, "Failed to save connection", http.StatusInternalServerError)
 		return
 	}
 
 	// Redirect to providers page
-	http.Redirect(w, r, "/providers", http.StatusFound)
+	http.Redirect(w, r, "/providers", http.StatusSeeOther)
 }
 
+// appleMusic returns the Apple Music provider, if it is enabled
+func (h *AuthHandlers) appleMusic() (musicKitProvider, bool) {
+	reg := providers.Lookup(h.registry, "applemusic")
+	if !reg.Enabled {
+		return nil, false
+	}
+	provider, ok := reg.Provider.(musicKitProvider)
+	return provider, ok
+}
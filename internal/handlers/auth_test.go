@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"html/template"
 	"net/http"
 	"net/http/httptest"
@@ -8,8 +10,11 @@ import (
 	"strings"
 	"testing"
 
+	"golang.org/x/oauth2"
+
 	"github.com/JanikSachs/PlayPort/internal/auth"
 	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
@@ -23,7 +28,7 @@ func setupTestAuthHandlers(t *testing.T) (*AuthHandlers, *auth.InMemoryStateStor
 		t.Fatalf("Failed to parse templates: %v", err)
 	}
 
-	ah := NewAuthHandlers(nil, nil, stateStore, userStore, sessionStore, templates, false, false)
+	ah := NewAuthHandlers(nil, stateStore, userStore, sessionStore, templates)
 	return ah, stateStore, userStore, sessionStore
 }
 
@@ -470,3 +475,107 @@ func TestHandleLogin_SessionValidThroughMiddleware(t *testing.T) {
 		t.Error("Middleware should inject userID into context after successful login")
 	}
 }
+
+// fakeOAuthProvider is an OAuth provider that accepts the code "good-code"
+// and records the verifier and the saved token
+type fakeOAuthProvider struct {
+	*providers.MockProvider
+	verifier string // verifier passed to AuthURL
+	saved    string // access token passed to SaveConnection
+}
+
+func (p *fakeOAuthProvider) AuthURL(state string) string {
+	return "https://auth.example.com/authorize?state=" + url.QueryEscape(state)
+}
+
+func (p *fakeOAuthProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	if code != "good-code" {
+		return nil, errors.New("invalid code")
+	}
+	return &oauth2.Token{AccessToken: "token-1"}, nil
+}
+
+func (p *fakeOAuthProvider) SaveConnection(ctx context.Context, token *oauth2.Token, userID string) error {
+	p.saved = token.AccessToken
+	return nil
+}
+
+// fakePKCEProvider is a fakeOAuthProvider using a PKCE code verifier
+type fakePKCEProvider struct {
+	fakeOAuthProvider
+}
+
+func (p *fakePKCEProvider) AuthURL(state, verifier string) string {
+	p.verifier = verifier
+	return p.fakeOAuthProvider.AuthURL(state)
+}
+
+func (p *fakePKCEProvider) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	if verifier == "" || verifier != p.verifier {
+		return nil, errors.New("invalid verifier")
+	}
+	return p.fakeOAuthProvider.Exchange(ctx, code)
+}
+
+func TestHandleOAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider interface {
+			providers.Provider
+			tokenSaver
+		}
+	}{
+		{name: "oauth", provider: &fakeOAuthProvider{MockProvider: providers.NewMockProvider()}},
+		{name: "pkce", provider: &fakePKCEProvider{fakeOAuthProvider{MockProvider: providers.NewMockProvider()}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ah, _, _, _ := setupTestAuthHandlers(t)
+			reg := providers.Registration{Slug: "fake", Name: "Fake", Login: providers.LoginOAuth, Enabled: true, Provider: tt.provider}
+
+			w := httptest.NewRecorder()
+			ah.HandleOAuthStart(reg)(w, httptest.NewRequest(http.MethodGet, "/auth/fake/start", nil))
+			if w.Code != http.StatusTemporaryRedirect {
+				t.Fatalf("Expected a redirect, got %d", w.Code)
+			}
+			location, _ := url.Parse(w.Header().Get("Location"))
+			state := location.Query().Get("state")
+
+			w = httptest.NewRecorder()
+			ah.HandleOAuthCallback(reg)(w, httptest.NewRequest(http.MethodGet, "/auth/fake/callback?code=good-code&state="+url.QueryEscape(state), nil))
+			if w.Code != http.StatusFound {
+				t.Fatalf("Expected a redirect to the providers page, got %d: %s", w.Code, w.Body)
+			}
+
+			var saved string
+			switch p := tt.provider.(type) {
+			case *fakeOAuthProvider:
+				saved = p.saved
+			case *fakePKCEProvider:
+				saved = p.saved
+			}
+			if saved != "token-1" {
+				t.Errorf("Expected the token to be saved, got %q", saved)
+			}
+
+			// The state is used up
+			w = httptest.NewRecorder()
+			ah.HandleOAuthCallback(reg)(w, httptest.NewRequest(http.MethodGet, "/auth/fake/callback?code=good-code&state="+url.QueryEscape(state), nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected a reused state to be rejected, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestHandleOAuth_Disabled(t *testing.T) {
+	ah, _, _, _ := setupTestAuthHandlers(t)
+	reg := providers.Registration{Slug: "fake", Name: "Fake", Login: providers.LoginOAuth}
+
+	w := httptest.NewRecorder()
+	ah.HandleOAuthStart(reg)(w, httptest.NewRequest(http.MethodGet, "/auth/fake/start", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "Fake is not configured") {
+		t.Errorf("Expected 503 for a disabled provider, got %d: %s", w.Code, w.Body)
+	}
+}
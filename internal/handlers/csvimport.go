@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/playlistfile"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// csvPreviewRows is the number of rows shown when mapping CSV columns
+const csvPreviewRows = 5
+
+// csvFieldLabels names the mappable CSV fields in the mapping form
+var csvFieldLabels = map[string]string{
+	playlistfile.FieldTitle:    "Title",
+	playlistfile.FieldArtist:   "Artist",
+	playlistfile.FieldAlbum:    "Album",
+	playlistfile.FieldDuration: "Duration",
+	playlistfile.FieldISRC:     "ISRC",
+	playlistfile.FieldID:       "Track ID / URI",
+}
+
+// csvFieldChoice is one row of the column mapping form
+type csvFieldChoice struct {
+	Field    string
+	Label    string
+	Column   int // selected column, -1 for none
+	Required bool
+}
+
+// importCSV imports an uploaded CSV file whose layout is recognised, and
+// otherwise asks the user to map its columns
+func (h *Handlers) importCSV(w http.ResponseWriter, req services.TransferRequest, file io.Reader, name string) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := playlistfile.PreviewCSV(bytes.NewReader(data), csvPreviewRows)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !preview.Detected {
+		h.renderCSVMapping(w, req, string(data), name, preview, "")
+		return
+	}
+
+	playlist, err := playlistfile.ReadCSVWithMapping(bytes.NewReader(data), name, preview.Mapping)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.startImport(w, req, playlist)
+}
+
+// HandleMapCSV is an HTMX endpoint that imports a CSV file with the column
+// mapping chosen in the mapping form. Invalid mappings show the form again.
+func (h *Handlers) HandleMapCSV(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*maxUploadBytes)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	req := services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		TargetProvider: r.FormValue("target_provider"),
+		TargetAccount:  r.FormValue("target_account"),
+	}
+	csvText := r.FormValue("csv")
+	name := r.FormValue("name")
+	if req.TargetProvider == "" || csvText == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	mapping := playlistfile.CSVMapping{
+		Columns:      map[string]int{},
+		DurationUnit: r.FormValue("duration_unit"),
+	}
+	for _, field := range playlistfile.CSVFields {
+		value := r.FormValue("column_" + field)
+		if value == "" {
+			continue
+		}
+		column, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid column for "+csvFieldLabels[field], http.StatusBadRequest)
+			return
+		}
+		mapping.Columns[field] = column
+	}
+
+	playlist, err := playlistfile.ReadCSVWithMapping(strings.NewReader(csvText), name, mapping)
+	if err != nil {
+		preview, perr := playlistfile.PreviewCSV(strings.NewReader(csvText), csvPreviewRows)
+		if perr != nil {
+			http.Error(w, perr.Error(), http.StatusBadRequest)
+			return
+		}
+		preview.Mapping = mapping
+		h.renderCSVMapping(w, req, csvText, name, preview, err.Error())
+		return
+	}
+
+	h.startImport(w, req, playlist)
+}
+
+// renderCSVMapping renders the preview and column mapping form of a CSV
+// upload. The file travels along in the form so nothing is kept server-side.
+func (h *Handlers) renderCSVMapping(w http.ResponseWriter, req services.TransferRequest, csvText, name string, preview playlistfile.CSVPreview, errMsg string) {
+	fields := make([]csvFieldChoice, 0, len(playlistfile.CSVFields))
+	for _, field := range playlistfile.CSVFields {
+		column, ok := preview.Mapping.Columns[field]
+		if !ok {
+			column = -1
+		}
+		fields = append(fields, csvFieldChoice{
+			Field:    field,
+			Label:    csvFieldLabels[field],
+			Column:   column,
+			Required: field == playlistfile.FieldTitle,
+		})
+	}
+
+	durationUnit := preview.Mapping.DurationUnit
+	if durationUnit == "" {
+		durationUnit = playlistfile.DurationSeconds
+	}
+
+	data := map[string]interface{}{
+		"Header":         preview.Header,
+		"Rows":           preview.Rows,
+		"Fields":         fields,
+		"DurationUnit":   durationUnit,
+		"CSV":            csvText,
+		"Name":           name,
+		"TargetProvider": req.TargetProvider,
+		"TargetAccount":  req.TargetAccount,
+		"Error":          errMsg,
+	}
+
+	if err := h.templates.ExecuteTemplate(w, "csv-mapping.html", data); err != nil {
+		log.Printf("Error rendering CSV mapping: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const unknownCSV = "Nr;Künstler;Stück\n1;Nena;99 Luftballons\n2;Falco;Rock Me Amadeus\n"
+
+func TestHandleUploadPlaylist_CSV(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	exportify := "Track URI,Track Name,Artist Name(s),Track Duration (ms)\nspotify:track:1,Hello,Adele,295502\n"
+	w := uploadFile(t, handlers, "Exportify.csv", exportify)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Transfer Status") {
+		t.Error("Recognised CSV layouts should be imported straight away")
+	}
+
+	w = uploadFile(t, handlers, "Liste.csv", unknownCSV)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Map CSV Columns") {
+		t.Error("Unknown CSV layouts should show the mapping form")
+	}
+	if !strings.Contains(body, "<td>99 Luftballons</td>") {
+		t.Error("The mapping form should preview the file's rows")
+	}
+	if !strings.Contains(body, `name="column_title" required`) {
+		t.Error("The title column should be required")
+	}
+}
+
+func TestHandleMapCSV(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/transfer/upload/csv", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handlers.HandleMapCSV(w, req)
+		return w
+	}
+
+	form := url.Values{
+		"target_provider": {"Mock Music"},
+		"csv":             {unknownCSV},
+		"name":            {"Neue Deutsche Welle"},
+		"column_title":    {"2"},
+		"column_artist":   {"1"},
+		"column_album":    {""},
+		"duration_unit":   {"seconds"},
+	}
+	w := post(form)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Transfer Status") {
+		t.Error("A valid mapping should start the import")
+	}
+
+	form.Del("column_title")
+	w = post(form)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Map CSV Columns") || !strings.Contains(body, "track title") {
+		t.Error("An invalid mapping should show the form again with the error")
+	}
+
+	form.Set("column_title", "first")
+	if w := post(form); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for non-numeric columns, got %d", w.Code)
+	}
+}
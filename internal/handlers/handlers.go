@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"html/template"
 	"log"
+	"mime"
 	"net/http"
-	"time"
+	"path/filepath"
+	"strings"
 
 	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/playlistfile"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/services"
 	"github.com/JanikSachs/PlayPort/internal/storage"
 	"github.com/JanikSachs/PlayPort/internal/version"
@@ -15,23 +21,23 @@ import (
 
 // Handlers contains all HTTP handlers
 type Handlers struct {
-	transferService       *services.TransferService
-	templates             *template.Template
-	connectionStore       storage.ConnectionStore
-	userStore             storage.UserStore
-	spotifyEnabled        bool
-	youtubeMusicEnabled   bool
+	transferService *services.TransferService
+	templates       *template.Template
+	connectionStore storage.ConnectionStore
+	userStore       storage.UserStore
+	registry        []providers.Registration
+	libraries       *libraryUploads
 }
 
-// NewHandlers creates a new Handlers instance
-func NewHandlers(transferService *services.TransferService, templates *template.Template, connectionStore storage.ConnectionStore, userStore storage.UserStore, spotifyEnabled bool, youtubeMusicEnabled bool) *Handlers {
+// NewHandlers creates a new Handlers instance for the providers of registry
+func NewHandlers(transferService *services.TransferService, templates *template.Template, connectionStore storage.ConnectionStore, userStore storage.UserStore, registry []providers.Registration) *Handlers {
 	return &Handlers{
-		transferService:     transferService,
-		templates:           templates,
-		connectionStore:     connectionStore,
-		userStore:           userStore,
-		spotifyEnabled:      spotifyEnabled,
-		youtubeMusicEnabled: youtubeMusicEnabled,
+		transferService: transferService,
+		templates:       templates,
+		connectionStore: connectionStore,
+		userStore:       userStore,
+		registry:        registry,
+		libraries:       newLibraryUploads(),
 	}
 }
 
@@ -57,43 +63,26 @@ func (h *Handlers) HandleHome(w http.ResponseWriter, r *http.Request) {
 
 // HandleProviders renders the providers page
 func (h *Handlers) HandleProviders(w http.ResponseWriter, r *http.Request) {
-	providers := h.transferService.ListProviders()
+	names := h.transferService.ListProviders()
 
-	// Check Spotify connection status
+	// List connected accounts of the providers with their own sections,
+	// both by provider slug
 	userID := middleware.UserIDFromContext(r.Context())
-	spotifyConnected := false
-	spotifyUserName := ""
-
-	if h.spotifyEnabled {
-		conn, err := h.connectionStore.Get("spotify", userID)
-		if err == nil && conn.Connected {
-			spotifyConnected = true
-			spotifyUserName = conn.ExternalUserName
-		}
-	}
-
-	// Check YouTube Music connection status
-	youtubeMusicConnected := false
-	youtubeMusicUserName := ""
-
-	if h.youtubeMusicEnabled {
-		conn, err := h.connectionStore.Get("youtubemusic", userID)
-		if err == nil && conn.Connected {
-			youtubeMusicConnected = true
-			youtubeMusicUserName = conn.ExternalUserName
+	enabled := make(map[string]bool)
+	accounts := make(map[string][]*models.Connection)
+	for _, reg := range h.registry {
+		if reg.Enabled {
+			enabled[reg.Slug] = true
+			accounts[reg.Slug] = h.connectedAccounts(reg.Slug, userID)
 		}
 	}
 
 	data := map[string]interface{}{
-		"Title":                  "Available Providers",
-		"Providers":              providers,
-		"SpotifyEnabled":         h.spotifyEnabled,
-		"SpotifyConnected":       spotifyConnected,
-		"SpotifyUserName":        spotifyUserName,
-		"YouTubeMusicEnabled":    h.youtubeMusicEnabled,
-		"YouTubeMusicConnected":  youtubeMusicConnected,
-		"YouTubeMusicUserName":   youtubeMusicUserName,
-		"Username":               h.getUsernameFromContext(r),
+		"Title":     "Available Providers",
+		"Providers": names,
+		"Enabled":   enabled,
+		"Accounts":  accounts,
+		"Username":  h.getUsernameFromContext(r),
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "providers.html", data); err != nil {
@@ -105,12 +94,12 @@ func (h *Handlers) HandleProviders(w http.ResponseWriter, r *http.Request) {
 
 // HandleTransfer renders the transfer page
 func (h *Handlers) HandleTransfer(w http.ResponseWriter, r *http.Request) {
-	providers := h.transferService.ListProviders()
+	userID := middleware.UserIDFromContext(r.Context())
 
 	data := map[string]interface{}{
-		"Title":     "Transfer Playlists",
-		"Providers": providers,
-		"Username":  h.getUsernameFromContext(r),
+		"Title":    "Transfer Playlists",
+		"Accounts": h.transferService.ListAccounts(userID),
+		"Username": h.getUsernameFromContext(r),
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "transfer.html", data); err != nil {
@@ -134,14 +123,17 @@ func (h *Handlers) HandleGetPlaylists(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID := middleware.UserIDFromContext(r.Context())
+	acct := providers.Account{UserID: userID, ExternalUserID: r.URL.Query().Get("account")}
+
 	// Authenticate
-	if err := provider.Authenticate(middleware.UserIDFromContext(r.Context())); err != nil {
+	if err := provider.Authenticate(acct); err != nil {
 		http.Error(w, "Authentication failed", http.StatusInternalServerError)
 		return
 	}
 
 	// Get playlists
-	playlists, err := provider.GetPlaylists(middleware.UserIDFromContext(r.Context()))
+	playlists, err := provider.GetPlaylists(acct)
 	if err != nil {
 		http.Error(w, "Failed to fetch playlists", http.StatusInternalServerError)
 		return
@@ -150,6 +142,9 @@ func (h *Handlers) HandleGetPlaylists(w http.ResponseWriter, r *http.Request) {
 	data := map[string]interface{}{
 		"Playlists": playlists,
 		"Provider":  providerName,
+		"Account":   acct.ExternalUserID,
+		"Targets":   h.transferService.ListAccounts(userID),
+		"Formats":   playlistfile.FileTypes,
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "playlist-list.html", data); err != nil {
@@ -159,6 +154,131 @@ func (h *Handlers) HandleGetPlaylists(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// maxUploadBytes limits the size of uploaded playlist files other than
+// libraries and DJ collections
+const maxUploadBytes = 5 << 20
+
+// HandleDownloadPlaylist sends a playlist with its tracks as a file in the
+// requested format (json, csv, m3u8, xspf, jspf, rekordbox or nml)
+func (h *Handlers) HandleDownloadPlaylist(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fileType, ok := playlistfile.LookupFileType(query.Get("format"))
+	if !ok {
+		http.Error(w, "Unsupported format", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := h.transferService.GetProvider(query.Get("provider"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	acct := providers.Account{UserID: middleware.UserIDFromContext(r.Context()), ExternalUserID: query.Get("account")}
+	if err := provider.Authenticate(acct); err != nil {
+		http.Error(w, "Authentication failed", http.StatusInternalServerError)
+		return
+	}
+
+	playlist, err := provider.ExportPlaylist(acct, query.Get("id"))
+	if err != nil {
+		log.Printf("Failed to export playlist for download: %v", err)
+		http.Error(w, "Failed to export playlist", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := playlistfile.Write(&buf, fileType.Format, playlist); err != nil {
+		log.Printf("Failed to encode playlist: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	filename := playlist.Name
+	if filename == "" {
+		filename = "playlist"
+	}
+	w.Header().Set("Content-Type", fileType.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + fileType.Extension}))
+	w.Write(buf.Bytes())
+}
+
+// HandleUploadPlaylist is an HTMX endpoint that imports an uploaded playlist
+// file into the chosen target account. The format is taken from the file
+// extension, and the import runs in the background like a transfer. iTunes
+// libraries and DJ collections first ask which of their playlists to import.
+func (h *Handlers) HandleUploadPlaylist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxLibraryBytes)
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		http.Error(w, "Invalid upload", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing playlist file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	targetProvider := r.FormValue("target_provider")
+	if targetProvider == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+
+	req := services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		TargetProvider: targetProvider,
+		TargetAccount:  r.FormValue("target_account"),
+	}
+
+	if playlistfile.IsCollectionFile(header.Filename) {
+		h.uploadLibrary(w, req, file)
+		return
+	}
+	if header.Size > maxUploadBytes {
+		http.Error(w, "Playlist files may be at most 5 MB", http.StatusBadRequest)
+		return
+	}
+
+	format, ok := playlistfile.FormatFromFileName(header.Filename)
+	if !ok {
+		http.Error(w, "Unsupported file type", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+
+	if format == playlistfile.FormatCSV {
+		h.importCSV(w, req, file, name)
+		return
+	}
+
+	playlist, err := playlistfile.Read(file, format, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.startImport(w, req, playlist)
+}
+
+// startImport starts importing an uploaded playlist and renders its progress
+func (h *Handlers) startImport(w http.ResponseWriter, req services.TransferRequest, playlist models.Playlist) {
+	progress, err := h.transferService.StartImport(req, playlist)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.renderTransferResult(w, progress)
+}
+
 // HandleStartTransfer is an HTMX endpoint that initiates a playlist transfer
 func (h *Handlers) HandleStartTransfer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -172,49 +292,74 @@ func (h *Handlers) HandleStartTransfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sourceProvider := r.FormValue("source_provider")
-	targetProvider := r.FormValue("target_provider")
-	playlistID := r.FormValue("playlist_id")
+	req := services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		SourceProvider: r.FormValue("source_provider"),
+		SourceAccount:  r.FormValue("source_account"),
+		TargetProvider: r.FormValue("target_provider"),
+		TargetAccount:  r.FormValue("target_account"),
+		Mode:           r.FormValue("mode"),
+		PlaylistID:     r.FormValue("playlist_id"),
+		PlaylistIDs:    r.Form["playlist_ids"],
+	}
 
-	if sourceProvider == "" || targetProvider == "" || playlistID == "" {
+	if !services.ValidMode(req.Mode) {
+		http.Error(w, "Unknown transfer mode", http.StatusBadRequest)
+		return
+	}
+	isPlaylist := req.Mode == "" || req.Mode == services.ModePlaylist
+	isBatch := req.Mode == services.ModeBatch
+	if req.SourceProvider == "" || req.TargetProvider == "" || (isPlaylist && req.PlaylistID == "") || (isBatch && len(req.PlaylistIDs) == 0) {
 		http.Error(w, "Missing required parameters", http.StatusBadRequest)
 		return
 	}
 
-	// Start transfer (simulated with delay for demo)
-	userID := middleware.UserIDFromContext(r.Context())
-	progress := &services.TransferProgress{
-		PlaylistID:     playlistID,
-		SourceProvider: sourceProvider,
-		TargetProvider: targetProvider,
-		Status:         "in_progress",
-		Progress:       0,
-		Message:        "Starting transfer...",
-		StartedAt:      time.Now(),
+	progress, err := h.transferService.StartTransfer(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	// Simulate transfer
-	go func() {
-		time.Sleep(2 * time.Second)
-		if err := h.transferService.TransferPlaylistForUser(sourceProvider, targetProvider, playlistID, userID); err != nil {
-			log.Printf("Transfer failed: %v", err)
-		}
-	}()
+	// Return progress as HTML; the fragment polls for updates until the transfer finishes
+	h.renderTransferResult(w, progress)
+}
 
-	// Update progress
-	progress.Progress = 50
-	progress.Message = "Transferring tracks..."
+// HandleTransferStatus returns the current progress of a transfer
+func (h *Handlers) HandleTransferStatus(w http.ResponseWriter, r *http.Request) {
+	progress, err := h.transferService.GetTransfer(middleware.UserIDFromContext(r.Context()), r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Transfer not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderTransferResult(w, progress)
+}
+
+// HandleCancelTransfer cancels a transfer that has not finished yet
+func (h *Handlers) HandleCancelTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	id := r.FormValue("id")
+	if err := h.transferService.CancelTransfer(userID, id); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 
-	time.Sleep(1 * time.Second)
+	progress, err := h.transferService.GetTransfer(userID, id)
+	if err != nil {
+		http.Error(w, "Transfer not found", http.StatusNotFound)
+		return
+	}
 
-	// Complete
-	progress.Progress = 100
-	progress.Status = "completed"
-	progress.Message = "Transfer complete!"
-	now := time.Now()
-	progress.CompletedAt = &now
+	h.renderTransferResult(w, progress)
+}
 
-	// Return progress as HTML
+// renderTransferResult renders the transfer-result.html fragment
+func (h *Handlers) renderTransferResult(w http.ResponseWriter, progress services.TransferProgress) {
 	data := map[string]interface{}{
 		"Progress": progress,
 	}
@@ -222,17 +367,38 @@ func (h *Handlers) HandleStartTransfer(w http.ResponseWriter, r *http.Request) {
 	if err := h.templates.ExecuteTemplate(w, "transfer-result.html", data); err != nil {
 		log.Printf("Error rendering transfer result: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
 	}
 }
 
+// connectedAccounts returns the user's active connections to a provider
+func (h *Handlers) connectedAccounts(provider, userID string) []*models.Connection {
+	connections, err := h.connectionStore.ListByProvider(provider, userID)
+	if err != nil {
+		log.Printf("Failed to list %s connections: %v", provider, err)
+		return nil
+	}
+
+	var accounts []*models.Connection
+	for _, conn := range connections {
+		if conn.Connected {
+			accounts = append(accounts, conn)
+		}
+	}
+	return accounts
+}
+
 // getUsernameFromContext retrieves the username from the request context
 func (h *Handlers) getUsernameFromContext(r *http.Request) string {
+	return usernameFromContext(h.userStore, r)
+}
+
+// usernameFromContext looks up the username of the logged-in user, if any
+func usernameFromContext(userStore storage.UserStore, r *http.Request) string {
 	userID := middleware.UserIDFromContext(r.Context())
 	if userID == "" {
 		return ""
 	}
-	user, err := h.userStore.Get(userID)
+	user, err := userStore.Get(userID)
 	if err != nil {
 		return ""
 	}
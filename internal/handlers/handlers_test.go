@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"html/template"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
 	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/services"
 	"github.com/JanikSachs/PlayPort/internal/storage"
@@ -29,7 +34,7 @@ func setupTestHandlers(t *testing.T) *Handlers {
 		t.Fatalf("Failed to parse templates: %v", err)
 	}
 	
-	return NewHandlers(transferService, templates, connectionStore, userStore, false, false)
+	return NewHandlers(transferService, templates, connectionStore, userStore, nil)
 }
 
 func TestHandleHome(t *testing.T) {
@@ -84,6 +89,44 @@ func TestHandleProviders(t *testing.T) {
 	}
 }
 
+func TestHandleProviders_MultipleSpotifyAccounts(t *testing.T) {
+	transferService := services.NewTransferService()
+	connectionStore := storage.NewInMemoryConnectionStore()
+	templates, err := template.ParseGlob("../../web/templates/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+	handlers := NewHandlers(transferService, templates, connectionStore, storage.NewInMemoryUserStore(), []providers.Registration{{Slug: "spotify", Name: "Spotify", Login: providers.LoginOAuth, Enabled: true}})
+
+	for _, conn := range []*models.Connection{
+		{Provider: "spotify", UserID: "user123", ExternalUserID: "alice-id", ExternalUserName: "Alice", Connected: true},
+		{Provider: "spotify", UserID: "user123", ExternalUserID: "bob-id", ExternalUserName: "Bob", Connected: true},
+	} {
+		if err := connectionStore.Save(conn); err != nil {
+			t.Fatalf("Failed to save connection: %v", err)
+		}
+	}
+
+	sessionStore := auth.NewInMemorySessionStore(0)
+	token, err := sessionStore.Create("user123")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/providers", nil)
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: token})
+	w := httptest.NewRecorder()
+
+	middleware.SessionMiddleware(sessionStore)(http.HandlerFunc(handlers.HandleProviders)).ServeHTTP(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{"Alice", "Bob", "account=alice-id", "account=bob-id", "Connect another Spotify account"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Response should contain %q", want)
+		}
+	}
+}
+
 func TestHandleTransfer(t *testing.T) {
 	handlers := setupTestHandlers(t)
 	
@@ -150,6 +193,12 @@ func TestHandleGetPlaylists(t *testing.T) {
 				if !strings.Contains(body, "Summer Vibes") {
 					t.Error("Response should contain playlist names")
 				}
+				if !strings.Contains(body, `href="/migrate?source_provider=Mock%20Music&source_account="`) {
+					t.Error("Response should link to the migration wizard")
+				}
+				if !strings.Contains(body, `name="playlist_ids" value="mock-1"`) {
+					t.Error("Response should let playlists be selected for a batch")
+				}
 			}
 		})
 	}
@@ -188,6 +237,57 @@ func TestHandleStartTransfer(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:   "Album transfer without playlist",
+			method: http.MethodPost,
+			formData: url.Values{
+				"source_provider": []string{"Mock Music"},
+				"target_provider": []string{"Mock Music"},
+				"mode":            []string{"albums"},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Library migration without playlist",
+			method: http.MethodPost,
+			formData: url.Values{
+				"source_provider": []string{"Mock Music"},
+				"target_provider": []string{"Mock Music"},
+				"mode":            []string{"library"},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Batch of selected playlists",
+			method: http.MethodPost,
+			formData: url.Values{
+				"source_provider": []string{"Mock Music"},
+				"target_provider": []string{"Mock Music"},
+				"mode":            []string{"batch"},
+				"playlist_ids":    []string{"mock-1", "mock-2"},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Batch without playlists",
+			method: http.MethodPost,
+			formData: url.Values{
+				"source_provider": []string{"Mock Music"},
+				"target_provider": []string{"Mock Music"},
+				"mode":            []string{"batch"},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Unknown mode",
+			method: http.MethodPost,
+			formData: url.Values{
+				"source_provider": []string{"Mock Music"},
+				"target_provider": []string{"Mock Music"},
+				"mode":            []string{"podcasts"},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -212,3 +312,115 @@ func TestHandleStartTransfer(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleTransferStatus(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	progress, err := handlers.transferService.StartTransfer(services.TransferRequest{
+		SourceProvider: "Mock Music",
+		TargetProvider: "Mock Music",
+		PlaylistID:     "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("StartTransfer() failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/transfer/status?id="+progress.ID, nil)
+	w := httptest.NewRecorder()
+
+	handlers.HandleTransferStatus(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Transfer Status: pending") {
+		t.Error("Response should show the pending status")
+	}
+	if !strings.Contains(body, `hx-trigger="every 2s"`) {
+		t.Error("Unfinished transfers should keep polling for updates")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/transfer/status?id=unknown", nil)
+	w = httptest.NewRecorder()
+
+	handlers.HandleTransferStatus(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown transfers, got %d", w.Code)
+	}
+}
+
+func TestHandleDownloadPlaylist(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/playlists/download?provider=Mock+Music&id=mock-1&format=xspf", nil)
+	w := httptest.NewRecorder()
+
+	handlers.HandleDownloadPlaylist(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/xspf+xml" {
+		t.Errorf("Expected XSPF content type, got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, `filename="Summer Vibes 2024.xspf"`) {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+	if !strings.Contains(w.Body.String(), "<trackList>") {
+		t.Error("Response should contain an XSPF track list")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/playlists/download?provider=Mock+Music&id=mock-1&format=wav", nil)
+	w = httptest.NewRecorder()
+
+	handlers.HandleDownloadPlaylist(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown formats, got %d", w.Code)
+	}
+}
+
+// uploadFile posts a playlist file to HandleUploadPlaylist, targeting the
+// mock provider
+func uploadFile(t *testing.T, handlers *Handlers, filename, content string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("target_provider", "Mock Music")
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile() failed: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/transfer/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	handlers.HandleUploadPlaylist(w, req)
+	return w
+}
+
+func TestHandleUploadPlaylist(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	jspf := `{"playlist":{"title":"Road Trip","track":[{"title":"Song","creator":"Artist"}]}}`
+	w := uploadFile(t, handlers, "road-trip.jspf", jspf)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Uploaded file") {
+		t.Error("Response should show the uploaded file as source")
+	}
+
+	if w := uploadFile(t, handlers, "notes.txt", "hello"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unsupported files, got %d", w.Code)
+	}
+	if w := uploadFile(t, handlers, "broken.xspf", "<playlist"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid files, got %d", w.Code)
+	}
+}
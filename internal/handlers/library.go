@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/playlistfile"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// maxLibraryBytes limits the size of uploaded libraries and DJ collections,
+// which list every track and are much larger than single playlists
+const maxLibraryBytes = 200 << 20
+
+// libraryUploadTTL is how long a parsed library waits for the user to
+// choose playlists
+const libraryUploadTTL = 30 * time.Minute
+
+// libraryUpload is a parsed library awaiting the user's choice
+type libraryUpload struct {
+	id        string
+	playlists []models.Playlist
+	expiresAt time.Time
+}
+
+// libraryUploads keeps the most recently uploaded library of each user in
+// memory, so the file only has to be sent once
+type libraryUploads struct {
+	mu     sync.Mutex
+	byUser map[string]*libraryUpload
+}
+
+// newLibraryUploads creates an empty library cache
+func newLibraryUploads() *libraryUploads {
+	return &libraryUploads{byUser: make(map[string]*libraryUpload)}
+}
+
+// put stores a user's library, replacing any earlier one, and returns its ID
+func (l *libraryUploads) put(userID string, playlists []models.Playlist) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	upload := &libraryUpload{
+		id:        hex.EncodeToString(b),
+		playlists: playlists,
+		expiresAt: time.Now().Add(libraryUploadTTL),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for user, u := range l.byUser {
+		if time.Now().After(u.expiresAt) {
+			delete(l.byUser, user)
+		}
+	}
+	l.byUser[userID] = upload
+	return upload.id, nil
+}
+
+// get returns a user's library if it is the one with the given ID and has
+// not expired
+func (l *libraryUploads) get(userID, id string) (*libraryUpload, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	upload, ok := l.byUser[userID]
+	if !ok || upload.id != id || time.Now().After(upload.expiresAt) {
+		return nil, false
+	}
+	return upload, true
+}
+
+// uploadLibrary parses an uploaded iTunes library or DJ collection and asks
+// which of its playlists to import
+func (h *Handlers) uploadLibrary(w http.ResponseWriter, req services.TransferRequest, file io.Reader) {
+	playlists, err := playlistfile.ReadCollection(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.libraries.put(req.UserID, playlists)
+	if err != nil {
+		log.Printf("Failed to store library upload: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"LibraryID":      id,
+		"Playlists":      playlists,
+		"TargetProvider": req.TargetProvider,
+		"TargetAccount":  req.TargetAccount,
+	}
+
+	if err := h.templates.ExecuteTemplate(w, "library-playlists.html", data); err != nil {
+		log.Printf("Error rendering library playlists: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// HandleImportLibrary is an HTMX endpoint that imports the playlists chosen
+// from an uploaded library, one background import each
+func (h *Handlers) HandleImportLibrary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	req := services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		TargetProvider: r.FormValue("target_provider"),
+		TargetAccount:  r.FormValue("target_account"),
+	}
+	selected := r.Form["playlist"]
+	if req.TargetProvider == "" || len(selected) == 0 {
+		http.Error(w, "Choose at least one playlist", http.StatusBadRequest)
+		return
+	}
+
+	upload, ok := h.libraries.get(req.UserID, r.FormValue("library_id"))
+	if !ok {
+		http.Error(w, "The uploaded library has expired; please upload it again", http.StatusNotFound)
+		return
+	}
+
+	byID := make(map[string]models.Playlist, len(upload.playlists))
+	for _, p := range upload.playlists {
+		byID[p.ID] = p
+	}
+	var playlists []models.Playlist
+	for _, id := range selected {
+		p, ok := byID[id]
+		if !ok {
+			http.Error(w, "Unknown playlist", http.StatusBadRequest)
+			return
+		}
+		playlists = append(playlists, p)
+	}
+
+	for _, p := range playlists {
+		progress, err := h.transferService.StartImport(req, p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.renderTransferResult(w, progress)
+	}
+}
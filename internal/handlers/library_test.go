@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testLibraryXML = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>Tracks</key>
+	<dict>
+		<key>1</key>
+		<dict>
+			<key>Track ID</key><integer>1</integer>
+			<key>Name</key><string>Hello</string>
+			<key>Artist</key><string>Adele</string>
+			<key>Persistent ID</key><string>AD00000000000001</string>
+		</dict>
+	</dict>
+	<key>Playlists</key>
+	<array>
+		<dict>
+			<key>Name</key><string>Library</string>
+			<key>Master</key><true/>
+			<key>Playlist Persistent ID</key><string>PL00000000000000</string>
+		</dict>
+		<dict>
+			<key>Name</key><string>Ballads</string>
+			<key>Playlist Persistent ID</key><string>PL00000000000001</string>
+			<key>Playlist Items</key>
+			<array><dict><key>Track ID</key><integer>1</integer></dict></array>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+func TestHandleImportLibrary(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	w := uploadFile(t, handlers, "Library.xml", testLibraryXML)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `value="PL00000000000001"`) || !strings.Contains(body, "Ballads") {
+		t.Error("The library's playlists should be offered for import")
+	}
+	if strings.Contains(body, `value="PL00000000000000"`) {
+		t.Error("The library itself should not be offered")
+	}
+
+	upload, ok := handlers.libraries.byUser[""]
+	if !ok {
+		t.Fatal("The parsed library should be kept for the next step")
+	}
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/transfer/library", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handlers.HandleImportLibrary(w, req)
+		return w
+	}
+
+	form := url.Values{
+		"library_id":      {upload.id},
+		"target_provider": {"Mock Music"},
+		"playlist":        {"PL00000000000001"},
+	}
+	w = post(form)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Count(w.Body.String(), "Transfer Status") != 1 {
+		t.Error("Each selected playlist should start one import")
+	}
+
+	form.Set("playlist", "PL00000000000000")
+	if w := post(form); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for playlists not offered, got %d", w.Code)
+	}
+
+	form.Set("library_id", "stale")
+	if w := post(form); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown libraries, got %d", w.Code)
+	}
+
+	form.Del("playlist")
+	if w := post(form); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a selection, got %d", w.Code)
+	}
+}
+
+func TestHandleUploadPlaylist_DJCollection(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	nml := `<?xml version="1.0" encoding="UTF-8"?>
+<NML VERSION="19"><HEAD COMPANY="www.native-instruments.com" PROGRAM="Traktor"></HEAD>
+<COLLECTION ENTRIES="1">
+<ENTRY TITLE="Hello" ARTIST="Adele"><LOCATION DIR="/:Music/:" FILE="hello.mp3" VOLUME=""></LOCATION></ENTRY>
+</COLLECTION>
+<PLAYLISTS><NODE TYPE="FOLDER" NAME="$ROOT"><SUBNODES COUNT="1">
+<NODE TYPE="PLAYLIST" NAME="Opening Set"><PLAYLIST ENTRIES="1" TYPE="LIST">
+<ENTRY><PRIMARYKEY TYPE="TRACK" KEY="/:Music/:hello.mp3"></PRIMARYKEY></ENTRY>
+</PLAYLIST></NODE>
+</SUBNODES></NODE></PLAYLISTS>
+</NML>`
+
+	w := uploadFile(t, handlers, "collection.nml", nml)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `value="Opening Set"`) {
+		t.Errorf("The collection's playlists should be offered for import, got %s", w.Body.String())
+	}
+
+	w = uploadFile(t, handlers, "notes.xml", "<html></html>")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown XML files, got %d", w.Code)
+	}
+}
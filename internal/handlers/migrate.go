@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// migrationAccounts is the source and target a library migration runs
+// between, as picked in the first step of the wizard
+type migrationAccounts struct {
+	SourceProvider string
+	SourceAccount  string
+	TargetProvider string
+	TargetAccount  string
+}
+
+// migrationAccountsFromForm reads the source and target of a migration from
+// the query or form
+func migrationAccountsFromForm(r *http.Request) migrationAccounts {
+	return migrationAccounts{
+		SourceProvider: r.FormValue("source_provider"),
+		SourceAccount:  r.FormValue("source_account"),
+		TargetProvider: r.FormValue("target_provider"),
+		TargetAccount:  r.FormValue("target_account"),
+	}
+}
+
+// HandleMigrate is the library migration wizard. Without a source and target
+// it asks for them; with both it lists the source's playlists for review.
+// Submitting the reviewed playlists starts them as one batch and redirects
+// to the migration's progress page.
+func (h *Handlers) HandleMigrate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		accounts := migrationAccountsFromForm(r)
+		if accounts.SourceProvider == "" || accounts.TargetProvider == "" {
+			h.renderMigrate(w, r, http.StatusOK, map[string]interface{}{"Step": 1, "Selected": accounts})
+			return
+		}
+		h.renderMigrationReview(w, r, accounts, http.StatusOK, "")
+	case http.MethodPost:
+		h.startMigration(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// renderMigrationReview renders the second step of the wizard, the source's
+// playlists with every one of them selected, with an error if any. Without
+// the source's playlists it falls back to the first step.
+func (h *Handlers) renderMigrationReview(w http.ResponseWriter, r *http.Request, accounts migrationAccounts, status int, errMsg string) {
+	userID := middleware.UserIDFromContext(r.Context())
+	data := map[string]interface{}{"Step": 1, "Selected": accounts}
+
+	source, err := h.transferService.GetProvider(accounts.SourceProvider)
+	if err != nil {
+		data["Error"] = "Unknown source provider: " + accounts.SourceProvider
+		h.renderMigrate(w, r, http.StatusBadRequest, data)
+		return
+	}
+	if _, err := h.transferService.GetProvider(accounts.TargetProvider); err != nil {
+		data["Error"] = "Unknown target provider: " + accounts.TargetProvider
+		h.renderMigrate(w, r, http.StatusBadRequest, data)
+		return
+	}
+
+	acct := providers.Account{UserID: userID, ExternalUserID: accounts.SourceAccount}
+	if err := source.Authenticate(acct); err != nil {
+		log.Printf("Failed to authenticate %s for a migration: %v", accounts.SourceProvider, err)
+		data["Error"] = "Could not sign in to " + accounts.SourceProvider + ". Reconnect the account and try again."
+		h.renderMigrate(w, r, http.StatusBadGateway, data)
+		return
+	}
+	playlists, err := source.GetPlaylists(acct)
+	if err != nil {
+		log.Printf("Failed to list %s playlists for a migration: %v", accounts.SourceProvider, err)
+		data["Error"] = "Could not load the playlists of " + accounts.SourceProvider + "."
+		h.renderMigrate(w, r, http.StatusBadGateway, data)
+		return
+	}
+
+	totalTracks := 0
+	for _, p := range playlists {
+		totalTracks += p.TrackCount
+	}
+	options := h.transferService.ListAccounts(userID)
+	data["Step"] = 2
+	data["Playlists"] = playlists
+	data["TotalTracks"] = totalTracks
+	data["SourceLabel"] = accountLabel(options, accounts.SourceProvider, accounts.SourceAccount)
+	data["TargetLabel"] = accountLabel(options, accounts.TargetProvider, accounts.TargetAccount)
+	data["Error"] = errMsg
+	h.renderMigrate(w, r, status, data)
+}
+
+// startMigration starts the reviewed playlists as one batch
+func (h *Handlers) startMigration(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	accounts := migrationAccountsFromForm(r)
+	playlistIDs := r.PostForm["playlist_ids"]
+	if accounts.SourceProvider == "" || accounts.TargetProvider == "" {
+		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+		return
+	}
+	if len(playlistIDs) == 0 {
+		h.renderMigrationReview(w, r, accounts, http.StatusBadRequest, "Select at least one playlist to migrate.")
+		return
+	}
+
+	progress, err := h.transferService.StartTransfer(services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		SourceProvider: accounts.SourceProvider,
+		SourceAccount:  accounts.SourceAccount,
+		TargetProvider: accounts.TargetProvider,
+		TargetAccount:  accounts.TargetAccount,
+		Mode:           services.ModeBatch,
+		PlaylistIDs:    playlistIDs,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/migrate/progress?id="+url.QueryEscape(progress.ID), http.StatusSeeOther)
+}
+
+// HandleMigrationProgress renders the last step of the wizard: the progress
+// of a migration and, once it has finished, the tracks that did not move
+func (h *Handlers) HandleMigrationProgress(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.UserIDFromContext(r.Context())
+	id := r.URL.Query().Get("id")
+
+	report, err := h.transferService.GetTransferReport(userID, id)
+	if err != nil {
+		http.Error(w, "Transfer not found", http.StatusNotFound)
+		return
+	}
+	progress, err := h.transferService.GetTransfer(userID, id)
+	if err != nil {
+		http.Error(w, "Transfer not found", http.StatusNotFound)
+		return
+	}
+
+	var missing []services.TrackResult
+	for _, track := range report.Tracks {
+		if track.Status == services.MatchNotFound || track.Status == services.MatchSkipped {
+			missing = append(missing, track)
+		}
+	}
+
+	h.renderMigrate(w, r, http.StatusOK, map[string]interface{}{
+		"Step":     3,
+		"Progress": progress,
+		"Report":   report,
+		"Missing":  missing,
+	})
+}
+
+// renderMigrate renders a step of the migration wizard
+func (h *Handlers) renderMigrate(w http.ResponseWriter, r *http.Request, status int, data map[string]interface{}) {
+	data["Title"] = "Migrate Library"
+	data["Username"] = h.getUsernameFromContext(r)
+	data["Accounts"] = h.transferService.ListAccounts(middleware.UserIDFromContext(r.Context()))
+
+	w.WriteHeader(status)
+	if err := h.templates.ExecuteTemplate(w, "migrate.html", data); err != nil {
+		log.Printf("Error rendering migrate template: %v", err)
+	}
+}
+
+// accountLabel returns the label of the account option for provider and
+// account, or the provider name if there is none
+func accountLabel(options []services.AccountOption, provider, account string) string {
+	for _, opt := range options {
+		if opt.Provider == provider && opt.Account == account {
+			return opt.Label
+		}
+	}
+	return provider
+}
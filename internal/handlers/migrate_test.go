@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+)
+
+func TestHandleMigrate(t *testing.T) {
+	handlers := setupTestHandlers(t)
+
+	sessionStore := auth.NewInMemorySessionStore(0)
+	session, err := sessionStore.Create("user123")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	serve := func(handler http.HandlerFunc, method, target string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session_token", Value: session})
+		w := httptest.NewRecorder()
+		middleware.SessionMiddleware(sessionStore)(handler).ServeHTTP(w, req)
+		return w
+	}
+	accounts := url.Values{"source_provider": {"Mock Music"}, "target_provider": {"Mock Music"}}
+
+	// Step 1: choose the source and target
+	w := serve(handlers.HandleMigrate, http.MethodGet, "/migrate?source_provider=Mock+Music", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `id="migrate-target-provider"`) || !strings.Contains(body, `data-account="" selected`) {
+		t.Error("The first step should ask for a target, with the given source selected")
+	}
+
+	// Step 2: review the source's playlists
+	w = serve(handlers.HandleMigrate, http.MethodGet, "/migrate?"+accounts.Encode(), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Summer Vibes") || !strings.Contains(body, `name="playlist_ids" value="mock-1" checked`) {
+		t.Error("The review step should list every playlist, selected")
+	}
+
+	w = serve(handlers.HandleMigrate, http.MethodGet, "/migrate?source_provider=Nope&target_provider=Mock+Music", nil)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Unknown source provider") {
+		t.Errorf("Expected an unknown source to be rejected, got %d", w.Code)
+	}
+
+	w = serve(handlers.HandleMigrate, http.MethodPost, "/migrate", accounts)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Select at least one playlist") {
+		t.Errorf("Expected a migration without playlists to be rejected, got %d", w.Code)
+	}
+
+	// Submit, and land on the progress page
+	form := url.Values{"playlist_ids": {"mock-1", "mock-2"}}
+	for key, values := range accounts {
+		form[key] = values
+	}
+	w = serve(handlers.HandleMigrate, http.MethodPost, "/migrate", form)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status 303, got %d: %s", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "/migrate/progress?id=") {
+		t.Fatalf("Expected a redirect to the progress page, got %q", location)
+	}
+
+	// Step 3: progress, then the report
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		w = serve(handlers.HandleMigrationProgress, http.MethodGet, location, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		body = w.Body.String()
+		if strings.Contains(body, "Migration Status: completed") || time.Now().After(deadline) {
+			break
+		}
+	}
+	if !strings.Contains(body, "Migration Status: completed") || !strings.Contains(body, "Workout Mix") {
+		t.Fatalf("Expected the completed migration with each playlist, got %s", body)
+	}
+	if !strings.Contains(body, "Report") || strings.Contains(body, `hx-trigger="every 2s"`) {
+		t.Error("A finished migration should show its report and stop polling")
+	}
+
+	w = serve(handlers.HandleMigrationProgress, http.MethodGet, "/migrate/progress?id=nope", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown migration, got %d", w.Code)
+	}
+}
@@ -1,141 +1,166 @@
 package handlers
 
 import (
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/JanikSachs/PlayPort/internal/middleware"
-	"github.com/JanikSachs/PlayPort/internal/providers/spotify"
-	"github.com/JanikSachs/PlayPort/internal/providers/youtubemusic"
+	"github.com/JanikSachs/PlayPort/internal/playlistfile"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/services"
 	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
-// ProviderHandlers contains provider-specific handlers
+// ProviderHandlers contains the handlers of the registered providers
 type ProviderHandlers struct {
-	spotifyProvider      *spotify.SpotifyProvider
-	youtubeMusicProvider *youtubemusic.YouTubeMusicProvider
-	connectionStore      storage.ConnectionStore
-	templates            *template.Template
-	spotifyEnabled       bool
-	youtubeMusicEnabled  bool
+	transferService   *services.TransferService
+	connectionService *services.ConnectionService
+	connectionStore   storage.ConnectionStore
+	templates         *template.Template
+	registry          []providers.Registration
 }
 
 // NewProviderHandlers creates new provider handlers
-func NewProviderHandlers(spotifyProvider *spotify.SpotifyProvider, youtubeMusicProvider *youtubemusic.YouTubeMusicProvider, connectionStore storage.ConnectionStore, templates *template.Template, spotifyEnabled bool, youtubeMusicEnabled bool) *ProviderHandlers {
+func NewProviderHandlers(transferService *services.TransferService, connectionService *services.ConnectionService, connectionStore storage.ConnectionStore, templates *template.Template, registry []providers.Registration) *ProviderHandlers {
 	return &ProviderHandlers{
-		spotifyProvider:     spotifyProvider,
-		youtubeMusicProvider: youtubeMusicProvider,
-		connectionStore:     connectionStore,
-		templates:           templates,
-		spotifyEnabled:      spotifyEnabled,
-		youtubeMusicEnabled: youtubeMusicEnabled,
+		transferService:   transferService,
+		connectionService: connectionService,
+		connectionStore:   connectionStore,
+		templates:         templates,
+		registry:          registry,
 	}
 }
 
-// HandleSpotifyPlaylists returns playlists for the Spotify provider
-func (h *ProviderHandlers) HandleSpotifyPlaylists(w http.ResponseWriter, r *http.Request) {
-	if !h.spotifyEnabled {
-		http.Error(w, "Spotify is not configured", http.StatusServiceUnavailable)
-		return
-	}
+// HandlePlaylists returns a handler listing the playlists of one of the
+// user's accounts of the registered provider
+func (h *ProviderHandlers) HandlePlaylists(reg providers.Registration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !reg.Enabled {
+			http.Error(w, reg.Name+" is not configured", http.StatusServiceUnavailable)
+			return
+		}
 
-	// Check authentication
-	if err := h.spotifyProvider.Authenticate(middleware.UserIDFromContext(r.Context())); err != nil {
-		log.Printf("Spotify not authenticated: %v", err)
-		w.WriteHeader(http.StatusUnauthorized)
-		if err := h.templates.ExecuteTemplate(w, "spotify-not-connected.html", nil); err != nil {
-			log.Printf("Error rendering template: %v", err)
-			http.Error(w, "Please connect your Spotify account first", http.StatusUnauthorized)
+		userID := middleware.UserIDFromContext(r.Context())
+		acct := providers.Account{UserID: userID, ExternalUserID: r.URL.Query().Get("account")}
+
+		// Check authentication
+		if err := reg.Provider.Authenticate(acct); err != nil {
+			log.Printf("%s not authenticated: %v", reg.Name, err)
+			w.WriteHeader(http.StatusUnauthorized)
+			if err := h.templates.ExecuteTemplate(w, reg.Slug+"-not-connected.html", nil); err != nil {
+				log.Printf("Error rendering template: %v", err)
+				http.Error(w, fmt.Sprintf("Please connect your %s account first", reg.Name), http.StatusUnauthorized)
+			}
+			return
 		}
-		return
-	}
 
-	// Get playlists
-	playlists, err := h.spotifyProvider.GetPlaylists(middleware.UserIDFromContext(r.Context()))
-	if err != nil {
-		log.Printf("Failed to fetch Spotify playlists: %v", err)
-		http.Error(w, "Failed to fetch playlists", http.StatusInternalServerError)
-		return
-	}
+		// Get playlists
+		playlists, err := reg.Provider.GetPlaylists(acct)
+		if err != nil {
+			log.Printf("Failed to fetch %s playlists: %v", reg.Name, err)
+			http.Error(w, "Failed to fetch playlists", http.StatusInternalServerError)
+			return
+		}
 
-	// Render playlist list template
-	data := map[string]interface{}{
-		"Playlists": playlists,
-		"Provider":  "Spotify",
-	}
+		// Render playlist list template
+		data := map[string]interface{}{
+			"Playlists": playlists,
+			"Provider":  reg.Provider.Name(),
+			"Account":   acct.ExternalUserID,
+			"Targets":   h.transferService.ListAccounts(userID),
+			"Formats":   playlistfile.FileTypes,
+		}
 
-	if err := h.templates.ExecuteTemplate(w, "playlist-list.html", data); err != nil {
-		log.Printf("Error rendering playlist list: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		if err := h.templates.ExecuteTemplate(w, "playlist-list.html", data); err != nil {
+			log.Printf("Error rendering playlist list: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
 	}
 }
 
-// HandleYouTubeMusicPlaylists returns playlists for the YouTube Music provider
-func (h *ProviderHandlers) HandleYouTubeMusicPlaylists(w http.ResponseWriter, r *http.Request) {
-	if !h.youtubeMusicEnabled {
-		http.Error(w, "YouTube Music is not configured", http.StatusServiceUnavailable)
-		return
+// HandleDisconnect returns a handler unlinking one of the user's accounts
+// of the registered provider
+func (h *ProviderHandlers) HandleDisconnect(reg providers.Registration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !reg.Enabled {
+			http.Error(w, reg.Name+" is not configured", http.StatusServiceUnavailable)
+			return
+		}
+		h.disconnect(w, r, reg.Provider, reg.Slug)
 	}
+}
 
-	// Check authentication
-	if err := h.youtubeMusicProvider.Authenticate(middleware.UserIDFromContext(r.Context())); err != nil {
-		log.Printf("YouTube Music not authenticated: %v", err)
-		w.WriteHeader(http.StatusUnauthorized)
-		if err := h.templates.ExecuteTemplate(w, "youtubemusic-not-connected.html", nil); err != nil {
-			log.Printf("Error rendering template: %v", err)
-			http.Error(w, "Please connect your YouTube Music account first", http.StatusUnauthorized)
+// HandleConnect returns a handler linking an account of the registered
+// provider with the credentials entered on the providers page
+func (h *ProviderHandlers) HandleConnect(reg providers.Registration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !reg.Enabled {
+			http.Error(w, reg.Name+" is not configured", http.StatusServiceUnavailable)
+			return
 		}
-		return
+		h.connect(w, r, reg.Provider, reg.Slug)
 	}
+}
 
-	// Get playlists
-	playlists, err := h.youtubeMusicProvider.GetPlaylists(middleware.UserIDFromContext(r.Context()))
-	if err != nil {
-		log.Printf("Failed to fetch YouTube Music playlists: %v", err)
-		http.Error(w, "Failed to fetch playlists", http.StatusInternalServerError)
+// connect links an account from the "username", "password" and "token"
+// form values and returns the user to the providers page
+func (h *ProviderHandlers) connect(w http.ResponseWriter, r *http.Request, provider providers.Provider, slug string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Render playlist list template
-	data := map[string]interface{}{
-		"Playlists": playlists,
-		"Provider":  "YouTube Music",
+	creds := providers.Credentials{
+		Username: strings.TrimSpace(r.FormValue("username")),
+		Password: r.FormValue("password"),
+		Token:    strings.TrimSpace(r.FormValue("token")),
 	}
-
-	if err := h.templates.ExecuteTemplate(w, "playlist-list.html", data); err != nil {
-		log.Printf("Error rendering playlist list: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	userID := middleware.UserIDFromContext(r.Context())
+	if _, err := h.connectionService.Connect(r.Context(), provider, slug, userID, creds); err != nil {
+		log.Printf("Failed to connect %s account: %v", slug, err)
+		http.Error(w, fmt.Sprintf("%s login failed: %v", provider.Name(), err), http.StatusBadRequest)
 		return
 	}
+
+	http.Redirect(w, r, "/providers", http.StatusSeeOther)
 }
 
-// GetConnectionStatus returns the Spotify connection status
-func (h *ProviderHandlers) GetConnectionStatus(userID string) (bool, string) {
-	if !h.spotifyEnabled {
-		return false, ""
+// disconnect removes the account named by the "account" form value and
+// returns the user to the providers page
+func (h *ProviderHandlers) disconnect(w http.ResponseWriter, r *http.Request, provider providers.Provider, slug string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	conn, err := h.connectionStore.Get("spotify", userID)
-	if err != nil {
-		return false, ""
+	account := r.FormValue("account")
+	if account == "" {
+		http.Error(w, "Missing account", http.StatusBadRequest)
+		return
 	}
 
-	if conn.Connected {
-		return true, conn.ExternalUserName
+	acct := providers.Account{UserID: middleware.UserIDFromContext(r.Context()), ExternalUserID: account}
+	if err := h.connectionService.Disconnect(r.Context(), provider, slug, acct); err != nil {
+		log.Printf("Failed to disconnect %s account: %v", slug, err)
+		http.Error(w, "Failed to disconnect account", http.StatusNotFound)
+		return
 	}
 
-	return false, ""
+	http.Redirect(w, r, "/providers", http.StatusSeeOther)
 }
 
-// GetYouTubeMusicConnectionStatus returns the YouTube Music connection status
-func (h *ProviderHandlers) GetYouTubeMusicConnectionStatus(userID string) (bool, string) {
-	if !h.youtubeMusicEnabled {
+// GetConnectionStatus returns the status of the user's default connection
+// to the registered provider with the given slug
+func (h *ProviderHandlers) GetConnectionStatus(slug, userID string) (bool, string) {
+	if !providers.Lookup(h.registry, slug).Enabled {
 		return false, ""
 	}
 
-	conn, err := h.connectionStore.Get("youtubemusic", userID)
+	conn, err := storage.FindConnection(h.connectionStore, slug, userID, "")
 	if err != nil {
 		return false, ""
 	}
@@ -146,4 +171,3 @@ func (h *ProviderHandlers) GetYouTubeMusicConnectionStatus(userID string) (bool,
 
 	return false, ""
 }
-
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// scheduleHistory is how many recent runs the schedules page shows per schedule
+const scheduleHistory = 5
+
+// ScheduleHandlers contains handlers for the scheduled transfers page
+type ScheduleHandlers struct {
+	scheduleService *services.ScheduleService
+	transferService *services.TransferService
+	userStore       storage.UserStore
+	templates       *template.Template
+}
+
+// NewScheduleHandlers creates a new ScheduleHandlers instance
+func NewScheduleHandlers(scheduleService *services.ScheduleService, transferService *services.TransferService, userStore storage.UserStore, templates *template.Template) *ScheduleHandlers {
+	return &ScheduleHandlers{
+		scheduleService: scheduleService,
+		transferService: transferService,
+		userStore:       userStore,
+		templates:       templates,
+	}
+}
+
+// scheduleView is a schedule with its most recent runs, newest first
+type scheduleView struct {
+	*models.Schedule
+	Runs []*models.ScheduleRun
+}
+
+// scheduleAccount is an account offered by the new schedule form. Value is
+// the provider slug, followed by a colon and the account ID if it has one.
+type scheduleAccount struct {
+	Value string
+	Label string
+}
+
+// HandleSchedules renders the schedules page with the history of each schedule
+func (h *ScheduleHandlers) HandleSchedules(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, "", "")
+}
+
+// HandleCreateSchedule schedules recurring transfers or syncs of a playlist
+func (h *ScheduleHandlers) HandleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	kind := r.FormValue("kind")
+	playlistID := strings.TrimSpace(r.FormValue("playlist_id"))
+	spec := strings.TrimSpace(r.FormValue("spec"))
+	source, sourceAccount, sourceErr := h.account(r.FormValue("source"))
+	target, targetAccount, targetErr := h.account(r.FormValue("target"))
+	if playlistID == "" || spec == "" || sourceErr != nil || targetErr != nil {
+		h.render(w, r, http.StatusBadRequest, "", "Please choose both accounts and enter a playlist ID and a schedule.")
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateSchedule(services.TransferRequest{
+		UserID:         middleware.UserIDFromContext(r.Context()),
+		SourceProvider: source,
+		SourceAccount:  sourceAccount,
+		TargetProvider: target,
+		TargetAccount:  targetAccount,
+		PlaylistID:     playlistID,
+	}, kind, spec)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrScheduleLimit) {
+			status = http.StatusConflict
+		}
+		h.render(w, r, status, "", "Creating the schedule failed: "+err.Error())
+		return
+	}
+	h.render(w, r, http.StatusOK, fmt.Sprintf("Scheduled; the first run is at %s UTC.", schedule.NextRunAt.Format("2006-01-02 15:04")), "")
+}
+
+// HandleUpdateSchedule enables or disables one of the user's schedules
+func (h *ScheduleHandlers) HandleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	enabled := r.FormValue("enabled") == "true"
+	if _, err := h.scheduleService.UpdateSchedule(middleware.UserIDFromContext(r.Context()), id, services.ScheduleUpdate{Enabled: &enabled}); err != nil {
+		h.render(w, r, http.StatusBadRequest, "", "Updating the schedule failed: "+err.Error())
+		return
+	}
+	h.render(w, r, http.StatusOK, "", "")
+}
+
+// HandleRunSchedule runs one of the user's schedules now
+func (h *ScheduleHandlers) HandleRunSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.scheduleService.RunSchedule(r.Context(), middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, services.ErrScheduleBusy) {
+			status = http.StatusConflict
+		}
+		h.render(w, r, status, "", "Running the schedule failed: "+err.Error())
+		return
+	}
+	if run.Status == services.StatusFailed {
+		log.Printf("Scheduled run of %s failed: %s", id, run.Message)
+		h.render(w, r, http.StatusBadGateway, "", "The run failed: "+run.Message)
+		return
+	}
+	h.render(w, r, http.StatusOK, run.Message+".", "")
+}
+
+// HandleDeleteSchedule removes one of the user's schedules
+func (h *ScheduleHandlers) HandleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.scheduleService.DeleteSchedule(middleware.UserIDFromContext(r.Context()), id); err != nil {
+		h.render(w, r, http.StatusNotFound, "", "Deleting the schedule failed: "+err.Error())
+		return
+	}
+	h.render(w, r, http.StatusOK, "Schedule deleted.", "")
+}
+
+// account resolves a form value of a scheduleAccount to a provider name and
+// account ID
+func (h *ScheduleHandlers) account(value string) (string, string, error) {
+	slug, account, _ := strings.Cut(value, ":")
+	provider, err := h.transferService.GetProviderBySlug(slug)
+	if err != nil {
+		return "", "", err
+	}
+	return provider.Name(), account, nil
+}
+
+// render renders the schedules page with a message or an error
+func (h *ScheduleHandlers) render(w http.ResponseWriter, r *http.Request, status int, message, errMsg string) {
+	userID := middleware.UserIDFromContext(r.Context())
+	schedules, err := h.scheduleService.ListSchedules(userID)
+	if err != nil {
+		log.Printf("Failed to list schedules: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	views := make([]scheduleView, 0, len(schedules))
+	for _, schedule := range schedules {
+		runs, err := h.scheduleService.ListRuns(userID, schedule.ID, scheduleHistory)
+		if err != nil {
+			log.Printf("Failed to list runs of schedule %s: %v", schedule.ID, err)
+		}
+		views = append(views, scheduleView{Schedule: schedule, Runs: runs})
+	}
+
+	var accounts []scheduleAccount
+	for _, option := range h.transferService.ListAccounts(userID) {
+		value := services.ProviderSlug(option.Provider)
+		if option.Account != "" {
+			value += ":" + option.Account
+		}
+		accounts = append(accounts, scheduleAccount{Value: value, Label: option.Label})
+	}
+
+	data := map[string]interface{}{
+		"Title":     "Schedules",
+		"Username":  usernameFromContext(h.userStore, r),
+		"Schedules": views,
+		"Accounts":  accounts,
+		"Message":   message,
+		"Error":     errMsg,
+	}
+
+	w.WriteHeader(status)
+	if err := h.templates.ExecuteTemplate(w, "schedules.html", data); err != nil {
+		log.Printf("Error rendering schedules template: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+func TestScheduleHandlers(t *testing.T) {
+	templates, err := template.ParseGlob("../../web/templates/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+
+	transferService := services.NewTransferService()
+	transferService.RegisterProvider(providers.NewMockProvider())
+	transferService.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+	scheduleService := services.NewScheduleService(storage.NewInMemoryScheduleStore(), transferService)
+	schedules := NewScheduleHandlers(scheduleService, transferService, storage.NewInMemoryUserStore(), templates)
+
+	sessionStore := auth.NewInMemorySessionStore(0)
+	session, err := sessionStore.Create("user123")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	serve := func(handler http.HandlerFunc, method string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/schedules", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session_token", Value: session})
+		w := httptest.NewRecorder()
+		middleware.SessionMiddleware(sessionStore)(handler).ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(schedules.HandleSchedules, http.MethodGet, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<option value="mockmusic">Mock Music</option>`) {
+		t.Fatalf("Expected the page with the mock account, got %d: %s", w.Code, w.Body.String())
+	}
+
+	form := url.Values{"source": {"mockmusic"}, "target": {"mockmusic"}, "playlist_id": {"mock-1"}, "kind": {"transfer"}, "spec": {"*/5 * * * *"}}
+	w = serve(schedules.HandleCreateSchedule, http.MethodPost, form)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "runs more often than every 15m0s") {
+		t.Errorf("Expected an error for a spec running too often, got %d", w.Code)
+	}
+
+	form.Set("spec", "@daily")
+	w = serve(schedules.HandleCreateSchedule, http.MethodPost, form)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Scheduled; the first run is at") {
+		t.Fatalf("Expected the schedule to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	list, _ := scheduleService.ListSchedules("user123")
+	if len(list) != 1 {
+		t.Fatalf("Expected one schedule, got %d", len(list))
+	}
+	id := list[0].ID
+
+	w = serve(schedules.HandleRunSchedule, http.MethodPost, url.Values{"id": {id}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Copied &#34;Summer Vibes 2024&#34;: 3 matched") {
+		t.Errorf("Expected the run outcome, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); !strings.Contains(body, `<span class="tag is-success is-light">completed</span>`) {
+		t.Error("The page should show the run in the schedule's history")
+	}
+
+	w = serve(schedules.HandleUpdateSchedule, http.MethodPost, url.Values{"id": {id}, "enabled": {"false"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "paused") {
+		t.Errorf("Expected the schedule to be paused, got %d", w.Code)
+	}
+
+	w = serve(schedules.HandleDeleteSchedule, http.MethodPost, url.Values{"id": {id}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "You have no schedules yet.") {
+		t.Errorf("Expected the schedule to be deleted, got %d", w.Code)
+	}
+	if w := serve(schedules.HandleRunSchedule, http.MethodPost, url.Values{"id": {id}}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected an error for a deleted schedule, got %d", w.Code)
+	}
+	if w := serve(schedules.HandleDeleteSchedule, http.MethodGet, nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET, got %d", w.Code)
+	}
+}
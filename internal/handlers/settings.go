@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// tokenExpiryOptions are the lifetimes offered when creating an API token, in days; 0 means never
+var tokenExpiryOptions = []int{30, 90, 365, 0}
+
+// SettingsHandlers contains handlers for the account settings page
+type SettingsHandlers struct {
+	apiTokenStore auth.APITokenStore
+	auditStore    storage.AuditStore
+	userStore     storage.UserStore
+	templates     *template.Template
+}
+
+// NewSettingsHandlers creates a new SettingsHandlers instance
+func NewSettingsHandlers(apiTokenStore auth.APITokenStore, auditStore storage.AuditStore, userStore storage.UserStore, templates *template.Template) *SettingsHandlers {
+	return &SettingsHandlers{
+		apiTokenStore: apiTokenStore,
+		auditStore:    auditStore,
+		userStore:     userStore,
+		templates:     templates,
+	}
+}
+
+// HandleSettings renders the settings page with the user's API tokens
+func (h *SettingsHandlers) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, "", "")
+}
+
+// HandleCreateToken creates an API token and shows its secret once
+func (h *SettingsHandlers) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	scope := r.FormValue("scope")
+	days, err := strconv.Atoi(r.FormValue("expires_in"))
+	if name == "" || !auth.ValidScope(scope) || err != nil || days < 0 {
+		h.render(w, r, http.StatusBadRequest, "", "Please enter a name and choose a scope and expiry.")
+		return
+	}
+
+	var expiresAt time.Time
+	if days > 0 {
+		expiresAt = time.Now().AddDate(0, 0, days)
+	}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	secret, token, err := h.apiTokenStore.Create(userID, name, []string{scope}, expiresAt)
+	if err != nil {
+		log.Printf("Failed to create API token: %v", err)
+		h.render(w, r, http.StatusInternalServerError, "", "Failed to create API token.")
+		return
+	}
+
+	h.audit(userID, "api_token.create", fmt.Sprintf("token %q (%s) with scope %s", token.Name, token.Prefix, scope))
+	h.render(w, r, http.StatusOK, secret, "")
+}
+
+// HandleRevokeToken deletes one of the user's API tokens
+func (h *SettingsHandlers) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing token ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	if err := h.apiTokenStore.Revoke(userID, id); err != nil {
+		log.Printf("Failed to revoke API token: %v", err)
+		http.Error(w, "API token not found", http.StatusNotFound)
+		return
+	}
+
+	h.audit(userID, "api_token.revoke", "token "+id)
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// render renders the settings page. newToken is the secret of a token that
+// was just created, which is only ever shown on this response.
+func (h *SettingsHandlers) render(w http.ResponseWriter, r *http.Request, status int, newToken, errMsg string) {
+	tokens, err := h.apiTokenStore.List(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		log.Printf("Failed to list API tokens: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":         "Settings",
+		"Username":      usernameFromContext(h.userStore, r),
+		"Tokens":        tokens,
+		"Scopes":        []string{auth.ScopeRead, auth.ScopeTransfer, auth.ScopeAdmin},
+		"ExpiryOptions": tokenExpiryOptions,
+		"NewToken":      newToken,
+		"Error":         errMsg,
+		"Now":           time.Now(),
+	}
+
+	w.WriteHeader(status)
+	if err := h.templates.ExecuteTemplate(w, "settings.html", data); err != nil {
+		log.Printf("Error rendering settings template: %v", err)
+	}
+}
+
+// audit records a token change; failures are only logged
+func (h *SettingsHandlers) audit(userID, action, details string) {
+	event := &models.AuditEvent{UserID: userID, Action: action, Details: details}
+	if err := h.auditStore.Record(event); err != nil {
+		log.Printf("Failed to record audit event: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+func TestSettingsHandlers_CreateAndRevokeToken(t *testing.T) {
+	templates, err := template.ParseGlob("../../web/templates/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+
+	apiTokenStore := auth.NewInMemoryAPITokenStore()
+	auditStore := storage.NewInMemoryAuditStore()
+	settings := NewSettingsHandlers(apiTokenStore, auditStore, storage.NewInMemoryUserStore(), templates)
+
+	sessionStore := auth.NewInMemorySessionStore(0)
+	session, err := sessionStore.Create("user123")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	serve := func(handler http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/settings/tokens", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session_token", Value: session})
+		w := httptest.NewRecorder()
+		middleware.SessionMiddleware(sessionStore)(handler).ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(settings.HandleCreateToken, url.Values{"name": {"cron job"}, "scope": {"transfer"}, "expires_in": {"30"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	tokens, _ := apiTokenStore.List("user123")
+	if len(tokens) != 1 || tokens[0].Name != "cron job" || tokens[0].ExpiresAt == nil {
+		t.Fatalf("Expected one expiring token named 'cron job', got %+v", tokens)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "will not be shown again") || !strings.Contains(body, tokens[0].Prefix) {
+		t.Error("Response should show the new token once")
+	}
+
+	if w := serve(settings.HandleCreateToken, url.Values{"name": {"bad"}, "scope": {"write"}, "expires_in": {"0"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown scope, got %d", w.Code)
+	}
+
+	w = serve(settings.HandleRevokeToken, url.Values{"id": {tokens[0].ID}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status 303, got %d", w.Code)
+	}
+	if tokens, _ := apiTokenStore.List("user123"); len(tokens) != 0 {
+		t.Errorf("Expected the token to be revoked, got %+v", tokens)
+	}
+
+	events, _ := auditStore.List("user123", 10)
+	if len(events) != 2 || events[0].Action != "api_token.revoke" || events[1].Action != "api_token.create" {
+		t.Errorf("Expected create and revoke audit events, got %+v", events)
+	}
+}
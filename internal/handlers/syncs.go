@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/services"
+)
+
+// HandleSyncs renders the sync links page, where conflicts of two-way links
+// are resolved
+func (h *Handlers) HandleSyncs(w http.ResponseWriter, r *http.Request) {
+	h.renderSyncs(w, r, http.StatusOK, "", "")
+}
+
+// HandleRunSync syncs one of the user's links
+func (h *Handlers) HandleRunSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Missing sync link ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.transferService.RunSync(r.Context(), middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		log.Printf("Failed to sync link %s: %v", id, err)
+		h.renderSyncs(w, r, http.StatusBadGateway, "", "Sync failed: "+err.Error())
+		return
+	}
+	h.renderSyncs(w, r, http.StatusOK, syncSummary(result), "")
+}
+
+// HandleResolveSyncConflict resolves a conflict of a two-way link in favour
+// of the side the user picked and syncs the link
+func (h *Handlers) HandleResolveSyncConflict(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.FormValue("id")
+	conflictID := r.FormValue("conflict")
+	keep := r.FormValue("keep")
+	if id == "" || conflictID == "" || (keep != models.SyncSideSource && keep != models.SyncSideTarget) {
+		http.Error(w, "Missing sync link, conflict or side", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.transferService.ResolveSyncConflict(r.Context(), middleware.UserIDFromContext(r.Context()), id, conflictID, keep)
+	if err != nil {
+		log.Printf("Failed to resolve conflict %s of sync link %s: %v", conflictID, id, err)
+		h.renderSyncs(w, r, http.StatusBadGateway, "", "Resolving the conflict failed: "+err.Error())
+		return
+	}
+	h.renderSyncs(w, r, http.StatusOK, syncSummary(result), "")
+}
+
+// renderSyncs renders the sync links page with a message or an error
+func (h *Handlers) renderSyncs(w http.ResponseWriter, r *http.Request, status int, message, errMsg string) {
+	links, err := h.transferService.ListSyncLinks(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		log.Printf("Failed to list sync links: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":    "Playlist Sync",
+		"Username": h.getUsernameFromContext(r),
+		"Links":    links,
+		"Message":  message,
+		"Error":    errMsg,
+	}
+
+	w.WriteHeader(status)
+	if err := h.templates.ExecuteTemplate(w, "syncs.html", data); err != nil {
+		log.Printf("Error rendering syncs template: %v", err)
+	}
+}
+
+// syncSummary describes what a sync changed in a sentence
+func syncSummary(result services.SyncResult) string {
+	summary := fmt.Sprintf("Synced %q: %d added to and %d removed from the %s playlist",
+		result.Link.Name, result.Added, result.Removed, result.Link.TargetProvider)
+	if result.Link.TwoWay {
+		summary += fmt.Sprintf(", %d added to and %d removed from the %s playlist",
+			result.SourceAdded, result.SourceRemoved, result.Link.SourceProvider)
+	}
+	if n := len(result.Link.Conflicts); n > 0 {
+		summary += fmt.Sprintf(". %d conflicts need your decision", n)
+	}
+	return summary + "."
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/middleware"
+	"github.com/JanikSachs/PlayPort/internal/services"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+func TestHandleSyncs(t *testing.T) {
+	handlers := setupTestHandlers(t)
+	handlers.transferService.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+	created, err := handlers.transferService.CreateSyncLink(context.Background(), services.TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", PlaylistID: "mock-2",
+	}, services.SyncOptions{TwoWay: true})
+	if err != nil {
+		t.Fatalf("CreateSyncLink() failed: %v", err)
+	}
+
+	sessionStore := auth.NewInMemorySessionStore(0)
+	session, err := sessionStore.Create("user123")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	serve := func(handler http.HandlerFunc, method string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/syncs", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session_token", Value: session})
+		w := httptest.NewRecorder()
+		middleware.SessionMiddleware(sessionStore)(handler).ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(handlers.HandleSyncs, http.MethodGet, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Workout Mix") || !strings.Contains(body, "two-way, conflicts: manual") {
+		t.Error("The page should list the two-way link")
+	}
+
+	w = serve(handlers.HandleRunSync, http.MethodPost, url.Values{"id": {created.Link.ID}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Synced &#34;Workout Mix&#34;") {
+		t.Errorf("Expected the sync summary, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(handlers.HandleResolveSyncConflict, http.MethodPost, url.Values{"id": {created.Link.ID}, "conflict": {"order"}, "keep": {"both"}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown side, got %d", w.Code)
+	}
+	w = serve(handlers.HandleResolveSyncConflict, http.MethodPost, url.Values{"id": {created.Link.ID}, "conflict": {"order"}, "keep": {"source"}})
+	if w.Code != http.StatusBadGateway || !strings.Contains(w.Body.String(), "sync conflict not found") {
+		t.Errorf("Expected an error for a conflict the link does not have, got %d", w.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+)
+
+const scopesKey contextKey = "scopes"
+
+// APITokenMiddleware returns an HTTP middleware that authenticates JSON API
+// requests carrying an "Authorization: Bearer <token>" header. It injects the
+// token owner's userID and the token's scopes into the request context.
+// Requests without a bearer token are passed on unchanged so the session
+// middleware can handle them; requests with an invalid token get a 401.
+func APITokenMiddleware(tokenStore auth.APITokenStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret, ok := bearerToken(r)
+			if !ok || !strings.HasPrefix(r.URL.Path, "/api/v1/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, err := tokenStore.Authenticate(secret)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				rejectUnauthenticated(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey, token.UserID)
+			ctx = context.WithValue(ctx, scopesKey, token.Scopes)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// HasScope reports whether the request may perform actions that need the
+// given API token scope. Requests authenticated by a session cookie have
+// every scope.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(scopesKey).([]string)
+	if !ok {
+		return true
+	}
+	return auth.ScopeAllows(scopes, scope)
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+)
+
+// scopeRecorder records the user and scope checks of the requests it serves
+type scopeRecorder struct {
+	userID        string
+	canTransfer   bool
+	canAdminister bool
+}
+
+func (s *scopeRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.userID = UserIDFromContext(r.Context())
+	s.canTransfer = HasScope(r.Context(), auth.ScopeTransfer)
+	s.canAdminister = HasScope(r.Context(), auth.ScopeAdmin)
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestAPITokenMiddleware(t *testing.T) {
+	tokenStore := auth.NewInMemoryAPITokenStore()
+	secret, _, err := tokenStore.Create("user123", "ci", []string{auth.ScopeTransfer}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to create API token: %v", err)
+	}
+	expired, _, err := tokenStore.Create("user123", "old", []string{auth.ScopeRead}, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create API token: %v", err)
+	}
+
+	sessionStore := auth.NewInMemorySessionStore(0)
+	recorder := &scopeRecorder{}
+	handler := APITokenMiddleware(tokenStore)(SessionMiddleware(sessionStore)(recorder))
+
+	serve := func(path, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		w := serve("/api/v1/transfers", "Bearer "+secret)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if recorder.userID != "user123" {
+			t.Errorf("Expected userID user123, got %q", recorder.userID)
+		}
+		if !recorder.canTransfer || recorder.canAdminister {
+			t.Errorf("Expected transfer but not admin scope, got transfer=%v admin=%v", recorder.canTransfer, recorder.canAdminister)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		w := serve("/api/v1/transfers", "Bearer pp_wrong")
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+		if w.Header().Get("WWW-Authenticate") == "" {
+			t.Error("Expected WWW-Authenticate header")
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		if w := serve("/api/v1/transfers", "Bearer "+expired); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("token outside the API", func(t *testing.T) {
+		w := serve("/settings", "Bearer "+secret)
+		if w.Code != http.StatusFound {
+			t.Errorf("Expected tokens to be ignored outside the API (302), got %d", w.Code)
+		}
+	})
+}
+
+func TestHasScope_Session(t *testing.T) {
+	sessionStore := auth.NewInMemorySessionStore(0)
+	token, err := sessionStore.Create("user123")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	recorder := &scopeRecorder{}
+	handler := APITokenMiddleware(auth.NewInMemoryAPITokenStore())(SessionMiddleware(sessionStore)(recorder))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transfers", nil)
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: token})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !recorder.canAdminister {
+		t.Error("Session requests should have every scope")
+	}
+}
@@ -15,10 +15,17 @@ const userIDKey contextKey = "userID"
 // SessionMiddleware returns an HTTP middleware that enforces authentication.
 // It reads a "session_token" cookie, looks up the session, and injects the userID
 // into the request context. Unauthenticated requests are redirected to /login,
-// except for public paths (login, register, static assets).
+// except for public paths (login, register, static assets). Unauthenticated
+// JSON API requests get a 401 error envelope instead of a redirect.
+// Requests already authenticated by APITokenMiddleware are passed through.
 func SessionMiddleware(sessionStore auth.SessionStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if UserIDFromContext(r.Context()) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Allow public paths without authentication
 			if isPublicPath(r) {
 				// Still try to inject userID if session exists
@@ -38,13 +45,13 @@ func SessionMiddleware(sessionStore auth.SessionStore) func(http.Handler) http.H
 			// For protected paths, require authentication
 			cookie, err := r.Cookie("session_token")
 			if err != nil {
-				http.Redirect(w, r, "/login", http.StatusFound)
+				rejectUnauthenticated(w, r)
 				return
 			}
 
 			uid, err := sessionStore.Get(cookie.Value)
 			if err != nil {
-				http.Redirect(w, r, "/login", http.StatusFound)
+				rejectUnauthenticated(w, r)
 				return
 			}
 
@@ -54,11 +61,23 @@ func SessionMiddleware(sessionStore auth.SessionStore) func(http.Handler) http.H
 	}
 }
 
+// rejectUnauthenticated sends API clients a 401 error envelope and
+// redirects browsers to the login page
+func rejectUnauthenticated(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"code":"unauthorized","message":"authentication required"}}` + "\n"))
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusFound)
+}
+
 // isPublicPath returns true for paths that don't require authentication
 func isPublicPath(r *http.Request) bool {
 	path := r.URL.Path
 
-	if path == "/login" || path == "/register" || path == "/logout" {
+	if path == "/login" || path == "/register" || path == "/logout" || path == "/api/v1/openapi.json" {
 		return true
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/JanikSachs/PlayPort/internal/auth"
@@ -33,12 +34,35 @@ func TestSessionMiddleware_RedirectsUnauthenticated(t *testing.T) {
 	}
 }
 
+func TestSessionMiddleware_RejectsUnauthenticatedAPIRequests(t *testing.T) {
+	sessionStore := auth.NewInMemorySessionStore(0)
+
+	handler := SessionMiddleware(sessionStore)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Handler should not be called without a session")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transfers", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON response, got %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), `"code":"unauthorized"`) {
+		t.Errorf("Expected error envelope, got %s", w.Body.String())
+	}
+}
+
 func TestSessionMiddleware_AllowsPublicPaths(t *testing.T) {
 	sessionStore := auth.NewInMemorySessionStore(0)
 
 	mw := SessionMiddleware(sessionStore)
 
-	publicPaths := []string{"/login", "/register", "/static/css/custom.css"}
+	publicPaths := []string{"/login", "/register", "/static/css/custom.css", "/api/v1/openapi.json"}
 
 	for _, path := range publicPaths {
 		t.Run(path, func(t *testing.T) {
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AuditEvent records a security-relevant action taken by a user
+type AuditEvent struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	Action         string    `json:"action"`           // e.g., "connection.disconnect"
+	Provider       string    `json:"provider"`         // e.g., "spotify"; empty if not provider-specific
+	ExternalUserID string    `json:"external_user_id"` // Provider account the action applied to
+	Details        string    `json:"details"`          // Human-readable outcome
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// MatchOverride pins the target track a source track is transferred as,
+// replacing automatic matching for that track
+type MatchOverride struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"-"`
+	SourceProvider string    `json:"source_provider"` // provider slug, e.g. "spotify"
+	SourceTrackID  string    `json:"source_track_id"`
+	TargetProvider string    `json:"target_provider"` // provider slug, e.g. "youtubemusic"
+	TargetTrackID  string    `json:"target_track_id"` // empty to skip the track
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -11,6 +11,9 @@ type Track struct {
 	Duration    int       `json:"duration"` // in seconds
 	ISRC        string    `json:"isrc"`     // International Standard Recording Code
 	ReleaseDate time.Time `json:"release_date"`
+	Location    string    `json:"location,omitempty"` // file path or URL, for file-based providers
+	BPM         float64   `json:"bpm,omitempty"`      // tempo, as kept by DJ software
+	Key         string    `json:"key,omitempty"`      // musical key, e.g. "Am" or "F#"
 }
 
 // Playlist represents a music playlist from any platform
@@ -25,6 +28,22 @@ type Playlist struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// Album represents an album saved in a user's library
+type Album struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Artist      string    `json:"artist"`
+	UPC         string    `json:"upc"` // Universal Product Code or EAN of the release
+	TrackCount  int       `json:"track_count"`
+	ReleaseDate time.Time `json:"release_date"`
+}
+
+// Artist represents an artist a user follows
+type Artist struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 // Connection represents a user's connection to a music platform
 type Connection struct {
 	ID               string    `json:"id"`
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Kinds of schedules
+const (
+	ScheduleTransfer = "transfer" // copy the source playlist to a new target playlist on every run
+	ScheduleSync     = "sync"     // copy the source playlist once, then sync the copy on every run
+)
+
+// Schedule runs a transfer or a sync of one of a user's playlists again and
+// again. Spec is a cron expression, a shorthand such as "@daily" or an
+// interval such as "@every 6h"; see package cron.
+type Schedule struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"-"`
+	Kind             string    `json:"kind"`            // ScheduleTransfer or ScheduleSync
+	SourceProvider   string    `json:"source_provider"` // provider name, e.g. "Spotify"
+	SourceAccount    string    `json:"source_account,omitempty"`
+	SourcePlaylistID string    `json:"source_playlist_id"`
+	TargetProvider   string    `json:"target_provider"` // provider name, e.g. "Deezer"
+	TargetAccount    string    `json:"target_account,omitempty"`
+	SyncLinkID       string    `json:"sync_link_id,omitempty"` // sync schedules only; set by the first run
+	Spec             string    `json:"spec"`
+	Enabled          bool      `json:"enabled"`
+	NextRunAt        time.Time `json:"next_run_at"`           // including jitter; zero if paused or the spec never fires again
+	LastRunAt        time.Time `json:"last_run_at"`           // zero if it never ran
+	LastStatus       string    `json:"last_status,omitempty"` // status of the last run
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ScheduleRun is the outcome of one run of a schedule
+type ScheduleRun struct {
+	ID         string    `json:"id"`
+	ScheduleID string    `json:"schedule_id"`
+	UserID     string    `json:"-"`
+	Status     string    `json:"status"`  // "completed" or "failed"
+	Message    string    `json:"message"` // what the run did, or why it failed
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
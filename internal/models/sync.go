@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Sides of a sync link
+const (
+	SyncSideSource = "source"
+	SyncSideTarget = "target"
+)
+
+// Conflict policies of two-way sync links: how conflicting edits made to
+// both playlists since the last sync are resolved
+const (
+	ConflictManual     = "manual" // flag the conflict for the user to resolve; the default
+	ConflictSourceWins = "source" // keep the source playlist's edit
+	ConflictTargetWins = "target" // keep the target playlist's edit
+)
+
+// ValidConflictPolicy reports whether policy is a known conflict policy
+func ValidConflictPolicy(policy string) bool {
+	switch policy {
+	case ConflictManual, ConflictSourceWins, ConflictTargetWins:
+		return true
+	}
+	return false
+}
+
+// Kinds of sync conflicts
+const (
+	ConflictReadded = "readded" // a track was added on one side after the other side removed it
+	ConflictOrder   = "order"   // both sides reordered their tracks, differently
+)
+
+// SyncLink ties a target playlist to the source playlist it was copied from,
+// so that later changes to the source can be applied to the target. Two-way
+// links also apply changes made to the target back to the source.
+type SyncLink struct {
+	ID               string         `json:"id"`
+	UserID           string         `json:"-"`
+	SourceProvider   string         `json:"source_provider"` // provider name, e.g. "Spotify"
+	SourceAccount    string         `json:"source_account,omitempty"`
+	SourcePlaylistID string         `json:"source_playlist_id"`
+	TargetProvider   string         `json:"target_provider"` // provider name, e.g. "Deezer"
+	TargetAccount    string         `json:"target_account,omitempty"`
+	TargetPlaylistID string         `json:"target_playlist_id"`
+	TwoWay           bool           `json:"two_way"`
+	ConflictPolicy   string         `json:"conflict_policy,omitempty"` // two-way links only
+	Name             string         `json:"name"`                      // source playlist name as of the last sync
+	Tracks           []SyncedTrack  `json:"tracks"`                    // tracks as of the last sync, in order
+	Conflicts        []SyncConflict `json:"conflicts,omitempty"`       // unresolved conflicts of the last sync
+	Disconnected     string         `json:"disconnected,omitempty"`    // why the link cannot sync since one of its accounts was disconnected; cleared by the next sync
+	LastSyncedAt     time.Time      `json:"last_synced_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// SyncedTrack is a source track of a sync link and the target track it was
+// matched to. On two-way links, tracks added to the target are stored with
+// the source track they were matched to.
+type SyncedTrack struct {
+	SourceID string `json:"source_id,omitempty"` // empty if no match was found
+	TargetID string `json:"target_id,omitempty"` // empty if no match was found
+}
+
+// SyncConflict is an edit made to one side of a two-way link that conflicts
+// with an edit made to the other side. Both edits are left unsynced until
+// the conflict is resolved.
+type SyncConflict struct {
+	ID      string `json:"id"`                 // stays the same on every sync until resolved
+	Kind    string `json:"kind"`               // "readded" or "order"
+	Side    string `json:"side,omitempty"`     // side the track was added to, for "readded"
+	TrackID string `json:"track_id,omitempty"` // ID of the added track on Side, for "readded"
+	Title   string `json:"title,omitempty"`
+	Artist  string `json:"artist,omitempty"`
+}
@@ -0,0 +1,180 @@
+package playlistfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// collectionExtensions are the extensions of whole-library exports: iTunes
+// and Rekordbox write XML, Traktor NML
+var collectionExtensions = []string{".xml", ".nml"}
+
+// sniffSize is how much of a collection file is read to find its root element
+const sniffSize = 64 << 10
+
+// IsCollectionFile reports whether a file name looks like an exported music
+// library, which holds many playlists; see ReadCollection
+func IsCollectionFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range collectionExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadCollection parses an exported music library and returns its
+// playlists: an iTunes or Apple Music Library.xml, a Rekordbox collection
+// XML or a Traktor NML file. The format is recognised by the root element.
+func ReadCollection(r io.Reader) ([]models.Playlist, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	root, err := sniffRootElement(br)
+	if err != nil {
+		return nil, err
+	}
+	switch root {
+	case "plist":
+		return ReadITunesLibrary(br)
+	case rekordboxRoot:
+		return ReadRekordbox(br)
+	case traktorRoot:
+		return ReadTraktor(br)
+	default:
+		return nil, fmt.Errorf("unrecognised library file with root element <%s>", root)
+	}
+}
+
+// readCollectionPlaylist reads a collection file that holds a single
+// playlist, such as the ones Write produces
+func readCollectionPlaylist(r io.Reader, name string) (models.Playlist, error) {
+	playlists, err := ReadCollection(r)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	if len(playlists) != 1 {
+		return models.Playlist{}, fmt.Errorf("expected one playlist, the file holds %d", len(playlists))
+	}
+	playlist := playlists[0]
+	if playlist.Name == "" {
+		playlist.Name = name
+	}
+	return playlist, nil
+}
+
+// sniffRootElement returns the name of the first element of an XML
+// document without consuming it
+func sniffRootElement(br *bufio.Reader) (string, error) {
+	head, err := br.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	dec := xml.NewDecoder(bytes.NewReader(head))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("not an XML library file")
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// collectionTracks gives each distinct track of a set of playlists a
+// number, starting at 1, as DJ collections list every track once and
+// playlists refer to them. It returns the tracks in order and a function
+// that looks up a track's number.
+func collectionTracks(playlists []models.Playlist) ([]models.Track, func(models.Track) int) {
+	var tracks []models.Track
+	numbers := map[string]int{}
+	for _, p := range playlists {
+		for _, t := range p.Tracks {
+			key := collectionTrackKey(t)
+			if _, ok := numbers[key]; !ok {
+				tracks = append(tracks, t)
+				numbers[key] = len(tracks)
+			}
+		}
+	}
+	return tracks, func(t models.Track) int { return numbers[collectionTrackKey(t)] }
+}
+
+// collectionTrackKey identifies a track by its file, its ID or, failing
+// both, its artist and title
+func collectionTrackKey(t models.Track) string {
+	switch {
+	case t.Location != "":
+		return "location:" + t.Location
+	case t.ID != "":
+		return "id:" + t.ID
+	default:
+		return "meta:" + t.Artist + "\x00" + t.Title
+	}
+}
+
+// uniquePlaylistIDs makes playlist IDs derived from names unique by
+// numbering repeats, so every playlist can be chosen on its own
+func uniquePlaylistIDs(playlists []models.Playlist) {
+	seen := map[string]int{}
+	for i := range playlists {
+		id := playlists[i].ID
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			playlists[i].ID = id + "#" + strconv.Itoa(n)
+		}
+	}
+}
+
+// fileURLPath turns a file:// URL into a local path. Windows libraries
+// write file://localhost/C:/…, which becomes C:/….
+func fileURLPath(location string) string {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "file" {
+		return location
+	}
+	p := u.Path
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return p
+}
+
+// pathFileURL turns a local path into a file://localhost/ URL, the form
+// Rekordbox uses. Locations that already are URLs are kept.
+func pathFileURL(p string) string {
+	if strings.Contains(p, "://") {
+		return p
+	}
+	p = strings.ReplaceAll(p, `\`, "/")
+	if len(p) >= 2 && p[1] == ':' {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Host: "localhost", Path: p}).String()
+}
+
+// formatBPM formats a tempo with two decimals, or returns "" if unknown
+func formatBPM(bpm float64) string {
+	if bpm <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(bpm, 'f', 2, 64)
+}
+
+// parseBPM parses a tempo attribute, ignoring invalid values
+func parseBPM(s string) float64 {
+	bpm, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || bpm <= 0 {
+		return 0
+	}
+	return bpm
+}
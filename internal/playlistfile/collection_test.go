@@ -0,0 +1,105 @@
+package playlistfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func TestReadCollection(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		first string
+	}{
+		{"itunes", testLibrary, "Summer & Sun"},
+		{"rekordbox", testRekordbox, "Warm Up"},
+		{"traktor", testTraktor, "Classics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlists, err := ReadCollection(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadCollection() failed: %v", err)
+			}
+			if len(playlists) == 0 || playlists[0].Name != tt.first {
+				t.Errorf("Expected %q first, got %+v", tt.first, playlists)
+			}
+		})
+	}
+
+	for _, input := range []string{"", "not xml", `<?xml version="1.0"?><playlist/>`} {
+		if _, err := ReadCollection(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadCollection(%q) should fail", input)
+		}
+	}
+}
+
+func TestRead_CollectionFormats(t *testing.T) {
+	playlist := models.Playlist{Name: "Crate", Tracks: []models.Track{
+		{ID: "/music/a.mp3", Location: "/music/a.mp3", Title: "A", Artist: "B", Duration: 61},
+	}}
+	for _, format := range []string{FormatRekordbox, FormatTraktor} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, playlist); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			got, err := Read(&buf, format, "fallback")
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+			if got.Name != "Crate" || got.TrackCount != 1 || got.Tracks[0].Location != "/music/a.mp3" {
+				t.Errorf("Expected the crate back, got %+v", got)
+			}
+		})
+	}
+
+	if _, err := Read(strings.NewReader(testRekordbox), FormatRekordbox, "x"); err == nil {
+		t.Error("Read() should reject collections with several playlists")
+	}
+}
+
+func TestIsCollectionFile(t *testing.T) {
+	tests := map[string]bool{
+		"Library.xml":    true,
+		"rekordbox.XML":  true,
+		"collection.nml": true,
+		"mix.xspf":       false,
+		"mix.m3u8":       false,
+	}
+	for name, want := range tests {
+		if got := IsCollectionFile(name); got != want {
+			t.Errorf("IsCollectionFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFileURLPath(t *testing.T) {
+	tests := map[string]string{
+		"file://localhost/C:/Users/Jane/Music/Song%201.mp3": "C:/Users/Jane/Music/Song 1.mp3",
+		"file:///Users/jane/Music/a.m4a":                    "/Users/jane/Music/a.m4a",
+		"http://example.com/stream":                         "http://example.com/stream",
+		"":                                                  "",
+	}
+	for in, want := range tests {
+		if got := fileURLPath(in); got != want {
+			t.Errorf("fileURLPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPathFileURL(t *testing.T) {
+	tests := map[string]string{
+		"/Users/jane/Music/a b.mp3": "file://localhost/Users/jane/Music/a%20b.mp3",
+		`C:\Music\a.mp3`:            "file://localhost/C:/Music/a.mp3",
+		"http://example.com/stream": "http://example.com/stream",
+	}
+	for in, want := range tests {
+		if got := pathFileURL(in); got != want {
+			t.Errorf("pathFileURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
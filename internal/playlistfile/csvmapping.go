@@ -0,0 +1,323 @@
+package playlistfile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// Track fields a CSV column can be mapped to
+const (
+	FieldTitle    = "title"
+	FieldArtist   = "artist"
+	FieldAlbum    = "album"
+	FieldDuration = "duration"
+	FieldISRC     = "isrc"
+	FieldID       = "id"
+)
+
+// CSVFields lists the mappable fields in the order they are shown
+var CSVFields = []string{FieldTitle, FieldArtist, FieldAlbum, FieldDuration, FieldISRC, FieldID}
+
+// CSV layouts recognised by DetectCSVMapping
+const (
+	LayoutExportify = "exportify"
+	LayoutSoundiiz  = "soundiiz"
+	LayoutGeneric   = "generic"
+)
+
+// Units of a numeric duration column. Clock values such as 3:45 are
+// accepted whatever the unit.
+const (
+	DurationSeconds = "seconds"
+	DurationMillis  = "ms"
+)
+
+// CSVMapping says which column holds which track field
+type CSVMapping struct {
+	Layout       string         // detected layout, empty if mapped by hand
+	Columns      map[string]int // field -> zero-based column index
+	DurationUnit string         // DurationSeconds (default) or DurationMillis
+}
+
+// CSVPreview is the start of a CSV file together with the mapping
+// DetectCSVMapping suggests for it
+type CSVPreview struct {
+	Header   []string
+	Rows     [][]string
+	Mapping  CSVMapping
+	Detected bool // whether Mapping can be used as is
+}
+
+// exportifyColumns maps Exportify's headers, old and new, to fields
+var exportifyColumns = map[string]string{
+	"track name":          FieldTitle,
+	"artist name(s)":      FieldArtist,
+	"album name":          FieldAlbum,
+	"track duration (ms)": FieldDuration,
+	"duration (ms)":       FieldDuration,
+	"isrc":                FieldISRC,
+	"track uri":           FieldID,
+	"spotify id":          FieldID,
+}
+
+// soundiizHeader is the column layout of Soundiiz CSV exports
+var soundiizHeader = []string{"title", "artist", "album", "isrc"}
+
+// genericColumns maps common header names to fields. This covers
+// PlayPort's own CSV, TuneMyMusic and most hand-made spreadsheets.
+var genericColumns = map[string]string{
+	"title":       FieldTitle,
+	"track":       FieldTitle,
+	"track name":  FieldTitle,
+	"track title": FieldTitle,
+	"name":        FieldTitle,
+	"song":        FieldTitle,
+	"song name":   FieldTitle,
+	"artist":      FieldArtist,
+	"artists":     FieldArtist,
+	"artist name": FieldArtist,
+	"performer":   FieldArtist,
+	"album":       FieldAlbum,
+	"album name":  FieldAlbum,
+	"album title": FieldAlbum,
+	"duration":    FieldDuration,
+	"length":      FieldDuration,
+	"time":        FieldDuration,
+	"isrc":        FieldISRC,
+	"id":          FieldID,
+	"uri":         FieldID,
+	"url":         FieldID,
+}
+
+// DetectCSVMapping recognises Exportify, Soundiiz and generic headers. It
+// reports false if no title column was found; the returned mapping then
+// holds whichever columns could be matched, as a starting point.
+func DetectCSVMapping(header []string) (CSVMapping, bool) {
+	names := make([]string, len(header))
+	for i, h := range header {
+		names[i] = normalizeHeader(h)
+	}
+
+	if mapping, ok := exportifyMapping(names); ok {
+		return mapping, true
+	}
+
+	mapping := CSVMapping{Layout: LayoutGeneric, Columns: map[string]int{}, DurationUnit: DurationSeconds}
+	if len(names) >= len(soundiizHeader) && slices.Equal(names[:len(soundiizHeader)], soundiizHeader) {
+		mapping.Layout = LayoutSoundiiz
+	}
+	for i, name := range names {
+		millis := false
+		if base, ok := strings.CutSuffix(name, "(ms)"); ok {
+			name, millis = strings.TrimSpace(base), true
+		} else if base, ok := strings.CutSuffix(name, "_ms"); ok {
+			name, millis = base, true
+		}
+
+		field, ok := genericColumns[name]
+		if !ok {
+			continue
+		}
+		if _, taken := mapping.Columns[field]; taken {
+			continue
+		}
+		mapping.Columns[field] = i
+		if field == FieldDuration && millis {
+			mapping.DurationUnit = DurationMillis
+		}
+	}
+
+	if _, ok := mapping.Columns[FieldTitle]; !ok {
+		mapping.Layout = ""
+		return mapping, false
+	}
+	return mapping, true
+}
+
+// exportifyMapping detects Exportify exports by their Track Name column
+// next to a Spotify track URI or ID
+func exportifyMapping(names []string) (CSVMapping, bool) {
+	mapping := CSVMapping{Layout: LayoutExportify, Columns: map[string]int{}, DurationUnit: DurationMillis}
+	for i, name := range names {
+		field, ok := exportifyColumns[name]
+		if _, taken := mapping.Columns[field]; ok && !taken {
+			mapping.Columns[field] = i
+		}
+	}
+	_, hasTitle := mapping.Columns[FieldTitle]
+	_, hasID := mapping.Columns[FieldID]
+	return mapping, hasTitle && hasID
+}
+
+// Validate checks that the mapping has a title column and no column is
+// out of range
+func (m CSVMapping) Validate(columns int) error {
+	if _, ok := m.Columns[FieldTitle]; !ok {
+		return fmt.Errorf("no column is mapped to the track title")
+	}
+	for field, i := range m.Columns {
+		if i < 0 || i >= columns {
+			return fmt.Errorf("column %d mapped to %s does not exist", i+1, field)
+		}
+	}
+	if m.DurationUnit != "" && m.DurationUnit != DurationSeconds && m.DurationUnit != DurationMillis {
+		return fmt.Errorf("unknown duration unit %q", m.DurationUnit)
+	}
+	return nil
+}
+
+// PreviewCSV reads the header and up to rows records of a CSV file and
+// suggests a column mapping for it
+func PreviewCSV(r io.Reader, rows int) (CSVPreview, error) {
+	records, err := readCSVRecords(r)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+
+	preview := CSVPreview{Header: records[0]}
+	preview.Mapping, preview.Detected = DetectCSVMapping(records[0])
+	for _, record := range records[1:] {
+		if len(preview.Rows) == rows {
+			break
+		}
+		if !blankRecord(record) {
+			preview.Rows = append(preview.Rows, record)
+		}
+	}
+	return preview, nil
+}
+
+// ReadCSVWithMapping decodes tracks from CSV with a header row, taking each
+// field from the column the mapping assigns to it. Rows without a title are
+// skipped.
+func ReadCSVWithMapping(r io.Reader, name string, mapping CSVMapping) (models.Playlist, error) {
+	records, err := readCSVRecords(r)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	return decodeCSVRecords(records, name, mapping)
+}
+
+// decodeCSVRecords turns the records of a CSV file, header first, into a
+// playlist
+func decodeCSVRecords(records [][]string, name string, mapping CSVMapping) (models.Playlist, error) {
+	if err := mapping.Validate(len(records[0])); err != nil {
+		return models.Playlist{}, fmt.Errorf("invalid CSV column mapping: %w", err)
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := mapping.Columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	playlist := models.Playlist{Name: name, Tracks: []models.Track{}}
+	for n, record := range records[1:] {
+		track := models.Track{
+			ID:     field(record, FieldID),
+			Title:  field(record, FieldTitle),
+			Artist: field(record, FieldArtist),
+			Album:  field(record, FieldAlbum),
+			ISRC:   strings.ToUpper(field(record, FieldISRC)),
+		}
+		if track.Title == "" {
+			continue
+		}
+		if d := field(record, FieldDuration); d != "" {
+			var err error
+			if track.Duration, err = parseCSVDuration(d, mapping.DurationUnit); err != nil {
+				return models.Playlist{}, fmt.Errorf("invalid duration %q on line %d", d, n+2)
+			}
+		}
+		playlist.Tracks = append(playlist.Tracks, track)
+	}
+
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// readCSVRecords reads a whole CSV file. The delimiter is sniffed from the
+// header line, since spreadsheet exports often use semicolons or tabs.
+func readCSVRecords(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV playlist: %w", err)
+	}
+	text := strings.TrimPrefix(decodeText(data), utf8BOM)
+
+	cr := csv.NewReader(strings.NewReader(text))
+	cr.Comma = sniffDelimiter(text)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV playlist: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("invalid CSV playlist: %w", io.EOF)
+	}
+	return records, nil
+}
+
+// sniffDelimiter picks the most frequent of comma, semicolon and tab in
+// the first line, preferring comma
+func sniffDelimiter(text string) rune {
+	line, _, _ := strings.Cut(text, "\n")
+	best, count := ',', strings.Count(line, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(line, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// parseCSVDuration reads a duration as h:mm:ss, m:ss or a number in the
+// given unit, returning whole seconds
+func parseCSVDuration(value, unit string) (int, error) {
+	if strings.Contains(value, ":") {
+		seconds := 0
+		for _, part := range strings.Split(value, ":") {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration")
+			}
+			seconds = seconds*60 + n
+		}
+		return seconds, nil
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid duration")
+	}
+	if unit == DurationMillis {
+		n /= 1000
+	}
+	return int(math.Round(n)), nil
+}
+
+// normalizeHeader lower-cases a header and collapses its whitespace
+func normalizeHeader(h string) string {
+	return strings.Join(strings.Fields(strings.ToLower(h)), " ")
+}
+
+// blankRecord reports whether every cell of a record is empty
+func blankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
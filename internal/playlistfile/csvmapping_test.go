@@ -0,0 +1,159 @@
+package playlistfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectCSVMapping_Layouts(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		layout string
+		unit   string
+		want   map[string]int
+	}{
+		{
+			name:   "exportify",
+			header: "Track URI,Track Name,Album Name,Artist Name(s),Release Date,Duration (ms),Popularity,ISRC",
+			layout: LayoutExportify,
+			unit:   DurationMillis,
+			want:   map[string]int{FieldID: 0, FieldTitle: 1, FieldAlbum: 2, FieldArtist: 3, FieldDuration: 5, FieldISRC: 7},
+		},
+		{
+			name:   "soundiiz",
+			header: "title,artist,album,isrc",
+			layout: LayoutSoundiiz,
+			unit:   DurationSeconds,
+			want:   map[string]int{FieldTitle: 0, FieldArtist: 1, FieldAlbum: 2, FieldISRC: 3},
+		},
+		{
+			name:   "playport",
+			header: "Title,Artist,Album,Duration,ISRC,ID",
+			layout: LayoutGeneric,
+			unit:   DurationSeconds,
+			want:   map[string]int{FieldTitle: 0, FieldArtist: 1, FieldAlbum: 2, FieldDuration: 3, FieldISRC: 4, FieldID: 5},
+		},
+		{
+			name:   "tunemymusic",
+			header: "Track name,Artist name,Album,Playlist name,Type,ISRC",
+			layout: LayoutGeneric,
+			unit:   DurationSeconds,
+			want:   map[string]int{FieldTitle: 0, FieldArtist: 1, FieldAlbum: 2, FieldISRC: 5},
+		},
+		{
+			name:   "millisecond column",
+			header: "Song,Performer,Length (ms)",
+			layout: LayoutGeneric,
+			unit:   DurationMillis,
+			want:   map[string]int{FieldTitle: 0, FieldArtist: 1, FieldDuration: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, ok := DetectCSVMapping(strings.Split(tt.header, ","))
+			if !ok {
+				t.Fatal("DetectCSVMapping() should recognise the header")
+			}
+			if mapping.Layout != tt.layout {
+				t.Errorf("Expected layout %q, got %q", tt.layout, mapping.Layout)
+			}
+			if mapping.DurationUnit != tt.unit {
+				t.Errorf("Expected duration unit %q, got %q", tt.unit, mapping.DurationUnit)
+			}
+			if len(mapping.Columns) != len(tt.want) {
+				t.Errorf("Expected columns %v, got %v", tt.want, mapping.Columns)
+			}
+			for field, i := range tt.want {
+				if got, ok := mapping.Columns[field]; !ok || got != i {
+					t.Errorf("Expected %s in column %d, got %v", field, i, mapping.Columns)
+				}
+			}
+		})
+	}
+}
+
+func TestReadCSV_Exportify(t *testing.T) {
+	input := utf8BOM + "Track URI,Track Name,Artist Name(s),Album Name,Track Duration (ms),ISRC\n" +
+		"spotify:track:4uLU6hMCjMI75M1A2tKUQC,Never Gonna Give You Up,Rick Astley,Whenever You Need Somebody,213573,gbarl9300135\n"
+
+	playlist, err := ReadCSV(strings.NewReader(input), "Exportify")
+	if err != nil {
+		t.Fatalf("ReadCSV() failed: %v", err)
+	}
+	if len(playlist.Tracks) != 1 {
+		t.Fatalf("Expected 1 track, got %d", len(playlist.Tracks))
+	}
+	track := playlist.Tracks[0]
+	if track.Title != "Never Gonna Give You Up" || track.Artist != "Rick Astley" {
+		t.Errorf("Unexpected track %+v", track)
+	}
+	if track.Duration != 214 {
+		t.Errorf("Expected duration 214s, got %d", track.Duration)
+	}
+	if track.ISRC != "GBARL9300135" {
+		t.Errorf("Expected upper-case ISRC, got %q", track.ISRC)
+	}
+	if track.ID != "spotify:track:4uLU6hMCjMI75M1A2tKUQC" {
+		t.Errorf("Expected the track URI as ID, got %q", track.ID)
+	}
+}
+
+func TestPreviewCSV_UnknownLayout(t *testing.T) {
+	input := "Nr;Künstler;Stück;Dauer\n1;Nena;99 Luftballons;3:53\n;;;\n2;Falco;Rock Me Amadeus;1:03:20\n3;Kraftwerk;Autobahn;22:43\n"
+
+	preview, err := PreviewCSV(strings.NewReader(input), 2)
+	if err != nil {
+		t.Fatalf("PreviewCSV() failed: %v", err)
+	}
+	if preview.Detected {
+		t.Error("PreviewCSV() should not detect an unknown layout")
+	}
+	if len(preview.Header) != 4 || preview.Header[2] != "Stück" {
+		t.Errorf("Expected a semicolon-separated header, got %q", preview.Header)
+	}
+	if len(preview.Rows) != 2 || preview.Rows[1][1] != "Falco" {
+		t.Errorf("Expected the first 2 non-blank rows, got %q", preview.Rows)
+	}
+
+	if _, err := ReadCSV(strings.NewReader(input), "x"); err == nil {
+		t.Error("ReadCSV() should reject files without a recognisable title column")
+	}
+
+	mapping := CSVMapping{Columns: map[string]int{FieldArtist: 1, FieldTitle: 2, FieldDuration: 3}}
+	playlist, err := ReadCSVWithMapping(strings.NewReader(input), "Mapped", mapping)
+	if err != nil {
+		t.Fatalf("ReadCSVWithMapping() failed: %v", err)
+	}
+	if len(playlist.Tracks) != 3 {
+		t.Fatalf("Expected 3 tracks, got %d", len(playlist.Tracks))
+	}
+	if got := playlist.Tracks[1]; got.Title != "Rock Me Amadeus" || got.Artist != "Falco" || got.Duration != 3800 {
+		t.Errorf("Unexpected track %+v", got)
+	}
+	if playlist.Tracks[0].Duration != 233 {
+		t.Errorf("Expected m:ss durations to be parsed, got %d", playlist.Tracks[0].Duration)
+	}
+}
+
+func TestReadCSVWithMapping_Invalid(t *testing.T) {
+	input := "a,b\nx,y\n"
+
+	tests := []struct {
+		name    string
+		mapping CSVMapping
+	}{
+		{"no title", CSVMapping{Columns: map[string]int{FieldArtist: 0}}},
+		{"column out of range", CSVMapping{Columns: map[string]int{FieldTitle: 0, FieldAlbum: 2}}},
+		{"unknown unit", CSVMapping{Columns: map[string]int{FieldTitle: 0}, DurationUnit: "minutes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadCSVWithMapping(strings.NewReader(input), "x", tt.mapping); err == nil {
+				t.Error("ReadCSVWithMapping() should reject the mapping")
+			}
+		})
+	}
+}
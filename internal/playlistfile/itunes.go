@@ -0,0 +1,257 @@
+package playlistfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// libraryTrack is the part of a Library.xml track entry PlayPort uses
+type libraryTrack struct {
+	persistentID string
+	name         string
+	artist       string
+	albumArtist  string
+	album        string
+	totalTimeMS  int64
+	location     string
+}
+
+// ReadITunesLibrary parses a Library.xml property list exported by iTunes
+// or the Apple Music app and returns its user playlists with their tracks.
+// The library itself, built-in lists such as Music or Podcasts, folders
+// and hidden playlists are left out. Playlist and track IDs are the
+// persistent IDs, and tracks stored as files get their path as Location.
+func ReadITunesLibrary(r io.Reader) ([]models.Playlist, error) {
+	dec := xml.NewDecoder(r)
+	root, err := nextPlistElement(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid iTunes library: %w", err)
+	}
+	if root.Name.Local == "plist" {
+		root, err = nextPlistElement(dec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid iTunes library: %w", err)
+		}
+	}
+	if root.Name.Local != "dict" {
+		return nil, fmt.Errorf("invalid iTunes library: expected a dict, got <%s>", root.Name.Local)
+	}
+
+	// Tracks come before Playlists in every export, but resolve the
+	// playlists afterwards so the order does not matter
+	tracks := map[int64]libraryTrack{}
+	var rawPlaylists []interface{}
+	err = decodePlistDict(dec, func(key string, start xml.StartElement) error {
+		switch key {
+		case "Tracks":
+			if start.Name.Local != "dict" {
+				return fmt.Errorf("the Tracks entry is not a dict")
+			}
+			return decodePlistDict(dec, func(_ string, start xml.StartElement) error {
+				value, err := decodePlistValue(dec, start)
+				if err != nil {
+					return err
+				}
+				entry, _ := value.(map[string]interface{})
+				if id, ok := entry["Track ID"].(int64); ok {
+					tracks[id] = libraryTrackFromPlist(entry)
+				}
+				return nil
+			})
+		case "Playlists":
+			value, err := decodePlistValue(dec, start)
+			if err != nil {
+				return err
+			}
+			rawPlaylists, _ = value.([]interface{})
+			return nil
+		default:
+			return dec.Skip()
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid iTunes library: %w", err)
+	}
+
+	playlists := []models.Playlist{}
+	for _, raw := range rawPlaylists {
+		entry, _ := raw.(map[string]interface{})
+		if entry == nil || plistBool(entry, "Master") || plistBool(entry, "Folder") ||
+			entry["Distinguished Kind"] != nil || entry["Visible"] == false {
+			continue
+		}
+
+		playlist := models.Playlist{
+			ID:          plistString(entry, "Playlist Persistent ID"),
+			Name:        plistString(entry, "Name"),
+			Description: plistString(entry, "Description"),
+			Tracks:      []models.Track{},
+		}
+		items, _ := entry["Playlist Items"].([]interface{})
+		for _, item := range items {
+			ref, _ := item.(map[string]interface{})
+			id, _ := ref["Track ID"].(int64)
+			if t, ok := tracks[id]; ok {
+				playlist.Tracks = append(playlist.Tracks, t.toTrack())
+			}
+		}
+		playlist.TrackCount = len(playlist.Tracks)
+		playlists = append(playlists, playlist)
+	}
+
+	return playlists, nil
+}
+
+// libraryTrackFromPlist reads a track entry of the Tracks dict
+func libraryTrackFromPlist(entry map[string]interface{}) libraryTrack {
+	totalTime, _ := entry["Total Time"].(int64)
+	return libraryTrack{
+		persistentID: plistString(entry, "Persistent ID"),
+		name:         plistString(entry, "Name"),
+		artist:       plistString(entry, "Artist"),
+		albumArtist:  plistString(entry, "Album Artist"),
+		album:        plistString(entry, "Album"),
+		totalTimeMS:  totalTime,
+		location:     plistString(entry, "Location"),
+	}
+}
+
+// toTrack converts a library track to the domain model. Tracks without an
+// artist fall back to the album artist.
+func (t libraryTrack) toTrack() models.Track {
+	artist := t.artist
+	if artist == "" {
+		artist = t.albumArtist
+	}
+	return models.Track{
+		ID:       t.persistentID,
+		Title:    t.name,
+		Artist:   artist,
+		Album:    t.album,
+		Duration: int(math.Round(float64(t.totalTimeMS) / 1000)),
+		Location: fileURLPath(t.location),
+	}
+}
+
+// nextPlistElement returns the next start element, skipping the prolog,
+// comments and whitespace
+func nextPlistElement(dec *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return t, nil
+		case xml.EndElement:
+			return xml.StartElement{}, fmt.Errorf("unexpected </%s>", t.Name.Local)
+		}
+	}
+}
+
+// decodePlistDict walks the key/value pairs of a dict whose start element
+// has been read, calling fn with each key and the start of its value. fn
+// must consume the value.
+func decodePlistDict(dec *xml.Decoder, fn func(key string, start xml.StartElement) error) error {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if t.Name.Local != "key" {
+				return fmt.Errorf("expected <key> in dict, got <%s>", t.Name.Local)
+			}
+			var key string
+			if err := dec.DecodeElement(&key, &t); err != nil {
+				return err
+			}
+			start, err := nextPlistElement(dec)
+			if err != nil {
+				return err
+			}
+			if err := fn(key, start); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decodePlistValue decodes the value whose start element has been read.
+// Dicts become maps, arrays slices, integers int64, reals float64 and
+// booleans bool; strings, dates and data stay strings.
+func decodePlistValue(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		m := map[string]interface{}{}
+		err := decodePlistDict(dec, func(key string, start xml.StartElement) error {
+			value, err := decodePlistValue(dec, start)
+			m[key] = value
+			return err
+		})
+		return m, err
+	case "array":
+		a := []interface{}{}
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.EndElement:
+				return a, nil
+			case xml.StartElement:
+				value, err := decodePlistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, value)
+			}
+		}
+	case "true", "false":
+		return start.Name.Local == "true", dec.Skip()
+	}
+
+	var text string
+	if err := dec.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "integer":
+		n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", text)
+		}
+		return n, nil
+	case "real":
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid real %q", text)
+		}
+		return f, nil
+	default:
+		return text, nil
+	}
+}
+
+// plistString returns a string value of a dict, or "" if it is missing
+func plistString(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// plistBool reports whether a boolean value of a dict is true
+func plistBool(m map[string]interface{}, key string) bool {
+	b, _ := m[key].(bool)
+	return b
+}
@@ -0,0 +1,136 @@
+package playlistfile
+
+import (
+	"strings"
+	"testing"
+)
+
+const testLibrary = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Major Version</key><integer>1</integer>
+	<key>Date</key><date>2024-05-01T10:00:00Z</date>
+	<key>Music Folder</key><string>file:///Users/jane/Music/Media/</string>
+	<key>Tracks</key>
+	<dict>
+		<key>101</key>
+		<dict>
+			<key>Track ID</key><integer>101</integer>
+			<key>Name</key><string>Bohemian Rhapsody</string>
+			<key>Artist</key><string>Queen</string>
+			<key>Album</key><string>A Night at the Opera</string>
+			<key>Total Time</key><integer>354320</integer>
+			<key>Persistent ID</key><string>1A2B3C4D5E6F7081</string>
+			<key>Location</key><string>file:///Users/jane/Music/Media/Queen/Bohemian%20Rhapsody.m4a</string>
+			<key>Compilation</key><true/>
+			<key>Rating</key><real>4.5</real>
+		</dict>
+		<key>102</key>
+		<dict>
+			<key>Track ID</key><integer>102</integer>
+			<key>Name</key><string>Kids</string>
+			<key>Album Artist</key><string>MGMT</string>
+			<key>Album</key><string>Oracular Spectacular</string>
+			<key>Total Time</key><integer>302800</integer>
+			<key>Persistent ID</key><string>0F0E0D0C0B0A0908</string>
+			<key>Apple Music</key><true/>
+		</dict>
+	</dict>
+	<key>Playlists</key>
+	<array>
+		<dict>
+			<key>Name</key><string>Library</string>
+			<key>Master</key><true/>
+			<key>Playlist Persistent ID</key><string>AAAA000000000001</string>
+			<key>Playlist Items</key>
+			<array>
+				<dict><key>Track ID</key><integer>101</integer></dict>
+				<dict><key>Track ID</key><integer>102</integer></dict>
+			</array>
+		</dict>
+		<dict>
+			<key>Name</key><string>Music</string>
+			<key>Distinguished Kind</key><integer>4</integer>
+			<key>Playlist Persistent ID</key><string>AAAA000000000002</string>
+		</dict>
+		<dict>
+			<key>Name</key><string>Road Trips</string>
+			<key>Folder</key><true/>
+			<key>Playlist Persistent ID</key><string>AAAA000000000003</string>
+		</dict>
+		<dict>
+			<key>Name</key><string>Summer &amp; Sun</string>
+			<key>Description</key><string>Windows down</string>
+			<key>Playlist Persistent ID</key><string>AAAA000000000004</string>
+			<key>Parent Persistent ID</key><string>AAAA000000000003</string>
+			<key>Playlist Items</key>
+			<array>
+				<dict><key>Track ID</key><integer>102</integer></dict>
+				<dict><key>Track ID</key><integer>999</integer></dict>
+				<dict><key>Track ID</key><integer>101</integer></dict>
+			</array>
+		</dict>
+		<dict>
+			<key>Name</key><string>Empty</string>
+			<key>Playlist Persistent ID</key><string>AAAA000000000005</string>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+func TestReadITunesLibrary(t *testing.T) {
+	playlists, err := ReadITunesLibrary(strings.NewReader(testLibrary))
+	if err != nil {
+		t.Fatalf("ReadITunesLibrary() failed: %v", err)
+	}
+	if len(playlists) != 2 {
+		t.Fatalf("Expected 2 user playlists, got %d: %+v", len(playlists), playlists)
+	}
+
+	summer := playlists[0]
+	if summer.ID != "AAAA000000000004" || summer.Name != "Summer & Sun" || summer.Description != "Windows down" {
+		t.Errorf("Unexpected playlist %+v", summer)
+	}
+	if summer.TrackCount != 2 || len(summer.Tracks) != 2 {
+		t.Fatalf("Expected 2 tracks (unknown IDs skipped), got %d", len(summer.Tracks))
+	}
+
+	kids := summer.Tracks[0]
+	if kids.ID != "0F0E0D0C0B0A0908" || kids.Title != "Kids" || kids.Album != "Oracular Spectacular" {
+		t.Errorf("Unexpected track %+v", kids)
+	}
+	if kids.Artist != "MGMT" {
+		t.Errorf("Expected the album artist as fallback, got %q", kids.Artist)
+	}
+	if kids.Duration != 303 || kids.Location != "" {
+		t.Errorf("Unexpected duration or location in %+v", kids)
+	}
+
+	queen := summer.Tracks[1]
+	if queen.Artist != "Queen" || queen.Duration != 354 {
+		t.Errorf("Unexpected track %+v", queen)
+	}
+	if queen.Location != "/Users/jane/Music/Media/Queen/Bohemian Rhapsody.m4a" {
+		t.Errorf("Expected a decoded file path, got %q", queen.Location)
+	}
+
+	if playlists[1].Name != "Empty" || playlists[1].TrackCount != 0 {
+		t.Errorf("Expected the empty playlist to be kept, got %+v", playlists[1])
+	}
+}
+
+func TestReadITunesLibrary_Invalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"<plist><array></array></plist>",
+		"<plist><dict><key>Tracks</key><dict><key>1</key><dict><key>Track ID</key><integer>x</integer></dict></dict></dict></plist>",
+		"<plist><dict><key>Tracks</key>",
+	}
+	for _, input := range inputs {
+		if _, err := ReadITunesLibrary(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadITunesLibrary(%q) should fail", input)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package playlistfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// FormatM3U and FormatM3U8 are extended M3U. Files are read as UTF-8 (falling back to
+// Latin-1 for legacy .m3u files) and always written as UTF-8, which makes
+// the output valid M3U8 as well.
+const (
+	FormatM3U  = "m3u"
+	FormatM3U8 = "m3u8"
+)
+
+// utf8BOM is the byte order mark some editors put at the start of M3U8 files
+const utf8BOM = "\ufeff"
+
+// ReadM3U decodes a plain or extended M3U playlist. Each entry's path or URL
+// is kept as written in Track.Location; the caller resolves relative paths.
+// The playlist is named by a #PLAYLIST directive, or name if there is none.
+func ReadM3U(r io.Reader, name string) (models.Playlist, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to read M3U playlist: %w", err)
+	}
+	text := strings.TrimPrefix(decodeText(data), utf8BOM)
+
+	playlist := models.Playlist{Name: name, Tracks: []models.Track{}}
+	var pending models.Track // metadata of the next entry, from directives
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			pending.Duration, pending.Artist, pending.Title = parseExtinf(strings.TrimPrefix(line, "#EXTINF:"))
+		case strings.HasPrefix(line, "#EXTALB:"):
+			pending.Album = strings.TrimSpace(strings.TrimPrefix(line, "#EXTALB:"))
+		case strings.HasPrefix(line, "#EXTART:"):
+			pending.Artist = strings.TrimSpace(strings.TrimPrefix(line, "#EXTART:"))
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			playlist.Name = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#"):
+			continue // #EXTM3U and directives we don't use
+		default:
+			track := pending
+			track.Location = line
+			track.ID = line
+			if track.Title == "" {
+				track.Artist, track.Title = titleFromLocation(line, track.Artist)
+			}
+			playlist.Tracks = append(playlist.Tracks, track)
+			pending = models.Track{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to read M3U playlist: %w", err)
+	}
+
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// WriteM3U encodes a playlist as extended M3U in UTF-8. Tracks without a
+// Location are written as "Artist - Title" placeholders, which players cannot
+// open but which tools that match by name can still resolve.
+func WriteM3U(w io.Writer, playlist models.Playlist) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	if playlist.Name != "" {
+		fmt.Fprintf(bw, "#PLAYLIST:%s\n", oneLine(playlist.Name))
+	}
+
+	for _, t := range playlist.Tracks {
+		duration := -1
+		if t.Duration > 0 {
+			duration = t.Duration
+		}
+		display := oneLine(t.Title)
+		if t.Artist != "" {
+			display = oneLine(t.Artist) + " - " + display
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n", duration, display)
+		if t.Album != "" {
+			fmt.Fprintf(bw, "#EXTALB:%s\n", oneLine(t.Album))
+		}
+
+		location := oneLine(t.Location)
+		if location == "" {
+			location = display
+		}
+		fmt.Fprintln(bw, location)
+	}
+
+	return bw.Flush()
+}
+
+// parseExtinf splits the value of an #EXTINF directive, e.g.
+// `215 tvg-name="x",Artist - Title`, into duration, artist and title
+func parseExtinf(value string) (int, string, string) {
+	// The display title starts after the first comma outside quoted attributes
+	inQuotes := false
+	split := -1
+	for i, r := range value {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ',' && !inQuotes {
+			split = i
+			break
+		}
+	}
+
+	info, display := value, ""
+	if split >= 0 {
+		info, display = value[:split], strings.TrimSpace(value[split+1:])
+	}
+
+	duration := 0
+	if fields := strings.Fields(info); len(fields) > 0 {
+		if d, err := strconv.ParseFloat(fields[0], 64); err == nil && d > 0 {
+			duration = int(d + 0.5)
+		}
+	}
+
+	artist, title, ok := strings.Cut(display, " - ")
+	if !ok {
+		return duration, "", display
+	}
+	return duration, strings.TrimSpace(artist), strings.TrimSpace(title)
+}
+
+// titleFromLocation derives artist and title from a file name such as
+// "Artist - Title.mp3", for entries without #EXTINF
+func titleFromLocation(location, artist string) (string, string) {
+	base := path.Base(filepath.ToSlash(location))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	if a, t, ok := strings.Cut(base, " - "); ok && artist == "" {
+		return strings.TrimSpace(a), strings.TrimSpace(t)
+	}
+	return artist, base
+}
+
+// decodeText returns data as a string, treating it as Latin-1 if it is not valid UTF-8
+func decodeText(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// oneLine replaces line breaks, which would end an M3U entry early
+func oneLine(s string) string {
+	return strings.TrimSpace(strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s))
+}
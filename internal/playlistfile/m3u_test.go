@@ -0,0 +1,89 @@
+package playlistfile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func TestReadM3U_Extended(t *testing.T) {
+	input := "\ufeff#EXTM3U\r\n" +
+		"#PLAYLIST:Café Classics\r\n" +
+		"#EXTINF:215 tvg-name=\"a,b\",Björk - Jóga\r\n" +
+		"#EXTALB:Homogenic\r\n" +
+		"music/Björk/Jóga.flac\r\n" +
+		"\r\n" +
+		"#EXTINF:-1,Untitled stream\r\n" +
+		"https://radio.example/stream\r\n" +
+		"/srv/music/Massive Attack - Teardrop.mp3\r\n"
+
+	playlist, err := ReadM3U(strings.NewReader(input), "fallback")
+	if err != nil {
+		t.Fatalf("ReadM3U() failed: %v", err)
+	}
+
+	if playlist.Name != "Café Classics" {
+		t.Errorf("Expected name from #PLAYLIST, got %q", playlist.Name)
+	}
+	want := []models.Track{
+		{ID: "music/Björk/Jóga.flac", Location: "music/Björk/Jóga.flac", Title: "Jóga", Artist: "Björk", Album: "Homogenic", Duration: 215},
+		{ID: "https://radio.example/stream", Location: "https://radio.example/stream", Title: "Untitled stream"},
+		{ID: "/srv/music/Massive Attack - Teardrop.mp3", Location: "/srv/music/Massive Attack - Teardrop.mp3", Title: "Teardrop", Artist: "Massive Attack"},
+	}
+	if !reflect.DeepEqual(playlist.Tracks, want) {
+		t.Errorf("Unexpected tracks:\ngot  %+v\nwant %+v", playlist.Tracks, want)
+	}
+}
+
+func TestReadM3U_Latin1(t *testing.T) {
+	// "Motörhead - Ace" in ISO-8859-1, as written by older players
+	input := []byte("#EXTINF:169,Mot\xf6rhead - Ace\nace.mp3\n")
+
+	playlist, err := ReadM3U(bytes.NewReader(input), "Legacy")
+	if err != nil {
+		t.Fatalf("ReadM3U() failed: %v", err)
+	}
+	if playlist.Name != "Legacy" || len(playlist.Tracks) != 1 || playlist.Tracks[0].Artist != "Motörhead" {
+		t.Errorf("Expected Latin-1 to be decoded, got %+v", playlist)
+	}
+}
+
+func TestM3U_RoundTrip(t *testing.T) {
+	want := models.Playlist{
+		Name: "Mix",
+		Tracks: []models.Track{
+			{ID: "a.mp3", Location: "a.mp3", Title: "Señorita", Artist: "Ana", Album: "Uno", Duration: 200},
+			{ID: "/abs/b.ogg", Location: "/abs/b.ogg", Title: "B", Duration: 0},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatM3U8, want); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "#EXTM3U\n#PLAYLIST:Mix\n#EXTINF:200,Ana - Señorita\n#EXTALB:Uno\na.mp3\n") {
+		t.Errorf("Unexpected M3U output:\n%s", buf.String())
+	}
+
+	got, err := Read(&buf, FormatM3U8, "")
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if got.Name != want.Name || !reflect.DeepEqual(got.Tracks, want.Tracks) {
+		t.Errorf("Playlist changed in round trip:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestWriteM3U_TracksWithoutLocation(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteM3U(&buf, models.Playlist{Tracks: []models.Track{{ID: "spotify-id", Title: "Line\nBreak", Artist: "X"}}})
+	if err != nil {
+		t.Fatalf("WriteM3U() failed: %v", err)
+	}
+	if buf.String() != "#EXTM3U\n#EXTINF:-1,X - Line Break\nX - Line Break\n" {
+		t.Errorf("Unexpected M3U output:\n%q", buf.String())
+	}
+}
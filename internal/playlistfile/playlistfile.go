@@ -0,0 +1,161 @@
+// Package playlistfile reads and writes playlists as files, so they can be
+// exported from one place and imported somewhere else.
+package playlistfile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// Supported file formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// FileType describes a playlist format offered for download and upload
+type FileType struct {
+	Format      string // format name accepted by Read and Write
+	Label       string // human-readable name
+	Extension   string // file extension, including the dot
+	ContentType string // MIME type of downloads
+}
+
+// FileTypes lists the formats playlists can be downloaded and uploaded as
+var FileTypes = []FileType{
+	{Format: FormatJSON, Label: "JSON", Extension: ".json", ContentType: "application/json"},
+	{Format: FormatCSV, Label: "CSV", Extension: ".csv", ContentType: "text/csv; charset=utf-8"},
+	{Format: FormatM3U8, Label: "M3U8", Extension: ".m3u8", ContentType: "audio/x-mpegurl; charset=utf-8"},
+	{Format: FormatXSPF, Label: "XSPF", Extension: ".xspf", ContentType: "application/xspf+xml"},
+	{Format: FormatJSPF, Label: "JSPF", Extension: ".jspf", ContentType: "application/json"},
+	{Format: FormatRekordbox, Label: "Rekordbox XML", Extension: ".xml", ContentType: "application/xml"},
+	{Format: FormatTraktor, Label: "Traktor NML", Extension: ".nml", ContentType: "application/xml"},
+}
+
+// LookupFileType returns the file type of a format name
+func LookupFileType(format string) (FileType, bool) {
+	for _, ft := range FileTypes {
+		if ft.Format == format {
+			return ft, true
+		}
+	}
+	return FileType{}, false
+}
+
+// FormatFromFileName guesses the format of a playlist file from its extension
+func FormatFromFileName(name string) (string, bool) {
+	ext := strings.ToLower(path.Ext(name))
+	if ext == ".m3u" {
+		return FormatM3U, true
+	}
+	for _, ft := range FileTypes {
+		if ft.Extension == ext {
+			return ft.Format, true
+		}
+	}
+	return "", false
+}
+
+// csvHeader is the column layout written by WriteCSV
+var csvHeader = []string{"Title", "Artist", "Album", "Duration", "ISRC", "ID"}
+
+// Write encodes a playlist in the given format
+func Write(w io.Writer, format string, playlist models.Playlist) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, playlist)
+	case FormatCSV:
+		return WriteCSV(w, playlist)
+	case FormatM3U, FormatM3U8:
+		return WriteM3U(w, playlist)
+	case FormatXSPF:
+		return WriteXSPF(w, playlist)
+	case FormatJSPF:
+		return WriteJSPF(w, playlist)
+	case FormatRekordbox:
+		return WriteRekordbox(w, []models.Playlist{playlist})
+	case FormatTraktor:
+		return WriteTraktor(w, []models.Playlist{playlist})
+	default:
+		return fmt.Errorf("unsupported playlist format: %s", format)
+	}
+}
+
+// Read decodes a playlist in the given format. Formats that carry no
+// playlist name, such as CSV, or files without one return a playlist named name.
+// M3U tracks keep their paths as written; see ReadM3U. Rekordbox and
+// Traktor files must hold a single playlist; see ReadCollection for more.
+func Read(r io.Reader, format, name string) (models.Playlist, error) {
+	switch format {
+	case FormatJSON:
+		return ReadJSON(r)
+	case FormatCSV:
+		return ReadCSV(r, name)
+	case FormatM3U, FormatM3U8:
+		return ReadM3U(r, name)
+	case FormatXSPF:
+		return ReadXSPF(r, name)
+	case FormatJSPF:
+		return ReadJSPF(r, name)
+	case FormatRekordbox, FormatTraktor:
+		return readCollectionPlaylist(r, name)
+	default:
+		return models.Playlist{}, fmt.Errorf("unsupported playlist format: %s", format)
+	}
+}
+
+// WriteJSON encodes a playlist, including its tracks, as indented JSON
+func WriteJSON(w io.Writer, playlist models.Playlist) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(playlist)
+}
+
+// ReadJSON decodes a playlist written by WriteJSON
+func ReadJSON(r io.Reader) (models.Playlist, error) {
+	var playlist models.Playlist
+	if err := json.NewDecoder(r).Decode(&playlist); err != nil {
+		return models.Playlist{}, fmt.Errorf("invalid JSON playlist: %w", err)
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// WriteCSV encodes a playlist's tracks as CSV with a header row.
+// Durations are written in seconds.
+func WriteCSV(w io.Writer, playlist models.Playlist) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, t := range playlist.Tracks {
+		record := []string{t.Title, t.Artist, t.Album, strconv.Itoa(t.Duration), t.ISRC, t.ID}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV decodes tracks from CSV with a header row. The columns are
+// detected by DetectCSVMapping; files it does not recognise need an
+// explicit mapping, see ReadCSVWithMapping.
+func ReadCSV(r io.Reader, name string) (models.Playlist, error) {
+	records, err := readCSVRecords(r)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	mapping, ok := DetectCSVMapping(records[0])
+	if !ok {
+		return models.Playlist{}, fmt.Errorf("invalid CSV playlist: no title column among %q", records[0])
+	}
+	return decodeCSVRecords(records, name, mapping)
+}
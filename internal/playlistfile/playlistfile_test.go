@@ -0,0 +1,73 @@
+package playlistfile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func testPlaylist() models.Playlist {
+	return models.Playlist{
+		Name: "Road Trip",
+		Tracks: []models.Track{
+			{ID: "t1", Title: "Highway, Pt. 1", Artist: "The \"Drivers\"", Album: "Miles", Duration: 215, ISRC: "USABC1234567"},
+			{ID: "t2", Title: "Exit", Artist: "Ramp", Duration: 180},
+		},
+		TrackCount: 2,
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			want := testPlaylist()
+
+			var buf bytes.Buffer
+			if err := Write(&buf, format, want); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			got, err := Read(&buf, format, want.Name)
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+
+			if got.Name != want.Name || got.TrackCount != 2 {
+				t.Errorf("Expected playlist %q with 2 tracks, got %q with %d", want.Name, got.Name, got.TrackCount)
+			}
+			if !reflect.DeepEqual(got.Tracks, want.Tracks) {
+				t.Errorf("Tracks changed in round trip:\ngot  %+v\nwant %+v", got.Tracks, want.Tracks)
+			}
+		})
+	}
+}
+
+func TestReadCSV_Columns(t *testing.T) {
+	input := "artist,TITLE,Notes\nAdele,Hello,live\n,,\nQueen,Bohemian Rhapsody\n"
+
+	playlist, err := ReadCSV(strings.NewReader(input), "Imported")
+	if err != nil {
+		t.Fatalf("ReadCSV() failed: %v", err)
+	}
+	if len(playlist.Tracks) != 2 {
+		t.Fatalf("Expected 2 tracks (blank rows skipped), got %d", len(playlist.Tracks))
+	}
+	if playlist.Tracks[1].Title != "Bohemian Rhapsody" || playlist.Tracks[1].Artist != "Queen" {
+		t.Errorf("Unexpected track: %+v", playlist.Tracks[1])
+	}
+
+	if _, err := ReadCSV(strings.NewReader("Artist\nAdele\n"), "x"); err == nil {
+		t.Error("ReadCSV() should require a Title column")
+	}
+	if _, err := ReadCSV(strings.NewReader("Title,Duration\nHello,long\n"), "x"); err == nil {
+		t.Error("ReadCSV() should reject invalid durations")
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "xml", testPlaylist()); err == nil {
+		t.Error("Write() should reject unknown formats")
+	}
+}
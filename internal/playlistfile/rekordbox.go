@@ -0,0 +1,219 @@
+package playlistfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// FormatRekordbox is the collection XML read and written by Pioneer
+// Rekordbox (File > Export Collection in xml format)
+const FormatRekordbox = "rekordbox"
+
+// rekordboxRoot is the root element of a Rekordbox collection
+const rekordboxRoot = "DJ_PLAYLISTS"
+
+// Rekordbox playlist tree node types
+const (
+	rekordboxFolder   = "0"
+	rekordboxPlaylist = "1"
+)
+
+// Rekordbox playlist key types: entries refer to tracks by TrackID or by
+// Location
+const (
+	rekordboxKeyByID       = "0"
+	rekordboxKeyByLocation = "1"
+)
+
+// rekordboxDocument is the root of a Rekordbox collection
+type rekordboxDocument struct {
+	XMLName    xml.Name           `xml:"DJ_PLAYLISTS"`
+	Version    string             `xml:"Version,attr"`
+	Product    rekordboxProduct   `xml:"PRODUCT"`
+	Collection rekordboxTrackList `xml:"COLLECTION"`
+	Playlists  rekordboxNode      `xml:"PLAYLISTS>NODE"`
+}
+
+// rekordboxProduct names the program that wrote a collection
+type rekordboxProduct struct {
+	Name    string `xml:"Name,attr"`
+	Version string `xml:"Version,attr,omitempty"`
+	Company string `xml:"Company,attr,omitempty"`
+}
+
+// rekordboxTrackList is the COLLECTION element listing every track
+type rekordboxTrackList struct {
+	Entries int              `xml:"Entries,attr"`
+	Tracks  []rekordboxTrack `xml:"TRACK"`
+}
+
+// rekordboxTrack is a collection track. TotalTime is in seconds and
+// Location a file://localhost/ URL.
+type rekordboxTrack struct {
+	TrackID    string `xml:"TrackID,attr"`
+	Name       string `xml:"Name,attr"`
+	Artist     string `xml:"Artist,attr"`
+	Album      string `xml:"Album,attr"`
+	TotalTime  string `xml:"TotalTime,attr,omitempty"`
+	AverageBpm string `xml:"AverageBpm,attr,omitempty"`
+	Tonality   string `xml:"Tonality,attr,omitempty"`
+	Location   string `xml:"Location,attr,omitempty"`
+}
+
+// rekordboxNode is a folder or playlist of the playlist tree. Folders
+// count their children, playlists their entries.
+type rekordboxNode struct {
+	Type    string              `xml:"Type,attr"`
+	Name    string              `xml:"Name,attr"`
+	Count   string              `xml:"Count,attr,omitempty"`
+	KeyType string              `xml:"KeyType,attr,omitempty"`
+	Entries string              `xml:"Entries,attr,omitempty"`
+	Nodes   []rekordboxNode     `xml:"NODE"`
+	Tracks  []rekordboxTrackRef `xml:"TRACK"`
+}
+
+// rekordboxTrackRef is a playlist entry, holding a TrackID or a Location
+// depending on the playlist's KeyType
+type rekordboxTrackRef struct {
+	Key string `xml:"Key,attr"`
+}
+
+// WriteRekordbox encodes playlists as a Rekordbox collection. Every track
+// is listed once in the collection; tracks without a Location are kept
+// and show up in Rekordbox as missing files.
+func WriteRekordbox(w io.Writer, playlists []models.Playlist) error {
+	tracks, number := collectionTracks(playlists)
+
+	doc := rekordboxDocument{
+		Version:    "1.0.0",
+		Product:    rekordboxProduct{Name: "PlayPort", Company: "PlayPort"},
+		Collection: rekordboxTrackList{Entries: len(tracks), Tracks: make([]rekordboxTrack, 0, len(tracks))},
+		Playlists: rekordboxNode{
+			Type:  rekordboxFolder,
+			Name:  "ROOT",
+			Count: strconv.Itoa(len(playlists)),
+		},
+	}
+	for i, t := range tracks {
+		rt := rekordboxTrack{
+			TrackID:    strconv.Itoa(i + 1),
+			Name:       t.Title,
+			Artist:     t.Artist,
+			Album:      t.Album,
+			AverageBpm: formatBPM(t.BPM),
+			Tonality:   t.Key,
+		}
+		if t.Duration > 0 {
+			rt.TotalTime = strconv.Itoa(t.Duration)
+		}
+		if t.Location != "" {
+			rt.Location = pathFileURL(t.Location)
+		}
+		doc.Collection.Tracks = append(doc.Collection.Tracks, rt)
+	}
+	for _, p := range playlists {
+		node := rekordboxNode{
+			Type:    rekordboxPlaylist,
+			Name:    p.Name,
+			KeyType: rekordboxKeyByID,
+			Entries: strconv.Itoa(len(p.Tracks)),
+			Tracks:  make([]rekordboxTrackRef, 0, len(p.Tracks)),
+		}
+		for _, t := range p.Tracks {
+			node.Tracks = append(node.Tracks, rekordboxTrackRef{Key: strconv.Itoa(number(t))})
+		}
+		doc.Playlists.Nodes = append(doc.Playlists.Nodes, node)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ReadRekordbox decodes a Rekordbox collection and returns its playlists,
+// including those in folders, whose names are prefixed with the folder
+// path ("Sets / Friday"). Tracks get their file path as Location and ID.
+func ReadRekordbox(r io.Reader) ([]models.Playlist, error) {
+	var doc rekordboxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid Rekordbox collection: %w", err)
+	}
+
+	byID := make(map[string]models.Track, len(doc.Collection.Tracks))
+	byLocation := make(map[string]models.Track, len(doc.Collection.Tracks))
+	for _, rt := range doc.Collection.Tracks {
+		t := rt.toTrack()
+		byID[rt.TrackID] = t
+		if rt.Location != "" {
+			byLocation[rt.Location] = t
+		}
+	}
+
+	playlists := []models.Playlist{}
+	var walk func(node rekordboxNode, folder string)
+	walk = func(node rekordboxNode, folder string) {
+		for _, child := range node.Nodes {
+			name := joinFolder(folder, child.Name)
+			if child.Type == rekordboxFolder {
+				walk(child, name)
+				continue
+			}
+
+			tracks := byID
+			if child.KeyType == rekordboxKeyByLocation {
+				tracks = byLocation
+			}
+			playlist := models.Playlist{ID: name, Name: name, Tracks: []models.Track{}}
+			for _, ref := range child.Tracks {
+				if t, ok := tracks[ref.Key]; ok {
+					playlist.Tracks = append(playlist.Tracks, t)
+				}
+			}
+			playlist.TrackCount = len(playlist.Tracks)
+			playlists = append(playlists, playlist)
+		}
+	}
+	walk(doc.Playlists, "")
+
+	uniquePlaylistIDs(playlists)
+	return playlists, nil
+}
+
+// toTrack converts a collection track to the domain model
+func (rt rekordboxTrack) toTrack() models.Track {
+	duration, _ := strconv.Atoi(strings.TrimSpace(rt.TotalTime))
+	t := models.Track{
+		ID:       rt.TrackID,
+		Title:    strings.TrimSpace(rt.Name),
+		Artist:   strings.TrimSpace(rt.Artist),
+		Album:    strings.TrimSpace(rt.Album),
+		Duration: duration,
+		BPM:      parseBPM(rt.AverageBpm),
+		Key:      strings.TrimSpace(rt.Tonality),
+	}
+	if rt.Location != "" {
+		t.Location = fileURLPath(rt.Location)
+		t.ID = t.Location
+	}
+	return t
+}
+
+// joinFolder prefixes a playlist name with the folders it is in
+func joinFolder(folder, name string) string {
+	if folder == "" {
+		return name
+	}
+	return folder + " / " + name
+}
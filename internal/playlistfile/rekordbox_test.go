@@ -0,0 +1,110 @@
+package playlistfile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+const testRekordbox = `<?xml version="1.0" encoding="UTF-8"?>
+<DJ_PLAYLISTS Version="1.0.0">
+  <PRODUCT Name="rekordbox" Version="6.8.4" Company="AlphaTheta"/>
+  <COLLECTION Entries="3">
+    <TRACK TrackID="11" Name="Strings of Life" Artist="Rhythim Is Rhythim" Album="Strings of Life" Kind="MP3 File" TotalTime="452" AverageBpm="120.40" Tonality="Bbm" Location="file://localhost/Users/jane/Music/Strings%20of%20Life.mp3">
+      <TEMPO Inizio="0.025" Bpm="120.40" Metro="4/4" Battito="1"/>
+    </TRACK>
+    <TRACK TrackID="12" Name="Windowlicker" Artist="Aphex Twin" Album="Windowlicker" TotalTime="367" AverageBpm="0.00" Location="file://localhost/C:/Music/Windowlicker.flac"/>
+    <TRACK TrackID="13" Name="Unlisted" Artist="Nobody" TotalTime="60"/>
+  </COLLECTION>
+  <PLAYLISTS>
+    <NODE Type="0" Name="ROOT" Count="2">
+      <NODE Type="1" Name="Warm Up" KeyType="0" Entries="3">
+        <TRACK Key="12"/>
+        <TRACK Key="11"/>
+        <TRACK Key="99"/>
+      </NODE>
+      <NODE Type="0" Name="Sets" Count="1">
+        <NODE Type="1" Name="Friday" KeyType="1" Entries="1">
+          <TRACK Key="file://localhost/Users/jane/Music/Strings%20of%20Life.mp3"/>
+        </NODE>
+      </NODE>
+    </NODE>
+  </PLAYLISTS>
+</DJ_PLAYLISTS>
+`
+
+func TestReadRekordbox(t *testing.T) {
+	playlists, err := ReadRekordbox(strings.NewReader(testRekordbox))
+	if err != nil {
+		t.Fatalf("ReadRekordbox() failed: %v", err)
+	}
+
+	if len(playlists) != 2 {
+		t.Fatalf("Expected 2 playlists, got %d", len(playlists))
+	}
+	warmUp, friday := playlists[0], playlists[1]
+	if warmUp.Name != "Warm Up" || warmUp.TrackCount != 2 {
+		t.Errorf("Expected Warm Up with 2 tracks, got %q with %d", warmUp.Name, warmUp.TrackCount)
+	}
+	if friday.Name != "Sets / Friday" || friday.ID != "Sets / Friday" {
+		t.Errorf("Expected the folder path in the name, got %q (%q)", friday.Name, friday.ID)
+	}
+
+	want := models.Track{
+		ID:       "/Users/jane/Music/Strings of Life.mp3",
+		Location: "/Users/jane/Music/Strings of Life.mp3",
+		Title:    "Strings of Life",
+		Artist:   "Rhythim Is Rhythim",
+		Album:    "Strings of Life",
+		Duration: 452,
+		BPM:      120.4,
+		Key:      "Bbm",
+	}
+	if !reflect.DeepEqual(warmUp.Tracks[1], want) {
+		t.Errorf("Expected %+v, got %+v", want, warmUp.Tracks[1])
+	}
+	if friday.TrackCount != 1 || !reflect.DeepEqual(friday.Tracks[0], want) {
+		t.Errorf("Expected Friday to find its track by location, got %+v", friday.Tracks)
+	}
+
+	aphex := warmUp.Tracks[0]
+	if aphex.Location != "C:/Music/Windowlicker.flac" || aphex.BPM != 0 {
+		t.Errorf("Expected a Windows path and no BPM, got %q / %v", aphex.Location, aphex.BPM)
+	}
+}
+
+func TestRekordbox_RoundTrip(t *testing.T) {
+	shared := models.Track{ID: "/music/shared.mp3", Location: "/music/shared.mp3", Title: "Shared", Artist: "Both", Album: "Mix", Duration: 300, BPM: 124, Key: "F#m"}
+	streamed := models.Track{ID: "spotify:track:1", Title: "Streamed & <Quoted>", Artist: "Online", Duration: 200}
+	want := []models.Playlist{
+		{ID: "Peak Time", Name: "Peak Time", Tracks: []models.Track{shared, streamed}, TrackCount: 2},
+		{ID: "Closing", Name: "Closing", Tracks: []models.Track{shared}, TrackCount: 1},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRekordbox(&buf, want); err != nil {
+		t.Fatalf("WriteRekordbox() failed: %v", err)
+	}
+	if n := strings.Count(buf.String(), `Name="Shared"`); n != 1 {
+		t.Errorf("Expected shared tracks to be listed once in the collection, got %d", n)
+	}
+
+	got, err := ReadRekordbox(&buf)
+	if err != nil {
+		t.Fatalf("ReadRekordbox() failed: %v", err)
+	}
+	// Tracks without a file are numbered in the collection
+	want[0].Tracks[1].ID = "2"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Playlists changed in round trip:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReadRekordbox_Invalid(t *testing.T) {
+	if _, err := ReadRekordbox(strings.NewReader("<NML></NML>")); err == nil {
+		t.Error("ReadRekordbox() should reject documents that are not Rekordbox collections")
+	}
+}
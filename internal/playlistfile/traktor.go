@@ -0,0 +1,359 @@
+package playlistfile
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// FormatTraktor is the NML collection format of Native Instruments Traktor
+const FormatTraktor = "nml"
+
+// traktorRoot is the root element of an NML file
+const traktorRoot = "NML"
+
+// Traktor playlist tree node types
+const (
+	traktorFolderNode   = "FOLDER"
+	traktorPlaylistNode = "PLAYLIST"
+)
+
+// traktorDirSep separates the folders of a Traktor LOCATION DIR, as in
+// "/:Users/:jane/:Music/:"
+const traktorDirSep = "/:"
+
+// traktorKeys names Traktor's MUSICAL_KEY values: 0–11 are C major to
+// B major, 12–23 C minor to B minor
+var traktorKeys = [24]string{
+	"C", "Db", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B",
+	"Cm", "C#m", "Dm", "Ebm", "Em", "Fm", "F#m", "Gm", "G#m", "Am", "Bbm", "Bm",
+}
+
+// pitchClasses maps note names, sharp or flat, to semitones above C
+var pitchClasses = map[string]int{
+	"C": 0, "C#": 1, "Db": 1, "D": 2, "D#": 3, "Eb": 3, "E": 4, "F": 5,
+	"F#": 6, "Gb": 6, "G": 7, "G#": 8, "Ab": 8, "A": 9, "A#": 10, "Bb": 10, "B": 11,
+}
+
+// traktorDocument is the root of an NML file
+type traktorDocument struct {
+	XMLName    xml.Name          `xml:"NML"`
+	Version    string            `xml:"VERSION,attr"`
+	Head       traktorHead       `xml:"HEAD"`
+	Collection traktorCollection `xml:"COLLECTION"`
+	Playlists  traktorNode       `xml:"PLAYLISTS>NODE"`
+}
+
+// traktorHead names the program that wrote an NML file
+type traktorHead struct {
+	Company string `xml:"COMPANY,attr"`
+	Program string `xml:"PROGRAM,attr"`
+}
+
+// traktorCollection lists every track of an NML file
+type traktorCollection struct {
+	Count   int            `xml:"ENTRIES,attr"`
+	Entries []traktorEntry `xml:"ENTRY"`
+}
+
+// traktorEntry is a collection track
+type traktorEntry struct {
+	Title      string           `xml:"TITLE,attr,omitempty"`
+	Artist     string           `xml:"ARTIST,attr,omitempty"`
+	Location   *traktorLocation `xml:"LOCATION"`
+	Album      *traktorAlbum    `xml:"ALBUM"`
+	Info       *traktorInfo     `xml:"INFO"`
+	Tempo      *traktorTempo    `xml:"TEMPO"`
+	MusicalKey *traktorKey      `xml:"MUSICAL_KEY"`
+}
+
+// traktorLocation is the file of a track, split into volume, folders and
+// file name
+type traktorLocation struct {
+	Dir    string `xml:"DIR,attr"`
+	File   string `xml:"FILE,attr"`
+	Volume string `xml:"VOLUME,attr"`
+}
+
+// traktorAlbum is the album of a track
+type traktorAlbum struct {
+	Title string `xml:"TITLE,attr,omitempty"`
+}
+
+// traktorInfo holds a track's length in seconds and its key as displayed
+type traktorInfo struct {
+	Key      string `xml:"KEY,attr,omitempty"`
+	Playtime string `xml:"PLAYTIME,attr,omitempty"`
+}
+
+// traktorTempo holds a track's BPM
+type traktorTempo struct {
+	BPM string `xml:"BPM,attr"`
+}
+
+// traktorKey holds a track's key as a number, see traktorKeys
+type traktorKey struct {
+	Value int `xml:"VALUE,attr"`
+}
+
+// traktorNode is a folder or playlist of the playlist tree
+type traktorNode struct {
+	Type     string           `xml:"TYPE,attr"`
+	Name     string           `xml:"NAME,attr"`
+	Subnodes *traktorSubnodes `xml:"SUBNODES"`
+	Playlist *traktorPlaylist `xml:"PLAYLIST"`
+}
+
+// traktorSubnodes are the children of a folder
+type traktorSubnodes struct {
+	Count int           `xml:"COUNT,attr"`
+	Nodes []traktorNode `xml:"NODE"`
+}
+
+// traktorPlaylist lists the entries of a playlist
+type traktorPlaylist struct {
+	Count   int                    `xml:"ENTRIES,attr"`
+	Type    string                 `xml:"TYPE,attr"`
+	UUID    string                 `xml:"UUID,attr,omitempty"`
+	Entries []traktorPlaylistEntry `xml:"ENTRY"`
+}
+
+// traktorPlaylistEntry refers to a collection track by its primary key,
+// the track's volume, folders and file name run together
+type traktorPlaylistEntry struct {
+	PrimaryKey traktorPrimaryKey `xml:"PRIMARYKEY"`
+}
+
+// traktorPrimaryKey is the key of a playlist entry
+type traktorPrimaryKey struct {
+	Type string `xml:"TYPE,attr"`
+	Key  string `xml:"KEY,attr"`
+}
+
+// WriteTraktor encodes playlists as a Traktor NML collection. Traktor
+// finds tracks by their files, so tracks without a Location are left out.
+// Paths on Windows drives keep the drive as the volume; other paths are
+// written without one, which Traktor resolves on the system volume.
+func WriteTraktor(w io.Writer, playlists []models.Playlist) error {
+	local := make([]models.Playlist, len(playlists))
+	for i, p := range playlists {
+		local[i] = p
+		local[i].Tracks = nil
+		for _, t := range p.Tracks {
+			if t.Location != "" && !strings.Contains(t.Location, "://") {
+				local[i].Tracks = append(local[i].Tracks, t)
+			}
+		}
+	}
+	tracks, _ := collectionTracks(local)
+
+	doc := traktorDocument{
+		Version:    "19",
+		Head:       traktorHead{Company: "www.native-instruments.com", Program: "Traktor"},
+		Collection: traktorCollection{Count: len(tracks), Entries: make([]traktorEntry, 0, len(tracks))},
+		Playlists: traktorNode{
+			Type:     traktorFolderNode,
+			Name:     "$ROOT",
+			Subnodes: &traktorSubnodes{Count: len(local)},
+		},
+	}
+	for _, t := range tracks {
+		doc.Collection.Entries = append(doc.Collection.Entries, traktorEntryFromTrack(t))
+	}
+	for _, p := range local {
+		list := &traktorPlaylist{Count: len(p.Tracks), Type: "LIST", UUID: traktorUUID()}
+		for _, t := range p.Tracks {
+			list.Entries = append(list.Entries, traktorPlaylistEntry{
+				PrimaryKey: traktorPrimaryKey{Type: "TRACK", Key: traktorLocationOf(t.Location).key()},
+			})
+		}
+		doc.Playlists.Subnodes.Nodes = append(doc.Playlists.Subnodes.Nodes, traktorNode{
+			Type: traktorPlaylistNode, Name: p.Name, Playlist: list,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ReadTraktor decodes a Traktor NML collection and returns its playlists,
+// including those in folders, whose names are prefixed with the folder
+// path. Smart playlists and Traktor's own "_LOOPS" and "_RECORDINGS" lists
+// are left out. Tracks get their file path as Location and ID.
+func ReadTraktor(r io.Reader) ([]models.Playlist, error) {
+	var doc traktorDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid Traktor collection: %w", err)
+	}
+
+	tracks := make(map[string]models.Track, len(doc.Collection.Entries))
+	for _, e := range doc.Collection.Entries {
+		if e.Location != nil {
+			tracks[e.Location.key()] = e.toTrack()
+		}
+	}
+
+	playlists := []models.Playlist{}
+	var walk func(node traktorNode, folder string)
+	walk = func(node traktorNode, folder string) {
+		if node.Subnodes == nil {
+			return
+		}
+		for _, child := range node.Subnodes.Nodes {
+			name := joinFolder(folder, child.Name)
+			switch {
+			case child.Type == traktorFolderNode:
+				walk(child, name)
+			case child.Type != traktorPlaylistNode || child.Playlist == nil || strings.HasPrefix(child.Name, "_"):
+				// smart playlists and Traktor's own lists
+			default:
+				playlist := models.Playlist{ID: name, Name: name, Tracks: []models.Track{}}
+				for _, entry := range child.Playlist.Entries {
+					if t, ok := tracks[entry.PrimaryKey.Key]; ok {
+						playlist.Tracks = append(playlist.Tracks, t)
+					}
+				}
+				playlist.TrackCount = len(playlist.Tracks)
+				playlists = append(playlists, playlist)
+			}
+		}
+	}
+	walk(doc.Playlists, "")
+
+	uniquePlaylistIDs(playlists)
+	return playlists, nil
+}
+
+// toTrack converts a collection entry to the domain model. The key is
+// taken from MUSICAL_KEY if known, and otherwise as Traktor displays it.
+func (e traktorEntry) toTrack() models.Track {
+	t := models.Track{
+		Title:  strings.TrimSpace(e.Title),
+		Artist: strings.TrimSpace(e.Artist),
+	}
+	if e.Location != nil {
+		t.Location = e.Location.path()
+		t.ID = t.Location
+	}
+	if e.Album != nil {
+		t.Album = strings.TrimSpace(e.Album.Title)
+	}
+	if e.Info != nil {
+		t.Duration, _ = strconv.Atoi(strings.TrimSpace(e.Info.Playtime))
+		t.Key = strings.TrimSpace(e.Info.Key)
+	}
+	if e.Tempo != nil {
+		t.BPM = parseBPM(e.Tempo.BPM)
+	}
+	if e.MusicalKey != nil && e.MusicalKey.Value >= 0 && e.MusicalKey.Value < len(traktorKeys) {
+		t.Key = traktorKeys[e.MusicalKey.Value]
+	}
+	return t
+}
+
+// traktorEntryFromTrack converts a track with a local file to a
+// collection entry
+func traktorEntryFromTrack(t models.Track) traktorEntry {
+	e := traktorEntry{
+		Title:    t.Title,
+		Artist:   t.Artist,
+		Location: traktorLocationOf(t.Location),
+	}
+	if t.Album != "" {
+		e.Album = &traktorAlbum{Title: t.Album}
+	}
+	if t.Duration > 0 || t.Key != "" {
+		e.Info = &traktorInfo{Key: t.Key}
+		if t.Duration > 0 {
+			e.Info.Playtime = strconv.Itoa(t.Duration)
+		}
+	}
+	if bpm := formatBPM(t.BPM); bpm != "" {
+		e.Tempo = &traktorTempo{BPM: bpm}
+	}
+	if value, ok := traktorKeyValue(t.Key); ok {
+		e.MusicalKey = &traktorKey{Value: value}
+	}
+	return e
+}
+
+// traktorLocationOf splits a local path into a Traktor location
+func traktorLocationOf(p string) *traktorLocation {
+	p = strings.ReplaceAll(p, `\`, "/")
+	var volume string
+	if len(p) >= 2 && p[1] == ':' {
+		volume, p = p[:2], p[2:]
+	}
+	dir, file := path.Split(p)
+	var folders []string
+	for _, f := range strings.Split(dir, "/") {
+		if f != "" {
+			folders = append(folders, f)
+		}
+	}
+	traktorDir := traktorDirSep
+	if len(folders) > 0 {
+		traktorDir += strings.Join(folders, traktorDirSep) + traktorDirSep
+	}
+	return &traktorLocation{Dir: traktorDir, File: file, Volume: volume}
+}
+
+// key returns the primary key playlists use to refer to the location
+func (l traktorLocation) key() string {
+	return l.Volume + l.Dir + l.File
+}
+
+// path turns a Traktor location into a local path. Windows drive volumes
+// are kept; named volumes are not, as the system volume is mounted at /.
+func (l traktorLocation) path() string {
+	p := strings.ReplaceAll(l.Dir, traktorDirSep, "/") + l.File
+	if len(l.Volume) == 2 && l.Volume[1] == ':' {
+		p = l.Volume + p
+	}
+	return p
+}
+
+// traktorKeyValue returns the MUSICAL_KEY value of a key such as "Am",
+// "F#" or "Ebmin"
+func traktorKeyValue(key string) (int, bool) {
+	key = strings.TrimSpace(key)
+	minor := false
+	for _, suffix := range []string{"min", "m"} {
+		if strings.HasSuffix(key, suffix) {
+			key, minor = strings.TrimSuffix(key, suffix), true
+			break
+		}
+	}
+	pitch, ok := pitchClasses[key]
+	if !ok {
+		return 0, false
+	}
+	if minor {
+		pitch += len(traktorKeys) / 2
+	}
+	return pitch, true
+}
+
+// traktorUUID returns a random playlist UUID in Traktor's 32-digit hex form
+func traktorUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
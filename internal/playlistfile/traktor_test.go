@@ -0,0 +1,121 @@
+package playlistfile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+const testTraktor = `<?xml version="1.0" encoding="UTF-8" standalone="no" ?>
+<NML VERSION="19"><HEAD COMPANY="www.native-instruments.com" PROGRAM="Traktor"></HEAD>
+<MUSICFOLDERS></MUSICFOLDERS>
+<COLLECTION ENTRIES="2">
+<ENTRY MODIFIED_DATE="2024/5/1" TITLE="Blue Monday" ARTIST="New Order"><LOCATION DIR="/:Users/:jane/:Music/:" FILE="Blue Monday.mp3" VOLUME="Macintosh HD" VOLUMEID="Macintosh HD"></LOCATION>
+<ALBUM TRACK="1" TITLE="Substance"></ALBUM><MODIFICATION_INFO AUTHOR_TYPE="user"></MODIFICATION_INFO>
+<INFO BITRATE="320000" GENRE="Synthpop" KEY="10m" PLAYTIME="449" PLAYTIME_FLOAT="448.653"></INFO>
+<TEMPO BPM="130.002" BPM_QUALITY="100.000"></TEMPO>
+<MUSICAL_KEY VALUE="17"></MUSICAL_KEY>
+</ENTRY>
+<ENTRY TITLE="Jaguar" ARTIST="DJ Rolando"><LOCATION DIR="/:DJ/:Techno/:" FILE="Jaguar.flac" VOLUME="D:" VOLUMEID="1234"></LOCATION>
+<INFO KEY="8A" PLAYTIME="400"></INFO>
+</ENTRY>
+</COLLECTION>
+<PLAYLISTS><NODE TYPE="FOLDER" NAME="$ROOT"><SUBNODES COUNT="4">
+<NODE TYPE="PLAYLIST" NAME="_LOOPS"><PLAYLIST ENTRIES="0" TYPE="LIST" UUID="1"></PLAYLIST></NODE>
+<NODE TYPE="PLAYLIST" NAME="Classics"><PLAYLIST ENTRIES="3" TYPE="LIST" UUID="2">
+<ENTRY><PRIMARYKEY TYPE="TRACK" KEY="Macintosh HD/:Users/:jane/:Music/:Blue Monday.mp3"></PRIMARYKEY></ENTRY>
+<ENTRY><PRIMARYKEY TYPE="TRACK" KEY="D:/:DJ/:Techno/:Jaguar.flac"></PRIMARYKEY></ENTRY>
+<ENTRY><PRIMARYKEY TYPE="TRACK" KEY="Macintosh HD/:Missing.mp3"></PRIMARYKEY></ENTRY>
+</PLAYLIST></NODE>
+<NODE TYPE="SMARTLIST" NAME="Recent"><SMARTLIST UUID="3"></SMARTLIST></NODE>
+<NODE TYPE="FOLDER" NAME="Gigs"><SUBNODES COUNT="1">
+<NODE TYPE="PLAYLIST" NAME="Berlin"><PLAYLIST ENTRIES="1" TYPE="LIST" UUID="4">
+<ENTRY><PRIMARYKEY TYPE="TRACK" KEY="D:/:DJ/:Techno/:Jaguar.flac"></PRIMARYKEY></ENTRY>
+</PLAYLIST></NODE>
+</SUBNODES></NODE>
+</SUBNODES></NODE></PLAYLISTS>
+</NML>
+`
+
+func TestReadTraktor(t *testing.T) {
+	playlists, err := ReadTraktor(strings.NewReader(testTraktor))
+	if err != nil {
+		t.Fatalf("ReadTraktor() failed: %v", err)
+	}
+
+	if len(playlists) != 2 {
+		t.Fatalf("Expected 2 playlists, got %+v", playlists)
+	}
+	classics, berlin := playlists[0], playlists[1]
+	if classics.Name != "Classics" || classics.TrackCount != 2 {
+		t.Errorf("Expected Classics with 2 tracks, got %q with %d", classics.Name, classics.TrackCount)
+	}
+	if berlin.Name != "Gigs / Berlin" || berlin.TrackCount != 1 {
+		t.Errorf("Expected Gigs / Berlin with 1 track, got %q with %d", berlin.Name, berlin.TrackCount)
+	}
+
+	want := models.Track{
+		ID:       "/Users/jane/Music/Blue Monday.mp3",
+		Location: "/Users/jane/Music/Blue Monday.mp3",
+		Title:    "Blue Monday",
+		Artist:   "New Order",
+		Album:    "Substance",
+		Duration: 449,
+		BPM:      130.002,
+		Key:      "Fm",
+	}
+	if !reflect.DeepEqual(classics.Tracks[0], want) {
+		t.Errorf("Expected %+v, got %+v", want, classics.Tracks[0])
+	}
+
+	jaguar := classics.Tracks[1]
+	if jaguar.Location != "D:/DJ/Techno/Jaguar.flac" || jaguar.Key != "8A" {
+		t.Errorf("Expected the drive in the path and the displayed key, got %q / %q", jaguar.Location, jaguar.Key)
+	}
+}
+
+func TestTraktor_RoundTrip(t *testing.T) {
+	local := models.Track{ID: "/music/a b.mp3", Location: "/music/a b.mp3", Title: "A & B", Artist: "Duo", Album: "Split", Duration: 300, BPM: 126.5, Key: "Am"}
+	windows := models.Track{ID: "C:/DJ/c.flac", Location: `C:\DJ\c.flac`, Title: "C", Artist: "Solo", Key: "Ebmin"}
+	streamed := models.Track{ID: "spotify:track:1", Title: "Streamed", Artist: "Online"}
+	playlists := []models.Playlist{{Name: "Crate", Tracks: []models.Track{local, streamed, windows}}}
+
+	var buf bytes.Buffer
+	if err := WriteTraktor(&buf, playlists); err != nil {
+		t.Fatalf("WriteTraktor() failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `DIR="/:music/:" FILE="a b.mp3"`) {
+		t.Errorf("Expected a Traktor location, got:\n%s", buf.String())
+	}
+
+	got, err := ReadTraktor(&buf)
+	if err != nil {
+		t.Fatalf("ReadTraktor() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "Crate" {
+		t.Fatalf("Expected the Crate playlist, got %+v", got)
+	}
+
+	windows.Location, windows.Key = "C:/DJ/c.flac", "Ebm"
+	want := []models.Track{local, windows}
+	if !reflect.DeepEqual(got[0].Tracks, want) {
+		t.Errorf("Tracks changed in round trip:\ngot  %+v\nwant %+v", got[0].Tracks, want)
+	}
+}
+
+func TestTraktorKeyValue(t *testing.T) {
+	tests := map[string]int{"C": 0, "F#": 6, "Gb": 6, "Am": 21, "Ebmin": 15, "Bm": 23}
+	for key, want := range tests {
+		if got, ok := traktorKeyValue(key); !ok || got != want {
+			t.Errorf("traktorKeyValue(%q) = %d, %v; want %d", key, got, ok, want)
+		}
+	}
+	for _, key := range []string{"", "8A", "H"} {
+		if _, ok := traktorKeyValue(key); ok {
+			t.Errorf("traktorKeyValue(%q) should not be recognized", key)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package playlistfile
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// XSPF ("spiff") and its JSON form JSPF, used by ListenBrainz
+const (
+	FormatXSPF = "xspf"
+	FormatJSPF = "jspf"
+)
+
+// xspfNamespace is the XML namespace of XSPF version 1
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+// isrcPrefix marks ISRCs among a track's identifiers, e.g. "isrc:USRC17607839"
+const isrcPrefix = "isrc:"
+
+// xspfPlaylist is the XSPF document root
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version    string      `xml:"version,attr"`
+	Title      string      `xml:"title,omitempty"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+// xspfTrack is an XSPF track. Durations are in milliseconds.
+type xspfTrack struct {
+	Locations   []string `xml:"location"`
+	Identifiers []string `xml:"identifier"`
+	Title       string   `xml:"title,omitempty"`
+	Creator     string   `xml:"creator,omitempty"`
+	Album       string   `xml:"album,omitempty"`
+	Duration    int      `xml:"duration,omitempty"`
+}
+
+// jspfDocument is the JSPF document root
+type jspfDocument struct {
+	Playlist jspfPlaylist `json:"playlist"`
+}
+
+// jspfPlaylist mirrors xspfPlaylist in JSPF
+type jspfPlaylist struct {
+	Title      string      `json:"title,omitempty"`
+	Annotation string      `json:"annotation,omitempty"`
+	Tracks     []jspfTrack `json:"track"`
+}
+
+// jspfTrack mirrors xspfTrack in JSPF
+type jspfTrack struct {
+	Locations   stringList `json:"location,omitempty"`
+	Identifiers stringList `json:"identifier,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Creator     string     `json:"creator,omitempty"`
+	Album       string     `json:"album,omitempty"`
+	Duration    int        `json:"duration,omitempty"`
+}
+
+// stringList is a JSPF location or identifier list. The JSPF draft uses
+// arrays, but some writers emit a single string, so both are accepted.
+type stringList []string
+
+// UnmarshalJSON accepts a string or an array of strings
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = stringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// WriteXSPF encodes a playlist as XSPF
+func WriteXSPF(w io.Writer, playlist models.Playlist) error {
+	doc := xspfPlaylist{
+		Version:    "1",
+		Title:      playlist.Name,
+		Annotation: playlist.Description,
+		Tracks:     make([]xspfTrack, 0, len(playlist.Tracks)),
+	}
+	for _, t := range playlist.Tracks {
+		locations, identifiers, duration := trackToSpiff(t)
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Locations: locations, Identifiers: identifiers,
+			Title: t.Title, Creator: t.Artist, Album: t.Album, Duration: duration,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ReadXSPF decodes an XSPF playlist. A missing title falls back to name.
+func ReadXSPF(r io.Reader, name string) (models.Playlist, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return models.Playlist{}, fmt.Errorf("invalid XSPF playlist: %w", err)
+	}
+
+	playlist := newSpiffPlaylist(doc.Title, doc.Annotation, name)
+	for _, t := range doc.Tracks {
+		playlist.Tracks = append(playlist.Tracks, trackFromSpiff(t.Locations, t.Identifiers, t.Title, t.Creator, t.Album, t.Duration))
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// WriteJSPF encodes a playlist as JSPF
+func WriteJSPF(w io.Writer, playlist models.Playlist) error {
+	doc := jspfDocument{Playlist: jspfPlaylist{
+		Title:      playlist.Name,
+		Annotation: playlist.Description,
+		Tracks:     make([]jspfTrack, 0, len(playlist.Tracks)),
+	}}
+	for _, t := range playlist.Tracks {
+		locations, identifiers, duration := trackToSpiff(t)
+		doc.Playlist.Tracks = append(doc.Playlist.Tracks, jspfTrack{
+			Locations: locations, Identifiers: identifiers,
+			Title: t.Title, Creator: t.Artist, Album: t.Album, Duration: duration,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ReadJSPF decodes a JSPF playlist. A missing title falls back to name.
+func ReadJSPF(r io.Reader, name string) (models.Playlist, error) {
+	var doc jspfDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return models.Playlist{}, fmt.Errorf("invalid JSPF playlist: %w", err)
+	}
+
+	playlist := newSpiffPlaylist(doc.Playlist.Title, doc.Playlist.Annotation, name)
+	for _, t := range doc.Playlist.Tracks {
+		playlist.Tracks = append(playlist.Tracks, trackFromSpiff(t.Locations, t.Identifiers, t.Title, t.Creator, t.Album, t.Duration))
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// newSpiffPlaylist creates an empty playlist from XSPF/JSPF metadata
+func newSpiffPlaylist(title, annotation, name string) models.Playlist {
+	if title == "" {
+		title = name
+	}
+	return models.Playlist{Name: title, Description: annotation, Tracks: []models.Track{}}
+}
+
+// trackToSpiff returns the locations, identifiers and duration (ms) of a track
+func trackToSpiff(t models.Track) ([]string, []string, int) {
+	var locations, identifiers []string
+	if t.Location != "" {
+		locations = append(locations, t.Location)
+	}
+	if t.ISRC != "" {
+		identifiers = append(identifiers, isrcPrefix+t.ISRC)
+	}
+	return locations, identifiers, t.Duration * 1000
+}
+
+// trackFromSpiff builds a track from XSPF/JSPF fields. The ISRC is taken from
+// an "isrc:" identifier; the ID is the first location, or else the first
+// other identifier, such as a MusicBrainz recording URL.
+func trackFromSpiff(locations, identifiers []string, title, creator, album string, durationMS int) models.Track {
+	track := models.Track{
+		Title:    strings.TrimSpace(title),
+		Artist:   strings.TrimSpace(creator),
+		Album:    strings.TrimSpace(album),
+		Duration: (durationMS + 500) / 1000,
+	}
+
+	for _, id := range identifiers {
+		id = strings.TrimSpace(id)
+		lower := strings.ToLower(id)
+		switch {
+		case strings.HasPrefix(lower, isrcPrefix):
+			track.ISRC = strings.ToUpper(id[len(isrcPrefix):])
+		case strings.HasPrefix(lower, "urn:"+isrcPrefix):
+			track.ISRC = strings.ToUpper(id[len("urn:"+isrcPrefix):])
+		case track.ID == "" && id != "":
+			track.ID = id
+		}
+	}
+
+	if len(locations) > 0 {
+		track.Location = strings.TrimSpace(locations[0])
+		track.ID = track.Location
+	}
+	return track
+}
@@ -0,0 +1,129 @@
+package playlistfile
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func spiffPlaylist() models.Playlist {
+	return models.Playlist{
+		Name:        "Crate Digging & <Friends>",
+		Description: "Finds from 2024",
+		Tracks: []models.Track{
+			{ID: "file:///music/a.flac", Location: "file:///music/a.flac", Title: "Señor", Artist: "Los \"Tres\"", Album: "Uno", Duration: 201, ISRC: "ESA011234567"},
+			{Title: "No Location", Artist: "Nobody", Duration: 95, ISRC: "GBAYE0000001"},
+			{Title: "Bare"},
+		},
+		TrackCount: 3,
+	}
+}
+
+func TestSpiff_RoundTrip(t *testing.T) {
+	for _, format := range []string{FormatXSPF, FormatJSPF} {
+		t.Run(format, func(t *testing.T) {
+			want := spiffPlaylist()
+
+			var buf bytes.Buffer
+			if err := Write(&buf, format, want); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			got, err := Read(&buf, format, "fallback")
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+
+			if got.Name != want.Name || got.Description != want.Description || got.TrackCount != 3 {
+				t.Errorf("Playlist metadata changed: got %q / %q / %d", got.Name, got.Description, got.TrackCount)
+			}
+			if !reflect.DeepEqual(got.Tracks, want.Tracks) {
+				t.Errorf("Tracks changed in round trip:\ngot  %+v\nwant %+v", got.Tracks, want.Tracks)
+			}
+		})
+	}
+}
+
+func TestWriteXSPF_Document(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXSPF(&buf, spiffPlaylist()); err != nil {
+		t.Fatalf("WriteXSPF() failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<playlist xmlns="http://xspf.org/ns/0/" version="1">`,
+		`<title>Crate Digging &amp; &lt;Friends&gt;</title>`,
+		`<identifier>isrc:ESA011234567</identifier>`,
+		`<duration>201000</duration>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("XSPF output should contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestReadJSPF_ListenBrainz(t *testing.T) {
+	// Shape of a playlist exported from ListenBrainz
+	input := `{
+	  "playlist": {
+	    "title": "Weekly Jams",
+	    "creator": "listenbrainz",
+	    "track": [
+	      {
+	        "title": "Karma Police",
+	        "creator": "Radiohead",
+	        "album": "OK Computer",
+	        "duration": 263500,
+	        "identifier": ["https://musicbrainz.org/recording/6d3b6e8b-2bc7-4d4b-9c0e-1d5c7b1e8f00"]
+	      },
+	      {"title": "Single", "identifier": "urn:isrc:usrc17607839", "location": "https://example.com/a.mp3"}
+	    ]
+	  }
+	}`
+
+	playlist, err := ReadJSPF(strings.NewReader(input), "fallback")
+	if err != nil {
+		t.Fatalf("ReadJSPF() failed: %v", err)
+	}
+
+	want := []models.Track{
+		{ID: "https://musicbrainz.org/recording/6d3b6e8b-2bc7-4d4b-9c0e-1d5c7b1e8f00", Title: "Karma Police", Artist: "Radiohead", Album: "OK Computer", Duration: 264},
+		{ID: "https://example.com/a.mp3", Location: "https://example.com/a.mp3", Title: "Single", ISRC: "USRC17607839"},
+	}
+	if playlist.Name != "Weekly Jams" || !reflect.DeepEqual(playlist.Tracks, want) {
+		t.Errorf("Unexpected playlist:\ngot  %+v\nwant %+v", playlist.Tracks, want)
+	}
+}
+
+func TestReadXSPF_Invalid(t *testing.T) {
+	if _, err := ReadXSPF(strings.NewReader("<html></html>"), "x"); err == nil {
+		t.Error("ReadXSPF() should reject documents that are not XSPF")
+	}
+	if _, err := ReadJSPF(strings.NewReader("[]"), "x"); err == nil {
+		t.Error("ReadJSPF() should reject documents that are not JSPF")
+	}
+}
+
+func TestFormatFromFileName(t *testing.T) {
+	tests := map[string]string{
+		"mix.XSPF":    FormatXSPF,
+		"mix.jspf":    FormatJSPF,
+		"old.m3u":     FormatM3U,
+		"new.m3u8":    FormatM3U8,
+		"sheet.csv":   FormatCSV,
+		"backup.json": FormatJSON,
+		"crate.xml":   FormatRekordbox,
+		"crate.NML":   FormatTraktor,
+	}
+	for name, want := range tests {
+		if got, ok := FormatFromFileName(name); !ok || got != want {
+			t.Errorf("FormatFromFileName(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := FormatFromFileName("notes.txt"); ok {
+		t.Error("FormatFromFileName() should not recognize .txt")
+	}
+}
@@ -0,0 +1,576 @@
+package applemusic
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+const (
+	apiURL = "https://api.music.apple.com"
+
+	// pageSize is the most items a library request returns
+	pageSize = 100
+
+	// addTracksBatch is the number of tracks added to a playlist per request
+	addTracksBatch = 100
+
+	// searchLimit is the number of candidates a track search returns
+	searchLimit = 10
+
+	// librarySongsName is the name of the library songs playlist
+	librarySongsName = "Library Songs"
+
+	// tokenLifetime is how long a developer token is valid. Apple allows up
+	// to six months; short-lived tokens limit the harm of a leaked one.
+	tokenLifetime = 12 * time.Hour
+
+	// tokenRenewal is how long before its expiry a developer token is
+	// replaced, so that a connect page never receives an expiring one
+	tokenRenewal = time.Hour
+)
+
+// errNoCreatedPlaylist is returned when creating a playlist answers no ID
+var errNoCreatedPlaylist = errors.New("apple music returned no playlist ID")
+
+// AppleMusicProvider implements the Provider interface for Apple Music.
+//
+// Requests are authorized with a developer token, signed with the team's
+// MusicKit key, and the user's Music User Token, which MusicKit JS obtains
+// on the connect page. Apple Music exposes no account ID, so a user has at
+// most one Apple Music connection, first stored under a hash of the Music
+// User Token; the token is kept as AccessToken.
+type AppleMusicProvider struct {
+	teamID          string
+	keyID           string
+	privateKey      *ecdsa.PrivateKey
+	connectionStore storage.ConnectionStore
+	httpClient      *http.Client
+	apiURL          string
+
+	mu          sync.Mutex
+	token       string    // current developer token
+	tokenExpiry time.Time // expiry of token
+	storefronts map[string]string
+}
+
+// NewAppleMusicProvider creates a new Apple Music provider from the team
+// ID, the key ID and the contents of the key's .p8 file
+func NewAppleMusicProvider(teamID, keyID string, privateKey []byte, connectionStore storage.ConnectionStore) (*AppleMusicProvider, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Apple Music private key: %w", err)
+	}
+
+	return &AppleMusicProvider{
+		teamID:          teamID,
+		keyID:           keyID,
+		privateKey:      key,
+		connectionStore: connectionStore,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		apiURL:          apiURL,
+		storefronts:     make(map[string]string),
+	}, nil
+}
+
+// Name returns the provider's name
+func (p *AppleMusicProvider) Name() string {
+	return "Apple Music"
+}
+
+// Authenticate checks if the user has a valid connection
+func (p *AppleMusicProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "applemusic", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return fmt.Errorf("not connected to Apple Music: %w", err)
+	}
+
+	if !conn.Connected {
+		return fmt.Errorf("Apple Music connection not active")
+	}
+
+	return nil
+}
+
+// Accounts returns the user's connected Apple Music accounts, oldest first
+func (p *AppleMusicProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("applemusic", userID)
+}
+
+// DeveloperToken returns a valid developer token, signing a new one when
+// the current one is about to expire. The connect page hands it to
+// MusicKit JS.
+func (p *AppleMusicProvider) DeveloperToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.token != "" && now.Add(tokenRenewal).Before(p.tokenExpiry) {
+		return p.token, nil
+	}
+
+	expiry := now.Add(tokenLifetime)
+	token, err := signToken(p.privateKey, p.teamID, p.keyID, now, expiry)
+	if err != nil {
+		return "", err
+	}
+	p.token, p.tokenExpiry = token, expiry
+	return token, nil
+}
+
+// SaveConnection checks a Music User Token from MusicKit JS by fetching the
+// user's storefront and saves the connection. A user who is already
+// connected gets the new token on the existing connection, so reconnecting
+// after the token expired keeps the account that sync links and schedules
+// refer to.
+func (p *AppleMusicProvider) SaveConnection(ctx context.Context, musicUserToken, userID string) error {
+	storefront, err := p.getStorefront(ctx, musicUserToken)
+	if err != nil {
+		return fmt.Errorf("failed to get storefront: %w", err)
+	}
+
+	existing, err := p.connectionStore.ListByProvider("applemusic", userID)
+	if err != nil {
+		return fmt.Errorf("failed to list connections: %w", err)
+	}
+	id := accountID(musicUserToken)
+	if len(existing) > 0 {
+		id = existing[0].ExternalUserID
+	}
+
+	name := "Apple Music"
+	if storefront.Attributes.Name != "" {
+		name += " (" + storefront.Attributes.Name + ")"
+	}
+
+	conn := &models.Connection{
+		Provider:         "applemusic",
+		UserID:           userID,
+		ExternalUserID:   id,
+		ExternalUserName: name,
+		AccessToken:      musicUserToken,
+		Connected:        true,
+	}
+	if err := p.connectionStore.Save(conn); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.storefronts[id] = storefront.ID
+	p.mu.Unlock()
+	return nil
+}
+
+// GetPlaylists retrieves all playlists in the user's library, after the
+// songs added to the library
+func (p *AppleMusicProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	// A single song is enough to learn the total
+	var songs SongsResponse
+	if err := p.call(ctx, conn.AccessToken, http.MethodGet, "/v1/me/library/songs?limit=1", nil, &songs); err != nil {
+		return nil, fmt.Errorf("failed to fetch library songs: %w", err)
+	}
+	library := libraryPlaylist()
+	if songs.Meta != nil {
+		library.TrackCount = songs.Meta.Total
+	}
+	allPlaylists := []models.Playlist{library}
+
+	path := "/v1/me/library/playlists?limit=" + strconv.Itoa(pageSize)
+	for path != "" {
+		var page PlaylistsResponse
+		if err := p.call(ctx, conn.AccessToken, http.MethodGet, path, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+		}
+		for _, item := range page.Data {
+			allPlaylists = append(allPlaylists, toPlaylist(item))
+		}
+		path = page.Next
+	}
+
+	return allPlaylists, nil
+}
+
+// ExportPlaylist exports a specific library playlist by ID, or the library
+// songs for the library playlist. ISRCs are taken from the catalog songs of
+// the library songs; songs uploaded to the library have none.
+func (p *AppleMusicProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	ctx := context.Background()
+
+	songsParams := url.Values{
+		"include": {"catalog"},
+		"limit":   {strconv.Itoa(pageSize)},
+	}.Encode()
+
+	var playlist models.Playlist
+	var path string
+	if id == providers.LibraryPlaylistID {
+		playlist = libraryPlaylist()
+		path = "/v1/me/library/songs?" + songsParams
+	} else {
+		var detail PlaylistsResponse
+		if err := p.call(ctx, conn.AccessToken, http.MethodGet, "/v1/me/library/playlists/"+url.PathEscape(id), nil, &detail); err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+		}
+		if len(detail.Data) == 0 {
+			return models.Playlist{}, fmt.Errorf("playlist not found: %s", id)
+		}
+		playlist = toPlaylist(detail.Data[0])
+		path = "/v1/me/library/playlists/" + url.PathEscape(id) + "/tracks?" + songsParams
+	}
+
+	for path != "" {
+		var page SongsResponse
+		err := p.call(ctx, conn.AccessToken, http.MethodGet, path, nil, &page)
+		// The tracks of an empty playlist are answered with 404
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound && len(playlist.Tracks) == 0 {
+			break
+		}
+		if err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch tracks: %w", err)
+		}
+
+		for _, song := range page.Data {
+			playlist.Tracks = append(playlist.Tracks, toTrack(song))
+		}
+		path = page.Next
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+
+	return playlist, nil
+}
+
+// ImportPlaylist creates a library playlist and adds the tracks to it.
+// Tracks are expected to carry catalog song IDs, as matched by SearchTrack,
+// or library song IDs; tracks without ID and repeats are skipped.
+func (p *AppleMusicProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	create := CreatePlaylistRequest{Attributes: CreatePlaylistAttributes{Name: playlist.Name, Description: playlist.Description}}
+	var created PlaylistsResponse
+	if err := p.call(ctx, conn.AccessToken, http.MethodPost, "/v1/me/library/playlists", create, &created); err != nil {
+		return fmt.Errorf("failed to create playlist: %w", err)
+	}
+	if len(created.Data) == 0 || created.Data[0].ID == "" {
+		return errNoCreatedPlaylist
+	}
+
+	var refs []TrackReference
+	seen := make(map[string]bool, len(playlist.Tracks))
+	for _, t := range playlist.Tracks {
+		if t.ID == "" || seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		refs = append(refs, TrackReference{ID: t.ID, Type: songType(t.ID)})
+	}
+
+	tracksPath := "/v1/me/library/playlists/" + url.PathEscape(created.Data[0].ID) + "/tracks"
+	for i := 0; i < len(refs); i += addTracksBatch {
+		end := i + addTracksBatch
+		if end > len(refs) {
+			end = len(refs)
+		}
+		if err := p.call(ctx, conn.AccessToken, http.MethodPost, tracksPath, TracksRequest{Data: refs[i:end]}, nil); err != nil {
+			return fmt.Errorf("failed to add tracks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SaveTracks adds the catalog songs among the tracks to the user's library.
+// Library songs are already there; tracks without ID and repeats are
+// skipped.
+func (p *AppleMusicProvider) SaveTracks(acct providers.Account, tracks []models.Track) error {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var ids []string
+	seen := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		if t.ID == "" || seen[t.ID] || songType(t.ID) != "songs" {
+			continue
+		}
+		seen[t.ID] = true
+		ids = append(ids, t.ID)
+	}
+
+	for i := 0; i < len(ids); i += addTracksBatch {
+		end := i + addTracksBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		path := "/v1/me/library?" + url.Values{"ids[songs]": {strings.Join(ids[i:end], ",")}}.Encode()
+		if err := p.call(ctx, conn.AccessToken, http.MethodPost, path, nil, nil); err != nil {
+			return fmt.Errorf("failed to add songs to library: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SearchTrack looks a track up by ISRC and searches the catalog of the
+// user's storefront by artist and title. ISRC matches, if any, come first.
+func (p *AppleMusicProvider) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	storefront, err := p.storefront(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	catalogPath := "/v1/catalog/" + url.PathEscape(storefront)
+
+	var candidates []models.Track
+	seen := make(map[string]bool)
+
+	if t.ISRC != "" {
+		var byISRC SongsResponse
+		if err := p.call(ctx, conn.AccessToken, http.MethodGet, catalogPath+"/songs?"+url.Values{"filter[isrc]": {t.ISRC}}.Encode(), nil, &byISRC); err != nil {
+			return nil, fmt.Errorf("failed to look up ISRC: %w", err)
+		}
+		for _, song := range byISRC.Data {
+			seen[song.ID] = true
+			candidates = append(candidates, toTrack(song))
+		}
+	}
+
+	params := url.Values{
+		"term":  {strings.TrimSpace(t.Artist + " " + t.Title)},
+		"types": {"songs"},
+		"limit": {strconv.Itoa(searchLimit)},
+	}
+	var result SearchResponse
+	if err := p.call(ctx, conn.AccessToken, http.MethodGet, catalogPath+"/search?"+params.Encode(), nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+	if result.Results.Songs != nil {
+		for _, song := range result.Results.Songs.Data {
+			if !seen[song.ID] {
+				seen[song.ID] = true
+				candidates = append(candidates, toTrack(song))
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// connection returns the account's connection
+func (p *AppleMusicProvider) connection(acct providers.Account) (*models.Connection, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "applemusic", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return nil, fmt.Errorf("not connected: %w", err)
+	}
+	return conn, nil
+}
+
+// getStorefront fetches the storefront of the Music User Token's account
+func (p *AppleMusicProvider) getStorefront(ctx context.Context, musicUserToken string) (Storefront, error) {
+	var resp StorefrontResponse
+	if err := p.call(ctx, musicUserToken, http.MethodGet, "/v1/me/storefront", nil, &resp); err != nil {
+		return Storefront{}, err
+	}
+	if len(resp.Data) == 0 {
+		return Storefront{}, fmt.Errorf("no storefront returned")
+	}
+	return resp.Data[0], nil
+}
+
+// storefront returns the ID of the account's storefront, which catalog
+// requests need. It is fetched once per account.
+func (p *AppleMusicProvider) storefront(ctx context.Context, conn *models.Connection) (string, error) {
+	p.mu.Lock()
+	id, ok := p.storefronts[conn.ExternalUserID]
+	p.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	storefront, err := p.getStorefront(ctx, conn.AccessToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to get storefront: %w", err)
+	}
+
+	p.mu.Lock()
+	p.storefronts[conn.ExternalUserID] = storefront.ID
+	p.mu.Unlock()
+	return storefront.ID, nil
+}
+
+// statusError is returned for answers with an unexpected HTTP status
+type statusError struct {
+	code int
+	err  error
+}
+
+// Error implements error
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%d %s: %v", e.code, http.StatusText(e.code), e.err)
+}
+
+// Unwrap returns the decoded API error
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// call sends an API request with the developer token and the Music User
+// Token and decodes the JSON answer into v, which may be nil. path is
+// relative to the API base URL and may carry a query, as pagination links
+// do.
+func (p *AppleMusicProvider) call(ctx context.Context, musicUserToken, method, path string, body, v interface{}) error {
+	developerToken, err := p.DeveloperToken()
+	if err != nil {
+		return err
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+developerToken)
+	req.Header.Set("Music-User-Token", musicUserToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		var apiErr ErrorResponse
+		if err := json.Unmarshal(respBody, &apiErr); err != nil || len(apiErr.Errors) == 0 {
+			return &statusError{code: resp.StatusCode, err: fmt.Errorf("%s", strings.TrimSpace(string(respBody)))}
+		}
+		return &statusError{code: resp.StatusCode, err: &apiErr}
+	}
+
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// accountID returns the ID a user's first Apple Music connection is stored
+// under
+func accountID(musicUserToken string) string {
+	sum := sha256.Sum256([]byte(musicUserToken))
+	return hex.EncodeToString(sum[:8])
+}
+
+// songType returns the resource type of a song ID: library song IDs start
+// with "i.", catalog song IDs are numeric
+func songType(id string) string {
+	if strings.HasPrefix(id, "i.") {
+		return "library-songs"
+	}
+	return "songs"
+}
+
+// libraryPlaylist returns the playlist of the library songs, without tracks
+func libraryPlaylist() models.Playlist {
+	return models.Playlist{
+		ID:        providers.LibraryPlaylistID,
+		Name:      librarySongsName,
+		Provider:  "Apple Music",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// toPlaylist converts a library playlist to the domain model, without
+// tracks
+func toPlaylist(item LibraryPlaylist) models.Playlist {
+	playlist := models.Playlist{
+		ID:        item.ID,
+		Name:      item.Attributes.Name,
+		Provider:  "Apple Music",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if item.Attributes.Description != nil {
+		playlist.Description = item.Attributes.Description.Standard
+	}
+	return playlist
+}
+
+// toTrack converts a library or catalog song to the domain model, filling
+// in the ISRC and missing attributes from the catalog song
+func toTrack(song Song) models.Track {
+	attrs := song.Attributes
+	if catalog := song.Relationships.Catalog; catalog != nil && len(catalog.Data) > 0 {
+		c := catalog.Data[0].Attributes
+		attrs.ISRC = c.ISRC
+		if attrs.ArtistName == "" {
+			attrs.ArtistName = c.ArtistName
+		}
+		if attrs.AlbumName == "" {
+			attrs.AlbumName = c.AlbumName
+		}
+		if attrs.DurationInMillis == 0 {
+			attrs.DurationInMillis = c.DurationInMillis
+		}
+	}
+
+	return models.Track{
+		ID:       song.ID,
+		Title:    attrs.Name,
+		Artist:   attrs.ArtistName,
+		Album:    attrs.AlbumName,
+		Duration: attrs.DurationInMillis / 1000,
+		ISRC:     attrs.ISRC,
+	}
+}
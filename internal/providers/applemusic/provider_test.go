@@ -0,0 +1,509 @@
+package applemusic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// fakeAppleMusic is an httptest stand-in for the Apple Music API. It
+// accepts developer tokens signed with key and the Music User Tokens
+// "user-token-1" and "user-token-2" of a user in the "us" storefront.
+type fakeAppleMusic struct {
+	mu      sync.Mutex
+	server  *httptest.Server
+	key     *ecdsa.PrivateKey
+	created []CreatePlaylistRequest
+	added   [][]TrackReference // bodies of add-track requests
+	library []string           // ids[songs] of add-to-library requests
+}
+
+func newFakeAppleMusic(t *testing.T) *fakeAppleMusic {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed: %v", err)
+	}
+	f := &fakeAppleMusic{key: key}
+	mux := http.NewServeMux()
+
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if err := verifyToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &key.PublicKey); err != nil {
+				writeError(w, http.StatusUnauthorized, "Unauthenticated", err.Error())
+				return
+			}
+			if token := r.Header.Get("Music-User-Token"); token != "user-token-1" && token != "user-token-2" {
+				writeError(w, http.StatusForbidden, "Forbidden", "Invalid music user token")
+				return
+			}
+			handler(w, r)
+		})
+	}
+
+	handle("GET /v1/me/storefront", func(w http.ResponseWriter, r *http.Request) {
+		sf := Storefront{ID: "us"}
+		sf.Attributes.Name = "United States"
+		writeJSON(w, StorefrontResponse{Data: []Storefront{sf}})
+	})
+	handle("GET /v1/me/library/playlists", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			writeJSON(w, PlaylistsResponse{
+				Data: []LibraryPlaylist{{ID: "p.1", Type: "library-playlists", Attributes: PlaylistAttributes{Name: "Evening", Description: &Description{Standard: "Quiet"}}}},
+				Next: "/v1/me/library/playlists?offset=1",
+			})
+			return
+		}
+		writeJSON(w, PlaylistsResponse{Data: []LibraryPlaylist{{ID: "p.2", Type: "library-playlists", Attributes: PlaylistAttributes{Name: "Empty"}}}})
+	})
+	handle("GET /v1/me/library/playlists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "p.1":
+			writeJSON(w, PlaylistsResponse{Data: []LibraryPlaylist{{ID: "p.1", Attributes: PlaylistAttributes{Name: "Evening", Description: &Description{Standard: "Quiet"}}}}})
+		case "p.2":
+			writeJSON(w, PlaylistsResponse{Data: []LibraryPlaylist{{ID: "p.2", Attributes: PlaylistAttributes{Name: "Empty"}}}})
+		default:
+			writeError(w, http.StatusNotFound, "Resource Not Found", "")
+		}
+	})
+	handle("GET /v1/me/library/playlists/{id}/tracks", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "p.1" {
+			writeError(w, http.StatusNotFound, "Resource Not Found", "")
+			return
+		}
+		if r.URL.Query().Get("include") != "catalog" {
+			writeError(w, http.StatusBadRequest, "Bad Request", "expected include=catalog")
+			return
+		}
+		catalog := Song{ID: "1440783617", Type: "songs", Attributes: SongAttributes{Name: "Teardrop", ArtistName: "Massive Attack", AlbumName: "Mezzanine", DurationInMillis: 330000, ISRC: "GBAAA9800044"}}
+		writeJSON(w, SongsResponse{Data: []Song{
+			{ID: "i.abc", Type: "library-songs", Attributes: SongAttributes{Name: "Teardrop", ArtistName: "Massive Attack", AlbumName: "Mezzanine", DurationInMillis: 330000},
+				Relationships: SongRelationships{Catalog: &SongsResponse{Data: []Song{catalog}}}},
+			{ID: "i.def", Type: "library-songs", Attributes: SongAttributes{Name: "Demo", ArtistName: "Me", DurationInMillis: 61500}},
+		}})
+	})
+	handle("GET /v1/me/library/songs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") == "1" {
+			writeJSON(w, SongsResponse{Data: []Song{{ID: "i.ghi", Type: "library-songs"}}, Meta: &Meta{Total: 2}})
+			return
+		}
+		if r.URL.Query().Get("offset") == "" {
+			catalog := Song{ID: "1440783617", Type: "songs", Attributes: SongAttributes{Name: "Teardrop", ArtistName: "Massive Attack", AlbumName: "Mezzanine", DurationInMillis: 330000, ISRC: "GBAAA9800044"}}
+			writeJSON(w, SongsResponse{
+				Data: []Song{{ID: "i.ghi", Type: "library-songs", Attributes: SongAttributes{Name: "Teardrop", ArtistName: "Massive Attack", AlbumName: "Mezzanine", DurationInMillis: 330000},
+					Relationships: SongRelationships{Catalog: &SongsResponse{Data: []Song{catalog}}}}},
+				Next: "/v1/me/library/songs?include=catalog&offset=1",
+			})
+			return
+		}
+		writeJSON(w, SongsResponse{Data: []Song{{ID: "i.jkl", Type: "library-songs", Attributes: SongAttributes{Name: "Angel", ArtistName: "Massive Attack", DurationInMillis: 379000}}}})
+	})
+	handle("POST /v1/me/library", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.library = append(f.library, r.URL.Query().Get("ids[songs]"))
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	handle("POST /v1/me/library/playlists", func(w http.ResponseWriter, r *http.Request) {
+		var create CreatePlaylistRequest
+		json.NewDecoder(r.Body).Decode(&create)
+		f.mu.Lock()
+		f.created = append(f.created, create)
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(PlaylistsResponse{Data: []LibraryPlaylist{{ID: "p.new", Type: "library-playlists"}}})
+	})
+	handle("POST /v1/me/library/playlists/p.new/tracks", func(w http.ResponseWriter, r *http.Request) {
+		var body TracksRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.added = append(f.added, body.Data)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handle("GET /v1/catalog/us/songs", func(w http.ResponseWriter, r *http.Request) {
+		var data []Song
+		if r.URL.Query().Get("filter[isrc]") == "GBAAA9800044" {
+			data = []Song{{ID: "1440783617", Type: "songs", Attributes: SongAttributes{Name: "Teardrop", ArtistName: "Massive Attack", DurationInMillis: 330000, ISRC: "GBAAA9800044"}}}
+		}
+		writeJSON(w, SongsResponse{Data: data})
+	})
+	handle("GET /v1/catalog/us/search", func(w http.ResponseWriter, r *http.Request) {
+		var result SearchResponse
+		if r.URL.Query().Get("term") == "Massive Attack Teardrop" && r.URL.Query().Get("types") == "songs" {
+			result.Results.Songs = &SongsResponse{Data: []Song{
+				{ID: "1440783617", Type: "songs", Attributes: SongAttributes{Name: "Teardrop", ArtistName: "Massive Attack", DurationInMillis: 330000, ISRC: "GBAAA9800044"}},
+				{ID: "1440783999", Type: "songs", Attributes: SongAttributes{Name: "Teardrop (Live)", ArtistName: "Massive Attack", DurationInMillis: 345000, ISRC: "GBAAA0600001"}},
+			}}
+		}
+		writeJSON(w, result)
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// verifyToken checks the ES256 signature and claims of a developer token
+func verifyToken(token string, pub *ecdsa.PublicKey) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed token")
+	}
+
+	var header map[string]string
+	if err := decodeSegment(parts[0], &header); err != nil || header["alg"] != "ES256" || header["kid"] != "KEY123" {
+		return fmt.Errorf("unexpected header %v", header)
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil || claims["iss"] != "TEAM123" {
+		return fmt.Errorf("unexpected claims %v", claims)
+	}
+	if exp, _ := claims["exp"].(float64); int64(exp) <= time.Now().Unix() {
+		return fmt.Errorf("token expired")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return fmt.Errorf("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(pub, digest[:], r, s) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, title, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Errors: []APIError{{Status: strconv.Itoa(status), Title: title, Detail: detail}}})
+}
+
+// encodeKey returns key in the PEM format of a .p8 file
+func encodeKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() failed: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// newTestProvider returns a provider talking to f with an account connected
+// for user123
+func newTestProvider(t *testing.T, f *fakeAppleMusic) (*AppleMusicProvider, storage.ConnectionStore) {
+	store := storage.NewInMemoryConnectionStore()
+	p, err := NewAppleMusicProvider("TEAM123", "KEY123", encodeKey(t, f.key), store)
+	if err != nil {
+		t.Fatalf("NewAppleMusicProvider() failed: %v", err)
+	}
+	p.apiURL = f.server.URL
+
+	if err := p.SaveConnection(context.Background(), "user-token-1", "user123"); err != nil {
+		t.Fatalf("SaveConnection() failed: %v", err)
+	}
+	return p, store
+}
+
+func TestAppleMusicProvider_Name(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+
+	if provider.Name() != "Apple Music" {
+		t.Errorf("Expected provider name 'Apple Music', got '%s'", provider.Name())
+	}
+}
+
+func TestNewAppleMusicProvider_InvalidKey(t *testing.T) {
+	rsaLike := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")})
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  []byte
+	}{
+		{"empty", nil},
+		{"not PEM", []byte("-----BEGIN nothing")},
+		{"not PKCS8", rsaLike},
+		{"wrong curve", encodeKey(t, p384)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAppleMusicProvider("TEAM123", "KEY123", tt.key, storage.NewInMemoryConnectionStore()); err == nil {
+				t.Error("Expected an error for an invalid key")
+			}
+		})
+	}
+}
+
+func TestAppleMusicProvider_DeveloperToken(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+
+	token, err := provider.DeveloperToken()
+	if err != nil {
+		t.Fatalf("DeveloperToken() failed: %v", err)
+	}
+	if err := verifyToken(token, &f.key.PublicKey); err != nil {
+		t.Errorf("Invalid developer token: %v", err)
+	}
+
+	again, err := provider.DeveloperToken()
+	if err != nil {
+		t.Fatalf("DeveloperToken() failed: %v", err)
+	}
+	if again != token {
+		t.Error("Expected the developer token to be reused until it nears expiry")
+	}
+
+	// An expiring token is replaced
+	provider.tokenExpiry = time.Now().Add(tokenRenewal / 2)
+	renewed, err := provider.DeveloperToken()
+	if err != nil {
+		t.Fatalf("DeveloperToken() failed: %v", err)
+	}
+	if renewed == token {
+		t.Error("Expected a new developer token when the old one is about to expire")
+	}
+}
+
+func TestAppleMusicProvider_SaveConnection(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, store := newTestProvider(t, f)
+
+	conns, err := store.ListByProvider("applemusic", "user123")
+	if err != nil || len(conns) != 1 {
+		t.Fatalf("Expected 1 connection, got %d (%v)", len(conns), err)
+	}
+	conn := conns[0]
+	if conn.ExternalUserID != accountID("user-token-1") || conn.ExternalUserName != "Apple Music (United States)" || conn.AccessToken != "user-token-1" {
+		t.Errorf("Unexpected connection %+v", conn)
+	}
+	if err := provider.Authenticate(providers.Account{UserID: "user123"}); err != nil {
+		t.Errorf("Authenticate() failed: %v", err)
+	}
+
+	if err := provider.SaveConnection(context.Background(), "revoked-token", "user456"); err == nil {
+		t.Error("Expected an error for an invalid Music User Token")
+	}
+	if err := provider.Authenticate(providers.Account{UserID: "user456"}); err == nil {
+		t.Error("Invalid tokens should not be saved")
+	}
+}
+
+func TestAppleMusicProvider_SaveConnection_Reconnect(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, store := newTestProvider(t, f)
+
+	before, _ := store.ListByProvider("applemusic", "user123")
+	if err := provider.SaveConnection(context.Background(), "user-token-2", "user123"); err != nil {
+		t.Fatalf("SaveConnection() failed: %v", err)
+	}
+
+	conns, err := store.ListByProvider("applemusic", "user123")
+	if err != nil || len(conns) != 1 {
+		t.Fatalf("Expected the reconnect to replace the connection, got %d (%v)", len(conns), err)
+	}
+	if conns[0].ExternalUserID != before[0].ExternalUserID || conns[0].AccessToken != "user-token-2" {
+		t.Errorf("Expected the new token under %s, got %+v", before[0].ExternalUserID, conns[0])
+	}
+	if err := provider.Authenticate(providers.Account{UserID: "user123", ExternalUserID: before[0].ExternalUserID}); err != nil {
+		t.Errorf("Authenticate() failed after reconnecting: %v", err)
+	}
+}
+
+func TestAppleMusicProvider_GetPlaylists(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlists, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	if len(playlists) != 3 {
+		t.Fatalf("Expected the library and 2 playlists across both pages, got %d", len(playlists))
+	}
+	if playlists[0].ID != providers.LibraryPlaylistID || playlists[0].Name != "Library Songs" || playlists[0].TrackCount != 2 {
+		t.Errorf("Expected the library songs first, got %+v", playlists[0])
+	}
+	if playlists[1].ID != "p.1" || playlists[1].Name != "Evening" || playlists[1].Description != "Quiet" || playlists[1].Provider != "Apple Music" {
+		t.Errorf("Unexpected playlist %+v", playlists[1])
+	}
+}
+
+func TestAppleMusicProvider_ExportPlaylist(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	playlist, err := provider.ExportPlaylist(acct, "p.1")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+	if len(playlist.Tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(playlist.Tracks))
+	}
+	want := models.Track{ID: "i.abc", Title: "Teardrop", Artist: "Massive Attack", Album: "Mezzanine", Duration: 330, ISRC: "GBAAA9800044"}
+	if playlist.Tracks[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, playlist.Tracks[0])
+	}
+	if playlist.Tracks[1].ISRC != "" || playlist.Tracks[1].Duration != 61 {
+		t.Errorf("Unexpected uploaded track %+v", playlist.Tracks[1])
+	}
+
+	empty, err := provider.ExportPlaylist(acct, "p.2")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed for an empty playlist: %v", err)
+	}
+	if empty.Name != "Empty" || len(empty.Tracks) != 0 {
+		t.Errorf("Unexpected empty playlist %+v", empty)
+	}
+
+	if _, err := provider.ExportPlaylist(acct, "p.missing"); err == nil {
+		t.Error("Expected an error for a missing playlist")
+	}
+}
+
+func TestAppleMusicProvider_ExportPlaylist_Library(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+
+	library, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, providers.LibraryPlaylistID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if library.Name != "Library Songs" || len(library.Tracks) != 2 {
+		t.Fatalf("Expected 2 library songs across both pages, got %+v", library)
+	}
+	if library.Tracks[0].ID != "i.ghi" || library.Tracks[0].ISRC != "GBAAA9800044" {
+		t.Errorf("Unexpected first song %+v", library.Tracks[0])
+	}
+	if library.Tracks[1].Title != "Angel" || library.Tracks[1].Duration != 379 {
+		t.Errorf("Unexpected second song %+v", library.Tracks[1])
+	}
+}
+
+func TestAppleMusicProvider_SaveTracks(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+
+	var tracks []models.Track
+	for i := 0; i < 120; i++ {
+		tracks = append(tracks, models.Track{ID: strconv.Itoa(1000 + i)})
+	}
+	tracks = append(tracks, models.Track{ID: "1000"}, models.Track{ID: "i.abc"}, models.Track{Title: "Unmatched"})
+
+	if err := provider.SaveTracks(providers.Account{UserID: "user123"}, tracks); err != nil {
+		t.Fatalf("SaveTracks() failed: %v", err)
+	}
+
+	if len(f.library) != 2 {
+		t.Fatalf("Expected songs to be added in 2 batches, got %d", len(f.library))
+	}
+	if ids := strings.Split(f.library[1], ","); len(ids) != 20 || ids[19] != "1119" {
+		t.Errorf("Expected catalog songs only in the last batch, got %v", ids)
+	}
+}
+
+func TestAppleMusicProvider_ImportPlaylist(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlist := models.Playlist{Name: "Imported", Description: "From Spotify"}
+	for i := 0; i < 120; i++ {
+		playlist.Tracks = append(playlist.Tracks, models.Track{ID: strconv.Itoa(1000 + i)})
+	}
+	playlist.Tracks = append(playlist.Tracks, models.Track{ID: "1000"}, models.Track{ID: "i.abc"}, models.Track{Title: "Unmatched"})
+
+	if err := provider.ImportPlaylist(providers.Account{UserID: "user123"}, playlist); err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+
+	if len(f.created) != 1 || f.created[0].Attributes.Name != "Imported" || f.created[0].Attributes.Description != "From Spotify" {
+		t.Errorf("Unexpected created playlists %+v", f.created)
+	}
+	if len(f.added) != 2 || len(f.added[0]) != 100 || len(f.added[1]) != 21 {
+		t.Fatalf("Expected tracks to be added in batches of 100 and 21, got %d batches", len(f.added))
+	}
+	if f.added[0][0] != (TrackReference{ID: "1000", Type: "songs"}) {
+		t.Errorf("Unexpected first track %+v", f.added[0][0])
+	}
+	if f.added[1][20] != (TrackReference{ID: "i.abc", Type: "library-songs"}) {
+		t.Errorf("Expected library songs to keep their type, got %+v", f.added[1][20])
+	}
+}
+
+func TestAppleMusicProvider_SearchTrack(t *testing.T) {
+	f := newFakeAppleMusic(t)
+	provider, _ := newTestProvider(t, f)
+
+	candidates, err := provider.SearchTrack(providers.Account{UserID: "user123"}, models.Track{Title: "Teardrop", Artist: "Massive Attack", ISRC: "GBAAA9800044"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+
+	if len(candidates) != 2 {
+		t.Fatalf("Expected 2 candidates without repeats, got %+v", candidates)
+	}
+	if candidates[0].ID != "1440783617" || candidates[0].ISRC != "GBAAA9800044" {
+		t.Errorf("Expected the ISRC match first, got %+v", candidates[0])
+	}
+	if candidates[1].ID != "1440783999" {
+		t.Errorf("Expected the search result second, got %+v", candidates[1])
+	}
+}
+
+func TestSongType(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"1440783617", "songs"},
+		{"i.abc123", "library-songs"},
+	}
+
+	for _, tt := range tests {
+		if got := songType(tt.id); got != tt.want {
+			t.Errorf("songType(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
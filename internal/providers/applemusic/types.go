@@ -0,0 +1,135 @@
+package applemusic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlaylistsResponse is a page of library playlists
+type PlaylistsResponse struct {
+	Data []LibraryPlaylist `json:"data"`
+	Next string            `json:"next"`
+}
+
+// LibraryPlaylist represents a playlist in the user's library
+type LibraryPlaylist struct {
+	ID         string             `json:"id"`
+	Type       string             `json:"type"`
+	Attributes PlaylistAttributes `json:"attributes"`
+}
+
+// PlaylistAttributes holds the attributes of a library playlist
+type PlaylistAttributes struct {
+	Name        string       `json:"name"`
+	Description *Description `json:"description,omitempty"`
+	CanEdit     bool         `json:"canEdit,omitempty"`
+}
+
+// Description is a playlist description
+type Description struct {
+	Standard string `json:"standard"`
+}
+
+// SongsResponse is a page of songs, from the library or the catalog
+type SongsResponse struct {
+	Data []Song `json:"data"`
+	Next string `json:"next"`
+	Meta *Meta  `json:"meta,omitempty"`
+}
+
+// Meta holds the total of a paged library collection
+type Meta struct {
+	Total int `json:"total"`
+}
+
+// Song represents a library song ("library-songs") or a catalog song
+// ("songs"). Library songs carry their catalog song as relationship when
+// requested with include=catalog; only catalog songs have an ISRC.
+type Song struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Attributes    SongAttributes    `json:"attributes"`
+	Relationships SongRelationships `json:"relationships"`
+}
+
+// SongAttributes holds the attributes of a song
+type SongAttributes struct {
+	Name             string `json:"name"`
+	ArtistName       string `json:"artistName"`
+	AlbumName        string `json:"albumName"`
+	DurationInMillis int    `json:"durationInMillis"`
+	ISRC             string `json:"isrc"`
+}
+
+// SongRelationships holds the relationships of a library song
+type SongRelationships struct {
+	Catalog *SongsResponse `json:"catalog,omitempty"`
+}
+
+// SearchResponse is the answer of a catalog search
+type SearchResponse struct {
+	Results struct {
+		Songs *SongsResponse `json:"songs"`
+	} `json:"results"`
+}
+
+// StorefrontResponse is the answer of the user's storefront request
+type StorefrontResponse struct {
+	Data []Storefront `json:"data"`
+}
+
+// Storefront is an Apple Music country store
+type Storefront struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+}
+
+// CreatePlaylistRequest is the body of a library playlist creation
+type CreatePlaylistRequest struct {
+	Attributes CreatePlaylistAttributes `json:"attributes"`
+}
+
+// CreatePlaylistAttributes holds the attributes of a new playlist
+type CreatePlaylistAttributes struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// TracksRequest is the body of a request adding tracks to a playlist
+type TracksRequest struct {
+	Data []TrackReference `json:"data"`
+}
+
+// TrackReference names a song by ID and type
+type TrackReference struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// ErrorResponse is the body of a failed request
+type ErrorResponse struct {
+	Errors []APIError `json:"errors"`
+}
+
+// APIError is one error of a failed request
+type APIError struct {
+	Status string `json:"status"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// Error implements error
+func (r *ErrorResponse) Error() string {
+	var msgs []string
+	for _, e := range r.Errors {
+		msg := e.Title
+		if e.Detail != "" {
+			msg += ": " + e.Detail
+		}
+		msgs = append(msgs, msg)
+	}
+	return fmt.Sprintf("apple music API error: %s", strings.Join(msgs, "; "))
+}
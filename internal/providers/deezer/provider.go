@@ -0,0 +1,723 @@
+package deezer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+const (
+	apiURL     = "https://api.deezer.com"
+	connectURL = "https://connect.deezer.com"
+
+	// pageSize is the number of items requested per page
+	pageSize = 100
+
+	// addTracksBatch is the number of tracks added to a playlist per request
+	addTracksBatch = 100
+
+	// searchLimit is the number of candidates a track search returns
+	searchLimit = 10
+
+	// favouritesName is the name of the favourite tracks library
+	favouritesName = "Favourite tracks"
+
+	// maxQuotaRetries is how often a request is retried after Deezer's rate
+	// limit of 50 requests per 5 seconds is hit
+	maxQuotaRetries = 3
+)
+
+// perms are the permissions PlayPort asks for. Deezer issues no refresh
+// tokens, so offline_access is needed for a token that does not expire.
+var perms = []string{"basic_access", "offline_access", "manage_library"}
+
+// DeezerProvider implements the Provider interface for Deezer
+type DeezerProvider struct {
+	appID           string
+	secret          string
+	redirectURL     string
+	connectionStore storage.ConnectionStore
+	httpClient      *http.Client
+	apiURL          string
+	connectURL      string
+	quotaBackoff    time.Duration
+}
+
+// NewDeezerProvider creates a new Deezer provider
+func NewDeezerProvider(appID, secret, redirectURL string, connectionStore storage.ConnectionStore) *DeezerProvider {
+	return &DeezerProvider{
+		appID:           appID,
+		secret:          secret,
+		redirectURL:     redirectURL,
+		connectionStore: connectionStore,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		apiURL:          apiURL,
+		connectURL:      connectURL,
+		quotaBackoff:    5 * time.Second,
+	}
+}
+
+// Name returns the provider's name
+func (p *DeezerProvider) Name() string {
+	return "Deezer"
+}
+
+// Authenticate checks if the user has a valid connection
+func (p *DeezerProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "deezer", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return fmt.Errorf("not connected to Deezer: %w", err)
+	}
+
+	if !conn.Connected {
+		return fmt.Errorf("Deezer connection not active")
+	}
+
+	// Tokens granted without offline_access expire and cannot be refreshed
+	if !conn.ExpiresAt.IsZero() && time.Now().After(conn.ExpiresAt) {
+		return fmt.Errorf("Deezer access token expired, please reconnect")
+	}
+
+	return nil
+}
+
+// AuthURL returns the OAuth authorization URL
+func (p *DeezerProvider) AuthURL(state string) string {
+	params := url.Values{
+		"app_id":       {p.appID},
+		"redirect_uri": {p.redirectURL},
+		"perms":        {strings.Join(perms, ",")},
+		"state":        {state},
+	}
+	return p.connectURL + "/oauth/auth.php?" + params.Encode()
+}
+
+// Accounts returns the user's connected Deezer accounts, oldest first
+func (p *DeezerProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("deezer", userID)
+}
+
+// Exchange exchanges an authorization code for a token. Deezer's token
+// endpoint is not standard OAuth 2.0, so it is called directly.
+func (p *DeezerProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	params := url.Values{
+		"app_id": {p.appID},
+		"secret": {p.secret},
+		"code":   {code},
+		"output": {"json"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.connectURL+"/oauth/access_token.php?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	// Invalid codes are answered with a plain text message such as "wrong code"
+	var result TokenResponse
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(body, &result) != nil || result.AccessToken == "" {
+		return nil, fmt.Errorf("deezer token exchange failed: %s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	token := &oauth2.Token{AccessToken: result.AccessToken, TokenType: "Bearer"}
+	if expires, err := result.Expires.Int64(); err == nil && expires > 0 {
+		token.Expiry = time.Now().Add(time.Duration(expires) * time.Second)
+	}
+	return token, nil
+}
+
+// SaveConnection saves a connection after OAuth
+func (p *DeezerProvider) SaveConnection(ctx context.Context, token *oauth2.Token, userID string) error {
+	var user User
+	if err := p.call(ctx, token.AccessToken, http.MethodGet, "/user/me", nil, &user); err != nil {
+		return fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	conn := &models.Connection{
+		Provider:         "deezer",
+		UserID:           userID,
+		ExternalUserID:   strconv.FormatInt(user.ID, 10),
+		ExternalUserName: user.Name,
+		AccessToken:      token.AccessToken,
+		ExpiresAt:        token.Expiry,
+		Scopes:           perms,
+		Connected:        true,
+	}
+
+	return p.connectionStore.Save(conn)
+}
+
+// GetPlaylists retrieves all playlists for the authenticated user. The
+// favourite tracks come first, as the library playlist.
+func (p *DeezerProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var allPlaylists []models.Playlist
+
+	for index := 0; ; {
+		var page PlaylistsResponse
+		if err := p.call(ctx, accessToken, http.MethodGet, "/user/me/playlists", pageParams(index), &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+		}
+
+		for _, item := range page.Data {
+			playlist := models.Playlist{
+				ID:          strconv.FormatInt(item.ID, 10),
+				Name:        item.Title,
+				Description: item.Description,
+				TrackCount:  item.NbTracks,
+				Provider:    "Deezer",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			if item.IsLovedTrack {
+				playlist.ID = providers.LibraryPlaylistID
+				playlist.Name = favouritesName
+				allPlaylists = append([]models.Playlist{playlist}, allPlaylists...)
+				continue
+			}
+			allPlaylists = append(allPlaylists, playlist)
+		}
+
+		index += len(page.Data)
+		if len(page.Data) == 0 || index >= page.Total {
+			break
+		}
+	}
+
+	return allPlaylists, nil
+}
+
+// ExportPlaylist exports a specific playlist by ID, or the favourite tracks
+// for the library playlist. Track lists carry no ISRC, so every track is
+// looked up on its own to get it.
+func (p *DeezerProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	ctx := context.Background()
+
+	var playlist models.Playlist
+	tracksPath := "/playlist/" + url.PathEscape(id) + "/tracks"
+	if id == providers.LibraryPlaylistID {
+		playlist = models.Playlist{
+			ID:        providers.LibraryPlaylistID,
+			Name:      favouritesName,
+			Provider:  "Deezer",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		tracksPath = "/user/me/tracks"
+	} else {
+		var detail Playlist
+		if err := p.call(ctx, accessToken, http.MethodGet, "/playlist/"+url.PathEscape(id), nil, &detail); err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+		}
+
+		playlist = models.Playlist{
+			ID:          strconv.FormatInt(detail.ID, 10),
+			Name:        detail.Title,
+			Description: detail.Description,
+			TrackCount:  detail.NbTracks,
+			Provider:    "Deezer",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+	}
+
+	var allTracks []models.Track
+	for index := 0; ; {
+		var page TracksResponse
+		if err := p.call(ctx, accessToken, http.MethodGet, tracksPath, pageParams(index), &page); err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch tracks: %w", err)
+		}
+
+		for _, item := range page.Data {
+			if item.ID == 0 {
+				continue // Skip removed tracks
+			}
+			allTracks = append(allTracks, toTrack(item))
+		}
+
+		index += len(page.Data)
+		if len(page.Data) == 0 || index >= page.Total {
+			break
+		}
+	}
+
+	for i := range allTracks {
+		var full Track
+		if err := p.call(ctx, accessToken, http.MethodGet, "/track/"+allTracks[i].ID, nil, &full); err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch track %s: %w", allTracks[i].ID, err)
+		}
+		allTracks[i].ISRC = full.ISRC
+	}
+
+	playlist.Tracks = allTracks
+	playlist.TrackCount = len(allTracks)
+	return playlist, nil
+}
+
+// ImportPlaylist creates a playlist in the user's library and adds the
+// tracks to it. Tracks are expected to carry Deezer track IDs, as matched
+// by SearchTrack; other tracks and repeats are skipped.
+func (p *DeezerProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	id, err := p.CreatePlaylist(acct, playlist)
+	if err != nil {
+		return err
+	}
+	return p.AddTracks(acct, id, playlist.Tracks)
+}
+
+// CreatePlaylist creates an empty playlist in the user's library
+func (p *DeezerProvider) CreatePlaylist(acct providers.Account, playlist models.Playlist) (string, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+
+	var created CreatedResponse
+	if err := p.call(ctx, accessToken, http.MethodPost, "/user/me/playlists", url.Values{"title": {playlist.Name}}, &created); err != nil {
+		return "", fmt.Errorf("failed to create playlist: %w", err)
+	}
+	id := strconv.FormatInt(created.ID, 10)
+
+	if playlist.Description != "" {
+		if err := p.call(ctx, accessToken, http.MethodPost, "/playlist/"+id, url.Values{"description": {playlist.Description}}, nil); err != nil {
+			return "", fmt.Errorf("failed to set playlist description: %w", err)
+		}
+	}
+
+	return id, nil
+}
+
+// AddTracks appends the tracks to a playlist. Tracks without a Deezer
+// track ID and repeats are skipped.
+func (p *DeezerProvider) AddTracks(acct providers.Account, playlistID string, tracks []models.Track) error {
+	if err := p.sendTracks(acct, http.MethodPost, playlistID, tracks); err != nil {
+		return fmt.Errorf("failed to add tracks: %w", err)
+	}
+	return nil
+}
+
+// RemoveTracks removes the tracks from a playlist
+func (p *DeezerProvider) RemoveTracks(acct providers.Account, playlistID string, tracks []models.Track) error {
+	if err := p.sendTracks(acct, http.MethodDelete, playlistID, tracks); err != nil {
+		return fmt.Errorf("failed to remove tracks: %w", err)
+	}
+	return nil
+}
+
+// ReorderTracks puts the tracks of a playlist in the order of tracks.
+// Deezer takes the whole order in one request.
+func (p *DeezerProvider) ReorderTracks(acct providers.Account, playlistID string, tracks []models.Track) error {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return err
+	}
+
+	order := url.Values{"order": {strings.Join(deezerTrackIDs(tracks), ",")}}
+	if err := p.call(context.Background(), accessToken, http.MethodPost, "/playlist/"+playlistID+"/tracks", order, nil); err != nil {
+		return fmt.Errorf("failed to reorder tracks: %w", err)
+	}
+	return nil
+}
+
+// sendTracks sends the Deezer IDs of the tracks to a playlist's tracks
+// endpoint in batches of addTracksBatch
+func (p *DeezerProvider) sendTracks(acct providers.Account, method, playlistID string, tracks []models.Track) error {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	trackIDs := deezerTrackIDs(tracks)
+	for i := 0; i < len(trackIDs); i += addTracksBatch {
+		end := min(i+addTracksBatch, len(trackIDs))
+		songs := url.Values{"songs": {strings.Join(trackIDs[i:end], ",")}}
+		if err := p.call(ctx, accessToken, method, "/playlist/"+playlistID+"/tracks", songs, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveTracks adds the tracks to the user's favourite tracks. Tracks are
+// expected to carry Deezer track IDs; other tracks and repeats are skipped.
+func (p *DeezerProvider) SaveTracks(acct providers.Account, tracks []models.Track) error {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, id := range deezerTrackIDs(tracks) {
+		if err := p.call(ctx, accessToken, http.MethodPost, "/user/me/tracks", url.Values{"track_id": {id}}, nil); err != nil {
+			return fmt.Errorf("failed to add track %s to favourites: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// SearchTrack looks a track up by ISRC and searches the catalog by artist
+// and title. The ISRC match, if any, comes first.
+func (p *DeezerProvider) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var candidates []models.Track
+
+	if t.ISRC != "" {
+		var byISRC Track
+		err := p.call(ctx, accessToken, http.MethodGet, "/track/isrc:"+url.PathEscape(t.ISRC), nil, &byISRC)
+		var apiErr *APIError
+		switch {
+		case err == nil && byISRC.ID != 0:
+			candidates = append(candidates, toTrack(byISRC))
+		case err != nil && !(errors.As(err, &apiErr) && apiErr.Code == errorCodeNotFound):
+			return nil, fmt.Errorf("failed to look up ISRC: %w", err)
+		}
+	}
+
+	query := fmt.Sprintf(`artist:"%s" track:"%s"`, searchTerm(t.Artist), searchTerm(t.Title))
+	var results TracksResponse
+	if err := p.call(ctx, accessToken, http.MethodGet, "/search/track", url.Values{"q": {query}, "limit": {strconv.Itoa(searchLimit)}}, &results); err != nil {
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+	for _, item := range results.Data {
+		candidates = append(candidates, toTrack(item))
+	}
+
+	return candidates, nil
+}
+
+// GetSavedAlbums retrieves the user's favourite albums
+func (p *DeezerProvider) GetSavedAlbums(acct providers.Account) ([]models.Album, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var albums []models.Album
+
+	for index := 0; ; {
+		var page AlbumsResponse
+		if err := p.call(ctx, accessToken, http.MethodGet, "/user/me/albums", pageParams(index), &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch albums: %w", err)
+		}
+		for _, item := range page.Data {
+			albums = append(albums, toAlbum(item))
+		}
+
+		index += len(page.Data)
+		if len(page.Data) == 0 || index >= page.Total {
+			break
+		}
+	}
+
+	return albums, nil
+}
+
+// SearchAlbum looks an album up by UPC and searches the catalog by artist
+// and title. The UPC match, if any, comes first.
+func (p *DeezerProvider) SearchAlbum(acct providers.Account, a models.Album) ([]models.Album, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var candidates []models.Album
+
+	if a.UPC != "" {
+		var byUPC Album
+		err := p.call(ctx, accessToken, http.MethodGet, "/album/upc:"+url.PathEscape(a.UPC), nil, &byUPC)
+		var apiErr *APIError
+		switch {
+		case err == nil && byUPC.ID != 0:
+			candidates = append(candidates, toAlbum(byUPC))
+		case err != nil && !(errors.As(err, &apiErr) && apiErr.Code == errorCodeNotFound):
+			return nil, fmt.Errorf("failed to look up UPC: %w", err)
+		}
+	}
+
+	query := fmt.Sprintf(`artist:"%s" album:"%s"`, searchTerm(a.Artist), searchTerm(a.Title))
+	var results AlbumsResponse
+	if err := p.call(ctx, accessToken, http.MethodGet, "/search/album", url.Values{"q": {query}, "limit": {strconv.Itoa(searchLimit)}}, &results); err != nil {
+		return nil, fmt.Errorf("failed to search albums: %w", err)
+	}
+	for _, item := range results.Data {
+		candidates = append(candidates, toAlbum(item))
+	}
+
+	return candidates, nil
+}
+
+// SaveAlbums adds the albums to the user's favourite albums. Albums without
+// a Deezer album ID and repeats are skipped.
+func (p *DeezerProvider) SaveAlbums(acct providers.Account, albums []models.Album) error {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(albums))
+	for i, a := range albums {
+		ids[i] = a.ID
+	}
+
+	ctx := context.Background()
+	for _, id := range deezerIDs(ids) {
+		if err := p.call(ctx, accessToken, http.MethodPost, "/user/me/albums", url.Values{"album_id": {id}}, nil); err != nil {
+			return fmt.Errorf("failed to add album %s to favourites: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// GetFollowedArtists retrieves the user's favourite artists
+func (p *DeezerProvider) GetFollowedArtists(acct providers.Account) ([]models.Artist, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var artists []models.Artist
+
+	for index := 0; ; {
+		var page ArtistsResponse
+		if err := p.call(ctx, accessToken, http.MethodGet, "/user/me/artists", pageParams(index), &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch artists: %w", err)
+		}
+		for _, item := range page.Data {
+			artists = append(artists, toArtist(item))
+		}
+
+		index += len(page.Data)
+		if len(page.Data) == 0 || index >= page.Total {
+			break
+		}
+	}
+
+	return artists, nil
+}
+
+// SearchArtist searches the catalog for artists by name
+func (p *DeezerProvider) SearchArtist(acct providers.Account, a models.Artist) ([]models.Artist, error) {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`artist:"%s"`, searchTerm(a.Name))
+	var results ArtistsResponse
+	if err := p.call(context.Background(), accessToken, http.MethodGet, "/search/artist", url.Values{"q": {query}, "limit": {strconv.Itoa(searchLimit)}}, &results); err != nil {
+		return nil, fmt.Errorf("failed to search artists: %w", err)
+	}
+
+	candidates := make([]models.Artist, 0, len(results.Data))
+	for _, item := range results.Data {
+		candidates = append(candidates, toArtist(item))
+	}
+	return candidates, nil
+}
+
+// FollowArtists adds the artists to the user's favourite artists. Artists
+// without a Deezer artist ID and repeats are skipped.
+func (p *DeezerProvider) FollowArtists(acct providers.Account, artists []models.Artist) error {
+	accessToken, err := p.accessToken(acct)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(artists))
+	for i, a := range artists {
+		ids[i] = a.ID
+	}
+
+	ctx := context.Background()
+	for _, id := range deezerIDs(ids) {
+		if err := p.call(ctx, accessToken, http.MethodPost, "/user/me/artists", url.Values{"artist_id": {id}}, nil); err != nil {
+			return fmt.Errorf("failed to add artist %s to favourites: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// accessToken returns the access token of the account's connection
+func (p *DeezerProvider) accessToken(acct providers.Account) (string, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "deezer", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return "", fmt.Errorf("not connected: %w", err)
+	}
+	return conn.AccessToken, nil
+}
+
+// call sends an API request with the parameters in the query string and
+// decodes the JSON answer into v, which may be nil. Requests that hit the
+// rate limit are retried after a pause.
+func (p *DeezerProvider) call(ctx context.Context, accessToken, method, path string, params url.Values, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := p.do(ctx, accessToken, method, path, params, v)
+		var apiErr *APIError
+		if attempt >= maxQuotaRetries || !errors.As(err, &apiErr) || apiErr.Code != errorCodeQuota {
+			return err
+		}
+
+		select {
+		case <-time.After(p.quotaBackoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// do sends a single API request, see call
+func (p *DeezerProvider) do(ctx context.Context, accessToken, method, path string, params url.Values, v interface{}) error {
+	query := url.Values{"access_token": {accessToken}}
+	for key, values := range params {
+		query[key] = values
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("deezer API error: %s - %s", resp.Status, string(body))
+	}
+
+	// Errors come as {"error": {...}} with status 200; some successful
+	// calls answer a bare true
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		var errResp errorResponse
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+			return errResp.Error
+		}
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// deezerTrackIDs returns the Deezer track IDs of the tracks, without
+// repeats. Tracks without a numeric ID are skipped.
+func deezerTrackIDs(tracks []models.Track) []string {
+	ids := make([]string, len(tracks))
+	for i, t := range tracks {
+		ids[i] = t.ID
+	}
+	return deezerIDs(ids)
+}
+
+// deezerIDs returns the numeric IDs among ids, without repeats
+func deezerIDs(ids []string) []string {
+	var numeric []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		numeric = append(numeric, id)
+	}
+	return numeric
+}
+
+// pageParams returns the paging parameters of the page starting at index
+func pageParams(index int) url.Values {
+	return url.Values{"index": {strconv.Itoa(index)}, "limit": {strconv.Itoa(pageSize)}}
+}
+
+// searchTerm strips quotes, which would end a quoted term of the advanced
+// search syntax
+func searchTerm(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, `"`, ""))
+}
+
+// toTrack converts a Deezer track to the domain model
+func toTrack(t Track) models.Track {
+	return models.Track{
+		ID:       strconv.FormatInt(t.ID, 10),
+		Title:    t.Title,
+		Artist:   t.Artist.Name,
+		Album:    t.Album.Title,
+		Duration: t.Duration,
+		ISRC:     t.ISRC,
+	}
+}
+
+// toAlbum converts a Deezer album to the domain model
+func toAlbum(a Album) models.Album {
+	releaseDate, _ := time.Parse("2006-01-02", a.ReleaseDate)
+	return models.Album{
+		ID:          strconv.FormatInt(a.ID, 10),
+		Title:       a.Title,
+		Artist:      a.Artist.Name,
+		UPC:         a.UPC,
+		TrackCount:  a.NbTracks,
+		ReleaseDate: releaseDate,
+	}
+}
+
+// toArtist converts a Deezer artist to the domain model
+func toArtist(a Artist) models.Artist {
+	return models.Artist{ID: strconv.FormatInt(a.ID, 10), Name: a.Name}
+}
@@ -0,0 +1,594 @@
+package deezer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// fakeDeezer is an httptest stand-in for the Deezer API and OAuth endpoints
+type fakeDeezer struct {
+	mu        sync.Mutex
+	server    *httptest.Server
+	created   []string // titles of created playlists
+	described []string
+	added     []string // songs parameters of add-track requests
+	removed   []string // songs parameters of remove-track requests
+	ordered   []string // order parameters of reorder requests
+	loved     []string // track_id parameters of add-favourite requests
+	albums    []string // album_id parameters of add-favourite requests
+	artists   []string // artist_id parameters of add-favourite requests
+	quotaHits int      // requests to answer with a quota error first
+}
+
+func newFakeDeezer(t *testing.T) *fakeDeezer {
+	f := &fakeDeezer{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/oauth/access_token.php", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("code") != "good-code" || r.URL.Query().Get("secret") != "secret" {
+			w.Write([]byte("wrong code"))
+			return
+		}
+		w.Write([]byte(`{"access_token":"token-1","expires":"0"}`))
+	})
+
+	api := func(pattern string, handler func(w http.ResponseWriter, r *http.Request)) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("access_token") != "token-1" {
+				writeJSON(w, map[string]interface{}{"error": APIError{Type: "OAuthException", Message: "Invalid OAuth access token.", Code: 300}})
+				return
+			}
+			f.mu.Lock()
+			quota := f.quotaHits > 0
+			if quota {
+				f.quotaHits--
+			}
+			f.mu.Unlock()
+			if quota {
+				writeJSON(w, map[string]interface{}{"error": APIError{Type: "Exception", Message: "Quota limit exceeded", Code: errorCodeQuota}})
+				return
+			}
+			handler(w, r)
+		})
+	}
+
+	api("/user/me", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, User{ID: 42, Name: "Deezer Fan"})
+	})
+	api("/user/me/playlists", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			f.mu.Lock()
+			f.created = append(f.created, r.URL.Query().Get("title"))
+			f.mu.Unlock()
+			writeJSON(w, CreatedResponse{ID: 900})
+			return
+		}
+		all := []Playlist{
+			{ID: 1, Title: "Chill", Description: "Slow songs", NbTracks: 2},
+			{ID: 2, Title: "Workout", NbTracks: 30},
+			{ID: 3, Title: "Loved Tracks", NbTracks: 1, IsLovedTrack: true},
+		}
+		writeJSON(w, PlaylistsResponse{Data: page(all, r), Total: len(all)})
+	})
+	api("/user/me/tracks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			f.mu.Lock()
+			f.loved = append(f.loved, r.URL.Query().Get("track_id"))
+			f.mu.Unlock()
+			w.Write([]byte("true"))
+			return
+		}
+		all := []Track{{ID: 916424, Title: "Teardrop", Duration: 330, Artist: Artist{Name: "Massive Attack"}, Album: Album{Title: "Mezzanine"}}}
+		writeJSON(w, TracksResponse{Data: page(all, r), Total: len(all)})
+	})
+	api("/playlist/1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, Playlist{ID: 1, Title: "Chill", Description: "Slow songs", NbTracks: 2})
+	})
+	api("/playlist/1/tracks", func(w http.ResponseWriter, r *http.Request) {
+		all := []Track{
+			{ID: 3135556, Title: "Harder, Better, Faster, Stronger", Duration: 224, Artist: Artist{Name: "Daft Punk"}, Album: Album{Title: "Discovery"}},
+			{ID: 0, Title: "Removed"},
+			{ID: 916424, Title: "Teardrop", Duration: 330, Artist: Artist{Name: "Massive Attack"}, Album: Album{Title: "Mezzanine"}},
+		}
+		writeJSON(w, TracksResponse{Data: page(all, r), Total: len(all)})
+	})
+	api("/playlist/900", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.described = append(f.described, r.URL.Query().Get("description"))
+		f.mu.Unlock()
+		w.Write([]byte("true"))
+	})
+	api("/playlist/900/tracks", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		f.mu.Lock()
+		switch {
+		case r.Method == http.MethodDelete:
+			f.removed = append(f.removed, query.Get("songs"))
+		case query.Has("order"):
+			f.ordered = append(f.ordered, query.Get("order"))
+		default:
+			f.added = append(f.added, query.Get("songs"))
+		}
+		f.mu.Unlock()
+		w.Write([]byte("true"))
+	})
+	api("/track/", func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/track/") {
+		case "3135556", "isrc:GBDUW0000059":
+			writeJSON(w, Track{ID: 3135556, Title: "Harder, Better, Faster, Stronger", Duration: 224, ISRC: "GBDUW0000059", Artist: Artist{Name: "Daft Punk"}})
+		case "916424":
+			writeJSON(w, Track{ID: 916424, Title: "Teardrop", Duration: 330, ISRC: "GBAAA9800044", Artist: Artist{Name: "Massive Attack"}})
+		default:
+			writeJSON(w, map[string]interface{}{"error": APIError{Type: "DataException", Message: "no data", Code: errorCodeNotFound}})
+		}
+	})
+	api("/search/track", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != `artist:"Daft Punk" track:"One More Time"` {
+			writeJSON(w, TracksResponse{})
+			return
+		}
+		writeJSON(w, TracksResponse{Data: []Track{
+			{ID: 3135553, Title: "One More Time", Duration: 320, Artist: Artist{Name: "Daft Punk"}, Album: Album{Title: "Discovery"}},
+		}, Total: 1})
+	})
+	api("/user/me/albums", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			f.mu.Lock()
+			f.albums = append(f.albums, r.URL.Query().Get("album_id"))
+			f.mu.Unlock()
+			w.Write([]byte("true"))
+			return
+		}
+		all := []Album{
+			{ID: 302127, Title: "Discovery", NbTracks: 14, ReleaseDate: "2001-03-07", Artist: Artist{Name: "Daft Punk"}},
+			{ID: 301775, Title: "Mezzanine", NbTracks: 11, ReleaseDate: "1998-04-20", Artist: Artist{Name: "Massive Attack"}},
+		}
+		writeJSON(w, AlbumsResponse{Data: page(all, r), Total: len(all)})
+	})
+	api("/album/", func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/album/") {
+		case "upc:724384960650":
+			writeJSON(w, Album{ID: 302127, Title: "Discovery", UPC: "724384960650", NbTracks: 14, Artist: Artist{Name: "Daft Punk"}})
+		default:
+			writeJSON(w, map[string]interface{}{"error": APIError{Type: "DataException", Message: "no data", Code: errorCodeNotFound}})
+		}
+	})
+	api("/search/album", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != `artist:"Daft Punk" album:"Homework"` {
+			writeJSON(w, AlbumsResponse{})
+			return
+		}
+		writeJSON(w, AlbumsResponse{Data: []Album{
+			{ID: 301800, Title: "Homework", NbTracks: 16, Artist: Artist{Name: "Daft Punk"}},
+		}, Total: 1})
+	})
+	api("/user/me/artists", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			f.mu.Lock()
+			f.artists = append(f.artists, r.URL.Query().Get("artist_id"))
+			f.mu.Unlock()
+			w.Write([]byte("true"))
+			return
+		}
+		all := []Artist{{ID: 27, Name: "Daft Punk"}, {ID: 2381, Name: "Massive Attack"}}
+		writeJSON(w, ArtistsResponse{Data: page(all, r), Total: len(all)})
+	})
+	api("/search/artist", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != `artist:"Daft Punk"` {
+			writeJSON(w, ArtistsResponse{})
+			return
+		}
+		writeJSON(w, ArtistsResponse{Data: []Artist{{ID: 27, Name: "Daft Punk"}}, Total: 1})
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// page returns the slice of items selected by the index and limit parameters
+func page[T any](items []T, r *http.Request) []T {
+	index, _ := strconv.Atoi(r.URL.Query().Get("index"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = len(items)
+	}
+	if index > len(items) {
+		index = len(items)
+	}
+	end := index + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[index:end]
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// newTestProvider returns a provider talking to f with user123 connected
+func newTestProvider(t *testing.T, f *fakeDeezer) *DeezerProvider {
+	store := storage.NewInMemoryConnectionStore()
+	p := NewDeezerProvider("app-id", "secret", "http://localhost/auth/deezer/callback", store)
+	p.apiURL = f.server.URL
+	p.connectURL = f.server.URL
+	p.quotaBackoff = 0
+
+	err := store.Save(&models.Connection{
+		Provider:       "deezer",
+		UserID:         "user123",
+		ExternalUserID: "42",
+		AccessToken:    "token-1",
+		Connected:      true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to save connection: %v", err)
+	}
+	return p
+}
+
+func TestDeezerProvider_Name(t *testing.T) {
+	provider := NewDeezerProvider("app-id", "secret", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	if provider.Name() != "Deezer" {
+		t.Errorf("Expected provider name 'Deezer', got '%s'", provider.Name())
+	}
+}
+
+func TestDeezerProvider_AuthURL(t *testing.T) {
+	provider := NewDeezerProvider("app-id", "secret", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	u, err := url.Parse(provider.AuthURL("test-state"))
+	if err != nil {
+		t.Fatalf("AuthURL() is not a URL: %v", err)
+	}
+
+	if u.Host != "connect.deezer.com" || u.Path != "/oauth/auth.php" {
+		t.Errorf("Expected the Deezer authorization endpoint, got %s", u)
+	}
+	q := u.Query()
+	if q.Get("app_id") != "app-id" || q.Get("state") != "test-state" || q.Get("redirect_uri") != "http://localhost/callback" {
+		t.Errorf("AuthURL() is missing parameters: %s", u.RawQuery)
+	}
+	if !strings.Contains(q.Get("perms"), "offline_access") || !strings.Contains(q.Get("perms"), "manage_library") {
+		t.Errorf("Expected offline_access and manage_library permissions, got %q", q.Get("perms"))
+	}
+}
+
+func TestDeezerProvider_ExchangeAndSaveConnection(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	if _, err := provider.Exchange(context.Background(), "bad-code"); err == nil {
+		t.Error("Exchange() should fail for invalid codes")
+	}
+
+	token, err := provider.Exchange(context.Background(), "good-code")
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	if token.AccessToken != "token-1" || !token.Expiry.IsZero() {
+		t.Errorf("Expected a non-expiring token-1, got %q expiring %v", token.AccessToken, token.Expiry)
+	}
+
+	if err := provider.SaveConnection(context.Background(), token, "user456"); err != nil {
+		t.Fatalf("SaveConnection() failed: %v", err)
+	}
+	accounts, err := provider.Accounts("user456")
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("Expected one account, got %v (%v)", accounts, err)
+	}
+	if accounts[0].ExternalUserID != "42" || accounts[0].ExternalUserName != "Deezer Fan" {
+		t.Errorf("Expected the Deezer profile to be stored, got %+v", accounts[0])
+	}
+}
+
+func TestDeezerProvider_Authenticate(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	if err := provider.Authenticate(providers.Account{UserID: "user123"}); err != nil {
+		t.Errorf("Authenticate() failed: %v", err)
+	}
+	if err := provider.Authenticate(providers.Account{UserID: "someone-else"}); err == nil {
+		t.Error("Authenticate() should fail when not connected")
+	}
+
+	conn, _ := provider.connectionStore.Get("deezer", "user123", "42")
+	conn.ExpiresAt = time.Now().Add(-time.Minute)
+	provider.connectionStore.Update(conn)
+	if err := provider.Authenticate(providers.Account{UserID: "user123"}); err == nil {
+		t.Error("Authenticate() should fail for expired tokens")
+	}
+}
+
+func TestDeezerProvider_GetPlaylists(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	playlists, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	if len(playlists) != 3 {
+		t.Fatalf("Expected 3 playlists, got %d", len(playlists))
+	}
+	if playlists[0].ID != providers.LibraryPlaylistID || playlists[0].Name != "Favourite tracks" || playlists[0].TrackCount != 1 {
+		t.Errorf("Expected the favourite tracks first, got %+v", playlists[0])
+	}
+	if playlists[1].ID != "1" || playlists[1].Name != "Chill" || playlists[1].TrackCount != 2 || playlists[1].Provider != "Deezer" {
+		t.Errorf("Unexpected playlist: %+v", playlists[1])
+	}
+}
+
+func TestDeezerProvider_ExportPlaylist(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+	f.quotaHits = 2
+
+	playlist, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, "1")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if playlist.Name != "Chill" || playlist.Description != "Slow songs" {
+		t.Errorf("Unexpected playlist metadata: %q / %q", playlist.Name, playlist.Description)
+	}
+	if len(playlist.Tracks) != 2 || playlist.TrackCount != 2 {
+		t.Fatalf("Expected 2 tracks without the removed one, got %d", len(playlist.Tracks))
+	}
+
+	want := models.Track{ID: "3135556", Title: "Harder, Better, Faster, Stronger", Artist: "Daft Punk", Album: "Discovery", Duration: 224, ISRC: "GBDUW0000059"}
+	if playlist.Tracks[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, playlist.Tracks[0])
+	}
+	if playlist.Tracks[1].ISRC != "GBAAA9800044" {
+		t.Errorf("Expected the ISRC of every track, got %q", playlist.Tracks[1].ISRC)
+	}
+}
+
+func TestDeezerProvider_ExportPlaylist_QuotaExhausted(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+	f.quotaHits = maxQuotaRetries + 1
+
+	_, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, "1")
+	if err == nil || !strings.Contains(err.Error(), "Quota limit exceeded") {
+		t.Errorf("Expected the quota error after %d retries, got %v", maxQuotaRetries, err)
+	}
+}
+
+func TestDeezerProvider_ImportPlaylist(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	tracks := []models.Track{{ID: "3135556"}, {ID: "not-a-deezer-id"}, {ID: "916424"}, {ID: "3135556"}}
+	for i := 0; i < addTracksBatch; i++ {
+		tracks = append(tracks, models.Track{ID: strconv.Itoa(1000 + i)})
+	}
+
+	err := provider.ImportPlaylist(providers.Account{UserID: "user123"}, models.Playlist{Name: "Imported", Description: "From PlayPort", Tracks: tracks})
+	if err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+
+	if len(f.created) != 1 || f.created[0] != "Imported" {
+		t.Errorf("Expected one playlist named Imported, got %v", f.created)
+	}
+	if len(f.described) != 1 || f.described[0] != "From PlayPort" {
+		t.Errorf("Expected the description to be set, got %v", f.described)
+	}
+	if len(f.added) != 2 {
+		t.Fatalf("Expected tracks to be added in 2 batches, got %d", len(f.added))
+	}
+	if !strings.HasPrefix(f.added[0], "3135556,916424,") {
+		t.Errorf("Expected repeated and non-Deezer IDs to be skipped, got %q", f.added[0])
+	}
+	if n := strings.Count(f.added[0], ",") + 1; n != addTracksBatch {
+		t.Errorf("Expected %d tracks in the first batch, got %d", addTracksBatch, n)
+	}
+}
+
+func TestDeezerProvider_EditPlaylist(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	id, err := provider.CreatePlaylist(acct, models.Playlist{Name: "Synced"})
+	if err != nil {
+		t.Fatalf("CreatePlaylist() failed: %v", err)
+	}
+	if id != "900" || len(f.created) != 1 || len(f.described) != 0 {
+		t.Errorf("Expected playlist 900 without a description, got %q, %v, %v", id, f.created, f.described)
+	}
+
+	if err := provider.AddTracks(acct, id, []models.Track{{ID: "1"}, {ID: "2"}}); err != nil {
+		t.Fatalf("AddTracks() failed: %v", err)
+	}
+	if err := provider.RemoveTracks(acct, id, []models.Track{{ID: "3"}, {ID: "not-a-deezer-id"}}); err != nil {
+		t.Fatalf("RemoveTracks() failed: %v", err)
+	}
+	if err := provider.ReorderTracks(acct, id, []models.Track{{ID: "2"}, {ID: "1"}}); err != nil {
+		t.Fatalf("ReorderTracks() failed: %v", err)
+	}
+
+	if len(f.added) != 1 || f.added[0] != "1,2" {
+		t.Errorf("Expected tracks 1,2 to be added, got %v", f.added)
+	}
+	if len(f.removed) != 1 || f.removed[0] != "3" {
+		t.Errorf("Expected track 3 to be removed, got %v", f.removed)
+	}
+	if len(f.ordered) != 1 || f.ordered[0] != "2,1" {
+		t.Errorf("Expected the order 2,1, got %v", f.ordered)
+	}
+}
+
+func TestDeezerProvider_SearchTrack(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	candidates, err := provider.SearchTrack(acct, models.Track{Title: "Harder Better Faster Stronger", Artist: "Daft Punk", ISRC: "GBDUW0000059"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) == 0 || candidates[0].ID != "3135556" || candidates[0].ISRC != "GBDUW0000059" {
+		t.Errorf("Expected the ISRC match first, got %+v", candidates)
+	}
+
+	candidates, err = provider.SearchTrack(acct, models.Track{Title: `One More "Time"`, Artist: "Daft Punk", ISRC: "XX0000000000"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "3135553" {
+		t.Errorf("Expected the search result for an unknown ISRC, got %+v", candidates)
+	}
+}
+
+func TestDeezerProvider_InvalidToken(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	conn, _ := provider.connectionStore.Get("deezer", "user123", "42")
+	conn.AccessToken = "revoked"
+	provider.connectionStore.Update(conn)
+
+	_, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err == nil || !strings.Contains(err.Error(), "OAuthException") {
+		t.Errorf("Expected the API error to be reported, got %v", err)
+	}
+}
+
+func TestDeezerProvider_ExportPlaylist_Library(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	playlist, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, providers.LibraryPlaylistID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if playlist.ID != providers.LibraryPlaylistID || playlist.Name != "Favourite tracks" {
+		t.Errorf("Unexpected playlist metadata: %q / %q", playlist.ID, playlist.Name)
+	}
+	if len(playlist.Tracks) != 1 || playlist.Tracks[0].ISRC != "GBAAA9800044" {
+		t.Errorf("Expected the favourite track with its ISRC, got %+v", playlist.Tracks)
+	}
+}
+
+func TestDeezerProvider_SaveTracks(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	tracks := []models.Track{{ID: "3135556"}, {ID: "spotify:track:x"}, {ID: "916424"}, {ID: "3135556"}}
+	if err := provider.SaveTracks(providers.Account{UserID: "user123"}, tracks); err != nil {
+		t.Fatalf("SaveTracks() failed: %v", err)
+	}
+
+	if strings.Join(f.loved, ",") != "3135556,916424" {
+		t.Errorf("Expected each Deezer track to be added once, got %v", f.loved)
+	}
+	if len(f.created) != 0 {
+		t.Errorf("Expected no playlist to be created, got %v", f.created)
+	}
+}
+
+func TestDeezerProvider_GetSavedAlbums(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	albums, err := provider.GetSavedAlbums(providers.Account{UserID: "user123"})
+	if err != nil {
+		t.Fatalf("GetSavedAlbums() failed: %v", err)
+	}
+
+	if len(albums) != 2 {
+		t.Fatalf("Expected 2 albums, got %d", len(albums))
+	}
+	if albums[0].ID != "302127" || albums[0].Artist != "Daft Punk" || albums[0].TrackCount != 14 {
+		t.Errorf("Unexpected first album %+v", albums[0])
+	}
+	if albums[1].ReleaseDate.Year() != 1998 {
+		t.Errorf("Expected the release date to be parsed, got %v", albums[1].ReleaseDate)
+	}
+}
+
+func TestDeezerProvider_SearchAlbum(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	candidates, err := provider.SearchAlbum(acct, models.Album{Title: "Discovery", Artist: "Daft Punk", UPC: "724384960650"})
+	if err != nil {
+		t.Fatalf("SearchAlbum() failed: %v", err)
+	}
+	if len(candidates) == 0 || candidates[0].ID != "302127" || candidates[0].UPC != "724384960650" {
+		t.Errorf("Expected the UPC match first, got %+v", candidates)
+	}
+
+	candidates, err = provider.SearchAlbum(acct, models.Album{Title: `"Homework"`, Artist: "Daft Punk", UPC: "000000000000"})
+	if err != nil {
+		t.Fatalf("SearchAlbum() failed: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "301800" {
+		t.Errorf("Expected the search result for an unknown UPC, got %+v", candidates)
+	}
+}
+
+func TestDeezerProvider_SaveAlbums(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+
+	albums := []models.Album{{ID: "302127"}, {ID: "spotify-album"}, {ID: "301775"}, {ID: "302127"}}
+	if err := provider.SaveAlbums(providers.Account{UserID: "user123"}, albums); err != nil {
+		t.Fatalf("SaveAlbums() failed: %v", err)
+	}
+
+	if strings.Join(f.albums, ",") != "302127,301775" {
+		t.Errorf("Expected each Deezer album to be added once, got %v", f.albums)
+	}
+}
+
+func TestDeezerProvider_Artists(t *testing.T) {
+	f := newFakeDeezer(t)
+	provider := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	artists, err := provider.GetFollowedArtists(acct)
+	if err != nil {
+		t.Fatalf("GetFollowedArtists() failed: %v", err)
+	}
+	if len(artists) != 2 || artists[0].ID != "27" || artists[1].Name != "Massive Attack" {
+		t.Errorf("Unexpected artists %+v", artists)
+	}
+
+	candidates, err := provider.SearchArtist(acct, models.Artist{Name: "Daft Punk"})
+	if err != nil {
+		t.Fatalf("SearchArtist() failed: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "27" {
+		t.Errorf("Unexpected search results %+v", candidates)
+	}
+
+	if err := provider.FollowArtists(acct, []models.Artist{{ID: "27"}, {ID: "27"}, {ID: "x"}}); err != nil {
+		t.Fatalf("FollowArtists() failed: %v", err)
+	}
+	if strings.Join(f.artists, ",") != "27" {
+		t.Errorf("Expected the artist to be followed once, got %v", f.artists)
+	}
+}
@@ -0,0 +1,114 @@
+package deezer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Deezer API error codes PlayPort handles
+const (
+	errorCodeQuota    = 4   // too many requests
+	errorCodeNotFound = 800 // no data
+)
+
+// APIError is the error object Deezer returns, with status 200, instead of
+// a result
+type APIError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("deezer API error %d (%s): %s", e.Code, e.Type, e.Message)
+}
+
+// errorResponse wraps an APIError
+type errorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// TokenResponse is the answer of the OAuth access token endpoint. Expires
+// is in seconds and 0 for tokens granted with offline_access; Deezer sends
+// it as a number or a string.
+type TokenResponse struct {
+	AccessToken string      `json:"access_token"`
+	Expires     json.Number `json:"expires"`
+}
+
+// User represents a Deezer user
+type User struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// PlaylistsResponse is a page of the user's playlists
+type PlaylistsResponse struct {
+	Data  []Playlist `json:"data"`
+	Total int        `json:"total"`
+}
+
+// Playlist represents a Deezer playlist. The user's favourite tracks are
+// also listed as a playlist, flagged with IsLovedTrack.
+type Playlist struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	NbTracks     int    `json:"nb_tracks"`
+	IsLovedTrack bool   `json:"is_loved_track"`
+	Creator      User   `json:"creator"`
+}
+
+// TracksResponse is a page of tracks, from a playlist or a search
+type TracksResponse struct {
+	Data  []Track `json:"data"`
+	Total int     `json:"total"`
+}
+
+// Track represents a Deezer track. Only the full track object has the
+// ISRC; tracks in lists leave it empty.
+type Track struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Duration int    `json:"duration"` // in seconds
+	ISRC     string `json:"isrc"`
+	Artist   Artist `json:"artist"`
+	Album    Album  `json:"album"`
+}
+
+// Artist represents a Deezer artist
+type Artist struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Album represents a Deezer album. Only the full album object has the
+// UPC; albums in lists leave it empty.
+type Album struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	UPC         string `json:"upc"`
+	NbTracks    int    `json:"nb_tracks"`
+	ReleaseDate string `json:"release_date"` // YYYY-MM-DD
+	Artist      Artist `json:"artist"`
+}
+
+// AlbumsResponse is a page of albums, from the user's favourites or a
+// search
+type AlbumsResponse struct {
+	Data  []Album `json:"data"`
+	Total int     `json:"total"`
+}
+
+// ArtistsResponse is a page of artists, from the user's favourites or a
+// search
+type ArtistsResponse struct {
+	Data  []Artist `json:"data"`
+	Total int      `json:"total"`
+}
+
+// CreatedResponse is the answer to creating an object
+type CreatedResponse struct {
+	ID int64 `json:"id"`
+}
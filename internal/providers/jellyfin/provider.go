@@ -0,0 +1,480 @@
+package jellyfin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+	"github.com/JanikSachs/PlayPort/internal/version"
+)
+
+const (
+	// pageSize is the number of items requested per page
+	pageSize = 100
+
+	// addItemsBatch is the number of tracks added to a playlist per request,
+	// which keeps the query string short
+	addItemsBatch = 100
+
+	// searchLimit is the number of candidates a track search returns
+	searchLimit = 10
+
+	// ticksPerSecond converts RunTimeTicks to seconds
+	ticksPerSecond = 10_000_000
+
+	// favouritesName is the name of the favourite tracks playlist
+	favouritesName = "Favourites"
+)
+
+// JellyfinProvider implements the Provider interface for a Jellyfin server.
+//
+// Accounts are linked with a username and either a password, which is
+// exchanged for an access token and not stored, or an API key created in
+// the server's dashboard. The connection's AccessToken holds the token and
+// ExternalUserID the Jellyfin user ID.
+type JellyfinProvider struct {
+	serverURL       string
+	connectionStore storage.ConnectionStore
+	httpClient      *http.Client
+}
+
+// NewJellyfinProvider creates a new Jellyfin provider for the server at
+// serverURL
+func NewJellyfinProvider(serverURL string, connectionStore storage.ConnectionStore) *JellyfinProvider {
+	return &JellyfinProvider{
+		serverURL:       strings.TrimRight(serverURL, "/"),
+		connectionStore: connectionStore,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the provider's name
+func (p *JellyfinProvider) Name() string {
+	return "Jellyfin"
+}
+
+// Authenticate checks if the user has a valid connection
+func (p *JellyfinProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "jellyfin", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return fmt.Errorf("not connected to Jellyfin: %w", err)
+	}
+
+	if !conn.Connected {
+		return fmt.Errorf("Jellyfin connection not active")
+	}
+
+	return nil
+}
+
+// Accounts returns the user's connected Jellyfin accounts, oldest first
+func (p *JellyfinProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("jellyfin", userID)
+}
+
+// Connect logs in with the password, or looks the user up with the API key
+// in creds.Token, and saves the connection
+func (p *JellyfinProvider) Connect(ctx context.Context, userID string, creds providers.Credentials) (*models.Connection, error) {
+	if creds.Username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+
+	var user User
+	var token string
+	switch {
+	case creds.Token != "":
+		var users []User
+		if err := p.call(ctx, creds.Token, userID, http.MethodGet, "/Users", nil, &users); err != nil {
+			return nil, p.loginError(err)
+		}
+		for _, u := range users {
+			if strings.EqualFold(u.Name, creds.Username) {
+				user = u
+			}
+		}
+		if user.ID == "" {
+			return nil, fmt.Errorf("no Jellyfin user named %q", creds.Username)
+		}
+		token = creds.Token
+	case creds.Password != "":
+		var result AuthenticationResult
+		login := AuthenticateRequest{Username: creds.Username, Pw: creds.Password}
+		if err := p.call(ctx, "", userID, http.MethodPost, "/Users/AuthenticateByName", login, &result); err != nil {
+			return nil, p.loginError(err)
+		}
+		user, token = result.User, result.AccessToken
+	default:
+		return nil, fmt.Errorf("password or API key is required")
+	}
+
+	conn := &models.Connection{
+		Provider:         "jellyfin",
+		UserID:           userID,
+		ExternalUserID:   user.ID,
+		ExternalUserName: user.Name,
+		AccessToken:      token,
+		Connected:        true,
+	}
+	if err := p.connectionStore.Save(conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// GetPlaylists retrieves the account's favourite tracks and all playlists
+// of the account
+func (p *JellyfinProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	itemsPath := "/Users/" + url.PathEscape(conn.ExternalUserID) + "/Items"
+
+	// An empty page is enough to learn the total
+	var favourites ItemsResult
+	query := favouritesQuery()
+	query.Set("Limit", "0")
+	if err := p.call(ctx, conn.AccessToken, conn.UserID, http.MethodGet, itemsPath+"?"+query.Encode(), nil, &favourites); err != nil {
+		return nil, fmt.Errorf("failed to fetch favourites: %w", err)
+	}
+	library := favouritesPlaylist()
+	library.TrackCount = favourites.TotalRecordCount
+
+	params := url.Values{
+		"IncludeItemTypes": {"Playlist"},
+		"Recursive":        {"true"},
+		"Fields":           {"ChildCount,Overview"},
+	}
+	items, err := p.items(ctx, conn, itemsPath, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+	}
+
+	playlists := []models.Playlist{library}
+	for _, item := range items {
+		playlists = append(playlists, toPlaylist(item))
+	}
+	return playlists, nil
+}
+
+// ExportPlaylist exports a specific playlist by ID, or the favourite tracks
+// for the library playlist. Items other than audio, such as videos, are
+// skipped.
+func (p *JellyfinProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	ctx := context.Background()
+
+	if id == providers.LibraryPlaylistID {
+		items, err := p.items(ctx, conn, "/Users/"+url.PathEscape(conn.ExternalUserID)+"/Items", favouritesQuery())
+		if err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch favourites: %w", err)
+		}
+		playlist := favouritesPlaylist()
+		for _, item := range items {
+			playlist.Tracks = append(playlist.Tracks, toTrack(item))
+		}
+		playlist.TrackCount = len(playlist.Tracks)
+		return playlist, nil
+	}
+
+	var detail Item
+	if err := p.call(ctx, conn.AccessToken, conn.UserID, http.MethodGet, "/Users/"+url.PathEscape(conn.ExternalUserID)+"/Items/"+url.PathEscape(id), nil, &detail); err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+	}
+
+	params := url.Values{
+		"UserId": {conn.ExternalUserID},
+		"Fields": {"ProviderIds"},
+	}
+	items, err := p.items(ctx, conn, "/Playlists/"+url.PathEscape(id)+"/Items", params)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch tracks: %w", err)
+	}
+
+	playlist := toPlaylist(detail)
+	for _, item := range items {
+		if item.Type == "Audio" {
+			playlist.Tracks = append(playlist.Tracks, toTrack(item))
+		}
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// ImportPlaylist creates an audio playlist and adds the tracks to it.
+// Tracks are expected to carry item IDs of this server, as matched by
+// SearchTrack; tracks without ID and repeats are skipped. Jellyfin playlists
+// have no description to carry over.
+func (p *JellyfinProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	itemIDs := uniqueIDs(playlist.Tracks)
+
+	// The first batch goes along with the creation
+	first := itemIDs
+	if len(first) > addItemsBatch {
+		first = first[:addItemsBatch]
+	}
+	create := CreatePlaylistRequest{
+		Name:      playlist.Name,
+		IDs:       first,
+		UserID:    conn.ExternalUserID,
+		MediaType: "Audio",
+	}
+	var created CreatePlaylistResult
+	if err := p.call(ctx, conn.AccessToken, conn.UserID, http.MethodPost, "/Playlists", create, &created); err != nil {
+		return fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	for i := len(first); i < len(itemIDs); i += addItemsBatch {
+		end := i + addItemsBatch
+		if end > len(itemIDs) {
+			end = len(itemIDs)
+		}
+		params := url.Values{
+			"Ids":    {strings.Join(itemIDs[i:end], ",")},
+			"UserId": {conn.ExternalUserID},
+		}
+		if err := p.call(ctx, conn.AccessToken, conn.UserID, http.MethodPost, "/Playlists/"+url.PathEscape(created.ID)+"/Items?"+params.Encode(), nil, nil); err != nil {
+			return fmt.Errorf("failed to add tracks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SaveTracks marks the tracks as favourites, one request per track. Tracks
+// are expected to carry item IDs of this server; tracks without ID and
+// repeats are skipped.
+func (p *JellyfinProvider) SaveTracks(acct providers.Account, tracks []models.Track) error {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	for _, id := range uniqueIDs(tracks) {
+		path := "/Users/" + url.PathEscape(conn.ExternalUserID) + "/FavoriteItems/" + url.PathEscape(id)
+		if err := p.call(ctx, conn.AccessToken, conn.UserID, http.MethodPost, path, nil, nil); err != nil {
+			return fmt.Errorf("failed to mark track %s as favourite: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// SearchTrack searches the server's music library for the title. Jellyfin
+// only searches names, so the matcher compares the artists of the results.
+func (p *JellyfinProvider) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"SearchTerm":       {t.Title},
+		"IncludeItemTypes": {"Audio"},
+		"Recursive":        {"true"},
+		"Fields":           {"ProviderIds"},
+		"Limit":            {strconv.Itoa(searchLimit)},
+	}
+	var result ItemsResult
+	path := "/Users/" + url.PathEscape(conn.ExternalUserID) + "/Items?" + params.Encode()
+	if err := p.call(context.Background(), conn.AccessToken, conn.UserID, http.MethodGet, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+
+	var candidates []models.Track
+	for _, item := range result.Items {
+		candidates = append(candidates, toTrack(item))
+	}
+	return candidates, nil
+}
+
+// items fetches all pages of an item query
+func (p *JellyfinProvider) items(ctx context.Context, conn *models.Connection, path string, params url.Values) ([]Item, error) {
+	var all []Item
+	for start := 0; ; {
+		query := url.Values{"StartIndex": {strconv.Itoa(start)}, "Limit": {strconv.Itoa(pageSize)}}
+		for key, values := range params {
+			query[key] = values
+		}
+
+		var page ItemsResult
+		if err := p.call(ctx, conn.AccessToken, conn.UserID, http.MethodGet, path+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Items...)
+
+		start += len(page.Items)
+		if len(page.Items) == 0 || start >= page.TotalRecordCount {
+			return all, nil
+		}
+	}
+}
+
+// connection returns the account's connection
+func (p *JellyfinProvider) connection(acct providers.Account) (*models.Connection, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "jellyfin", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return nil, fmt.Errorf("not connected: %w", err)
+	}
+	return conn, nil
+}
+
+// loginError turns a failed login call into the error shown to the user
+func (p *JellyfinProvider) loginError(err error) error {
+	if statusErr, ok := err.(*statusError); ok && statusErr.code == http.StatusUnauthorized {
+		return fmt.Errorf("wrong username, password or API key")
+	}
+	return fmt.Errorf("failed to reach Jellyfin server: %w", err)
+}
+
+// statusError is returned for answers with an unexpected HTTP status
+type statusError struct {
+	code int
+	body string
+}
+
+// Error implements error
+func (e *statusError) Error() string {
+	return fmt.Sprintf("jellyfin API error: %d %s - %s", e.code, http.StatusText(e.code), e.body)
+}
+
+// call sends a JSON request and decodes the JSON answer into v, which may
+// be nil. The local user ID names the device, since Jellyfin keeps one
+// session per user and device.
+func (p *JellyfinProvider) call(ctx context.Context, token, localUserID, method, path string, body, v interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.serverURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization(token, localUserID))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &statusError{code: resp.StatusCode, body: string(respBody)}
+	}
+
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// authorization returns the MediaBrowser authorization header, which
+// identifies PlayPort and carries the token, if any
+func authorization(token, localUserID string) string {
+	header := fmt.Sprintf(`MediaBrowser Client="PlayPort", Device="PlayPort", DeviceId="playport-%s", Version="%s"`, url.QueryEscape(localUserID), version.Version)
+	if token != "" {
+		header += fmt.Sprintf(`, Token="%s"`, token)
+	}
+	return header
+}
+
+// favouritesQuery returns the item query of the favourite tracks
+func favouritesQuery() url.Values {
+	return url.Values{
+		"Filters":          {"IsFavorite"},
+		"IncludeItemTypes": {"Audio"},
+		"Recursive":        {"true"},
+		"Fields":           {"ProviderIds"},
+	}
+}
+
+// favouritesPlaylist returns the playlist of the favourite tracks, without
+// tracks
+func favouritesPlaylist() models.Playlist {
+	return models.Playlist{
+		ID:        providers.LibraryPlaylistID,
+		Name:      favouritesName,
+		Provider:  "Jellyfin",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// uniqueIDs returns the IDs of the tracks without repeats, skipping tracks
+// without ID
+func uniqueIDs(tracks []models.Track) []string {
+	var ids []string
+	seen := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		if t.ID == "" || seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// toPlaylist converts a Jellyfin playlist to the domain model, without
+// tracks
+func toPlaylist(item Item) models.Playlist {
+	return models.Playlist{
+		ID:          item.ID,
+		Name:        item.Name,
+		Description: item.Overview,
+		TrackCount:  item.ChildCount,
+		Provider:    "Jellyfin",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// toTrack converts a Jellyfin audio item to the domain model
+func toTrack(item Item) models.Track {
+	artist := strings.Join(item.Artists, ", ")
+	if artist == "" {
+		artist = item.AlbumArtist
+	}
+	return models.Track{
+		ID:       item.ID,
+		Title:    item.Name,
+		Artist:   artist,
+		Album:    item.Album,
+		Duration: int(item.RunTimeTicks / ticksPerSecond),
+		ISRC:     item.ProviderIDs["ISRC"],
+	}
+}
@@ -0,0 +1,344 @@
+package jellyfin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// fakeJellyfin is an httptest stand-in for a Jellyfin server with the user
+// "alice" (ID u1), password "sesame" and API key "key-1"
+type fakeJellyfin struct {
+	mu          sync.Mutex
+	server      *httptest.Server
+	created     []CreatePlaylistRequest
+	added       []string // Ids parameters of add item requests
+	searchTerms []string
+	pageStarts  []string // StartIndex parameters of playlist item requests
+	favourited  []string
+}
+
+func newFakeJellyfin(t *testing.T) *fakeJellyfin {
+	f := &fakeJellyfin{}
+	mux := http.NewServeMux()
+
+	authorized := func(w http.ResponseWriter, r *http.Request) bool {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "MediaBrowser ") || !strings.Contains(auth, `Client="PlayPort"`) {
+			http.Error(w, "bad authorization header", http.StatusBadRequest)
+			return false
+		}
+		if !strings.Contains(auth, `Token="session-1"`) && !strings.Contains(auth, `Token="key-1"`) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+
+	mux.HandleFunc("POST /Users/AuthenticateByName", func(w http.ResponseWriter, r *http.Request) {
+		var login AuthenticateRequest
+		json.NewDecoder(r.Body).Decode(&login)
+		if login.Username != "alice" || login.Pw != "sesame" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, AuthenticationResult{User: User{ID: "u1", Name: "alice"}, AccessToken: "session-1"})
+	})
+	mux.HandleFunc("GET /Users", func(w http.ResponseWriter, r *http.Request) {
+		if authorized(w, r) {
+			writeJSON(w, []User{{ID: "u0", Name: "admin"}, {ID: "u1", Name: "alice"}})
+		}
+	})
+	mux.HandleFunc("GET /Users/u1/Items", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		q := r.URL.Query()
+		if term := q.Get("SearchTerm"); term != "" {
+			f.mu.Lock()
+			f.searchTerms = append(f.searchTerms, term)
+			f.mu.Unlock()
+			var items []Item
+			if term == "Teardrop" && q.Get("IncludeItemTypes") == "Audio" {
+				items = []Item{{ID: "a1", Name: "Teardrop", Type: "Audio", Artists: []string{"Massive Attack"}, RunTimeTicks: 3300000000, ProviderIDs: map[string]string{"ISRC": "GBAAA9800044"}}}
+			}
+			writeJSON(w, ItemsResult{Items: items, TotalRecordCount: len(items)})
+			return
+		}
+		if q.Get("Filters") == "IsFavorite" && q.Get("IncludeItemTypes") == "Audio" {
+			all := []Item{{ID: "a2", Name: "Angel", Type: "Audio", Album: "Mezzanine", AlbumArtist: "Massive Attack", RunTimeTicks: 3790000000}}
+			var items []Item
+			if q.Get("Limit") != "0" {
+				items = all
+			}
+			writeJSON(w, ItemsResult{Items: items, TotalRecordCount: len(all)})
+			return
+		}
+		if q.Get("IncludeItemTypes") != "Playlist" {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		items := []Item{{ID: "p1", Name: "Evening", Type: "Playlist", Overview: "Quiet", ChildCount: 3}}
+		writeJSON(w, ItemsResult{Items: items, TotalRecordCount: len(items)})
+	})
+	mux.HandleFunc("GET /Users/u1/Items/p1", func(w http.ResponseWriter, r *http.Request) {
+		if authorized(w, r) {
+			writeJSON(w, Item{ID: "p1", Name: "Evening", Type: "Playlist", Overview: "Quiet", ChildCount: 3})
+		}
+	})
+	mux.HandleFunc("GET /Playlists/p1/Items", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		f.mu.Lock()
+		f.pageStarts = append(f.pageStarts, r.URL.Query().Get("StartIndex"))
+		f.mu.Unlock()
+
+		// One item per page to exercise paging
+		all := []Item{
+			{ID: "a1", Name: "Teardrop", Type: "Audio", Album: "Mezzanine", Artists: []string{"Massive Attack"}, RunTimeTicks: 3300000000, ProviderIDs: map[string]string{"ISRC": "GBAAA9800044"}},
+			{ID: "v1", Name: "Teardrop (Video)", Type: "MusicVideo"},
+			{ID: "a2", Name: "Angel", Type: "Audio", Album: "Mezzanine", AlbumArtist: "Massive Attack", RunTimeTicks: 3790000000},
+		}
+		start, _ := strconv.Atoi(r.URL.Query().Get("StartIndex"))
+		var items []Item
+		if start < len(all) {
+			items = all[start : start+1]
+		}
+		writeJSON(w, ItemsResult{Items: items, TotalRecordCount: len(all), StartIndex: start})
+	})
+	mux.HandleFunc("POST /Users/u1/FavoriteItems/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		f.mu.Lock()
+		f.favourited = append(f.favourited, r.PathValue("id"))
+		f.mu.Unlock()
+		writeJSON(w, map[string]bool{"IsFavorite": true})
+	})
+	mux.HandleFunc("POST /Playlists", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		var create CreatePlaylistRequest
+		json.NewDecoder(r.Body).Decode(&create)
+		f.mu.Lock()
+		f.created = append(f.created, create)
+		f.mu.Unlock()
+		writeJSON(w, CreatePlaylistResult{ID: "new1"})
+	})
+	mux.HandleFunc("POST /Playlists/new1/Items", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		f.mu.Lock()
+		f.added = append(f.added, r.URL.Query().Get("Ids"))
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// newTestProvider returns a provider talking to f with alice connected for
+// user123 by password
+func newTestProvider(t *testing.T, f *fakeJellyfin) *JellyfinProvider {
+	p := NewJellyfinProvider(f.server.URL+"/", storage.NewInMemoryConnectionStore())
+
+	if _, err := p.Connect(context.Background(), "user123", providers.Credentials{Username: "alice", Password: "sesame"}); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	return p
+}
+
+func TestJellyfinProvider_Name(t *testing.T) {
+	provider := NewJellyfinProvider("http://localhost:8096", storage.NewInMemoryConnectionStore())
+
+	if provider.Name() != "Jellyfin" {
+		t.Errorf("Expected provider name 'Jellyfin', got '%s'", provider.Name())
+	}
+}
+
+func TestJellyfinProvider_Connect(t *testing.T) {
+	f := newFakeJellyfin(t)
+
+	tests := []struct {
+		name      string
+		creds     providers.Credentials
+		wantToken string
+		wantErr   string
+	}{
+		{"password", providers.Credentials{Username: "alice", Password: "sesame"}, "session-1", ""},
+		{"API key", providers.Credentials{Username: "Alice", Token: "key-1"}, "key-1", ""},
+		{"wrong password", providers.Credentials{Username: "alice", Password: "open"}, "", "wrong username"},
+		{"wrong API key", providers.Credentials{Username: "alice", Token: "key-2"}, "", "wrong username"},
+		{"unknown user", providers.Credentials{Username: "carol", Token: "key-1"}, "", "no Jellyfin user"},
+		{"no secret", providers.Credentials{Username: "alice"}, "", "password or API key is required"},
+		{"no username", providers.Credentials{Password: "sesame"}, "", "username is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewJellyfinProvider(f.server.URL, storage.NewInMemoryConnectionStore())
+
+			conn, err := provider.Connect(context.Background(), "user123", tt.creds)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if err := provider.Authenticate(providers.Account{UserID: "user123"}); err == nil {
+					t.Error("Failed connects should not save a connection")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Connect() failed: %v", err)
+			}
+
+			if conn.ExternalUserID != "u1" || conn.ExternalUserName != "alice" || conn.AccessToken != tt.wantToken {
+				t.Errorf("Unexpected connection %+v", conn)
+			}
+			if _, err := provider.GetPlaylists(providers.Account{UserID: "user123"}); err != nil {
+				t.Errorf("GetPlaylists() failed with stored token: %v", err)
+			}
+		})
+	}
+}
+
+func TestJellyfinProvider_GetPlaylists(t *testing.T) {
+	f := newFakeJellyfin(t)
+	provider := newTestProvider(t, f)
+
+	playlists, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	if len(playlists) != 2 {
+		t.Fatalf("Expected the favourites and 1 playlist, got %d", len(playlists))
+	}
+	if playlists[0].ID != providers.LibraryPlaylistID || playlists[0].Name != "Favourites" || playlists[0].TrackCount != 1 {
+		t.Errorf("Expected the favourites first, got %+v", playlists[0])
+	}
+	if playlists[1].ID != "p1" || playlists[1].Name != "Evening" || playlists[1].Description != "Quiet" || playlists[1].TrackCount != 3 || playlists[1].Provider != "Jellyfin" {
+		t.Errorf("Unexpected playlist %+v", playlists[1])
+	}
+}
+
+func TestJellyfinProvider_ExportPlaylist(t *testing.T) {
+	f := newFakeJellyfin(t)
+	provider := newTestProvider(t, f)
+
+	playlist, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, "p1")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if strings.Join(f.pageStarts, ",") != "0,1,2" {
+		t.Errorf("Expected pages starting at 0, 1 and 2, got %v", f.pageStarts)
+	}
+	if len(playlist.Tracks) != 2 {
+		t.Fatalf("Expected 2 tracks without the video, got %d", len(playlist.Tracks))
+	}
+	want := models.Track{ID: "a1", Title: "Teardrop", Artist: "Massive Attack", Album: "Mezzanine", Duration: 330, ISRC: "GBAAA9800044"}
+	if playlist.Tracks[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, playlist.Tracks[0])
+	}
+	if playlist.Tracks[1].Artist != "Massive Attack" || playlist.Tracks[1].Duration != 379 {
+		t.Errorf("Expected the album artist as fallback, got %+v", playlist.Tracks[1])
+	}
+	if playlist.TrackCount != 2 {
+		t.Errorf("Expected track count 2, got %d", playlist.TrackCount)
+	}
+
+	if _, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, "missing"); err == nil {
+		t.Error("Expected an error for a missing playlist")
+	}
+}
+
+func TestJellyfinProvider_ExportPlaylist_Favourites(t *testing.T) {
+	f := newFakeJellyfin(t)
+	provider := newTestProvider(t, f)
+
+	favourites, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, providers.LibraryPlaylistID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	want := models.Track{ID: "a2", Title: "Angel", Artist: "Massive Attack", Album: "Mezzanine", Duration: 379}
+	if favourites.Name != "Favourites" || len(favourites.Tracks) != 1 || favourites.Tracks[0] != want {
+		t.Errorf("Unexpected favourites %+v", favourites)
+	}
+}
+
+func TestJellyfinProvider_SaveTracks(t *testing.T) {
+	f := newFakeJellyfin(t)
+	provider := newTestProvider(t, f)
+
+	tracks := []models.Track{{ID: "a1"}, {ID: "a2"}, {ID: "a1"}, {Title: "Unmatched"}}
+	if err := provider.SaveTracks(providers.Account{UserID: "user123"}, tracks); err != nil {
+		t.Fatalf("SaveTracks() failed: %v", err)
+	}
+
+	if len(f.favourited) != 2 || f.favourited[0] != "a1" || f.favourited[1] != "a2" {
+		t.Errorf("Expected a1 and a2 to be favourited once each, got %v", f.favourited)
+	}
+}
+
+func TestJellyfinProvider_ImportPlaylist(t *testing.T) {
+	f := newFakeJellyfin(t)
+	provider := newTestProvider(t, f)
+
+	playlist := models.Playlist{Name: "Imported"}
+	for i := 0; i < 250; i++ {
+		playlist.Tracks = append(playlist.Tracks, models.Track{ID: "a" + strconv.Itoa(i)})
+	}
+	playlist.Tracks = append(playlist.Tracks, models.Track{ID: "a0"}, models.Track{Title: "Unmatched"})
+
+	if err := provider.ImportPlaylist(providers.Account{UserID: "user123"}, playlist); err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+
+	if len(f.created) != 1 {
+		t.Fatalf("Expected 1 created playlist, got %d", len(f.created))
+	}
+	created := f.created[0]
+	if created.Name != "Imported" || created.UserID != "u1" || created.MediaType != "Audio" || len(created.IDs) != 100 {
+		t.Errorf("Unexpected create request %+v", created)
+	}
+	if len(f.added) != 2 {
+		t.Fatalf("Expected 2 add requests, got %d", len(f.added))
+	}
+	if ids := strings.Split(f.added[1], ","); len(ids) != 50 || ids[49] != "a249" {
+		t.Errorf("Expected the last 50 tracks in order, got %d ending with %s", len(ids), ids[len(ids)-1])
+	}
+}
+
+func TestJellyfinProvider_SearchTrack(t *testing.T) {
+	f := newFakeJellyfin(t)
+	provider := newTestProvider(t, f)
+
+	candidates, err := provider.SearchTrack(providers.Account{UserID: "user123"}, models.Track{Title: "Teardrop", Artist: "Massive Attack"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "a1" || candidates[0].ISRC != "GBAAA9800044" || candidates[0].Duration != 330 {
+		t.Errorf("Unexpected candidates %+v", candidates)
+	}
+}
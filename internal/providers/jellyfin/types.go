@@ -0,0 +1,54 @@
+package jellyfin
+
+// AuthenticateRequest is the body of a password login
+type AuthenticateRequest struct {
+	Username string `json:"Username"`
+	Pw       string `json:"Pw"`
+}
+
+// AuthenticationResult is the answer of a password login
+type AuthenticationResult struct {
+	User        User   `json:"User"`
+	AccessToken string `json:"AccessToken"`
+}
+
+// User represents a Jellyfin user
+type User struct {
+	ID   string `json:"Id"`
+	Name string `json:"Name"`
+}
+
+// ItemsResult is a page of library items
+type ItemsResult struct {
+	Items            []Item `json:"Items"`
+	TotalRecordCount int    `json:"TotalRecordCount"`
+	StartIndex       int    `json:"StartIndex"`
+}
+
+// Item is a library item: a playlist or an audio track. RunTimeTicks counts
+// 100 nanosecond ticks.
+type Item struct {
+	ID           string            `json:"Id"`
+	Name         string            `json:"Name"`
+	Type         string            `json:"Type"`
+	Overview     string            `json:"Overview"`
+	ChildCount   int               `json:"ChildCount"`
+	Album        string            `json:"Album"`
+	AlbumArtist  string            `json:"AlbumArtist"`
+	Artists      []string          `json:"Artists"`
+	RunTimeTicks int64             `json:"RunTimeTicks"`
+	ProviderIDs  map[string]string `json:"ProviderIds"`
+}
+
+// CreatePlaylistRequest is the body of a playlist creation
+type CreatePlaylistRequest struct {
+	Name      string   `json:"Name"`
+	IDs       []string `json:"Ids"`
+	UserID    string   `json:"UserId"`
+	MediaType string   `json:"MediaType"`
+}
+
+// CreatePlaylistResult is the answer of a playlist creation
+type CreatePlaylistResult struct {
+	ID string `json:"Id"`
+}
@@ -0,0 +1,430 @@
+// Package localmusic implements a provider for a local music collection.
+// It reads the tags of the audio files under a directory and offers its
+// folders and M3U files as playlists.
+package localmusic
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/JanikSachs/PlayPort/internal/audiotags"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/playlistfile"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/providers/m3u"
+)
+
+// Playlist ID prefixes for the two kinds of playlists
+const (
+	folderPrefix = "folder:"
+	m3uPrefix    = "m3u:"
+)
+
+// PlaylistDir is the directory under the music directory that imported
+// playlists are written to
+const PlaylistDir = "Playlists"
+
+// rescanInterval is how long a scan of the collection is reused. Rescans
+// only read the tags of new or changed files.
+const rescanInterval = 30 * time.Second
+
+// localFile is an audio file of the collection with its tags
+type localFile struct {
+	rel     string // slash-separated path below the music directory
+	modTime time.Time
+	size    int64
+	tags    audiotags.Tags
+}
+
+// Provider implements the Provider interface on a directory of audio
+// files. The collection is shared by all PlayPort users.
+type Provider struct {
+	dir string
+
+	mu        sync.Mutex
+	files     map[string]*localFile // by rel
+	playlists []string              // rel paths of M3U files
+	scannedAt time.Time
+}
+
+// NewProvider creates a provider for the music collection under dir
+func NewProvider(dir string) *Provider {
+	return &Provider{dir: dir, files: make(map[string]*localFile)}
+}
+
+// Name returns the provider's name
+func (p *Provider) Name() string {
+	return "Local Music"
+}
+
+// Authenticate checks that the music directory is usable
+func (p *Provider) Authenticate(acct providers.Account) error {
+	info, err := os.Stat(p.dir)
+	if err != nil {
+		return fmt.Errorf("music directory not available: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("music directory %s is not a directory", p.dir)
+	}
+	return nil
+}
+
+// GetPlaylists lists every folder that directly contains audio files, and
+// every M3U file in the collection
+func (p *Provider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	files, m3us, err := p.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, f := range files {
+		counts[pathDir(f.rel)]++
+	}
+
+	playlists := make([]models.Playlist, 0, len(counts)+len(m3us))
+	for dir, count := range counts {
+		playlists = append(playlists, models.Playlist{
+			ID:         folderPrefix + dir,
+			Name:       p.folderName(dir),
+			Provider:   p.Name(),
+			TrackCount: count,
+		})
+	}
+	for _, rel := range m3us {
+		playlists = append(playlists, models.Playlist{
+			ID:       m3uPrefix + rel,
+			Name:     strings.TrimSuffix(pathBase(rel), filepath.Ext(rel)),
+			Provider: p.Name(),
+		})
+	}
+
+	sort.Slice(playlists, func(i, j int) bool { return playlists[i].ID < playlists[j].ID })
+	return playlists, nil
+}
+
+// ExportPlaylist returns a folder's tracks in disc and track order, or the
+// entries of an M3U file. M3U entries that point at files of the
+// collection carry those files' tags.
+func (p *Provider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	files, _, err := p.scan()
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	switch {
+	case strings.HasPrefix(id, folderPrefix):
+		dir := strings.TrimPrefix(id, folderPrefix)
+		if dir != "." && !filepath.IsLocal(filepath.FromSlash(dir)) {
+			return models.Playlist{}, fmt.Errorf("invalid folder playlist ID: %s", id)
+		}
+
+		var inDir []*localFile
+		for _, f := range files {
+			if pathDir(f.rel) == dir {
+				inDir = append(inDir, f)
+			}
+		}
+		if len(inDir) == 0 {
+			return models.Playlist{}, fmt.Errorf("folder playlist not found: %s", dir)
+		}
+		sort.Slice(inDir, func(i, j int) bool {
+			a, b := inDir[i].tags, inDir[j].tags
+			if a.DiscNumber != b.DiscNumber {
+				return a.DiscNumber < b.DiscNumber
+			}
+			if a.TrackNumber != b.TrackNumber {
+				return a.TrackNumber < b.TrackNumber
+			}
+			return inDir[i].rel < inDir[j].rel
+		})
+
+		playlist := models.Playlist{ID: id, Name: p.folderName(dir), Provider: p.Name()}
+		for _, f := range inDir {
+			playlist.Tracks = append(playlist.Tracks, p.track(f))
+		}
+		playlist.TrackCount = len(playlist.Tracks)
+		return playlist, nil
+
+	case strings.HasPrefix(id, m3uPrefix):
+		rel := strings.TrimPrefix(id, m3uPrefix)
+		if !filepath.IsLocal(filepath.FromSlash(rel)) || !isPlaylistFile(rel) {
+			return models.Playlist{}, fmt.Errorf("invalid M3U playlist ID: %s", id)
+		}
+		return p.readM3U(id, rel, files)
+
+	default:
+		return models.Playlist{}, fmt.Errorf("invalid playlist ID: %s", id)
+	}
+}
+
+// ImportPlaylist writes a playlist as a new .m3u8 file in the Playlists
+// folder of the collection, with paths relative to it. Transfers only pass
+// on tracks SearchTrack found or the user pinned, so every entry names a
+// local file; tracks that do not are left out.
+func (p *Provider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	files, _, err := p.scan()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(p.dir, PlaylistDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create playlist directory: %w", err)
+	}
+
+	tracks := make([]models.Track, 0, len(playlist.Tracks))
+	for _, t := range playlist.Tracks {
+		if f, ok := files[t.ID]; ok {
+			t.Location = filepath.Join(p.dir, filepath.FromSlash(f.rel))
+		}
+		if t.Location == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, t.Location); err == nil {
+			t.Location = filepath.ToSlash(rel)
+		}
+		tracks = append(tracks, t)
+	}
+	playlist.Tracks = tracks
+
+	f, err := m3u.CreateFile(dir, playlist.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create playlist file: %w", err)
+	}
+	if err := playlistfile.WriteM3U(f, playlist); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write playlist file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.scannedAt = time.Time{} // list the new playlist right away
+	p.mu.Unlock()
+	return nil
+}
+
+// SearchTrack finds files of the collection with the same ISRC, or with
+// the same title and artist once case, punctuation and bracketed
+// annotations are ignored
+func (p *Provider) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	files, _, err := p.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	title, artist := searchKey(t.Title), searchKey(t.Artist)
+	var byISRC, byName []models.Track
+	for _, f := range files {
+		track := p.track(f)
+		switch {
+		case t.ISRC != "" && strings.EqualFold(track.ISRC, t.ISRC):
+			byISRC = append(byISRC, track)
+		case searchKey(track.Title) == title && (artist == "" || strings.Contains(searchKey(track.Artist), artist)):
+			byName = append(byName, track)
+		}
+	}
+
+	sortTracks(byISRC)
+	sortTracks(byName)
+	return append(byISRC, byName...), nil
+}
+
+// readM3U reads an M3U file of the collection, resolving relative entries
+// against its directory
+func (p *Provider) readM3U(id, rel string, files map[string]*localFile) (models.Playlist, error) {
+	path := filepath.Join(p.dir, filepath.FromSlash(rel))
+	f, err := os.Open(path)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("M3U playlist not found: %s", rel)
+	}
+	defer f.Close()
+
+	playlist, err := playlistfile.ReadM3U(f, strings.TrimSuffix(pathBase(rel), filepath.Ext(rel)))
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	for i, t := range playlist.Tracks {
+		if strings.Contains(t.Location, "://") {
+			continue
+		}
+		location := filepath.FromSlash(t.Location)
+		if !filepath.IsAbs(location) {
+			location = filepath.Join(filepath.Dir(path), location)
+		}
+
+		if r, err := filepath.Rel(p.dir, location); err == nil && filepath.IsLocal(r) {
+			if local, ok := files[filepath.ToSlash(r)]; ok {
+				playlist.Tracks[i] = p.track(local)
+				continue
+			}
+		}
+		playlist.Tracks[i].Location = location
+		playlist.Tracks[i].ID = location
+	}
+
+	playlist.ID = id
+	playlist.Provider = p.Name()
+	return playlist, nil
+}
+
+// track converts a file of the collection to the domain model. Untagged
+// files are named after the file, and tracks without an artist fall back
+// to the album artist.
+func (p *Provider) track(f *localFile) models.Track {
+	track := models.Track{
+		ID:       f.rel,
+		Title:    f.tags.Title,
+		Artist:   f.tags.Artist,
+		Album:    f.tags.Album,
+		ISRC:     f.tags.ISRC,
+		Duration: int(f.tags.Duration.Round(time.Second) / time.Second),
+		Location: filepath.Join(p.dir, filepath.FromSlash(f.rel)),
+	}
+	if track.Artist == "" {
+		track.Artist = f.tags.AlbumArtist
+	}
+	if track.Title == "" {
+		track.Title = strings.TrimSuffix(pathBase(f.rel), filepath.Ext(f.rel))
+	}
+	return track
+}
+
+// scan walks the music directory, reading the tags of files that are new
+// or changed since the last scan. Hidden files and directories are skipped.
+// It returns a snapshot of the audio files by rel and the M3U files.
+func (p *Provider) scan() (map[string]*localFile, []string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.scannedAt) < rescanInterval {
+		return p.files, p.playlists, nil
+	}
+
+	files := make(map[string]*localFile, len(p.files))
+	var playlists []string
+	err := filepath.WalkDir(p.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == p.dir {
+				return err
+			}
+			log.Printf("Local music: skipping %s: %v", path, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != p.dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(p.dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if isPlaylistFile(rel) {
+			playlists = append(playlists, rel)
+			return nil
+		}
+		if !audiotags.Supported(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed during the scan
+		}
+		if old, ok := p.files[rel]; ok && old.modTime.Equal(info.ModTime()) && old.size == info.Size() {
+			files[rel] = old
+			return nil
+		}
+
+		tags, err := audiotags.Read(path)
+		if err != nil {
+			log.Printf("Local music: reading tags failed: %v", err)
+		}
+		files[rel] = &localFile{rel: rel, modTime: info.ModTime(), size: info.Size(), tags: tags}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan music directory: %w", err)
+	}
+
+	sort.Strings(playlists)
+	p.files, p.playlists, p.scannedAt = files, playlists, time.Now()
+	return files, playlists, nil
+}
+
+// folderName names a folder playlist by its path, or the music directory's
+// name for files at the top level
+func (p *Provider) folderName(dir string) string {
+	if dir == "." {
+		return filepath.Base(p.dir)
+	}
+	return dir
+}
+
+// searchKey reduces a title or artist name to a comparable form: lower
+// case letters and digits only, without bracketed annotations
+func searchKey(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sortTracks orders search results by path so results are stable
+func sortTracks(tracks []models.Track) {
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].ID < tracks[j].ID })
+}
+
+// pathDir returns the directory of a slash-separated path of the
+// collection, "." for files at the top level
+func pathDir(rel string) string {
+	if i := strings.LastIndexByte(rel, '/'); i >= 0 {
+		return rel[:i]
+	}
+	return "."
+}
+
+// pathBase returns the last element of a slash-separated path
+func pathBase(rel string) string {
+	return rel[strings.LastIndexByte(rel, '/')+1:]
+}
+
+// isPlaylistFile reports whether name has an M3U extension
+func isPlaylistFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".m3u" || ext == ".m3u8"
+}
@@ -0,0 +1,225 @@
+package localmusic
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+// mp3 builds an MP3 file holding only an ID3v2.3 tag with the given frames
+func mp3(frames map[string]string) []byte {
+	var body []byte
+	for id, text := range frames {
+		body = append(body, id...)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(text)+1))
+		body = append(body, 0, 0, 3) // flags, UTF-8
+		body = append(body, text...)
+	}
+	n := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+	return append(header, body...)
+}
+
+// setupCollection creates a small music collection and returns its directory
+func setupCollection(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"Queen/A Night at the Opera/11 Bohemian Rhapsody.mp3": mp3(map[string]string{
+			"TIT2": "Bohemian Rhapsody", "TPE1": "Queen", "TALB": "A Night at the Opera",
+			"TRCK": "11/12", "TSRC": "GBUM71029604", "TLEN": "354000",
+		}),
+		"Queen/A Night at the Opera/02 Lazing on a Sunday Afternoon.mp3": mp3(map[string]string{
+			"TIT2": "Lazing on a Sunday Afternoon", "TPE2": "Queen", "TRCK": "2",
+		}),
+		"Queen/A Night at the Opera/cover.jpg": []byte("jpeg"),
+		"untagged.mp3":                         {},
+		".trash/Old Song.mp3":                  mp3(map[string]string{"TIT2": "Old Song"}),
+		"Playlists/Road Trip.m3u8": []byte("#EXTM3U\n" +
+			"../Queen/A Night at the Opera/11 Bohemian Rhapsody.mp3\n" +
+			"#EXTINF:200,Someone - Missing\n" +
+			"../missing.mp3\n"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestProvider_GetPlaylists(t *testing.T) {
+	dir := setupCollection(t)
+	p := NewProvider(dir)
+	acct := providers.Account{UserID: "user123"}
+
+	if err := p.Authenticate(acct); err != nil {
+		t.Fatalf("Authenticate() failed: %v", err)
+	}
+
+	playlists, err := p.GetPlaylists(acct)
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	got := map[string]models.Playlist{}
+	for _, pl := range playlists {
+		got[pl.ID] = pl
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 playlists, got %+v", playlists)
+	}
+	if pl := got["folder:Queen/A Night at the Opera"]; pl.TrackCount != 2 || pl.Name != "Queen/A Night at the Opera" {
+		t.Errorf("Unexpected album folder playlist %+v", pl)
+	}
+	if pl := got["folder:."]; pl.TrackCount != 1 || pl.Name != filepath.Base(dir) {
+		t.Errorf("Unexpected top-level folder playlist %+v", pl)
+	}
+	if pl := got["m3u:Playlists/Road Trip.m3u8"]; pl.Name != "Road Trip" {
+		t.Errorf("Unexpected M3U playlist %+v", pl)
+	}
+}
+
+func TestProvider_ExportPlaylist(t *testing.T) {
+	dir := setupCollection(t)
+	p := NewProvider(dir)
+	acct := providers.Account{}
+
+	album, err := p.ExportPlaylist(acct, "folder:Queen/A Night at the Opera")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+	if len(album.Tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(album.Tracks))
+	}
+	first, second := album.Tracks[0], album.Tracks[1]
+	if first.Title != "Lazing on a Sunday Afternoon" || first.Artist != "Queen" {
+		t.Errorf("Expected tracks in track number order with the album artist as fallback, got %+v", first)
+	}
+	if second.ISRC != "GBUM71029604" || second.Duration != 354 || second.ID != "Queen/A Night at the Opera/11 Bohemian Rhapsody.mp3" {
+		t.Errorf("Unexpected track %+v", second)
+	}
+	if want := filepath.Join(dir, "Queen", "A Night at the Opera", "11 Bohemian Rhapsody.mp3"); second.Location != want {
+		t.Errorf("Expected location %q, got %q", want, second.Location)
+	}
+
+	top, err := p.ExportPlaylist(acct, "folder:.")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+	if len(top.Tracks) != 1 || top.Tracks[0].Title != "untagged" {
+		t.Errorf("Untagged files should be named after the file, got %+v", top.Tracks)
+	}
+
+	trip, err := p.ExportPlaylist(acct, "m3u:Playlists/Road Trip.m3u8")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+	if len(trip.Tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(trip.Tracks))
+	}
+	if trip.Tracks[0].ISRC != "GBUM71029604" {
+		t.Errorf("M3U entries of the collection should carry their tags, got %+v", trip.Tracks[0])
+	}
+	if missing := trip.Tracks[1]; missing.Title != "Missing" || missing.Location != filepath.Join(dir, "missing.mp3") {
+		t.Errorf("Unknown entries should keep their M3U metadata, got %+v", missing)
+	}
+
+	for _, id := range []string{"folder:../elsewhere", "m3u:../../etc/passwd.m3u", "m3u:Queen/cover.jpg", "album:x", "folder:Nope"} {
+		if _, err := p.ExportPlaylist(acct, id); err == nil {
+			t.Errorf("ExportPlaylist(%q) should fail", id)
+		}
+	}
+}
+
+func TestProvider_SearchTrack(t *testing.T) {
+	p := NewProvider(setupCollection(t))
+	acct := providers.Account{}
+
+	byISRC, err := p.SearchTrack(acct, models.Track{Title: "Something Else", ISRC: "gbum71029604"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(byISRC) != 1 || byISRC[0].Title != "Bohemian Rhapsody" {
+		t.Errorf("Expected an ISRC match, got %+v", byISRC)
+	}
+
+	byName, err := p.SearchTrack(acct, models.Track{Title: "Bohemian Rhapsody (Remastered 2011)", Artist: "QUEEN"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(byName) != 1 || byName[0].ID != "Queen/A Night at the Opera/11 Bohemian Rhapsody.mp3" {
+		t.Errorf("Expected a title and artist match, got %+v", byName)
+	}
+
+	none, err := p.SearchTrack(acct, models.Track{Title: "Old Song"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("Hidden folders should not be searched, got %+v", none)
+	}
+}
+
+func TestProvider_ImportPlaylist(t *testing.T) {
+	dir := setupCollection(t)
+	p := NewProvider(dir)
+	acct := providers.Account{}
+
+	found, err := p.SearchTrack(acct, models.Track{Title: "Bohemian Rhapsody", Artist: "Queen"})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("SearchTrack() = %v, %v", found, err)
+	}
+	pinned := models.Track{ID: "Queen/A Night at the Opera/02 Lazing on a Sunday Afternoon.mp3", Title: "Lazing"}
+	unmatched := models.Track{ID: "spotify-123", Title: "Elsewhere"}
+
+	playlist := models.Playlist{Name: "Queen: Best Of", Tracks: []models.Track{found[0], pinned, unmatched}}
+	if err := p.ImportPlaylist(acct, playlist); err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "Playlists", "Queen_ Best Of.m3u8"))
+	if err != nil {
+		t.Fatalf("Expected the playlist file to be written: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"../Queen/A Night at the Opera/11 Bohemian Rhapsody.mp3\n",
+		"../Queen/A Night at the Opera/02 Lazing on a Sunday Afternoon.mp3\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected %q in:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "Elsewhere") {
+		t.Errorf("Tracks without a local file should be left out:\n%s", content)
+	}
+
+	playlists, err := p.GetPlaylists(acct)
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+	listed := false
+	for _, pl := range playlists {
+		listed = listed || pl.ID == "m3u:Playlists/Queen_ Best Of.m3u8"
+	}
+	if !listed {
+		t.Errorf("The imported playlist should be listed right away, got %+v", playlists)
+	}
+}
+
+func TestProvider_AuthenticateMissingDir(t *testing.T) {
+	p := NewProvider(filepath.Join(t.TempDir(), "missing"))
+	if err := p.Authenticate(providers.Account{}); err == nil {
+		t.Error("Authenticate() should fail without a music directory")
+	}
+}
@@ -0,0 +1,239 @@
+// Package m3u implements a file-based provider that keeps playlists as
+// extended M3U files, for users with local music libraries.
+package m3u
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/playlistfile"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+// M3UProvider implements the Provider interface on a directory of M3U files.
+// Each user's playlists live in a subdirectory named after their user ID.
+type M3UProvider struct {
+	dir string
+}
+
+// NewM3UProvider creates a provider that stores playlists under dir
+func NewM3UProvider(dir string) *M3UProvider {
+	return &M3UProvider{dir: dir}
+}
+
+// Name returns the provider's name
+func (p *M3UProvider) Name() string {
+	return "M3U"
+}
+
+// Authenticate checks that the playlist directory is usable
+func (p *M3UProvider) Authenticate(acct providers.Account) error {
+	info, err := os.Stat(p.dir)
+	if err != nil {
+		return fmt.Errorf("M3U directory not available: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("M3U directory %s is not a directory", p.dir)
+	}
+	return nil
+}
+
+// GetPlaylists lists the M3U files of the user, by file name
+func (p *M3UProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	dir, err := p.userDir(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []models.Playlist{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list M3U playlists: %w", err)
+	}
+
+	playlists := []models.Playlist{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isPlaylistFile(entry.Name()) {
+			continue
+		}
+		playlist, err := p.readPlaylist(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		playlist.Tracks = nil
+		playlists = append(playlists, playlist)
+	}
+
+	sort.Slice(playlists, func(i, j int) bool { return playlists[i].ID < playlists[j].ID })
+	return playlists, nil
+}
+
+// ExportPlaylist reads an M3U file. Relative track paths are resolved
+// against the playlist's directory so they stay valid elsewhere.
+func (p *M3UProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	dir, err := p.userDir(acct)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	if !validFileName(id) || !isPlaylistFile(id) {
+		return models.Playlist{}, fmt.Errorf("invalid M3U playlist ID: %s", id)
+	}
+	return p.readPlaylist(dir, id)
+}
+
+// ImportPlaylist writes a playlist as a new UTF-8 .m3u8 file named after it.
+// Tracks inside the user's directory are written with relative paths.
+func (p *M3UProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	dir, err := p.userDir(acct)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create M3U directory: %w", err)
+	}
+
+	tracks := make([]models.Track, len(playlist.Tracks))
+	for i, t := range playlist.Tracks {
+		if filepath.IsAbs(t.Location) {
+			if rel, err := filepath.Rel(dir, t.Location); err == nil && filepath.IsLocal(rel) {
+				t.Location = filepath.ToSlash(rel)
+			}
+		}
+		tracks[i] = t
+	}
+	playlist.Tracks = tracks
+
+	f, err := CreateFile(dir, playlist.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create M3U file: %w", err)
+	}
+	if err := playlistfile.WriteM3U(f, playlist); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write M3U file: %w", err)
+	}
+	return f.Close()
+}
+
+// readPlaylist reads the M3U file name in dir
+func (p *M3UProvider) readPlaylist(dir, name string) (models.Playlist, error) {
+	path := filepath.Join(dir, name)
+	f, err := os.Open(path)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("M3U playlist not found: %s", name)
+	}
+	defer f.Close()
+
+	playlist, err := playlistfile.ReadM3U(f, strings.TrimSuffix(name, filepath.Ext(name)))
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	for i, t := range playlist.Tracks {
+		if strings.Contains(t.Location, "://") || filepath.IsAbs(t.Location) {
+			continue
+		}
+		// WriteM3U leaves "Artist - Title" placeholders for tracks without a
+		// file; they are matched by name rather than resolved to a path
+		location := filepath.Join(dir, filepath.FromSlash(t.Location))
+		if !isTrackFile(location) {
+			playlist.Tracks[i].Location = ""
+			continue
+		}
+		playlist.Tracks[i].Location = location
+		playlist.Tracks[i].ID = location
+	}
+
+	playlist.ID = name
+	playlist.Provider = p.Name()
+	if info, err := f.Stat(); err == nil {
+		playlist.CreatedAt = info.ModTime()
+		playlist.UpdatedAt = info.ModTime()
+	}
+	return playlist, nil
+}
+
+// userDir returns the directory holding the user's playlists
+func (p *M3UProvider) userDir(acct providers.Account) (string, error) {
+	if acct.UserID == "" {
+		return p.dir, nil
+	}
+	if !validFileName(acct.UserID) {
+		return "", fmt.Errorf("invalid user ID for M3U storage: %s", acct.UserID)
+	}
+	return filepath.Join(p.dir, acct.UserID), nil
+}
+
+// audioExtensions are the extensions of audio files a playlist entry may
+// point to
+var audioExtensions = map[string]bool{
+	".mp3": true, ".flac": true, ".m4a": true, ".mp4": true, ".m4b": true, ".aac": true,
+	".ogg": true, ".oga": true, ".opus": true, ".wav": true, ".aif": true, ".aiff": true,
+	".wma": true, ".ape": true, ".wv": true, ".alac": true, ".dsf": true,
+}
+
+// isTrackFile reports whether a resolved playlist entry is a real file
+// path: an existing file, or a name with an audio extension
+func isTrackFile(path string) bool {
+	if audioExtensions[strings.ToLower(filepath.Ext(path))] {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// isPlaylistFile reports whether name has an M3U extension
+func isPlaylistFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".m3u" || ext == ".m3u8"
+}
+
+// validFileName reports whether name is a single path element inside a directory
+func validFileName(name string) bool {
+	return filepath.IsLocal(name) && filepath.Base(name) == name
+}
+
+// fileName turns a playlist name into a safe file name without extension
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		name = "Playlist " + time.Now().Format("2006-01-02 150405")
+	}
+	return name
+}
+
+// CreateFile creates a new .m3u8 file in dir named after a playlist. Names
+// are made safe for the file system, and existing files are never replaced.
+func CreateFile(dir, playlistName string) (*os.File, error) {
+	return createUnique(dir, fileName(playlistName), ".m3u8")
+}
+
+// createUnique creates base+ext in dir, adding " (2)", " (3)", ... to the
+// name if a file by that name already exists
+func createUnique(dir, base, ext string) (*os.File, error) {
+	for n := 1; ; n++ {
+		name := base + ext
+		if n > 1 {
+			name = fmt.Sprintf("%s (%d)%s", base, n, ext)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+}
@@ -0,0 +1,112 @@
+package m3u
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+func TestM3UProvider_Authenticate(t *testing.T) {
+	if err := NewM3UProvider(t.TempDir()).Authenticate(providers.Account{}); err != nil {
+		t.Errorf("Authenticate() failed for an existing directory: %v", err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := NewM3UProvider(missing).Authenticate(providers.Account{}); err == nil {
+		t.Error("Authenticate() should fail for a missing directory")
+	}
+}
+
+func TestM3UProvider_ImportAndExport(t *testing.T) {
+	dir := t.TempDir()
+	provider := NewM3UProvider(dir)
+	acct := providers.Account{UserID: "user123"}
+	userDir := filepath.Join(dir, "user123")
+
+	playlist := models.Playlist{
+		Name: "Late Night: Jazz/Soul",
+		Tracks: []models.Track{
+			{ID: "sp-1", Title: "So What", Artist: "Miles Davis", Duration: 562},
+			{ID: "local", Title: "Local", Location: filepath.Join(userDir, "music", "local.flac")},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if err := provider.ImportPlaylist(acct, playlist); err != nil {
+			t.Fatalf("ImportPlaylist() failed: %v", err)
+		}
+	}
+
+	playlists, err := provider.GetPlaylists(acct)
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+	if len(playlists) != 2 || playlists[0].ID != "Late Night_ Jazz_Soul (2).m3u8" || playlists[1].ID != "Late Night_ Jazz_Soul.m3u8" {
+		t.Fatalf("Expected two files with safe, unique names, got %+v", playlists)
+	}
+	if playlists[1].Name != "Late Night: Jazz/Soul" || playlists[1].TrackCount != 2 || playlists[1].Provider != "M3U" {
+		t.Errorf("Unexpected playlist summary: %+v", playlists[1])
+	}
+
+	data, err := os.ReadFile(filepath.Join(userDir, playlists[1].ID))
+	if err != nil {
+		t.Fatalf("Failed to read M3U file: %v", err)
+	}
+	if !strings.Contains(string(data), "\nmusic/local.flac\n") {
+		t.Errorf("Tracks inside the user directory should be written relative:\n%s", data)
+	}
+
+	exported, err := provider.ExportPlaylist(acct, playlists[1].ID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+	if got := exported.Tracks[1].Location; got != filepath.Join(userDir, "music", "local.flac") {
+		t.Errorf("Relative paths should be resolved against the playlist directory, got %q", got)
+	}
+	if exported.Tracks[0].Title != "So What" || exported.Tracks[0].Artist != "Miles Davis" || exported.Tracks[0].Duration != 562 {
+		t.Errorf("Unexpected track: %+v", exported.Tracks[0])
+	}
+	if exported.Tracks[0].Location != "" {
+		t.Errorf("The placeholder of a track without a file should not become a path, got %q", exported.Tracks[0].Location)
+	}
+
+	// Exporting the playlist again keeps the placeholder instead of a made-up path
+	if err := provider.ImportPlaylist(acct, exported); err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(userDir, "Late Night_ Jazz_Soul (3).m3u8"))
+	if err != nil {
+		t.Fatalf("Failed to read M3U file: %v", err)
+	}
+	if !strings.Contains(string(data), "\nMiles Davis - So What\n") || strings.Contains(string(data), userDir) {
+		t.Errorf("Expected the placeholder to be written again:\n%s", data)
+	}
+}
+
+func TestM3UProvider_Isolation(t *testing.T) {
+	dir := t.TempDir()
+	provider := NewM3UProvider(dir)
+
+	if err := provider.ImportPlaylist(providers.Account{UserID: "alice"}, models.Playlist{Name: "Mine"}); err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+
+	playlists, err := provider.GetPlaylists(providers.Account{UserID: "bob"})
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+	if len(playlists) != 0 {
+		t.Errorf("Users should not see each other's playlists, got %+v", playlists)
+	}
+
+	for _, id := range []string{"../alice/Mine.m3u8", "Mine.txt", ""} {
+		if _, err := provider.ExportPlaylist(providers.Account{UserID: "bob"}, id); err == nil {
+			t.Errorf("ExportPlaylist(%q) should fail", id)
+		}
+	}
+	if _, err := provider.GetPlaylists(providers.Account{UserID: "../etc"}); err == nil {
+		t.Error("GetPlaylists() should reject user IDs that escape the directory")
+	}
+}
@@ -2,16 +2,23 @@ package providers
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/JanikSachs/PlayPort/internal/models"
 )
 
-// MockProvider is a mock implementation of the Provider interface for testing
+// MockProvider is a mock implementation of the Provider interface for testing.
+// It is safe for concurrent use.
 type MockProvider struct {
+	mu           sync.Mutex
 	name         string
 	authenticated bool
 	playlists    []models.Playlist
+	albums       []models.Album
+	artists      []models.Artist
 }
 
 // NewMockProvider creates a new mock provider with sample data
@@ -102,6 +109,14 @@ func NewMockProvider() *MockProvider {
 				},
 			},
 		},
+		albums: []models.Album{
+			{ID: "album-1", Title: "Good Times", Artist: "The Happy Band", UPC: "000000000001", TrackCount: 10},
+			{ID: "album-2", Title: "Night Sky", Artist: "Ambient Dreams", UPC: "000000000002", TrackCount: 8},
+		},
+		artists: []models.Artist{
+			{ID: "artist-1", Name: "Ocean Sounds"},
+			{ID: "artist-2", Name: "Energy Squad"},
+		},
 	}
 }
 
@@ -111,13 +126,19 @@ func (m *MockProvider) Name() string {
 }
 
 // Authenticate simulates authentication
-func (m *MockProvider) Authenticate(userID string) error {
+func (m *MockProvider) Authenticate(acct Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.authenticated = true
 	return nil
 }
 
 // GetPlaylists returns mock playlists
-func (m *MockProvider) GetPlaylists(userID string) ([]models.Playlist, error) {
+func (m *MockProvider) GetPlaylists(acct Account) ([]models.Playlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if !m.authenticated {
 		return nil, fmt.Errorf("not authenticated")
 	}
@@ -125,7 +146,10 @@ func (m *MockProvider) GetPlaylists(userID string) ([]models.Playlist, error) {
 }
 
 // ExportPlaylist exports a specific playlist by ID
-func (m *MockProvider) ExportPlaylist(userID, id string) (models.Playlist, error) {
+func (m *MockProvider) ExportPlaylist(acct Account, id string) (models.Playlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if !m.authenticated {
 		return models.Playlist{}, fmt.Errorf("not authenticated")
 	}
@@ -139,8 +163,36 @@ func (m *MockProvider) ExportPlaylist(userID, id string) (models.Playlist, error
 	return models.Playlist{}, fmt.Errorf("playlist not found: %s", id)
 }
 
+// SearchTrack finds tracks in the mock playlists with the same ISRC or title
+func (m *MockProvider) SearchTrack(acct Account, t models.Track) ([]models.Track, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	var results []models.Track
+	seen := make(map[string]bool)
+	for _, playlist := range m.playlists {
+		for _, track := range playlist.Tracks {
+			if seen[track.ID] {
+				continue
+			}
+			if (t.ISRC != "" && strings.EqualFold(track.ISRC, t.ISRC)) || strings.EqualFold(track.Title, t.Title) {
+				results = append(results, track)
+				seen[track.ID] = true
+			}
+		}
+	}
+	return results, nil
+}
+
 // ImportPlaylist simulates importing a playlist
-func (m *MockProvider) ImportPlaylist(p models.Playlist) error {
+func (m *MockProvider) ImportPlaylist(acct Account, p models.Playlist) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if !m.authenticated {
 		return fmt.Errorf("not authenticated")
 	}
@@ -155,3 +207,205 @@ func (m *MockProvider) ImportPlaylist(p models.Playlist) error {
 	m.playlists = append(m.playlists, newPlaylist)
 	return nil
 }
+
+// CreatePlaylist adds an empty playlist and returns its ID
+func (m *MockProvider) CreatePlaylist(acct Account, p models.Playlist) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return "", fmt.Errorf("not authenticated")
+	}
+
+	created := models.Playlist{
+		ID:          fmt.Sprintf("mock-created-%d", len(m.playlists)+1),
+		Name:        p.Name,
+		Description: p.Description,
+		Provider:    m.name,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	m.playlists = append(m.playlists, created)
+	return created.ID, nil
+}
+
+// AddTracks appends the tracks that are not in the playlist yet
+func (m *MockProvider) AddTracks(acct Account, playlistID string, tracks []models.Track) error {
+	return m.editPlaylist(playlistID, func(p *models.Playlist) error {
+		for _, track := range tracks {
+			if !slices.ContainsFunc(p.Tracks, func(t models.Track) bool { return t.ID == track.ID }) {
+				p.Tracks = append(p.Tracks, track)
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveTracks removes the tracks from the playlist
+func (m *MockProvider) RemoveTracks(acct Account, playlistID string, tracks []models.Track) error {
+	return m.editPlaylist(playlistID, func(p *models.Playlist) error {
+		p.Tracks = slices.DeleteFunc(p.Tracks, func(t models.Track) bool {
+			return slices.ContainsFunc(tracks, func(r models.Track) bool { return r.ID == t.ID })
+		})
+		return nil
+	})
+}
+
+// ReorderTracks puts the playlist's tracks in the order of tracks
+func (m *MockProvider) ReorderTracks(acct Account, playlistID string, tracks []models.Track) error {
+	return m.editPlaylist(playlistID, func(p *models.Playlist) error {
+		if len(tracks) != len(p.Tracks) {
+			return fmt.Errorf("expected %d tracks to reorder, got %d", len(p.Tracks), len(tracks))
+		}
+		reordered := make([]models.Track, 0, len(tracks))
+		for _, track := range tracks {
+			i := slices.IndexFunc(p.Tracks, func(t models.Track) bool { return t.ID == track.ID })
+			if i < 0 {
+				return fmt.Errorf("track not in playlist: %s", track.ID)
+			}
+			reordered = append(reordered, p.Tracks[i])
+		}
+		p.Tracks = reordered
+		return nil
+	})
+}
+
+// editPlaylist applies fn to a copy of the playlist with the ID and stores
+// the copy if fn succeeds. Playlists returned earlier are left unchanged.
+func (m *MockProvider) editPlaylist(id string, fn func(p *models.Playlist) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return fmt.Errorf("not authenticated")
+	}
+
+	i := slices.IndexFunc(m.playlists, func(p models.Playlist) bool { return p.ID == id })
+	if i < 0 {
+		return fmt.Errorf("playlist not found: %s", id)
+	}
+	edited := m.playlists[i]
+	edited.Tracks = slices.Clone(edited.Tracks)
+	if err := fn(&edited); err != nil {
+		return err
+	}
+	edited.TrackCount = len(edited.Tracks)
+	edited.UpdatedAt = time.Now()
+
+	playlists := slices.Clone(m.playlists)
+	playlists[i] = edited
+	m.playlists = playlists
+	return nil
+}
+
+// GetSavedAlbums returns the mock saved albums
+func (m *MockProvider) GetSavedAlbums(acct Account) ([]models.Album, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	return m.albums, nil
+}
+
+// SearchAlbum finds saved albums with the same UPC or title
+func (m *MockProvider) SearchAlbum(acct Account, a models.Album) ([]models.Album, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	var results []models.Album
+	for _, album := range m.albums {
+		if (a.UPC != "" && album.UPC == a.UPC) || strings.EqualFold(album.Title, a.Title) {
+			results = append(results, album)
+		}
+	}
+	return results, nil
+}
+
+// SaveAlbums adds the albums that are not saved yet
+func (m *MockProvider) SaveAlbums(acct Account, albums []models.Album) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return fmt.Errorf("not authenticated")
+	}
+
+	for _, album := range albums {
+		if !m.hasAlbum(album.ID) {
+			m.albums = append(m.albums, album)
+		}
+	}
+	return nil
+}
+
+// GetFollowedArtists returns the mock followed artists
+func (m *MockProvider) GetFollowedArtists(acct Account) ([]models.Artist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	return m.artists, nil
+}
+
+// SearchArtist finds followed artists with the same name
+func (m *MockProvider) SearchArtist(acct Account, a models.Artist) ([]models.Artist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	var results []models.Artist
+	for _, artist := range m.artists {
+		if strings.EqualFold(artist.Name, a.Name) {
+			results = append(results, artist)
+		}
+	}
+	return results, nil
+}
+
+// FollowArtists adds the artists that are not followed yet
+func (m *MockProvider) FollowArtists(acct Account, artists []models.Artist) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.authenticated {
+		return fmt.Errorf("not authenticated")
+	}
+
+	for _, artist := range artists {
+		if !m.followsArtist(artist.ID) {
+			m.artists = append(m.artists, artist)
+		}
+	}
+	return nil
+}
+
+// hasAlbum reports whether an album with the ID is saved. m.mu must be held.
+func (m *MockProvider) hasAlbum(id string) bool {
+	for _, album := range m.albums {
+		if album.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// followsArtist reports whether an artist with the ID is followed. m.mu must be held.
+func (m *MockProvider) followsArtist(id string) bool {
+	for _, artist := range m.artists {
+		if artist.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -22,7 +22,7 @@ func TestMockProvider_Name(t *testing.T) {
 func TestMockProvider_Authenticate(t *testing.T) {
 	provider := NewMockProvider()
 
-	err := provider.Authenticate("")
+	err := provider.Authenticate(Account{})
 	if err != nil {
 		t.Errorf("Authenticate() should not return error, got: %v", err)
 	}
@@ -32,18 +32,18 @@ func TestMockProvider_GetPlaylists(t *testing.T) {
 	provider := NewMockProvider()
 
 	// Should fail without authentication
-	_, err := provider.GetPlaylists("")
+	_, err := provider.GetPlaylists(Account{})
 	if err == nil {
 		t.Error("GetPlaylists() should fail without authentication")
 	}
 
 	// Authenticate first
-	if err := provider.Authenticate(""); err != nil {
+	if err := provider.Authenticate(Account{}); err != nil {
 		t.Fatalf("Failed to authenticate: %v", err)
 	}
 
 	// Should succeed after authentication
-	playlists, err := provider.GetPlaylists("")
+	playlists, err := provider.GetPlaylists(Account{})
 	if err != nil {
 		t.Errorf("GetPlaylists() returned error: %v", err)
 	}
@@ -70,18 +70,18 @@ func TestMockProvider_ExportPlaylist(t *testing.T) {
 	provider := NewMockProvider()
 
 	// Should fail without authentication
-	_, err := provider.ExportPlaylist("", "mock-1")
+	_, err := provider.ExportPlaylist(Account{}, "mock-1")
 	if err == nil {
 		t.Error("ExportPlaylist() should fail without authentication")
 	}
 
 	// Authenticate first
-	if err := provider.Authenticate(""); err != nil {
+	if err := provider.Authenticate(Account{}); err != nil {
 		t.Fatalf("Failed to authenticate: %v", err)
 	}
 
 	// Should succeed with valid ID
-	playlist, err := provider.ExportPlaylist("", "mock-1")
+	playlist, err := provider.ExportPlaylist(Account{}, "mock-1")
 	if err != nil {
 		t.Errorf("ExportPlaylist() returned error: %v", err)
 	}
@@ -95,7 +95,7 @@ func TestMockProvider_ExportPlaylist(t *testing.T) {
 	}
 
 	// Should fail with invalid ID
-	_, err = provider.ExportPlaylist("", "invalid-id")
+	_, err = provider.ExportPlaylist(Account{}, "invalid-id")
 	if err == nil {
 		t.Error("ExportPlaylist() should fail with invalid ID")
 	}
@@ -124,28 +124,28 @@ func TestMockProvider_ImportPlaylist(t *testing.T) {
 	}
 
 	// Should fail without authentication
-	err := provider.ImportPlaylist(testPlaylist)
+	err := provider.ImportPlaylist(Account{}, testPlaylist)
 	if err == nil {
 		t.Error("ImportPlaylist() should fail without authentication")
 	}
 
 	// Authenticate first
-	if err := provider.Authenticate(""); err != nil {
+	if err := provider.Authenticate(Account{}); err != nil {
 		t.Fatalf("Failed to authenticate: %v", err)
 	}
 
 	// Get initial playlist count
-	initialPlaylists, _ := provider.GetPlaylists("")
+	initialPlaylists, _ := provider.GetPlaylists(Account{})
 	initialCount := len(initialPlaylists)
 
 	// Should succeed after authentication
-	err = provider.ImportPlaylist(testPlaylist)
+	err = provider.ImportPlaylist(Account{}, testPlaylist)
 	if err != nil {
 		t.Errorf("ImportPlaylist() returned error: %v", err)
 	}
 
 	// Verify playlist was added
-	playlists, _ := provider.GetPlaylists("")
+	playlists, _ := provider.GetPlaylists(Account{})
 	if len(playlists) != initialCount+1 {
 		t.Errorf("Expected %d playlists after import, got %d", initialCount+1, len(playlists))
 	}
@@ -156,3 +156,104 @@ func TestMockProvider_ImportPlaylist(t *testing.T) {
 		t.Errorf("Imported playlist provider should be '%s', got '%s'", provider.Name(), lastPlaylist.Provider)
 	}
 }
+
+func TestMockProvider_SearchTrack(t *testing.T) {
+	provider := NewMockProvider()
+	provider.Authenticate(Account{})
+
+	results, err := provider.SearchTrack(Account{}, models.Track{ISRC: "mock12345002"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "track-2" {
+		t.Errorf("Expected track-2 by ISRC, got %+v", results)
+	}
+
+	results, err = provider.SearchTrack(Account{}, models.Track{Title: "moonlight"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "track-6" {
+		t.Errorf("Expected track-6 by title, got %+v", results)
+	}
+
+	results, _ = provider.SearchTrack(Account{}, models.Track{Title: "Unknown Song"})
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+}
+
+func TestMockProvider_Albums(t *testing.T) {
+	provider := NewMockProvider()
+	provider.Authenticate(Account{})
+
+	albums, err := provider.GetSavedAlbums(Account{})
+	if err != nil || len(albums) != 2 {
+		t.Fatalf("Expected 2 saved albums, got %d, %v", len(albums), err)
+	}
+
+	results, _ := provider.SearchAlbum(Account{}, models.Album{UPC: "000000000002"})
+	if len(results) != 1 || results[0].ID != "album-2" {
+		t.Errorf("Expected album-2 by UPC, got %+v", results)
+	}
+
+	err = provider.SaveAlbums(Account{}, []models.Album{{ID: "album-1"}, {ID: "album-3", Title: "New"}})
+	if err != nil {
+		t.Fatalf("SaveAlbums() failed: %v", err)
+	}
+	albums, _ = provider.GetSavedAlbums(Account{})
+	if len(albums) != 3 || albums[2].ID != "album-3" {
+		t.Errorf("Expected only album-3 to be added, got %+v", albums)
+	}
+}
+
+func TestMockProvider_Artists(t *testing.T) {
+	provider := NewMockProvider()
+	provider.Authenticate(Account{})
+
+	results, err := provider.SearchArtist(Account{}, models.Artist{Name: "ocean sounds"})
+	if err != nil || len(results) != 1 || results[0].ID != "artist-1" {
+		t.Errorf("Expected artist-1 by name, got %+v, %v", results, err)
+	}
+
+	if err := provider.FollowArtists(Account{}, []models.Artist{{ID: "artist-1"}, {ID: "artist-3", Name: "New"}}); err != nil {
+		t.Fatalf("FollowArtists() failed: %v", err)
+	}
+	artists, _ := provider.GetFollowedArtists(Account{})
+	if len(artists) != 3 || artists[2].ID != "artist-3" {
+		t.Errorf("Expected only artist-3 to be followed, got %+v", artists)
+	}
+}
+
+func TestMockProvider_EditPlaylist(t *testing.T) {
+	provider := NewMockProvider()
+	provider.Authenticate(Account{})
+
+	id, err := provider.CreatePlaylist(Account{}, models.Playlist{Name: "Synced"})
+	if err != nil {
+		t.Fatalf("CreatePlaylist() failed: %v", err)
+	}
+
+	if err := provider.AddTracks(Account{}, id, []models.Track{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "a"}}); err != nil {
+		t.Fatalf("AddTracks() failed: %v", err)
+	}
+	before, _ := provider.ExportPlaylist(Account{}, id)
+
+	if err := provider.RemoveTracks(Account{}, id, []models.Track{{ID: "b"}}); err != nil {
+		t.Fatalf("RemoveTracks() failed: %v", err)
+	}
+	if err := provider.ReorderTracks(Account{}, id, []models.Track{{ID: "c"}, {ID: "a"}}); err != nil {
+		t.Fatalf("ReorderTracks() failed: %v", err)
+	}
+	if err := provider.ReorderTracks(Account{}, id, []models.Track{{ID: "c"}}); err == nil {
+		t.Error("ReorderTracks() should fail without every track of the playlist")
+	}
+
+	playlist, _ := provider.ExportPlaylist(Account{}, id)
+	if playlist.Name != "Synced" || playlist.TrackCount != 2 || playlist.Tracks[0].ID != "c" || playlist.Tracks[1].ID != "a" {
+		t.Errorf("Expected Synced with tracks c, a, got %+v", playlist)
+	}
+	if len(before.Tracks) != 3 || before.Tracks[1].ID != "b" {
+		t.Errorf("Editing should not change playlists exported earlier, got %+v", before.Tracks)
+	}
+}
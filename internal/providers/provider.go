@@ -1,21 +1,153 @@
 package providers
 
-import "github.com/JanikSachs/PlayPort/internal/models"
+import (
+	"context"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// Account selects which of a user's linked accounts a provider call acts on
+type Account struct {
+	// UserID is the local app user ID
+	UserID string
+
+	// ExternalUserID is the provider's account ID. An empty value selects
+	// the user's default account, which is the only one for most users.
+	ExternalUserID string
+}
 
 // Provider defines the interface that all music platform providers must implement
 type Provider interface {
 	// Name returns the provider's name (e.g., "Spotify", "Apple Music")
 	Name() string
 
-	// Authenticate checks if the given user has a valid connection to the provider
-	Authenticate(userID string) error
+	// Authenticate checks if the given account has a valid connection to the provider
+	Authenticate(acct Account) error
+
+	// GetPlaylists retrieves all playlists of the given account
+	GetPlaylists(acct Account) ([]models.Playlist, error)
+
+	// ExportPlaylist exports a specific playlist by ID from the given account
+	ExportPlaylist(acct Account, id string) (models.Playlist, error)
+
+	// ImportPlaylist imports a playlist into the given account
+	ImportPlaylist(acct Account, p models.Playlist) error
+}
+
+// AccountLister is implemented by providers that link user accounts through
+// stored connections. A user may link several accounts of the same provider.
+type AccountLister interface {
+	// Accounts returns the user's connected accounts, oldest first
+	Accounts(userID string) ([]*models.Connection, error)
+}
+
+// Revoker is implemented by providers that can invalidate a connection's
+// tokens on the provider side, so the grant no longer works once disconnected.
+type Revoker interface {
+	// Revoke revokes the connection's OAuth grant with the provider
+	Revoke(ctx context.Context, conn *models.Connection) error
+}
+
+// TrackSearcher is implemented by providers that can look up tracks in their
+// catalog. Transfers to such providers match every track before importing,
+// and import only the tracks that were found.
+type TrackSearcher interface {
+	// SearchTrack returns catalog tracks resembling t, best candidates first
+	SearchTrack(acct Account, t models.Track) ([]models.Track, error)
+}
+
+// LibraryPlaylistID is the playlist ID under which providers expose an
+// account's saved tracks, such as Spotify's Liked Songs, as a virtual
+// playlist. Providers with a saved library list it first in GetPlaylists and
+// export it like any other playlist.
+const LibraryPlaylistID = "library"
+
+// LibrarySaver is implemented by providers that can add tracks to an
+// account's saved library. Transfers of a library playlist to such providers
+// save the tracks there instead of creating a playlist.
+type LibrarySaver interface {
+	// SaveTracks adds the tracks, identified by their IDs on this provider,
+	// to the account's saved library
+	SaveTracks(acct Account, tracks []models.Track) error
+}
+
+// PlaylistEditor is implemented by providers that can change playlists in
+// place. Sync links can only target such providers, since each sync edits
+// the playlist created by the first one. Tracks are identified by their IDs
+// on this provider, and a playlist holds each track at most once.
+type PlaylistEditor interface {
+	// CreatePlaylist creates an empty playlist with p's name and
+	// description and returns its ID
+	CreatePlaylist(acct Account, p models.Playlist) (string, error)
+
+	// AddTracks appends the tracks to the end of the playlist
+	AddTracks(acct Account, playlistID string, tracks []models.Track) error
 
-	// GetPlaylists retrieves all playlists for the given user
-	GetPlaylists(userID string) ([]models.Playlist, error)
+	// RemoveTracks removes the tracks from the playlist
+	RemoveTracks(acct Account, playlistID string, tracks []models.Track) error
 
-	// ExportPlaylist exports a specific playlist by ID for the given user
-	ExportPlaylist(userID, id string) (models.Playlist, error)
+	// ReorderTracks puts the playlist's tracks in the order of tracks,
+	// which holds every track of the playlist
+	ReorderTracks(acct Account, playlistID string, tracks []models.Track) error
+}
+
+// AlbumLister is implemented by providers that can list the albums an
+// account saved to its library
+type AlbumLister interface {
+	// GetSavedAlbums returns the account's saved albums
+	GetSavedAlbums(acct Account) ([]models.Album, error)
+}
+
+// AlbumSearcher is implemented by providers that can look up albums in their
+// catalog. Album transfers to such providers match every album before saving.
+type AlbumSearcher interface {
+	// SearchAlbum returns catalog albums resembling a, best candidates first
+	SearchAlbum(acct Account, a models.Album) ([]models.Album, error)
+}
+
+// AlbumSaver is implemented by providers that can add albums to an account's
+// library
+type AlbumSaver interface {
+	// SaveAlbums adds the albums, identified by their IDs on this provider,
+	// to the account's library
+	SaveAlbums(acct Account, albums []models.Album) error
+}
+
+// ArtistLister is implemented by providers that can list the artists an
+// account follows
+type ArtistLister interface {
+	// GetFollowedArtists returns the artists the account follows
+	GetFollowedArtists(acct Account) ([]models.Artist, error)
+}
+
+// ArtistSearcher is implemented by providers that can look up artists in
+// their catalog. Artist transfers to such providers match every artist
+// before following.
+type ArtistSearcher interface {
+	// SearchArtist returns catalog artists resembling a, best candidates first
+	SearchArtist(acct Account, a models.Artist) ([]models.Artist, error)
+}
+
+// ArtistFollower is implemented by providers that can follow artists
+type ArtistFollower interface {
+	// FollowArtists makes the account follow the artists, identified by
+	// their IDs on this provider
+	FollowArtists(acct Account, artists []models.Artist) error
+}
+
+// Credentials are what a user enters to link an account of a provider that
+// does not use OAuth: a username with either a password or a token, such as
+// an API key
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
 
-	// ImportPlaylist imports a playlist into the provider
-	ImportPlaylist(p models.Playlist) error
+// Connector is implemented by providers whose accounts are linked with
+// credentials instead of OAuth, such as self-hosted media servers.
+type Connector interface {
+	// Connect verifies the credentials with the provider and saves a
+	// connection for the local user
+	Connect(ctx context.Context, userID string, creds Credentials) (*models.Connection, error)
 }
@@ -0,0 +1,30 @@
+package providers
+
+// How the accounts of a registered provider are linked
+const (
+	LoginOAuth       = "oauth"       // OAuth authorization code flow, with PKCE for some providers
+	LoginMusicKit    = "musickit"    // MusicKit JS on a connect page (Apple Music)
+	LoginCredentials = "credentials" // username and password or token entered on the providers page
+)
+
+// Registration is an entry of the provider registry: a provider whose
+// accounts users can link, and whether the configuration enables it
+type Registration struct {
+	Slug     string   // provider name in routes and connections, e.g. "spotify"
+	Name     string   // display name, e.g. "Spotify"
+	Login    string   // LoginOAuth, LoginMusicKit or LoginCredentials
+	Requires string   // the configuration that enables the provider, for logs
+	Enabled  bool     // whether that configuration is set
+	Provider Provider // nil unless Enabled
+}
+
+// Lookup returns the registration of the provider with the given slug, or
+// a disabled registration if there is none
+func Lookup(registry []Registration, slug string) Registration {
+	for _, reg := range registry {
+		if reg.Slug == slug {
+			return reg
+		}
+	}
+	return Registration{Slug: slug}
+}
@@ -0,0 +1,493 @@
+package soundcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+const (
+	apiURL     = "https://api.soundcloud.com"
+	signOutURL = "https://secure.soundcloud.com/sign-out"
+
+	// pageSize is the number of items requested per page
+	pageSize = 200
+
+	// searchLimit is the number of candidates a track search returns
+	searchLimit = 10
+
+	// maxPlaylistTracks is the most tracks a SoundCloud playlist holds
+	maxPlaylistTracks = 500
+)
+
+// endpoint is SoundCloud's OAuth 2.1 endpoint. Authorization requests must
+// use PKCE.
+var endpoint = oauth2.Endpoint{
+	AuthURL:   "https://secure.soundcloud.com/authorize",
+	TokenURL:  "https://secure.soundcloud.com/oauth/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+// SoundCloudProvider implements the Provider interface for SoundCloud
+type SoundCloudProvider struct {
+	config          *oauth2.Config
+	connectionStore storage.ConnectionStore
+	httpClient      *http.Client
+	apiURL          string
+	signOutURL      string
+}
+
+// NewSoundCloudProvider creates a new SoundCloud provider
+func NewSoundCloudProvider(clientID, clientSecret, redirectURL string, connectionStore storage.ConnectionStore) *SoundCloudProvider {
+	config := &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Endpoint:     endpoint,
+	}
+
+	return &SoundCloudProvider{
+		config:          config,
+		connectionStore: connectionStore,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		apiURL:          apiURL,
+		signOutURL:      signOutURL,
+	}
+}
+
+// Name returns the provider's name
+func (p *SoundCloudProvider) Name() string {
+	return "SoundCloud"
+}
+
+// Authenticate checks if the user has a valid connection
+func (p *SoundCloudProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "soundcloud", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return fmt.Errorf("not connected to SoundCloud: %w", err)
+	}
+
+	if !conn.Connected {
+		return fmt.Errorf("SoundCloud connection not active")
+	}
+
+	return nil
+}
+
+// AuthURL returns the OAuth authorization URL with the PKCE challenge of
+// verifier, which must be kept with the state for Exchange
+func (p *SoundCloudProvider) AuthURL(state, verifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+// Accounts returns the user's connected SoundCloud accounts, oldest first
+func (p *SoundCloudProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("soundcloud", userID)
+}
+
+// Exchange exchanges an authorization code for a token. verifier is the
+// PKCE code verifier passed to AuthURL.
+func (p *SoundCloudProvider) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	return p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+}
+
+// SaveConnection saves a connection after OAuth
+func (p *SoundCloudProvider) SaveConnection(ctx context.Context, token *oauth2.Token, userID string) error {
+	me, err := p.getMe(ctx, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	name := me.FullName
+	if name == "" {
+		name = me.Username
+	}
+
+	conn := &models.Connection{
+		Provider:         "soundcloud",
+		UserID:           userID,
+		ExternalUserID:   strconv.FormatInt(me.ID, 10),
+		ExternalUserName: name,
+		AccessToken:      token.AccessToken,
+		RefreshToken:     token.RefreshToken,
+		ExpiresAt:        token.Expiry,
+		Connected:        true,
+	}
+
+	return p.connectionStore.Save(conn)
+}
+
+// Revoke signs the connection's access token out of SoundCloud
+func (p *SoundCloudProvider) Revoke(ctx context.Context, conn *models.Connection) error {
+	if conn.AccessToken == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]string{"access_token": conn.AccessToken})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.signOutURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create sign-out request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to sign out: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Expired tokens are answered with 401 and need no sign-out
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("sign-out returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// GetPlaylists retrieves the user's playlists, preceded by a "Likes"
+// playlist of the tracks the user liked
+func (p *SoundCloudProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	ctx := context.Background()
+	_, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return nil, err
+	}
+
+	me, err := p.getMe(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+	allPlaylists := []models.Playlist{likesPlaylist(me.PublicFavoritesCount)}
+
+	path := "/me/playlists?" + url.Values{
+		"linked_partitioning": {"true"},
+		"show_tracks":         {"false"},
+		"limit":               {strconv.Itoa(pageSize)},
+	}.Encode()
+	for path != "" {
+		var page PlaylistsPage
+		if err := p.call(ctx, accessToken, http.MethodGet, path, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+		}
+		for _, item := range page.Collection {
+			allPlaylists = append(allPlaylists, toPlaylist(item))
+		}
+		path = page.NextHref
+	}
+
+	return allPlaylists, nil
+}
+
+// ExportPlaylist exports a specific playlist by ID, or the liked tracks for
+// the ID "likes"
+func (p *SoundCloudProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	ctx := context.Background()
+	_, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	var playlist models.Playlist
+	var tracksPath string
+	if id == providers.LibraryPlaylistID {
+		playlist = likesPlaylist(0)
+		tracksPath = "/me/likes/tracks"
+	} else {
+		var item Playlist
+		if err := p.call(ctx, accessToken, http.MethodGet, "/playlists/"+url.PathEscape(id)+"?show_tracks=false", nil, &item); err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+		}
+		playlist = toPlaylist(item)
+		tracksPath = "/playlists/" + url.PathEscape(id) + "/tracks"
+	}
+
+	path := tracksPath + "?" + url.Values{
+		"linked_partitioning": {"true"},
+		"limit":               {strconv.Itoa(pageSize)},
+	}.Encode()
+	for path != "" {
+		var page TracksPage
+		if err := p.call(ctx, accessToken, http.MethodGet, path, nil, &page); err != nil {
+			return models.Playlist{}, fmt.Errorf("failed to fetch tracks: %w", err)
+		}
+		for _, t := range page.Collection {
+			playlist.Tracks = append(playlist.Tracks, toTrack(t))
+		}
+		path = page.NextHref
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+
+	return playlist, nil
+}
+
+// ImportPlaylist creates a private playlist with the tracks. Tracks are
+// expected to carry SoundCloud track IDs, as matched by SearchTrack; other
+// tracks and repeats are skipped. Playlists longer than SoundCloud allows
+// are rejected before anything is created.
+func (p *SoundCloudProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	ctx := context.Background()
+	_, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return err
+	}
+
+	refs := []TrackReference{}
+	for _, id := range trackIDs(playlist.Tracks) {
+		refs = append(refs, TrackReference{ID: id})
+	}
+	if len(refs) > maxPlaylistTracks {
+		return fmt.Errorf("soundcloud playlists hold at most %d tracks, got %d", maxPlaylistTracks, len(refs))
+	}
+
+	create := CreatePlaylistRequest{Playlist: NewPlaylist{
+		Title:       playlist.Name,
+		Description: playlist.Description,
+		Sharing:     "private",
+		Tracks:      refs,
+	}}
+	if err := p.call(ctx, accessToken, http.MethodPost, "/playlists", create, nil); err != nil {
+		return fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	return nil
+}
+
+// SaveTracks likes the tracks on SoundCloud, one request per track
+func (p *SoundCloudProvider) SaveTracks(acct providers.Account, tracks []models.Track) error {
+	ctx := context.Background()
+	_, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range trackIDs(tracks) {
+		path := "/likes/tracks/" + strconv.FormatInt(id, 10)
+		if err := p.call(ctx, accessToken, http.MethodPost, path, nil, nil); err != nil {
+			return fmt.Errorf("failed to like track %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// SearchTrack searches SoundCloud's tracks by artist and title. The API has
+// no ISRC lookup; the matcher still prefers a candidate whose publisher
+// metadata carries the same ISRC.
+func (p *SoundCloudProvider) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	ctx := context.Background()
+	_, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"q":                   {strings.TrimSpace(t.Artist + " " + t.Title)},
+		"limit":               {strconv.Itoa(searchLimit)},
+		"linked_partitioning": {"true"},
+	}
+	var page TracksPage
+	if err := p.call(ctx, accessToken, http.MethodGet, "/tracks?"+params.Encode(), nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+
+	var candidates []models.Track
+	for _, match := range page.Collection {
+		candidates = append(candidates, toTrack(match))
+	}
+	return candidates, nil
+}
+
+// getMe fetches the profile of the user the access token belongs to
+func (p *SoundCloudProvider) getMe(ctx context.Context, accessToken string) (*User, error) {
+	var me User
+	if err := p.call(ctx, accessToken, http.MethodGet, "/me", nil, &me); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// accessToken returns the account's connection and a valid access token,
+// refreshing and storing the token when it has expired. SoundCloud rotates
+// refresh tokens, so the new one is stored as well.
+func (p *SoundCloudProvider) accessToken(ctx context.Context, acct providers.Account) (*models.Connection, string, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "soundcloud", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return nil, "", fmt.Errorf("not connected: %w", err)
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  conn.AccessToken,
+		RefreshToken: conn.RefreshToken,
+		Expiry:       conn.ExpiresAt,
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	fresh, err := p.config.TokenSource(ctx, token).Token()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	if fresh.AccessToken != conn.AccessToken {
+		conn.AccessToken = fresh.AccessToken
+		if fresh.RefreshToken != "" {
+			conn.RefreshToken = fresh.RefreshToken
+		}
+		conn.ExpiresAt = fresh.Expiry
+		if err := p.connectionStore.Update(conn); err != nil {
+			return nil, "", fmt.Errorf("failed to update token: %w", err)
+		}
+	}
+
+	return conn, fresh.AccessToken, nil
+}
+
+// call sends an API request and decodes the JSON answer into v, which may
+// be nil. path is relative to the API base URL; absolute next_href links
+// of paged answers are followed only if they point at the API, so the
+// access token is never sent anywhere else.
+func (p *SoundCloudProvider) call(ctx context.Context, accessToken, method, path string, body, v interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = p.apiURL + path
+	} else if err := p.checkAPIURL(path); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "OAuth "+accessToken)
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("soundcloud API error: %s - %s", resp.Status, string(respBody))
+	}
+
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// checkAPIURL fails unless the absolute URL rawURL has the scheme and host
+// of the API base URL
+func (p *SoundCloudProvider) checkAPIURL(rawURL string) error {
+	api, err := url.Parse(p.apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid API URL: %w", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid next_href %q: %w", rawURL, err)
+	}
+	if u.Scheme != api.Scheme || u.Host != api.Host {
+		return fmt.Errorf("refusing to follow next_href to %s://%s outside the SoundCloud API", u.Scheme, u.Host)
+	}
+	return nil
+}
+
+// likesPlaylist returns the pseudo-playlist of the user's liked tracks
+func likesPlaylist(trackCount int) models.Playlist {
+	return models.Playlist{
+		ID:          providers.LibraryPlaylistID,
+		Name:        "Likes",
+		Description: "Tracks you liked on SoundCloud",
+		TrackCount:  trackCount,
+		Provider:    "SoundCloud",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// trackIDs returns the numeric SoundCloud IDs of the tracks without repeats,
+// skipping tracks that were not matched
+func trackIDs(tracks []models.Track) []int64 {
+	var ids []int64
+	seen := make(map[int64]bool, len(tracks))
+	for _, t := range tracks {
+		id, err := strconv.ParseInt(t.ID, 10, 64)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// toPlaylist converts a SoundCloud playlist to the domain model, without
+// tracks
+func toPlaylist(item Playlist) models.Playlist {
+	return models.Playlist{
+		ID:          strconv.FormatInt(item.ID, 10),
+		Name:        item.Title,
+		Description: item.Description,
+		TrackCount:  item.TrackCount,
+		Provider:    "SoundCloud",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// toTrack converts a SoundCloud track to the domain model. The publisher
+// metadata, where present, names the credited artist and the album; the
+// uploader stands in for the artist otherwise.
+func toTrack(item Track) models.Track {
+	t := models.Track{
+		ID:       strconv.FormatInt(item.ID, 10),
+		Title:    item.Title,
+		Artist:   item.User.Username,
+		Duration: item.Duration / 1000,
+	}
+
+	if meta := item.PublisherMetadata; meta != nil {
+		if meta.Artist != "" {
+			t.Artist = meta.Artist
+		}
+		t.Album = meta.AlbumTitle
+		if t.Album == "" {
+			t.Album = meta.ReleaseTitle
+		}
+		t.ISRC = meta.ISRC
+	}
+	return t
+}
@@ -0,0 +1,465 @@
+package soundcloud
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// fakeSoundCloud is an httptest stand-in for the SoundCloud API and OAuth
+// endpoints
+type fakeSoundCloud struct {
+	mu         sync.Mutex
+	server     *httptest.Server
+	challenge  string // PKCE challenge the token endpoint accepts
+	created    []NewPlaylist
+	liked      []string
+	signedOut  []string
+	queries    []string
+	authHeader string // Authorization header of the last API request
+	nextHref   string // next_href of the first playlists page, if set
+}
+
+var teardrop = Track{
+	ID: 1001, Title: "Teardrop", Duration: 330000, User: User{Username: "massiveattackofficial"},
+	PublisherMetadata: &PublisherMetadata{Artist: "Massive Attack", AlbumTitle: "Mezzanine", ISRC: "GBAAA9800044"},
+}
+
+var bootleg = Track{ID: 1002, Title: "Teardrop (Bootleg Edit)", Duration: 412500, User: User{Username: "dj-someone"}}
+
+func newFakeSoundCloud(t *testing.T) *fakeSoundCloud {
+	f := &fakeSoundCloud{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			writeJSON(w, map[string]interface{}{"access_token": "token-1", "refresh_token": "refresh-1", "token_type": "bearer", "expires_in": 3600})
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			// SoundCloud rotates refresh tokens
+			writeJSON(w, map[string]interface{}{"access_token": "token-2", "refresh_token": "refresh-2", "token_type": "bearer", "expires_in": 3600})
+		}
+	})
+	mux.HandleFunc("POST /sign-out", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.signedOut = append(f.signedOut, body["access_token"])
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	api := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+			f.mu.Lock()
+			f.authHeader = auth
+			f.mu.Unlock()
+			if auth != "OAuth token-1" && auth != "OAuth token-2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				writeJSON(w, map[string]string{"error": "invalid_token"})
+				return
+			}
+			handler(w, r)
+		})
+	}
+
+	api("GET /me", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, User{ID: 42, Username: "listener42", FullName: "Lee Listener", PublicFavoritesCount: 2})
+	})
+	api("GET /me/playlists", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("linked_partitioning") != "true" {
+			http.Error(w, "expected linked partitioning", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			next := f.server.URL + "/me/playlists?linked_partitioning=true&cursor=2"
+			if f.nextHref != "" {
+				next = f.nextHref
+			}
+			writeJSON(w, PlaylistsPage{
+				Collection: []Playlist{{ID: 501, Title: "Night Drive", Description: "Slow", TrackCount: 2}},
+				NextHref:   next,
+			})
+			return
+		}
+		writeJSON(w, PlaylistsPage{Collection: []Playlist{{ID: 502, Title: "Gym", TrackCount: 0}}})
+	})
+	api("GET /me/likes/tracks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, TracksPage{Collection: []Track{teardrop, bootleg}})
+	})
+	api("POST /likes/tracks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.liked = append(f.liked, r.PathValue("id"))
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+	api("GET /playlists/501", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, Playlist{ID: 501, Title: "Night Drive", Description: "Slow", TrackCount: 2})
+	})
+	api("GET /playlists/501/tracks", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			writeJSON(w, TracksPage{Collection: []Track{teardrop}, NextHref: f.server.URL + "/playlists/501/tracks?linked_partitioning=true&cursor=2"})
+			return
+		}
+		writeJSON(w, TracksPage{Collection: []Track{bootleg}})
+	})
+	api("POST /playlists", func(w http.ResponseWriter, r *http.Request) {
+		var body CreatePlaylistRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.created = append(f.created, body.Playlist)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, Playlist{ID: 900, Title: body.Playlist.Title})
+	})
+	api("GET /tracks", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		f.mu.Lock()
+		f.queries = append(f.queries, q)
+		f.mu.Unlock()
+		var page TracksPage
+		if q == "Massive Attack Teardrop" {
+			page.Collection = []Track{teardrop, bootleg}
+		}
+		writeJSON(w, page)
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	json.NewEncoder(w).Encode(v)
+}
+
+// newTestProvider returns a provider talking to f with user123 connected
+func newTestProvider(t *testing.T, f *fakeSoundCloud) (*SoundCloudProvider, storage.ConnectionStore) {
+	store := storage.NewInMemoryConnectionStore()
+	p := NewSoundCloudProvider("client-id", "client-secret", "http://localhost/auth/soundcloud/callback", store)
+	p.apiURL = f.server.URL
+	p.signOutURL = f.server.URL + "/sign-out"
+	p.config.Endpoint.AuthURL = f.server.URL + "/authorize"
+	p.config.Endpoint.TokenURL = f.server.URL + "/oauth/token"
+
+	err := store.Save(&models.Connection{
+		Provider:       "soundcloud",
+		UserID:         "user123",
+		ExternalUserID: "42",
+		AccessToken:    "token-1",
+		RefreshToken:   "refresh-1",
+		ExpiresAt:      time.Now().Add(time.Hour),
+		Connected:      true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to save connection: %v", err)
+	}
+	return p, store
+}
+
+func TestSoundCloudProvider_Name(t *testing.T) {
+	provider := NewSoundCloudProvider("client-id", "client-secret", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	if provider.Name() != "SoundCloud" {
+		t.Errorf("Expected provider name 'SoundCloud', got '%s'", provider.Name())
+	}
+}
+
+func TestSoundCloudProvider_AuthURL(t *testing.T) {
+	provider := NewSoundCloudProvider("client-id", "client-secret", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	u, err := url.Parse(provider.AuthURL("test-state", "test-verifier"))
+	if err != nil {
+		t.Fatalf("AuthURL() is not a URL: %v", err)
+	}
+
+	if u.Host != "secure.soundcloud.com" || u.Path != "/authorize" {
+		t.Errorf("Expected the SoundCloud authorization endpoint, got %s", u)
+	}
+	q := u.Query()
+	if q.Get("client_id") != "client-id" || q.Get("state") != "test-state" || q.Get("response_type") != "code" {
+		t.Errorf("AuthURL() is missing parameters: %s", u.RawQuery)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Errorf("Expected a S256 PKCE challenge, got %s", u.RawQuery)
+	}
+}
+
+func TestSoundCloudProvider_ExchangeAndSaveConnection(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, store := newTestProvider(t, f)
+
+	states := auth.NewInMemoryStateStore()
+	state, verifier, err := states.GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE() failed: %v", err)
+	}
+	u, _ := url.Parse(provider.AuthURL(state, verifier))
+	f.challenge = u.Query().Get("code_challenge")
+
+	ctx := context.Background()
+	if _, err := provider.Exchange(ctx, "good-code", oauth2.GenerateVerifier()); err == nil {
+		t.Error("Expected an error exchanging with another verifier")
+	}
+
+	verifier, ok := states.ValidatePKCE(state)
+	if !ok {
+		t.Fatal("ValidatePKCE() rejected the state")
+	}
+	token, err := provider.Exchange(ctx, "good-code", verifier)
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+
+	if err := provider.SaveConnection(ctx, token, "user456"); err != nil {
+		t.Fatalf("SaveConnection() failed: %v", err)
+	}
+	conn, err := store.Get("soundcloud", "user456", "42")
+	if err != nil {
+		t.Fatalf("Expected a saved connection: %v", err)
+	}
+	if conn.ExternalUserName != "Lee Listener" || conn.AccessToken != "token-1" || conn.RefreshToken != "refresh-1" || !conn.Connected {
+		t.Errorf("Unexpected connection %+v", conn)
+	}
+	if f.authHeader != "OAuth token-1" {
+		t.Errorf("Expected the OAuth authorization scheme, got %q", f.authHeader)
+	}
+}
+
+func TestSoundCloudProvider_Exchange_OtherInstance(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	states := auth.NewInMemoryStateStore()
+
+	// The authorization starts on one instance...
+	first := NewSoundCloudProvider("client-id", "client-secret", "http://localhost/auth/soundcloud/callback", storage.NewInMemoryConnectionStore())
+	state, verifier, err := states.GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE() failed: %v", err)
+	}
+	u, _ := url.Parse(first.AuthURL(state, verifier))
+	f.challenge = u.Query().Get("code_challenge")
+
+	// ...and the callback lands on another one sharing the state store
+	second, _ := newTestProvider(t, f)
+	verifier, ok := states.ValidatePKCE(state)
+	if !ok {
+		t.Fatal("ValidatePKCE() rejected the state")
+	}
+	if _, err := second.Exchange(context.Background(), "good-code", verifier); err != nil {
+		t.Errorf("Exchange() failed on another instance: %v", err)
+	}
+}
+
+func TestSoundCloudProvider_Authenticate_NotConnected(t *testing.T) {
+	provider := NewSoundCloudProvider("client-id", "client-secret", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	if err := provider.Authenticate(providers.Account{UserID: "user123"}); err == nil {
+		t.Error("Expected error for a user without connection")
+	}
+}
+
+func TestSoundCloudProvider_GetPlaylists(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlists, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	if len(playlists) != 3 {
+		t.Fatalf("Expected likes and 2 playlists, got %d", len(playlists))
+	}
+	if playlists[0].ID != providers.LibraryPlaylistID || playlists[0].Name != "Likes" || playlists[0].TrackCount != 2 {
+		t.Errorf("Expected the likes first, got %+v", playlists[0])
+	}
+	if playlists[1].ID != "501" || playlists[1].Name != "Night Drive" || playlists[1].Description != "Slow" || playlists[1].Provider != "SoundCloud" {
+		t.Errorf("Unexpected playlist %+v", playlists[1])
+	}
+	if playlists[2].ID != "502" {
+		t.Errorf("Expected the second page to be followed, got %+v", playlists[2])
+	}
+}
+
+func TestSoundCloudProvider_GetPlaylists_ForeignNextHref(t *testing.T) {
+	var leaked []string
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("Authorization"))
+		writeJSON(w, PlaylistsPage{})
+	}))
+	t.Cleanup(elsewhere.Close)
+
+	f := newFakeSoundCloud(t)
+	f.nextHref = elsewhere.URL + "/me/playlists?cursor=2"
+	provider, _ := newTestProvider(t, f)
+
+	if _, err := provider.GetPlaylists(providers.Account{UserID: "user123"}); err == nil {
+		t.Error("Expected an error for a next_href outside the API")
+	}
+	if len(leaked) != 0 {
+		t.Errorf("Expected no request outside the API, got %d with %q", len(leaked), leaked)
+	}
+}
+
+func TestSoundCloudProvider_ExportPlaylist(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, _ := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	tests := []struct {
+		id       string
+		wantName string
+	}{
+		{"501", "Night Drive"},
+		{providers.LibraryPlaylistID, "Likes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			playlist, err := provider.ExportPlaylist(acct, tt.id)
+			if err != nil {
+				t.Fatalf("ExportPlaylist() failed: %v", err)
+			}
+			if playlist.Name != tt.wantName || len(playlist.Tracks) != 2 || playlist.TrackCount != 2 {
+				t.Fatalf("Unexpected playlist %+v", playlist)
+			}
+
+			want := models.Track{ID: "1001", Title: "Teardrop", Artist: "Massive Attack", Album: "Mezzanine", Duration: 330, ISRC: "GBAAA9800044"}
+			if playlist.Tracks[0] != want {
+				t.Errorf("Expected publisher metadata %+v, got %+v", want, playlist.Tracks[0])
+			}
+			want = models.Track{ID: "1002", Title: "Teardrop (Bootleg Edit)", Artist: "dj-someone", Duration: 412}
+			if playlist.Tracks[1] != want {
+				t.Errorf("Expected the uploader as artist %+v, got %+v", want, playlist.Tracks[1])
+			}
+		})
+	}
+}
+
+func TestSoundCloudProvider_RefreshesExpiredToken(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, store := newTestProvider(t, f)
+
+	conn, _ := store.Get("soundcloud", "user123", "42")
+	conn.ExpiresAt = time.Now().Add(-time.Minute)
+	store.Update(conn)
+
+	if _, err := provider.GetPlaylists(providers.Account{UserID: "user123"}); err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	conn, _ = store.Get("soundcloud", "user123", "42")
+	if conn.AccessToken != "token-2" || conn.RefreshToken != "refresh-2" {
+		t.Errorf("Expected the refreshed and rotated tokens to be stored, got %q and %q", conn.AccessToken, conn.RefreshToken)
+	}
+}
+
+func TestSoundCloudProvider_ImportPlaylist(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, _ := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	playlist := models.Playlist{
+		Name:        "Imported",
+		Description: "From Spotify",
+		Tracks:      []models.Track{{ID: "1001"}, {ID: "1002"}, {ID: "1001"}, {ID: "spotify:track:x"}, {Title: "Unmatched"}},
+	}
+	if err := provider.ImportPlaylist(acct, playlist); err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+
+	if len(f.created) != 1 {
+		t.Fatalf("Expected 1 created playlist, got %d", len(f.created))
+	}
+	created := f.created[0]
+	if created.Title != "Imported" || created.Description != "From Spotify" || created.Sharing != "private" {
+		t.Errorf("Unexpected playlist %+v", created)
+	}
+	if len(created.Tracks) != 2 || created.Tracks[0].ID != 1001 || created.Tracks[1].ID != 1002 {
+		t.Errorf("Expected tracks 1001 and 1002 once each, got %+v", created.Tracks)
+	}
+
+	var long models.Playlist
+	for i := 0; i <= maxPlaylistTracks; i++ {
+		long.Tracks = append(long.Tracks, models.Track{ID: strconv.Itoa(i + 1)})
+	}
+	if err := provider.ImportPlaylist(acct, long); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("Expected an error for a playlist over the limit, got %v", err)
+	}
+	if len(f.created) != 1 {
+		t.Error("Playlists over the limit should not be created")
+	}
+}
+
+func TestSoundCloudProvider_SaveTracks(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, _ := newTestProvider(t, f)
+
+	tracks := []models.Track{{ID: "1001"}, {ID: "1002"}, {ID: "1001"}, {ID: "spotify:track:x"}, {Title: "Unmatched"}}
+	if err := provider.SaveTracks(providers.Account{UserID: "user123"}, tracks); err != nil {
+		t.Fatalf("SaveTracks() failed: %v", err)
+	}
+
+	if len(f.liked) != 2 || f.liked[0] != "1001" || f.liked[1] != "1002" {
+		t.Errorf("Expected tracks 1001 and 1002 to be liked once each, got %v", f.liked)
+	}
+}
+
+func TestSoundCloudProvider_SearchTrack(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, _ := newTestProvider(t, f)
+
+	candidates, err := provider.SearchTrack(providers.Account{UserID: "user123"}, models.Track{Title: "Teardrop", Artist: "Massive Attack"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) != 2 || candidates[0].ISRC != "GBAAA9800044" {
+		t.Errorf("Unexpected candidates %+v", candidates)
+	}
+	if len(f.queries) != 1 || f.queries[0] != "Massive Attack Teardrop" {
+		t.Errorf("Unexpected search queries %v", f.queries)
+	}
+}
+
+func TestSoundCloudProvider_Revoke(t *testing.T) {
+	f := newFakeSoundCloud(t)
+	provider, store := newTestProvider(t, f)
+
+	conn, _ := store.Get("soundcloud", "user123", "42")
+	if err := provider.Revoke(context.Background(), conn); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if len(f.signedOut) != 1 || f.signedOut[0] != "token-1" {
+		t.Errorf("Expected token-1 to be signed out, got %v", f.signedOut)
+	}
+}
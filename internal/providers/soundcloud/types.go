@@ -0,0 +1,69 @@
+package soundcloud
+
+// User represents a SoundCloud user profile
+type User struct {
+	ID                    int64  `json:"id"`
+	Username              string `json:"username"`
+	FullName              string `json:"full_name"`
+	PublicFavoritesCount  int    `json:"public_favorites_count"`
+	PrivatePlaylistsCount int    `json:"private_playlists_count"`
+}
+
+// Playlist represents a SoundCloud playlist ("set"). Tracks is only set
+// when requested.
+type Playlist struct {
+	ID          int64   `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Sharing     string  `json:"sharing"`
+	TrackCount  int     `json:"track_count"`
+	Tracks      []Track `json:"tracks,omitempty"`
+}
+
+// Track represents a SoundCloud track. Uploads distributed by labels carry
+// publisher metadata naming the credited artist, the release and the ISRC.
+type Track struct {
+	ID                int64              `json:"id"`
+	Title             string             `json:"title"`
+	Duration          int                `json:"duration"` // in milliseconds
+	User              User               `json:"user"`
+	PublisherMetadata *PublisherMetadata `json:"publisher_metadata"`
+}
+
+// PublisherMetadata holds the label metadata of a track
+type PublisherMetadata struct {
+	Artist       string `json:"artist"`
+	AlbumTitle   string `json:"album_title"`
+	ReleaseTitle string `json:"release_title"`
+	ISRC         string `json:"isrc"`
+}
+
+// PlaylistsPage is a page of playlists with linked partitioning
+type PlaylistsPage struct {
+	Collection []Playlist `json:"collection"`
+	NextHref   string     `json:"next_href"`
+}
+
+// TracksPage is a page of tracks with linked partitioning
+type TracksPage struct {
+	Collection []Track `json:"collection"`
+	NextHref   string  `json:"next_href"`
+}
+
+// CreatePlaylistRequest is the body of a playlist creation
+type CreatePlaylistRequest struct {
+	Playlist NewPlaylist `json:"playlist"`
+}
+
+// NewPlaylist holds the attributes of a new playlist
+type NewPlaylist struct {
+	Title       string           `json:"title"`
+	Description string           `json:"description,omitempty"`
+	Sharing     string           `json:"sharing"`
+	Tracks      []TrackReference `json:"tracks"`
+}
+
+// TrackReference names a track by ID
+type TrackReference struct {
+	ID int64 `json:"id"`
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,11 +14,21 @@ import (
 	"golang.org/x/oauth2/spotify"
 
 	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
 const (
 	baseURL = "https://api.spotify.com/v1"
+
+	// likedSongsName is the name of the saved tracks library
+	likedSongsName = "Liked Songs"
+
+	// libraryScope grants reading the saved tracks and albums
+	libraryScope = "user-library-read"
+
+	// followScope grants reading the followed artists
+	followScope = "user-follow-read"
 )
 
 // SpotifyProvider implements the Provider interface for Spotify
@@ -38,6 +49,8 @@ func NewSpotifyProvider(clientID, clientSecret, redirectURL string, connectionSt
 			"user-read-email",
 			"playlist-read-private",
 			"playlist-read-collaborative",
+			libraryScope,
+			followScope,
 		},
 		Endpoint: spotify.Endpoint,
 	}
@@ -55,8 +68,8 @@ func (p *SpotifyProvider) Name() string {
 }
 
 // Authenticate checks if the user has a valid connection
-func (p *SpotifyProvider) Authenticate(userID string) error {
-	conn, err := p.connectionStore.Get("spotify", userID)
+func (p *SpotifyProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "spotify", acct.UserID, acct.ExternalUserID)
 	if err != nil {
 		return fmt.Errorf("not connected to Spotify: %w", err)
 	}
@@ -70,7 +83,13 @@ func (p *SpotifyProvider) Authenticate(userID string) error {
 
 // AuthURL returns the OAuth authorization URL
 func (p *SpotifyProvider) AuthURL(state string) string {
-	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	// show_dialog lets users switch Spotify accounts when linking another one
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("show_dialog", "true"))
+}
+
+// Accounts returns the user's connected Spotify accounts, oldest first
+func (p *SpotifyProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("spotify", userID)
 }
 
 // Exchange exchanges an authorization code for a token
@@ -102,8 +121,8 @@ func (p *SpotifyProvider) SaveConnection(ctx context.Context, token *oauth2.Toke
 }
 
 // GetPlaylists retrieves all playlists for the authenticated user
-func (p *SpotifyProvider) GetPlaylists(userID string) ([]models.Playlist, error) {
-	conn, err := p.connectionStore.Get("spotify", userID)
+func (p *SpotifyProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "spotify", acct.UserID, acct.ExternalUserID)
 	if err != nil {
 		return nil, fmt.Errorf("not connected: %w", err)
 	}
@@ -118,6 +137,24 @@ func (p *SpotifyProvider) GetPlaylists(userID string) ([]models.Playlist, error)
 	client := p.config.Client(ctx, token)
 
 	var allPlaylists []models.Playlist
+
+	// List the Liked Songs first. Connections made before the library scope
+	// was requested cannot read them until the account is reconnected.
+	if slices.Contains(conn.Scopes, libraryScope) {
+		liked, err := p.getTracksPage(client, fmt.Sprintf("%s/me/tracks?limit=1", baseURL))
+		if err != nil {
+			return nil, err
+		}
+		allPlaylists = append(allPlaylists, models.Playlist{
+			ID:         providers.LibraryPlaylistID,
+			Name:       likedSongsName,
+			TrackCount: liked.Total,
+			Provider:   "Spotify",
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		})
+	}
+
 	url := fmt.Sprintf("%s/me/playlists?limit=50", baseURL)
 
 	for url != "" {
@@ -164,8 +201,8 @@ func (p *SpotifyProvider) GetPlaylists(userID string) ([]models.Playlist, error)
 }
 
 // ExportPlaylist exports a specific playlist by ID
-func (p *SpotifyProvider) ExportPlaylist(userID, id string) (models.Playlist, error) {
-	conn, err := p.connectionStore.Get("spotify", userID)
+func (p *SpotifyProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "spotify", acct.UserID, acct.ExternalUserID)
 	if err != nil {
 		return models.Playlist{}, fmt.Errorf("not connected: %w", err)
 	}
@@ -179,56 +216,32 @@ func (p *SpotifyProvider) ExportPlaylist(userID, id string) (models.Playlist, er
 	ctx := context.Background()
 	client := p.config.Client(ctx, token)
 
-	// Get playlist details
-	playlistURL := fmt.Sprintf("%s/playlists/%s", baseURL, id)
-	resp, err := client.Get(playlistURL)
-	if err != nil {
-		return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return models.Playlist{}, fmt.Errorf("spotify API error: %s - %s", resp.Status, string(body))
-	}
-
-	var playlistDetail PlaylistDetail
-	if err := json.NewDecoder(resp.Body).Decode(&playlistDetail); err != nil {
-		return models.Playlist{}, fmt.Errorf("failed to decode playlist: %w", err)
-	}
-
-	playlist := models.Playlist{
-		ID:          playlistDetail.ID,
-		Name:        playlistDetail.Name,
-		Description: playlistDetail.Description,
-		TrackCount:  playlistDetail.Tracks.Total,
-		Provider:    "Spotify",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	var playlist models.Playlist
+	tracksURL := fmt.Sprintf("%s/playlists/%s/tracks?limit=100", baseURL, id)
+	if id == providers.LibraryPlaylistID {
+		playlist = models.Playlist{
+			ID:        providers.LibraryPlaylistID,
+			Name:      likedSongsName,
+			Provider:  "Spotify",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		tracksURL = fmt.Sprintf("%s/me/tracks?limit=50", baseURL)
+	} else {
+		// Get playlist details
+		playlist, err = p.getPlaylistDetail(client, id)
+		if err != nil {
+			return models.Playlist{}, err
+		}
 	}
 
 	// Fetch all tracks with pagination
 	var allTracks []models.Track
-	tracksURL := fmt.Sprintf("%s/playlists/%s/tracks?limit=100", baseURL, id)
-
 	for tracksURL != "" {
-		resp, err := client.Get(tracksURL)
+		tracksResponse, err := p.getTracksPage(client, tracksURL)
 		if err != nil {
-			return models.Playlist{}, fmt.Errorf("failed to fetch tracks: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			return models.Playlist{}, fmt.Errorf("spotify API error: %s - %s", resp.Status, string(body))
-		}
-
-		var tracksResponse TracksResponse
-		if err := json.NewDecoder(resp.Body).Decode(&tracksResponse); err != nil {
-			resp.Body.Close()
-			return models.Playlist{}, fmt.Errorf("failed to decode tracks: %w", err)
+			return models.Playlist{}, err
 		}
-		resp.Body.Close()
 
 		for _, item := range tracksResponse.Items {
 			if item.Track.ID == "" {
@@ -259,6 +272,7 @@ func (p *SpotifyProvider) ExportPlaylist(userID, id string) (models.Playlist, er
 			allTracks = append(allTracks, track)
 		}
 
+		playlist.TrackCount = tracksResponse.Total
 		tracksURL = tracksResponse.Next
 	}
 
@@ -273,10 +287,150 @@ func (p *SpotifyProvider) ExportPlaylist(userID, id string) (models.Playlist, er
 }
 
 // ImportPlaylist imports a playlist into Spotify (not implemented yet)
-func (p *SpotifyProvider) ImportPlaylist(playlist models.Playlist) error {
+func (p *SpotifyProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
 	return fmt.Errorf("importing to Spotify is not yet implemented")
 }
 
+// GetSavedAlbums retrieves the albums saved in the user's library
+func (p *SpotifyProvider) GetSavedAlbums(acct providers.Account) ([]models.Album, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "spotify", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return nil, fmt.Errorf("not connected: %w", err)
+	}
+	if !slices.Contains(conn.Scopes, libraryScope) {
+		return nil, fmt.Errorf("reconnect Spotify to allow reading saved albums")
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  conn.AccessToken,
+		RefreshToken: conn.RefreshToken,
+		Expiry:       conn.ExpiresAt,
+	}
+	client := p.config.Client(context.Background(), token)
+
+	var albums []models.Album
+	for url := fmt.Sprintf("%s/me/albums?limit=50", baseURL); url != ""; {
+		var page SavedAlbumsResponse
+		if err := p.getJSON(client, url, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch saved albums: %w", err)
+		}
+		for _, item := range page.Items {
+			albums = append(albums, toAlbum(item.Album))
+		}
+		url = page.Next
+	}
+
+	if err := p.updateTokenIfChanged(token, conn); err != nil {
+		return nil, fmt.Errorf("failed to update token: %w", err)
+	}
+	return albums, nil
+}
+
+// GetFollowedArtists retrieves the artists the user follows
+func (p *SpotifyProvider) GetFollowedArtists(acct providers.Account) ([]models.Artist, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "spotify", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return nil, fmt.Errorf("not connected: %w", err)
+	}
+	if !slices.Contains(conn.Scopes, followScope) {
+		return nil, fmt.Errorf("reconnect Spotify to allow reading followed artists")
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  conn.AccessToken,
+		RefreshToken: conn.RefreshToken,
+		Expiry:       conn.ExpiresAt,
+	}
+	client := p.config.Client(context.Background(), token)
+
+	var artists []models.Artist
+	for url := fmt.Sprintf("%s/me/following?type=artist&limit=50", baseURL); url != ""; {
+		var page FollowedArtistsResponse
+		if err := p.getJSON(client, url, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch followed artists: %w", err)
+		}
+		for _, item := range page.Artists.Items {
+			artists = append(artists, models.Artist{ID: item.ID, Name: item.Name})
+		}
+		url = page.Artists.Next
+	}
+
+	if err := p.updateTokenIfChanged(token, conn); err != nil {
+		return nil, fmt.Errorf("failed to update token: %w", err)
+	}
+	return artists, nil
+}
+
+// getJSON fetches url and decodes the JSON answer into v
+func (p *SpotifyProvider) getJSON(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("spotify API error: %s - %s", resp.Status, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// getPlaylistDetail fetches the details of a playlist
+func (p *SpotifyProvider) getPlaylistDetail(client *http.Client, id string) (models.Playlist, error) {
+	resp, err := client.Get(fmt.Sprintf("%s/playlists/%s", baseURL, id))
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return models.Playlist{}, fmt.Errorf("spotify API error: %s - %s", resp.Status, string(body))
+	}
+
+	var playlistDetail PlaylistDetail
+	if err := json.NewDecoder(resp.Body).Decode(&playlistDetail); err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to decode playlist: %w", err)
+	}
+
+	return models.Playlist{
+		ID:          playlistDetail.ID,
+		Name:        playlistDetail.Name,
+		Description: playlistDetail.Description,
+		TrackCount:  playlistDetail.Tracks.Total,
+		Provider:    "Spotify",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+// getTracksPage fetches a page of playlist tracks or saved tracks, which
+// share the same shape
+func (p *SpotifyProvider) getTracksPage(client *http.Client, url string) (*TracksResponse, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tracks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("spotify API error: %s - %s", resp.Status, string(body))
+	}
+
+	var tracksResponse TracksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tracksResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode tracks: %w", err)
+	}
+
+	return &tracksResponse, nil
+}
+
 // getUserProfile fetches the Spotify user profile
 func (p *SpotifyProvider) getUserProfile(ctx context.Context, token *oauth2.Token) (*UserProfile, error) {
 	client := p.config.Client(ctx, token)
@@ -312,3 +466,34 @@ func (p *SpotifyProvider) updateTokenIfChanged(newToken *oauth2.Token, conn *mod
 	}
 	return nil
 }
+
+// toAlbum converts a Spotify album to the domain model
+func toAlbum(a AlbumDetail) models.Album {
+	artistNames := make([]string, len(a.Artists))
+	for i, artist := range a.Artists {
+		artistNames[i] = artist.Name
+	}
+
+	return models.Album{
+		ID:          a.ID,
+		Title:       a.Name,
+		Artist:      strings.Join(artistNames, ", "),
+		UPC:         a.ExternalIDs.UPC,
+		TrackCount:  a.TotalTracks,
+		ReleaseDate: parseReleaseDate(a.ReleaseDate, a.ReleaseDatePrecision),
+	}
+}
+
+// parseReleaseDate parses a release date of the given precision, returning
+// the zero time if it is malformed
+func parseReleaseDate(date, precision string) time.Time {
+	layout := "2006-01-02"
+	switch precision {
+	case "year":
+		layout = "2006"
+	case "month":
+		layout = "2006-01"
+	}
+	t, _ := time.Parse(layout, date)
+	return t
+}
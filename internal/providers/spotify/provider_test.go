@@ -6,8 +6,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
@@ -135,7 +137,7 @@ func TestSpotifyProvider_Authenticate_NotConnected(t *testing.T) {
 	store := storage.NewInMemoryConnectionStore()
 	provider := NewSpotifyProvider("client-id", "client-secret", "http://localhost/callback", store)
 
-	err := provider.Authenticate("user123")
+	err := provider.Authenticate(providers.Account{UserID: "user123"})
 	if err == nil {
 		t.Error("Authenticate() should fail when not connected")
 	}
@@ -145,7 +147,7 @@ func TestSpotifyProvider_ImportPlaylist_NotImplemented(t *testing.T) {
 	store := storage.NewInMemoryConnectionStore()
 	provider := NewSpotifyProvider("client-id", "client-secret", "http://localhost/callback", store)
 
-	err := provider.ImportPlaylist(models.Playlist{})
+	err := provider.ImportPlaylist(providers.Account{UserID: "user123"}, models.Playlist{})
 	if err == nil {
 		t.Error("ImportPlaylist() should return error (not implemented)")
 	}
@@ -209,3 +211,91 @@ func TestPlaylistsResponse(t *testing.T) {
 		t.Errorf("Expected total 100, got %d", response.Total)
 	}
 }
+
+func TestSpotifyProvider_GetFollowedArtists_MissingScope(t *testing.T) {
+	store := storage.NewInMemoryConnectionStore()
+	provider := NewSpotifyProvider("client-id", "client-secret", "http://localhost/callback", store)
+
+	// Connections made before the follow scope was requested lack it
+	if err := store.Save(&models.Connection{
+		Provider:       "spotify",
+		UserID:         "user123",
+		ExternalUserID: "spotify123",
+		AccessToken:    "access-token",
+		ExpiresAt:      time.Now().Add(time.Hour),
+		Scopes:         []string{"playlist-read-private", libraryScope},
+		Connected:      true,
+	}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	_, err := provider.GetFollowedArtists(providers.Account{UserID: "user123", ExternalUserID: "spotify123"})
+	if err == nil || !strings.Contains(err.Error(), "reconnect") {
+		t.Errorf("GetFollowedArtists() error = %v, want a reconnect error", err)
+	}
+}
+
+func TestSavedAlbumsResponse(t *testing.T) {
+	jsonData := `{
+		"items": [
+			{
+				"album": {
+					"id": "album1",
+					"name": "Good Times",
+					"artists": [{"id": "a1", "name": "The Happy Band"}, {"id": "a2", "name": "Guest"}],
+					"total_tracks": 12,
+					"release_date": "1998",
+					"release_date_precision": "year",
+					"external_ids": {"upc": "0000000000001"}
+				}
+			}
+		],
+		"next": null,
+		"total": 1
+	}`
+
+	var response SavedAlbumsResponse
+	if err := json.Unmarshal([]byte(jsonData), &response); err != nil {
+		t.Fatalf("Failed to unmarshal saved albums response: %v", err)
+	}
+
+	if len(response.Items) != 1 {
+		t.Fatalf("Expected 1 album, got %d", len(response.Items))
+	}
+
+	album := toAlbum(response.Items[0].Album)
+	if album.Title != "Good Times" || album.Artist != "The Happy Band, Guest" {
+		t.Errorf("Unexpected album %q by %q", album.Title, album.Artist)
+	}
+	if album.UPC != "0000000000001" || album.TrackCount != 12 {
+		t.Errorf("Expected UPC and track count to be set, got %q and %d", album.UPC, album.TrackCount)
+	}
+	if album.ReleaseDate.Year() != 1998 {
+		t.Errorf("Expected release year 1998, got %v", album.ReleaseDate)
+	}
+	if response.Next != "" {
+		t.Errorf("Expected no next page, got %q", response.Next)
+	}
+}
+
+func TestFollowedArtistsResponse(t *testing.T) {
+	jsonData := `{
+		"artists": {
+			"items": [{"id": "artist1", "name": "Ocean Sounds"}],
+			"next": "https://api.spotify.com/v1/me/following?type=artist&after=artist1",
+			"total": 2
+		}
+	}`
+
+	var response FollowedArtistsResponse
+	if err := json.Unmarshal([]byte(jsonData), &response); err != nil {
+		t.Fatalf("Failed to unmarshal followed artists response: %v", err)
+	}
+
+	if len(response.Artists.Items) != 1 || response.Artists.Items[0].Name != "Ocean Sounds" {
+		t.Errorf("Unexpected artists %+v", response.Artists.Items)
+	}
+	if response.Artists.Next == "" {
+		t.Error("Expected a next cursor URL")
+	}
+}
@@ -67,7 +67,48 @@ type ArtistInfo struct {
 	Name string `json:"name"`
 }
 
-// ExternalIDs represents external IDs like ISRC
+// ExternalIDs represents external IDs like the ISRC of a track or the UPC
+// of an album
 type ExternalIDs struct {
 	ISRC string `json:"isrc"`
+	UPC  string `json:"upc"`
+}
+
+// SavedAlbumsResponse represents a page of the user's saved albums
+type SavedAlbumsResponse struct {
+	Items []SavedAlbumItem `json:"items"`
+	Next  string           `json:"next"`
+	Total int              `json:"total"`
+}
+
+// SavedAlbumItem represents an album in the user's library
+type SavedAlbumItem struct {
+	Album AlbumDetail `json:"album"`
+}
+
+// AlbumDetail represents detailed album information
+type AlbumDetail struct {
+	ID                   string       `json:"id"`
+	Name                 string       `json:"name"`
+	Artists              []ArtistInfo `json:"artists"`
+	TotalTracks          int          `json:"total_tracks"`
+	ReleaseDate          string       `json:"release_date"`
+	ReleaseDatePrecision string       `json:"release_date_precision"` // "year", "month" or "day"
+	ExternalIDs          ExternalIDs  `json:"external_ids"`
+}
+
+// FollowedArtistsResponse represents the response from Spotify's followed
+// artists endpoint, which pages with a cursor
+type FollowedArtistsResponse struct {
+	Artists struct {
+		Items []ArtistDetail `json:"items"`
+		Next  string         `json:"next"`
+		Total int            `json:"total"`
+	} `json:"artists"`
+}
+
+// ArtistDetail represents detailed artist information
+type ArtistDetail struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
 }
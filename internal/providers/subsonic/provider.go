@@ -0,0 +1,444 @@
+package subsonic
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+const (
+	// apiVersion is the Subsonic REST API version PlayPort speaks
+	apiVersion = "1.16.1"
+
+	// clientName identifies PlayPort to the server
+	clientName = "PlayPort"
+
+	// addSongsBatch is the number of songs added to a playlist per request,
+	// which keeps the query string short
+	addSongsBatch = 100
+
+	// searchLimit is the number of candidates a track search returns
+	searchLimit = 10
+
+	// starredName is the name of the starred songs playlist
+	starredName = "Starred"
+)
+
+// SubsonicProvider implements the Provider interface for servers speaking
+// the Subsonic REST API, such as Navidrome, Gonic or Airsonic.
+//
+// Accounts are linked with a username and either a password or an
+// OpenSubsonic API key. Passwords are not stored: the connection keeps the
+// salted token md5(password + salt) as AccessToken and the salt as
+// RefreshToken. For API keys, RefreshToken is empty.
+type SubsonicProvider struct {
+	serverURL       string
+	connectionStore storage.ConnectionStore
+	httpClient      *http.Client
+}
+
+// NewSubsonicProvider creates a new Subsonic provider for the server at
+// serverURL
+func NewSubsonicProvider(serverURL string, connectionStore storage.ConnectionStore) *SubsonicProvider {
+	return &SubsonicProvider{
+		serverURL:       strings.TrimRight(serverURL, "/"),
+		connectionStore: connectionStore,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the provider's name
+func (p *SubsonicProvider) Name() string {
+	return "Subsonic"
+}
+
+// Authenticate checks if the user has a valid connection
+func (p *SubsonicProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "subsonic", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return fmt.Errorf("not connected to Subsonic: %w", err)
+	}
+
+	if !conn.Connected {
+		return fmt.Errorf("Subsonic connection not active")
+	}
+
+	return nil
+}
+
+// Accounts returns the user's connected Subsonic accounts, oldest first
+func (p *SubsonicProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("subsonic", userID)
+}
+
+// Connect checks the credentials with a ping and saves the connection. A
+// token in creds is used as an API key, otherwise the password is.
+func (p *SubsonicProvider) Connect(ctx context.Context, userID string, creds providers.Credentials) (*models.Connection, error) {
+	if creds.Username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+
+	conn := &models.Connection{
+		Provider:         "subsonic",
+		UserID:           userID,
+		ExternalUserID:   creds.Username,
+		ExternalUserName: creds.Username,
+		Connected:        true,
+	}
+
+	switch {
+	case creds.Token != "":
+		conn.AccessToken = creds.Token
+	case creds.Password != "":
+		salt, err := newSalt()
+		if err != nil {
+			return nil, err
+		}
+		conn.AccessToken = saltedToken(creds.Password, salt)
+		conn.RefreshToken = salt
+	default:
+		return nil, fmt.Errorf("password or API key is required")
+	}
+
+	if _, err := p.call(ctx, conn, "ping", nil); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.Code == errorCodeWrongCredentials || apiErr.Code == errorCodeInvalidAPIKey) {
+			return nil, fmt.Errorf("wrong username, password or API key")
+		}
+		return nil, fmt.Errorf("failed to reach Subsonic server: %w", err)
+	}
+
+	if err := p.connectionStore.Save(conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// GetPlaylists retrieves the account's starred songs and all playlists the
+// account can see, including public playlists of other users
+func (p *SubsonicProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	starred, err := p.getStarred(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	starred.TrackCount = len(starred.Tracks)
+	starred.Tracks = nil
+
+	resp, err := p.call(ctx, conn, "getPlaylists", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+	}
+
+	playlists := []models.Playlist{starred}
+	if resp.Playlists != nil {
+		for _, item := range resp.Playlists.Playlist {
+			playlists = append(playlists, toPlaylist(item))
+		}
+	}
+	return playlists, nil
+}
+
+// ExportPlaylist exports a specific playlist by ID, or the starred songs for
+// the library playlist
+func (p *SubsonicProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	if id == providers.LibraryPlaylistID {
+		return p.getStarred(context.Background(), conn)
+	}
+
+	resp, err := p.call(context.Background(), conn, "getPlaylist", url.Values{"id": {id}})
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+	}
+	if resp.Playlist == nil {
+		return models.Playlist{}, fmt.Errorf("playlist not found: %s", id)
+	}
+
+	playlist := toPlaylist(*resp.Playlist)
+	for _, song := range resp.Playlist.Entry {
+		playlist.Tracks = append(playlist.Tracks, toTrack(song))
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// ImportPlaylist creates a playlist and adds the tracks to it. Tracks are
+// expected to carry song IDs of this server, as matched by SearchTrack;
+// tracks without ID and repeats are skipped.
+func (p *SubsonicProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	resp, err := p.call(ctx, conn, "createPlaylist", url.Values{"name": {playlist.Name}})
+	if err != nil {
+		return fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	// Servers implementing API versions before 1.14.0 do not return the
+	// new playlist
+	var playlistID string
+	if resp.Playlist != nil {
+		playlistID = resp.Playlist.ID
+	} else if playlistID, err = p.findPlaylist(ctx, conn, playlist.Name); err != nil {
+		return err
+	}
+
+	if playlist.Description != "" {
+		if _, err := p.call(ctx, conn, "updatePlaylist", url.Values{"playlistId": {playlistID}, "comment": {playlist.Description}}); err != nil {
+			return fmt.Errorf("failed to set playlist comment: %w", err)
+		}
+	}
+
+	songIDs := uniqueIDs(playlist.Tracks)
+	for i := 0; i < len(songIDs); i += addSongsBatch {
+		end := i + addSongsBatch
+		if end > len(songIDs) {
+			end = len(songIDs)
+		}
+		params := url.Values{"playlistId": {playlistID}, "songIdToAdd": songIDs[i:end]}
+		if _, err := p.call(ctx, conn, "updatePlaylist", params); err != nil {
+			return fmt.Errorf("failed to add songs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SaveTracks stars the tracks. Tracks are expected to carry song IDs of this
+// server; tracks without ID and repeats are skipped.
+func (p *SubsonicProvider) SaveTracks(acct providers.Account, tracks []models.Track) error {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	songIDs := uniqueIDs(tracks)
+	for i := 0; i < len(songIDs); i += addSongsBatch {
+		end := i + addSongsBatch
+		if end > len(songIDs) {
+			end = len(songIDs)
+		}
+		if _, err := p.call(ctx, conn, "star", url.Values{"id": songIDs[i:end]}); err != nil {
+			return fmt.Errorf("failed to star songs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SearchTrack searches the server's library by artist and title. Subsonic
+// has no ISRC lookup; the matcher still prefers a candidate whose ISRC tag
+// agrees.
+func (p *SubsonicProvider) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	conn, err := p.connection(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"query":       {strings.TrimSpace(t.Artist + " " + t.Title)},
+		"songCount":   {strconv.Itoa(searchLimit)},
+		"albumCount":  {"0"},
+		"artistCount": {"0"},
+	}
+	resp, err := p.call(context.Background(), conn, "search3", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search songs: %w", err)
+	}
+
+	var candidates []models.Track
+	if resp.SearchResult3 != nil {
+		for _, song := range resp.SearchResult3.Song {
+			candidates = append(candidates, toTrack(song))
+		}
+	}
+	return candidates, nil
+}
+
+// getStarred returns the account's starred songs as a playlist
+func (p *SubsonicProvider) getStarred(ctx context.Context, conn *models.Connection) (models.Playlist, error) {
+	resp, err := p.call(ctx, conn, "getStarred2", nil)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch starred songs: %w", err)
+	}
+
+	playlist := models.Playlist{
+		ID:        providers.LibraryPlaylistID,
+		Name:      starredName,
+		Provider:  "Subsonic",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if resp.Starred2 != nil {
+		for _, song := range resp.Starred2.Song {
+			playlist.Tracks = append(playlist.Tracks, toTrack(song))
+		}
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+	return playlist, nil
+}
+
+// findPlaylist returns the ID of the newest of the account's playlists with
+// the given name
+func (p *SubsonicProvider) findPlaylist(ctx context.Context, conn *models.Connection, name string) (string, error) {
+	resp, err := p.call(ctx, conn, "getPlaylists", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to find created playlist: %w", err)
+	}
+
+	var id string
+	if resp.Playlists != nil {
+		for _, item := range resp.Playlists.Playlist {
+			if item.Name == name && (item.Owner == "" || item.Owner == conn.ExternalUserID) {
+				id = item.ID
+			}
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("created playlist %q not found", name)
+	}
+	return id, nil
+}
+
+// connection returns the account's connection
+func (p *SubsonicProvider) connection(acct providers.Account) (*models.Connection, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "subsonic", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return nil, fmt.Errorf("not connected: %w", err)
+	}
+	return conn, nil
+}
+
+// call invokes an API method with the connection's credentials and returns
+// the response of a successful call
+func (p *SubsonicProvider) call(ctx context.Context, conn *models.Connection, method string, params url.Values) (*Response, error) {
+	query := url.Values{
+		"v": {apiVersion},
+		"c": {clientName},
+		"f": {"json"},
+	}
+	if conn.RefreshToken == "" {
+		query.Set("apiKey", conn.AccessToken)
+	} else {
+		query.Set("u", conn.ExternalUserID)
+		query.Set("t", conn.AccessToken)
+		query.Set("s", conn.RefreshToken)
+	}
+	for key, values := range params {
+		query[key] = values
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.serverURL+"/rest/"+method+".view?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("subsonic API error: %s - %s", resp.Status, string(body))
+	}
+
+	var result envelope
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Response.Status != "ok" {
+		if result.Response.Error != nil {
+			return nil, result.Response.Error
+		}
+		return nil, fmt.Errorf("subsonic API call %s failed", method)
+	}
+	return &result.Response, nil
+}
+
+// newSalt returns a random salt for token authentication
+func newSalt() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// saltedToken returns the authentication token of a password and salt
+func saltedToken(password, salt string) string {
+	sum := md5.Sum([]byte(password + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+// uniqueIDs returns the IDs of the tracks without repeats, skipping tracks
+// without ID
+func uniqueIDs(tracks []models.Track) []string {
+	var ids []string
+	seen := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		if t.ID == "" || seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+// toPlaylist converts a Subsonic playlist to the domain model, without
+// tracks
+func toPlaylist(item Playlist) models.Playlist {
+	return models.Playlist{
+		ID:          item.ID,
+		Name:        item.Name,
+		Description: item.Comment,
+		TrackCount:  item.SongCount,
+		Provider:    "Subsonic",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// toTrack converts a Subsonic song to the domain model
+func toTrack(song Song) models.Track {
+	t := models.Track{
+		ID:       song.ID,
+		Title:    song.Title,
+		Artist:   song.Artist,
+		Album:    song.Album,
+		Duration: song.Duration,
+	}
+	if len(song.ISRC) > 0 {
+		t.ISRC = song.ISRC[0]
+	}
+	return t
+}
@@ -0,0 +1,336 @@
+package subsonic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// fakeSubsonic is an httptest stand-in for a Subsonic server with the user
+// "alice", password "sesame" and API key "key-1"
+type fakeSubsonic struct {
+	mu            sync.Mutex
+	server        *httptest.Server
+	legacyCreate  bool // answer createPlaylist without the playlist, as before API 1.14.0
+	created       []string
+	comments      []string
+	added         [][]string // songIdToAdd parameters of updatePlaylist requests
+	starred       [][]string // id parameters of star requests
+	searchQueries []string
+}
+
+func newFakeSubsonic(t *testing.T) *fakeSubsonic {
+	f := &fakeSubsonic{}
+	mux := http.NewServeMux()
+
+	method := func(name string, handler func(q map[string][]string) Response) {
+		mux.HandleFunc("/rest/"+name+".view", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			var resp Response
+			switch {
+			case q.Get("f") != "json" || q.Get("c") == "" || q.Get("v") == "":
+				resp = Response{Status: "failed", Error: &APIError{Code: 10, Message: "Required parameter is missing"}}
+			case q.Get("apiKey") != "":
+				if q.Get("apiKey") != "key-1" || q.Get("u") != "" {
+					resp = Response{Status: "failed", Error: &APIError{Code: errorCodeInvalidAPIKey, Message: "Invalid API key"}}
+				}
+			case q.Get("u") != "alice" || q.Get("t") != saltedToken("sesame", q.Get("s")):
+				resp = Response{Status: "failed", Error: &APIError{Code: errorCodeWrongCredentials, Message: "Wrong username or password"}}
+			}
+			if resp.Status == "" {
+				resp = handler(q)
+				resp.Status = "ok"
+			}
+			resp.Version = apiVersion
+			json.NewEncoder(w).Encode(envelope{Response: resp})
+		})
+	}
+
+	method("ping", func(q map[string][]string) Response { return Response{} })
+	method("getPlaylists", func(q map[string][]string) Response {
+		playlists := []Playlist{
+			{ID: "1", Name: "Evening", Comment: "Quiet", Owner: "alice", SongCount: 2},
+			{ID: "2", Name: "Shared", Owner: "bob", SongCount: 5},
+		}
+		f.mu.Lock()
+		for i, name := range f.created {
+			playlists = append(playlists, Playlist{ID: strconv.Itoa(100 + i), Name: name, Owner: "alice"})
+		}
+		f.mu.Unlock()
+		return Response{Playlists: &Playlists{Playlist: playlists}}
+	})
+	method("getPlaylist", func(q map[string][]string) Response {
+		if q["id"][0] != "1" {
+			return Response{}
+		}
+		return Response{Playlist: &Playlist{
+			ID: "1", Name: "Evening", Comment: "Quiet", Owner: "alice", SongCount: 2,
+			Entry: []Song{
+				{ID: "s1", Title: "Teardrop", Artist: "Massive Attack", Album: "Mezzanine", Duration: 330, ISRC: []string{"GBAAA9800044"}},
+				{ID: "s2", Title: "Angel", Artist: "Massive Attack", Album: "Mezzanine", Duration: 379},
+			},
+		}}
+	})
+	method("createPlaylist", func(q map[string][]string) Response {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.created = append(f.created, q["name"][0])
+		if f.legacyCreate {
+			return Response{}
+		}
+		return Response{Playlist: &Playlist{ID: strconv.Itoa(100 + len(f.created) - 1), Name: q["name"][0]}}
+	})
+	method("updatePlaylist", func(q map[string][]string) Response {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if q["playlistId"][0] != "100" {
+			return Response{}
+		}
+		if comment, ok := q["comment"]; ok {
+			f.comments = append(f.comments, comment[0])
+		}
+		if songs, ok := q["songIdToAdd"]; ok {
+			f.added = append(f.added, songs)
+		}
+		return Response{}
+	})
+	method("getStarred2", func(q map[string][]string) Response {
+		return Response{Starred2: &Starred2{Song: []Song{
+			{ID: "s2", Title: "Angel", Artist: "Massive Attack", Album: "Mezzanine", Duration: 379},
+		}}}
+	})
+	method("star", func(q map[string][]string) Response {
+		f.mu.Lock()
+		f.starred = append(f.starred, q["id"])
+		f.mu.Unlock()
+		return Response{}
+	})
+	method("search3", func(q map[string][]string) Response {
+		f.mu.Lock()
+		f.searchQueries = append(f.searchQueries, q["query"][0])
+		f.mu.Unlock()
+		if q["query"][0] != "Massive Attack Teardrop" {
+			return Response{SearchResult3: &SearchResult3{}}
+		}
+		return Response{SearchResult3: &SearchResult3{Song: []Song{
+			{ID: "s1", Title: "Teardrop", Artist: "Massive Attack", Duration: 330, ISRC: []string{"GBAAA9800044"}},
+		}}}
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// newTestProvider returns a provider talking to f with alice connected for
+// user123 by password
+func newTestProvider(t *testing.T, f *fakeSubsonic) (*SubsonicProvider, storage.ConnectionStore) {
+	store := storage.NewInMemoryConnectionStore()
+	p := NewSubsonicProvider(f.server.URL+"/", store)
+
+	if _, err := p.Connect(context.Background(), "user123", providers.Credentials{Username: "alice", Password: "sesame"}); err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	return p, store
+}
+
+func TestSubsonicProvider_Name(t *testing.T) {
+	provider := NewSubsonicProvider("http://localhost:4533", storage.NewInMemoryConnectionStore())
+
+	if provider.Name() != "Subsonic" {
+		t.Errorf("Expected provider name 'Subsonic', got '%s'", provider.Name())
+	}
+}
+
+func TestSubsonicProvider_Connect(t *testing.T) {
+	f := newFakeSubsonic(t)
+
+	tests := []struct {
+		name    string
+		creds   providers.Credentials
+		wantErr string
+	}{
+		{"password", providers.Credentials{Username: "alice", Password: "sesame"}, ""},
+		{"API key", providers.Credentials{Username: "alice", Token: "key-1"}, ""},
+		{"wrong password", providers.Credentials{Username: "alice", Password: "open"}, "wrong username"},
+		{"wrong API key", providers.Credentials{Username: "alice", Token: "key-2"}, "wrong username"},
+		{"no secret", providers.Credentials{Username: "alice"}, "password or API key is required"},
+		{"no username", providers.Credentials{Password: "sesame"}, "username is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewInMemoryConnectionStore()
+			provider := NewSubsonicProvider(f.server.URL, store)
+
+			conn, err := provider.Connect(context.Background(), "user123", tt.creds)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if err := provider.Authenticate(providers.Account{UserID: "user123"}); err == nil {
+					t.Error("Failed connects should not save a connection")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Connect() failed: %v", err)
+			}
+
+			if conn.ExternalUserID != "alice" || conn.AccessToken == "sesame" {
+				t.Errorf("Unexpected connection %+v", conn)
+			}
+			if err := provider.Authenticate(providers.Account{UserID: "user123"}); err != nil {
+				t.Errorf("Authenticate() failed after Connect: %v", err)
+			}
+
+			// The stored credentials keep working
+			if _, err := provider.GetPlaylists(providers.Account{UserID: "user123"}); err != nil {
+				t.Errorf("GetPlaylists() failed with stored credentials: %v", err)
+			}
+		})
+	}
+}
+
+func TestSubsonicProvider_GetPlaylists(t *testing.T) {
+	f := newFakeSubsonic(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlists, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	if len(playlists) != 3 {
+		t.Fatalf("Expected the starred songs and 2 playlists, got %d", len(playlists))
+	}
+	if playlists[0].ID != providers.LibraryPlaylistID || playlists[0].Name != "Starred" || playlists[0].TrackCount != 1 || len(playlists[0].Tracks) != 0 {
+		t.Errorf("Expected the starred songs first, got %+v", playlists[0])
+	}
+	if playlists[1].ID != "1" || playlists[1].Name != "Evening" || playlists[1].Description != "Quiet" || playlists[1].TrackCount != 2 || playlists[1].Provider != "Subsonic" {
+		t.Errorf("Unexpected playlist %+v", playlists[1])
+	}
+}
+
+func TestSubsonicProvider_ExportPlaylist(t *testing.T) {
+	f := newFakeSubsonic(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlist, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, "1")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if len(playlist.Tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(playlist.Tracks))
+	}
+	want := models.Track{ID: "s1", Title: "Teardrop", Artist: "Massive Attack", Album: "Mezzanine", Duration: 330, ISRC: "GBAAA9800044"}
+	if playlist.Tracks[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, playlist.Tracks[0])
+	}
+	if playlist.Tracks[1].ISRC != "" {
+		t.Errorf("Expected no ISRC for an untagged song, got %q", playlist.Tracks[1].ISRC)
+	}
+
+	if _, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, "missing"); err == nil {
+		t.Error("Expected an error for a missing playlist")
+	}
+}
+
+func TestSubsonicProvider_ExportPlaylist_Starred(t *testing.T) {
+	f := newFakeSubsonic(t)
+	provider, _ := newTestProvider(t, f)
+
+	starred, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, providers.LibraryPlaylistID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if starred.Name != "Starred" || len(starred.Tracks) != 1 || starred.Tracks[0].ID != "s2" {
+		t.Errorf("Unexpected starred songs %+v", starred)
+	}
+}
+
+func TestSubsonicProvider_SaveTracks(t *testing.T) {
+	f := newFakeSubsonic(t)
+	provider, _ := newTestProvider(t, f)
+
+	tracks := []models.Track{{ID: "s1"}, {ID: "s2"}, {ID: "s1"}, {Title: "Unmatched"}}
+	if err := provider.SaveTracks(providers.Account{UserID: "user123"}, tracks); err != nil {
+		t.Fatalf("SaveTracks() failed: %v", err)
+	}
+
+	if len(f.starred) != 1 || strings.Join(f.starred[0], ",") != "s1,s2" {
+		t.Errorf("Expected s1 and s2 to be starred once each, got %v", f.starred)
+	}
+}
+
+func TestSubsonicProvider_ImportPlaylist(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		t.Run("legacy="+strconv.FormatBool(legacy), func(t *testing.T) {
+			f := newFakeSubsonic(t)
+			f.legacyCreate = legacy
+			provider, _ := newTestProvider(t, f)
+
+			playlist := models.Playlist{Name: "Imported", Description: "From Spotify"}
+			for i := 0; i < 150; i++ {
+				playlist.Tracks = append(playlist.Tracks, models.Track{ID: "s" + strconv.Itoa(i)})
+			}
+			playlist.Tracks = append(playlist.Tracks, models.Track{ID: "s0"}, models.Track{Title: "Unmatched"})
+
+			if err := provider.ImportPlaylist(providers.Account{UserID: "user123"}, playlist); err != nil {
+				t.Fatalf("ImportPlaylist() failed: %v", err)
+			}
+
+			if len(f.created) != 1 || f.created[0] != "Imported" {
+				t.Errorf("Unexpected created playlists %v", f.created)
+			}
+			if len(f.comments) != 1 || f.comments[0] != "From Spotify" {
+				t.Errorf("Expected the description as comment, got %v", f.comments)
+			}
+			if len(f.added) != 2 || len(f.added[0]) != 100 || len(f.added[1]) != 50 {
+				t.Fatalf("Expected songs to be added in batches of 100 and 50, got %d batches", len(f.added))
+			}
+			if f.added[0][0] != "s0" || f.added[1][49] != "s149" {
+				t.Errorf("Songs should be added in order, got %s and %s", f.added[0][0], f.added[1][49])
+			}
+		})
+	}
+}
+
+func TestSubsonicProvider_SearchTrack(t *testing.T) {
+	f := newFakeSubsonic(t)
+	provider, _ := newTestProvider(t, f)
+
+	candidates, err := provider.SearchTrack(providers.Account{UserID: "user123"}, models.Track{Title: "Teardrop", Artist: "Massive Attack"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].ID != "s1" || candidates[0].ISRC != "GBAAA9800044" {
+		t.Errorf("Unexpected candidates %+v", candidates)
+	}
+
+	candidates, err = provider.SearchTrack(providers.Account{UserID: "user123"}, models.Track{Title: "Unknown", Artist: "Nobody"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %+v", candidates)
+	}
+}
+
+func TestSaltedToken(t *testing.T) {
+	// Example from the Subsonic API documentation
+	if got := saltedToken("sesame", "c19b2d"); got != "26719a1196d2a940705a59634eb18eab" {
+		t.Errorf("saltedToken() = %s, want 26719a1196d2a940705a59634eb18eab", got)
+	}
+}
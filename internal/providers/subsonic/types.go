@@ -0,0 +1,74 @@
+package subsonic
+
+import "fmt"
+
+// Subsonic API error codes of failed logins
+const (
+	errorCodeWrongCredentials = 40
+	errorCodeInvalidAPIKey    = 44 // OpenSubsonic
+)
+
+// envelope wraps every answer of the Subsonic REST API in JSON format
+type envelope struct {
+	Response Response `json:"subsonic-response"`
+}
+
+// Response is the body of an answer. Only the field of the called method
+// is set.
+type Response struct {
+	Status        string         `json:"status"`
+	Version       string         `json:"version"`
+	Error         *APIError      `json:"error,omitempty"`
+	Playlists     *Playlists     `json:"playlists,omitempty"`
+	Playlist      *Playlist      `json:"playlist,omitempty"`
+	SearchResult3 *SearchResult3 `json:"searchResult3,omitempty"`
+	Starred2      *Starred2      `json:"starred2,omitempty"`
+}
+
+// APIError is the error of a failed call
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("subsonic API error %d: %s", e.Code, e.Message)
+}
+
+// Playlists is the answer of getPlaylists
+type Playlists struct {
+	Playlist []Playlist `json:"playlist"`
+}
+
+// Playlist represents a Subsonic playlist. Entry is only set by getPlaylist.
+type Playlist struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Comment   string `json:"comment"`
+	Owner     string `json:"owner"`
+	SongCount int    `json:"songCount"`
+	Entry     []Song `json:"entry"`
+}
+
+// Song represents a song. ISRC is an OpenSubsonic extension that servers
+// such as Navidrome fill from the file tags.
+type Song struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Artist   string   `json:"artist"`
+	Album    string   `json:"album"`
+	Duration int      `json:"duration"` // in seconds
+	ISRC     []string `json:"isrc"`
+}
+
+// SearchResult3 is the answer of search3
+type SearchResult3 struct {
+	Song []Song `json:"song"`
+}
+
+// Starred2 is the answer of getStarred2. Starred artists and albums are
+// left out.
+type Starred2 struct {
+	Song []Song `json:"song"`
+}
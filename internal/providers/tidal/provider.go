@@ -0,0 +1,762 @@
+package tidal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+const (
+	apiURL = "https://openapi.tidal.com/v2"
+
+	// mediaType is the JSON:API media type of request and response bodies
+	mediaType = "application/vnd.api+json"
+
+	// batchSize is the most tracks fetched, or playlist items added, per
+	// request
+	batchSize = 20
+
+	// searchLimit is the number of candidates a track search returns
+	searchLimit = 10
+
+	// maxRetries is how often a request is retried after a 429 response
+	maxRetries = 3
+
+	// defaultCountry is used when the user's profile has no country
+	defaultCountry = "US"
+
+	// collectionName is the name of the collection's tracks library
+	collectionName = "My Collection"
+
+	// collectionScope grants reading the user's collection
+	collectionScope = "collection.read"
+)
+
+// endpoint is Tidal's OAuth endpoint. The authorization code flow uses PKCE
+// and needs no client secret.
+var endpoint = oauth2.Endpoint{
+	AuthURL:   "https://login.tidal.com/authorize",
+	TokenURL:  "https://auth.tidal.com/v1/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+// errNoCreatedPlaylist is returned when creating a playlist answers no ID
+var errNoCreatedPlaylist = errors.New("tidal returned no playlist ID")
+
+var durationRegex = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)(?:\.\d+)?S)?$`)
+
+// TidalProvider implements the Provider interface for Tidal
+type TidalProvider struct {
+	config          *oauth2.Config
+	connectionStore storage.ConnectionStore
+	httpClient      *http.Client
+	apiURL          string
+	retryBackoff    time.Duration // used when a 429 response has no Retry-After
+
+	mu        sync.Mutex
+	countries map[string]string // country code by Tidal user ID
+}
+
+// NewTidalProvider creates a new Tidal provider. The client secret may be
+// empty.
+func NewTidalProvider(clientID, clientSecret, redirectURL string, connectionStore storage.ConnectionStore) *TidalProvider {
+	config := &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes: []string{
+			"user.read",
+			"playlists.read",
+			"playlists.write",
+			"search.read",
+			collectionScope,
+			"collection.write",
+		},
+		Endpoint: endpoint,
+	}
+
+	return &TidalProvider{
+		config:          config,
+		connectionStore: connectionStore,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		apiURL:          apiURL,
+		retryBackoff:    2 * time.Second,
+		countries:       make(map[string]string),
+	}
+}
+
+// Name returns the provider's name
+func (p *TidalProvider) Name() string {
+	return "Tidal"
+}
+
+// Authenticate checks if the user has a valid connection
+func (p *TidalProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "tidal", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return fmt.Errorf("not connected to Tidal: %w", err)
+	}
+
+	if !conn.Connected {
+		return fmt.Errorf("Tidal connection not active")
+	}
+
+	return nil
+}
+
+// AuthURL returns the OAuth authorization URL with the PKCE challenge of
+// verifier, which must be kept with the state for Exchange
+func (p *TidalProvider) AuthURL(state, verifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+// Accounts returns the user's connected Tidal accounts, oldest first
+func (p *TidalProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("tidal", userID)
+}
+
+// Exchange exchanges an authorization code for a token. verifier is the
+// PKCE code verifier passed to AuthURL.
+func (p *TidalProvider) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	return p.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+}
+
+// SaveConnection saves a connection after OAuth
+func (p *TidalProvider) SaveConnection(ctx context.Context, token *oauth2.Token, userID string) error {
+	user, attrs, err := p.getUser(ctx, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	name := attrs.Username
+	if name == "" {
+		name = attrs.Email
+	}
+
+	conn := &models.Connection{
+		Provider:         "tidal",
+		UserID:           userID,
+		ExternalUserID:   user.ID,
+		ExternalUserName: name,
+		AccessToken:      token.AccessToken,
+		RefreshToken:     token.RefreshToken,
+		ExpiresAt:        token.Expiry,
+		Scopes:           p.config.Scopes,
+		Connected:        true,
+	}
+
+	return p.connectionStore.Save(conn)
+}
+
+// GetPlaylists retrieves all playlists owned by the authenticated user
+func (p *TidalProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	ctx := context.Background()
+	conn, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return nil, err
+	}
+	country, err := p.country(ctx, conn.ExternalUserID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var allPlaylists []models.Playlist
+
+	// List the collection's tracks first. Connections made before the
+	// collection scopes were requested cannot read them until reconnected.
+	if slices.Contains(conn.Scopes, collectionScope) {
+		trackIDs, err := p.relationshipTrackIDs(ctx, accessToken, collectionPath(conn.ExternalUserID, country))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch collection: %w", err)
+		}
+		allPlaylists = append(allPlaylists, models.Playlist{
+			ID:         providers.LibraryPlaylistID,
+			Name:       collectionName,
+			TrackCount: len(trackIDs),
+			Provider:   "Tidal",
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		})
+	}
+
+	path := "/playlists?" + url.Values{
+		"countryCode":         {country},
+		"filter[r.owners.id]": {conn.ExternalUserID},
+	}.Encode()
+
+	for path != "" {
+		var doc Document
+		if err := p.call(ctx, accessToken, http.MethodGet, path, nil, &doc); err != nil {
+			return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+		}
+
+		var resources []Resource
+		if err := json.Unmarshal(doc.Data, &resources); err != nil {
+			return nil, fmt.Errorf("failed to decode playlists: %w", err)
+		}
+		for _, r := range resources {
+			var attrs PlaylistAttributes
+			if err := json.Unmarshal(r.Attributes, &attrs); err != nil {
+				return nil, fmt.Errorf("failed to decode playlist %s: %w", r.ID, err)
+			}
+			allPlaylists = append(allPlaylists, models.Playlist{
+				ID:          r.ID,
+				Name:        attrs.Name,
+				Description: attrs.Description,
+				TrackCount:  attrs.NumberOfItems,
+				Provider:    "Tidal",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			})
+		}
+
+		path = doc.Links.Next
+	}
+
+	return allPlaylists, nil
+}
+
+// ExportPlaylist exports a specific playlist by ID, or the collection's
+// tracks for the library playlist. Videos in the playlist are skipped.
+func (p *TidalProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	ctx := context.Background()
+	conn, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	country, err := p.country(ctx, conn.ExternalUserID, accessToken)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	countryParam := url.Values{"countryCode": {country}}.Encode()
+
+	if id == providers.LibraryPlaylistID {
+		playlist := models.Playlist{
+			ID:        providers.LibraryPlaylistID,
+			Name:      collectionName,
+			Provider:  "Tidal",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		return p.exportTracks(ctx, accessToken, country, collectionPath(conn.ExternalUserID, country), playlist)
+	}
+
+	var doc Document
+	if err := p.call(ctx, accessToken, http.MethodGet, "/playlists/"+url.PathEscape(id)+"?"+countryParam, nil, &doc); err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+	}
+	var resource Resource
+	if err := json.Unmarshal(doc.Data, &resource); err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to decode playlist: %w", err)
+	}
+	var attrs PlaylistAttributes
+	if err := json.Unmarshal(resource.Attributes, &attrs); err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to decode playlist: %w", err)
+	}
+
+	playlist := models.Playlist{
+		ID:          resource.ID,
+		Name:        attrs.Name,
+		Description: attrs.Description,
+		TrackCount:  attrs.NumberOfItems,
+		Provider:    "Tidal",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	return p.exportTracks(ctx, accessToken, country, "/playlists/"+url.PathEscape(id)+"/relationships/items?"+countryParam, playlist)
+}
+
+// exportTracks collects the track IDs of the relationship at path, then
+// fetches the tracks in batches and adds them to the playlist
+func (p *TidalProvider) exportTracks(ctx context.Context, accessToken, country, path string, playlist models.Playlist) (models.Playlist, error) {
+	trackIDs, err := p.relationshipTrackIDs(ctx, accessToken, path)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch playlist items: %w", err)
+	}
+
+	tracks, err := p.getTracks(ctx, accessToken, country, trackIDs)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	for _, trackID := range trackIDs {
+		if t, ok := tracks[trackID]; ok {
+			playlist.Tracks = append(playlist.Tracks, t)
+		}
+	}
+	playlist.TrackCount = len(playlist.Tracks)
+
+	return playlist, nil
+}
+
+// relationshipTrackIDs returns the IDs of the tracks in the relationship at
+// path, following its pages; other items such as videos are left out
+func (p *TidalProvider) relationshipTrackIDs(ctx context.Context, accessToken, path string) ([]string, error) {
+	var trackIDs []string
+	for path != "" {
+		var page Document
+		if err := p.call(ctx, accessToken, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		var items []ResourceIdentifier
+		if err := json.Unmarshal(page.Data, &items); err != nil {
+			return nil, fmt.Errorf("failed to decode items: %w", err)
+		}
+		for _, item := range items {
+			if item.Type == "tracks" {
+				trackIDs = append(trackIDs, item.ID)
+			}
+		}
+		path = page.Links.Next
+	}
+	return trackIDs, nil
+}
+
+// collectionPath returns the path of the tracks in a user's collection
+func collectionPath(tidalUserID, country string) string {
+	return "/userCollections/" + url.PathEscape(tidalUserID) + "/relationships/tracks?" + url.Values{"countryCode": {country}}.Encode()
+}
+
+// ImportPlaylist creates an unlisted playlist and adds the tracks to it.
+// Tracks are expected to carry Tidal track IDs, as matched by SearchTrack;
+// other tracks and repeats are skipped.
+func (p *TidalProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	ctx := context.Background()
+	conn, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return err
+	}
+	country, err := p.country(ctx, conn.ExternalUserID, accessToken)
+	if err != nil {
+		return err
+	}
+
+	create := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "playlists",
+			"attributes": PlaylistAttributes{
+				Name:        playlist.Name,
+				Description: playlist.Description,
+				AccessType:  "UNLISTED",
+			},
+		},
+	}
+	var doc Document
+	if err := p.call(ctx, accessToken, http.MethodPost, "/playlists?"+url.Values{"countryCode": {country}}.Encode(), create, &doc); err != nil {
+		return fmt.Errorf("failed to create playlist: %w", err)
+	}
+	var created Resource
+	if err := json.Unmarshal(doc.Data, &created); err != nil {
+		return fmt.Errorf("failed to decode created playlist: %w", err)
+	}
+	if created.ID == "" {
+		return errNoCreatedPlaylist
+	}
+
+	itemsPath := "/playlists/" + url.PathEscape(created.ID) + "/relationships/items"
+	if err := p.addTracks(ctx, accessToken, itemsPath, playlist.Tracks); err != nil {
+		return fmt.Errorf("failed to add tracks: %w", err)
+	}
+
+	return nil
+}
+
+// SaveTracks adds the tracks to the user's collection. Tracks are expected
+// to carry Tidal track IDs; other tracks and repeats are skipped.
+func (p *TidalProvider) SaveTracks(acct providers.Account, tracks []models.Track) error {
+	ctx := context.Background()
+	conn, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return err
+	}
+	country, err := p.country(ctx, conn.ExternalUserID, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := p.addTracks(ctx, accessToken, collectionPath(conn.ExternalUserID, country), tracks); err != nil {
+		return fmt.Errorf("failed to add tracks to collection: %w", err)
+	}
+
+	return nil
+}
+
+// addTracks adds the tracks with Tidal IDs to the relationship at path,
+// batchSize at a time and without repeats
+func (p *TidalProvider) addTracks(ctx context.Context, accessToken, path string, tracks []models.Track) error {
+	var items []ResourceIdentifier
+	seen := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		if _, err := strconv.ParseInt(t.ID, 10, 64); err != nil || seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		items = append(items, ResourceIdentifier{ID: t.ID, Type: "tracks"})
+	}
+
+	for i := 0; i < len(items); i += batchSize {
+		end := i + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		body := map[string]interface{}{"data": items[i:end]}
+		if err := p.call(ctx, accessToken, http.MethodPost, path, body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchTrack looks a track up by ISRC and searches the catalog by artist
+// and title. ISRC matches, if any, come first.
+func (p *TidalProvider) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	ctx := context.Background()
+	conn, accessToken, err := p.accessToken(ctx, acct)
+	if err != nil {
+		return nil, err
+	}
+	country, err := p.country(ctx, conn.ExternalUserID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []models.Track
+	seen := make(map[string]bool)
+
+	if t.ISRC != "" {
+		params := url.Values{
+			"countryCode":  {country},
+			"filter[isrc]": {t.ISRC},
+			"include":      {"artists,albums"},
+		}
+		var doc Document
+		if err := p.call(ctx, accessToken, http.MethodGet, "/tracks?"+params.Encode(), nil, &doc); err != nil {
+			return nil, fmt.Errorf("failed to look up ISRC: %w", err)
+		}
+		byISRC, err := decodeTracks(doc)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range byISRC {
+			seen[match.ID] = true
+			candidates = append(candidates, match)
+		}
+	}
+
+	query := strings.TrimSpace(t.Artist + " " + t.Title)
+	var doc Document
+	searchPath := "/searchResults/" + url.PathEscape(query) + "/relationships/tracks?" + url.Values{"countryCode": {country}}.Encode()
+	if err := p.call(ctx, accessToken, http.MethodGet, searchPath, nil, &doc); err != nil {
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+	var results []ResourceIdentifier
+	if err := json.Unmarshal(doc.Data, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode search results: %w", err)
+	}
+
+	var trackIDs []string
+	for _, r := range results {
+		if r.Type == "tracks" && !seen[r.ID] && len(trackIDs) < searchLimit {
+			trackIDs = append(trackIDs, r.ID)
+		}
+	}
+	tracks, err := p.getTracks(ctx, accessToken, country, trackIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, trackID := range trackIDs {
+		if match, ok := tracks[trackID]; ok {
+			candidates = append(candidates, match)
+		}
+	}
+
+	return candidates, nil
+}
+
+// getTracks fetches tracks with their artists and albums, batchSize at a
+// time, keyed by track ID
+func (p *TidalProvider) getTracks(ctx context.Context, accessToken, country string, ids []string) (map[string]models.Track, error) {
+	tracks := make(map[string]models.Track, len(ids))
+	for i := 0; i < len(ids); i += batchSize {
+		end := i + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		params := url.Values{
+			"countryCode": {country},
+			"filter[id]":  ids[i:end],
+			"include":     {"artists,albums"},
+		}
+
+		var doc Document
+		if err := p.call(ctx, accessToken, http.MethodGet, "/tracks?"+params.Encode(), nil, &doc); err != nil {
+			return nil, fmt.Errorf("failed to fetch tracks: %w", err)
+		}
+		batch, err := decodeTracks(doc)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range batch {
+			tracks[t.ID] = t
+		}
+	}
+	return tracks, nil
+}
+
+// decodeTracks converts the tracks of a document to the domain model,
+// taking artist and album names from the included resources
+func decodeTracks(doc Document) ([]models.Track, error) {
+	var resources []Resource
+	if err := json.Unmarshal(doc.Data, &resources); err != nil {
+		return nil, fmt.Errorf("failed to decode tracks: %w", err)
+	}
+
+	names := make(map[ResourceIdentifier]string, len(doc.Included))
+	for _, r := range doc.Included {
+		var attrs struct {
+			Name  string `json:"name"`
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(r.Attributes, &attrs); err != nil {
+			continue
+		}
+		name := attrs.Name
+		if r.Type == "albums" {
+			name = attrs.Title
+		}
+		names[ResourceIdentifier{ID: r.ID, Type: r.Type}] = name
+	}
+
+	tracks := make([]models.Track, 0, len(resources))
+	for _, r := range resources {
+		var attrs TrackAttributes
+		if err := json.Unmarshal(r.Attributes, &attrs); err != nil {
+			return nil, fmt.Errorf("failed to decode track %s: %w", r.ID, err)
+		}
+
+		t := models.Track{
+			ID:       r.ID,
+			Title:    attrs.FullTitle(),
+			Duration: parseDuration(attrs.Duration),
+			ISRC:     attrs.ISRC,
+		}
+
+		var artists []string
+		for _, id := range r.Relationships["artists"].Identifiers() {
+			if name := names[id]; name != "" {
+				artists = append(artists, name)
+			}
+		}
+		t.Artist = strings.Join(artists, ", ")
+
+		if albums := r.Relationships["albums"].Identifiers(); len(albums) > 0 {
+			t.Album = names[albums[0]]
+		}
+
+		tracks = append(tracks, t)
+	}
+	return tracks, nil
+}
+
+// getUser fetches the Tidal user the access token belongs to
+func (p *TidalProvider) getUser(ctx context.Context, accessToken string) (Resource, UserAttributes, error) {
+	var doc Document
+	if err := p.call(ctx, accessToken, http.MethodGet, "/users/me", nil, &doc); err != nil {
+		return Resource{}, UserAttributes{}, err
+	}
+
+	var user Resource
+	if err := json.Unmarshal(doc.Data, &user); err != nil {
+		return Resource{}, UserAttributes{}, fmt.Errorf("failed to decode user: %w", err)
+	}
+	var attrs UserAttributes
+	if err := json.Unmarshal(user.Attributes, &attrs); err != nil {
+		return Resource{}, UserAttributes{}, fmt.Errorf("failed to decode user: %w", err)
+	}
+
+	if attrs.Country != "" {
+		p.mu.Lock()
+		p.countries[user.ID] = attrs.Country
+		p.mu.Unlock()
+	}
+	return user, attrs, nil
+}
+
+// country returns the user's country, which catalog requests need to pick
+// the available tracks. It is fetched once per user.
+func (p *TidalProvider) country(ctx context.Context, tidalUserID, accessToken string) (string, error) {
+	p.mu.Lock()
+	country, ok := p.countries[tidalUserID]
+	p.mu.Unlock()
+	if ok {
+		return country, nil
+	}
+
+	_, attrs, err := p.getUser(ctx, accessToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user profile: %w", err)
+	}
+	if attrs.Country != "" {
+		return attrs.Country, nil
+	}
+
+	p.mu.Lock()
+	p.countries[tidalUserID] = defaultCountry
+	p.mu.Unlock()
+	return defaultCountry, nil
+}
+
+// accessToken returns the account's connection and a valid access token,
+// refreshing and storing the token when it has expired
+func (p *TidalProvider) accessToken(ctx context.Context, acct providers.Account) (*models.Connection, string, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "tidal", acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return nil, "", fmt.Errorf("not connected: %w", err)
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  conn.AccessToken,
+		RefreshToken: conn.RefreshToken,
+		Expiry:       conn.ExpiresAt,
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	fresh, err := p.config.TokenSource(ctx, token).Token()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	if fresh.AccessToken != conn.AccessToken {
+		conn.AccessToken = fresh.AccessToken
+		if fresh.RefreshToken != "" {
+			conn.RefreshToken = fresh.RefreshToken
+		}
+		conn.ExpiresAt = fresh.Expiry
+		if err := p.connectionStore.Update(conn); err != nil {
+			return nil, "", fmt.Errorf("failed to update token: %w", err)
+		}
+	}
+
+	return conn, fresh.AccessToken, nil
+}
+
+// call sends an API request and decodes the JSON:API answer into v, which
+// may be nil. path is relative to the API base URL and may carry a query,
+// as pagination links do. Requests answered with 429 are retried after the
+// time the server asks for.
+func (p *TidalProvider) call(ctx context.Context, accessToken, method, path string, body, v interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := p.do(ctx, accessToken, method, path, payload, v)
+		if resp == nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= maxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(p.retryAfter(resp)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// do sends a single API request, see call. The response is returned, with
+// its body consumed, unless the request could not be sent.
+func (p *TidalProvider) do(ctx context.Context, accessToken, method, path string, payload []byte, v interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.resolve(path), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", mediaType)
+	if payload != nil {
+		req.Header.Set("Content-Type", mediaType)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errDoc ErrorDocument
+		if json.Unmarshal(respBody, &errDoc) == nil && len(errDoc.Errors) > 0 {
+			return resp, &errDoc.Errors[0]
+		}
+		return resp, fmt.Errorf("tidal API error: %s - %s", resp.Status, string(respBody))
+	}
+
+	if v == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return resp, nil
+	}
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return resp, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp, nil
+}
+
+// resolve returns the URL of an API path or pagination link
+func (p *TidalProvider) resolve(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return p.apiURL + path
+}
+
+// retryAfter returns the wait a 429 response asks for
+func (p *TidalProvider) retryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return p.retryBackoff
+}
+
+// parseDuration parses an ISO 8601 duration string (e.g. "PT4M13S") into seconds
+func parseDuration(iso8601 string) int {
+	matches := durationRegex.FindStringSubmatch(iso8601)
+	if matches == nil {
+		return 0
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.Atoi(matches[3])
+
+	return hours*3600 + minutes*60 + seconds
+}
@@ -0,0 +1,655 @@
+package tidal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// fakeTidal is an httptest stand-in for the Tidal API and OAuth endpoints
+type fakeTidal struct {
+	mu          sync.Mutex
+	server      *httptest.Server
+	challenge   string // PKCE challenge the token endpoint accepts
+	created     []PlaylistAttributes
+	added       [][]ResourceIdentifier // bodies of add-item requests
+	collected   [][]ResourceIdentifier // bodies of add-to-collection requests
+	trackBatch  []int                  // number of IDs per track request
+	rateLimited int                    // requests to answer with 429 first
+}
+
+// fakeTrack is a catalog track of the fake server
+type fakeTrack struct {
+	id, title, version, isrc, duration, artistID, albumID string
+}
+
+var fakeArtists = map[string]string{"a1": "Röyksopp", "a2": "Robyn", "a3": "Daft Punk"}
+var fakeAlbums = map[string]string{"b1": "The Inevitable End", "b2": "Discovery"}
+
+// fakeCatalog returns the fake server's tracks: 23 numbered tracks plus
+// two Daft Punk songs
+func fakeCatalog() map[string]fakeTrack {
+	catalog := map[string]fakeTrack{
+		"3135556": {id: "3135556", title: "Harder, Better, Faster, Stronger", isrc: "GBDUW0000059", duration: "PT3M44S", artistID: "a3", albumID: "b2"},
+		"3135553": {id: "3135553", title: "One More Time", version: "Radio Edit", isrc: "GBDUW0000053", duration: "PT5M20S", artistID: "a3", albumID: "b2"},
+	}
+	for i := 0; i < 23; i++ {
+		id := strconv.Itoa(1000 + i)
+		catalog[id] = fakeTrack{id: id, title: "Song " + id, isrc: "NOXYZ" + id, duration: "PT4M", artistID: "a1", albumID: "b1"}
+	}
+	return catalog
+}
+
+func newFakeTidal(t *testing.T) *fakeTidal {
+	f := &fakeTidal{}
+	catalog := fakeCatalog()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			writeJSON(w, map[string]interface{}{"access_token": "token-1", "refresh_token": "refresh-1", "token_type": "Bearer", "expires_in": 3600})
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			writeJSON(w, map[string]interface{}{"access_token": "token-2", "token_type": "Bearer", "expires_in": 3600})
+		}
+	})
+
+	api := func(pattern string, handler func(w http.ResponseWriter, r *http.Request)) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if auth := r.Header.Get("Authorization"); auth != "Bearer token-1" && auth != "Bearer token-2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				writeJSON(w, ErrorDocument{Errors: []APIError{{Status: "401", Code: "UNAUTHORIZED", Detail: "invalid token"}}})
+				return
+			}
+			f.mu.Lock()
+			limited := f.rateLimited > 0
+			if limited {
+				f.rateLimited--
+			}
+			f.mu.Unlock()
+			if limited {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			if r.Method == http.MethodGet && r.URL.Query().Get("countryCode") != "NO" && r.URL.Path != "/v2/users/me" {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(w, ErrorDocument{Errors: []APIError{{Status: "400", Code: "VALUE_REGEX_MISMATCH", Detail: "countryCode"}}})
+				return
+			}
+			w.Header().Set("Content-Type", mediaType)
+			handler(w, r)
+		})
+	}
+
+	api("/v2/users/me", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"id": "7", "type": "users",
+			"attributes": UserAttributes{Username: "tidalfan", Country: "NO"},
+		}})
+	})
+	api("/v2/playlists", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var body struct {
+				Data struct {
+					Type       string             `json:"type"`
+					Attributes PlaylistAttributes `json:"attributes"`
+				} `json:"data"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			f.mu.Lock()
+			f.created = append(f.created, body.Data.Attributes)
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"id": "new-pl", "type": "playlists"}})
+			return
+		}
+		if r.URL.Query().Get("filter[r.owners.id]") != "7" {
+			writeJSON(w, map[string]interface{}{"data": []interface{}{}})
+			return
+		}
+		if r.URL.Query().Get("page[cursor]") == "" {
+			writeJSON(w, map[string]interface{}{
+				"data": []interface{}{
+					playlistResource("pl-1", PlaylistAttributes{Name: "Nordic", Description: "Cold synths", NumberOfItems: 24}),
+					playlistResource("pl-2", PlaylistAttributes{Name: "Empty"}),
+				},
+				"links": Links{Next: "/playlists?countryCode=NO&filter%5Br.owners.id%5D=7&page%5Bcursor%5D=next"},
+			})
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"data": []interface{}{playlistResource("pl-3", PlaylistAttributes{Name: "Daft", NumberOfItems: 2})},
+		})
+	})
+	api("/v2/playlists/pl-1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"data": playlistResource("pl-1", PlaylistAttributes{Name: "Nordic", Description: "Cold synths", NumberOfItems: 24})})
+	})
+	api("/v2/playlists/pl-1/relationships/items", func(w http.ResponseWriter, r *http.Request) {
+		// 23 tracks over two pages, with a video in between
+		var items []ResourceIdentifier
+		next := ""
+		if r.URL.Query().Get("page[cursor]") == "" {
+			for i := 0; i < 12; i++ {
+				items = append(items, ResourceIdentifier{ID: strconv.Itoa(1000 + i), Type: "tracks"})
+			}
+			items = append(items, ResourceIdentifier{ID: "v1", Type: "videos"})
+			next = "/playlists/pl-1/relationships/items?countryCode=NO&page%5Bcursor%5D=2"
+		} else {
+			for i := 12; i < 23; i++ {
+				items = append(items, ResourceIdentifier{ID: strconv.Itoa(1000 + i), Type: "tracks"})
+			}
+		}
+		writeJSON(w, map[string]interface{}{"data": items, "links": Links{Next: next}})
+	})
+	api("/v2/playlists/new-pl/relationships/items", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Data []ResourceIdentifier `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.added = append(f.added, body.Data)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+	api("/v2/userCollections/7/relationships/tracks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if r.URL.Query().Get("countryCode") != "NO" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var body struct {
+				Data []ResourceIdentifier `json:"data"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			f.mu.Lock()
+			f.collected = append(f.collected, body.Data)
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": []ResourceIdentifier{{ID: "3135556", Type: "tracks"}, {ID: "3135553", Type: "tracks"}}})
+	})
+	api("/v2/tracks", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var tracks []fakeTrack
+		if isrc := q.Get("filter[isrc]"); isrc != "" {
+			for _, ft := range catalog {
+				if ft.isrc == isrc {
+					tracks = append(tracks, ft)
+				}
+			}
+		} else {
+			f.mu.Lock()
+			f.trackBatch = append(f.trackBatch, len(q["filter[id]"]))
+			f.mu.Unlock()
+			// Answer in reverse order, which the provider must not rely on
+			ids := q["filter[id]"]
+			for i := len(ids) - 1; i >= 0; i-- {
+				if ft, ok := catalog[ids[i]]; ok {
+					tracks = append(tracks, ft)
+				}
+			}
+		}
+		writeJSON(w, tracksDocument(tracks))
+	})
+	api("/v2/searchResults/", func(w http.ResponseWriter, r *http.Request) {
+		var items []ResourceIdentifier
+		if strings.HasPrefix(r.URL.Path, "/v2/searchResults/Daft Punk One More Time/") {
+			items = []ResourceIdentifier{{ID: "3135553", Type: "tracks"}, {ID: "3135556", Type: "tracks"}}
+		}
+		writeJSON(w, map[string]interface{}{"data": items})
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func playlistResource(id string, attrs PlaylistAttributes) map[string]interface{} {
+	return map[string]interface{}{"id": id, "type": "playlists", "attributes": attrs}
+}
+
+// tracksDocument returns a tracks document including artists and albums
+func tracksDocument(tracks []fakeTrack) map[string]interface{} {
+	data := []interface{}{}
+	included := []interface{}{}
+	for _, ft := range tracks {
+		data = append(data, map[string]interface{}{
+			"id": ft.id, "type": "tracks",
+			"attributes": TrackAttributes{Title: ft.title, Version: ft.version, ISRC: ft.isrc, Duration: ft.duration},
+			"relationships": map[string]interface{}{
+				"artists": map[string]interface{}{"data": []ResourceIdentifier{{ID: ft.artistID, Type: "artists"}}},
+				"albums":  map[string]interface{}{"data": []ResourceIdentifier{{ID: ft.albumID, Type: "albums"}}},
+			},
+		})
+		included = append(included,
+			map[string]interface{}{"id": ft.artistID, "type": "artists", "attributes": ArtistAttributes{Name: fakeArtists[ft.artistID]}},
+			map[string]interface{}{"id": ft.albumID, "type": "albums", "attributes": AlbumAttributes{Title: fakeAlbums[ft.albumID]}},
+		)
+	}
+	return map[string]interface{}{"data": data, "included": included}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	json.NewEncoder(w).Encode(v)
+}
+
+// newTestProvider returns a provider talking to f with user123 connected
+func newTestProvider(t *testing.T, f *fakeTidal) (*TidalProvider, storage.ConnectionStore) {
+	store := storage.NewInMemoryConnectionStore()
+	p := NewTidalProvider("client-id", "", "http://localhost/auth/tidal/callback", store)
+	p.apiURL = f.server.URL + "/v2"
+	p.config.Endpoint.AuthURL = f.server.URL + "/authorize"
+	p.config.Endpoint.TokenURL = f.server.URL + "/v1/oauth2/token"
+	p.retryBackoff = 0
+
+	err := store.Save(&models.Connection{
+		Provider:       "tidal",
+		UserID:         "user123",
+		ExternalUserID: "7",
+		AccessToken:    "token-1",
+		RefreshToken:   "refresh-1",
+		ExpiresAt:      time.Now().Add(time.Hour),
+		Connected:      true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to save connection: %v", err)
+	}
+	return p, store
+}
+
+func TestTidalProvider_Name(t *testing.T) {
+	provider := NewTidalProvider("client-id", "", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	if provider.Name() != "Tidal" {
+		t.Errorf("Expected provider name 'Tidal', got '%s'", provider.Name())
+	}
+}
+
+func TestTidalProvider_AuthURL(t *testing.T) {
+	provider := NewTidalProvider("client-id", "", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	u, err := url.Parse(provider.AuthURL("test-state", "test-verifier"))
+	if err != nil {
+		t.Fatalf("AuthURL() is not a URL: %v", err)
+	}
+
+	if u.Host != "login.tidal.com" || u.Path != "/authorize" {
+		t.Errorf("Expected the Tidal authorization endpoint, got %s", u)
+	}
+	q := u.Query()
+	if q.Get("client_id") != "client-id" || q.Get("state") != "test-state" || q.Get("redirect_uri") != "http://localhost/callback" {
+		t.Errorf("AuthURL() is missing parameters: %s", u.RawQuery)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Errorf("Expected a S256 PKCE challenge, got %s", u.RawQuery)
+	}
+	if !strings.Contains(q.Get("scope"), "playlists.write") {
+		t.Errorf("Expected the playlists.write scope, got %q", q.Get("scope"))
+	}
+
+	other, _ := url.Parse(provider.AuthURL("other-state", "other-verifier"))
+	if other.Query().Get("code_challenge") == q.Get("code_challenge") {
+		t.Error("Expected a different challenge per verifier")
+	}
+}
+
+func TestTidalProvider_ExchangeAndSaveConnection(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, store := newTestProvider(t, f)
+
+	states := auth.NewInMemoryStateStore()
+	state, verifier, err := states.GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE() failed: %v", err)
+	}
+	u, _ := url.Parse(provider.AuthURL(state, verifier))
+	f.challenge = u.Query().Get("code_challenge")
+
+	ctx := context.Background()
+	if _, err := provider.Exchange(ctx, "good-code", oauth2.GenerateVerifier()); err == nil {
+		t.Error("Expected an error exchanging with another verifier")
+	}
+
+	verifier, ok := states.ValidatePKCE(state)
+	if !ok {
+		t.Fatal("ValidatePKCE() rejected the state")
+	}
+	token, err := provider.Exchange(ctx, "good-code", verifier)
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	if token.AccessToken != "token-1" || token.RefreshToken != "refresh-1" {
+		t.Errorf("Unexpected token %+v", token)
+	}
+
+	if err := provider.SaveConnection(ctx, token, "user456"); err != nil {
+		t.Fatalf("SaveConnection() failed: %v", err)
+	}
+	conn, err := store.Get("tidal", "user456", "7")
+	if err != nil {
+		t.Fatalf("Expected a saved connection: %v", err)
+	}
+	if conn.ExternalUserName != "tidalfan" || conn.RefreshToken != "refresh-1" || !conn.Connected {
+		t.Errorf("Unexpected connection %+v", conn)
+	}
+
+	if err := provider.Authenticate(providers.Account{UserID: "user456"}); err != nil {
+		t.Errorf("Authenticate() failed after SaveConnection: %v", err)
+	}
+}
+
+func TestTidalProvider_Exchange_OtherInstance(t *testing.T) {
+	f := newFakeTidal(t)
+	states := auth.NewInMemoryStateStore()
+
+	// The authorization starts on one instance...
+	first := NewTidalProvider("client-id", "", "http://localhost/auth/tidal/callback", storage.NewInMemoryConnectionStore())
+	state, verifier, err := states.GeneratePKCE()
+	if err != nil {
+		t.Fatalf("GeneratePKCE() failed: %v", err)
+	}
+	u, _ := url.Parse(first.AuthURL(state, verifier))
+	f.challenge = u.Query().Get("code_challenge")
+
+	// ...and the callback lands on another one sharing the state store
+	second, _ := newTestProvider(t, f)
+	verifier, ok := states.ValidatePKCE(state)
+	if !ok {
+		t.Fatal("ValidatePKCE() rejected the state")
+	}
+	if _, err := second.Exchange(context.Background(), "good-code", verifier); err != nil {
+		t.Errorf("Exchange() failed on another instance: %v", err)
+	}
+}
+
+func TestTidalProvider_Authenticate_NotConnected(t *testing.T) {
+	provider := NewTidalProvider("client-id", "", "http://localhost/callback", storage.NewInMemoryConnectionStore())
+
+	if err := provider.Authenticate(providers.Account{UserID: "user123"}); err == nil {
+		t.Error("Expected error for a user without connection")
+	}
+}
+
+func TestTidalProvider_GetPlaylists(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, _ := newTestProvider(t, f)
+	f.rateLimited = 2
+
+	playlists, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	if len(playlists) != 3 {
+		t.Fatalf("Expected 3 playlists over two pages, got %d", len(playlists))
+	}
+	if playlists[0].ID != "pl-1" || playlists[0].Name != "Nordic" || playlists[0].TrackCount != 24 || playlists[0].Provider != "Tidal" {
+		t.Errorf("Unexpected first playlist %+v", playlists[0])
+	}
+	if playlists[2].Name != "Daft" {
+		t.Errorf("Expected the second page's playlist last, got %q", playlists[2].Name)
+	}
+}
+
+func TestTidalProvider_ExportPlaylist(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlist, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, "pl-1")
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if playlist.Name != "Nordic" || playlist.Description != "Cold synths" {
+		t.Errorf("Unexpected playlist %+v", playlist)
+	}
+	if len(playlist.Tracks) != 23 || playlist.TrackCount != 23 {
+		t.Fatalf("Expected 23 tracks without the video, got %d", len(playlist.Tracks))
+	}
+	for i, track := range playlist.Tracks {
+		if want := strconv.Itoa(1000 + i); track.ID != want {
+			t.Fatalf("Expected track %d to be %s, got %s", i, want, track.ID)
+		}
+	}
+
+	first := playlist.Tracks[0]
+	if first.Title != "Song 1000" || first.Artist != "Röyksopp" || first.Album != "The Inevitable End" || first.Duration != 240 || first.ISRC != "NOXYZ1000" {
+		t.Errorf("Unexpected track %+v", first)
+	}
+
+	if fmt.Sprint(f.trackBatch) != "[20 3]" {
+		t.Errorf("Expected tracks to be fetched in batches of 20, got %v", f.trackBatch)
+	}
+}
+
+func TestTidalProvider_RefreshesExpiredToken(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, store := newTestProvider(t, f)
+
+	conn, _ := store.Get("tidal", "user123", "7")
+	conn.ExpiresAt = time.Now().Add(-time.Minute)
+	store.Update(conn)
+
+	if _, err := provider.GetPlaylists(providers.Account{UserID: "user123"}); err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+
+	conn, _ = store.Get("tidal", "user123", "7")
+	if conn.AccessToken != "token-2" {
+		t.Errorf("Expected the refreshed token to be stored, got %q", conn.AccessToken)
+	}
+	if conn.RefreshToken != "refresh-1" {
+		t.Errorf("Expected the refresh token to be kept, got %q", conn.RefreshToken)
+	}
+}
+
+func TestTidalProvider_ImportPlaylist(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlist := models.Playlist{Name: "Imported", Description: "From Spotify"}
+	for i := 0; i < 23; i++ {
+		playlist.Tracks = append(playlist.Tracks, models.Track{ID: strconv.Itoa(1000 + i)})
+	}
+	playlist.Tracks = append(playlist.Tracks,
+		models.Track{ID: "1000"},                     // repeat
+		models.Track{ID: "spotify:track:abc"},        // not a Tidal ID
+		models.Track{Title: "Unmatched, without ID"}, // no ID
+	)
+
+	if err := provider.ImportPlaylist(providers.Account{UserID: "user123"}, playlist); err != nil {
+		t.Fatalf("ImportPlaylist() failed: %v", err)
+	}
+
+	if len(f.created) != 1 || f.created[0].Name != "Imported" || f.created[0].Description != "From Spotify" || f.created[0].AccessType != "UNLISTED" {
+		t.Errorf("Unexpected created playlists %+v", f.created)
+	}
+	if len(f.added) != 2 || len(f.added[0]) != 20 || len(f.added[1]) != 3 {
+		t.Fatalf("Expected items to be added in batches of 20 and 3, got %v", f.added)
+	}
+	if f.added[0][0] != (ResourceIdentifier{ID: "1000", Type: "tracks"}) {
+		t.Errorf("Unexpected first item %+v", f.added[0][0])
+	}
+}
+
+func TestTidalProvider_SearchTrack(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, _ := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	candidates, err := provider.SearchTrack(acct, models.Track{Title: "One More Time", Artist: "Daft Punk", ISRC: "GBDUW0000053"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("Expected the ISRC match and one more search result, got %+v", candidates)
+	}
+	if candidates[0].ID != "3135553" || candidates[0].Title != "One More Time (Radio Edit)" || candidates[0].Artist != "Daft Punk" {
+		t.Errorf("Expected the ISRC match first, got %+v", candidates[0])
+	}
+	if candidates[1].ID != "3135556" {
+		t.Errorf("Expected the other search result second, got %+v", candidates[1])
+	}
+
+	candidates, err = provider.SearchTrack(acct, models.Track{Title: "Unknown", Artist: "Nobody"})
+	if err != nil {
+		t.Fatalf("SearchTrack() failed: %v", err)
+	}
+	if len(candidates) != 0 {
+		t.Errorf("Expected no candidates, got %+v", candidates)
+	}
+}
+
+func TestTidalProvider_InvalidToken(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, store := newTestProvider(t, f)
+
+	conn, _ := store.Get("tidal", "user123", "7")
+	conn.AccessToken = "revoked"
+	store.Update(conn)
+
+	_, err := provider.GetPlaylists(providers.Account{UserID: "user123"})
+	if err == nil || !strings.Contains(err.Error(), "UNAUTHORIZED") {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"PT3M44S", 224},
+		{"PT1H2M3S", 3723},
+		{"PT4M", 240},
+		{"PT59.5S", 59},
+		{"", 0},
+		{"invalid", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := parseDuration(tt.input); got != tt.want {
+				t.Errorf("parseDuration(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelationship_Identifiers(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"list", `[{"id":"1","type":"artists"},{"id":"2","type":"artists"}]`, 2},
+		{"single", `{"id":"1","type":"users"}`, 1},
+		{"null", `null`, 0},
+		{"missing", ``, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Relationship{Data: json.RawMessage(tt.data)}
+			if got := r.Identifiers(); len(got) != tt.want {
+				t.Errorf("Expected %d identifiers, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTidalProvider_GetPlaylists_Collection(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, store := newTestProvider(t, f)
+	acct := providers.Account{UserID: "user123"}
+
+	// Connections without the collection scopes keep listing only playlists
+	playlists, err := provider.GetPlaylists(acct)
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+	if len(playlists) != 3 || playlists[0].ID == providers.LibraryPlaylistID {
+		t.Fatalf("Expected no collection without its scope, got %+v", playlists[0])
+	}
+
+	conn, _ := store.Get("tidal", "user123", "7")
+	conn.Scopes = provider.config.Scopes
+	store.Update(conn)
+
+	playlists, err = provider.GetPlaylists(acct)
+	if err != nil {
+		t.Fatalf("GetPlaylists() failed: %v", err)
+	}
+	if len(playlists) != 4 {
+		t.Fatalf("Expected the collection and 3 playlists, got %d", len(playlists))
+	}
+	if playlists[0].ID != providers.LibraryPlaylistID || playlists[0].Name != "My Collection" || playlists[0].TrackCount != 2 {
+		t.Errorf("Expected the collection first, got %+v", playlists[0])
+	}
+}
+
+func TestTidalProvider_ExportPlaylist_Collection(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, _ := newTestProvider(t, f)
+
+	playlist, err := provider.ExportPlaylist(providers.Account{UserID: "user123"}, providers.LibraryPlaylistID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+
+	if playlist.ID != providers.LibraryPlaylistID || playlist.Name != "My Collection" || len(playlist.Tracks) != 2 {
+		t.Fatalf("Unexpected playlist %+v", playlist)
+	}
+	if playlist.Tracks[0].ID != "3135556" || playlist.Tracks[0].ISRC != "GBDUW0000059" || playlist.Tracks[1].ID != "3135553" {
+		t.Errorf("Expected the collection's tracks in order, got %+v", playlist.Tracks)
+	}
+}
+
+func TestTidalProvider_SaveTracks(t *testing.T) {
+	f := newFakeTidal(t)
+	provider, _ := newTestProvider(t, f)
+
+	tracks := []models.Track{{ID: "3135556"}, {ID: "spotify:track:x"}, {ID: "3135553"}, {ID: "3135556"}}
+	if err := provider.SaveTracks(providers.Account{UserID: "user123"}, tracks); err != nil {
+		t.Fatalf("SaveTracks() failed: %v", err)
+	}
+
+	if fmt.Sprint(f.collected) != "[[{3135556 tracks} {3135553 tracks}]]" {
+		t.Errorf("Expected each Tidal track to be added once, got %v", f.collected)
+	}
+	if len(f.created) != 0 {
+		t.Errorf("Expected no playlist to be created, got %d", len(f.created))
+	}
+}
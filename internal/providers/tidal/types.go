@@ -0,0 +1,121 @@
+package tidal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Document is a JSON:API top-level document. Data holds a single resource
+// or a list of resources or resource identifiers, depending on the endpoint.
+type Document struct {
+	Data     json.RawMessage `json:"data"`
+	Included []Resource      `json:"included"`
+	Links    Links           `json:"links"`
+}
+
+// Links are the pagination links of a document. Next is relative to the
+// API base URL and empty on the last page.
+type Links struct {
+	Next string `json:"next"`
+}
+
+// Resource is a JSON:API resource object
+type Resource struct {
+	ID            string                  `json:"id"`
+	Type          string                  `json:"type"`
+	Attributes    json.RawMessage         `json:"attributes"`
+	Relationships map[string]Relationship `json:"relationships"`
+}
+
+// ResourceIdentifier points to a resource, e.g. a playlist item
+type ResourceIdentifier struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Relationship links a resource to others. Data is a single identifier or
+// a list of them.
+type Relationship struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// Identifiers returns the resources the relationship points to
+func (r Relationship) Identifiers() []ResourceIdentifier {
+	data := bytes.TrimSpace(r.Data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if data[0] == '{' {
+		var id ResourceIdentifier
+		if json.Unmarshal(data, &id) != nil {
+			return nil
+		}
+		return []ResourceIdentifier{id}
+	}
+	var ids []ResourceIdentifier
+	if json.Unmarshal(data, &ids) != nil {
+		return nil
+	}
+	return ids
+}
+
+// ErrorDocument is the body of a failed request
+type ErrorDocument struct {
+	Errors []APIError `json:"errors"`
+}
+
+// APIError is a JSON:API error object
+type APIError struct {
+	Status string `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("tidal API error %s (%s): %s", e.Status, e.Code, e.Detail)
+}
+
+// UserAttributes are the attributes of a users resource
+type UserAttributes struct {
+	Username string `json:"username"`
+	Country  string `json:"country"`
+	Email    string `json:"email"`
+}
+
+// PlaylistAttributes are the attributes of a playlists resource
+type PlaylistAttributes struct {
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	NumberOfItems int    `json:"numberOfItems,omitempty"`
+	AccessType    string `json:"accessType,omitempty"`
+}
+
+// TrackAttributes are the attributes of a tracks resource. Duration is an
+// ISO 8601 duration such as "PT3M45S".
+type TrackAttributes struct {
+	Title    string `json:"title"`
+	Version  string `json:"version"`
+	ISRC     string `json:"isrc"`
+	Duration string `json:"duration"`
+}
+
+// FullTitle returns the title with the version, e.g. "Song (Remastered)"
+func (a TrackAttributes) FullTitle() string {
+	if a.Version == "" || strings.Contains(a.Title, a.Version) {
+		return a.Title
+	}
+	return a.Title + " (" + a.Version + ")"
+}
+
+// ArtistAttributes are the attributes of an artists resource
+type ArtistAttributes struct {
+	Name string `json:"name"`
+}
+
+// AlbumAttributes are the attributes of an albums resource
+type AlbumAttributes struct {
+	Title string `json:"title"`
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -15,11 +16,22 @@ import (
 	"golang.org/x/oauth2/google"
 
 	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
 const (
 	baseURL = "https://www.googleapis.com/youtube/v3"
+
+	// revokeURL is Google's OAuth token revocation endpoint
+	revokeURL = "https://oauth2.googleapis.com/revoke"
+
+	// likedPlaylistID is YouTube's ID for the liked videos of the
+	// authenticated channel, which include the songs liked in YouTube Music
+	likedPlaylistID = "LL"
+
+	// likedMusicName is the name of the saved tracks library
+	likedMusicName = "Liked Music"
 )
 
 // YouTubeMusicProvider implements the Provider interface for YouTube Music
@@ -27,6 +39,7 @@ type YouTubeMusicProvider struct {
 	config          *oauth2.Config
 	connectionStore storage.ConnectionStore
 	httpClient      *http.Client
+	revokeURL       string
 }
 
 // NewYouTubeMusicProvider creates a new YouTube Music provider
@@ -45,6 +58,7 @@ func NewYouTubeMusicProvider(clientID, clientSecret, redirectURL string, connect
 		config:          config,
 		connectionStore: connectionStore,
 		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		revokeURL:       revokeURL,
 	}
 }
 
@@ -54,8 +68,8 @@ func (p *YouTubeMusicProvider) Name() string {
 }
 
 // Authenticate checks if the user has a valid connection
-func (p *YouTubeMusicProvider) Authenticate(userID string) error {
-	conn, err := p.connectionStore.Get("youtubemusic", userID)
+func (p *YouTubeMusicProvider) Authenticate(acct providers.Account) error {
+	conn, err := storage.FindConnection(p.connectionStore, "youtubemusic", acct.UserID, acct.ExternalUserID)
 	if err != nil {
 		return fmt.Errorf("not connected to YouTube Music: %w", err)
 	}
@@ -69,7 +83,54 @@ func (p *YouTubeMusicProvider) Authenticate(userID string) error {
 
 // AuthURL returns the OAuth authorization URL
 func (p *YouTubeMusicProvider) AuthURL(state string) string {
-	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	// select_account lets users pick another Google account when linking a second one;
+	// consent makes Google issue a refresh token on every connect
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "select_account consent"))
+}
+
+// Accounts returns the user's connected YouTube Music accounts, oldest first
+func (p *YouTubeMusicProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.connectionStore.ListByProvider("youtubemusic", userID)
+}
+
+// Revoke revokes the connection's grant with Google. Revoking the refresh token
+// also invalidates every access token issued from it.
+func (p *YouTubeMusicProvider) Revoke(ctx context.Context, conn *models.Connection) error {
+	token := conn.RefreshToken
+	if token == "" {
+		token = conn.AccessToken
+	}
+	if token == "" {
+		return nil
+	}
+
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.revokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create revoke request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Google answers 400 invalid_token for tokens that are already revoked or expired
+	if resp.StatusCode == http.StatusBadRequest {
+		body, _ := io.ReadAll(resp.Body)
+		if strings.Contains(string(body), "invalid_token") {
+			return nil
+		}
+		return fmt.Errorf("revoke returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("revoke returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
 }
 
 // Exchange exchanges an authorization code for a token
@@ -101,8 +162,8 @@ func (p *YouTubeMusicProvider) SaveConnection(ctx context.Context, token *oauth2
 }
 
 // GetPlaylists retrieves all playlists for the authenticated user
-func (p *YouTubeMusicProvider) GetPlaylists(userID string) ([]models.Playlist, error) {
-	conn, err := p.connectionStore.Get("youtubemusic", userID)
+func (p *YouTubeMusicProvider) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "youtubemusic", acct.UserID, acct.ExternalUserID)
 	if err != nil {
 		return nil, fmt.Errorf("not connected: %w", err)
 	}
@@ -116,7 +177,19 @@ func (p *YouTubeMusicProvider) GetPlaylists(userID string) ([]models.Playlist, e
 	ctx := context.Background()
 	client := p.config.Client(ctx, token)
 
-	var allPlaylists []models.Playlist
+	// List the liked music first; an empty page carries the total
+	liked, err := p.getPlaylistItemsPage(client, likedPlaylistID, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	allPlaylists := []models.Playlist{{
+		ID:         providers.LibraryPlaylistID,
+		Name:       likedMusicName,
+		TrackCount: liked.PageInfo.TotalResults,
+		Provider:   "YouTube Music",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}}
 	pageToken := ""
 
 	for {
@@ -171,8 +244,8 @@ func (p *YouTubeMusicProvider) GetPlaylists(userID string) ([]models.Playlist, e
 }
 
 // ExportPlaylist exports a specific playlist by ID
-func (p *YouTubeMusicProvider) ExportPlaylist(userID, id string) (models.Playlist, error) {
-	conn, err := p.connectionStore.Get("youtubemusic", userID)
+func (p *YouTubeMusicProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	conn, err := storage.FindConnection(p.connectionStore, "youtubemusic", acct.UserID, acct.ExternalUserID)
 	if err != nil {
 		return models.Playlist{}, fmt.Errorf("not connected: %w", err)
 	}
@@ -186,37 +259,23 @@ func (p *YouTubeMusicProvider) ExportPlaylist(userID, id string) (models.Playlis
 	ctx := context.Background()
 	client := p.config.Client(ctx, token)
 
-	// Get playlist details
-	playlistURL := fmt.Sprintf("%s/playlists?part=snippet,contentDetails&id=%s", baseURL, id)
-	resp, err := client.Get(playlistURL)
-	if err != nil {
-		return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return models.Playlist{}, fmt.Errorf("YouTube API error: %s - %s", resp.Status, string(body))
-	}
-
-	var playlistList PlaylistListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&playlistList); err != nil {
-		return models.Playlist{}, fmt.Errorf("failed to decode playlist: %w", err)
-	}
-
-	if len(playlistList.Items) == 0 {
-		return models.Playlist{}, fmt.Errorf("playlist not found: %s", id)
-	}
-
-	playlistDetail := playlistList.Items[0]
-	playlist := models.Playlist{
-		ID:          playlistDetail.ID,
-		Name:        playlistDetail.Snippet.Title,
-		Description: playlistDetail.Snippet.Description,
-		TrackCount:  playlistDetail.ContentDetails.ItemCount,
-		Provider:    "YouTube Music",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	var playlist models.Playlist
+	itemsID := id
+	if id == providers.LibraryPlaylistID {
+		playlist = models.Playlist{
+			ID:        providers.LibraryPlaylistID,
+			Name:      likedMusicName,
+			Provider:  "YouTube Music",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		itemsID = likedPlaylistID
+	} else {
+		// Get playlist details
+		playlist, err = p.getPlaylistDetail(client, id)
+		if err != nil {
+			return models.Playlist{}, err
+		}
 	}
 
 	// Fetch all playlist items (video IDs) with pagination
@@ -225,29 +284,11 @@ func (p *YouTubeMusicProvider) ExportPlaylist(userID, id string) (models.Playlis
 	pageToken := ""
 
 	for {
-		itemsURL := fmt.Sprintf("%s/playlistItems?part=snippet&playlistId=%s&maxResults=50", baseURL, id)
-		if pageToken != "" {
-			itemsURL += "&pageToken=" + pageToken
-		}
-
-		resp, err := client.Get(itemsURL)
+		itemsResponse, err := p.getPlaylistItemsPage(client, itemsID, 50, pageToken)
 		if err != nil {
-			return models.Playlist{}, fmt.Errorf("failed to fetch playlist items: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			return models.Playlist{}, fmt.Errorf("YouTube API error: %s - %s", resp.Status, string(body))
+			return models.Playlist{}, err
 		}
 
-		var itemsResponse PlaylistItemListResponse
-		if err := json.NewDecoder(resp.Body).Decode(&itemsResponse); err != nil {
-			resp.Body.Close()
-			return models.Playlist{}, fmt.Errorf("failed to decode playlist items: %w", err)
-		}
-		resp.Body.Close()
-
 		for _, item := range itemsResponse.Items {
 			if item.Snippet.ResourceID.Kind == "youtube#video" && item.Snippet.ResourceID.VideoID != "" {
 				videoIDs = append(videoIDs, item.Snippet.ResourceID.VideoID)
@@ -260,6 +301,9 @@ func (p *YouTubeMusicProvider) ExportPlaylist(userID, id string) (models.Playlis
 		}
 		pageToken = itemsResponse.NextPageToken
 	}
+	if id == providers.LibraryPlaylistID {
+		playlist.TrackCount = len(videoIDs)
+	}
 
 	// Fetch video details in batches of 50 to get duration
 	var allTracks []models.Track
@@ -325,10 +369,71 @@ func (p *YouTubeMusicProvider) ExportPlaylist(userID, id string) (models.Playlis
 }
 
 // ImportPlaylist imports a playlist into YouTube Music (not implemented yet)
-func (p *YouTubeMusicProvider) ImportPlaylist(playlist models.Playlist) error {
+func (p *YouTubeMusicProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
 	return fmt.Errorf("importing to YouTube Music is not yet implemented")
 }
 
+// getPlaylistDetail fetches the details of a playlist
+func (p *YouTubeMusicProvider) getPlaylistDetail(client *http.Client, id string) (models.Playlist, error) {
+	playlistURL := fmt.Sprintf("%s/playlists?part=snippet,contentDetails&id=%s", baseURL, id)
+	resp, err := client.Get(playlistURL)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to fetch playlist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return models.Playlist{}, fmt.Errorf("YouTube API error: %s - %s", resp.Status, string(body))
+	}
+
+	var playlistList PlaylistListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&playlistList); err != nil {
+		return models.Playlist{}, fmt.Errorf("failed to decode playlist: %w", err)
+	}
+
+	if len(playlistList.Items) == 0 {
+		return models.Playlist{}, fmt.Errorf("playlist not found: %s", id)
+	}
+
+	playlistDetail := playlistList.Items[0]
+	return models.Playlist{
+		ID:          playlistDetail.ID,
+		Name:        playlistDetail.Snippet.Title,
+		Description: playlistDetail.Snippet.Description,
+		TrackCount:  playlistDetail.ContentDetails.ItemCount,
+		Provider:    "YouTube Music",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+// getPlaylistItemsPage fetches a page of up to maxResults items of a playlist
+func (p *YouTubeMusicProvider) getPlaylistItemsPage(client *http.Client, playlistID string, maxResults int, pageToken string) (*PlaylistItemListResponse, error) {
+	itemsURL := fmt.Sprintf("%s/playlistItems?part=snippet&playlistId=%s&maxResults=%d", baseURL, playlistID, maxResults)
+	if pageToken != "" {
+		itemsURL += "&pageToken=" + pageToken
+	}
+
+	resp, err := client.Get(itemsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch playlist items: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("YouTube API error: %s - %s", resp.Status, string(body))
+	}
+
+	var itemsResponse PlaylistItemListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&itemsResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode playlist items: %w", err)
+	}
+
+	return &itemsResponse, nil
+}
+
 // getUserChannel fetches the authenticated user's YouTube channel
 func (p *YouTubeMusicProvider) getUserChannel(ctx context.Context, token *oauth2.Token) (*ChannelItem, error) {
 	client := p.config.Client(ctx, token)
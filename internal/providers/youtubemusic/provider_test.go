@@ -1,6 +1,7 @@
 package youtubemusic
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
@@ -47,7 +49,7 @@ func TestYouTubeMusicProvider_Authenticate_NotConnected(t *testing.T) {
 	store := storage.NewInMemoryConnectionStore()
 	provider := NewYouTubeMusicProvider("client-id", "client-secret", "http://localhost/callback", store)
 
-	err := provider.Authenticate("user123")
+	err := provider.Authenticate(providers.Account{UserID: "user123"})
 	if err == nil {
 		t.Error("Authenticate() should fail when not connected")
 	}
@@ -57,7 +59,7 @@ func TestYouTubeMusicProvider_ImportPlaylist_NotImplemented(t *testing.T) {
 	store := storage.NewInMemoryConnectionStore()
 	provider := NewYouTubeMusicProvider("client-id", "client-secret", "http://localhost/callback", store)
 
-	err := provider.ImportPlaylist(models.Playlist{})
+	err := provider.ImportPlaylist(providers.Account{UserID: "user123"}, models.Playlist{})
 	if err == nil {
 		t.Error("ImportPlaylist() should return error (not implemented)")
 	}
@@ -251,3 +253,37 @@ func TestChannelListResponse(t *testing.T) {
 		t.Errorf("Expected channel title 'My YouTube Channel', got '%s'", response.Items[0].Snippet.Title)
 	}
 }
+
+func TestYouTubeMusicProvider_Revoke(t *testing.T) {
+	var gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse revoke request: %v", err)
+		}
+		gotToken = r.PostFormValue("token")
+		if gotToken == "already-revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_token"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := storage.NewInMemoryConnectionStore()
+	provider := NewYouTubeMusicProvider("client-id", "client-secret", "http://localhost/callback", store)
+	provider.revokeURL = server.URL
+
+	conn := &models.Connection{AccessToken: "access-token", RefreshToken: "refresh-token"}
+	if err := provider.Revoke(context.Background(), conn); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if gotToken != "refresh-token" {
+		t.Errorf("Revoke() should send the refresh token, got %q", gotToken)
+	}
+
+	conn = &models.Connection{RefreshToken: "already-revoked"}
+	if err := provider.Revoke(context.Background(), conn); err != nil {
+		t.Errorf("Revoke() should ignore tokens that are already invalid, got %v", err)
+	}
+}
@@ -6,33 +6,28 @@ import (
 	"net/http"
 	"path/filepath"
 
-	"github.com/JanikSachs/PlayPort/internal/auth"
+	"github.com/JanikSachs/PlayPort/internal/api"
+	"github.com/JanikSachs/PlayPort/internal/app"
 	"github.com/JanikSachs/PlayPort/internal/handlers"
 	"github.com/JanikSachs/PlayPort/internal/middleware"
-	"github.com/JanikSachs/PlayPort/internal/providers/spotify"
-	"github.com/JanikSachs/PlayPort/internal/providers/youtubemusic"
+	"github.com/JanikSachs/PlayPort/internal/providers"
 	"github.com/JanikSachs/PlayPort/internal/services"
-	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	addr                 string
-	mux                  *http.ServeMux
-	transferService      *services.TransferService
-	templates            *template.Template
-	spotifyProvider      *spotify.SpotifyProvider
-	youtubeMusicProvider *youtubemusic.YouTubeMusicProvider
-	connectionStore      storage.ConnectionStore
-	userStore            storage.UserStore
-	stateStore           auth.StateStore
-	sessionStore         auth.SessionStore
-	spotifyEnabled       bool
-	youtubeMusicEnabled  bool
+	addr              string
+	mux               *http.ServeMux
+	transferService   *services.TransferService
+	connectionService *services.ConnectionService
+	scheduleService   *services.ScheduleService
+	templates         *template.Template
+	registry          []providers.Registration
+	stores            *app.Stores
 }
 
-// New creates a new server instance
-func New(addr string, transferService *services.TransferService, spotifyProvider *spotify.SpotifyProvider, youtubeMusicProvider *youtubemusic.YouTubeMusicProvider, connectionStore storage.ConnectionStore, userStore storage.UserStore, stateStore auth.StateStore, sessionStore auth.SessionStore, spotifyEnabled bool, youtubeMusicEnabled bool) (*Server, error) {
+// New creates a new server instance for the providers of registry
+func New(addr string, transferService *services.TransferService, connectionService *services.ConnectionService, scheduleService *services.ScheduleService, registry []providers.Registration, stores *app.Stores) (*Server, error) {
 	// Parse templates
 	templates, err := template.ParseGlob(filepath.Join("web", "templates", "*.html"))
 	if err != nil {
@@ -40,18 +35,14 @@ func New(addr string, transferService *services.TransferService, spotifyProvider
 	}
 
 	s := &Server{
-		addr:                addr,
-		mux:                 http.NewServeMux(),
-		transferService:     transferService,
-		templates:           templates,
-		spotifyProvider:     spotifyProvider,
-		youtubeMusicProvider: youtubeMusicProvider,
-		connectionStore:     connectionStore,
-		userStore:           userStore,
-		stateStore:          stateStore,
-		sessionStore:        sessionStore,
-		spotifyEnabled:      spotifyEnabled,
-		youtubeMusicEnabled: youtubeMusicEnabled,
+		addr:              addr,
+		mux:               http.NewServeMux(),
+		transferService:   transferService,
+		connectionService: connectionService,
+		scheduleService:   scheduleService,
+		templates:         templates,
+		registry:          registry,
+		stores:            stores,
 	}
 
 	s.setupRoutes()
@@ -61,9 +52,11 @@ func New(addr string, transferService *services.TransferService, spotifyProvider
 // setupRoutes configures all HTTP routes
 func (s *Server) setupRoutes() {
 	// Create handlers
-	h := handlers.NewHandlers(s.transferService, s.templates, s.connectionStore, s.userStore, s.spotifyEnabled, s.youtubeMusicEnabled)
-	authHandlers := handlers.NewAuthHandlers(s.spotifyProvider, s.youtubeMusicProvider, s.stateStore, s.userStore, s.sessionStore, s.templates, s.spotifyEnabled, s.youtubeMusicEnabled)
-	providerHandlers := handlers.NewProviderHandlers(s.spotifyProvider, s.youtubeMusicProvider, s.connectionStore, s.templates, s.spotifyEnabled, s.youtubeMusicEnabled)
+	h := handlers.NewHandlers(s.transferService, s.templates, s.stores.Connections, s.stores.Users, s.registry)
+	authHandlers := handlers.NewAuthHandlers(s.registry, s.stores.State, s.stores.Users, s.stores.Sessions, s.templates)
+	settingsHandlers := handlers.NewSettingsHandlers(s.stores.APITokens, s.stores.Audit, s.stores.Users, s.templates)
+	scheduleHandlers := handlers.NewScheduleHandlers(s.scheduleService, s.transferService, s.stores.Users, s.templates)
+	providerHandlers := handlers.NewProviderHandlers(s.transferService, s.connectionService, s.stores.Connections, s.templates, s.registry)
 
 	// Static files
 	fs := http.FileServer(http.Dir("web/static"))
@@ -90,27 +83,55 @@ func (s *Server) setupRoutes() {
 	s.mux.HandleFunc("/", h.HandleHome)
 	s.mux.HandleFunc("/providers", h.HandleProviders)
 	s.mux.HandleFunc("/transfer", h.HandleTransfer)
+	s.mux.HandleFunc("/migrate", h.HandleMigrate)
+	s.mux.HandleFunc("/migrate/progress", h.HandleMigrationProgress)
+	s.mux.HandleFunc("/syncs", h.HandleSyncs)
+	s.mux.HandleFunc("/syncs/run", h.HandleRunSync)
+	s.mux.HandleFunc("/syncs/resolve", h.HandleResolveSyncConflict)
+	s.mux.HandleFunc("/schedules", scheduleHandlers.HandleSchedules)
+	s.mux.HandleFunc("/schedules/create", scheduleHandlers.HandleCreateSchedule)
+	s.mux.HandleFunc("/schedules/update", scheduleHandlers.HandleUpdateSchedule)
+	s.mux.HandleFunc("/schedules/run", scheduleHandlers.HandleRunSchedule)
+	s.mux.HandleFunc("/schedules/delete", scheduleHandlers.HandleDeleteSchedule)
+	s.mux.HandleFunc("/settings", settingsHandlers.HandleSettings)
+	s.mux.HandleFunc("/settings/tokens", settingsHandlers.HandleCreateToken)
+	s.mux.HandleFunc("/settings/tokens/revoke", settingsHandlers.HandleRevokeToken)
 
-	// OAuth routes - Spotify
-	s.mux.HandleFunc("/auth/spotify/start", authHandlers.HandleSpotifyStart)
-	s.mux.HandleFunc("/auth/spotify/callback", authHandlers.HandleSpotifyCallback)
-
-	// OAuth routes - YouTube Music
-	s.mux.HandleFunc("/auth/youtubemusic/start", authHandlers.HandleYouTubeMusicStart)
-	s.mux.HandleFunc("/auth/youtubemusic/callback", authHandlers.HandleYouTubeMusicCallback)
-
-	// Provider-specific endpoints
-	s.mux.HandleFunc("/providers/spotify/playlists", providerHandlers.HandleSpotifyPlaylists)
-	s.mux.HandleFunc("/providers/youtubemusic/playlists", providerHandlers.HandleYouTubeMusicPlaylists)
+	// Provider logins, playlists and disconnects
+	for _, reg := range s.registry {
+		switch reg.Login {
+		case providers.LoginOAuth:
+			s.mux.HandleFunc("/auth/"+reg.Slug+"/start", authHandlers.HandleOAuthStart(reg))
+			s.mux.HandleFunc("/auth/"+reg.Slug+"/callback", authHandlers.HandleOAuthCallback(reg))
+			s.mux.HandleFunc("/providers/"+reg.Slug+"/playlists", providerHandlers.HandlePlaylists(reg))
+		case providers.LoginMusicKit:
+			s.mux.HandleFunc("/auth/"+reg.Slug+"/start", authHandlers.HandleAppleMusicStart)
+			s.mux.HandleFunc("/auth/"+reg.Slug+"/callback", authHandlers.HandleAppleMusicCallback)
+			s.mux.HandleFunc("/providers/"+reg.Slug+"/playlists", providerHandlers.HandlePlaylists(reg))
+		case providers.LoginCredentials:
+			s.mux.HandleFunc("/providers/"+reg.Slug+"/connect", providerHandlers.HandleConnect(reg))
+		}
+		s.mux.HandleFunc("/providers/"+reg.Slug+"/disconnect", providerHandlers.HandleDisconnect(reg))
+	}
 
 	// HTMX endpoints
 	s.mux.HandleFunc("/api/playlists", h.HandleGetPlaylists)
 	s.mux.HandleFunc("/api/transfer/start", h.HandleStartTransfer)
+	s.mux.HandleFunc("/api/transfer/status", h.HandleTransferStatus)
+	s.mux.HandleFunc("/api/transfer/cancel", h.HandleCancelTransfer)
+	s.mux.HandleFunc("/api/transfer/upload", h.HandleUploadPlaylist)
+	s.mux.HandleFunc("/api/transfer/upload/csv", h.HandleMapCSV)
+	s.mux.HandleFunc("/api/transfer/library", h.HandleImportLibrary)
+	s.mux.HandleFunc("/playlists/download", h.HandleDownloadPlaylist)
+
+	// JSON REST API
+	api.New(s.transferService, s.connectionService, s.scheduleService, s.stores.Connections, s.stores.Overrides).Register(s.mux)
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	log.Printf("Server starting on %s", s.addr)
-	sessionMW := middleware.SessionMiddleware(s.sessionStore)
-	return http.ListenAndServe(s.addr, sessionMW(s.mux))
+	sessionMW := middleware.SessionMiddleware(s.stores.Sessions)
+	apiTokenMW := middleware.APITokenMiddleware(s.stores.APITokens)
+	return http.ListenAndServe(s.addr, apiTokenMW(sessionMW(s.mux)))
 }
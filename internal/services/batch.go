@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+// trackCache shares match outcomes between the playlists of a batch, keyed
+// by source track ID. A nil cache holds nothing.
+type trackCache struct {
+	mu      sync.Mutex
+	results map[string]TrackResult
+}
+
+// newTrackCache creates an empty track cache
+func newTrackCache() *trackCache {
+	return &trackCache{results: make(map[string]TrackResult)}
+}
+
+// get returns the cached outcome for a source track ID
+func (c *trackCache) get(id string) (TrackResult, bool) {
+	if c == nil || id == "" {
+		return TrackResult{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.results[id]
+	return r, ok
+}
+
+// put caches the outcome for a source track ID
+func (c *trackCache) put(id string, r TrackResult) {
+	if c == nil || id == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[id] = r
+}
+
+// SetBatchLimits sets how many playlists of a batch are transferred at once
+// and how many playlists may use the same provider at once across all
+// batches. Limits below 1 are left unchanged.
+func (s *TransferService) SetBatchLimits(workers, perProvider int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if workers >= 1 {
+		s.batchWorkers = workers
+	}
+	if perProvider >= 1 {
+		s.providerConcurrency = perProvider
+		s.providerSlots = make(map[string]chan struct{})
+	}
+}
+
+// acquireProviders waits for a free slot with each of the named providers.
+// Slots are taken in name order so that batches going in opposite
+// directions cannot block each other. The returned function frees them.
+func (s *TransferService) acquireProviders(ctx context.Context, names ...string) (func(), error) {
+	names = slices.Clone(names)
+	sort.Strings(names)
+	names = slices.Compact(names)
+
+	s.mu.Lock()
+	slots := make([]chan struct{}, len(names))
+	for i, name := range names {
+		if s.providerSlots[name] == nil {
+			s.providerSlots[name] = make(chan struct{}, s.providerConcurrency)
+		}
+		slots[i] = s.providerSlots[name]
+	}
+	s.mu.Unlock()
+
+	release := func(n int) {
+		for _, slot := range slots[:n] {
+			<-slot
+		}
+	}
+	for i, slot := range slots {
+		select {
+		case slot <- struct{}{}:
+		case <-ctx.Done():
+			release(i)
+			return nil, ctx.Err()
+		}
+	}
+	return func() { release(len(slots)) }, nil
+}
+
+// migrateLibrary transfers every playlist of the source account as one batch
+func (s *TransferService) migrateLibrary(ctx context.Context, req TransferRequest, source providers.Provider, sourceAccount providers.Account, job *transferJob) error {
+	s.updateJob(job, func(p *TransferProgress) {
+		p.PlaylistName = libraryName
+		p.Progress = 5
+		p.Message = "Listing playlists..."
+	})
+	playlists, err := source.GetPlaylists(sourceAccount)
+	if err != nil {
+		return fmt.Errorf("listing playlists failed: %w", err)
+	}
+
+	return s.transferBatch(ctx, req, source, sourceAccount, playlists, job)
+}
+
+// transferSelected transfers the playlists in req.PlaylistIDs as one batch.
+// Their names are filled in as they are exported.
+func (s *TransferService) transferSelected(ctx context.Context, req TransferRequest, source providers.Provider, sourceAccount providers.Account, job *transferJob) error {
+	s.updateJob(job, func(p *TransferProgress) {
+		p.PlaylistName = batchName
+	})
+
+	var playlists []models.Playlist
+	seen := make(map[string]bool, len(req.PlaylistIDs))
+	for _, id := range req.PlaylistIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		playlists = append(playlists, models.Playlist{ID: id, Name: id})
+	}
+
+	return s.transferBatch(ctx, req, source, sourceAccount, playlists, job)
+}
+
+// transferBatch transfers playlists through a pool of workers. Each source
+// track is matched once and the outcome reused by the other playlists.
+// Every playlist keeps its own status in the job's progress; one that fails
+// is recorded and skipped, and the batch only fails if none of its
+// playlists could be transferred.
+func (s *TransferService) transferBatch(ctx context.Context, req TransferRequest, source providers.Provider, sourceAccount providers.Account, playlists []models.Playlist, job *transferJob) error {
+	target, targetAccount, err := s.authenticateTarget(req)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		job = &transferJob{req: req}
+	}
+
+	statuses := make([]PlaylistResult, len(playlists))
+	for i, p := range playlists {
+		statuses[i] = PlaylistResult{ID: p.ID, Name: p.Name, Status: StatusPending, TrackCount: p.TrackCount}
+	}
+	s.mu.Lock()
+	job.progress.Playlists = statuses
+	job.progress.Progress = 10
+	job.progress.Message = fmt.Sprintf("Transferring %d playlists...", len(playlists))
+	job.batchTracks = make([][]TrackResult, len(playlists))
+	workers := min(s.batchWorkers, len(playlists))
+	s.mu.Unlock()
+
+	cache := newTrackCache()
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				s.transferBatchPlaylist(ctx, req, source, sourceAccount, target, targetAccount, job, i, playlists[i].ID, cache)
+			}
+		}()
+	}
+
+feed:
+	for i := range playlists {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failed := 0
+	for _, p := range job.progress.Playlists {
+		if p.Status == StatusFailed {
+			failed++
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		statuses := slices.Clone(job.progress.Playlists)
+		for i := range statuses {
+			if statuses[i].Status == StatusPending {
+				statuses[i].Status = StatusCancelled
+			}
+		}
+		job.progress.Playlists = statuses
+		return err
+	}
+	if failed > 0 && failed == len(playlists) {
+		return fmt.Errorf("none of the %d playlists could be transferred", failed)
+	}
+	return nil
+}
+
+// transferBatchPlaylist exports, matches and saves the i-th playlist of a
+// batch once both providers have a free slot, recording its status in job
+func (s *TransferService) transferBatchPlaylist(ctx context.Context, req TransferRequest, source providers.Provider, sourceAccount providers.Account, target providers.Provider, targetAccount providers.Account, job *transferJob, i int, id string, cache *trackCache) {
+	release, err := s.acquireProviders(ctx, source.Name(), target.Name())
+	if err != nil {
+		s.setBatchStatus(job, i, func(p *PlaylistResult) {
+			p.Status = StatusCancelled
+		})
+		return
+	}
+	s.setBatchStatus(job, i, func(p *PlaylistResult) {
+		p.Status = StatusInProgress
+	})
+
+	var results []TrackResult
+	playlist, err := source.ExportPlaylist(sourceAccount, id)
+	if err != nil {
+		err = fmt.Errorf("export failed: %w", err)
+	} else if results, err = s.matchTracks(ctx, req, target, playlist.Tracks, cache); err != nil {
+		err = fmt.Errorf("matching failed: %w", err)
+	} else {
+		err = savePlaylist(target, targetAccount, playlist, results)
+	}
+	release()
+
+	s.setBatchStatus(job, i, func(p *PlaylistResult) {
+		if playlist.Name != "" {
+			p.Name = playlist.Name
+		}
+		p.TrackCount = len(results)
+		p.Transferred = 0
+		for _, r := range results {
+			if r.Target != nil {
+				p.Transferred++
+			}
+		}
+		switch {
+		case err == nil:
+			p.Status = StatusCompleted
+		case ctx.Err() != nil:
+			p.Status = StatusCancelled
+		default:
+			p.Status = StatusFailed
+			p.Error = err.Error()
+		}
+		job.batchTracks[i] = results
+	})
+}
+
+// setBatchStatus applies fn to the status of the i-th playlist of a batch
+// and updates the batch's progress. The statuses are copied rather than
+// changed in place, since snapshots of the progress share them.
+func (s *TransferService) setBatchStatus(job *transferJob, i int, fn func(p *PlaylistResult)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := slices.Clone(job.progress.Playlists)
+	fn(&statuses[i])
+	job.progress.Playlists = statuses
+
+	done := 0
+	for _, p := range statuses {
+		switch p.Status {
+		case StatusCompleted, StatusFailed, StatusCancelled:
+			done++
+		}
+	}
+	job.progress.Progress = 10 + 85*done/len(statuses)
+	job.progress.Message = fmt.Sprintf("Transferred %d of %d playlists...", done, len(statuses))
+}
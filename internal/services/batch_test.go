@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+func TestTransferService_RunTransfer_Batch(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", Mode: ModeBatch,
+		PlaylistIDs: []string{"mock-1", "missing", "mock-3", "mock-1"},
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+
+	if report.PlaylistName != "Selected playlists" || len(report.Playlists) != 3 {
+		t.Fatalf("Expected one entry per distinct playlist, got %+v", report.Playlists)
+	}
+	first, missing := report.Playlists[0], report.Playlists[1]
+	if first.Status != StatusCompleted || first.Name != "Summer Vibes 2024" || first.Transferred != 3 {
+		t.Errorf("Unexpected status for mock-1: %+v", first)
+	}
+	if missing.Status != StatusFailed || missing.Name != "missing" || missing.Error == "" {
+		t.Errorf("Expected the unknown playlist to fail on its own, got %+v", missing)
+	}
+	if report.Summary[MatchFound] != len(report.Tracks) || len(report.Tracks) == 0 {
+		t.Errorf("Expected the tracks of the transferred playlists, got %v", report.Summary)
+	}
+
+	_, err = s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", Mode: ModeBatch,
+	})
+	if err == nil {
+		t.Error("Expected a batch without playlists to be rejected")
+	}
+}
+
+// slowSource exports numbered playlists slowly and records how many
+// exports run at once
+type slowSource struct {
+	*providers.MockProvider
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (p *slowSource) Name() string { return "Slow" }
+
+func (p *slowSource) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	p.mu.Lock()
+	p.running++
+	p.peak = max(p.peak, p.running)
+	p.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+	return models.Playlist{ID: id, Name: "Playlist " + id}, nil
+}
+
+func TestTransferService_Batch_ProviderLimit(t *testing.T) {
+	s := NewTransferService()
+	s.SetBatchLimits(4, 2)
+	source := &slowSource{MockProvider: providers.NewMockProvider()}
+	s.RegisterProvider(source)
+	s.RegisterProvider(providers.NewMockProvider())
+
+	ids := make([]string, 6)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i + 1)
+	}
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Slow", TargetProvider: "Mock Music", Mode: ModeBatch, PlaylistIDs: ids,
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+
+	if source.peak != 2 {
+		t.Errorf("Expected at most 2 playlists to use the provider at once, got %d", source.peak)
+	}
+	for _, p := range report.Playlists {
+		if p.Status != StatusCompleted {
+			t.Errorf("Expected every playlist to complete, got %+v", p)
+		}
+	}
+}
+
+func TestTransferService_AcquireProviders(t *testing.T) {
+	s := NewTransferService()
+	s.SetBatchLimits(0, 1)
+
+	// The same provider on both sides takes a single slot
+	release, err := s.acquireProviders(context.Background(), "Mock Music", "Mock Music")
+	if err != nil {
+		t.Fatalf("acquireProviders() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquireProviders(ctx, "Mock Music"); err == nil {
+		t.Error("Expected to wait for the busy provider until the context ends")
+	}
+
+	release()
+	release, err = s.acquireProviders(context.Background(), "Mock Music")
+	if err != nil {
+		t.Fatalf("acquireProviders() failed after release: %v", err)
+	}
+	release()
+}
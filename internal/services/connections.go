@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// ConnectionService manages the lifecycle of users' provider connections
+type ConnectionService struct {
+	connectionStore storage.ConnectionStore
+	auditStore      storage.AuditStore
+	transferService *TransferService
+	scheduleService *ScheduleService // nil if schedules are not enabled
+}
+
+// NewConnectionService creates a new connection service
+func NewConnectionService(connectionStore storage.ConnectionStore, auditStore storage.AuditStore, transferService *TransferService) *ConnectionService {
+	return &ConnectionService{
+		connectionStore: connectionStore,
+		auditStore:      auditStore,
+		transferService: transferService,
+	}
+}
+
+// SetScheduleService lets Disconnect pause the schedules that use the
+// disconnected account
+func (s *ConnectionService) SetScheduleService(scheduleService *ScheduleService) {
+	s.scheduleService = scheduleService
+}
+
+// Connect links a provider account with credentials, for providers that do
+// not use OAuth, and records it in the audit log
+func (s *ConnectionService) Connect(ctx context.Context, provider providers.Provider, slug, userID string, creds providers.Credentials) (*models.Connection, error) {
+	connector, ok := provider.(providers.Connector)
+	if !ok {
+		return nil, fmt.Errorf("%s accounts are not linked with credentials", provider.Name())
+	}
+
+	conn, err := connector.Connect(ctx, userID, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &models.AuditEvent{
+		UserID:         userID,
+		Action:         "connection.connect",
+		Provider:       slug,
+		ExternalUserID: conn.ExternalUserID,
+	}
+	if err := s.auditStore.Record(event); err != nil {
+		log.Printf("Failed to record audit event: %v", err)
+	}
+
+	return conn, nil
+}
+
+// Disconnect unlinks a provider account from the user. It cancels unfinished
+// transfers using the account, flags the sync links and pauses the
+// schedules using it, revokes the grant where the provider supports
+// it, deletes the stored connection and records the outcome in the audit log.
+// A failed revocation does not prevent the connection from being deleted.
+func (s *ConnectionService) Disconnect(ctx context.Context, provider providers.Provider, slug string, acct providers.Account) error {
+	conn, err := storage.FindConnection(s.connectionStore, slug, acct.UserID, acct.ExternalUserID)
+	if err != nil {
+		return err
+	}
+
+	var details []string
+
+	// Stop transfers first so none picks up the connection while it is torn down
+	if cancelled := s.transferService.CancelAccountTransfers(acct.UserID, provider.Name(), conn.ExternalUserID); cancelled > 0 {
+		details = append(details, fmt.Sprintf("%d transfer(s) cancelled", cancelled))
+	}
+	if flagged, err := s.transferService.FlagAccountSyncLinks(acct.UserID, provider.Name(), conn.ExternalUserID); err != nil {
+		log.Printf("Failed to flag sync links of %s account: %v", slug, err)
+		details = append(details, "flagging sync links failed: "+err.Error())
+	} else if flagged > 0 {
+		details = append(details, fmt.Sprintf("%d sync link(s) flagged", flagged))
+	}
+	if s.scheduleService != nil {
+		if paused, err := s.scheduleService.PauseAccountSchedules(acct.UserID, provider.Name(), conn.ExternalUserID); err != nil {
+			log.Printf("Failed to pause schedules of %s account: %v", slug, err)
+			details = append(details, "pausing schedules failed: "+err.Error())
+		} else if paused > 0 {
+			details = append(details, fmt.Sprintf("%d schedule(s) paused", paused))
+		}
+	}
+
+	if revoker, ok := provider.(providers.Revoker); ok {
+		if err := revoker.Revoke(ctx, conn); err != nil {
+			log.Printf("Failed to revoke %s token: %v", slug, err)
+			details = append(details, "token revocation failed: "+err.Error())
+		} else {
+			details = append(details, "token revoked")
+		}
+	} else {
+		details = append(details, "token revocation not supported by provider")
+	}
+
+	if err := s.connectionStore.Delete(slug, acct.UserID, conn.ExternalUserID); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+
+	event := &models.AuditEvent{
+		UserID:         acct.UserID,
+		Action:         "connection.disconnect",
+		Provider:       slug,
+		ExternalUserID: conn.ExternalUserID,
+		Details:        strings.Join(details, "; "),
+	}
+	if err := s.auditStore.Record(event); err != nil {
+		// The connection is already gone; losing the log entry must not undo that
+		log.Printf("Failed to record audit event: %v", err)
+	}
+
+	return nil
+}
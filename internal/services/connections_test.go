@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// revokingProvider is a linked-account provider that records revocations
+type revokingProvider struct {
+	*providers.MockProvider
+	store     storage.ConnectionStore
+	revoked   []string
+	revokeErr error
+}
+
+func (p *revokingProvider) Name() string { return "Revoking" }
+
+func (p *revokingProvider) Accounts(userID string) ([]*models.Connection, error) {
+	return p.store.ListByProvider("revoking", userID)
+}
+
+func (p *revokingProvider) Revoke(ctx context.Context, conn *models.Connection) error {
+	p.revoked = append(p.revoked, conn.RefreshToken)
+	return p.revokeErr
+}
+
+func setupConnectionService(t *testing.T) (*ConnectionService, *revokingProvider, storage.ConnectionStore, storage.AuditStore, *TransferService) {
+	t.Helper()
+
+	connectionStore := storage.NewInMemoryConnectionStore()
+	auditStore := storage.NewInMemoryAuditStore()
+	transferService := NewTransferService()
+	transferService.startDelay = time.Hour
+
+	provider := &revokingProvider{MockProvider: providers.NewMockProvider(), store: connectionStore}
+	transferService.RegisterProvider(provider)
+	transferService.RegisterProvider(providers.NewMockProvider())
+
+	for _, id := range []string{"alice", "bob"} {
+		conn := &models.Connection{Provider: "revoking", UserID: "user123", ExternalUserID: id, RefreshToken: id + "-token", Connected: true}
+		if err := connectionStore.Save(conn); err != nil {
+			t.Fatalf("Failed to save connection: %v", err)
+		}
+	}
+
+	return NewConnectionService(connectionStore, auditStore, transferService), provider, connectionStore, auditStore, transferService
+}
+
+func TestConnectionService_Disconnect(t *testing.T) {
+	service, provider, connectionStore, auditStore, transferService := setupConnectionService(t)
+
+	aliceJob, err := transferService.StartTransfer(TransferRequest{
+		UserID: "user123", SourceProvider: "Revoking", SourceAccount: "alice",
+		TargetProvider: "Mock Music", PlaylistID: "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("StartTransfer() failed: %v", err)
+	}
+	bobJob, err := transferService.StartTransfer(TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", PlaylistID: "mock-1",
+		TargetProvider: "Revoking", TargetAccount: "bob",
+	})
+	if err != nil {
+		t.Fatalf("StartTransfer() failed: %v", err)
+	}
+
+	err = service.Disconnect(context.Background(), provider, "revoking", providers.Account{UserID: "user123", ExternalUserID: "alice"})
+	if err != nil {
+		t.Fatalf("Disconnect() failed: %v", err)
+	}
+
+	if len(provider.revoked) != 1 || provider.revoked[0] != "alice-token" {
+		t.Errorf("Expected alice's token to be revoked, got %v", provider.revoked)
+	}
+
+	if _, err := connectionStore.Get("revoking", "user123", "alice"); err == nil {
+		t.Error("Disconnected connection should be deleted")
+	}
+	if _, err := connectionStore.Get("revoking", "user123", "bob"); err != nil {
+		t.Errorf("Other accounts should stay connected: %v", err)
+	}
+
+	if status := waitForStatus(t, transferService, aliceJob.ID, StatusCancelled); status != StatusCancelled {
+		t.Errorf("Transfer using the account should be cancelled, got %s", status)
+	}
+	if progress, _ := transferService.GetTransfer("user123", bobJob.ID); progress.Status != StatusPending {
+		t.Errorf("Transfer using another account should stay pending, got %s", progress.Status)
+	}
+
+	events, err := auditStore.List("user123", 0)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 audit event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Action != "connection.disconnect" || event.Provider != "revoking" || event.ExternalUserID != "alice" {
+		t.Errorf("Unexpected audit event: %+v", event)
+	}
+	if !strings.Contains(event.Details, "token revoked") || !strings.Contains(event.Details, "1 transfer(s) cancelled") {
+		t.Errorf("Audit details should describe the outcome, got %q", event.Details)
+	}
+}
+
+func TestConnectionService_Disconnect_SyncLinks(t *testing.T) {
+	service, provider, _, auditStore, transferService := setupConnectionService(t)
+	links := storage.NewInMemorySyncLinkStore()
+	transferService.SetSyncLinkStore(links)
+
+	aliceLink := &models.SyncLink{UserID: "user123", SourceProvider: "Revoking", SourceAccount: "alice", SourcePlaylistID: "mock-1", TargetProvider: "Mock Music", TargetPlaylistID: "mock-2"}
+	bobLink := &models.SyncLink{UserID: "user123", SourceProvider: "Mock Music", SourcePlaylistID: "mock-1", TargetProvider: "Revoking", TargetAccount: "bob", TargetPlaylistID: "mock-2"}
+	for _, link := range []*models.SyncLink{aliceLink, bobLink} {
+		if err := links.Save(link); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+
+	err := service.Disconnect(context.Background(), provider, "revoking", providers.Account{UserID: "user123", ExternalUserID: "alice"})
+	if err != nil {
+		t.Fatalf("Disconnect() failed: %v", err)
+	}
+
+	if got, _ := links.Get("user123", aliceLink.ID); !strings.Contains(got.Disconnected, "Revoking account alice was disconnected") {
+		t.Errorf("Expected the link using alice's account to be flagged, got %q", got.Disconnected)
+	}
+	if got, _ := links.Get("user123", bobLink.ID); got.Disconnected != "" {
+		t.Errorf("Links using other accounts should not be flagged, got %q", got.Disconnected)
+	}
+
+	events, _ := auditStore.List("user123", 0)
+	if len(events) != 1 || !strings.Contains(events[0].Details, "1 sync link(s) flagged") {
+		t.Errorf("Audit details should count the flagged links, got %+v", events)
+	}
+}
+
+func TestConnectionService_Disconnect_Schedules(t *testing.T) {
+	service, provider, _, auditStore, transferService := setupConnectionService(t)
+	scheduleService := NewScheduleService(storage.NewInMemoryScheduleStore(), transferService)
+	service.SetScheduleService(scheduleService)
+
+	alice, err := scheduleService.CreateSchedule(TransferRequest{UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Revoking", TargetAccount: "alice", PlaylistID: "mock-1"}, models.ScheduleTransfer, "@daily")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+	bob, err := scheduleService.CreateSchedule(TransferRequest{UserID: "user123", SourceProvider: "Revoking", SourceAccount: "bob", TargetProvider: "Mock Music", PlaylistID: "mock-1"}, models.ScheduleTransfer, "@daily")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+
+	err = service.Disconnect(context.Background(), provider, "revoking", providers.Account{UserID: "user123", ExternalUserID: "alice"})
+	if err != nil {
+		t.Fatalf("Disconnect() failed: %v", err)
+	}
+
+	if got, _ := scheduleService.GetSchedule("user123", alice.ID); got.Enabled {
+		t.Error("The schedule using alice's account should be paused")
+	}
+	runs, _ := scheduleService.ListRuns("user123", alice.ID, 0)
+	if len(runs) != 1 || runs[0].Status != StatusFailed || runs[0].Message != "Paused: the Revoking account alice was disconnected" {
+		t.Errorf("Expected a failed run explaining the pause, got %+v", runs)
+	}
+	if got, _ := scheduleService.GetSchedule("user123", bob.ID); !got.Enabled {
+		t.Error("Schedules using other accounts should keep running")
+	}
+
+	events, _ := auditStore.List("user123", 0)
+	if len(events) != 1 || !strings.Contains(events[0].Details, "1 schedule(s) paused") {
+		t.Errorf("Audit details should count the paused schedules, got %+v", events)
+	}
+}
+
+func TestConnectionService_Disconnect_RevokeFailure(t *testing.T) {
+	service, provider, connectionStore, auditStore, _ := setupConnectionService(t)
+	provider.revokeErr = errors.New("provider unavailable")
+
+	err := service.Disconnect(context.Background(), provider, "revoking", providers.Account{UserID: "user123", ExternalUserID: "bob"})
+	if err != nil {
+		t.Fatalf("Disconnect() should succeed when revocation fails: %v", err)
+	}
+
+	if _, err := connectionStore.Get("revoking", "user123", "bob"); err == nil {
+		t.Error("Connection should be deleted even if revocation fails")
+	}
+
+	events, _ := auditStore.List("user123", 0)
+	if len(events) != 1 || !strings.Contains(events[0].Details, "token revocation failed") {
+		t.Errorf("Audit log should record the failed revocation, got %+v", events)
+	}
+}
+
+func TestConnectionService_Disconnect_NotFound(t *testing.T) {
+	service, provider, _, auditStore, _ := setupConnectionService(t)
+
+	err := service.Disconnect(context.Background(), provider, "revoking", providers.Account{UserID: "user123", ExternalUserID: "carol"})
+	if err == nil {
+		t.Error("Disconnect() should fail for unknown accounts")
+	}
+
+	if events, _ := auditStore.List("user123", 0); len(events) != 0 {
+		t.Errorf("Failed disconnects should not be audit-logged, got %d events", len(events))
+	}
+}
+
+// credentialProvider is a provider linked with a username and password
+type credentialProvider struct {
+	*providers.MockProvider
+	store storage.ConnectionStore
+}
+
+func (p *credentialProvider) Name() string { return "Credential" }
+
+func (p *credentialProvider) Connect(ctx context.Context, userID string, creds providers.Credentials) (*models.Connection, error) {
+	if creds.Password != "secret" {
+		return nil, errors.New("wrong username or password")
+	}
+	conn := &models.Connection{Provider: "credential", UserID: userID, ExternalUserID: creds.Username, Connected: true}
+	return conn, p.store.Save(conn)
+}
+
+func TestConnectionService_Connect(t *testing.T) {
+	service, revoking, connectionStore, auditStore, _ := setupConnectionService(t)
+	provider := &credentialProvider{MockProvider: providers.NewMockProvider(), store: connectionStore}
+
+	if _, err := service.Connect(context.Background(), provider, "credential", "user123", providers.Credentials{Username: "carol", Password: "wrong"}); err == nil {
+		t.Error("Connect() should fail for wrong credentials")
+	}
+
+	conn, err := service.Connect(context.Background(), provider, "credential", "user123", providers.Credentials{Username: "carol", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Connect() failed: %v", err)
+	}
+	if conn.ExternalUserID != "carol" {
+		t.Errorf("Expected the connection of carol, got %+v", conn)
+	}
+	if _, err := connectionStore.Get("credential", "user123", "carol"); err != nil {
+		t.Errorf("Connection should be saved: %v", err)
+	}
+
+	events, _ := auditStore.List("user123", 0)
+	if len(events) != 1 || events[0].Action != "connection.connect" || events[0].ExternalUserID != "carol" {
+		t.Errorf("Expected one connect audit event, got %+v", events)
+	}
+
+	if _, err := service.Connect(context.Background(), revoking, "revoking", "user123", providers.Credentials{Username: "alice"}); err == nil {
+		t.Error("Connect() should fail for OAuth providers")
+	}
+}
+
+// waitForStatus polls a transfer until it reaches the wanted status or a second passes
+func waitForStatus(t *testing.T, s *TransferService, id, want string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		progress, err := s.GetTransfer("user123", id)
+		if err != nil {
+			t.Fatalf("GetTransfer() failed: %v", err)
+		}
+		if progress.Status == want || time.Now().After(deadline) {
+			return progress.Status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
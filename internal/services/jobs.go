@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+// Transfer job statuses
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusCancelled  = "cancelled"
+)
+
+const (
+	// jobRetention is how long finished transfers stay queryable
+	jobRetention = time.Hour
+
+	// defaultBatchWorkers is how many playlists of a batch are transferred at once
+	defaultBatchWorkers = 4
+
+	// defaultProviderConcurrency is how many playlists may use the same
+	// provider at once, across all batches
+	defaultProviderConcurrency = 2
+)
+
+// transferJob is a transfer running in the background
+type transferJob struct {
+	req         TransferRequest
+	upload      *models.Playlist // playlist to import instead of exporting req.PlaylistID
+	progress    TransferProgress
+	results     []TrackResult
+	albums      []AlbumResult
+	artists     []ArtistResult
+	batchTracks [][]TrackResult // track results per entry of progress.Playlists
+	cancel      context.CancelFunc
+}
+
+// finished reports whether the job has reached a final status
+func (j *transferJob) finished() bool {
+	switch j.progress.Status {
+	case StatusCompleted, StatusFailed, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// StartTransfer queues a transfer and runs it in the background.
+// Default (empty) accounts are resolved up front so the job can later be
+// matched against the connection it uses.
+func (s *TransferService) StartTransfer(req TransferRequest) (TransferProgress, error) {
+	source, err := s.GetProvider(req.SourceProvider)
+	if err != nil {
+		return TransferProgress{}, fmt.Errorf("source provider error: %w", err)
+	}
+	target, err := s.GetProvider(req.TargetProvider)
+	if err != nil {
+		return TransferProgress{}, fmt.Errorf("target provider error: %w", err)
+	}
+
+	req.SourceAccount = resolveAccount(source, req.UserID, req.SourceAccount)
+	req.TargetAccount = resolveAccount(target, req.UserID, req.TargetAccount)
+
+	return s.startJob(req, nil)
+}
+
+// StartImport queues the import of a playlist that did not come from a
+// provider, such as an uploaded file, into req's target account.
+// It is tracked, cancelled and reported like any other transfer.
+func (s *TransferService) StartImport(req TransferRequest, playlist models.Playlist) (TransferProgress, error) {
+	target, err := s.GetProvider(req.TargetProvider)
+	if err != nil {
+		return TransferProgress{}, fmt.Errorf("target provider error: %w", err)
+	}
+	req.SourceProvider, req.SourceAccount, req.PlaylistID = "", "", ""
+	req.TargetAccount = resolveAccount(target, req.UserID, req.TargetAccount)
+
+	return s.startJob(req, &playlist)
+}
+
+// startJob registers a job for req and runs it in the background
+func (s *TransferService) startJob(req TransferRequest, upload *models.Playlist) (TransferProgress, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return TransferProgress{}, fmt.Errorf("failed to generate transfer ID: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &transferJob{
+		req:    req,
+		upload: upload,
+		cancel: cancel,
+		progress: TransferProgress{
+			ID:             hex.EncodeToString(b),
+			PlaylistID:     req.PlaylistID,
+			Mode:           req.Mode,
+			SourceProvider: req.SourceProvider,
+			SourceAccount:  req.SourceAccount,
+			TargetProvider: req.TargetProvider,
+			TargetAccount:  req.TargetAccount,
+			Status:         StatusPending,
+			Message:        "Waiting to start...",
+			StartedAt:      time.Now(),
+		},
+	}
+	if upload != nil {
+		job.progress.PlaylistName = upload.Name
+	}
+
+	s.mu.Lock()
+	s.pruneJobsLocked()
+	s.jobs[job.progress.ID] = job
+	snapshot := job.progress
+	s.mu.Unlock()
+
+	go s.runJob(ctx, job)
+
+	return snapshot, nil
+}
+
+// GetTransfer returns the current state of one of the user's transfers
+func (s *TransferService) GetTransfer(userID, id string) (TransferProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.req.UserID != userID {
+		return TransferProgress{}, fmt.Errorf("transfer not found: %s", id)
+	}
+	return job.progress, nil
+}
+
+// ListTransfers returns the user's recent transfers, newest first
+func (s *TransferService) ListTransfers(userID string) []TransferProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transfers []TransferProgress
+	for _, job := range s.jobs {
+		if job.req.UserID == userID {
+			transfers = append(transfers, job.progress)
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].StartedAt.Equal(transfers[j].StartedAt) {
+			return transfers[i].ID < transfers[j].ID
+		}
+		return transfers[i].StartedAt.After(transfers[j].StartedAt)
+	})
+	return transfers
+}
+
+// GetTransferReport returns the per-track outcome of one of the user's transfers.
+// Tracks appear once matching has finished.
+func (s *TransferService) GetTransferReport(userID, id string) (TransferReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.req.UserID != userID {
+		return TransferReport{}, fmt.Errorf("transfer not found: %s", id)
+	}
+	return newReport(job), nil
+}
+
+// CancelTransfer cancels one of the user's unfinished transfers
+func (s *TransferService) CancelTransfer(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.req.UserID != userID {
+		return fmt.Errorf("transfer not found: %s", id)
+	}
+	if job.finished() {
+		return fmt.Errorf("transfer already %s", job.progress.Status)
+	}
+
+	job.cancel()
+	return nil
+}
+
+// CancelAccountTransfers cancels the user's unfinished transfers that read
+// from or write to the given provider account. It returns how many were cancelled.
+func (s *TransferService) CancelAccountTransfers(userID, providerName, externalUserID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cancelled := 0
+	for _, job := range s.jobs {
+		if job.req.UserID != userID || job.finished() {
+			continue
+		}
+		usesSource := job.req.SourceProvider == providerName && job.req.SourceAccount == externalUserID
+		usesTarget := job.req.TargetProvider == providerName && job.req.TargetAccount == externalUserID
+		if usesSource || usesTarget {
+			job.cancel()
+			cancelled++
+		}
+	}
+	return cancelled
+}
+
+// runJob waits out the start delay and runs the transfer, recording its
+// outcome. Cancelling ctx stops the job whether it is still queued or running.
+func (s *TransferService) runJob(ctx context.Context, job *transferJob) {
+	defer job.cancel()
+
+	timer := time.NewTimer(s.startDelay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+
+	err := ctx.Err()
+	if err == nil {
+		s.updateJob(job, func(p *TransferProgress) {
+			p.Status = StatusInProgress
+			p.Message = "Starting transfer..."
+		})
+		if job.upload != nil {
+			_, err = s.importPlaylist(ctx, job.req, *job.upload, job)
+		} else {
+			_, err = s.transfer(ctx, job.req, job)
+		}
+	}
+
+	s.updateJob(job, func(p *TransferProgress) {
+		now := time.Now()
+		p.CompletedAt = &now
+		switch {
+		case err == nil:
+			p.Status = StatusCompleted
+			p.Progress = 100
+			p.Message = "Transfer complete!"
+		case errors.Is(err, context.Canceled):
+			p.Status = StatusCancelled
+			p.Message = "Transfer cancelled"
+		default:
+			log.Printf("Transfer failed: %v", err)
+			p.Status = StatusFailed
+			p.Message = err.Error()
+		}
+	})
+}
+
+// updateJob applies fn to the job's progress under the service lock.
+// A nil job is ignored so synchronous transfers can share the same code path.
+func (s *TransferService) updateJob(job *transferJob, fn func(p *TransferProgress)) {
+	if job == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&job.progress)
+}
+
+// pruneJobsLocked drops finished jobs past their retention. s.mu must be held.
+func (s *TransferService) pruneJobsLocked() {
+	cutoff := time.Now().Add(-jobRetention)
+	for id, job := range s.jobs {
+		if job.finished() && job.progress.CompletedAt != nil && job.progress.CompletedAt.Before(cutoff) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// resolveAccount returns the provider account a transfer will use, picking the
+// user's default account when none was chosen
+func resolveAccount(provider providers.Provider, userID, externalUserID string) string {
+	if externalUserID != "" {
+		return externalUserID
+	}
+	lister, ok := provider.(providers.AccountLister)
+	if !ok {
+		return ""
+	}
+	connections, err := lister.Accounts(userID)
+	if err != nil || len(connections) == 0 {
+		return ""
+	}
+	return connections[0].ExternalUserID
+}
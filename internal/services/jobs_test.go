@@ -0,0 +1,142 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+func TestTransferService_StartTransfer_Completes(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+
+	progress, err := s.StartTransfer(TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", PlaylistID: "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("StartTransfer() failed: %v", err)
+	}
+	if progress.ID == "" {
+		t.Error("StartTransfer() should assign an ID")
+	}
+
+	if status := waitForStatus(t, s, progress.ID, StatusCompleted); status != StatusCompleted {
+		t.Errorf("Expected transfer to complete, got %s", status)
+	}
+
+	if _, err := s.GetTransfer("someone-else", progress.ID); err == nil {
+		t.Error("GetTransfer() should not expose other users' transfers")
+	}
+}
+
+func TestTransferService_StartTransfer_UnknownProvider(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+
+	_, err := s.StartTransfer(TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Nope", PlaylistID: "mock-1",
+	})
+	if err == nil {
+		t.Error("StartTransfer() should reject unknown providers")
+	}
+}
+
+func TestTransferService_CancelTransfer(t *testing.T) {
+	s := NewTransferService()
+	s.startDelay = time.Hour
+	s.RegisterProvider(providers.NewMockProvider())
+
+	progress, err := s.StartTransfer(TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", PlaylistID: "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("StartTransfer() failed: %v", err)
+	}
+
+	if err := s.CancelTransfer("someone-else", progress.ID); err == nil {
+		t.Error("CancelTransfer() should not cancel other users' transfers")
+	}
+	if err := s.CancelTransfer("user123", progress.ID); err != nil {
+		t.Fatalf("CancelTransfer() failed: %v", err)
+	}
+
+	if status := waitForStatus(t, s, progress.ID, StatusCancelled); status != StatusCancelled {
+		t.Errorf("Expected transfer to be cancelled, got %s", status)
+	}
+	if err := s.CancelTransfer("user123", progress.ID); err == nil {
+		t.Error("CancelTransfer() should fail for finished transfers")
+	}
+}
+
+// blockingSource is a mock source whose exports signal exporting and then
+// wait until release is closed
+type blockingSource struct {
+	*providers.MockProvider
+	exporting chan struct{}
+	release   chan struct{}
+}
+
+func (p *blockingSource) Name() string { return "Blocking" }
+
+func (p *blockingSource) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	close(p.exporting)
+	<-p.release
+	return p.MockProvider.ExportPlaylist(acct, id)
+}
+
+func TestTransferService_CancelTransfer_Running(t *testing.T) {
+	s := NewTransferService()
+	source := &blockingSource{MockProvider: providers.NewMockProvider(), exporting: make(chan struct{}), release: make(chan struct{})}
+	s.RegisterProvider(source)
+	s.RegisterProvider(providers.NewMockProvider())
+
+	progress, err := s.StartTransfer(TransferRequest{
+		UserID: "user123", SourceProvider: "Blocking", TargetProvider: "Mock Music", PlaylistID: "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("StartTransfer() failed: %v", err)
+	}
+
+	<-source.exporting
+	if status := waitForStatus(t, s, progress.ID, StatusInProgress); status != StatusInProgress {
+		t.Fatalf("Expected transfer to be running, got %s", status)
+	}
+	if err := s.CancelTransfer("user123", progress.ID); err != nil {
+		t.Fatalf("CancelTransfer() failed: %v", err)
+	}
+	close(source.release)
+
+	if status := waitForStatus(t, s, progress.ID, StatusCancelled); status != StatusCancelled {
+		t.Errorf("Expected the running transfer to be cancelled, got %s", status)
+	}
+}
+
+func TestTransferService_StartImport(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+
+	playlist := models.Playlist{
+		Name:   "Uploaded",
+		Tracks: []models.Track{{Title: "Song", Artist: "Artist"}},
+	}
+	progress, err := s.StartImport(TransferRequest{UserID: "user123", TargetProvider: "Mock Music"}, playlist)
+	if err != nil {
+		t.Fatalf("StartImport() failed: %v", err)
+	}
+	if progress.SourceProvider != "" {
+		t.Errorf("Expected no source provider for uploads, got %q", progress.SourceProvider)
+	}
+	if progress.PlaylistName != "Uploaded" {
+		t.Errorf("Expected playlist name 'Uploaded', got %q", progress.PlaylistName)
+	}
+
+	if status := waitForStatus(t, s, progress.ID, StatusCompleted); status != StatusCompleted {
+		t.Errorf("Expected import to complete, got %s", status)
+	}
+
+	if _, err := s.StartImport(TransferRequest{UserID: "user123", TargetProvider: "Nope"}, playlist); err == nil {
+		t.Error("StartImport() should reject unknown providers")
+	}
+}
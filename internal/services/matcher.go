@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+// Track match outcomes recorded in transfer reports
+const (
+	MatchFound    = "matched"   // found in the target catalog
+	MatchOverride = "override"  // pinned by a user's match override
+	MatchSkipped  = "skipped"   // excluded by a user's match override
+	MatchNotFound = "not_found" // no confident match in the target catalog
+	MatchDeferred = "deferred"  // passed on as-is; the target matches it on import
+)
+
+// TrackResult is the outcome of transferring a single track
+type TrackResult struct {
+	Source models.Track  `json:"source"`
+	Target *models.Track `json:"target,omitempty"`
+	Status string        `json:"status"`
+}
+
+// AlbumResult is the outcome of transferring a single saved album
+type AlbumResult struct {
+	Source models.Album  `json:"source"`
+	Target *models.Album `json:"target,omitempty"`
+	Status string        `json:"status"`
+}
+
+// ArtistResult is the outcome of transferring a single followed artist
+type ArtistResult struct {
+	Source models.Artist  `json:"source"`
+	Target *models.Artist `json:"target,omitempty"`
+	Status string         `json:"status"`
+}
+
+// PlaylistResult is the status of one playlist of a batch or library
+// migration
+type PlaylistResult struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Status      string `json:"status"` // a transfer job status
+	Error       string `json:"error,omitempty"`
+	TrackCount  int    `json:"track_count"`
+	Transferred int    `json:"transferred"` // tracks with a target
+}
+
+// TransferReport lists what happened to each track, album or artist of a
+// transfer
+type TransferReport struct {
+	TransferID   string           `json:"transfer_id"`
+	PlaylistName string           `json:"playlist_name"`
+	Status       string           `json:"status"`
+	Summary      map[string]int   `json:"summary"` // item count per match outcome
+	Tracks       []TrackResult    `json:"tracks"`
+	Albums       []AlbumResult    `json:"albums,omitempty"`    // album transfers only
+	Artists      []ArtistResult   `json:"artists,omitempty"`   // artist transfers only
+	Playlists    []PlaylistResult `json:"playlists,omitempty"` // batches and library migrations only
+}
+
+// durationTolerance is how far apart in seconds two recordings of the same track may be
+const durationTolerance = 10
+
+// matchTracks decides which target track each source track is transferred as.
+// Match overrides take precedence; otherwise targets that can search their
+// catalog are searched, and other targets receive the source tracks unchanged.
+// Tracks found in cache reuse the earlier outcome, and new outcomes are
+// added to it; cache may be nil.
+func (s *TransferService) matchTracks(ctx context.Context, req TransferRequest, target providers.Provider, tracks []models.Track, cache *trackCache) ([]TrackResult, error) {
+	searcher, canSearch := target.(providers.TrackSearcher)
+	targetAccount := providers.Account{UserID: req.UserID, ExternalUserID: req.TargetAccount}
+	sourceSlug := ProviderSlug(req.SourceProvider)
+	targetSlug := ProviderSlug(req.TargetProvider)
+
+	results := make([]TrackResult, 0, len(tracks))
+	for _, track := range tracks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if cached, ok := cache.get(track.ID); ok {
+			cached.Source = track
+			results = append(results, cached)
+			continue
+		}
+
+		result := TrackResult{Source: track}
+		if override := s.lookupOverride(req.UserID, sourceSlug, track.ID, targetSlug); override != nil {
+			if override.TargetTrackID == "" {
+				result.Status = MatchSkipped
+			} else {
+				pinned := track
+				pinned.ID = override.TargetTrackID
+				result.Target = &pinned
+				result.Status = MatchOverride
+			}
+		} else if !canSearch {
+			deferred := track
+			result.Target = &deferred
+			result.Status = MatchDeferred
+		} else {
+			candidates, err := searcher.SearchTrack(targetAccount, track)
+			if err != nil {
+				return nil, fmt.Errorf("search for %q failed: %w", track.Title, err)
+			}
+			if match := bestMatch(track, candidates); match != nil {
+				result.Target = match
+				result.Status = MatchFound
+			} else {
+				result.Status = MatchNotFound
+			}
+		}
+
+		cache.put(track.ID, result)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// matchAlbums decides which target album each saved album is transferred
+// as. Targets that can search their catalog are searched; other targets
+// receive the albums unchanged.
+func matchAlbums(ctx context.Context, target providers.Provider, targetAccount providers.Account, albums []models.Album) ([]AlbumResult, error) {
+	searcher, canSearch := target.(providers.AlbumSearcher)
+
+	results := make([]AlbumResult, 0, len(albums))
+	for _, album := range albums {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := AlbumResult{Source: album}
+		if !canSearch {
+			deferred := album
+			result.Target = &deferred
+			result.Status = MatchDeferred
+			results = append(results, result)
+			continue
+		}
+
+		candidates, err := searcher.SearchAlbum(targetAccount, album)
+		if err != nil {
+			return nil, fmt.Errorf("search for %q failed: %w", album.Title, err)
+		}
+		if match := bestAlbumMatch(album, candidates); match != nil {
+			result.Target = match
+			result.Status = MatchFound
+		} else {
+			result.Status = MatchNotFound
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// matchArtists decides which target artist each followed artist is
+// transferred as, like matchAlbums
+func matchArtists(ctx context.Context, target providers.Provider, targetAccount providers.Account, artists []models.Artist) ([]ArtistResult, error) {
+	searcher, canSearch := target.(providers.ArtistSearcher)
+
+	results := make([]ArtistResult, 0, len(artists))
+	for _, artist := range artists {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := ArtistResult{Source: artist}
+		if !canSearch {
+			deferred := artist
+			result.Target = &deferred
+			result.Status = MatchDeferred
+			results = append(results, result)
+			continue
+		}
+
+		candidates, err := searcher.SearchArtist(targetAccount, artist)
+		if err != nil {
+			return nil, fmt.Errorf("search for %q failed: %w", artist.Name, err)
+		}
+		if match := bestArtistMatch(artist, candidates); match != nil {
+			result.Target = match
+			result.Status = MatchFound
+		} else {
+			result.Status = MatchNotFound
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// lookupOverride returns the user's override for a source track, or nil
+func (s *TransferService) lookupOverride(userID, sourceProvider, sourceTrackID, targetProvider string) *models.MatchOverride {
+	if s.overrides == nil || sourceTrackID == "" {
+		return nil
+	}
+	override, err := s.overrides.Get(userID, sourceProvider, sourceTrackID, targetProvider)
+	if err != nil {
+		return nil
+	}
+	return override
+}
+
+// bestMatch picks the candidate that is the same recording as track: an
+// identical ISRC wins, otherwise the normalized title and artist must agree
+// and the durations must be close. It returns nil if no candidate qualifies.
+func bestMatch(track models.Track, candidates []models.Track) *models.Track {
+	if track.ISRC != "" {
+		for i := range candidates {
+			if strings.EqualFold(candidates[i].ISRC, track.ISRC) {
+				return &candidates[i]
+			}
+		}
+	}
+
+	title, artist := normalize(track.Title), normalize(track.Artist)
+	for i := range candidates {
+		c := &candidates[i]
+		if normalize(c.Title) != title || normalize(c.Artist) != artist {
+			continue
+		}
+		if track.Duration > 0 && c.Duration > 0 && abs(track.Duration-c.Duration) > durationTolerance {
+			continue
+		}
+		return c
+	}
+
+	return nil
+}
+
+// bestAlbumMatch picks the candidate that is the same release as album: an
+// identical UPC wins, otherwise the normalized title and artist must agree.
+// It returns nil if no candidate qualifies.
+func bestAlbumMatch(album models.Album, candidates []models.Album) *models.Album {
+	if upc := normalizeUPC(album.UPC); upc != "" {
+		for i := range candidates {
+			if normalizeUPC(candidates[i].UPC) == upc {
+				return &candidates[i]
+			}
+		}
+	}
+
+	title, artist := normalize(album.Title), normalize(album.Artist)
+	for i := range candidates {
+		if normalize(candidates[i].Title) == title && normalize(candidates[i].Artist) == artist {
+			return &candidates[i]
+		}
+	}
+
+	return nil
+}
+
+// bestArtistMatch picks the candidate whose normalized name agrees with the
+// artist's, or returns nil
+func bestArtistMatch(artist models.Artist, candidates []models.Artist) *models.Artist {
+	name := normalize(artist.Name)
+	for i := range candidates {
+		if normalize(candidates[i].Name) == name {
+			return &candidates[i]
+		}
+	}
+	return nil
+}
+
+// normalizeUPC strips the leading zeros that tell a 12-digit UPC from the
+// same code written as a 13-digit EAN
+func normalizeUPC(upc string) string {
+	return strings.TrimLeft(strings.TrimSpace(upc), "0")
+}
+
+// normalize reduces a title or artist name to a comparable form: lower case,
+// without bracketed annotations such as "(Remastered)" or featured artists,
+// and with punctuation folded to single spaces
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	for _, sep := range []string{" feat. ", " ft. ", " featuring "} {
+		if i := strings.Index(s, sep); i >= 0 {
+			s = s[:i]
+		}
+	}
+
+	var b strings.Builder
+	depth := 0
+	space := false
+	for _, r := range s {
+		switch {
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0:
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// newReport builds a report from a job's per-item results
+func newReport(job *transferJob) TransferReport {
+	results := job.results
+	if job.batchTracks != nil {
+		results = nil
+		for _, tracks := range job.batchTracks {
+			results = append(results, tracks...)
+		}
+	}
+	if results == nil {
+		results = []TrackResult{}
+	}
+
+	summary := map[string]int{
+		MatchFound:    0,
+		MatchOverride: 0,
+		MatchSkipped:  0,
+		MatchNotFound: 0,
+		MatchDeferred: 0,
+	}
+	for _, r := range results {
+		summary[r.Status]++
+	}
+	for _, r := range job.albums {
+		summary[r.Status]++
+	}
+	for _, r := range job.artists {
+		summary[r.Status]++
+	}
+
+	return TransferReport{
+		TransferID:   job.progress.ID,
+		PlaylistName: job.progress.PlaylistName,
+		Status:       job.progress.Status,
+		Summary:      summary,
+		Tracks:       results,
+		Albums:       job.albums,
+		Artists:      job.artists,
+		Playlists:    job.progress.Playlists,
+	}
+}
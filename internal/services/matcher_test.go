@@ -0,0 +1,230 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// plainProvider is a target that cannot search its catalog
+type plainProvider struct {
+	*providers.MockProvider
+	imported []models.Playlist
+}
+
+func (p *plainProvider) Name() string { return "Plain" }
+
+func (p *plainProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	p.imported = append(p.imported, playlist)
+	return nil
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Bohemian Rhapsody", "bohemian rhapsody"},
+		{"Bohemian Rhapsody (Remastered 2011)", "bohemian rhapsody"},
+		{"Song [Live] - Edit", "song edit"},
+		{"Get Lucky feat. Pharrell Williams", "get lucky"},
+		{"AC/DC", "ac dc"},
+		{"  Beyoncé  ", "beyoncé"},
+	}
+
+	for _, tt := range tests {
+		if got := normalize(tt.in); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	track := models.Track{Title: "Sunshine Day (Remastered)", Artist: "The Happy Band", Duration: 180, ISRC: "ISRC1"}
+
+	tests := []struct {
+		name       string
+		candidates []models.Track
+		wantID     string
+	}{
+		{
+			name: "ISRC wins over title",
+			candidates: []models.Track{
+				{ID: "by-title", Title: "Sunshine Day", Artist: "The Happy Band", Duration: 180},
+				{ID: "by-isrc", Title: "Something Else", ISRC: "isrc1"},
+			},
+			wantID: "by-isrc",
+		},
+		{
+			name:       "normalized title and artist",
+			candidates: []models.Track{{ID: "c1", Title: "Sunshine Day", Artist: "the happy band", Duration: 185}},
+			wantID:     "c1",
+		},
+		{
+			name:       "duration too far apart",
+			candidates: []models.Track{{ID: "c1", Title: "Sunshine Day", Artist: "The Happy Band", Duration: 420}},
+		},
+		{
+			name:       "different artist",
+			candidates: []models.Track{{ID: "c1", Title: "Sunshine Day", Artist: "Cover Band"}},
+		},
+		{
+			name: "no candidates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bestMatch(track, tt.candidates)
+			if tt.wantID == "" {
+				if got != nil {
+					t.Errorf("Expected no match, got %s", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != tt.wantID {
+				t.Errorf("Expected match %s, got %+v", tt.wantID, got)
+			}
+		})
+	}
+}
+
+func TestBestAlbumMatch(t *testing.T) {
+	album := models.Album{Title: "Good Times (Deluxe Edition)", Artist: "The Happy Band", UPC: "0000000000017"}
+
+	tests := []struct {
+		name       string
+		candidates []models.Album
+		wantID     string
+	}{
+		{
+			name: "UPC wins over title",
+			candidates: []models.Album{
+				{ID: "by-title", Title: "Good Times", Artist: "The Happy Band"},
+				{ID: "by-upc", Title: "Something Else", UPC: "000000000017"},
+			},
+			wantID: "by-upc",
+		},
+		{
+			name:       "normalized title and artist",
+			candidates: []models.Album{{ID: "a1", Title: "Good Times", Artist: "the happy band", UPC: "999"}},
+			wantID:     "a1",
+		},
+		{
+			name:       "different artist",
+			candidates: []models.Album{{ID: "a1", Title: "Good Times", Artist: "Cover Band"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bestAlbumMatch(album, tt.candidates)
+			if tt.wantID == "" {
+				if got != nil {
+					t.Errorf("Expected no match, got %s", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != tt.wantID {
+				t.Errorf("Expected match %s, got %+v", tt.wantID, got)
+			}
+		})
+	}
+}
+
+func TestBestArtistMatch(t *testing.T) {
+	candidates := []models.Artist{{ID: "a1", Name: "Beyonce"}, {ID: "a2", Name: "AC/DC"}}
+
+	if got := bestArtistMatch(models.Artist{Name: "ac-dc"}, candidates); got == nil || got.ID != "a2" {
+		t.Errorf("Expected a2, got %+v", got)
+	}
+	if got := bestArtistMatch(models.Artist{Name: "Beyoncé"}, candidates); got != nil {
+		t.Errorf("Expected no match for a different spelling, got %+v", got)
+	}
+}
+
+func TestTransferService_AppliesMatchOverrides(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+
+	overrides := storage.NewInMemoryMatchOverrideStore()
+	s.SetMatchOverrideStore(overrides)
+	for _, o := range []*models.MatchOverride{
+		{UserID: "user123", SourceProvider: "mockmusic", SourceTrackID: "track-1", TargetProvider: "mockmusic", TargetTrackID: "track-3"},
+		{UserID: "user123", SourceProvider: "mockmusic", SourceTrackID: "track-2", TargetProvider: "mockmusic"},
+	} {
+		if err := overrides.Save(o); err != nil {
+			t.Fatalf("Failed to save override: %v", err)
+		}
+	}
+
+	progress, err := s.StartTransfer(TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", PlaylistID: "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("StartTransfer() failed: %v", err)
+	}
+	if status := waitForStatus(t, s, progress.ID, StatusCompleted); status != StatusCompleted {
+		t.Fatalf("Expected transfer to complete, got %s", status)
+	}
+
+	report, err := s.GetTransferReport("user123", progress.ID)
+	if err != nil {
+		t.Fatalf("GetTransferReport() failed: %v", err)
+	}
+	if report.PlaylistName != "Summer Vibes 2024" {
+		t.Errorf("Expected playlist name in report, got %q", report.PlaylistName)
+	}
+
+	want := map[string]string{"track-1": MatchOverride, "track-2": MatchSkipped, "track-3": MatchFound}
+	if len(report.Tracks) != len(want) {
+		t.Fatalf("Expected %d track results, got %d", len(want), len(report.Tracks))
+	}
+	for _, r := range report.Tracks {
+		if r.Status != want[r.Source.ID] {
+			t.Errorf("Track %s: status %s, want %s", r.Source.ID, r.Status, want[r.Source.ID])
+		}
+	}
+	if report.Tracks[0].Target == nil || report.Tracks[0].Target.ID != "track-3" {
+		t.Errorf("Override should pin track-1 to track-3, got %+v", report.Tracks[0].Target)
+	}
+	if report.Summary[MatchOverride] != 1 || report.Summary[MatchSkipped] != 1 || report.Summary[MatchFound] != 1 {
+		t.Errorf("Unexpected summary: %v", report.Summary)
+	}
+}
+
+func TestTransferService_DefersMatchingToTargetsWithoutSearch(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+	target := &plainProvider{MockProvider: providers.NewMockProvider()}
+	s.RegisterProvider(target)
+
+	err := s.TransferPlaylistForUser(TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Plain", PlaylistID: "mock-2",
+	})
+	if err != nil {
+		t.Fatalf("TransferPlaylistForUser() failed: %v", err)
+	}
+
+	if len(target.imported) != 1 {
+		t.Fatalf("Expected 1 imported playlist, got %d", len(target.imported))
+	}
+	imported := target.imported[0]
+	if len(imported.Tracks) != 2 || imported.Tracks[0].ID != "track-4" {
+		t.Errorf("Targets without search should receive the source tracks, got %+v", imported.Tracks)
+	}
+}
+
+func TestProviderSlug(t *testing.T) {
+	tests := map[string]string{
+		"Spotify":       "spotify",
+		"YouTube Music": "youtubemusic",
+		"Mock Music":    "mockmusic",
+	}
+	for name, want := range tests {
+		if got := ProviderSlug(name); got != want {
+			t.Errorf("ProviderSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// syncSide is one playlist of a two-way link as it is now
+type syncSide struct {
+	name    string            // models.SyncSideSource or models.SyncSideTarget
+	ids     []string          // track IDs in order, without repeats
+	matches map[string]string // ID on the other side of each track added since the last sync; "" if unmatched
+}
+
+// twoWayMerge is the outcome of merging the edits made to both playlists of
+// a two-way link since the last sync
+type twoWayMerge struct {
+	tracks    []models.SyncedTrack  // the new common track list
+	source    syncDiff              // changes to the source playlist, by source track ID
+	target    syncDiff              // changes to the target playlist, by target track ID
+	conflicts []models.SyncConflict // conflicts left for the user to resolve
+	resolved  int                   // conflicts resolved by the policy or the user
+}
+
+// mergeTwoWay merges the edits made to the source and the target of a
+// two-way link since the last sync, whose common track list was base.
+//
+// Tracks added to one side are added to the other, and tracks removed from
+// one side are removed from the other. A track added to one side whose
+// match was removed from the other is a conflict, as are both sides
+// reordering the tracks they still share differently. resolve returns the
+// side whose edit wins a conflict, by conflict ID, or "" to leave it
+// unresolved. Unresolved conflicts leave both sides' conflicting edits in
+// place and out of the common track list, so the next sync finds them again.
+func mergeTwoWay(base []models.SyncedTrack, source, target syncSide, resolve func(id string) string) twoWayMerge {
+	var m twoWayMerge
+	common := commonTracks(base)
+	onSource := positions(source.ids)
+	onTarget := positions(target.ids)
+
+	removed := map[string]map[string]bool{source.name: {}, target.name: {}}
+	for _, t := range common {
+		if _, ok := onSource[t.SourceID]; !ok {
+			removed[source.name][t.SourceID] = true
+		}
+		if _, ok := onTarget[t.TargetID]; !ok {
+			removed[target.name][t.TargetID] = true
+		}
+	}
+
+	// held are IDs of removed tracks whose removal waits for a conflict to
+	// be resolved; left are added tracks that wait for the same
+	held := map[string]map[string]bool{source.name: {}, target.name: {}}
+	left := map[string]map[string]bool{source.name: {}, target.name: {}}
+
+	var added []models.SyncedTrack
+	paired := map[string]map[string]bool{source.name: {}, target.name: {}}
+	for _, side := range []syncSide{source, target} {
+		other := target.name
+		if side.name == target.name {
+			other = source.name
+		}
+
+		for _, id := range newTrackIDs(common, side) {
+			if paired[side.name][id] {
+				continue
+			}
+			match := side.matches[id]
+			if match != "" && removed[other][match] {
+				conflict := models.SyncConflict{
+					ID: models.ConflictReadded + ":" + side.name + ":" + id, Kind: models.ConflictReadded,
+					Side: side.name, TrackID: id,
+				}
+				switch resolve(conflict.ID) {
+				case side.name:
+					// Keep the addition and undo the removal
+					delete(removed[other], match)
+					m.resolved++
+				case other:
+					// Keep the removal and undo the addition
+					m.resolved++
+					continue
+				default:
+					held[other][match] = true
+					left[side.name][id] = true
+					m.conflicts = append(m.conflicts, conflict)
+					continue
+				}
+			}
+
+			pair := models.SyncedTrack{SourceID: id, TargetID: match}
+			if side.name == target.name {
+				pair = models.SyncedTrack{SourceID: match, TargetID: id}
+			}
+			added = append(added, pair)
+			paired[side.name][id] = true
+			if match != "" {
+				// A track added to both sides is paired once
+				paired[other][match] = true
+			}
+		}
+	}
+
+	// Tracks whose removal is held are left as they are on both sides
+	frozen := map[string]map[string]bool{source.name: {}, target.name: {}}
+	var kept, shared []models.SyncedTrack
+	for _, t := range common {
+		switch {
+		case held[source.name][t.SourceID] || held[target.name][t.TargetID]:
+			frozen[source.name][t.SourceID] = true
+			frozen[target.name][t.TargetID] = true
+			kept = append(kept, t)
+		case removed[source.name][t.SourceID] || removed[target.name][t.TargetID]:
+		default:
+			kept = append(kept, t)
+			shared = append(shared, t)
+		}
+	}
+	m.tracks = append(kept, added...)
+
+	// Reordering the shared tracks on one side reorders the other; both
+	// sides reordering them differently is a conflict
+	sourceOrder := sortInSlots(slices.Clone(shared), sideKey(models.SyncSideSource, onSource))
+	targetOrder := sortInSlots(slices.Clone(shared), sideKey(models.SyncSideTarget, onTarget))
+	lead := source
+	leadPositions := onSource
+	switch {
+	case slices.Equal(sourceOrder, shared) && !slices.Equal(targetOrder, shared):
+		lead, leadPositions = target, onTarget
+	case !slices.Equal(sourceOrder, shared) && !slices.Equal(targetOrder, shared) && !slices.Equal(sourceOrder, targetOrder):
+		conflict := models.SyncConflict{ID: models.ConflictOrder, Kind: models.ConflictOrder}
+		switch resolve(conflict.ID) {
+		case source.name:
+			m.resolved++
+		case target.name:
+			lead, leadPositions = target, onTarget
+			m.resolved++
+		default:
+			lead = syncSide{}
+			m.conflicts = append(m.conflicts, conflict)
+		}
+	}
+	reorder := lead.name != ""
+	if reorder {
+		m.tracks = sortInSlots(m.tracks, sideKey(lead.name, leadPositions))
+	}
+
+	m.source = mergedDiff(source, m.tracks, frozen[source.name], left[source.name], reorder)
+	m.target = mergedDiff(target, m.tracks, frozen[target.name], left[target.name], reorder)
+	return m
+}
+
+// mergedDiff returns the changes that turn side into its part of tracks.
+// Frozen IDs are not added back and left IDs are kept where they are. If
+// reorder is set, side is put in the order of tracks.
+func mergedDiff(side syncSide, tracks []models.SyncedTrack, frozen, left map[string]bool, reorder bool) syncDiff {
+	want := make(map[string]int, len(tracks))
+	var ids []string
+	for i, t := range tracks {
+		id := sideID(t, side.name)
+		if _, ok := want[id]; id == "" || ok {
+			continue
+		}
+		want[id] = i
+		ids = append(ids, id)
+	}
+
+	var after []string
+	current := positions(side.ids)
+	for _, id := range side.ids {
+		if _, ok := want[id]; ok || left[id] {
+			after = append(after, id)
+		}
+	}
+	for _, id := range ids {
+		if _, ok := current[id]; !ok && !frozen[id] {
+			after = append(after, id)
+		}
+	}
+
+	if reorder {
+		after = sortInSlots(after, func(id string) (int, bool) {
+			i, ok := want[id]
+			return i, ok
+		})
+	}
+	return diffIDs(side.ids, after)
+}
+
+// commonTracks returns the tracks of base that were matched on both sides.
+// The others are matched again by each sync.
+func commonTracks(base []models.SyncedTrack) []models.SyncedTrack {
+	var common []models.SyncedTrack
+	for _, t := range base {
+		if t.SourceID != "" && t.TargetID != "" {
+			common = append(common, t)
+		}
+	}
+	return common
+}
+
+// newTrackIDs returns the IDs of side's tracks that are not in common
+func newTrackIDs(common []models.SyncedTrack, side syncSide) []string {
+	known := make(map[string]bool, len(common))
+	for _, t := range common {
+		known[sideID(t, side.name)] = true
+	}
+
+	var ids []string
+	for _, id := range side.ids {
+		if !known[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sideID returns the ID of t on the side
+func sideID(t models.SyncedTrack, side string) string {
+	if side == models.SyncSideTarget {
+		return t.TargetID
+	}
+	return t.SourceID
+}
+
+// sideKey returns a sortInSlots key that orders tracks by their position on
+// a side
+func sideKey(side string, positions map[string]int) func(models.SyncedTrack) (int, bool) {
+	return func(t models.SyncedTrack) (int, bool) {
+		i, ok := positions[sideID(t, side)]
+		return i, ok
+	}
+}
+
+// positions maps each ID to its index in ids
+func positions(ids []string) map[string]int {
+	m := make(map[string]int, len(ids))
+	for i, id := range ids {
+		if _, ok := m[id]; !ok {
+			m[id] = i
+		}
+	}
+	return m
+}
+
+// sortInSlots sorts the items that have a key by it, in place. Items
+// without a key keep their index.
+func sortInSlots[T any](items []T, key func(T) (int, bool)) []T {
+	var slots, keys []int
+	var keyed []T
+	for i, item := range items {
+		if k, ok := key(item); ok {
+			slots = append(slots, i)
+			keys = append(keys, k)
+			keyed = append(keyed, item)
+		}
+	}
+
+	order := make([]int, len(keyed))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] < keys[order[b]] })
+	for i, j := range order {
+		items[slots[i]] = keyed[j]
+	}
+	return items
+}
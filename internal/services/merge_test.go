@@ -0,0 +1,131 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func TestMergeTwoWay(t *testing.T) {
+	pairs := func(ids ...string) []models.SyncedTrack {
+		tracks := make([]models.SyncedTrack, len(ids))
+		for i, id := range ids {
+			tracks[i] = models.SyncedTrack{SourceID: "s" + id, TargetID: "t" + id}
+		}
+		return tracks
+	}
+	side := func(name string, ids []string, matches map[string]string) syncSide {
+		return syncSide{name: name, ids: ids, matches: matches}
+	}
+	manual := func(string) string { return "" }
+
+	tests := []struct {
+		name           string
+		base           []models.SyncedTrack // s1-t1 and s2-t2 if nil
+		source, target syncSide
+		resolve        func(string) string
+		wantSource     syncDiff
+		wantTarget     syncDiff
+		conflicts      []string
+	}{
+		{
+			name:    "unchanged",
+			source:  side("source", []string{"s1", "s2"}, nil),
+			target:  side("target", []string{"t1", "t2"}, nil),
+			resolve: manual,
+		},
+		{
+			name:       "added and removed on both sides",
+			source:     side("source", []string{"s1", "s9"}, map[string]string{"s9": "t9"}),
+			target:     side("target", []string{"t2", "t8"}, map[string]string{"t8": "s8"}),
+			resolve:    manual,
+			wantSource: syncDiff{add: []string{"s8"}, remove: []string{"s1"}},
+			wantTarget: syncDiff{add: []string{"t9"}, remove: []string{"t2"}, order: []string{"t9", "t8"}},
+		},
+		{
+			name:    "added to both sides",
+			source:  side("source", []string{"s1", "s2", "s9"}, map[string]string{"s9": "t9"}),
+			target:  side("target", []string{"t1", "t2", "t9"}, map[string]string{"t9": "s9"}),
+			resolve: manual,
+		},
+		{
+			name:       "unmatched additions stay on their side",
+			source:     side("source", []string{"s1", "s2", "s9"}, map[string]string{"s9": ""}),
+			target:     side("target", []string{"t1"}, nil),
+			resolve:    manual,
+			wantSource: syncDiff{remove: []string{"s2"}},
+		},
+		{
+			name:       "reordered on one side",
+			source:     side("source", []string{"s1", "s2"}, nil),
+			target:     side("target", []string{"t2", "t1"}, nil),
+			resolve:    manual,
+			wantSource: syncDiff{order: []string{"s2", "s1"}},
+		},
+		{
+			name:      "reordered differently",
+			base:      pairs("1", "2", "3"),
+			source:    side("source", []string{"s2", "s1", "s3"}, nil),
+			target:    side("target", []string{"t1", "t3", "t2"}, nil),
+			resolve:   manual,
+			conflicts: []string{"order"},
+		},
+		{
+			name:       "reordered differently, target wins",
+			base:       pairs("1", "2", "3"),
+			source:     side("source", []string{"s2", "s1", "s3"}, nil),
+			target:     side("target", []string{"t1", "t3", "t2"}, nil),
+			resolve:    func(string) string { return "target" },
+			wantSource: syncDiff{order: []string{"s1", "s3", "s2"}},
+		},
+		{
+			name:      "re-added after removal",
+			source:    side("source", []string{"s2", "s9"}, map[string]string{"s9": "t1"}),
+			target:    side("target", []string{"t2"}, nil),
+			resolve:   manual,
+			conflicts: []string{"readded:source:s9"},
+		},
+		{
+			name:       "re-added after removal, source wins",
+			source:     side("source", []string{"s2", "s9"}, map[string]string{"s9": "t1"}),
+			target:     side("target", []string{"t2"}, nil),
+			resolve:    func(string) string { return "source" },
+			wantTarget: syncDiff{add: []string{"t1"}},
+		},
+		{
+			name:       "re-added after removal, target wins",
+			source:     side("source", []string{"s2", "s9"}, map[string]string{"s9": "t1"}),
+			target:     side("target", []string{"t2"}, nil),
+			resolve:    func(string) string { return "target" },
+			wantSource: syncDiff{remove: []string{"s9"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := tt.base
+			if base == nil {
+				base = pairs("1", "2")
+			}
+			m := mergeTwoWay(base, tt.source, tt.target, tt.resolve)
+			assertDiff(t, "source", m.source, tt.wantSource)
+			assertDiff(t, "target", m.target, tt.wantTarget)
+			var conflicts []string
+			for _, c := range m.conflicts {
+				conflicts = append(conflicts, c.ID)
+			}
+			if !slices.Equal(conflicts, tt.conflicts) {
+				t.Errorf("Expected conflicts %v, got %v", tt.conflicts, conflicts)
+			}
+		})
+	}
+}
+
+// assertDiff compares a syncDiff with the expected one
+func assertDiff(t *testing.T, side string, got, want syncDiff) {
+	t.Helper()
+	if !slices.Equal(got.add, want.add) || !slices.Equal(got.remove, want.remove) || !slices.Equal(got.order, want.order) {
+		t.Errorf("Expected %s changes %+v, got %+v", side, want, got)
+	}
+}
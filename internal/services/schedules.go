@@ -0,0 +1,513 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/cron"
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+const (
+	// defaultSchedulePoll is how often the scheduler looks for due schedules
+	defaultSchedulePoll = 30 * time.Second
+
+	// defaultMaxSchedules is how many schedules each user may have
+	defaultMaxSchedules = 10
+
+	// defaultMinScheduleInterval is the shortest time allowed between two
+	// runs of a schedule
+	defaultMinScheduleInterval = 15 * time.Minute
+
+	// defaultScheduleJitter is the largest random delay added to each run
+	defaultScheduleJitter = 5 * time.Minute
+
+	// intervalSamples is how many upcoming runs of a spec are checked
+	// against the minimum interval
+	intervalSamples = 100
+)
+
+var (
+	// ErrScheduleLimit is returned when a user already has as many
+	// schedules as allowed
+	ErrScheduleLimit = errors.New("schedule limit reached")
+
+	// ErrScheduleBusy is returned when another scheduled run of the same
+	// user is in progress
+	ErrScheduleBusy = errors.New("another scheduled run is in progress")
+)
+
+// ScheduleService runs users' transfers and syncs on a schedule. Each user
+// has at most one scheduled run in progress at a time; schedules that come
+// due meanwhile wait for it to finish. Every run is delayed by a random
+// jitter so that schedules with the same spec do not hit the providers at
+// once.
+type ScheduleService struct {
+	store           storage.ScheduleStore
+	transferService *TransferService
+
+	maxPerUser  int           // 0 means no limit
+	minInterval time.Duration // shortest time between two runs
+	jitter      time.Duration // largest delay added to each run
+	poll        time.Duration // how often due schedules are looked for
+
+	now    func() time.Time
+	random func(n int64) int64 // returns a number in [0, n)
+
+	mu      sync.Mutex
+	running map[string]bool // IDs of users with a run in progress
+	wg      sync.WaitGroup  // runs in progress
+}
+
+// NewScheduleService creates a new schedule service
+func NewScheduleService(store storage.ScheduleStore, transferService *TransferService) *ScheduleService {
+	return &ScheduleService{
+		store:           store,
+		transferService: transferService,
+		maxPerUser:      defaultMaxSchedules,
+		minInterval:     defaultMinScheduleInterval,
+		jitter:          defaultScheduleJitter,
+		poll:            defaultSchedulePoll,
+		now:             time.Now,
+		random:          rand.Int64N,
+		running:         make(map[string]bool),
+	}
+}
+
+// SetLimits sets how many schedules each user may have, the shortest time
+// allowed between two runs of a schedule and the largest random delay added
+// to each run. A maxPerUser of 0 removes the limit.
+func (s *ScheduleService) SetLimits(maxPerUser int, minInterval, jitter time.Duration) {
+	s.maxPerUser = maxPerUser
+	s.minInterval = minInterval
+	s.jitter = jitter
+}
+
+// ScheduleUpdate changes the settings of a schedule
+type ScheduleUpdate struct {
+	Spec    string // new spec; empty keeps the current one
+	Enabled *bool  // nil keeps the current setting
+}
+
+// CreateSchedule schedules the transfer of the playlist req.PlaylistID
+// between the request's accounts. Transfer schedules copy the playlist to
+// a new target playlist on every run; sync schedules copy it on their first
+// run and sync the copy on later ones. Default (empty) accounts are
+// resolved up front so that every run uses the same account.
+func (s *ScheduleService) CreateSchedule(req TransferRequest, kind, spec string) (*models.Schedule, error) {
+	if kind != models.ScheduleTransfer && kind != models.ScheduleSync {
+		return nil, fmt.Errorf("invalid schedule kind: %q", kind)
+	}
+	if req.PlaylistID == "" {
+		return nil, fmt.Errorf("no playlist selected")
+	}
+	source, err := s.transferService.GetProvider(req.SourceProvider)
+	if err != nil {
+		return nil, fmt.Errorf("source provider error: %w", err)
+	}
+	target, err := s.transferService.GetProvider(req.TargetProvider)
+	if err != nil {
+		return nil, fmt.Errorf("target provider error: %w", err)
+	}
+	next, err := s.nextRun(spec, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	if s.maxPerUser > 0 {
+		existing, err := s.store.List(req.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list schedules: %w", err)
+		}
+		if len(existing) >= s.maxPerUser {
+			return nil, fmt.Errorf("%w: at most %d schedules per user", ErrScheduleLimit, s.maxPerUser)
+		}
+	}
+
+	schedule := &models.Schedule{
+		UserID:           req.UserID,
+		Kind:             kind,
+		SourceProvider:   source.Name(),
+		SourceAccount:    resolveAccount(source, req.UserID, req.SourceAccount),
+		SourcePlaylistID: req.PlaylistID,
+		TargetProvider:   target.Name(),
+		TargetAccount:    resolveAccount(target, req.UserID, req.TargetAccount),
+		Spec:             spec,
+		Enabled:          true,
+		NextRunAt:        next,
+	}
+	if err := s.store.Save(schedule); err != nil {
+		return nil, fmt.Errorf("saving schedule failed: %w", err)
+	}
+	return schedule, nil
+}
+
+// ListSchedules returns the user's schedules, oldest first
+func (s *ScheduleService) ListSchedules(userID string) ([]*models.Schedule, error) {
+	return s.store.List(userID)
+}
+
+// GetSchedule returns one of the user's schedules
+func (s *ScheduleService) GetSchedule(userID, id string) (*models.Schedule, error) {
+	return s.store.Get(userID, id)
+}
+
+// UpdateSchedule changes the spec of one of the user's schedules or
+// enables or disables it. The next run of an enabled schedule is worked out
+// again from now; a disabled schedule has none, and its spec is only
+// checked if it changes.
+func (s *ScheduleService) UpdateSchedule(userID, id string, update ScheduleUpdate) (*models.Schedule, error) {
+	schedule, err := s.store.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Spec != "" {
+		schedule.Spec = update.Spec
+	}
+	if update.Enabled != nil {
+		schedule.Enabled = *update.Enabled
+	}
+
+	schedule.NextRunAt = time.Time{}
+	if schedule.Enabled || update.Spec != "" {
+		next, err := s.nextRun(schedule.Spec, s.now())
+		if err != nil {
+			return nil, err
+		}
+		if schedule.Enabled {
+			schedule.NextRunAt = next
+		}
+	}
+	if err := s.store.Update(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule removes one of the user's schedules and its history. The
+// playlists and sync link it created are kept.
+func (s *ScheduleService) DeleteSchedule(userID, id string) error {
+	return s.store.Delete(userID, id)
+}
+
+// ListRuns returns the most recent runs of one of the user's schedules,
+// newest first, or of all of them if scheduleID is empty
+func (s *ScheduleService) ListRuns(userID, scheduleID string, limit int) ([]*models.ScheduleRun, error) {
+	if scheduleID != "" {
+		if _, err := s.store.Get(userID, scheduleID); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.ListRuns(userID, scheduleID, limit)
+}
+
+// RunSchedule runs one of the user's schedules now, in the foreground,
+// without moving its next run. It fails with ErrScheduleBusy while another
+// run of the user is in progress.
+func (s *ScheduleService) RunSchedule(ctx context.Context, userID, id string) (*models.ScheduleRun, error) {
+	schedule, err := s.store.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.acquire(userID) {
+		return nil, ErrScheduleBusy
+	}
+	defer s.release(userID)
+
+	return s.run(ctx, schedule), nil
+}
+
+// Start looks for due schedules every poll interval and runs them in the
+// background until ctx is cancelled. Schedules that came due while the
+// server was down run once when it starts.
+func (s *ScheduleService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.poll)
+		defer ticker.Stop()
+		for {
+			s.runDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runDue starts the due schedules of users without a run in progress and
+// returns how many it started. A schedule's next run is claimed before it
+// starts, so that servers sharing a database run it once.
+func (s *ScheduleService) runDue(ctx context.Context) int {
+	now := s.now()
+	due, err := s.store.Due(now)
+	if err != nil {
+		log.Printf("Failed to list due schedules: %v", err)
+		return 0
+	}
+
+	started := 0
+	for _, schedule := range due {
+		if !s.acquire(schedule.UserID) {
+			continue
+		}
+
+		next, err := s.nextRun(schedule.Spec, now)
+		if err != nil {
+			// The limits changed since the schedule was saved
+			log.Printf("Schedule %s is no longer valid: %v", schedule.ID, err)
+			if claimed, claimErr := s.store.Claim(schedule, time.Time{}); claimErr != nil {
+				log.Printf("Failed to claim schedule %s: %v", schedule.ID, claimErr)
+			} else if claimed {
+				if err := s.pause(schedule.UserID, schedule.ID, "Paused: "+err.Error()); err != nil {
+					log.Printf("Failed to pause schedule %s: %v", schedule.ID, err)
+				}
+			}
+			s.release(schedule.UserID)
+			continue
+		}
+		claimed, err := s.store.Claim(schedule, next)
+		if err != nil || !claimed {
+			if err != nil {
+				log.Printf("Failed to claim schedule %s: %v", schedule.ID, err)
+			}
+			s.release(schedule.UserID)
+			continue
+		}
+
+		started++
+		s.wg.Add(1)
+		go func(schedule *models.Schedule) {
+			defer s.wg.Done()
+			defer s.release(schedule.UserID)
+			s.run(ctx, schedule)
+		}(schedule)
+	}
+	return started
+}
+
+// run runs a schedule once and records the outcome in its history
+func (s *ScheduleService) run(ctx context.Context, schedule *models.Schedule) *models.ScheduleRun {
+	run := &models.ScheduleRun{
+		ScheduleID: schedule.ID,
+		UserID:     schedule.UserID,
+		StartedAt:  s.now(),
+	}
+
+	message, err := s.execute(ctx, schedule)
+	run.FinishedAt = s.now()
+	run.Status = StatusCompleted
+	run.Message = message
+	if err != nil {
+		run.Status = StatusFailed
+		run.Message = err.Error()
+	}
+
+	if err := s.store.RecordRun(run); err != nil {
+		log.Printf("Failed to record run of schedule %s: %v", schedule.ID, err)
+	}
+	return run
+}
+
+// execute transfers or syncs a schedule's playlist and describes the outcome
+func (s *ScheduleService) execute(ctx context.Context, schedule *models.Schedule) (string, error) {
+	req := TransferRequest{
+		UserID:         schedule.UserID,
+		SourceProvider: schedule.SourceProvider,
+		SourceAccount:  schedule.SourceAccount,
+		TargetProvider: schedule.TargetProvider,
+		TargetAccount:  schedule.TargetAccount,
+		Mode:           ModePlaylist,
+		PlaylistID:     schedule.SourcePlaylistID,
+	}
+
+	if schedule.Kind == models.ScheduleTransfer {
+		report, err := s.transferService.RunTransfer(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Copied %q: %d matched, %d override, %d skipped, %d not found, %d deferred",
+			report.PlaylistName, report.Summary[MatchFound], report.Summary[MatchOverride],
+			report.Summary[MatchSkipped], report.Summary[MatchNotFound], report.Summary[MatchDeferred]), nil
+	}
+
+	linkID, err := s.findSyncLink(schedule)
+	if err != nil {
+		return "", err
+	}
+	if linkID == "" {
+		result, err := s.transferService.CreateSyncLink(ctx, req, SyncOptions{})
+		if err != nil {
+			return "", err
+		}
+		// Later runs sync the copy made by this one. Should storing the link
+		// fail, the next run finds it by its playlists instead of copying again.
+		if err := s.setSyncLink(schedule, result.Link.ID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Copied %q to %s playlist %s: %d added, %d unmatched",
+			result.Link.Name, result.Link.TargetProvider, result.Link.TargetPlaylistID, result.Added, result.Unmatched), nil
+	}
+	if linkID != schedule.SyncLinkID {
+		if err := s.setSyncLink(schedule, linkID); err != nil {
+			return "", err
+		}
+	}
+
+	result, err := s.transferService.RunSync(ctx, schedule.UserID, linkID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Synced %q: %d added, %d removed, %d unmatched",
+		result.Link.Name, result.Added, result.Removed, result.Unmatched), nil
+}
+
+// PauseAccountSchedules pauses each of the user's enabled schedules that
+// uses the given provider account, recording in its history that the
+// account was disconnected. It returns how many schedules were paused.
+func (s *ScheduleService) PauseAccountSchedules(userID, providerName, externalUserID string) (int, error) {
+	schedules, err := s.store.List(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	paused := 0
+	for _, schedule := range schedules {
+		usesSource := schedule.SourceProvider == providerName && schedule.SourceAccount == externalUserID
+		usesTarget := schedule.TargetProvider == providerName && schedule.TargetAccount == externalUserID
+		if !schedule.Enabled || (!usesSource && !usesTarget) {
+			continue
+		}
+		reason := fmt.Sprintf("Paused: the %s account %s was disconnected", providerName, externalUserID)
+		if err := s.pause(userID, schedule.ID, reason); err != nil {
+			return paused, err
+		}
+		paused++
+	}
+	return paused, nil
+}
+
+// pause disables one of the user's schedules and records a failed run
+// giving the reason, so its history shows why it stopped running
+func (s *ScheduleService) pause(userID, id, reason string) error {
+	schedule, err := s.store.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	schedule.Enabled = false
+	schedule.NextRunAt = time.Time{}
+	if err := s.store.Update(schedule); err != nil {
+		return err
+	}
+
+	now := s.now()
+	return s.store.RecordRun(&models.ScheduleRun{
+		ScheduleID: id,
+		UserID:     userID,
+		Status:     StatusFailed,
+		Message:    reason,
+		StartedAt:  now,
+		FinishedAt: now,
+	})
+}
+
+// findSyncLink returns the ID of the sync link a sync schedule keeps in
+// step: the one stored on the schedule, or else a one-way link between the
+// schedule's playlist and target account, which a first run may have made
+// without storing it. It returns an empty ID if there is neither, for
+// example because the user deleted the link, so that a new copy is made.
+func (s *ScheduleService) findSyncLink(schedule *models.Schedule) (string, error) {
+	links, err := s.transferService.ListSyncLinks(schedule.UserID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list sync links: %w", err)
+	}
+
+	found := ""
+	for _, link := range links {
+		if link.ID == schedule.SyncLinkID {
+			return link.ID, nil
+		}
+		if found == "" && !link.TwoWay &&
+			link.SourceProvider == schedule.SourceProvider && link.SourceAccount == schedule.SourceAccount &&
+			link.SourcePlaylistID == schedule.SourcePlaylistID &&
+			link.TargetProvider == schedule.TargetProvider && link.TargetAccount == schedule.TargetAccount {
+			found = link.ID
+		}
+	}
+	return found, nil
+}
+
+// setSyncLink stores the sync link a schedule's first run created, keeping
+// any changes made to the schedule since it started
+func (s *ScheduleService) setSyncLink(schedule *models.Schedule, linkID string) error {
+	current, err := s.store.Get(schedule.UserID, schedule.ID)
+	if err != nil {
+		return err
+	}
+	current.SyncLinkID = linkID
+	if err := s.store.Update(current); err != nil {
+		return fmt.Errorf("saving sync link of schedule failed: %w", err)
+	}
+	schedule.SyncLinkID = linkID
+	return nil
+}
+
+// nextRun parses spec and returns its first run after now, delayed by the
+// jitter. The spec's runs must be at least the minimum interval apart. The
+// jitter is kept below half the time until the run after, so it never
+// delays a run past the next one.
+func (s *ScheduleService) nextRun(spec string, now time.Time) (time.Time, error) {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("schedule %q never runs", spec)
+	}
+	gap := time.Duration(0)
+	for i, t := 0, next; i < intervalSamples; i++ {
+		after := schedule.Next(t)
+		if after.IsZero() {
+			break
+		}
+		if d := after.Sub(t); d < s.minInterval {
+			return time.Time{}, fmt.Errorf("schedule %q runs more often than every %s", spec, s.minInterval)
+		} else if gap == 0 {
+			gap = d
+		}
+		t = after
+	}
+
+	if limit := min(s.jitter, gap/2); limit > 0 {
+		next = next.Add(time.Duration(s.random(int64(limit))))
+	}
+	return next, nil
+}
+
+// acquire marks a run of the user as in progress, unless one already is
+func (s *ScheduleService) acquire(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running[userID] {
+		return false
+	}
+	s.running[userID] = true
+	return true
+}
+
+// release marks the user's run as finished
+func (s *ScheduleService) release(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, userID)
+}
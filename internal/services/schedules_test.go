@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// setupScheduleService returns a schedule service on the mock provider
+// whose clock reads *now and whose jitter is always half its limit
+func setupScheduleService(t *testing.T, now *time.Time) (*ScheduleService, storage.ScheduleStore) {
+	t.Helper()
+	transfers := NewTransferService()
+	transfers.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+	transfers.RegisterProvider(providers.NewMockProvider())
+
+	store := storage.NewInMemoryScheduleStore()
+	s := NewScheduleService(store, transfers)
+	s.now = func() time.Time { return *now }
+	s.random = func(n int64) int64 { return n / 2 }
+	return s, store
+}
+
+// mockRequest is a transfer of a mock playlist to the mock provider
+func mockRequest(userID, playlistID string) TransferRequest {
+	return TransferRequest{UserID: userID, SourceProvider: "Mock Music", TargetProvider: "Mock Music", PlaylistID: playlistID}
+}
+
+func TestScheduleService_CreateSchedule(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 7, 0, 0, time.UTC)
+	s, _ := setupScheduleService(t, &now)
+	s.SetLimits(2, time.Hour, 10*time.Minute)
+
+	schedule, err := s.CreateSchedule(mockRequest("alice", "mock-1"), models.ScheduleTransfer, "0 3 * * *")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+	want := time.Date(2024, time.May, 16, 3, 5, 0, 0, time.UTC)
+	if !schedule.NextRunAt.Equal(want) || !schedule.Enabled || schedule.SourceProvider != "Mock Music" {
+		t.Errorf("Expected an enabled schedule first running at %v, got %+v", want, schedule)
+	}
+
+	// The jitter stays below half the time between runs
+	s.SetLimits(2, time.Hour, 2*time.Hour)
+	schedule, err = s.CreateSchedule(mockRequest("alice", "mock-2"), models.ScheduleSync, "@every 2h")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+	if want := now.Add(2*time.Hour + 30*time.Minute); !schedule.NextRunAt.Equal(want) {
+		t.Errorf("Expected the first run at %v, got %v", want, schedule.NextRunAt)
+	}
+
+	if _, err := s.CreateSchedule(mockRequest("alice", "mock-3"), models.ScheduleTransfer, "@daily"); !errors.Is(err, ErrScheduleLimit) {
+		t.Errorf("Expected ErrScheduleLimit for a third schedule, got %v", err)
+	}
+	if _, err := s.CreateSchedule(mockRequest("bob", "mock-3"), models.ScheduleTransfer, "@daily"); err != nil {
+		t.Errorf("The limit should apply per user, got %v", err)
+	}
+
+	invalid := []struct {
+		name string
+		req  TransferRequest
+		kind string
+		spec string
+	}{
+		{"too often", mockRequest("carol", "mock-1"), models.ScheduleTransfer, "*/30 * * * *"},
+		{"too often at times", mockRequest("carol", "mock-1"), models.ScheduleTransfer, "0,10 4 * * *"},
+		{"bad spec", mockRequest("carol", "mock-1"), models.ScheduleTransfer, "every day"},
+		{"never runs", mockRequest("carol", "mock-1"), models.ScheduleTransfer, "0 0 30 2 *"},
+		{"unknown kind", mockRequest("carol", "mock-1"), "backup", "@daily"},
+		{"no playlist", mockRequest("carol", ""), models.ScheduleTransfer, "@daily"},
+		{"unknown provider", TransferRequest{UserID: "carol", SourceProvider: "Nope", TargetProvider: "Mock Music", PlaylistID: "mock-1"}, models.ScheduleTransfer, "@daily"},
+	}
+	for _, tt := range invalid {
+		if _, err := s.CreateSchedule(tt.req, tt.kind, tt.spec); err == nil {
+			t.Errorf("%s: CreateSchedule() should fail", tt.name)
+		}
+	}
+}
+
+func TestScheduleService_RunDue(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	s, store := setupScheduleService(t, &now)
+	s.SetLimits(0, time.Hour, 0)
+
+	transfer, err := s.CreateSchedule(mockRequest("alice", "mock-1"), models.ScheduleTransfer, "@hourly")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+	syncing, err := s.CreateSchedule(mockRequest("bob", "mock-2"), models.ScheduleSync, "@every 6h")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+	failing, err := s.CreateSchedule(mockRequest("carol", "missing"), models.ScheduleTransfer, "@hourly")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+
+	if started := s.runDue(context.Background()); started != 0 {
+		t.Fatalf("Expected nothing to be due yet, started %d", started)
+	}
+
+	// The server was down for a day: every schedule runs once
+	now = now.Add(24 * time.Hour)
+	if started := s.runDue(context.Background()); started != 3 {
+		t.Fatalf("Expected 3 runs to start, started %d", started)
+	}
+	s.wg.Wait()
+
+	runs, _ := s.ListRuns("alice", transfer.ID, 0)
+	if len(runs) != 1 || runs[0].Status != StatusCompleted || runs[0].Message != `Copied "Summer Vibes 2024": 3 matched, 0 override, 0 skipped, 0 not found, 0 deferred` {
+		t.Errorf("Expected a completed transfer run, got %+v", runs)
+	}
+	got, _ := s.GetSchedule("alice", transfer.ID)
+	if want := time.Date(2024, time.May, 16, 11, 0, 0, 0, time.UTC); !got.NextRunAt.Equal(want) || got.LastStatus != StatusCompleted || !got.LastRunAt.Equal(now) {
+		t.Errorf("Expected the next run at %v after a completed one, got %+v", want, got)
+	}
+
+	runs, _ = s.ListRuns("carol", failing.ID, 0)
+	if len(runs) != 1 || runs[0].Status != StatusFailed || !strings.Contains(runs[0].Message, "missing") {
+		t.Errorf("Expected a failed run, got %+v", runs)
+	}
+
+	// The first run of a sync schedule copies the playlist, later ones sync it
+	got, _ = s.GetSchedule("bob", syncing.ID)
+	if got.SyncLinkID == "" {
+		t.Fatal("Expected the first sync run to store its sync link")
+	}
+	now = now.Add(6 * time.Hour)
+	if started := s.runDue(context.Background()); started != 3 {
+		t.Fatalf("Expected 3 runs to start, started %d", started)
+	}
+	s.wg.Wait()
+	runs, _ = s.ListRuns("bob", syncing.ID, 0)
+	if len(runs) != 2 || runs[0].Message != `Synced "Workout Mix": 0 added, 0 removed, 0 unmatched` || !strings.HasPrefix(runs[1].Message, `Copied "Workout Mix" to Mock Music playlist`) {
+		t.Errorf("Expected a copy and then a sync, got %+v %+v", runs[1], runs[0])
+	}
+
+	// Disabled schedules do not run
+	disabled := false
+	if _, err := s.UpdateSchedule("carol", failing.ID, ScheduleUpdate{Enabled: &disabled}); err != nil {
+		t.Fatalf("UpdateSchedule() failed: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if started := s.runDue(context.Background()); started != 1 {
+		t.Errorf("Expected only alice's schedule to run, started %d", started)
+	}
+	s.wg.Wait()
+
+	if runs, _ := store.ListRuns("alice", "", 0); len(runs) != 3 {
+		t.Errorf("Expected 3 runs of alice's schedule, got %d", len(runs))
+	}
+}
+
+func TestScheduleService_RunDue_NoLongerValid(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	s, _ := setupScheduleService(t, &now)
+	s.SetLimits(0, time.Hour, 0)
+
+	schedule, err := s.CreateSchedule(mockRequest("alice", "mock-1"), models.ScheduleTransfer, "@hourly")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+
+	// The minimum interval was raised since the schedule was created
+	s.SetLimits(0, 2*time.Hour, 0)
+	now = now.Add(time.Hour)
+	if started := s.runDue(context.Background()); started != 0 {
+		t.Errorf("Expected the invalid schedule not to run, started %d", started)
+	}
+
+	got, _ := s.GetSchedule("alice", schedule.ID)
+	if got.Enabled || !got.NextRunAt.IsZero() || got.LastStatus != StatusFailed {
+		t.Errorf("Expected the schedule to be paused after a failed run, got %+v", got)
+	}
+	runs, _ := s.ListRuns("alice", schedule.ID, 0)
+	if len(runs) != 1 || runs[0].Status != StatusFailed || !strings.Contains(runs[0].Message, "runs more often than every 2h0m0s") {
+		t.Errorf("Expected a failed run giving the reason, got %+v", runs)
+	}
+
+	// Resuming it needs a spec within the limits
+	enabled := true
+	if _, err := s.UpdateSchedule("alice", schedule.ID, ScheduleUpdate{Enabled: &enabled}); err == nil {
+		t.Error("UpdateSchedule() should not resume a schedule with an invalid spec")
+	}
+	if got, err := s.UpdateSchedule("alice", schedule.ID, ScheduleUpdate{Spec: "@daily", Enabled: &enabled}); err != nil || !got.Enabled || got.NextRunAt.IsZero() {
+		t.Errorf("Expected the schedule to resume with a new spec, got %+v, %v", got, err)
+	}
+}
+
+// failingScheduleStore is a schedule store whose updates fail while fail is set
+type failingScheduleStore struct {
+	storage.ScheduleStore
+	fail bool
+}
+
+func (s *failingScheduleStore) Update(schedule *models.Schedule) error {
+	if s.fail {
+		return errors.New("database unavailable")
+	}
+	return s.ScheduleStore.Update(schedule)
+}
+
+func TestScheduleService_SyncLinkRecovery(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	s, store := setupScheduleService(t, &now)
+	failing := &failingScheduleStore{ScheduleStore: store}
+	s.store = failing
+
+	schedule, err := s.CreateSchedule(mockRequest("alice", "mock-2"), models.ScheduleSync, "@daily")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+
+	// The first run copies the playlist but cannot store its sync link
+	failing.fail = true
+	run, err := s.RunSchedule(context.Background(), "alice", schedule.ID)
+	if err != nil {
+		t.Fatalf("RunSchedule() failed: %v", err)
+	}
+	if run.Status != StatusFailed || !strings.Contains(run.Message, "database unavailable") {
+		t.Errorf("Expected the run to fail storing the link, got %+v", run)
+	}
+	failing.fail = false
+
+	// The next run finds the link instead of copying the playlist again
+	run, _ = s.RunSchedule(context.Background(), "alice", schedule.ID)
+	if run.Status != StatusCompleted || !strings.HasPrefix(run.Message, `Synced "Workout Mix"`) {
+		t.Errorf("Expected the copy to be synced, got %+v", run)
+	}
+	links, _ := s.transferService.ListSyncLinks("alice")
+	if len(links) != 1 {
+		t.Fatalf("Expected one sync link, got %d", len(links))
+	}
+	if got, _ := s.GetSchedule("alice", schedule.ID); got.SyncLinkID != links[0].ID {
+		t.Errorf("Expected the schedule to store link %s, got %q", links[0].ID, got.SyncLinkID)
+	}
+
+	// A deleted link is replaced by a new copy
+	if err := s.transferService.DeleteSyncLink("alice", links[0].ID); err != nil {
+		t.Fatalf("DeleteSyncLink() failed: %v", err)
+	}
+	run, _ = s.RunSchedule(context.Background(), "alice", schedule.ID)
+	if run.Status != StatusCompleted || !strings.HasPrefix(run.Message, `Copied "Workout Mix"`) {
+		t.Errorf("Expected a new copy, got %+v", run)
+	}
+	links, _ = s.transferService.ListSyncLinks("alice")
+	if got, _ := s.GetSchedule("alice", schedule.ID); len(links) != 1 || got.SyncLinkID != links[0].ID {
+		t.Errorf("Expected the schedule to store the new link, got %+v", got)
+	}
+}
+
+func TestScheduleService_OneRunPerUser(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	s, _ := setupScheduleService(t, &now)
+	s.SetLimits(0, time.Hour, 0)
+
+	first, _ := s.CreateSchedule(mockRequest("alice", "mock-1"), models.ScheduleTransfer, "@hourly")
+	second, _ := s.CreateSchedule(mockRequest("alice", "mock-2"), models.ScheduleTransfer, "@hourly")
+
+	// A run of alice's is in progress
+	s.acquire("alice")
+	if _, err := s.RunSchedule(context.Background(), "alice", first.ID); !errors.Is(err, ErrScheduleBusy) {
+		t.Errorf("Expected ErrScheduleBusy, got %v", err)
+	}
+	now = now.Add(time.Hour)
+	if started := s.runDue(context.Background()); started != 0 {
+		t.Errorf("Expected no runs while alice's run is in progress, started %d", started)
+	}
+	s.release("alice")
+
+	// Both schedules are still due, but only one runs at a time
+	if started := s.runDue(context.Background()); started != 1 {
+		t.Errorf("Expected one run to start, started %d", started)
+	}
+	s.wg.Wait()
+	if started := s.runDue(context.Background()); started != 1 {
+		t.Errorf("Expected the other run to start, started %d", started)
+	}
+	s.wg.Wait()
+
+	for _, id := range []string{first.ID, second.ID} {
+		if runs, _ := s.ListRuns("alice", id, 0); len(runs) != 1 {
+			t.Errorf("Expected schedule %s to run once, got %d runs", id, len(runs))
+		}
+	}
+}
+
+func TestScheduleService_RunSchedule(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	s, _ := setupScheduleService(t, &now)
+
+	schedule, err := s.CreateSchedule(mockRequest("alice", "mock-3"), models.ScheduleTransfer, "@daily")
+	if err != nil {
+		t.Fatalf("CreateSchedule() failed: %v", err)
+	}
+	run, err := s.RunSchedule(context.Background(), "alice", schedule.ID)
+	if err != nil {
+		t.Fatalf("RunSchedule() failed: %v", err)
+	}
+	if run.Status != StatusCompleted || run.ScheduleID != schedule.ID {
+		t.Errorf("Expected a completed run, got %+v", run)
+	}
+
+	got, _ := s.GetSchedule("alice", schedule.ID)
+	if !got.NextRunAt.Equal(schedule.NextRunAt) {
+		t.Errorf("Running a schedule by hand should not move its next run, got %v", got.NextRunAt)
+	}
+	if _, err := s.RunSchedule(context.Background(), "bob", schedule.ID); err == nil {
+		t.Error("RunSchedule() should not run other users' schedules")
+	}
+	if _, err := s.ListRuns("bob", schedule.ID, 0); err == nil {
+		t.Error("ListRuns() should not list other users' runs")
+	}
+}
@@ -0,0 +1,556 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// SyncResult summarizes what a sync changed in the target playlist and, for
+// two-way links, in the source playlist
+type SyncResult struct {
+	Link            *models.SyncLink `json:"link"`
+	Added           int              `json:"added"`
+	Removed         int              `json:"removed"`
+	Reordered       bool             `json:"reordered"`
+	SourceAdded     int              `json:"source_added,omitempty"`
+	SourceRemoved   int              `json:"source_removed,omitempty"`
+	SourceReordered bool             `json:"source_reordered,omitempty"`
+	Unmatched       int              `json:"unmatched"`          // tracks without a match on the other side
+	Resolved        int              `json:"resolved,omitempty"` // conflicts resolved by the policy or the user
+}
+
+// SyncOptions configures a new sync link
+type SyncOptions struct {
+	TwoWay         bool   // also apply the target playlist's edits to the source
+	ConflictPolicy string // models.ConflictManual, ConflictSourceWins or ConflictTargetWins; empty means manual
+}
+
+// syncDiff is what a sync changes in the target playlist, by target track ID
+type syncDiff struct {
+	add    []string
+	remove []string
+	order  []string // every target track in its new order; nil if adding and removing keeps the order
+}
+
+// SetSyncLinkStore enables sync links, which keep a copied playlist in step
+// with its source
+func (s *TransferService) SetSyncLinkStore(store storage.SyncLinkStore) {
+	s.syncLinks = store
+}
+
+// CreateSyncLink copies the playlist req.PlaylistID to a new playlist in the
+// target account and links the copy to its source, so that later syncs
+// apply only what changed. The target provider must be a PlaylistEditor,
+// and so must the source provider of two-way links.
+func (s *TransferService) CreateSyncLink(ctx context.Context, req TransferRequest, opts SyncOptions) (SyncResult, error) {
+	if s.syncLinks == nil {
+		return SyncResult{}, fmt.Errorf("sync links are not enabled")
+	}
+	if req.PlaylistID == "" {
+		return SyncResult{}, fmt.Errorf("no playlist selected")
+	}
+	switch {
+	case !opts.TwoWay:
+		opts.ConflictPolicy = ""
+	case opts.ConflictPolicy == "":
+		opts.ConflictPolicy = models.ConflictManual
+	case !models.ValidConflictPolicy(opts.ConflictPolicy):
+		return SyncResult{}, fmt.Errorf("invalid conflict policy: %q", opts.ConflictPolicy)
+	}
+
+	source, sourceAccount, err := s.authenticateSource(req)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if _, ok := source.(providers.PlaylistEditor); opts.TwoWay && !ok {
+		return SyncResult{}, fmt.Errorf("%s cannot edit playlists, so it cannot be synced to", source.Name())
+	}
+	target, targetAccount, editor, err := s.authenticateEditor(req)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	playlist, err := source.ExportPlaylist(sourceAccount, req.PlaylistID)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("export failed: %w", err)
+	}
+	synced, err := s.matchSynced(ctx, req, target, playlist.Tracks, nil)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	targetID, err := editor.CreatePlaylist(targetAccount, playlist)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("creating playlist failed: %w", err)
+	}
+
+	// The link is saved with no tracks before any are added, so a failure
+	// from here on leaves a link whose next sync fills the created playlist
+	// instead of an orphaned playlist that a retry would duplicate
+	link := &models.SyncLink{
+		UserID:           req.UserID,
+		SourceProvider:   source.Name(),
+		SourceAccount:    resolveAccount(source, req.UserID, req.SourceAccount),
+		SourcePlaylistID: req.PlaylistID,
+		TargetProvider:   target.Name(),
+		TargetAccount:    resolveAccount(target, req.UserID, req.TargetAccount),
+		TargetPlaylistID: targetID,
+		Name:             playlist.Name,
+		TwoWay:           opts.TwoWay,
+		ConflictPolicy:   opts.ConflictPolicy,
+	}
+	if err := s.syncLinks.Save(link); err != nil {
+		return SyncResult{}, fmt.Errorf("saving sync link for created playlist %s failed: %w", targetID, err)
+	}
+
+	diff := diffSync(nil, synced)
+	if err := editor.AddTracks(targetAccount, targetID, syncTracks(diff.add)); err != nil {
+		return SyncResult{Link: link}, fmt.Errorf("adding tracks failed, sync the link to retry: %w", err)
+	}
+
+	link.Tracks = synced
+	link.LastSyncedAt = time.Now()
+	if err := s.syncLinks.Update(link); err != nil {
+		return SyncResult{Link: link}, fmt.Errorf("saving sync link failed: %w", err)
+	}
+
+	return SyncResult{Link: link, Added: len(diff.add), Unmatched: unmatched(synced)}, nil
+}
+
+// ListSyncLinks returns the user's sync links, oldest first
+func (s *TransferService) ListSyncLinks(userID string) ([]*models.SyncLink, error) {
+	if s.syncLinks == nil {
+		return nil, nil
+	}
+	return s.syncLinks.List(userID)
+}
+
+// GetSyncLink returns one of the user's sync links
+func (s *TransferService) GetSyncLink(userID, id string) (*models.SyncLink, error) {
+	if s.syncLinks == nil {
+		return nil, fmt.Errorf("sync link not found: %s", id)
+	}
+	return s.syncLinks.Get(userID, id)
+}
+
+// DeleteSyncLink removes one of the user's sync links. The target playlist
+// is kept and simply no longer synced.
+func (s *TransferService) DeleteSyncLink(userID, id string) error {
+	if s.syncLinks == nil {
+		return fmt.Errorf("sync link not found: %s", id)
+	}
+	return s.syncLinks.Delete(userID, id)
+}
+
+// FlagAccountSyncLinks notes on each of the user's sync links that uses
+// the given provider account that the account was disconnected, so the
+// links show why they cannot sync until it is linked again. It returns how
+// many links were flagged.
+func (s *TransferService) FlagAccountSyncLinks(userID, providerName, externalUserID string) (int, error) {
+	if s.syncLinks == nil {
+		return 0, nil
+	}
+	links, err := s.syncLinks.List(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	flagged := 0
+	for _, link := range links {
+		usesSource := link.SourceProvider == providerName && link.SourceAccount == externalUserID
+		usesTarget := link.TargetProvider == providerName && link.TargetAccount == externalUserID
+		if !usesSource && !usesTarget {
+			continue
+		}
+		link.Disconnected = fmt.Sprintf("The %s account %s was disconnected; link it again to resume syncing.", providerName, externalUserID)
+		if err := s.syncLinks.Update(link); err != nil {
+			return flagged, err
+		}
+		flagged++
+	}
+	return flagged, nil
+}
+
+// RunSync applies the changes made to a link's source playlist since the
+// last sync to its target playlist: tracks added to the source are matched
+// and added, tracks removed from it are removed, and the target is
+// reordered if the order changed. Tracks matched before are not looked up
+// again. Two-way links also apply the target's changes to the source, see
+// mergeTwoWay. The link's synced state is only updated once every change
+// has been applied, so a failed sync is retried in full by the next one.
+func (s *TransferService) RunSync(ctx context.Context, userID, id string) (SyncResult, error) {
+	return s.runSync(ctx, userID, id, nil)
+}
+
+// ResolveSyncConflict syncs a two-way link, resolving one of the conflicts
+// flagged by the last sync in favour of the edit made on the side keep
+func (s *TransferService) ResolveSyncConflict(ctx context.Context, userID, id, conflictID, keep string) (SyncResult, error) {
+	if keep != models.SyncSideSource && keep != models.SyncSideTarget {
+		return SyncResult{}, fmt.Errorf("invalid side: %q", keep)
+	}
+	link, err := s.GetSyncLink(userID, id)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if !slices.ContainsFunc(link.Conflicts, func(c models.SyncConflict) bool { return c.ID == conflictID }) {
+		return SyncResult{}, fmt.Errorf("sync conflict not found: %s", conflictID)
+	}
+
+	return s.runSync(ctx, userID, id, map[string]string{conflictID: keep})
+}
+
+// runSync syncs a link once no other sync of it is running. resolutions
+// maps conflict IDs to the side whose edit wins, before the link's policy.
+func (s *TransferService) runSync(ctx context.Context, userID, id string, resolutions map[string]string) (SyncResult, error) {
+	s.mu.Lock()
+	if s.syncing[id] {
+		s.mu.Unlock()
+		return SyncResult{}, fmt.Errorf("sync link %s is already syncing", id)
+	}
+	s.syncing[id] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.syncing, id)
+		s.mu.Unlock()
+	}()
+
+	// Loaded under the guard, so the sync starts from the tracks the
+	// previous sync of the link stored
+	link, err := s.GetSyncLink(userID, id)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	if link.TwoWay {
+		return s.syncTwoWay(ctx, link, resolutions)
+	}
+	return s.syncOneWay(ctx, link)
+}
+
+// syncOneWay applies the changes made to the link's source to its target
+func (s *TransferService) syncOneWay(ctx context.Context, link *models.SyncLink) (SyncResult, error) {
+	req := syncRequest(link)
+	source, sourceAccount, err := s.authenticateSource(req)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	target, targetAccount, editor, err := s.authenticateEditor(req)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	playlist, err := source.ExportPlaylist(sourceAccount, link.SourcePlaylistID)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("export failed: %w", err)
+	}
+	synced, err := s.matchSynced(ctx, req, target, playlist.Tracks, link.Tracks)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return SyncResult{}, err
+	}
+
+	diff := diffSync(link.Tracks, synced)
+	if err := applySyncDiff(editor, targetAccount, link.TargetPlaylistID, diff); err != nil {
+		return SyncResult{}, err
+	}
+
+	link.Name = playlist.Name
+	link.Tracks = synced
+	link.Disconnected = ""
+	link.LastSyncedAt = time.Now()
+	if err := s.syncLinks.Update(link); err != nil {
+		return SyncResult{}, fmt.Errorf("saving sync link failed: %w", err)
+	}
+
+	return SyncResult{
+		Link:      link,
+		Added:     len(diff.add),
+		Removed:   len(diff.remove),
+		Reordered: diff.order != nil,
+		Unmatched: unmatched(synced),
+	}, nil
+}
+
+// syncTwoWay merges the changes made to the link's source and target since
+// the last sync and applies them to both. Conflicts are resolved by
+// resolutions, then by the link's policy; the rest are stored on the link.
+func (s *TransferService) syncTwoWay(ctx context.Context, link *models.SyncLink, resolutions map[string]string) (SyncResult, error) {
+	req := syncRequest(link)
+	reverse := TransferRequest{
+		UserID:         req.UserID,
+		SourceProvider: req.TargetProvider,
+		SourceAccount:  req.TargetAccount,
+		TargetProvider: req.SourceProvider,
+		TargetAccount:  req.SourceAccount,
+	}
+	source, sourceAccount, sourceEditor, err := s.authenticateEditor(reverse)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	target, targetAccount, targetEditor, err := s.authenticateEditor(req)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	sourcePlaylist, err := source.ExportPlaylist(sourceAccount, link.SourcePlaylistID)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("source export failed: %w", err)
+	}
+	targetPlaylist, err := target.ExportPlaylist(targetAccount, link.TargetPlaylistID)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("target export failed: %w", err)
+	}
+
+	sourceSide, err := s.syncSide(ctx, req, models.SyncSideSource, target, sourcePlaylist.Tracks, link.Tracks)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	targetSide, err := s.syncSide(ctx, reverse, models.SyncSideTarget, source, targetPlaylist.Tracks, link.Tracks)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return SyncResult{}, err
+	}
+
+	merge := mergeTwoWay(link.Tracks, sourceSide, targetSide, func(id string) string {
+		if side, ok := resolutions[id]; ok {
+			return side
+		}
+		if link.ConflictPolicy == models.ConflictManual {
+			return ""
+		}
+		return link.ConflictPolicy
+	})
+	describeConflicts(merge.conflicts, sourcePlaylist.Tracks, targetPlaylist.Tracks)
+
+	if err := applySyncDiff(sourceEditor, sourceAccount, link.SourcePlaylistID, merge.source); err != nil {
+		return SyncResult{}, fmt.Errorf("source: %w", err)
+	}
+	if err := applySyncDiff(targetEditor, targetAccount, link.TargetPlaylistID, merge.target); err != nil {
+		return SyncResult{}, fmt.Errorf("target: %w", err)
+	}
+
+	link.Name = sourcePlaylist.Name
+	link.Tracks = merge.tracks
+	link.Conflicts = merge.conflicts
+	link.Disconnected = ""
+	link.LastSyncedAt = time.Now()
+	if err := s.syncLinks.Update(link); err != nil {
+		return SyncResult{}, fmt.Errorf("saving sync link failed: %w", err)
+	}
+
+	return SyncResult{
+		Link:            link,
+		Added:           len(merge.target.add),
+		Removed:         len(merge.target.remove),
+		Reordered:       merge.target.order != nil,
+		SourceAdded:     len(merge.source.add),
+		SourceRemoved:   len(merge.source.remove),
+		SourceReordered: merge.source.order != nil,
+		Unmatched:       unmatched(merge.tracks),
+		Resolved:        merge.resolved,
+	}, nil
+}
+
+// syncSide reads one playlist of a two-way link and matches the tracks
+// added to it since the last sync on the other side. req transfers from
+// the playlist's side to other.
+func (s *TransferService) syncSide(ctx context.Context, req TransferRequest, name string, other providers.Provider, tracks []models.Track, base []models.SyncedTrack) (syncSide, error) {
+	side := syncSide{name: name, matches: make(map[string]string)}
+	seen := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			side.ids = append(side.ids, t.ID)
+		}
+	}
+
+	added := make(map[string]bool)
+	for _, id := range newTrackIDs(commonTracks(base), side) {
+		added[id] = true
+	}
+	var unsynced []models.Track
+	for _, t := range tracks {
+		if added[t.ID] {
+			unsynced = append(unsynced, t)
+			delete(added, t.ID)
+		}
+	}
+
+	results, err := s.matchTracks(ctx, req, other, unsynced, newTrackCache())
+	if err != nil {
+		return syncSide{}, fmt.Errorf("matching failed: %w", err)
+	}
+	for _, r := range results {
+		side.matches[r.Source.ID] = ""
+		if r.Target != nil {
+			side.matches[r.Source.ID] = r.Target.ID
+		}
+	}
+	return side, nil
+}
+
+// describeConflicts fills in the title and artist of the added track of
+// each conflict
+func describeConflicts(conflicts []models.SyncConflict, sourceTracks, targetTracks []models.Track) {
+	for i := range conflicts {
+		c := &conflicts[i]
+		tracks := sourceTracks
+		if c.Side == models.SyncSideTarget {
+			tracks = targetTracks
+		}
+		if j := slices.IndexFunc(tracks, func(t models.Track) bool { return t.ID == c.TrackID }); c.TrackID != "" && j >= 0 {
+			c.Title = tracks[j].Title
+			c.Artist = tracks[j].Artist
+		}
+	}
+}
+
+// applySyncDiff applies diff to a playlist: removals first, then additions,
+// then the new order
+func applySyncDiff(editor providers.PlaylistEditor, acct providers.Account, playlistID string, diff syncDiff) error {
+	if len(diff.remove) > 0 {
+		if err := editor.RemoveTracks(acct, playlistID, syncTracks(diff.remove)); err != nil {
+			return fmt.Errorf("removing tracks failed: %w", err)
+		}
+	}
+	if len(diff.add) > 0 {
+		if err := editor.AddTracks(acct, playlistID, syncTracks(diff.add)); err != nil {
+			return fmt.Errorf("adding tracks failed: %w", err)
+		}
+	}
+	if diff.order != nil {
+		if err := editor.ReorderTracks(acct, playlistID, syncTracks(diff.order)); err != nil {
+			return fmt.Errorf("reordering tracks failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncRequest returns the transfer request from a link's source to its
+// target
+func syncRequest(link *models.SyncLink) TransferRequest {
+	return TransferRequest{
+		UserID:         link.UserID,
+		SourceProvider: link.SourceProvider,
+		SourceAccount:  link.SourceAccount,
+		TargetProvider: link.TargetProvider,
+		TargetAccount:  link.TargetAccount,
+		PlaylistID:     link.SourcePlaylistID,
+	}
+}
+
+// authenticateEditor is authenticateTarget for targets that must be able to
+// edit playlists in place
+func (s *TransferService) authenticateEditor(req TransferRequest) (providers.Provider, providers.Account, providers.PlaylistEditor, error) {
+	target, targetAccount, err := s.authenticateTarget(req)
+	if err != nil {
+		return nil, providers.Account{}, nil, err
+	}
+	editor, ok := target.(providers.PlaylistEditor)
+	if !ok {
+		return nil, providers.Account{}, nil, fmt.Errorf("%s cannot edit playlists, so it cannot be synced to", target.Name())
+	}
+	return target, targetAccount, editor, nil
+}
+
+// matchSynced pairs each source track with its target track. Tracks that
+// were matched by the previous sync keep their target; the others are
+// matched like those of a transfer.
+func (s *TransferService) matchSynced(ctx context.Context, req TransferRequest, target providers.Provider, tracks []models.Track, previous []models.SyncedTrack) ([]models.SyncedTrack, error) {
+	cache := newTrackCache()
+	for _, t := range previous {
+		if t.TargetID != "" {
+			cache.put(t.SourceID, TrackResult{Target: &models.Track{ID: t.TargetID}, Status: MatchFound})
+		}
+	}
+
+	results, err := s.matchTracks(ctx, req, target, tracks, cache)
+	if err != nil {
+		return nil, fmt.Errorf("matching failed: %w", err)
+	}
+
+	synced := make([]models.SyncedTrack, len(results))
+	for i, r := range results {
+		synced[i].SourceID = r.Source.ID
+		if r.Target != nil {
+			synced[i].TargetID = r.Target.ID
+		}
+	}
+	return synced, nil
+}
+
+// diffSync compares the target tracks of two synced states. A playlist
+// holds each target track once, so repeats after the first are ignored.
+func diffSync(before, after []models.SyncedTrack) syncDiff {
+	return diffIDs(syncedTargetIDs(before), syncedTargetIDs(after))
+}
+
+// diffIDs compares two track lists without repeats
+func diffIDs(old, current []string) syncDiff {
+	var diff syncDiff
+	kept := make([]string, 0, len(old))
+	for _, id := range old {
+		if slices.Contains(current, id) {
+			kept = append(kept, id)
+		} else {
+			diff.remove = append(diff.remove, id)
+		}
+	}
+	for _, id := range current {
+		if !slices.Contains(old, id) {
+			diff.add = append(diff.add, id)
+		}
+	}
+
+	// Added tracks end up at the end of the playlist
+	if !slices.Equal(append(kept, diff.add...), current) {
+		diff.order = current
+	}
+	return diff
+}
+
+// syncedTargetIDs returns the target track IDs of tracks in order, without
+// repeats and unmatched tracks
+func syncedTargetIDs(tracks []models.SyncedTrack) []string {
+	var ids []string
+	seen := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		if t.TargetID == "" || seen[t.TargetID] {
+			continue
+		}
+		seen[t.TargetID] = true
+		ids = append(ids, t.TargetID)
+	}
+	return ids
+}
+
+// syncTracks returns tracks that carry only the IDs, for PlaylistEditor calls
+func syncTracks(ids []string) []models.Track {
+	tracks := make([]models.Track, len(ids))
+	for i, id := range ids {
+		tracks[i] = models.Track{ID: id}
+	}
+	return tracks
+}
+
+// unmatched counts the synced tracks without a match on the other side
+func unmatched(tracks []models.SyncedTrack) int {
+	n := 0
+	for _, t := range tracks {
+		if t.SourceID == "" || t.TargetID == "" {
+			n++
+		}
+	}
+	return n
+}
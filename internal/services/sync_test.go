@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
+)
+
+// syncSource exports one playlist whose tracks the test changes between syncs
+type syncSource struct {
+	*providers.MockProvider
+	tracks []models.Track
+}
+
+func (p *syncSource) Name() string { return "Source" }
+
+func (p *syncSource) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	return models.Playlist{ID: id, Name: "Road Trip", Tracks: p.tracks}, nil
+}
+
+func TestTransferService_RunSync(t *testing.T) {
+	var (
+		sunshine = models.Track{ID: "s-1", Title: "Sunshine Day", ISRC: "MOCK12345001"}
+		beach    = models.Track{ID: "s-2", Title: "Beach Walk", ISRC: "MOCK12345002"}
+		breeze   = models.Track{ID: "s-3", Title: "Summer Breeze", ISRC: "MOCK12345003"}
+		moon     = models.Track{ID: "s-6", Title: "Moonlight", ISRC: "MOCK12345006"}
+		unknown  = models.Track{ID: "s-9", Title: "Unknown Song"}
+	)
+
+	s := NewTransferService()
+	s.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+	source := &syncSource{MockProvider: providers.NewMockProvider(), tracks: []models.Track{sunshine, beach, breeze, unknown}}
+	target := &countingTarget{MockProvider: providers.NewMockProvider(), searches: make(map[string]int)}
+	s.RegisterProvider(source)
+	s.RegisterProvider(target)
+
+	created, err := s.CreateSyncLink(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Source", TargetProvider: "Counting", PlaylistID: "road",
+	}, SyncOptions{})
+	if err != nil {
+		t.Fatalf("CreateSyncLink() failed: %v", err)
+	}
+	link := created.Link
+	if created.Added != 3 || created.Unmatched != 1 || link.Name != "Road Trip" || len(link.Tracks) != 4 {
+		t.Fatalf("Unexpected result of the first sync: %+v", created)
+	}
+	assertTargetTracks(t, target, link.TargetPlaylistID, "track-1", "track-2", "track-3")
+
+	// Drop one track, add one and move the last to the front
+	source.tracks = []models.Track{breeze, sunshine, unknown, moon}
+	result, err := s.RunSync(context.Background(), "user123", link.ID)
+	if err != nil {
+		t.Fatalf("RunSync() failed: %v", err)
+	}
+	if result.Added != 1 || result.Removed != 1 || !result.Reordered || result.Unmatched != 1 {
+		t.Errorf("Unexpected sync result: %+v", result)
+	}
+	assertTargetTracks(t, target, link.TargetPlaylistID, "track-3", "track-1", "track-6")
+	if target.searches["s-1"] != 1 || target.searches["s-6"] != 1 || target.searches["s-9"] != 2 {
+		t.Errorf("Expected only new and unmatched tracks to be searched again, got %v", target.searches)
+	}
+
+	stored, _ := s.GetSyncLink("user123", link.ID)
+	if len(stored.Tracks) != 4 || stored.Tracks[3] != (models.SyncedTrack{SourceID: "s-6", TargetID: "track-6"}) {
+		t.Errorf("Expected the synced state to be stored, got %+v", stored.Tracks)
+	}
+
+	result, err = s.RunSync(context.Background(), "user123", link.ID)
+	if err != nil {
+		t.Fatalf("RunSync() failed: %v", err)
+	}
+	if result.Added != 0 || result.Removed != 0 || result.Reordered {
+		t.Errorf("Expected an unchanged source to change nothing, got %+v", result)
+	}
+
+	if _, err := s.RunSync(context.Background(), "other", link.ID); err == nil {
+		t.Error("RunSync() should not sync other users' links")
+	}
+}
+
+func TestTransferService_CreateSyncLink_NeedsEditor(t *testing.T) {
+	s := NewTransferService()
+	s.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+	s.RegisterProvider(&syncSource{MockProvider: providers.NewMockProvider()})
+	s.RegisterProvider(playlistsOnly{Provider: providers.NewMockProvider()})
+
+	_, err := s.CreateSyncLink(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Source", TargetProvider: "Mock Music", PlaylistID: "road",
+	}, SyncOptions{})
+	if err == nil || !strings.Contains(err.Error(), "cannot edit playlists") {
+		t.Errorf("Expected targets that cannot edit playlists to be rejected, got %v", err)
+	}
+}
+
+// flakyTarget is a sync target whose AddTracks fails while failAdd is set
+type flakyTarget struct {
+	*countingTarget
+	failAdd bool
+}
+
+func (p *flakyTarget) AddTracks(acct providers.Account, playlistID string, tracks []models.Track) error {
+	if p.failAdd {
+		return errors.New("rate limited")
+	}
+	return p.countingTarget.AddTracks(acct, playlistID, tracks)
+}
+
+func TestTransferService_CreateSyncLink_AddTracksFails(t *testing.T) {
+	s := NewTransferService()
+	s.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+	source := &syncSource{MockProvider: providers.NewMockProvider(), tracks: []models.Track{
+		{ID: "s-1", Title: "Sunshine Day", ISRC: "MOCK12345001"},
+		{ID: "s-2", Title: "Beach Walk", ISRC: "MOCK12345002"},
+	}}
+	target := &flakyTarget{
+		countingTarget: &countingTarget{MockProvider: providers.NewMockProvider(), searches: make(map[string]int)},
+		failAdd:        true,
+	}
+	s.RegisterProvider(source)
+	s.RegisterProvider(target)
+
+	_, err := s.CreateSyncLink(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Source", TargetProvider: "Counting", PlaylistID: "road",
+	}, SyncOptions{})
+	if err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Fatalf("Expected the AddTracks error, got %v", err)
+	}
+
+	// The created playlist is kept on a link, and syncing the link fills it
+	links, err := s.ListSyncLinks("user123")
+	if err != nil || len(links) != 1 {
+		t.Fatalf("Expected the link to the created playlist to be saved, got %v, %v", links, err)
+	}
+	if links[0].TargetPlaylistID == "" || len(links[0].Tracks) != 0 {
+		t.Fatalf("Expected a link with no synced tracks, got %+v", links[0])
+	}
+
+	target.failAdd = false
+	result, err := s.RunSync(context.Background(), "user123", links[0].ID)
+	if err != nil {
+		t.Fatalf("RunSync() failed: %v", err)
+	}
+	if result.Added != 2 {
+		t.Errorf("Expected the retry to add both tracks, got %+v", result)
+	}
+	assertTargetTracks(t, target.countingTarget, links[0].TargetPlaylistID, "track-1", "track-2")
+}
+
+// namedMock is a mock provider under another name, so that two of them can
+// be registered
+type namedMock struct {
+	*providers.MockProvider
+	name string
+}
+
+func (p *namedMock) Name() string { return p.name }
+
+func TestTransferService_RunSync_TwoWay(t *testing.T) {
+	ctx := context.Background()
+	acct := providers.Account{UserID: "user123"}
+	s := NewTransferService()
+	s.SetSyncLinkStore(storage.NewInMemorySyncLinkStore())
+	source := &namedMock{MockProvider: providers.NewMockProvider(), name: "Left"}
+	target := &namedMock{MockProvider: providers.NewMockProvider(), name: "Right"}
+	s.RegisterProvider(source)
+	s.RegisterProvider(target)
+
+	if _, err := s.CreateSyncLink(ctx, TransferRequest{UserID: "user123", SourceProvider: "Left", TargetProvider: "Right", PlaylistID: "mock-1"},
+		SyncOptions{TwoWay: true, ConflictPolicy: "newest"}); err == nil {
+		t.Error("CreateSyncLink() should reject unknown conflict policies")
+	}
+
+	// The source playlist holds track-1, track-2 and track-3
+	source.Authenticate(acct)
+	playlistID, _ := source.CreatePlaylist(acct, models.Playlist{Name: "Shared"})
+	source.AddTracks(acct, playlistID, []models.Track{
+		{ID: "track-1", Title: "Sunshine Day", Artist: "The Happy Band"},
+		{ID: "track-2", Title: "Beach Walk", Artist: "Ocean Sounds"},
+		{ID: "track-3", Title: "Summer Breeze", Artist: "Wind Chasers"},
+	})
+	created, err := s.CreateSyncLink(ctx, TransferRequest{
+		UserID: "user123", SourceProvider: "Left", TargetProvider: "Right", PlaylistID: playlistID,
+	}, SyncOptions{TwoWay: true})
+	if err != nil {
+		t.Fatalf("CreateSyncLink() failed: %v", err)
+	}
+	link := created.Link
+	if !link.TwoWay || link.ConflictPolicy != models.ConflictManual {
+		t.Fatalf("Expected a two-way link with the manual policy, got %+v", link)
+	}
+
+	// Add a track on each side and remove one on the target
+	source.AddTracks(acct, playlistID, []models.Track{{ID: "track-4", Title: "Power Up", Artist: "Energy Squad"}})
+	target.RemoveTracks(acct, link.TargetPlaylistID, []models.Track{{ID: "track-2"}})
+	target.AddTracks(acct, link.TargetPlaylistID, []models.Track{{ID: "track-5", Title: "Push Harder", Artist: "Fitness Beats"}})
+	result, err := s.RunSync(ctx, "user123", link.ID)
+	if err != nil {
+		t.Fatalf("RunSync() failed: %v", err)
+	}
+	if result.Added != 1 || !result.Reordered || result.SourceAdded != 1 || result.SourceRemoved != 1 || len(result.Link.Conflicts) != 0 {
+		t.Errorf("Unexpected sync result: %+v", result)
+	}
+	assertPlaylistTracks(t, source.MockProvider, playlistID, "track-1", "track-3", "track-4", "track-5")
+	assertPlaylistTracks(t, target.MockProvider, link.TargetPlaylistID, "track-1", "track-3", "track-4", "track-5")
+
+	// Replacing track-1 with another version on the source while the
+	// target removes it is a conflict, flagged until it is resolved
+	source.RemoveTracks(acct, playlistID, []models.Track{{ID: "track-1"}})
+	source.AddTracks(acct, playlistID, []models.Track{{ID: "alt-1", Title: "Sunshine Day", Artist: "The Happy Band"}})
+	target.RemoveTracks(acct, link.TargetPlaylistID, []models.Track{{ID: "track-1"}})
+	for range 2 {
+		result, err = s.RunSync(ctx, "user123", link.ID)
+		if err != nil {
+			t.Fatalf("RunSync() failed: %v", err)
+		}
+		want := models.SyncConflict{
+			ID: "readded:source:alt-1", Kind: models.ConflictReadded, Side: models.SyncSideSource,
+			TrackID: "alt-1", Title: "Sunshine Day", Artist: "The Happy Band",
+		}
+		if len(result.Link.Conflicts) != 1 || result.Link.Conflicts[0] != want {
+			t.Fatalf("Expected conflict %+v, got %+v", want, result.Link.Conflicts)
+		}
+		assertPlaylistTracks(t, source.MockProvider, playlistID, "track-3", "track-4", "track-5", "alt-1")
+		assertPlaylistTracks(t, target.MockProvider, link.TargetPlaylistID, "track-3", "track-4", "track-5")
+	}
+
+	if _, err := s.ResolveSyncConflict(ctx, "user123", link.ID, "order", models.SyncSideSource); err == nil {
+		t.Error("ResolveSyncConflict() should fail for conflicts the link does not have")
+	}
+	result, err = s.ResolveSyncConflict(ctx, "user123", link.ID, "readded:source:alt-1", models.SyncSideSource)
+	if err != nil {
+		t.Fatalf("ResolveSyncConflict() failed: %v", err)
+	}
+	if result.Resolved != 1 || len(result.Link.Conflicts) != 0 {
+		t.Errorf("Expected the conflict to be resolved, got %+v", result)
+	}
+	assertPlaylistTracks(t, target.MockProvider, link.TargetPlaylistID, "track-3", "track-4", "track-5", "track-1")
+}
+
+func TestDiffSync(t *testing.T) {
+	synced := func(targets ...string) []models.SyncedTrack {
+		tracks := make([]models.SyncedTrack, len(targets))
+		for i, id := range targets {
+			tracks[i] = models.SyncedTrack{SourceID: "s-" + id, TargetID: id}
+		}
+		return tracks
+	}
+
+	tests := []struct {
+		name          string
+		before, after []models.SyncedTrack
+		add, remove   []string
+		order         []string
+	}{
+		{name: "unchanged", before: synced("a", "b"), after: synced("a", "b")},
+		{name: "appended", before: synced("a"), after: synced("a", "b"), add: []string{"b"}},
+		{name: "removed", before: synced("a", "b", "c"), after: synced("a", "c"), remove: []string{"b"}},
+		{name: "inserted", before: synced("a", "b"), after: synced("c", "a", "b"), add: []string{"c"}, order: []string{"c", "a", "b"}},
+		{name: "moved", before: synced("a", "b", "c"), after: synced("c", "a", "b"), order: []string{"c", "a", "b"}},
+		{name: "unmatched and repeats", before: synced("a", ""), after: synced("a", "", "a", "b"), add: []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffSync(tt.before, tt.after)
+			if !slices.Equal(diff.add, tt.add) || !slices.Equal(diff.remove, tt.remove) || !slices.Equal(diff.order, tt.order) {
+				t.Errorf("diffSync() = %+v, want add %v, remove %v, order %v", diff, tt.add, tt.remove, tt.order)
+			}
+		})
+	}
+}
+
+// assertPlaylistTracks checks the track IDs of a mock provider's playlist
+func assertPlaylistTracks(t *testing.T, p *providers.MockProvider, playlistID string, want ...string) {
+	t.Helper()
+	playlist, err := p.ExportPlaylist(providers.Account{}, playlistID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+	ids := make([]string, len(playlist.Tracks))
+	for i, track := range playlist.Tracks {
+		ids[i] = track.ID
+	}
+	if !slices.Equal(ids, want) {
+		t.Errorf("Expected %s tracks %v, got %v", playlistID, want, ids)
+	}
+}
+
+// assertTargetTracks checks the track IDs of a playlist on the target
+func assertTargetTracks(t *testing.T, target *countingTarget, playlistID string, want ...string) {
+	t.Helper()
+	playlist, err := target.ExportPlaylist(providers.Account{}, playlistID)
+	if err != nil {
+		t.Fatalf("ExportPlaylist() failed: %v", err)
+	}
+	ids := make([]string, len(playlist.Tracks))
+	for i, track := range playlist.Tracks {
+		ids[i] = track.ID
+	}
+	if !slices.Equal(ids, want) {
+		t.Errorf("Expected target tracks %v, got %v", want, ids)
+	}
+}
@@ -1,21 +1,46 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"github.com/JanikSachs/PlayPort/internal/models"
 	"github.com/JanikSachs/PlayPort/internal/providers"
+	"github.com/JanikSachs/PlayPort/internal/storage"
 )
 
 // TransferService handles playlist transfers between providers
 type TransferService struct {
 	providers map[string]providers.Provider
+
+	overrides storage.MatchOverrideStore
+	syncLinks storage.SyncLinkStore
+
+	mu         sync.Mutex
+	jobs       map[string]*transferJob
+	startDelay time.Duration   // how long started transfers wait in the queue, zero outside tests
+	syncing    map[string]bool // IDs of the sync links being synced
+
+	batchWorkers        int                      // playlists of a batch transferred at once
+	providerConcurrency int                      // playlists using one provider at once
+	providerSlots       map[string]chan struct{} // semaphore per provider name
 }
 
 // NewTransferService creates a new transfer service
 func NewTransferService() *TransferService {
 	return &TransferService{
 		providers: make(map[string]providers.Provider),
+		jobs:      make(map[string]*transferJob),
+		syncing:   make(map[string]bool),
+
+		batchWorkers:        defaultBatchWorkers,
+		providerConcurrency: defaultProviderConcurrency,
+		providerSlots:       make(map[string]chan struct{}),
 	}
 }
 
@@ -24,6 +49,33 @@ func (s *TransferService) RegisterProvider(provider providers.Provider) {
 	s.providers[provider.Name()] = provider
 }
 
+// SetMatchOverrideStore makes transfers apply the users' match overrides
+func (s *TransferService) SetMatchOverrideStore(store storage.MatchOverrideStore) {
+	s.overrides = store
+}
+
+// ProviderSlug returns the URL-safe identifier of a provider name,
+// e.g. "youtubemusic" for "YouTube Music"
+func ProviderSlug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// GetProviderBySlug retrieves a provider by its slug
+func (s *TransferService) GetProviderBySlug(slug string) (providers.Provider, error) {
+	for name, provider := range s.providers {
+		if ProviderSlug(name) == slug {
+			return provider, nil
+		}
+	}
+	return nil, fmt.Errorf("provider not found: %s", slug)
+}
+
 // GetProvider retrieves a provider by name
 func (s *TransferService) GetProvider(name string) (providers.Provider, error) {
 	provider, ok := s.providers[name]
@@ -33,62 +85,420 @@ func (s *TransferService) GetProvider(name string) (providers.Provider, error) {
 	return provider, nil
 }
 
-// ListProviders returns all registered providers
+// ListProviders returns the names of all registered providers in alphabetical order
 func (s *TransferService) ListProviders() []string {
 	names := make([]string, 0, len(s.providers))
 	for name := range s.providers {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
-// TransferPlaylistForUser transfers a playlist from source to target provider for a specific user
-func (s *TransferService) TransferPlaylistForUser(sourceProvider, targetProvider, playlistID, userID string) error {
-	// Get source provider
-	source, err := s.GetProvider(sourceProvider)
+// AccountOption is a provider account that can be picked as a transfer source or target
+type AccountOption struct {
+	Provider string // Provider name, e.g. "Spotify"
+	Account  string // Provider account ID; empty for providers without linked accounts
+	Label    string // Human-readable name, e.g. "Spotify (alice)"
+}
+
+// ListAccounts returns every provider account the user can transfer from or to.
+// Providers with linked accounts contribute one option per connected account;
+// other providers contribute a single option.
+func (s *TransferService) ListAccounts(userID string) []AccountOption {
+	var options []AccountOption
+	for _, name := range s.ListProviders() {
+		lister, ok := s.providers[name].(providers.AccountLister)
+		if !ok {
+			options = append(options, AccountOption{Provider: name, Label: name})
+			continue
+		}
+
+		connections, err := lister.Accounts(userID)
+		if err != nil {
+			continue
+		}
+		for _, conn := range connections {
+			if !conn.Connected {
+				continue
+			}
+			label := conn.ExternalUserName
+			if label == "" {
+				label = conn.ExternalUserID
+			}
+			options = append(options, AccountOption{
+				Provider: name,
+				Account:  conn.ExternalUserID,
+				Label:    fmt.Sprintf("%s (%s)", name, label),
+			})
+		}
+	}
+	return options
+}
+
+// Transfer modes select what a transfer copies
+const (
+	ModePlaylist = "playlist" // a playlist and its tracks; the default
+	ModeAlbums   = "albums"   // the albums saved in the source account
+	ModeArtists  = "artists"  // the artists the source account follows
+	ModeLibrary  = "library"  // every playlist of the source account
+	ModeBatch    = "batch"    // the playlists in PlaylistIDs
+)
+
+// Names under which album and artist transfers are listed
+const (
+	savedAlbumsName     = "Saved albums"
+	followedArtistsName = "Followed artists"
+	libraryName         = "All playlists"
+	batchName           = "Selected playlists"
+)
+
+// ValidMode reports whether mode is a transfer mode. The empty mode is
+// ModePlaylist.
+func ValidMode(mode string) bool {
+	switch mode {
+	case "", ModePlaylist, ModeAlbums, ModeArtists, ModeLibrary, ModeBatch:
+		return true
+	}
+	return false
+}
+
+// TransferRequest describes a transfer between two provider accounts
+type TransferRequest struct {
+	UserID         string
+	SourceProvider string
+	SourceAccount  string // Provider account ID; empty selects the default account
+	TargetProvider string
+	TargetAccount  string   // Provider account ID; empty selects the default account
+	Mode           string   // what to transfer; empty transfers a playlist
+	PlaylistID     string   // playlist to transfer, in ModePlaylist only
+	PlaylistIDs    []string // playlists to transfer, in ModeBatch only
+}
+
+// TransferPlaylistForUser transfers a playlist from the source account to the target account of a user
+func (s *TransferService) TransferPlaylistForUser(req TransferRequest) error {
+	_, err := s.transfer(context.Background(), req, nil)
+	return err
+}
+
+// RunTransfer transfers a playlist in the foreground and reports what
+// happened to each track. Unlike StartTransfer it is not listed as a job.
+func (s *TransferService) RunTransfer(ctx context.Context, req TransferRequest) (TransferReport, error) {
+	job := &transferJob{req: req}
+	results, err := s.transfer(ctx, req, job)
+	return foregroundReport(job, results, err)
+}
+
+// ImportPlaylistForUser imports a playlist that did not come from a provider,
+// such as a file, into the target account of the request. Its tracks are
+// matched like those of a transfer; req.SourceProvider may be empty.
+func (s *TransferService) ImportPlaylistForUser(ctx context.Context, req TransferRequest, playlist models.Playlist) (TransferReport, error) {
+	job := &transferJob{req: req}
+	results, err := s.importPlaylist(ctx, req, playlist, job)
+	return foregroundReport(job, results, err)
+}
+
+// foregroundReport builds the report of a transfer that ran outside the job list
+func foregroundReport(job *transferJob, results []TrackResult, err error) (TransferReport, error) {
+	job.results = results
+	job.progress.Status = StatusCompleted
 	if err != nil {
-		return fmt.Errorf("source provider error: %w", err)
+		job.progress.Status = StatusFailed
+	}
+	return newReport(job), err
+}
+
+// transfer runs a transfer, reporting progress to job if it is not nil.
+// It stops between steps once ctx is cancelled. Album and artist transfers
+// record their results in job and return no track results, and so do
+// batches, which keep the results of each playlist apart.
+func (s *TransferService) transfer(ctx context.Context, req TransferRequest, job *transferJob) ([]TrackResult, error) {
+	if !ValidMode(req.Mode) {
+		return nil, fmt.Errorf("unknown transfer mode: %s", req.Mode)
+	}
+
+	if req.Mode == ModeBatch && len(req.PlaylistIDs) == 0 {
+		return nil, fmt.Errorf("no playlists selected")
 	}
 
-	// Get target provider
-	target, err := s.GetProvider(targetProvider)
+	source, sourceAccount, err := s.authenticateSource(req)
 	if err != nil {
-		return fmt.Errorf("target provider error: %w", err)
+		return nil, err
 	}
 
-	// Authenticate with source
-	if err := source.Authenticate(userID); err != nil {
-		return fmt.Errorf("source authentication failed: %w", err)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	// Authenticate with target
-	if err := target.Authenticate(userID); err != nil {
-		return fmt.Errorf("target authentication failed: %w", err)
+	switch req.Mode {
+	case ModeAlbums:
+		return nil, s.transferAlbums(ctx, req, source, sourceAccount, job)
+	case ModeArtists:
+		return nil, s.transferArtists(ctx, req, source, sourceAccount, job)
+	case ModeLibrary:
+		return nil, s.migrateLibrary(ctx, req, source, sourceAccount, job)
+	case ModeBatch:
+		return nil, s.transferSelected(ctx, req, source, sourceAccount, job)
 	}
 
+	s.updateJob(job, func(p *TransferProgress) {
+		p.Progress = 10
+		p.Message = "Exporting playlist..."
+	})
+
 	// Export playlist from source
-	playlist, err := source.ExportPlaylist(userID, playlistID)
+	playlist, err := source.ExportPlaylist(sourceAccount, req.PlaylistID)
 	if err != nil {
-		return fmt.Errorf("export failed: %w", err)
+		return nil, fmt.Errorf("export failed: %w", err)
+	}
+
+	return s.importPlaylist(ctx, req, playlist, job)
+}
+
+// importPlaylist matches the playlist's tracks on the target and imports it there
+func (s *TransferService) importPlaylist(ctx context.Context, req TransferRequest, playlist models.Playlist, job *transferJob) ([]TrackResult, error) {
+	target, targetAccount, err := s.authenticateTarget(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.updateJob(job, func(p *TransferProgress) {
+		p.PlaylistName = playlist.Name
+		p.Progress = 30
+		p.Message = "Matching tracks..."
+	})
+
+	// Match tracks on the target
+	results, err := s.matchTracks(ctx, req, target, playlist.Tracks, nil)
+	if err != nil {
+		return nil, fmt.Errorf("matching failed: %w", err)
+	}
+	if job != nil {
+		s.mu.Lock()
+		job.results = results
+		s.mu.Unlock()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+
+	s.updateJob(job, func(p *TransferProgress) {
+		p.Progress = 70
+		p.Message = "Transferring tracks..."
+		if _, ok := librarySaver(target, playlist); ok {
+			p.Message = "Saving tracks to library..."
+		}
+	})
+	return results, savePlaylist(target, targetAccount, playlist, results)
+}
+
+// librarySaver returns the target's LibrarySaver if playlist is a saved
+// library and the target has one to save it to
+func librarySaver(target providers.Provider, playlist models.Playlist) (providers.LibrarySaver, bool) {
+	saver, ok := target.(providers.LibrarySaver)
+	return saver, ok && playlist.ID == providers.LibraryPlaylistID
+}
+
+// savePlaylist writes the matched tracks of playlist to the target account.
+// Saved libraries go to the target's library where it has one; other
+// playlists are imported as new playlists.
+func savePlaylist(target providers.Provider, targetAccount providers.Account, playlist models.Playlist, results []TrackResult) error {
+	matched := make([]models.Track, 0, len(results))
+	for _, r := range results {
+		if r.Target != nil {
+			matched = append(matched, *r.Target)
+		}
 	}
+	playlist.Tracks = matched
+	playlist.TrackCount = len(matched)
 
-	// Import playlist to target
-	if err := target.ImportPlaylist(playlist); err != nil {
+	if saver, ok := librarySaver(target, playlist); ok {
+		if err := saver.SaveTracks(targetAccount, playlist.Tracks); err != nil {
+			return fmt.Errorf("saving to library failed: %w", err)
+		}
+		return nil
+	}
+
+	if err := target.ImportPlaylist(targetAccount, playlist); err != nil {
 		return fmt.Errorf("import failed: %w", err)
 	}
+	return nil
+}
+
+// transferAlbums matches the albums saved in the source account on the
+// target and saves them to the target account's library
+func (s *TransferService) transferAlbums(ctx context.Context, req TransferRequest, source providers.Provider, sourceAccount providers.Account, job *transferJob) error {
+	lister, ok := source.(providers.AlbumLister)
+	if !ok {
+		return fmt.Errorf("%s cannot list saved albums", source.Name())
+	}
+	target, targetAccount, err := s.authenticateTarget(req)
+	if err != nil {
+		return err
+	}
+	saver, ok := target.(providers.AlbumSaver)
+	if !ok {
+		return fmt.Errorf("%s cannot save albums", target.Name())
+	}
+
+	s.updateJob(job, func(p *TransferProgress) {
+		p.PlaylistName = savedAlbumsName
+		p.Progress = 10
+		p.Message = "Exporting saved albums..."
+	})
+	albums, err := lister.GetSavedAlbums(sourceAccount)
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.updateJob(job, func(p *TransferProgress) {
+		p.Progress = 30
+		p.Message = "Matching albums..."
+	})
+	results, err := matchAlbums(ctx, target, targetAccount, albums)
+	if err != nil {
+		return fmt.Errorf("matching failed: %w", err)
+	}
+	if job != nil {
+		s.mu.Lock()
+		job.albums = results
+		s.mu.Unlock()
+	}
+
+	matched := make([]models.Album, 0, len(results))
+	for _, r := range results {
+		if r.Target != nil {
+			matched = append(matched, *r.Target)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.updateJob(job, func(p *TransferProgress) {
+		p.Progress = 70
+		p.Message = "Saving albums..."
+	})
+	if err := saver.SaveAlbums(targetAccount, matched); err != nil {
+		return fmt.Errorf("saving albums failed: %w", err)
+	}
 	return nil
 }
 
+// transferArtists matches the artists the source account follows on the
+// target and follows them with the target account
+func (s *TransferService) transferArtists(ctx context.Context, req TransferRequest, source providers.Provider, sourceAccount providers.Account, job *transferJob) error {
+	lister, ok := source.(providers.ArtistLister)
+	if !ok {
+		return fmt.Errorf("%s cannot list followed artists", source.Name())
+	}
+	target, targetAccount, err := s.authenticateTarget(req)
+	if err != nil {
+		return err
+	}
+	follower, ok := target.(providers.ArtistFollower)
+	if !ok {
+		return fmt.Errorf("%s cannot follow artists", target.Name())
+	}
+
+	s.updateJob(job, func(p *TransferProgress) {
+		p.PlaylistName = followedArtistsName
+		p.Progress = 10
+		p.Message = "Exporting followed artists..."
+	})
+	artists, err := lister.GetFollowedArtists(sourceAccount)
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.updateJob(job, func(p *TransferProgress) {
+		p.Progress = 30
+		p.Message = "Matching artists..."
+	})
+	results, err := matchArtists(ctx, target, targetAccount, artists)
+	if err != nil {
+		return fmt.Errorf("matching failed: %w", err)
+	}
+	if job != nil {
+		s.mu.Lock()
+		job.artists = results
+		s.mu.Unlock()
+	}
+
+	matched := make([]models.Artist, 0, len(results))
+	for _, r := range results {
+		if r.Target != nil {
+			matched = append(matched, *r.Target)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.updateJob(job, func(p *TransferProgress) {
+		p.Progress = 70
+		p.Message = "Following artists..."
+	})
+	if err := follower.FollowArtists(targetAccount, matched); err != nil {
+		return fmt.Errorf("following artists failed: %w", err)
+	}
+	return nil
+}
+
+// authenticateSource returns the request's source provider and account once
+// the account is authenticated
+func (s *TransferService) authenticateSource(req TransferRequest) (providers.Provider, providers.Account, error) {
+	source, err := s.GetProvider(req.SourceProvider)
+	if err != nil {
+		return nil, providers.Account{}, fmt.Errorf("source provider error: %w", err)
+	}
+
+	sourceAccount := providers.Account{UserID: req.UserID, ExternalUserID: req.SourceAccount}
+	if err := source.Authenticate(sourceAccount); err != nil {
+		return nil, providers.Account{}, fmt.Errorf("source authentication failed: %w", err)
+	}
+	return source, sourceAccount, nil
+}
+
+// authenticateTarget returns the request's target provider and account once
+// the account is authenticated
+func (s *TransferService) authenticateTarget(req TransferRequest) (providers.Provider, providers.Account, error) {
+	target, err := s.GetProvider(req.TargetProvider)
+	if err != nil {
+		return nil, providers.Account{}, fmt.Errorf("target provider error: %w", err)
+	}
+
+	targetAccount := providers.Account{UserID: req.UserID, ExternalUserID: req.TargetAccount}
+	if err := target.Authenticate(targetAccount); err != nil {
+		return nil, providers.Account{}, fmt.Errorf("target authentication failed: %w", err)
+	}
+	return target, targetAccount, nil
+}
+
 // TransferProgress represents the status of a playlist transfer
 type TransferProgress struct {
-	PlaylistID    string    `json:"playlist_id"`
-	PlaylistName  string    `json:"playlist_name"`
-	SourceProvider string   `json:"source_provider"`
-	TargetProvider string   `json:"target_provider"`
-	Status        string    `json:"status"` // "pending", "in_progress", "completed", "failed"
-	Progress      int       `json:"progress"` // 0-100
-	Message       string    `json:"message"`
-	StartedAt     time.Time `json:"started_at"`
-	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	ID             string           `json:"id"`
+	PlaylistID     string           `json:"playlist_id"`
+	PlaylistName   string           `json:"playlist_name"`
+	Mode           string           `json:"mode,omitempty"` // "playlist", "albums", "artists", "library" or "batch"; empty for playlists
+	SourceProvider string           `json:"source_provider"`
+	SourceAccount  string           `json:"source_account,omitempty"`
+	TargetProvider string           `json:"target_provider"`
+	TargetAccount  string           `json:"target_account,omitempty"`
+	Status         string           `json:"status"`              // "pending", "in_progress", "completed", "failed", "cancelled"
+	Progress       int              `json:"progress"`            // 0-100
+	Playlists      []PlaylistResult `json:"playlists,omitempty"` // status of each playlist of a batch
+	Message        string           `json:"message"`
+	StartedAt      time.Time        `json:"started_at"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
 }
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+	"github.com/JanikSachs/PlayPort/internal/providers"
+)
+
+func TestTransferService_RunTransfer(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", PlaylistID: "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+
+	if report.Status != StatusCompleted || report.PlaylistName == "" {
+		t.Errorf("Expected a completed report with the playlist name, got %+v", report)
+	}
+	if len(report.Tracks) == 0 || report.Summary[MatchFound] != len(report.Tracks) {
+		t.Errorf("Expected every track to match, got summary %v", report.Summary)
+	}
+	if jobs := s.ListTransfers("user123"); len(jobs) != 0 {
+		t.Errorf("Foreground transfers should not be listed as jobs, got %d", len(jobs))
+	}
+
+	report, err = s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Mock Music", TargetProvider: "Mock Music", PlaylistID: "missing",
+	})
+	if err == nil || report.Status != StatusFailed {
+		t.Errorf("Expected a failed report for an unknown playlist, got %+v, %v", report, err)
+	}
+}
+
+func TestTransferService_ImportPlaylistForUser(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(providers.NewMockProvider())
+
+	playlist := models.Playlist{
+		Name: "From a file",
+		Tracks: []models.Track{
+			{ID: "row-1", Title: "Some Title", ISRC: "mock12345002"},
+			{ID: "row-2", Title: "Unknown Song", Artist: "Nobody"},
+		},
+	}
+
+	report, err := s.ImportPlaylistForUser(context.Background(), TransferRequest{
+		UserID: "user123", TargetProvider: "Mock Music",
+	}, playlist)
+	if err != nil {
+		t.Fatalf("ImportPlaylistForUser() failed: %v", err)
+	}
+
+	if report.PlaylistName != "From a file" {
+		t.Errorf("Expected playlist name 'From a file', got %q", report.PlaylistName)
+	}
+	if report.Summary[MatchFound] != 1 || report.Summary[MatchNotFound] != 1 {
+		t.Errorf("Expected one matched and one missing track, got %v", report.Summary)
+	}
+	if report.Tracks[0].Target == nil || report.Tracks[0].Target.ID != "track-2" {
+		t.Errorf("Expected row-1 to match track-2 by ISRC, got %+v", report.Tracks[0].Target)
+	}
+}
+
+// libraryProvider is a provider with a saved library that records the tracks saved to it
+type libraryProvider struct {
+	*providers.MockProvider
+	saved    []models.Track
+	imported []models.Playlist
+}
+
+func (p *libraryProvider) Name() string { return "Library" }
+
+func (p *libraryProvider) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	if id != providers.LibraryPlaylistID {
+		return p.MockProvider.ExportPlaylist(acct, id)
+	}
+	return models.Playlist{
+		ID:     providers.LibraryPlaylistID,
+		Name:   "Liked Songs",
+		Tracks: []models.Track{{ID: "liked-1", Title: "Beach Walk", ISRC: "MOCK12345002"}},
+	}, nil
+}
+
+func (p *libraryProvider) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	p.imported = append(p.imported, playlist)
+	return nil
+}
+
+func (p *libraryProvider) SaveTracks(acct providers.Account, tracks []models.Track) error {
+	p.saved = append(p.saved, tracks...)
+	return nil
+}
+
+func TestTransferService_RunTransfer_Library(t *testing.T) {
+	s := NewTransferService()
+	provider := &libraryProvider{MockProvider: providers.NewMockProvider()}
+	s.RegisterProvider(provider)
+	s.RegisterProvider(providers.NewMockProvider())
+
+	// The library goes to the target's library
+	_, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Library", TargetProvider: "Library", PlaylistID: providers.LibraryPlaylistID,
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+	if len(provider.imported) != 0 {
+		t.Errorf("Expected no playlist to be created, got %d", len(provider.imported))
+	}
+	if len(provider.saved) != 1 || provider.saved[0].ID != "track-2" {
+		t.Errorf("Expected the matched track to be saved, got %+v", provider.saved)
+	}
+
+	// Other playlists are imported as playlists
+	provider.saved = nil
+	_, err = s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Library", TargetProvider: "Library", PlaylistID: "mock-1",
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+	if len(provider.imported) != 1 || len(provider.saved) != 0 {
+		t.Errorf("Expected a created playlist and nothing saved, got %d playlists and %d saved tracks", len(provider.imported), len(provider.saved))
+	}
+
+	// Targets without a library get a playlist
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Library", TargetProvider: "Mock Music", PlaylistID: providers.LibraryPlaylistID,
+	})
+	if err != nil || report.PlaylistName != "Liked Songs" {
+		t.Errorf("Expected the library to be imported as playlist, got %+v, %v", report, err)
+	}
+}
+
+// collectionProvider is a source whose saved albums and followed artists
+// differ from the mock catalog
+type collectionProvider struct {
+	*providers.MockProvider
+}
+
+func (p *collectionProvider) Name() string { return "Collection" }
+
+func (p *collectionProvider) GetSavedAlbums(acct providers.Account) ([]models.Album, error) {
+	return []models.Album{
+		{ID: "c-album-1", Title: "Good Times (Remastered)", Artist: "The Happy Band", UPC: "000000000001"},
+		{ID: "c-album-2", Title: "Unknown Album", Artist: "Nobody"},
+	}, nil
+}
+
+func (p *collectionProvider) GetFollowedArtists(acct providers.Account) ([]models.Artist, error) {
+	return []models.Artist{{ID: "c-artist-1", Name: "Energy Squad"}, {ID: "c-artist-2", Name: "Nobody"}}, nil
+}
+
+// playlistsOnly hides every capability but the Provider interface
+type playlistsOnly struct {
+	providers.Provider
+}
+
+func TestTransferService_RunTransfer_Albums(t *testing.T) {
+	s := NewTransferService()
+	target := providers.NewMockProvider()
+	s.RegisterProvider(&collectionProvider{MockProvider: providers.NewMockProvider()})
+	s.RegisterProvider(target)
+
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Collection", TargetProvider: "Mock Music", Mode: ModeAlbums,
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+
+	if report.PlaylistName != "Saved albums" || len(report.Albums) != 2 || len(report.Tracks) != 0 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if report.Albums[0].Status != MatchFound || report.Albums[0].Target.ID != "album-1" {
+		t.Errorf("Expected the first album to match album-1 by UPC, got %+v", report.Albums[0])
+	}
+	if report.Summary[MatchFound] != 1 || report.Summary[MatchNotFound] != 1 {
+		t.Errorf("Expected one matched and one missing album, got %v", report.Summary)
+	}
+	albums, _ := target.GetSavedAlbums(providers.Account{})
+	if len(albums) != 2 {
+		t.Errorf("Expected the already saved album not to be added again, got %d albums", len(albums))
+	}
+}
+
+func TestTransferService_RunTransfer_Artists(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(&collectionProvider{MockProvider: providers.NewMockProvider()})
+	s.RegisterProvider(providers.NewMockProvider())
+
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Collection", TargetProvider: "Mock Music", Mode: ModeArtists,
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+
+	if report.PlaylistName != "Followed artists" || len(report.Artists) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if report.Artists[0].Target == nil || report.Artists[0].Target.ID != "artist-2" || report.Artists[1].Status != MatchNotFound {
+		t.Errorf("Unexpected artist results %+v", report.Artists)
+	}
+}
+
+func TestTransferService_RunTransfer_UnsupportedMode(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(&collectionProvider{MockProvider: providers.NewMockProvider()})
+	s.RegisterProvider(playlistsOnly{Provider: providers.NewMockProvider()})
+
+	tests := []struct {
+		name   string
+		req    TransferRequest
+		errMsg string
+	}{
+		{"source cannot list albums", TransferRequest{SourceProvider: "Mock Music", TargetProvider: "Collection", Mode: ModeAlbums}, "cannot list saved albums"},
+		{"target cannot follow artists", TransferRequest{SourceProvider: "Collection", TargetProvider: "Mock Music", Mode: ModeArtists}, "cannot follow artists"},
+		{"unknown mode", TransferRequest{SourceProvider: "Collection", TargetProvider: "Collection", Mode: "podcasts"}, "unknown transfer mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.UserID = "user123"
+			_, err := s.RunTransfer(context.Background(), tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected an error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+// batchSource lists two playlists that share a track and one that cannot
+// be exported, unless playlists is set
+type batchSource struct {
+	*providers.MockProvider
+	playlists []models.Playlist
+}
+
+func (p *batchSource) Name() string { return "Batch" }
+
+func (p *batchSource) GetPlaylists(acct providers.Account) ([]models.Playlist, error) {
+	if p.playlists != nil {
+		return p.playlists, nil
+	}
+	return []models.Playlist{{ID: "road", Name: "Road Trip"}, {ID: "beach", Name: "Beach"}, {ID: "gone", Name: "Deleted"}}, nil
+}
+
+func (p *batchSource) ExportPlaylist(acct providers.Account, id string) (models.Playlist, error) {
+	shared := models.Track{ID: "b-1", Title: "Beach Walk", ISRC: "MOCK12345002"}
+	switch id {
+	case "road":
+		return models.Playlist{ID: id, Name: "Road Trip", Tracks: []models.Track{shared, {ID: "b-2", Title: "Unknown Song"}}}, nil
+	case "beach":
+		return models.Playlist{ID: id, Name: "Beach", Tracks: []models.Track{shared, {ID: "b-3", Title: "Sunshine Day", ISRC: "MOCK12345001"}}}, nil
+	}
+	return models.Playlist{}, errors.New("playlist not found")
+}
+
+// countingTarget records the searches and imports it receives
+type countingTarget struct {
+	*providers.MockProvider
+	searches map[string]int
+	imported []models.Playlist
+}
+
+func (p *countingTarget) Name() string { return "Counting" }
+
+func (p *countingTarget) SearchTrack(acct providers.Account, t models.Track) ([]models.Track, error) {
+	p.searches[t.ID]++
+	return p.MockProvider.SearchTrack(acct, t)
+}
+
+func (p *countingTarget) ImportPlaylist(acct providers.Account, playlist models.Playlist) error {
+	p.imported = append(p.imported, playlist)
+	return nil
+}
+
+func TestTransferService_RunTransfer_LibraryMigration(t *testing.T) {
+	s := NewTransferService()
+	s.SetBatchLimits(1, 0) // one playlist at a time keeps the imports in order
+	target := &countingTarget{MockProvider: providers.NewMockProvider(), searches: make(map[string]int)}
+	s.RegisterProvider(&batchSource{MockProvider: providers.NewMockProvider()})
+	s.RegisterProvider(target)
+
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Batch", TargetProvider: "Counting", Mode: ModeLibrary,
+	})
+	if err != nil {
+		t.Fatalf("RunTransfer() failed: %v", err)
+	}
+
+	if report.PlaylistName != "All playlists" || len(report.Playlists) != 3 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if len(target.imported) != 2 || target.imported[1].Name != "Beach" {
+		t.Errorf("Expected both exported playlists to be imported, got %+v", target.imported)
+	}
+	if target.searches["b-1"] != 1 {
+		t.Errorf("Expected the shared track to be searched once, got %d searches", target.searches["b-1"])
+	}
+
+	road, deleted := report.Playlists[0], report.Playlists[2]
+	if road.Status != StatusCompleted || road.TrackCount != 2 || road.Transferred != 1 {
+		t.Errorf("Unexpected result for Road Trip: %+v", road)
+	}
+	if deleted.Status != StatusFailed || !strings.Contains(deleted.Error, "export failed") {
+		t.Errorf("Expected the deleted playlist to fail, got %+v", deleted)
+	}
+	if len(report.Tracks) != 4 || report.Summary[MatchFound] != 3 || report.Summary[MatchNotFound] != 1 {
+		t.Errorf("Expected the tracks of every playlist in the report, got %v", report.Summary)
+	}
+}
+
+func TestTransferService_RunTransfer_LibraryMigrationFails(t *testing.T) {
+	s := NewTransferService()
+	s.RegisterProvider(&batchSource{MockProvider: providers.NewMockProvider(), playlists: []models.Playlist{{ID: "gone", Name: "Deleted"}}})
+	s.RegisterProvider(providers.NewMockProvider())
+
+	report, err := s.RunTransfer(context.Background(), TransferRequest{
+		UserID: "user123", SourceProvider: "Batch", TargetProvider: "Mock Music", Mode: ModeLibrary,
+	})
+	if err == nil || report.Status != StatusFailed {
+		t.Errorf("Expected the migration to fail when no playlist could be transferred, got %+v, %v", report, err)
+	}
+	if len(report.Playlists) != 1 || report.Playlists[0].Status != StatusFailed {
+		t.Errorf("Expected the failed playlist in the report, got %+v", report.Playlists)
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// AuditStore defines the interface for the append-only audit log
+type AuditStore interface {
+	// Record appends an event to the log, setting its ID and CreatedAt
+	Record(event *models.AuditEvent) error
+
+	// List returns a user's most recent events, newest first.
+	// A limit of zero or less returns all events.
+	List(userID string, limit int) ([]*models.AuditEvent, error)
+}
+
+// InMemoryAuditStore is a thread-safe in-memory audit store
+type InMemoryAuditStore struct {
+	mu     sync.RWMutex
+	events []*models.AuditEvent // in insertion order
+}
+
+// NewInMemoryAuditStore creates a new in-memory audit store
+func NewInMemoryAuditStore() *InMemoryAuditStore {
+	return &InMemoryAuditStore{}
+}
+
+// Record appends an event to the log
+func (s *InMemoryAuditStore) Record(event *models.AuditEvent) error {
+	if err := prepareAuditEvent(event); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *event
+	s.events = append(s.events, &stored)
+	return nil
+}
+
+// List returns a user's most recent events, newest first
+func (s *InMemoryAuditStore) List(userID string, limit int) ([]*models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []*models.AuditEvent
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if s.events[i].UserID != userID {
+			continue
+		}
+		event := *s.events[i]
+		events = append(events, &event)
+		if limit > 0 && len(events) == limit {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+// prepareAuditEvent validates an event and assigns its ID and timestamp
+func prepareAuditEvent(event *models.AuditEvent) error {
+	if event == nil {
+		return fmt.Errorf("audit event cannot be nil")
+	}
+	if event.UserID == "" {
+		return fmt.Errorf("userID cannot be empty")
+	}
+	if event.Action == "" {
+		return fmt.Errorf("action cannot be empty")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate audit event ID: %w", err)
+	}
+	event.ID = hex.EncodeToString(b)
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	return nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func TestAuditStore_RecordAndList(t *testing.T) {
+	forEachAuditStore(t, func(t *testing.T, store AuditStore) {
+		for _, action := range []string{"first", "second", "third"} {
+			event := &models.AuditEvent{UserID: "user123", Action: action, Provider: "spotify"}
+			if err := store.Record(event); err != nil {
+				t.Fatalf("Record() failed: %v", err)
+			}
+			if event.ID == "" || event.CreatedAt.IsZero() {
+				t.Error("Record() should set ID and CreatedAt")
+			}
+		}
+		if err := store.Record(&models.AuditEvent{UserID: "other", Action: "elsewhere"}); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+
+		events, err := store.List("user123", 0)
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if len(events) != 3 {
+			t.Fatalf("Expected 3 events, got %d", len(events))
+		}
+		for i, want := range []string{"third", "second", "first"} {
+			if events[i].Action != want {
+				t.Errorf("events[%d].Action = %q, want %q", i, events[i].Action, want)
+			}
+		}
+		if events[0].Provider != "spotify" {
+			t.Errorf("Expected provider spotify, got %q", events[0].Provider)
+		}
+
+		limited, err := store.List("user123", 2)
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if len(limited) != 2 || limited[0].Action != "third" {
+			t.Errorf("List() with limit should return the 2 newest events, got %d", len(limited))
+		}
+	})
+}
+
+func TestAuditStore_Record_Validation(t *testing.T) {
+	forEachAuditStore(t, func(t *testing.T, store AuditStore) {
+		tests := []struct {
+			name  string
+			event *models.AuditEvent
+		}{
+			{name: "nil event", event: nil},
+			{name: "empty user", event: &models.AuditEvent{Action: "connection.disconnect"}},
+			{name: "empty action", event: &models.AuditEvent{UserID: "user123"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := store.Record(tt.event); err == nil {
+					t.Error("Record() should fail validation")
+				}
+			})
+		}
+	})
+}
+
+func forEachAuditStore(t *testing.T, fn func(t *testing.T, store AuditStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewInMemoryAuditStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, NewSQLAuditStore(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, NewSQLAuditStore(dbtest.NewPostgres(t)))
+	})
+}
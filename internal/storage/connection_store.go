@@ -3,35 +3,62 @@ package storage
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/JanikSachs/PlayPort/internal/models"
 )
 
+// ErrConnectionNotFound is wrapped by the errors returned for connections
+// that do not exist
+var ErrConnectionNotFound = errors.New("connection not found")
+
 // ConnectionStore defines the interface for storing provider connections
 type ConnectionStore interface {
 	// Save stores a connection
 	Save(conn *models.Connection) error
 
-	// Get retrieves a connection by provider and user ID
-	Get(provider, userID string) (*models.Connection, error)
+	// Get retrieves a connection by provider, user ID and the provider's account ID
+	Get(provider, userID, externalUserID string) (*models.Connection, error)
 
 	// Update updates an existing connection
 	Update(conn *models.Connection) error
 
 	// Delete removes a connection
-	Delete(provider, userID string) error
+	Delete(provider, userID, externalUserID string) error
 
 	// List returns all connections for a user
 	List(userID string) ([]*models.Connection, error)
+
+	// ListByProvider returns all of a user's connections to one provider, oldest first
+	ListByProvider(provider, userID string) ([]*models.Connection, error)
+}
+
+// FindConnection retrieves the connection to a specific provider account.
+// An empty externalUserID selects the user's oldest connection to the provider,
+// which is the only one for users who linked a single account.
+func FindConnection(store ConnectionStore, provider, userID, externalUserID string) (*models.Connection, error) {
+	if externalUserID != "" {
+		return store.Get(provider, userID, externalUserID)
+	}
+
+	connections, err := store.ListByProvider(provider, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(connections) == 0 {
+		return nil, fmt.Errorf("%w for provider %s and user %s", ErrConnectionNotFound, provider, userID)
+	}
+	return connections[0], nil
 }
 
 // InMemoryConnectionStore is a thread-safe in-memory connection store
 type InMemoryConnectionStore struct {
 	mu          sync.RWMutex
-	connections map[string]*models.Connection // key: "provider:userID"
+	connections map[string]*models.Connection // key: "provider:userID:externalUserID"
 }
 
 // NewInMemoryConnectionStore creates a new in-memory connection store
@@ -56,10 +83,14 @@ func (s *InMemoryConnectionStore) Save(conn *models.Connection) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := makeKey(conn.Provider, conn.UserID)
+	key := makeKey(conn.Provider, conn.UserID, conn.ExternalUserID)
 	
-	// Set timestamps
+	// Set timestamps. A reconnected account keeps its ID and creation time.
 	now := time.Now()
+	if existing, ok := s.connections[key]; ok {
+		conn.ID = existing.ID
+		conn.CreatedAt = existing.CreatedAt
+	}
 	if conn.ID == "" {
 		// Generate a unique ID using crypto/rand
 		randBytes := make([]byte, 16)
@@ -75,15 +106,15 @@ func (s *InMemoryConnectionStore) Save(conn *models.Connection) error {
 	return nil
 }
 
-// Get retrieves a connection by provider and user ID
-func (s *InMemoryConnectionStore) Get(provider, userID string) (*models.Connection, error) {
+// Get retrieves a connection by provider, user ID and the provider's account ID
+func (s *InMemoryConnectionStore) Get(provider, userID, externalUserID string) (*models.Connection, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	key := makeKey(provider, userID)
+	key := makeKey(provider, userID, externalUserID)
 	conn, exists := s.connections[key]
 	if !exists {
-		return nil, fmt.Errorf("connection not found for provider %s and user %s", provider, userID)
+		return nil, fmt.Errorf("%w for provider %s, user %s and account %s", ErrConnectionNotFound, provider, userID, externalUserID)
 	}
 
 	return conn, nil
@@ -104,9 +135,9 @@ func (s *InMemoryConnectionStore) Update(conn *models.Connection) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := makeKey(conn.Provider, conn.UserID)
+	key := makeKey(conn.Provider, conn.UserID, conn.ExternalUserID)
 	if _, exists := s.connections[key]; !exists {
-		return fmt.Errorf("connection not found for provider %s and user %s", conn.Provider, conn.UserID)
+		return fmt.Errorf("%w for provider %s, user %s and account %s", ErrConnectionNotFound, conn.Provider, conn.UserID, conn.ExternalUserID)
 	}
 
 	conn.UpdatedAt = time.Now()
@@ -115,13 +146,13 @@ func (s *InMemoryConnectionStore) Update(conn *models.Connection) error {
 }
 
 // Delete removes a connection
-func (s *InMemoryConnectionStore) Delete(provider, userID string) error {
+func (s *InMemoryConnectionStore) Delete(provider, userID, externalUserID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := makeKey(provider, userID)
+	key := makeKey(provider, userID, externalUserID)
 	if _, exists := s.connections[key]; !exists {
-		return fmt.Errorf("connection not found for provider %s and user %s", provider, userID)
+		return fmt.Errorf("%w for provider %s, user %s and account %s", ErrConnectionNotFound, provider, userID, externalUserID)
 	}
 
 	delete(s.connections, key)
@@ -143,7 +174,29 @@ func (s *InMemoryConnectionStore) List(userID string) ([]*models.Connection, err
 	return connections, nil
 }
 
+// ListByProvider returns all of a user's connections to one provider, oldest first
+func (s *InMemoryConnectionStore) ListByProvider(provider, userID string) ([]*models.Connection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var connections []*models.Connection
+	for _, conn := range s.connections {
+		if conn.Provider == provider && conn.UserID == userID {
+			connections = append(connections, conn)
+		}
+	}
+
+	sort.Slice(connections, func(i, j int) bool {
+		if connections[i].CreatedAt.Equal(connections[j].CreatedAt) {
+			return connections[i].ExternalUserID < connections[j].ExternalUserID
+		}
+		return connections[i].CreatedAt.Before(connections[j].CreatedAt)
+	})
+
+	return connections, nil
+}
+
 // makeKey creates a unique key for a connection
-func makeKey(provider, userID string) string {
-	return fmt.Sprintf("%s:%s", provider, userID)
+func makeKey(provider, userID, externalUserID string) string {
+	return fmt.Sprintf("%s:%s:%s", provider, userID, externalUserID)
 }
@@ -4,244 +4,411 @@ import (
 	"testing"
 	"time"
 
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
 	"github.com/JanikSachs/PlayPort/internal/models"
 )
 
 func TestConnectionStore_Save(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	conn := &models.Connection{
-		Provider:       "spotify",
-		UserID:         "user123",
-		AccessToken:    "access-token",
-		RefreshToken:   "refresh-token",
-		ExpiresAt:      time.Now().Add(1 * time.Hour),
-		Connected:      true,
-	}
-
-	err := store.Save(conn)
-	if err != nil {
-		t.Fatalf("Save() failed: %v", err)
-	}
-
-	if conn.ID == "" {
-		t.Error("Save() should set ID")
-	}
-
-	if conn.CreatedAt.IsZero() {
-		t.Error("Save() should set CreatedAt")
-	}
-
-	if conn.UpdatedAt.IsZero() {
-		t.Error("Save() should set UpdatedAt")
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		conn := &models.Connection{
+			Provider:     "spotify",
+			UserID:       "user123",
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			ExpiresAt:    time.Now().Add(1 * time.Hour),
+			Connected:    true,
+		}
+
+		err := store.Save(conn)
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		if conn.ID == "" {
+			t.Error("Save() should set ID")
+		}
+
+		if conn.CreatedAt.IsZero() {
+			t.Error("Save() should set CreatedAt")
+		}
+
+		if conn.UpdatedAt.IsZero() {
+			t.Error("Save() should set UpdatedAt")
+		}
+	})
 }
 
 func TestConnectionStore_Save_Validation(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	tests := []struct {
-		name string
-		conn *models.Connection
-	}{
-		{
-			name: "nil connection",
-			conn: nil,
-		},
-		{
-			name: "empty provider",
-			conn: &models.Connection{UserID: "user123"},
-		},
-		{
-			name: "empty userID",
-			conn: &models.Connection{Provider: "spotify"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := store.Save(tt.conn)
-			if err == nil {
-				t.Error("Save() should return error for invalid connection")
-			}
-		})
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		tests := []struct {
+			name string
+			conn *models.Connection
+		}{
+			{
+				name: "nil connection",
+				conn: nil,
+			},
+			{
+				name: "empty provider",
+				conn: &models.Connection{UserID: "user123"},
+			},
+			{
+				name: "empty userID",
+				conn: &models.Connection{Provider: "spotify"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := store.Save(tt.conn)
+				if err == nil {
+					t.Error("Save() should return error for invalid connection")
+				}
+			})
+		}
+	})
 }
 
 func TestConnectionStore_Get(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	conn := &models.Connection{
-		Provider:     "spotify",
-		UserID:       "user123",
-		AccessToken:  "access-token",
-		RefreshToken: "refresh-token",
-		Connected:    true,
-	}
-
-	err := store.Save(conn)
-	if err != nil {
-		t.Fatalf("Save() failed: %v", err)
-	}
-
-	retrieved, err := store.Get("spotify", "user123")
-	if err != nil {
-		t.Fatalf("Get() failed: %v", err)
-	}
-
-	if retrieved.Provider != conn.Provider {
-		t.Errorf("Expected provider %s, got %s", conn.Provider, retrieved.Provider)
-	}
-
-	if retrieved.UserID != conn.UserID {
-		t.Errorf("Expected userID %s, got %s", conn.UserID, retrieved.UserID)
-	}
-
-	if retrieved.AccessToken != conn.AccessToken {
-		t.Errorf("Expected access token %s, got %s", conn.AccessToken, retrieved.AccessToken)
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		conn := &models.Connection{
+			Provider:     "spotify",
+			UserID:       "user123",
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			Connected:    true,
+		}
+
+		err := store.Save(conn)
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		retrieved, err := store.Get("spotify", "user123", "")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+
+		if retrieved.Provider != conn.Provider {
+			t.Errorf("Expected provider %s, got %s", conn.Provider, retrieved.Provider)
+		}
+
+		if retrieved.UserID != conn.UserID {
+			t.Errorf("Expected userID %s, got %s", conn.UserID, retrieved.UserID)
+		}
+
+		if retrieved.AccessToken != conn.AccessToken {
+			t.Errorf("Expected access token %s, got %s", conn.AccessToken, retrieved.AccessToken)
+		}
+	})
 }
 
 func TestConnectionStore_Get_NotFound(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	_, err := store.Get("spotify", "nonexistent")
-	if err == nil {
-		t.Error("Get() should return error for non-existent connection")
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		_, err := store.Get("spotify", "nonexistent", "")
+		if err == nil {
+			t.Error("Get() should return error for non-existent connection")
+		}
+	})
 }
 
 func TestConnectionStore_Update(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	conn := &models.Connection{
-		Provider:     "spotify",
-		UserID:       "user123",
-		AccessToken:  "old-token",
-		RefreshToken: "refresh-token",
-		Connected:    true,
-	}
-
-	err := store.Save(conn)
-	if err != nil {
-		t.Fatalf("Save() failed: %v", err)
-	}
-
-	// Update the connection
-	conn.AccessToken = "new-token"
-	err = store.Update(conn)
-	if err != nil {
-		t.Fatalf("Update() failed: %v", err)
-	}
-
-	// Verify update
-	retrieved, err := store.Get("spotify", "user123")
-	if err != nil {
-		t.Fatalf("Get() failed: %v", err)
-	}
-
-	if retrieved.AccessToken != "new-token" {
-		t.Errorf("Expected access token 'new-token', got %s", retrieved.AccessToken)
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		conn := &models.Connection{
+			Provider:     "spotify",
+			UserID:       "user123",
+			AccessToken:  "old-token",
+			RefreshToken: "refresh-token",
+			Connected:    true,
+		}
+
+		err := store.Save(conn)
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		// Update the connection
+		conn.AccessToken = "new-token"
+		err = store.Update(conn)
+		if err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+
+		// Verify update
+		retrieved, err := store.Get("spotify", "user123", "")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+
+		if retrieved.AccessToken != "new-token" {
+			t.Errorf("Expected access token 'new-token', got %s", retrieved.AccessToken)
+		}
+	})
 }
 
 func TestConnectionStore_Update_NotFound(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	conn := &models.Connection{
-		Provider: "spotify",
-		UserID:   "nonexistent",
-	}
-
-	err := store.Update(conn)
-	if err == nil {
-		t.Error("Update() should return error for non-existent connection")
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		conn := &models.Connection{
+			Provider: "spotify",
+			UserID:   "nonexistent",
+		}
+
+		err := store.Update(conn)
+		if err == nil {
+			t.Error("Update() should return error for non-existent connection")
+		}
+	})
 }
 
 func TestConnectionStore_Delete(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	conn := &models.Connection{
-		Provider:    "spotify",
-		UserID:      "user123",
-		AccessToken: "access-token",
-		Connected:   true,
-	}
-
-	err := store.Save(conn)
-	if err != nil {
-		t.Fatalf("Save() failed: %v", err)
-	}
-
-	// Delete the connection
-	err = store.Delete("spotify", "user123")
-	if err != nil {
-		t.Fatalf("Delete() failed: %v", err)
-	}
-
-	// Verify deletion
-	_, err = store.Get("spotify", "user123")
-	if err == nil {
-		t.Error("Get() should return error after deletion")
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		conn := &models.Connection{
+			Provider:    "spotify",
+			UserID:      "user123",
+			AccessToken: "access-token",
+			Connected:   true,
+		}
+
+		err := store.Save(conn)
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		// Delete the connection
+		err = store.Delete("spotify", "user123", "")
+		if err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+
+		// Verify deletion
+		_, err = store.Get("spotify", "user123", "")
+		if err == nil {
+			t.Error("Get() should return error after deletion")
+		}
+	})
 }
 
 func TestConnectionStore_Delete_NotFound(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	err := store.Delete("spotify", "nonexistent")
-	if err == nil {
-		t.Error("Delete() should return error for non-existent connection")
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		err := store.Delete("spotify", "nonexistent", "")
+		if err == nil {
+			t.Error("Delete() should return error for non-existent connection")
+		}
+	})
 }
 
 func TestConnectionStore_List(t *testing.T) {
-	store := NewInMemoryConnectionStore()
-
-	// Add multiple connections for the same user
-	conn1 := &models.Connection{
-		Provider:    "spotify",
-		UserID:      "user123",
-		AccessToken: "token1",
-		Connected:   true,
-	}
-
-	conn2 := &models.Connection{
-		Provider:    "apple-music",
-		UserID:      "user123",
-		AccessToken: "token2",
-		Connected:   true,
-	}
-
-	conn3 := &models.Connection{
-		Provider:    "spotify",
-		UserID:      "user456",
-		AccessToken: "token3",
-		Connected:   true,
-	}
-
-	store.Save(conn1)
-	store.Save(conn2)
-	store.Save(conn3)
-
-	// List connections for user123
-	connections, err := store.List("user123")
-	if err != nil {
-		t.Fatalf("List() failed: %v", err)
-	}
-
-	if len(connections) != 2 {
-		t.Errorf("Expected 2 connections for user123, got %d", len(connections))
-	}
-
-	// List connections for user456
-	connections, err = store.List("user456")
-	if err != nil {
-		t.Fatalf("List() failed: %v", err)
-	}
-
-	if len(connections) != 1 {
-		t.Errorf("Expected 1 connection for user456, got %d", len(connections))
-	}
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		// Add multiple connections for the same user
+		conn1 := &models.Connection{
+			Provider:    "spotify",
+			UserID:      "user123",
+			AccessToken: "token1",
+			Connected:   true,
+		}
+
+		conn2 := &models.Connection{
+			Provider:    "apple-music",
+			UserID:      "user123",
+			AccessToken: "token2",
+			Connected:   true,
+		}
+
+		conn3 := &models.Connection{
+			Provider:    "spotify",
+			UserID:      "user456",
+			AccessToken: "token3",
+			Connected:   true,
+		}
+
+		store.Save(conn1)
+		store.Save(conn2)
+		store.Save(conn3)
+
+		// List connections for user123
+		connections, err := store.List("user123")
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+
+		if len(connections) != 2 {
+			t.Errorf("Expected 2 connections for user123, got %d", len(connections))
+		}
+
+		// List connections for user456
+		connections, err = store.List("user456")
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+
+		if len(connections) != 1 {
+			t.Errorf("Expected 1 connection for user456, got %d", len(connections))
+		}
+	})
+}
+
+func TestConnectionStore_MultipleAccountsPerProvider(t *testing.T) {
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		first := &models.Connection{
+			Provider:         "spotify",
+			UserID:           "user123",
+			ExternalUserID:   "alice",
+			ExternalUserName: "Alice",
+			AccessToken:      "token-alice",
+			Connected:        true,
+		}
+		second := &models.Connection{
+			Provider:         "spotify",
+			UserID:           "user123",
+			ExternalUserID:   "bob",
+			ExternalUserName: "Bob",
+			AccessToken:      "token-bob",
+			Connected:        true,
+		}
+
+		if err := store.Save(first); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if err := store.Save(second); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		connections, err := store.ListByProvider("spotify", "user123")
+		if err != nil {
+			t.Fatalf("ListByProvider() failed: %v", err)
+		}
+		if len(connections) != 2 {
+			t.Fatalf("Expected 2 Spotify connections, got %d", len(connections))
+		}
+
+		retrieved, err := store.Get("spotify", "user123", "bob")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if retrieved.AccessToken != "token-bob" {
+			t.Errorf("Expected access token 'token-bob', got %s", retrieved.AccessToken)
+		}
+
+		// Reconnecting the same account replaces it instead of adding a third
+		reconnect := &models.Connection{
+			Provider:       "spotify",
+			UserID:         "user123",
+			ExternalUserID: "alice",
+			AccessToken:    "token-alice-2",
+			Connected:      true,
+		}
+		if err := store.Save(reconnect); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		connections, err = store.ListByProvider("spotify", "user123")
+		if err != nil {
+			t.Fatalf("ListByProvider() failed: %v", err)
+		}
+		if len(connections) != 2 {
+			t.Errorf("Expected 2 Spotify connections after reconnect, got %d", len(connections))
+		}
+
+		// Deleting one account leaves the other
+		if err := store.Delete("spotify", "user123", "alice"); err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+		if _, err := store.Get("spotify", "user123", "bob"); err != nil {
+			t.Errorf("Get() should still find the other account: %v", err)
+		}
+	})
+}
+
+func TestConnectionStore_Save_Reconnect(t *testing.T) {
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		first := &models.Connection{Provider: "spotify", UserID: "user123", ExternalUserID: "alice", AccessToken: "token-1", Connected: true}
+		if err := store.Save(first); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		second := &models.Connection{Provider: "spotify", UserID: "user123", ExternalUserID: "bob", Connected: true}
+		if err := store.Save(second); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		// Reconnecting alice keeps her connection's ID and creation time, so
+		// she stays the default account
+		time.Sleep(10 * time.Millisecond)
+		reconnect := &models.Connection{Provider: "spotify", UserID: "user123", ExternalUserID: "alice", AccessToken: "token-2", Connected: true}
+		if err := store.Save(reconnect); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if reconnect.ID != first.ID || !reconnect.CreatedAt.Equal(first.CreatedAt) {
+			t.Errorf("Expected ID %s created at %v, got %s created at %v", first.ID, first.CreatedAt, reconnect.ID, reconnect.CreatedAt)
+		}
+
+		retrieved, err := store.Get("spotify", "user123", "alice")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if retrieved.ID != first.ID || !retrieved.CreatedAt.Equal(first.CreatedAt) || retrieved.AccessToken != "token-2" {
+			t.Errorf("Expected the stored connection to keep its ID and creation time with the new token, got %+v", retrieved)
+		}
+		if retrieved.UpdatedAt.Equal(first.CreatedAt) {
+			t.Error("Save() should move UpdatedAt on reconnect")
+		}
+
+		def, err := FindConnection(store, "spotify", "user123", "")
+		if err != nil {
+			t.Fatalf("FindConnection() failed: %v", err)
+		}
+		if def.ExternalUserID != "alice" {
+			t.Errorf("Expected alice to stay the default account, got %s", def.ExternalUserID)
+		}
+	})
+}
+
+func TestFindConnection(t *testing.T) {
+	forEachConnectionStore(t, func(t *testing.T, store ConnectionStore) {
+		if _, err := FindConnection(store, "spotify", "user123", ""); err == nil {
+			t.Error("FindConnection() should fail when the user has no connection")
+		}
+
+		first := &models.Connection{Provider: "spotify", UserID: "user123", ExternalUserID: "alice", Connected: true}
+		if err := store.Save(first); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		second := &models.Connection{Provider: "spotify", UserID: "user123", ExternalUserID: "bob", Connected: true}
+		if err := store.Save(second); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		// An empty account ID selects the oldest connection
+		conn, err := FindConnection(store, "spotify", "user123", "")
+		if err != nil {
+			t.Fatalf("FindConnection() failed: %v", err)
+		}
+		if conn.ExternalUserID != "alice" {
+			t.Errorf("Expected default account 'alice', got %s", conn.ExternalUserID)
+		}
+
+		conn, err = FindConnection(store, "spotify", "user123", "bob")
+		if err != nil {
+			t.Fatalf("FindConnection() failed: %v", err)
+		}
+		if conn.ExternalUserID != "bob" {
+			t.Errorf("Expected account 'bob', got %s", conn.ExternalUserID)
+		}
+
+		if _, err := FindConnection(store, "spotify", "user123", "carol"); err == nil {
+			t.Error("FindConnection() should fail for an unknown account")
+		}
+	})
+}
+
+// forEachConnectionStore runs fn as a subtest against a fresh instance of every ConnectionStore backend
+func forEachConnectionStore(t *testing.T, fn func(t *testing.T, store ConnectionStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewInMemoryConnectionStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, NewSQLConnectionStore(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, NewSQLConnectionStore(dbtest.NewPostgres(t)))
+	})
 }
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// MatchOverrideStore defines the interface for storing users' track match overrides
+type MatchOverrideStore interface {
+	// Save stores an override, replacing any existing override for the same
+	// source track and target provider
+	Save(o *models.MatchOverride) error
+
+	// Get retrieves the override for a source track on a target provider
+	Get(userID, sourceProvider, sourceTrackID, targetProvider string) (*models.MatchOverride, error)
+
+	// List returns all of a user's overrides, oldest first
+	List(userID string) ([]*models.MatchOverride, error)
+
+	// Delete removes one of the user's overrides by ID
+	Delete(userID, id string) error
+}
+
+// InMemoryMatchOverrideStore is a thread-safe in-memory match override store
+type InMemoryMatchOverrideStore struct {
+	mu        sync.RWMutex
+	overrides map[string]*models.MatchOverride // key: "userID:sourceProvider:sourceTrackID:targetProvider"
+}
+
+// NewInMemoryMatchOverrideStore creates a new in-memory match override store
+func NewInMemoryMatchOverrideStore() *InMemoryMatchOverrideStore {
+	return &InMemoryMatchOverrideStore{
+		overrides: make(map[string]*models.MatchOverride),
+	}
+}
+
+// Save stores an override
+func (s *InMemoryMatchOverrideStore) Save(o *models.MatchOverride) error {
+	if err := validateMatchOverride(o); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := overrideKey(o.UserID, o.SourceProvider, o.SourceTrackID, o.TargetProvider)
+	now := time.Now()
+	if existing, ok := s.overrides[key]; ok {
+		o.ID = existing.ID
+		o.CreatedAt = existing.CreatedAt
+	} else {
+		id, err := newOverrideID()
+		if err != nil {
+			return err
+		}
+		o.ID = id
+		o.CreatedAt = now
+	}
+	o.UpdatedAt = now
+
+	stored := *o
+	s.overrides[key] = &stored
+	return nil
+}
+
+// Get retrieves the override for a source track on a target provider
+func (s *InMemoryMatchOverrideStore) Get(userID, sourceProvider, sourceTrackID, targetProvider string) (*models.MatchOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.overrides[overrideKey(userID, sourceProvider, sourceTrackID, targetProvider)]
+	if !ok {
+		return nil, fmt.Errorf("match override not found for %s track %s on %s", sourceProvider, sourceTrackID, targetProvider)
+	}
+	found := *o
+	return &found, nil
+}
+
+// List returns all of a user's overrides, oldest first
+func (s *InMemoryMatchOverrideStore) List(userID string) ([]*models.MatchOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var overrides []*models.MatchOverride
+	for _, o := range s.overrides {
+		if o.UserID == userID {
+			found := *o
+			overrides = append(overrides, &found)
+		}
+	}
+
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].CreatedAt.Equal(overrides[j].CreatedAt) {
+			return overrides[i].ID < overrides[j].ID
+		}
+		return overrides[i].CreatedAt.Before(overrides[j].CreatedAt)
+	})
+
+	return overrides, nil
+}
+
+// Delete removes one of the user's overrides by ID
+func (s *InMemoryMatchOverrideStore) Delete(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, o := range s.overrides {
+		if o.UserID == userID && o.ID == id {
+			delete(s.overrides, key)
+			return nil
+		}
+	}
+	return fmt.Errorf("match override not found: %s", id)
+}
+
+// validateMatchOverride checks the fields that identify an override
+func validateMatchOverride(o *models.MatchOverride) error {
+	if o == nil {
+		return fmt.Errorf("match override cannot be nil")
+	}
+	if o.UserID == "" {
+		return fmt.Errorf("userID cannot be empty")
+	}
+	if o.SourceProvider == "" || o.SourceTrackID == "" {
+		return fmt.Errorf("source provider and track ID cannot be empty")
+	}
+	if o.TargetProvider == "" {
+		return fmt.Errorf("target provider cannot be empty")
+	}
+	return nil
+}
+
+// newOverrideID generates a random match override ID
+func newOverrideID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate match override ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// overrideKey creates a unique key for a match override
+func overrideKey(userID, sourceProvider, sourceTrackID, targetProvider string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", userID, sourceProvider, sourceTrackID, targetProvider)
+}
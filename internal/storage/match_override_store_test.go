@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func TestMatchOverrideStore_SaveAndGet(t *testing.T) {
+	forEachMatchOverrideStore(t, func(t *testing.T, store MatchOverrideStore) {
+		o := &models.MatchOverride{
+			UserID:         "user123",
+			SourceProvider: "spotify",
+			SourceTrackID:  "track-1",
+			TargetProvider: "youtubemusic",
+			TargetTrackID:  "video-1",
+		}
+		if err := store.Save(o); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if o.ID == "" || o.CreatedAt.IsZero() {
+			t.Error("Save() should set ID and CreatedAt")
+		}
+		firstID := o.ID
+
+		// Saving again for the same source track replaces the target
+		replacement := &models.MatchOverride{
+			UserID:         "user123",
+			SourceProvider: "spotify",
+			SourceTrackID:  "track-1",
+			TargetProvider: "youtubemusic",
+			TargetTrackID:  "video-2",
+		}
+		if err := store.Save(replacement); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if replacement.ID != firstID {
+			t.Errorf("Replacing an override should keep its ID, got %s want %s", replacement.ID, firstID)
+		}
+
+		got, err := store.Get("user123", "spotify", "track-1", "youtubemusic")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if got.TargetTrackID != "video-2" {
+			t.Errorf("Expected target video-2, got %s", got.TargetTrackID)
+		}
+
+		if _, err := store.Get("user123", "spotify", "track-1", "deezer"); err == nil {
+			t.Error("Get() should fail for other target providers")
+		}
+		if _, err := store.Get("other", "spotify", "track-1", "youtubemusic"); err == nil {
+			t.Error("Get() should not return other users' overrides")
+		}
+	})
+}
+
+func TestMatchOverrideStore_ListAndDelete(t *testing.T) {
+	forEachMatchOverrideStore(t, func(t *testing.T, store MatchOverrideStore) {
+		for _, trackID := range []string{"track-1", "track-2"} {
+			o := &models.MatchOverride{UserID: "user123", SourceProvider: "spotify", SourceTrackID: trackID, TargetProvider: "youtubemusic"}
+			if err := store.Save(o); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+		}
+
+		overrides, err := store.List("user123")
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if len(overrides) != 2 {
+			t.Fatalf("Expected 2 overrides, got %d", len(overrides))
+		}
+
+		if err := store.Delete("other", overrides[0].ID); err == nil {
+			t.Error("Delete() should not remove other users' overrides")
+		}
+		if err := store.Delete("user123", overrides[0].ID); err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+		if err := store.Delete("user123", overrides[0].ID); err == nil {
+			t.Error("Delete() should fail for unknown overrides")
+		}
+
+		remaining, _ := store.List("user123")
+		if len(remaining) != 1 || remaining[0].SourceTrackID != overrides[1].SourceTrackID {
+			t.Errorf("Expected only %s to remain, got %+v", overrides[1].SourceTrackID, remaining)
+		}
+	})
+}
+
+func TestMatchOverrideStore_Save_Validation(t *testing.T) {
+	forEachMatchOverrideStore(t, func(t *testing.T, store MatchOverrideStore) {
+		tests := []struct {
+			name     string
+			override *models.MatchOverride
+		}{
+			{name: "nil override", override: nil},
+			{name: "empty user", override: &models.MatchOverride{SourceProvider: "spotify", SourceTrackID: "t", TargetProvider: "youtubemusic"}},
+			{name: "empty source track", override: &models.MatchOverride{UserID: "u", SourceProvider: "spotify", TargetProvider: "youtubemusic"}},
+			{name: "empty target provider", override: &models.MatchOverride{UserID: "u", SourceProvider: "spotify", SourceTrackID: "t"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := store.Save(tt.override); err == nil {
+					t.Error("Save() should fail validation")
+				}
+			})
+		}
+	})
+}
+
+func forEachMatchOverrideStore(t *testing.T, fn func(t *testing.T, store MatchOverrideStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewInMemoryMatchOverrideStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, NewSQLMatchOverrideStore(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, NewSQLMatchOverrideStore(dbtest.NewPostgres(t)))
+	})
+}
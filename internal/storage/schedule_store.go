@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// maxScheduleRuns is how many of its most recent runs each schedule keeps
+const maxScheduleRuns = 50
+
+// ScheduleStore defines the interface for storing users' schedules and the
+// history of their runs
+type ScheduleStore interface {
+	// Save stores a new schedule, setting its ID and creation time
+	Save(schedule *models.Schedule) error
+
+	// Update replaces the settings of an existing schedule: its spec,
+	// whether it is enabled, its next run time and its sync link
+	Update(schedule *models.Schedule) error
+
+	// Get retrieves one of the user's schedules by ID
+	Get(userID, id string) (*models.Schedule, error)
+
+	// List returns all of a user's schedules, oldest first
+	List(userID string) ([]*models.Schedule, error)
+
+	// Delete removes one of the user's schedules by ID, with its runs
+	Delete(userID, id string) error
+
+	// Due returns the enabled schedules of all users whose next run time
+	// is set and not after now, earliest first
+	Due(now time.Time) ([]*models.Schedule, error)
+
+	// Claim moves the next run time of a schedule from schedule.NextRunAt
+	// to next, and reports whether it did. It does not if the schedule
+	// changed since it was read, such as when another instance claimed the
+	// same run first.
+	Claim(schedule *models.Schedule, next time.Time) (bool, error)
+
+	// RecordRun stores the outcome of a run, setting its ID, and makes it
+	// the last run of its schedule. Only the most recent runs of each
+	// schedule are kept.
+	RecordRun(run *models.ScheduleRun) error
+
+	// ListRuns returns the most recent runs of one of the user's
+	// schedules, newest first, or of all of them if scheduleID is empty.
+	// A limit of zero or less returns all runs.
+	ListRuns(userID, scheduleID string, limit int) ([]*models.ScheduleRun, error)
+}
+
+// InMemoryScheduleStore is a thread-safe in-memory schedule store
+type InMemoryScheduleStore struct {
+	mu        sync.RWMutex
+	schedules map[string]*models.Schedule // key: schedule ID
+	runs      []*models.ScheduleRun       // in insertion order
+}
+
+// NewInMemoryScheduleStore creates a new in-memory schedule store
+func NewInMemoryScheduleStore() *InMemoryScheduleStore {
+	return &InMemoryScheduleStore{
+		schedules: make(map[string]*models.Schedule),
+	}
+}
+
+// Save stores a new schedule
+func (s *InMemoryScheduleStore) Save(schedule *models.Schedule) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+
+	id, err := newScheduleID()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	schedule.ID = id
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *schedule
+	s.schedules[id] = &stored
+	return nil
+}
+
+// Update replaces the settings of an existing schedule
+func (s *InMemoryScheduleStore) Update(schedule *models.Schedule) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.schedules[schedule.ID]
+	if !ok || existing.UserID != schedule.UserID {
+		return fmt.Errorf("schedule not found: %s", schedule.ID)
+	}
+
+	schedule.UpdatedAt = time.Now()
+	stored := *existing
+	stored.Spec = schedule.Spec
+	stored.Enabled = schedule.Enabled
+	stored.NextRunAt = schedule.NextRunAt
+	stored.SyncLinkID = schedule.SyncLinkID
+	stored.UpdatedAt = schedule.UpdatedAt
+	s.schedules[schedule.ID] = &stored
+	return nil
+}
+
+// Get retrieves one of the user's schedules by ID
+func (s *InMemoryScheduleStore) Get(userID, id string) (*models.Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok || schedule.UserID != userID {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+	c := *schedule
+	return &c, nil
+}
+
+// List returns all of a user's schedules, oldest first
+func (s *InMemoryScheduleStore) List(userID string) ([]*models.Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var schedules []*models.Schedule
+	for _, schedule := range s.schedules {
+		if schedule.UserID == userID {
+			c := *schedule
+			schedules = append(schedules, &c)
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].ID < schedules[j].ID
+		}
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	return schedules, nil
+}
+
+// Delete removes one of the user's schedules by ID, with its runs
+func (s *InMemoryScheduleStore) Delete(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	if !ok || schedule.UserID != userID {
+		return fmt.Errorf("schedule not found: %s", id)
+	}
+	delete(s.schedules, id)
+
+	runs := s.runs[:0]
+	for _, run := range s.runs {
+		if run.ScheduleID != id {
+			runs = append(runs, run)
+		}
+	}
+	s.runs = runs
+	return nil
+}
+
+// Due returns the enabled schedules whose next run time has come
+func (s *InMemoryScheduleStore) Due(now time.Time) ([]*models.Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var schedules []*models.Schedule
+	for _, schedule := range s.schedules {
+		if schedule.Enabled && !schedule.NextRunAt.IsZero() && !schedule.NextRunAt.After(now) {
+			c := *schedule
+			schedules = append(schedules, &c)
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].NextRunAt.Equal(schedules[j].NextRunAt) {
+			return schedules[i].ID < schedules[j].ID
+		}
+		return schedules[i].NextRunAt.Before(schedules[j].NextRunAt)
+	})
+
+	return schedules, nil
+}
+
+// Claim moves the next run time of a schedule unless it changed
+func (s *InMemoryScheduleStore) Claim(schedule *models.Schedule, next time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.schedules[schedule.ID]
+	if !ok || existing.UserID != schedule.UserID || !existing.Enabled || !existing.NextRunAt.Equal(schedule.NextRunAt) {
+		return false, nil
+	}
+
+	stored := *existing
+	stored.NextRunAt = next
+	s.schedules[schedule.ID] = &stored
+	schedule.NextRunAt = next
+	return true, nil
+}
+
+// RecordRun stores the outcome of a run
+func (s *InMemoryScheduleStore) RecordRun(run *models.ScheduleRun) error {
+	if err := prepareScheduleRun(run); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[run.ScheduleID]
+	if !ok || schedule.UserID != run.UserID {
+		return fmt.Errorf("schedule not found: %s", run.ScheduleID)
+	}
+	stored := *schedule
+	stored.LastRunAt = run.StartedAt
+	stored.LastStatus = run.Status
+	s.schedules[run.ScheduleID] = &stored
+
+	c := *run
+	s.runs = append(s.runs, &c)
+
+	// Drop the oldest runs of the schedule beyond maxScheduleRuns
+	count := 0
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		if s.runs[i].ScheduleID != run.ScheduleID {
+			continue
+		}
+		if count++; count > maxScheduleRuns {
+			s.runs = append(s.runs[:i], s.runs[i+1:]...)
+		}
+	}
+	return nil
+}
+
+// ListRuns returns the most recent runs of the user's schedules, newest first
+func (s *InMemoryScheduleStore) ListRuns(userID, scheduleID string, limit int) ([]*models.ScheduleRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var runs []*models.ScheduleRun
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		run := s.runs[i]
+		if run.UserID != userID || (scheduleID != "" && run.ScheduleID != scheduleID) {
+			continue
+		}
+		c := *run
+		runs = append(runs, &c)
+		if limit > 0 && len(runs) == limit {
+			break
+		}
+	}
+
+	return runs, nil
+}
+
+// validateSchedule checks the fields that identify a schedule
+func validateSchedule(schedule *models.Schedule) error {
+	if schedule == nil {
+		return fmt.Errorf("schedule cannot be nil")
+	}
+	if schedule.UserID == "" {
+		return fmt.Errorf("userID cannot be empty")
+	}
+	if schedule.Kind != models.ScheduleTransfer && schedule.Kind != models.ScheduleSync {
+		return fmt.Errorf("invalid schedule kind: %q", schedule.Kind)
+	}
+	if schedule.SourceProvider == "" || schedule.SourcePlaylistID == "" {
+		return fmt.Errorf("source provider and playlist ID cannot be empty")
+	}
+	if schedule.TargetProvider == "" {
+		return fmt.Errorf("target provider cannot be empty")
+	}
+	if schedule.Spec == "" {
+		return fmt.Errorf("spec cannot be empty")
+	}
+	return nil
+}
+
+// prepareScheduleRun validates a run and assigns its ID
+func prepareScheduleRun(run *models.ScheduleRun) error {
+	if run == nil {
+		return fmt.Errorf("schedule run cannot be nil")
+	}
+	if run.UserID == "" || run.ScheduleID == "" {
+		return fmt.Errorf("userID and schedule ID cannot be empty")
+	}
+	if run.Status == "" {
+		return fmt.Errorf("status cannot be empty")
+	}
+
+	id, err := newScheduleID()
+	if err != nil {
+		return err
+	}
+	run.ID = id
+	return nil
+}
+
+// newScheduleID generates a random schedule or run ID
+func newScheduleID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate schedule ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
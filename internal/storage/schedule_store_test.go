@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// newTestSchedule returns a valid schedule of the user, due at next
+func newTestSchedule(userID string, next time.Time) *models.Schedule {
+	return &models.Schedule{
+		UserID:           userID,
+		Kind:             models.ScheduleTransfer,
+		SourceProvider:   "Spotify",
+		SourcePlaylistID: "source-1",
+		TargetProvider:   "Deezer",
+		Spec:             "@daily",
+		Enabled:          true,
+		NextRunAt:        next,
+	}
+}
+
+func TestScheduleStore_SaveGetAndUpdate(t *testing.T) {
+	forEachScheduleStore(t, func(t *testing.T, store ScheduleStore) {
+		next := time.Date(2024, time.May, 16, 0, 2, 0, 0, time.UTC)
+		schedule := newTestSchedule("user123", next)
+		schedule.Kind = models.ScheduleSync
+		schedule.TargetAccount = "dz-1"
+		if err := store.Save(schedule); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if schedule.ID == "" || schedule.CreatedAt.IsZero() {
+			t.Error("Save() should set ID and CreatedAt")
+		}
+
+		got, err := store.Get("user123", schedule.ID)
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if got.Kind != models.ScheduleSync || got.TargetAccount != "dz-1" || !got.Enabled || !got.NextRunAt.Equal(next) || !got.LastRunAt.IsZero() {
+			t.Errorf("Get() returned %+v, want %+v", got, schedule)
+		}
+		if _, err := store.Get("other", schedule.ID); err == nil {
+			t.Error("Get() should not return other users' schedules")
+		}
+
+		schedule.Spec = "@hourly"
+		schedule.Enabled = false
+		schedule.NextRunAt = time.Time{}
+		schedule.SyncLinkID = "link-1"
+		if err := store.Update(schedule); err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+		got, _ = store.Get("user123", schedule.ID)
+		if got.Spec != "@hourly" || got.Enabled || !got.NextRunAt.IsZero() || got.SyncLinkID != "link-1" {
+			t.Errorf("Update() stored %+v, want %+v", got, schedule)
+		}
+
+		stranger := *schedule
+		stranger.UserID = "other"
+		if err := store.Update(&stranger); err == nil {
+			t.Error("Update() should not change other users' schedules")
+		}
+	})
+}
+
+func TestScheduleStore_DueAndClaim(t *testing.T) {
+	forEachScheduleStore(t, func(t *testing.T, store ScheduleStore) {
+		now := time.Date(2024, time.May, 16, 12, 0, 0, 0, time.UTC)
+
+		late := newTestSchedule("alice", now.Add(-time.Hour))
+		due := newTestSchedule("bob", now.Add(-time.Minute+time.Millisecond))
+		later := newTestSchedule("alice", now.Add(time.Minute))
+		disabled := newTestSchedule("alice", now.Add(-time.Hour))
+		disabled.Enabled = false
+		never := newTestSchedule("alice", time.Time{})
+		for _, schedule := range []*models.Schedule{due, late, later, disabled, never} {
+			if err := store.Save(schedule); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+		}
+
+		schedules, err := store.Due(now)
+		if err != nil {
+			t.Fatalf("Due() failed: %v", err)
+		}
+		if len(schedules) != 2 || schedules[0].ID != late.ID || schedules[1].ID != due.ID {
+			t.Fatalf("Expected the late and due schedules, earliest first, got %+v", schedules)
+		}
+
+		claimed := *schedules[0]
+		ok, err := store.Claim(&claimed, now.Add(12*time.Hour))
+		if err != nil || !ok {
+			t.Fatalf("Claim() = %v, %v, want true", ok, err)
+		}
+		// A second instance holding the same due schedule loses the race
+		if ok, err := store.Claim(schedules[0], now.Add(13*time.Hour)); err != nil || ok {
+			t.Errorf("Second Claim() = %v, %v, want false", ok, err)
+		}
+		stale := *schedules[1]
+		stale.NextRunAt = stale.NextRunAt.Add(time.Second)
+		if ok, _ := store.Claim(&stale, now.Add(time.Hour)); ok {
+			t.Error("Claim() should fail once the next run time changed")
+		}
+
+		schedules, _ = store.Due(now)
+		if len(schedules) != 1 || schedules[0].ID != due.ID {
+			t.Errorf("Expected only the unclaimed schedule to be due, got %+v", schedules)
+		}
+		got, _ := store.Get("alice", late.ID)
+		if !got.NextRunAt.Equal(now.Add(12 * time.Hour)) {
+			t.Errorf("Expected the claimed run to move to %v, got %v", now.Add(12*time.Hour), got.NextRunAt)
+		}
+	})
+}
+
+func TestScheduleStore_Runs(t *testing.T) {
+	forEachScheduleStore(t, func(t *testing.T, store ScheduleStore) {
+		first := newTestSchedule("alice", time.Now())
+		second := newTestSchedule("alice", time.Now())
+		for _, schedule := range []*models.Schedule{first, second} {
+			if err := store.Save(schedule); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+		}
+
+		start := time.Date(2024, time.May, 16, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < maxScheduleRuns+2; i++ {
+			run := &models.ScheduleRun{
+				ScheduleID: first.ID, UserID: "alice", Status: "completed",
+				StartedAt: start.Add(time.Duration(i) * time.Hour), FinishedAt: start.Add(time.Duration(i)*time.Hour + time.Minute),
+			}
+			if i == maxScheduleRuns+1 {
+				run.Status, run.Message = "failed", "export failed"
+			}
+			if err := store.RecordRun(run); err != nil {
+				t.Fatalf("RecordRun() failed: %v", err)
+			}
+		}
+		if err := store.RecordRun(&models.ScheduleRun{ScheduleID: second.ID, UserID: "alice", Status: "completed", StartedAt: start, FinishedAt: start}); err != nil {
+			t.Fatalf("RecordRun() failed: %v", err)
+		}
+		if err := store.RecordRun(&models.ScheduleRun{ScheduleID: first.ID, UserID: "bob", Status: "completed", StartedAt: start, FinishedAt: start}); err == nil {
+			t.Error("RecordRun() should not record runs of other users' schedules")
+		}
+
+		runs, err := store.ListRuns("alice", first.ID, 0)
+		if err != nil {
+			t.Fatalf("ListRuns() failed: %v", err)
+		}
+		if len(runs) != maxScheduleRuns {
+			t.Fatalf("Expected the %d most recent runs, got %d", maxScheduleRuns, len(runs))
+		}
+		last := start.Add(time.Duration(maxScheduleRuns+1) * time.Hour)
+		if runs[0].Status != "failed" || runs[0].Message != "export failed" || !runs[0].StartedAt.Equal(last) {
+			t.Errorf("Expected the failed run first, got %+v", runs[0])
+		}
+		if oldest := runs[len(runs)-1]; !oldest.StartedAt.Equal(start.Add(2 * time.Hour)) {
+			t.Errorf("Expected the oldest two runs to be dropped, oldest kept is %v", oldest.StartedAt)
+		}
+
+		if runs, _ := store.ListRuns("alice", "", 3); len(runs) != 3 {
+			t.Errorf("Expected 3 runs with a limit, got %d", len(runs))
+		}
+		if runs, _ := store.ListRuns("bob", "", 0); len(runs) != 0 {
+			t.Errorf("Expected no runs for another user, got %d", len(runs))
+		}
+
+		got, _ := store.Get("alice", first.ID)
+		if got.LastStatus != "failed" || !got.LastRunAt.Equal(last) {
+			t.Errorf("Expected the last run to be recorded on the schedule, got %q at %v", got.LastStatus, got.LastRunAt)
+		}
+
+		if err := store.Delete("alice", first.ID); err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+		if runs, _ := store.ListRuns("alice", "", 0); len(runs) != 1 || runs[0].ScheduleID != second.ID {
+			t.Errorf("Delete() should remove the schedule's runs, got %+v", runs)
+		}
+		if schedules, _ := store.List("alice"); len(schedules) != 1 || schedules[0].ID != second.ID {
+			t.Errorf("Expected only the second schedule to be left, got %+v", schedules)
+		}
+		if err := store.Delete("alice", first.ID); err == nil {
+			t.Error("Delete() should fail for a deleted schedule")
+		}
+	})
+}
+
+func TestScheduleStore_Validation(t *testing.T) {
+	store := NewInMemoryScheduleStore()
+	tests := []struct {
+		name   string
+		modify func(s *models.Schedule)
+	}{
+		{"no user", func(s *models.Schedule) { s.UserID = "" }},
+		{"unknown kind", func(s *models.Schedule) { s.Kind = "backup" }},
+		{"no playlist", func(s *models.Schedule) { s.SourcePlaylistID = "" }},
+		{"no target", func(s *models.Schedule) { s.TargetProvider = "" }},
+		{"no spec", func(s *models.Schedule) { s.Spec = "" }},
+	}
+
+	for _, tt := range tests {
+		schedule := newTestSchedule("user123", time.Now())
+		tt.modify(schedule)
+		if err := store.Save(schedule); err == nil {
+			t.Errorf("%s: Save() should fail", tt.name)
+		}
+	}
+}
+
+func forEachScheduleStore(t *testing.T, fn func(t *testing.T, store ScheduleStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewInMemoryScheduleStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, NewSQLScheduleStore(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, NewSQLScheduleStore(dbtest.NewPostgres(t)))
+	})
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// SQLAuditStore is an AuditStore backed by a SQL database
+type SQLAuditStore struct {
+	db *database.DB
+}
+
+// NewSQLAuditStore creates a new SQL-backed audit store.
+// The database must already be migrated.
+func NewSQLAuditStore(db *database.DB) *SQLAuditStore {
+	return &SQLAuditStore{db: db}
+}
+
+// Record appends an event to the log
+func (s *SQLAuditStore) Record(event *models.AuditEvent) error {
+	if err := prepareAuditEvent(event); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`INSERT INTO audit_events (id, user_id, action, provider, external_user_id, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.ID, event.UserID, event.Action, event.Provider, event.ExternalUserID, event.Details, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// List returns a user's most recent events, newest first
+func (s *SQLAuditStore) List(userID string, limit int) ([]*models.AuditEvent, error) {
+	query := `SELECT id, user_id, action, provider, external_user_id, details, created_at
+		FROM audit_events WHERE user_id = ? ORDER BY seq DESC`
+	args := []interface{}{userID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		var event models.AuditEvent
+		if err := rows.Scan(&event.ID, &event.UserID, &event.Action, &event.Provider,
+			&event.ExternalUserID, &event.Details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		event.CreatedAt = event.CreatedAt.UTC()
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return events, nil
+}
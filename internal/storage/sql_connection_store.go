@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+const connectionColumns = `id, provider, user_id, external_user_id, external_user_name,
+	access_token, refresh_token, expires_at, scopes, connected, created_at, updated_at`
+
+// SQLConnectionStore is a ConnectionStore backed by a SQL database
+type SQLConnectionStore struct {
+	db *database.DB
+}
+
+// NewSQLConnectionStore creates a new SQL-backed connection store.
+// The database must already be migrated.
+func NewSQLConnectionStore(db *database.DB) *SQLConnectionStore {
+	return &SQLConnectionStore{db: db}
+}
+
+// Save stores a connection, replacing any existing connection to the same provider account
+func (s *SQLConnectionStore) Save(conn *models.Connection) error {
+	if err := validateConnection(conn); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if conn.ID == "" {
+		randBytes := make([]byte, 16)
+		if _, err := rand.Read(randBytes); err != nil {
+			return fmt.Errorf("failed to generate connection ID: %w", err)
+		}
+		conn.ID = fmt.Sprintf("%s-%s", makeKey(conn.Provider, conn.UserID, conn.ExternalUserID), hex.EncodeToString(randBytes))
+		conn.CreatedAt = now
+	}
+	conn.UpdatedAt = now
+
+	// A reconnected account keeps its ID and creation time, so references to
+	// it stay valid and it stays the default account if it was the oldest
+	err := s.db.QueryRow(`INSERT INTO connections (`+connectionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (provider, user_id, external_user_id) DO UPDATE SET
+			external_user_name = excluded.external_user_name,
+			access_token = excluded.access_token,
+			refresh_token = excluded.refresh_token,
+			expires_at = excluded.expires_at,
+			scopes = excluded.scopes,
+			connected = excluded.connected,
+			updated_at = excluded.updated_at
+		RETURNING id, created_at`,
+		conn.ID, conn.Provider, conn.UserID, conn.ExternalUserID, conn.ExternalUserName,
+		conn.AccessToken, conn.RefreshToken, conn.ExpiresAt.UTC(), strings.Join(conn.Scopes, " "),
+		conn.Connected, conn.CreatedAt.UTC(), conn.UpdatedAt,
+	).Scan(&conn.ID, &conn.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save connection: %w", err)
+	}
+	conn.CreatedAt = conn.CreatedAt.UTC()
+
+	return nil
+}
+
+// Get retrieves a connection by provider, user ID and the provider's account ID
+func (s *SQLConnectionStore) Get(provider, userID, externalUserID string) (*models.Connection, error) {
+	row := s.db.QueryRow("SELECT "+connectionColumns+" FROM connections WHERE provider = ? AND user_id = ? AND external_user_id = ?", provider, userID, externalUserID)
+	conn, err := scanConnection(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w for provider %s, user %s and account %s", ErrConnectionNotFound, provider, userID, externalUserID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	return conn, nil
+}
+
+// Update updates an existing connection
+func (s *SQLConnectionStore) Update(conn *models.Connection) error {
+	if err := validateConnection(conn); err != nil {
+		return err
+	}
+
+	updatedAt := time.Now().UTC().Truncate(time.Microsecond)
+	result, err := s.db.Exec(`UPDATE connections SET
+			external_user_name = ?, access_token = ?, refresh_token = ?,
+			expires_at = ?, scopes = ?, connected = ?, updated_at = ?
+		WHERE provider = ? AND user_id = ? AND external_user_id = ?`,
+		conn.ExternalUserName, conn.AccessToken, conn.RefreshToken,
+		conn.ExpiresAt.UTC(), strings.Join(conn.Scopes, " "), conn.Connected, updatedAt,
+		conn.Provider, conn.UserID, conn.ExternalUserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update connection: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to update connection: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("%w for provider %s, user %s and account %s", ErrConnectionNotFound, conn.Provider, conn.UserID, conn.ExternalUserID)
+	}
+
+	conn.UpdatedAt = updatedAt
+	return nil
+}
+
+// Delete removes a connection
+func (s *SQLConnectionStore) Delete(provider, userID, externalUserID string) error {
+	result, err := s.db.Exec("DELETE FROM connections WHERE provider = ? AND user_id = ? AND external_user_id = ?", provider, userID, externalUserID)
+	if err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("%w for provider %s, user %s and account %s", ErrConnectionNotFound, provider, userID, externalUserID)
+	}
+
+	return nil
+}
+
+// List returns all connections for a user
+func (s *SQLConnectionStore) List(userID string) ([]*models.Connection, error) {
+	return s.query("SELECT "+connectionColumns+" FROM connections WHERE user_id = ? ORDER BY provider, created_at, external_user_id", userID)
+}
+
+// ListByProvider returns all of a user's connections to one provider, oldest first
+func (s *SQLConnectionStore) ListByProvider(provider, userID string) ([]*models.Connection, error) {
+	return s.query("SELECT "+connectionColumns+" FROM connections WHERE provider = ? AND user_id = ? ORDER BY created_at, external_user_id", provider, userID)
+}
+
+// query runs a SELECT over connectionColumns and scans every row
+func (s *SQLConnectionStore) query(query string, args ...any) ([]*models.Connection, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+	defer rows.Close()
+
+	var connections []*models.Connection
+	for rows.Next() {
+		conn, err := scanConnection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list connections: %w", err)
+		}
+		connections = append(connections, conn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	return connections, nil
+}
+
+// validateConnection checks the fields required to key a connection
+func validateConnection(conn *models.Connection) error {
+	if conn == nil {
+		return fmt.Errorf("connection cannot be nil")
+	}
+	if conn.Provider == "" {
+		return fmt.Errorf("provider cannot be empty")
+	}
+	if conn.UserID == "" {
+		return fmt.Errorf("userID cannot be empty")
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanConnection reads a connection selected with connectionColumns
+func scanConnection(row rowScanner) (*models.Connection, error) {
+	var conn models.Connection
+	var scopes string
+	err := row.Scan(
+		&conn.ID, &conn.Provider, &conn.UserID, &conn.ExternalUserID, &conn.ExternalUserName,
+		&conn.AccessToken, &conn.RefreshToken, &conn.ExpiresAt, &scopes, &conn.Connected,
+		&conn.CreatedAt, &conn.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Scopes = strings.Fields(scopes)
+	conn.ExpiresAt = conn.ExpiresAt.UTC()
+	conn.CreatedAt = conn.CreatedAt.UTC()
+	conn.UpdatedAt = conn.UpdatedAt.UTC()
+	return &conn, nil
+}
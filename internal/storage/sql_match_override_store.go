@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+const matchOverrideColumns = `id, user_id, source_provider, source_track_id, target_provider,
+	target_track_id, created_at, updated_at`
+
+// SQLMatchOverrideStore is a MatchOverrideStore backed by a SQL database
+type SQLMatchOverrideStore struct {
+	db *database.DB
+}
+
+// NewSQLMatchOverrideStore creates a new SQL-backed match override store.
+// The database must already be migrated.
+func NewSQLMatchOverrideStore(db *database.DB) *SQLMatchOverrideStore {
+	return &SQLMatchOverrideStore{db: db}
+}
+
+// Save stores an override, keeping the ID and creation time of the one it replaces
+func (s *SQLMatchOverrideStore) Save(o *models.MatchOverride) error {
+	if err := validateMatchOverride(o); err != nil {
+		return err
+	}
+
+	id, err := newOverrideID()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	row := s.db.QueryRow(`INSERT INTO match_overrides (`+matchOverrideColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, source_provider, source_track_id, target_provider) DO UPDATE SET
+			target_track_id = excluded.target_track_id,
+			updated_at = excluded.updated_at
+		RETURNING id, created_at`,
+		id, o.UserID, o.SourceProvider, o.SourceTrackID, o.TargetProvider, o.TargetTrackID, now, now,
+	)
+	if err := row.Scan(&o.ID, &o.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save match override: %w", err)
+	}
+	o.CreatedAt = o.CreatedAt.UTC()
+	o.UpdatedAt = now
+
+	return nil
+}
+
+// Get retrieves the override for a source track on a target provider
+func (s *SQLMatchOverrideStore) Get(userID, sourceProvider, sourceTrackID, targetProvider string) (*models.MatchOverride, error) {
+	row := s.db.QueryRow("SELECT "+matchOverrideColumns+` FROM match_overrides
+		WHERE user_id = ? AND source_provider = ? AND source_track_id = ? AND target_provider = ?`,
+		userID, sourceProvider, sourceTrackID, targetProvider)
+	o, err := scanMatchOverride(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("match override not found for %s track %s on %s", sourceProvider, sourceTrackID, targetProvider)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match override: %w", err)
+	}
+	return o, nil
+}
+
+// List returns all of a user's overrides, oldest first
+func (s *SQLMatchOverrideStore) List(userID string) ([]*models.MatchOverride, error) {
+	rows, err := s.db.Query("SELECT "+matchOverrideColumns+" FROM match_overrides WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list match overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []*models.MatchOverride
+	for rows.Next() {
+		o, err := scanMatchOverride(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan match override: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list match overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// Delete removes one of the user's overrides by ID
+func (s *SQLMatchOverrideStore) Delete(userID, id string) error {
+	result, err := s.db.Exec("DELETE FROM match_overrides WHERE user_id = ? AND id = ?", userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete match override: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete match override: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("match override not found: %s", id)
+	}
+	return nil
+}
+
+// scanMatchOverride scans a match override row
+func scanMatchOverride(row rowScanner) (*models.MatchOverride, error) {
+	var o models.MatchOverride
+	if err := row.Scan(&o.ID, &o.UserID, &o.SourceProvider, &o.SourceTrackID, &o.TargetProvider,
+		&o.TargetTrackID, &o.CreatedAt, &o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	o.CreatedAt = o.CreatedAt.UTC()
+	o.UpdatedAt = o.UpdatedAt.UTC()
+	return &o, nil
+}
@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+const scheduleColumns = `id, user_id, kind, source_provider, source_account, source_playlist_id,
+	target_provider, target_account, sync_link_id, spec, enabled, next_run_at,
+	last_run_at, last_status, created_at, updated_at`
+
+const scheduleRunColumns = "id, schedule_id, user_id, status, message, started_at, finished_at"
+
+// SQLScheduleStore is a ScheduleStore backed by a SQL database
+type SQLScheduleStore struct {
+	db *database.DB
+}
+
+// NewSQLScheduleStore creates a new SQL-backed schedule store.
+// The database must already be migrated.
+func NewSQLScheduleStore(db *database.DB) *SQLScheduleStore {
+	return &SQLScheduleStore{db: db}
+}
+
+// Save stores a new schedule
+func (s *SQLScheduleStore) Save(schedule *models.Schedule) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+
+	id, err := newScheduleID()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	schedule.NextRunAt = truncateTime(schedule.NextRunAt)
+	schedule.LastRunAt = truncateTime(schedule.LastRunAt)
+
+	_, err = s.db.Exec(`INSERT INTO schedules (`+scheduleColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, schedule.UserID, schedule.Kind, schedule.SourceProvider, schedule.SourceAccount, schedule.SourcePlaylistID,
+		schedule.TargetProvider, schedule.TargetAccount, schedule.SyncLinkID, schedule.Spec, schedule.Enabled, nullableTime(schedule.NextRunAt),
+		nullableTime(schedule.LastRunAt), schedule.LastStatus, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+
+	schedule.ID = id
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	return nil
+}
+
+// Update replaces the settings of an existing schedule
+func (s *SQLScheduleStore) Update(schedule *models.Schedule) error {
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	nextRunAt := truncateTime(schedule.NextRunAt)
+
+	result, err := s.db.Exec(`UPDATE schedules SET spec = ?, enabled = ?, next_run_at = ?, sync_link_id = ?, updated_at = ?
+		WHERE user_id = ? AND id = ?`,
+		schedule.Spec, schedule.Enabled, nullableTime(nextRunAt), schedule.SyncLinkID, now, schedule.UserID, schedule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to update schedule: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("schedule not found: %s", schedule.ID)
+	}
+
+	schedule.NextRunAt = nextRunAt
+	schedule.UpdatedAt = now
+	return nil
+}
+
+// Get retrieves one of the user's schedules by ID
+func (s *SQLScheduleStore) Get(userID, id string) (*models.Schedule, error) {
+	row := s.db.QueryRow("SELECT "+scheduleColumns+" FROM schedules WHERE user_id = ? AND id = ?", userID, id)
+	schedule, err := scanSchedule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("schedule not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// List returns all of a user's schedules, oldest first
+func (s *SQLScheduleStore) List(userID string) ([]*models.Schedule, error) {
+	return s.query("SELECT "+scheduleColumns+" FROM schedules WHERE user_id = ? ORDER BY created_at, id", userID)
+}
+
+// Delete removes one of the user's schedules by ID, with its runs
+func (s *SQLScheduleStore) Delete(userID, id string) error {
+	return s.db.WithTx(func(tx *database.Tx) error {
+		result, err := tx.Exec("DELETE FROM schedules WHERE user_id = ? AND id = ?", userID, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete schedule: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to delete schedule: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("schedule not found: %s", id)
+		}
+
+		if _, err := tx.Exec("DELETE FROM schedule_runs WHERE schedule_id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete schedule runs: %w", err)
+		}
+		return nil
+	})
+}
+
+// Due returns the enabled schedules whose next run time has come
+func (s *SQLScheduleStore) Due(now time.Time) ([]*models.Schedule, error) {
+	return s.query(`SELECT `+scheduleColumns+` FROM schedules
+		WHERE enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?
+		ORDER BY next_run_at, id`, true, now.UTC())
+}
+
+// Claim moves the next run time of a schedule unless it changed
+func (s *SQLScheduleStore) Claim(schedule *models.Schedule, next time.Time) (bool, error) {
+	next = truncateTime(next)
+	result, err := s.db.Exec(`UPDATE schedules SET next_run_at = ?
+		WHERE user_id = ? AND id = ? AND enabled = ? AND next_run_at = ?`,
+		nullableTime(next), schedule.UserID, schedule.ID, true, schedule.NextRunAt.UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	schedule.NextRunAt = next
+	return true, nil
+}
+
+// RecordRun stores the outcome of a run
+func (s *SQLScheduleStore) RecordRun(run *models.ScheduleRun) error {
+	if err := prepareScheduleRun(run); err != nil {
+		return err
+	}
+	run.StartedAt = truncateTime(run.StartedAt)
+	run.FinishedAt = truncateTime(run.FinishedAt)
+
+	return s.db.WithTx(func(tx *database.Tx) error {
+		result, err := tx.Exec("UPDATE schedules SET last_run_at = ?, last_status = ? WHERE user_id = ? AND id = ?",
+			run.StartedAt, run.Status, run.UserID, run.ScheduleID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record schedule run: %w", err)
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to record schedule run: %w", err)
+		} else if n == 0 {
+			return fmt.Errorf("schedule not found: %s", run.ScheduleID)
+		}
+
+		_, err = tx.Exec("INSERT INTO schedule_runs ("+scheduleRunColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+			run.ID, run.ScheduleID, run.UserID, run.Status, run.Message, run.StartedAt, run.FinishedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record schedule run: %w", err)
+		}
+
+		_, err = tx.Exec(`DELETE FROM schedule_runs WHERE schedule_id = ? AND id NOT IN (
+			SELECT id FROM schedule_runs WHERE schedule_id = ? ORDER BY started_at DESC, id DESC LIMIT ?)`,
+			run.ScheduleID, run.ScheduleID, maxScheduleRuns,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to prune schedule runs: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListRuns returns the most recent runs of the user's schedules, newest first
+func (s *SQLScheduleStore) ListRuns(userID, scheduleID string, limit int) ([]*models.ScheduleRun, error) {
+	query := "SELECT " + scheduleRunColumns + " FROM schedule_runs WHERE user_id = ?"
+	args := []any{userID}
+	if scheduleID != "" {
+		query += " AND schedule_id = ?"
+		args = append(args, scheduleID)
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*models.ScheduleRun
+	for rows.Next() {
+		var run models.ScheduleRun
+		if err := rows.Scan(&run.ID, &run.ScheduleID, &run.UserID, &run.Status, &run.Message, &run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule run: %w", err)
+		}
+		run.StartedAt = run.StartedAt.UTC()
+		run.FinishedAt = run.FinishedAt.UTC()
+		runs = append(runs, &run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list schedule runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// query returns the schedules selected by a query for scheduleColumns
+func (s *SQLScheduleStore) query(query string, args ...any) ([]*models.Schedule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*models.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// scanSchedule reads a schedule selected with scheduleColumns
+func scanSchedule(row rowScanner) (*models.Schedule, error) {
+	var schedule models.Schedule
+	var nextRunAt, lastRunAt sql.NullTime
+	err := row.Scan(
+		&schedule.ID, &schedule.UserID, &schedule.Kind, &schedule.SourceProvider, &schedule.SourceAccount, &schedule.SourcePlaylistID,
+		&schedule.TargetProvider, &schedule.TargetAccount, &schedule.SyncLinkID, &schedule.Spec, &schedule.Enabled, &nextRunAt,
+		&lastRunAt, &schedule.LastStatus, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if nextRunAt.Valid {
+		schedule.NextRunAt = nextRunAt.Time.UTC()
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = lastRunAt.Time.UTC()
+	}
+	schedule.CreatedAt = schedule.CreatedAt.UTC()
+	schedule.UpdatedAt = schedule.UpdatedAt.UTC()
+	return &schedule, nil
+}
+
+// truncateTime converts t to UTC at the precision the database keeps. The
+// zero time stays zero.
+func truncateTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// nullableTime stores the zero time as NULL
+func nullableTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+const syncLinkColumns = `id, user_id, source_provider, source_account, source_playlist_id,
+	target_provider, target_account, target_playlist_id, two_way, conflict_policy,
+	name, tracks, conflicts, disconnected, last_synced_at, created_at, updated_at`
+
+// SQLSyncLinkStore is a SyncLinkStore backed by a SQL database
+type SQLSyncLinkStore struct {
+	db *database.DB
+}
+
+// NewSQLSyncLinkStore creates a new SQL-backed sync link store.
+// The database must already be migrated.
+func NewSQLSyncLinkStore(db *database.DB) *SQLSyncLinkStore {
+	return &SQLSyncLinkStore{db: db}
+}
+
+// Save stores a new link
+func (s *SQLSyncLinkStore) Save(link *models.SyncLink) error {
+	if err := validateSyncLink(link); err != nil {
+		return err
+	}
+
+	id, err := newSyncLinkID()
+	if err != nil {
+		return err
+	}
+	tracks, conflicts, err := encodeSyncState(link)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	link.LastSyncedAt = link.LastSyncedAt.UTC().Truncate(time.Microsecond)
+
+	_, err = s.db.Exec(`INSERT INTO sync_links (`+syncLinkColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, link.UserID, link.SourceProvider, link.SourceAccount, link.SourcePlaylistID,
+		link.TargetProvider, link.TargetAccount, link.TargetPlaylistID, link.TwoWay, link.ConflictPolicy,
+		link.Name, tracks, conflicts, link.Disconnected, link.LastSyncedAt, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sync link: %w", err)
+	}
+
+	link.ID = id
+	link.CreatedAt = now
+	link.UpdatedAt = now
+	return nil
+}
+
+// Update replaces the synced state of an existing link
+func (s *SQLSyncLinkStore) Update(link *models.SyncLink) error {
+	if err := validateSyncLink(link); err != nil {
+		return err
+	}
+
+	tracks, conflicts, err := encodeSyncState(link)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	lastSyncedAt := link.LastSyncedAt.UTC().Truncate(time.Microsecond)
+
+	result, err := s.db.Exec(`UPDATE sync_links SET name = ?, tracks = ?, conflicts = ?, disconnected = ?, last_synced_at = ?, updated_at = ?
+		WHERE user_id = ? AND id = ?`,
+		link.Name, tracks, conflicts, link.Disconnected, lastSyncedAt, now, link.UserID, link.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update sync link: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to update sync link: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("sync link not found: %s", link.ID)
+	}
+
+	link.LastSyncedAt = lastSyncedAt
+	link.UpdatedAt = now
+	return nil
+}
+
+// Get retrieves one of the user's links by ID
+func (s *SQLSyncLinkStore) Get(userID, id string) (*models.SyncLink, error) {
+	row := s.db.QueryRow("SELECT "+syncLinkColumns+" FROM sync_links WHERE user_id = ? AND id = ?", userID, id)
+	link, err := scanSyncLink(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("sync link not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync link: %w", err)
+	}
+	return link, nil
+}
+
+// List returns all of a user's links, oldest first
+func (s *SQLSyncLinkStore) List(userID string) ([]*models.SyncLink, error) {
+	rows, err := s.db.Query("SELECT "+syncLinkColumns+" FROM sync_links WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*models.SyncLink
+	for rows.Next() {
+		link, err := scanSyncLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sync link: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sync links: %w", err)
+	}
+
+	return links, nil
+}
+
+// Delete removes one of the user's links by ID
+func (s *SQLSyncLinkStore) Delete(userID, id string) error {
+	result, err := s.db.Exec("DELETE FROM sync_links WHERE user_id = ? AND id = ?", userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete sync link: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete sync link: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("sync link not found: %s", id)
+	}
+	return nil
+}
+
+// encodeSyncState encodes a link's tracks and conflicts for the tracks and
+// conflicts columns
+func encodeSyncState(link *models.SyncLink) (string, string, error) {
+	tracks := link.Tracks
+	if tracks == nil {
+		tracks = []models.SyncedTrack{}
+	}
+	encodedTracks, err := json.Marshal(tracks)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode synced tracks: %w", err)
+	}
+
+	conflicts := link.Conflicts
+	if conflicts == nil {
+		conflicts = []models.SyncConflict{}
+	}
+	encodedConflicts, err := json.Marshal(conflicts)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode sync conflicts: %w", err)
+	}
+	return string(encodedTracks), string(encodedConflicts), nil
+}
+
+// scanSyncLink reads a link selected with syncLinkColumns
+func scanSyncLink(row rowScanner) (*models.SyncLink, error) {
+	var link models.SyncLink
+	var tracks, conflicts string
+	err := row.Scan(
+		&link.ID, &link.UserID, &link.SourceProvider, &link.SourceAccount, &link.SourcePlaylistID,
+		&link.TargetProvider, &link.TargetAccount, &link.TargetPlaylistID, &link.TwoWay, &link.ConflictPolicy,
+		&link.Name, &tracks, &conflicts, &link.Disconnected, &link.LastSyncedAt, &link.CreatedAt, &link.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(tracks), &link.Tracks); err != nil {
+		return nil, fmt.Errorf("failed to decode synced tracks: %w", err)
+	}
+	if err := json.Unmarshal([]byte(conflicts), &link.Conflicts); err != nil {
+		return nil, fmt.Errorf("failed to decode sync conflicts: %w", err)
+	}
+	if len(link.Conflicts) == 0 {
+		link.Conflicts = nil
+	}
+	link.LastSyncedAt = link.LastSyncedAt.UTC()
+	link.CreatedAt = link.CreatedAt.UTC()
+	link.UpdatedAt = link.UpdatedAt.UTC()
+	return &link, nil
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// SQLUserStore is a UserStore backed by a SQL database
+type SQLUserStore struct {
+	db *database.DB
+}
+
+// NewSQLUserStore creates a new SQL-backed user store.
+// The database must already be migrated.
+func NewSQLUserStore(db *database.DB) *SQLUserStore {
+	return &SQLUserStore{db: db}
+}
+
+// Create creates a new user with a random UUID-style ID
+func (s *SQLUserStore) Create() (*models.User, error) {
+	return s.insert("", "")
+}
+
+// Get retrieves a user by ID
+func (s *SQLUserStore) Get(id string) (*models.User, error) {
+	row := s.db.QueryRow("SELECT id, username, password_hash, created_at FROM users WHERE id = ?", id)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user not found: %s", id)
+	}
+	return user, err
+}
+
+// CreateWithCredentials creates a new user with a username and password hash
+func (s *SQLUserStore) CreateWithCredentials(username, passwordHash string) (*models.User, error) {
+	return s.insert(username, passwordHash)
+}
+
+// GetByUsername retrieves a user by username
+func (s *SQLUserStore) GetByUsername(username string) (*models.User, error) {
+	row := s.db.QueryRow("SELECT id, username, password_hash, created_at FROM users WHERE username = ?", username)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user not found: %s", username)
+	}
+	return user, err
+}
+
+// insert stores a new user row. An empty username is stored as NULL so
+// that any number of anonymous users can coexist with the UNIQUE constraint.
+// The uniqueness check and insert happen in one statement, so two instances
+// registering the same username at once cannot both succeed.
+func (s *SQLUserStore) insert(username, passwordHash string) (*models.User, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate user ID: %w", err)
+	}
+
+	user := &models.User{
+		ID:           hex.EncodeToString(b),
+		Username:     username,
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	result, err := s.db.Exec(
+		"INSERT INTO users (id, username, password_hash, created_at) VALUES (?, ?, ?, ?) ON CONFLICT (username) DO NOTHING",
+		user.ID, sql.NullString{String: username, Valid: username != ""}, passwordHash, user.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	} else if n == 0 {
+		return nil, fmt.Errorf("username already taken: %s", username)
+	}
+
+	return user, nil
+}
+
+// scanUser reads a user from a single-row query result
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var username sql.NullString
+	if err := row.Scan(&user.ID, &username, &user.PasswordHash, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	user.Username = username.String
+	user.CreatedAt = user.CreatedAt.UTC()
+	return &user, nil
+}
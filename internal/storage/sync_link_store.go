@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+// SyncLinkStore defines the interface for storing users' playlist sync links
+type SyncLinkStore interface {
+	// Save stores a new link, setting its ID and creation time
+	Save(link *models.SyncLink) error
+
+	// Update replaces the synced state of an existing link: its name,
+	// tracks, conflicts, disconnected note and last sync time
+	Update(link *models.SyncLink) error
+
+	// Get retrieves one of the user's links by ID
+	Get(userID, id string) (*models.SyncLink, error)
+
+	// List returns all of a user's links, oldest first
+	List(userID string) ([]*models.SyncLink, error)
+
+	// Delete removes one of the user's links by ID
+	Delete(userID, id string) error
+}
+
+// InMemorySyncLinkStore is a thread-safe in-memory sync link store
+type InMemorySyncLinkStore struct {
+	mu    sync.RWMutex
+	links map[string]*models.SyncLink // key: link ID
+}
+
+// NewInMemorySyncLinkStore creates a new in-memory sync link store
+func NewInMemorySyncLinkStore() *InMemorySyncLinkStore {
+	return &InMemorySyncLinkStore{
+		links: make(map[string]*models.SyncLink),
+	}
+}
+
+// Save stores a new link
+func (s *InMemorySyncLinkStore) Save(link *models.SyncLink) error {
+	if err := validateSyncLink(link); err != nil {
+		return err
+	}
+
+	id, err := newSyncLinkID()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	link.ID = id
+	link.CreatedAt = now
+	link.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.links[id] = copySyncLink(link)
+	return nil
+}
+
+// Update replaces the synced state of an existing link
+func (s *InMemorySyncLinkStore) Update(link *models.SyncLink) error {
+	if err := validateSyncLink(link); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.links[link.ID]
+	if !ok || existing.UserID != link.UserID {
+		return fmt.Errorf("sync link not found: %s", link.ID)
+	}
+
+	link.UpdatedAt = time.Now()
+	stored := copySyncLink(existing)
+	stored.Name = link.Name
+	stored.Tracks = slices.Clone(link.Tracks)
+	stored.Conflicts = slices.Clone(link.Conflicts)
+	stored.Disconnected = link.Disconnected
+	stored.LastSyncedAt = link.LastSyncedAt
+	stored.UpdatedAt = link.UpdatedAt
+	s.links[link.ID] = stored
+	return nil
+}
+
+// Get retrieves one of the user's links by ID
+func (s *InMemorySyncLinkStore) Get(userID, id string) (*models.SyncLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.links[id]
+	if !ok || link.UserID != userID {
+		return nil, fmt.Errorf("sync link not found: %s", id)
+	}
+	return copySyncLink(link), nil
+}
+
+// List returns all of a user's links, oldest first
+func (s *InMemorySyncLinkStore) List(userID string) ([]*models.SyncLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var links []*models.SyncLink
+	for _, link := range s.links {
+		if link.UserID == userID {
+			links = append(links, copySyncLink(link))
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].ID < links[j].ID
+		}
+		return links[i].CreatedAt.Before(links[j].CreatedAt)
+	})
+
+	return links, nil
+}
+
+// Delete removes one of the user's links by ID
+func (s *InMemorySyncLinkStore) Delete(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.links[id]
+	if !ok || link.UserID != userID {
+		return fmt.Errorf("sync link not found: %s", id)
+	}
+	delete(s.links, id)
+	return nil
+}
+
+// copySyncLink returns a copy of link that shares no tracks or conflicts
+// with it
+func copySyncLink(link *models.SyncLink) *models.SyncLink {
+	c := *link
+	c.Tracks = slices.Clone(link.Tracks)
+	c.Conflicts = slices.Clone(link.Conflicts)
+	return &c
+}
+
+// validateSyncLink checks the fields that identify a link
+func validateSyncLink(link *models.SyncLink) error {
+	if link == nil {
+		return fmt.Errorf("sync link cannot be nil")
+	}
+	if link.UserID == "" {
+		return fmt.Errorf("userID cannot be empty")
+	}
+	if link.SourceProvider == "" || link.SourcePlaylistID == "" {
+		return fmt.Errorf("source provider and playlist ID cannot be empty")
+	}
+	if link.TargetProvider == "" || link.TargetPlaylistID == "" {
+		return fmt.Errorf("target provider and playlist ID cannot be empty")
+	}
+	if link.TwoWay && !models.ValidConflictPolicy(link.ConflictPolicy) {
+		return fmt.Errorf("invalid conflict policy: %q", link.ConflictPolicy)
+	}
+	return nil
+}
+
+// newSyncLinkID generates a random sync link ID
+func newSyncLinkID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate sync link ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/JanikSachs/PlayPort/internal/database/dbtest"
+	"github.com/JanikSachs/PlayPort/internal/models"
+)
+
+func TestSyncLinkStore_SaveGetAndUpdate(t *testing.T) {
+	forEachSyncLinkStore(t, func(t *testing.T, store SyncLinkStore) {
+		link := &models.SyncLink{
+			UserID:           "user123",
+			SourceProvider:   "Spotify",
+			SourcePlaylistID: "source-1",
+			TargetProvider:   "Deezer",
+			TargetAccount:    "dz-1",
+			TargetPlaylistID: "target-1",
+			Name:             "Road Trip",
+			Tracks:           []models.SyncedTrack{{SourceID: "a", TargetID: "1"}, {SourceID: "b"}},
+			LastSyncedAt:     time.Now(),
+		}
+		if err := store.Save(link); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if link.ID == "" || link.CreatedAt.IsZero() {
+			t.Error("Save() should set ID and CreatedAt")
+		}
+
+		got, err := store.Get("user123", link.ID)
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if got.TargetAccount != "dz-1" || got.Name != "Road Trip" || !slices.Equal(got.Tracks, link.Tracks) {
+			t.Errorf("Get() returned %+v, want %+v", got, link)
+		}
+		if _, err := store.Get("other", link.ID); err == nil {
+			t.Error("Get() should not return other users' links")
+		}
+
+		// Changing the returned link must not change the stored one
+		got.Tracks[0].TargetID = "changed"
+		again, _ := store.Get("user123", link.ID)
+		if again.Tracks[0].TargetID != "1" {
+			t.Error("Get() should return a copy of the stored tracks")
+		}
+
+		link.Name = "Road Trip 2"
+		link.Tracks = []models.SyncedTrack{{SourceID: "c", TargetID: "3"}}
+		link.Disconnected = "The Deezer account dz-1 was disconnected"
+		link.LastSyncedAt = time.Now().Add(time.Minute)
+		if err := store.Update(link); err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+		got, _ = store.Get("user123", link.ID)
+		if got.Name != "Road Trip 2" || !slices.Equal(got.Tracks, link.Tracks) || got.Disconnected != link.Disconnected {
+			t.Errorf("Update() stored %+v, want %+v", got, link)
+		}
+		if !got.LastSyncedAt.Equal(link.LastSyncedAt) {
+			t.Errorf("Expected LastSyncedAt %v, got %v", link.LastSyncedAt, got.LastSyncedAt)
+		}
+
+		stranger := *link
+		stranger.UserID = "other"
+		if err := store.Update(&stranger); err == nil {
+			t.Error("Update() should not change other users' links")
+		}
+	})
+}
+
+func TestSyncLinkStore_ListAndDelete(t *testing.T) {
+	forEachSyncLinkStore(t, func(t *testing.T, store SyncLinkStore) {
+		for _, id := range []string{"source-1", "source-2"} {
+			link := &models.SyncLink{UserID: "user123", SourceProvider: "Spotify", SourcePlaylistID: id, TargetProvider: "Deezer", TargetPlaylistID: "target-" + id}
+			if err := store.Save(link); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+		}
+
+		links, err := store.List("user123")
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if len(links) != 2 {
+			t.Fatalf("Expected 2 links, got %d", len(links))
+		}
+		if err := store.Delete("other", links[0].ID); err == nil {
+			t.Error("Delete() should not remove other users' links")
+		}
+		if err := store.Delete("user123", links[0].ID); err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+		if err := store.Delete("user123", links[0].ID); err == nil {
+			t.Error("Delete() should fail for unknown links")
+		}
+
+		remaining, _ := store.List("user123")
+		if len(remaining) != 1 || remaining[0].ID != links[1].ID {
+			t.Errorf("Expected only %s to remain, got %+v", links[1].ID, remaining)
+		}
+	})
+}
+
+func TestSyncLinkStore_TwoWayConflicts(t *testing.T) {
+	forEachSyncLinkStore(t, func(t *testing.T, store SyncLinkStore) {
+		link := &models.SyncLink{
+			UserID: "user123", SourceProvider: "Spotify", SourcePlaylistID: "source-1",
+			TargetProvider: "Deezer", TargetPlaylistID: "target-1",
+			TwoWay: true, ConflictPolicy: models.ConflictManual,
+		}
+		if err := store.Save(link); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		got, _ := store.Get("user123", link.ID)
+		if !got.TwoWay || got.ConflictPolicy != models.ConflictManual || got.Conflicts != nil {
+			t.Errorf("Get() returned %+v, want a two-way link without conflicts", got)
+		}
+
+		link.Conflicts = []models.SyncConflict{
+			{ID: "readded:target:t-9", Kind: models.ConflictReadded, Side: models.SyncSideTarget, TrackID: "t-9", Title: "Moonlight"},
+			{ID: "order", Kind: models.ConflictOrder},
+		}
+		if err := store.Update(link); err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+		got, _ = store.Get("user123", link.ID)
+		if !slices.Equal(got.Conflicts, link.Conflicts) {
+			t.Errorf("Expected conflicts %+v, got %+v", link.Conflicts, got.Conflicts)
+		}
+
+		link.Conflicts = nil
+		if err := store.Update(link); err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+		if got, _ = store.Get("user123", link.ID); got.Conflicts != nil {
+			t.Errorf("Expected resolved conflicts to be cleared, got %+v", got.Conflicts)
+		}
+	})
+}
+
+func TestSyncLinkStore_Save_Validation(t *testing.T) {
+	forEachSyncLinkStore(t, func(t *testing.T, store SyncLinkStore) {
+		tests := []struct {
+			name string
+			link *models.SyncLink
+		}{
+			{name: "nil link", link: nil},
+			{name: "empty user", link: &models.SyncLink{SourceProvider: "Spotify", SourcePlaylistID: "s", TargetProvider: "Deezer", TargetPlaylistID: "t"}},
+			{name: "empty source playlist", link: &models.SyncLink{UserID: "u", SourceProvider: "Spotify", TargetProvider: "Deezer", TargetPlaylistID: "t"}},
+			{name: "empty target playlist", link: &models.SyncLink{UserID: "u", SourceProvider: "Spotify", SourcePlaylistID: "s", TargetProvider: "Deezer"}},
+			{name: "unknown conflict policy", link: &models.SyncLink{UserID: "u", SourceProvider: "Spotify", SourcePlaylistID: "s", TargetProvider: "Deezer", TargetPlaylistID: "t", TwoWay: true, ConflictPolicy: "newest"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := store.Save(tt.link); err == nil {
+					t.Error("Save() should fail validation")
+				}
+			})
+		}
+	})
+}
+
+func forEachSyncLinkStore(t *testing.T, fn func(t *testing.T, store SyncLinkStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewInMemorySyncLinkStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, NewSQLSyncLinkStore(dbtest.NewSQLite(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, NewSQLSyncLinkStore(dbtest.NewPostgres(t)))
+	})
+}
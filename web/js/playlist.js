@@ -1,19 +1,38 @@
-// Handles the Transfer button in playlist-list.html.
+// Handles the Transfer buttons in playlist-list.html and transfer.html.
 // Uses event delegation so it works for HTMX-injected content.
-// The button carries data-target-provider and data-target-input attributes
-// (set in the template) to avoid relying on dynamic IDs in JS.
+// The button carries data-target-provider, data-target-input and
+// data-target-account-input attributes (set in the template) to avoid relying
+// on dynamic IDs in JS. Buttons that also pick the source carry the matching
+// data-source-* attributes.
 export function initPlaylist() {
   document.addEventListener('click', function (e) {
     const btn = e.target.closest('button[data-target-provider]');
     if (!btn) return;
 
-    const select = document.getElementById(btn.dataset.targetProvider);
-    const input = document.getElementById(btn.dataset.targetInput);
-    if (!select || !input) return;
-
-    input.value = select.value;
-    if (!select.value) {
+    const target = copySelection(btn.dataset.targetProvider, btn.dataset.targetInput, btn.dataset.targetAccountInput);
+    let source = true;
+    if (btn.dataset.sourceProvider) {
+      source = copySelection(btn.dataset.sourceProvider, btn.dataset.sourceInput, btn.dataset.sourceAccountInput);
+    }
+    if (!target || !source) {
       e.preventDefault();
     }
   });
 }
+
+// copySelection copies the provider and account of the selected option into
+// the hidden inputs and reports whether a provider was selected
+function copySelection(selectId, inputId, accountInputId) {
+  const select = document.getElementById(selectId);
+  const input = document.getElementById(inputId);
+  if (!select || !input) return true;
+
+  input.value = select.value;
+
+  const accountInput = document.getElementById(accountInputId);
+  const option = select.selectedOptions[0];
+  if (accountInput) {
+    accountInput.value = option ? option.dataset.account || '' : '';
+  }
+  return select.value !== '';
+}
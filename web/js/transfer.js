@@ -1,11 +1,14 @@
 // Handles the "Load Playlists" button in transfer.html.
-// Sets hx-get with the selected provider as a query param before HTMX fires.
+// Sets hx-get with the selected provider and account as query params before HTMX fires.
 export function initTransfer() {
   const btn = document.getElementById('load-playlists-btn');
   if (!btn) return;
 
   btn.addEventListener('click', function () {
-    const provider = document.getElementById('source-provider').value;
-    this.setAttribute('hx-get', '/api/playlists?provider=' + encodeURIComponent(provider));
+    const select = document.getElementById('source-provider');
+    const option = select.selectedOptions[0];
+    const account = option ? option.dataset.account || '' : '';
+    this.setAttribute('hx-get', '/api/playlists?provider=' + encodeURIComponent(select.value) +
+      '&account=' + encodeURIComponent(account));
   });
 }